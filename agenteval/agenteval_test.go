@@ -0,0 +1,308 @@
+package agenteval
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/testing/mocks"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// scriptedAgent 依次返回预设的输出
+type scriptedAgent struct {
+	*core.BaseAgent
+	mu      sync.Mutex
+	calls   int
+	outputs []func(input *core.AgentInput) (*core.AgentOutput, error)
+}
+
+func newScriptedAgent(outputs ...func(input *core.AgentInput) (*core.AgentOutput, error)) *scriptedAgent {
+	return &scriptedAgent{
+		BaseAgent: core.NewBaseAgent("scripted", "scripted test agent", nil),
+		outputs:   outputs,
+	}
+}
+
+func (a *scriptedAgent) Invoke(ctx context.Context, input *core.AgentInput) (*core.AgentOutput, error) {
+	a.mu.Lock()
+	next := a.outputs[a.calls%len(a.outputs)]
+	a.calls++
+	a.mu.Unlock()
+	return next(input)
+}
+
+func answer(result interface{}, calls ...core.ToolCall) func(*core.AgentInput) (*core.AgentOutput, error) {
+	return func(*core.AgentInput) (*core.AgentOutput, error) {
+		return &core.AgentOutput{
+			Result:         result,
+			Status:         "success",
+			ReasoningSteps: []core.ReasoningStep{{Step: 1, Action: "answer", Success: true}},
+			ToolCalls:      calls,
+		}, nil
+	}
+}
+
+// recordingTB 记录错误而不使外层测试失败
+type recordingTB struct {
+	testing.TB
+	errors []string
+	logs   []string
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func check(t *testing.T, criterion AnswerCriterion, ans string) (bool, string) {
+	t.Helper()
+	passed, reason, err := criterion.Check(context.Background(), &core.AgentInput{Task: "task"}, ans)
+	require.NoError(t, err)
+	return passed, reason
+}
+
+func TestAnswerCriteria(t *testing.T) {
+	passed, _ := check(t, Exact("42"), " 42\n")
+	assert.True(t, passed)
+	passed, reason := check(t, Exact("42"), "41")
+	assert.False(t, passed)
+	assert.Contains(t, reason, `expected "42"`)
+
+	passed, _ = check(t, Matches(`(?i)paris`), "The capital is Paris.")
+	assert.True(t, passed)
+	passed, _ = check(t, Matches(`^\d+$`), "forty-two")
+	assert.False(t, passed)
+
+	passed, reason = check(t, Satisfies("non_empty", func(answer string) error {
+		if answer == "" {
+			return errors.New("empty answer")
+		}
+		return nil
+	}), "")
+	assert.False(t, passed)
+	assert.Equal(t, "empty answer", reason)
+}
+
+func TestMatchesJSONSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"city", "population"},
+		"properties": map[string]interface{}{
+			"city":       map[string]interface{}{"type": "string", "minLength": 1},
+			"population": map[string]interface{}{"type": "integer", "minimum": 0},
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string", "enum": []interface{}{"capital", "port"}},
+			},
+		},
+		"additionalProperties": false,
+	}
+	criterion := MatchesJSONSchema(schema)
+
+	passed, reason := check(t, criterion, "Here you go:\n```json\n{\"city\": \"Paris\", \"population\": 2100000, \"tags\": [\"capital\"]}\n```")
+	assert.True(t, passed, reason)
+
+	passed, reason = check(t, criterion, `{"city": "Paris", "population": -1.5, "tags": ["river"], "mayor": "x"}`)
+	assert.False(t, passed)
+	assert.Contains(t, reason, "$.population: expected type integer")
+	assert.Contains(t, reason, "$.tags[0]: value river is not one of")
+	assert.Contains(t, reason, "$.mayor: additional property is not allowed")
+
+	passed, reason = check(t, criterion, `{"city": "Paris"}`)
+	assert.False(t, passed)
+	assert.Contains(t, reason, `missing required property "population"`)
+
+	passed, reason = check(t, criterion, "no json here")
+	assert.False(t, passed)
+	assert.Contains(t, reason, "not JSON")
+}
+
+func TestJudgedBy(t *testing.T) {
+	verdict := `{"pass": true, "score": 0.9, "reason": "correct"}`
+	judge := mocks.NewMockLLMClient(mocks.WithResponder(func(string) string { return verdict }))
+	passed, _ := check(t, JudgedBy(judge, "Must name the capital of France", 0.8), "Paris")
+	assert.True(t, passed)
+	prompts := judge.GetPrompts()
+	require.Len(t, prompts, 1)
+	assert.Contains(t, prompts[0], "Must name the capital of France")
+	assert.Contains(t, prompts[0], "Paris")
+
+	verdict = `{"pass": true, "score": 0.5, "reason": "vague"}`
+	passed, reason := check(t, JudgedBy(judge, "rubric", 0.8), "somewhere in Europe")
+	assert.False(t, passed)
+	assert.Contains(t, reason, "vague")
+
+	verdict = "not a verdict"
+	_, _, err := JudgedBy(judge, "rubric", 0.8).Check(context.Background(), &core.AgentInput{}, "x")
+	assert.Error(t, err)
+}
+
+func TestCheckTrajectory(t *testing.T) {
+	output := &core.AgentOutput{
+		ReasoningSteps: make([]core.ReasoningStep, 4),
+		ToolCalls: []core.ToolCall{
+			{ToolName: "search", Input: map[string]interface{}{"query": "Weather in Paris", "limit": 5}},
+			{ToolName: "shell", Input: map[string]interface{}{"cmd": "rm -rf /"}},
+		},
+	}
+
+	failures := checkTrajectory(Trajectory{
+		RequiredTools: []ToolExpectation{
+			RequireTool("search", map[string]ArgMatcher{"query": ArgContains("paris"), "limit": Eq(5)}),
+		},
+		MaxSteps: 4,
+	}, output)
+	assert.Empty(t, failures)
+
+	failures = checkTrajectory(Trajectory{
+		RequiredTools: []ToolExpectation{
+			RequireTool("search", map[string]ArgMatcher{"query": ArgMatches(`^London`)}),
+			RequireTool("calculator", nil),
+		},
+		ForbiddenTools: []string{"shell"},
+		MaxSteps:       2,
+		MaxToolCalls:   1,
+	}, output)
+	assert.Equal(t, []string{
+		"missing tool call search(query ~ /^London/)",
+		"missing tool call calculator",
+		`forbidden tool "shell" was called`,
+		"took 4 steps, limit is 2",
+		"made 2 tool calls, limit is 1",
+	}, failures)
+}
+
+func TestRunner_PassRateAndVariance(t *testing.T) {
+	// 第一个用例每三次运行失败一次
+	agent := newScriptedAgent(
+		answer("42", core.ToolCall{ToolName: "calculator", Input: map[string]interface{}{"expr": "6*7"}}),
+		answer("42", core.ToolCall{ToolName: "calculator", Input: map[string]interface{}{"expr": "6*7"}}),
+		answer("41"),
+	)
+	suite := NewSuite("math", &Case{
+		Name:   "multiply",
+		Input:  &core.AgentInput{Task: "What is 6*7?"},
+		Answer: []AnswerCriterion{Exact("42")},
+		Trajectory: Trajectory{
+			RequiredTools: []ToolExpectation{RequireTool("calculator", map[string]ArgMatcher{"expr": AnyArg()})},
+		},
+	})
+
+	report, err := NewRunner(Config{Runs: 3}).Run(context.Background(), agent, suite)
+	require.NoError(t, err)
+
+	assert.Equal(t, "math", report.Suite)
+	assert.Equal(t, "scripted", report.Agent)
+	assert.Equal(t, 3, report.TotalRuns)
+	assert.Equal(t, 2, report.Passed)
+	assert.InDelta(t, 2.0/3, report.PassRate, 1e-9)
+
+	result := report.Case("multiply")
+	require.NotNil(t, result)
+	require.Len(t, result.Runs, 3)
+	assert.True(t, result.Flaky)
+	assert.InDelta(t, 2.0/3, result.MeanScore, 1e-9)
+	assert.InDelta(t, 2.0/9, result.ScoreVariance, 1e-9)
+
+	failed := result.Runs[2]
+	assert.Equal(t, 3, failed.Run)
+	assert.False(t, failed.Passed)
+	assert.Equal(t, 0.0, failed.Score)
+	assert.Len(t, failed.Failures, 2)
+}
+
+func TestRunner_ConcurrentRunsAndErrors(t *testing.T) {
+	agent := newScriptedAgent(func(input *core.AgentInput) (*core.AgentOutput, error) {
+		input.Context["mutated"] = true
+		if input.Task == "fail" {
+			return nil, errors.New("model unavailable")
+		}
+		return answer(map[string]interface{}{"ok": true})(input)
+	})
+	input := &core.AgentInput{Task: "ok", Context: map[string]interface{}{}}
+	suite := NewSuite("errors",
+		&Case{Name: "ok", Input: input, Answer: []AnswerCriterion{Exact(`{"ok":true}`)}, Runs: 5},
+		&Case{Name: "fail", Input: &core.AgentInput{Task: "fail", Context: map[string]interface{}{}}},
+	)
+
+	report, err := NewRunner(Config{Runs: 2, Concurrency: 4}).Run(context.Background(), agent, suite)
+	require.NoError(t, err)
+
+	assert.Empty(t, input.Context, "runs must not mutate the case input")
+	assert.Equal(t, 1.0, report.Case("ok").PassRate)
+	assert.Len(t, report.Case("ok").Runs, 5)
+	failed := report.Case("fail")
+	assert.Len(t, failed.Runs, 2)
+	assert.Equal(t, 0.0, failed.PassRate)
+	assert.False(t, failed.Flaky)
+	assert.Equal(t, "model unavailable", failed.Runs[0].Error)
+
+	_, err = NewRunner(Config{}).Run(context.Background(), agent, NewSuite("dup", &Case{Name: "a"}, &Case{Name: "a"}))
+	assert.Error(t, err)
+}
+
+func TestReport_JSONAndJUnit(t *testing.T) {
+	agent := newScriptedAgent(answer("yes"), answer("no"))
+	suite := NewSuite("smoke", &Case{Name: "affirm", Input: &core.AgentInput{Task: "Say yes"}, Answer: []AnswerCriterion{Exact("yes")}})
+	report, err := NewRunner(Config{Runs: 2}).Run(context.Background(), agent, suite)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.PassRate, decoded.PassRate)
+	assert.Len(t, decoded.Cases[0].Runs, 2)
+
+	buf.Reset()
+	require.NoError(t, report.WriteJUnit(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	var doc junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, 2, doc.Tests)
+	assert.Equal(t, 1, doc.Failures)
+	require.Len(t, doc.Suites, 1)
+	cases := doc.Suites[0].Cases
+	require.Len(t, cases, 2)
+	assert.Equal(t, "affirm#1", cases[0].Name)
+	assert.Nil(t, cases[0].Failure)
+	require.NotNil(t, cases[1].Failure)
+	assert.Contains(t, cases[1].Failure.Body, `expected "yes"`)
+
+	dir := t.TempDir()
+	require.NoError(t, report.SaveJSON(filepath.Join(dir, "report.json")))
+	require.NoError(t, report.SaveJUnit(filepath.Join(dir, "junit.xml")))
+}
+
+func TestRequirePassRate(t *testing.T) {
+	agent := newScriptedAgent(answer("yes"), answer("no"))
+	suite := NewSuite("smoke", &Case{Name: "affirm", Input: &core.AgentInput{Task: "Say yes"}, Answer: []AnswerCriterion{Exact("yes")}})
+
+	tb := &recordingTB{}
+	report := RunT(tb, agent, suite, Config{Runs: 4}, 0.5)
+	require.NotNil(t, report)
+	assert.Empty(t, tb.errors)
+
+	assert.False(t, RequirePassRate(tb, report, 0.9))
+	require.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "pass rate 0.50 (2/4) is below threshold 0.90")
+	assert.Len(t, tb.logs, 2)
+	assert.Contains(t, tb.logs[0], "affirm#2")
+}
@@ -0,0 +1,61 @@
+// Package agenteval 提供 Agent 行为评估与回归测试工具
+//
+// 测试用例声明输入、最终答案的判定条件(精确匹配、正则、JSON Schema 或 LLM 评审)
+// 以及轨迹期望(必须/禁止调用的工具、最大步数、参数匹配)。
+// Runner 对任意 core.Agent 多次运行每个用例,统计通过率与得分方差,
+// 并生成 JSON 与 JUnit XML 报告;RequirePassRate 可在 go test 中对通过率设置阈值
+package agenteval
+
+import (
+	"github.com/kart-io/goagent/core"
+)
+
+// Case 评估用例
+type Case struct {
+	// Name 用例名称(必需,在套件内唯一)
+	Name string
+
+	// Input Agent 输入,每次运行都会使用其副本
+	Input *core.AgentInput
+
+	// Answer 最终答案需要满足的全部条件
+	Answer []AnswerCriterion
+
+	// Trajectory 执行轨迹期望
+	Trajectory Trajectory
+
+	// Runs 运行次数,覆盖 Runner 的默认值
+	Runs int
+}
+
+// Suite 评估套件
+type Suite struct {
+	Name  string
+	Cases []*Case
+}
+
+// NewSuite 创建评估套件
+func NewSuite(name string, cases ...*Case) *Suite {
+	return &Suite{Name: name, Cases: cases}
+}
+
+// Add 添加用例
+func (s *Suite) Add(cases ...*Case) *Suite {
+	s.Cases = append(s.Cases, cases...)
+	return s
+}
+
+// cloneInput 复制输入,避免 Agent 修改上下文影响后续运行
+func cloneInput(input *core.AgentInput) *core.AgentInput {
+	if input == nil {
+		return &core.AgentInput{}
+	}
+	cloned := *input
+	if input.Context != nil {
+		cloned.Context = make(map[string]interface{}, len(input.Context))
+		for k, v := range input.Context {
+			cloned.Context[k] = v
+		}
+	}
+	return &cloned
+}
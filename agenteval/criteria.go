@@ -0,0 +1,203 @@
+package agenteval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/parsers"
+	"github.com/kart-io/goagent/utils/json"
+	"github.com/kart-io/goagent/utils/jsonschema"
+)
+
+// AnswerCriterion 最终答案判定条件
+type AnswerCriterion interface {
+	// Name 条件名称,用于报告
+	Name() string
+
+	// Check 检查答案,未通过时返回原因;err 表示检查本身失败(例如评审模型不可用)
+	Check(ctx context.Context, input *core.AgentInput, answer string) (passed bool, reason string, err error)
+}
+
+// exactCriterion 精确匹配
+type exactCriterion struct {
+	expected string
+}
+
+// Exact 答案去除首尾空白后与 expected 完全相同
+func Exact(expected string) AnswerCriterion {
+	return &exactCriterion{expected: expected}
+}
+
+func (c *exactCriterion) Name() string { return "exact" }
+
+func (c *exactCriterion) Check(ctx context.Context, input *core.AgentInput, answer string) (bool, string, error) {
+	if strings.TrimSpace(answer) == strings.TrimSpace(c.expected) {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("expected %q, got %q", c.expected, truncate(answer, 200)), nil
+}
+
+// regexCriterion 正则匹配
+type regexCriterion struct {
+	pattern *regexp.Regexp
+}
+
+// Matches 答案匹配正则表达式,表达式无效时 panic(与 regexp.MustCompile 一致)
+func Matches(pattern string) AnswerCriterion {
+	return &regexCriterion{pattern: regexp.MustCompile(pattern)}
+}
+
+func (c *regexCriterion) Name() string { return "regex" }
+
+func (c *regexCriterion) Check(ctx context.Context, input *core.AgentInput, answer string) (bool, string, error) {
+	if c.pattern.MatchString(answer) {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("answer does not match /%s/: %q", c.pattern, truncate(answer, 200)), nil
+}
+
+// schemaCriterion JSON Schema 校验
+type schemaCriterion struct {
+	schema map[string]interface{}
+}
+
+// MatchesJSONSchema 答案是满足 schema 的 JSON
+//
+// 支持 type、properties、required、additionalProperties、items、enum、const、
+// minimum、maximum、minLength、maxLength、pattern、minItems、maxItems;
+// 答案中可以包含 Markdown 代码块或说明文字
+func MatchesJSONSchema(schema map[string]interface{}) AnswerCriterion {
+	return &schemaCriterion{schema: schema}
+}
+
+func (c *schemaCriterion) Name() string { return "json_schema" }
+
+func (c *schemaCriterion) Check(ctx context.Context, input *core.AgentInput, answer string) (bool, string, error) {
+	value, err := parsers.NewJSONOutputParser[interface{}](false).Parse(ctx, answer)
+	if err != nil {
+		return false, fmt.Sprintf("answer is not JSON: %q", truncate(answer, 200)), nil
+	}
+	if violations := jsonschema.Validate(c.schema, value); len(violations) > 0 {
+		return false, strings.Join(violations, "; "), nil
+	}
+	return true, "", nil
+}
+
+// DefaultJudgePrompt 默认的 LLM 评审提示词,占位符依次为任务、答案和评判标准
+const DefaultJudgePrompt = `You are grading an AI agent's final answer.
+
+Task:
+%s
+
+Answer:
+%s
+
+Grading rubric:
+%s
+
+Respond with JSON only: {"pass": true or false, "score": number between 0 and 1, "reason": "short explanation"}`
+
+// judgeVerdict 评审模型的输出
+type judgeVerdict struct {
+	Pass   bool    `json:"pass"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// judgeCriterion LLM 评审
+type judgeCriterion struct {
+	client    llm.Client
+	rubric    string
+	threshold float64
+}
+
+// JudgedBy 由 LLM 按照评判标准评审答案,得分不低于 threshold 且判定通过时算作通过
+func JudgedBy(client llm.Client, rubric string, threshold float64) AnswerCriterion {
+	return &judgeCriterion{client: client, rubric: rubric, threshold: threshold}
+}
+
+func (c *judgeCriterion) Name() string { return "llm_judge" }
+
+func (c *judgeCriterion) Check(ctx context.Context, input *core.AgentInput, answer string) (bool, string, error) {
+	task := input.Task
+	if input.Instruction != "" {
+		task = strings.TrimSpace(task + "\n" + input.Instruction)
+	}
+
+	resp, err := c.client.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{llm.UserMessage(fmt.Sprintf(DefaultJudgePrompt, task, answer, c.rubric))},
+	})
+	if err != nil {
+		return false, "", agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "judge request failed").
+			WithComponent("agent_eval").
+			WithOperation("judge")
+	}
+
+	verdict, err := parsers.NewJSONOutputParser[judgeVerdict](false).Parse(ctx, resp.Content)
+	if err != nil {
+		return false, "", agentErrors.Wrap(err, agentErrors.CodeLLMResponse, "failed to parse judge verdict").
+			WithComponent("agent_eval").
+			WithOperation("judge").
+			WithContext("output", truncate(resp.Content, 200))
+	}
+
+	if verdict.Pass && verdict.Score >= c.threshold {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("judge score %.2f (threshold %.2f): %s", verdict.Score, c.threshold, verdict.Reason), nil
+}
+
+// funcCriterion 自定义判定
+type funcCriterion struct {
+	name  string
+	check func(answer string) error
+}
+
+// Satisfies 使用自定义函数判定答案,函数返回的错误作为失败原因
+func Satisfies(name string, check func(answer string) error) AnswerCriterion {
+	return &funcCriterion{name: name, check: check}
+}
+
+func (c *funcCriterion) Name() string { return c.name }
+
+func (c *funcCriterion) Check(ctx context.Context, input *core.AgentInput, answer string) (bool, string, error) {
+	if err := c.check(answer); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// answerText 将 Agent 输出的结果转换为文本,非字符串结果序列化为 JSON
+func answerText(output *core.AgentOutput) string {
+	if output == nil {
+		return ""
+	}
+	switch result := output.Result.(type) {
+	case nil:
+		return output.Message
+	case string:
+		return result
+	case fmt.Stringer:
+		return result.String()
+	default:
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Sprint(result)
+		}
+		return string(data)
+	}
+}
+
+// truncate 截断过长的文本
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "..."
+}
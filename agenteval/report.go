@@ -0,0 +1,226 @@
+package agenteval
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// RunResult 单次运行结果
+type RunResult struct {
+	Run       int           `json:"run"`
+	Passed    bool          `json:"passed"`
+	Score     float64       `json:"score"` // 通过的检查项占比
+	Answer    string        `json:"answer,omitempty"`
+	Steps     int           `json:"steps"`
+	ToolCalls []string      `json:"tool_calls,omitempty"`
+	Failures  []string      `json:"failures,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"latency"`
+}
+
+// CaseResult 单个用例在多次运行上的结果
+type CaseResult struct {
+	Name          string        `json:"name"`
+	Passed        int           `json:"passed"`
+	PassRate      float64       `json:"pass_rate"`
+	MeanScore     float64       `json:"mean_score"`
+	ScoreVariance float64       `json:"score_variance"`
+	Flaky         bool          `json:"flaky"` // 多次运行中既有通过也有失败
+	MeanLatency   time.Duration `json:"mean_latency"`
+	Runs          []*RunResult  `json:"runs"`
+}
+
+// Report 评估报告
+type Report struct {
+	Suite     string        `json:"suite"`
+	Agent     string        `json:"agent"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	TotalRuns int           `json:"total_runs"`
+	Passed    int           `json:"passed"`
+	PassRate  float64       `json:"pass_rate"`
+	Cases     []*CaseResult `json:"cases"`
+}
+
+// summarize 计算用例与整体的通过率和得分分布
+func (r *Report) summarize() {
+	r.TotalRuns, r.Passed = 0, 0
+	for _, c := range r.Cases {
+		c.Passed = 0
+		var scoreSum, latencySum float64
+		for _, run := range c.Runs {
+			if run.Passed {
+				c.Passed++
+			}
+			scoreSum += run.Score
+			latencySum += float64(run.Latency)
+		}
+
+		n := float64(len(c.Runs))
+		if n == 0 {
+			continue
+		}
+		c.PassRate = float64(c.Passed) / n
+		c.MeanScore = scoreSum / n
+		c.MeanLatency = time.Duration(latencySum / n)
+		var squares float64
+		for _, run := range c.Runs {
+			squares += (run.Score - c.MeanScore) * (run.Score - c.MeanScore)
+		}
+		c.ScoreVariance = squares / n
+		c.Flaky = c.Passed > 0 && c.Passed < len(c.Runs)
+
+		r.TotalRuns += len(c.Runs)
+		r.Passed += c.Passed
+	}
+	if r.TotalRuns > 0 {
+		r.PassRate = float64(r.Passed) / float64(r.TotalRuns)
+	}
+}
+
+// Case 按名称查找用例结果
+func (r *Report) Case(name string) *CaseResult {
+	for _, c := range r.Cases {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// WriteJSON 以 JSON 格式写出报告
+func (r *Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to marshal report").
+			WithComponent("agent_eval").
+			WithOperation("write_json")
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// SaveJSON 将报告保存为 JSON 文件
+func (r *Report) SaveJSON(path string) error {
+	return saveFile(path, "save_json", r.WriteJSON)
+}
+
+// junitTestSuites JUnit XML 根元素
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit 以 JUnit XML 格式写出报告,每次运行对应一个 testcase
+//
+// 运行出错记为 error,检查未通过记为 failure,可直接被 CI 系统解析
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      r.Suite,
+		Time:      seconds(r.Duration),
+		Timestamp: r.StartedAt.Format(time.RFC3339),
+	}
+	for _, c := range r.Cases {
+		for _, run := range c.Runs {
+			tc := junitTestCase{
+				Name:      c.Name,
+				ClassName: r.Suite + "." + r.Agent,
+				Time:      seconds(run.Latency),
+				SystemOut: run.Answer,
+			}
+			if len(c.Runs) > 1 {
+				tc.Name = fmt.Sprintf("%s#%d", c.Name, run.Run)
+			}
+			switch {
+			case run.Error != "":
+				suite.Errors++
+				tc.Error = &junitMessage{Message: run.Error, Body: run.Error}
+			case !run.Passed:
+				suite.Failures++
+				tc.Failure = &junitMessage{
+					Message: fmt.Sprintf("%d check(s) failed", len(run.Failures)),
+					Body:    strings.Join(run.Failures, "\n"),
+				}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+	}
+	suite.Tests = len(suite.Cases)
+
+	doc := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to marshal JUnit report").
+			WithComponent("agent_eval").
+			WithOperation("write_junit")
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// SaveJUnit 将报告保存为 JUnit XML 文件
+func (r *Report) SaveJUnit(path string) error {
+	return saveFile(path, "save_junit", r.WriteJUnit)
+}
+
+// saveFile 创建文件并写入
+func saveFile(path, operation string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to create report file").
+			WithComponent("agent_eval").
+			WithOperation(operation).
+			WithContext("path", path)
+	}
+	defer func() { _ = file.Close() }()
+	return write(file)
+}
+
+// seconds 以秒为单位格式化耗时
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
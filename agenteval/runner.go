@@ -0,0 +1,188 @@
+package agenteval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Config Runner 配置
+type Config struct {
+	// Runs 每个用例的默认运行次数,默认 1;大于 1 时可观察非确定性行为
+	Runs int
+
+	// Concurrency 并发运行数,默认 1
+	Concurrency int
+
+	// Timeout 单次运行超时,0 表示不限制
+	Timeout time.Duration
+}
+
+// Runner 评估运行器
+type Runner struct {
+	runs        int
+	concurrency int
+	timeout     time.Duration
+}
+
+// NewRunner 创建评估运行器
+func NewRunner(config Config) *Runner {
+	if config.Runs <= 0 {
+		config.Runs = 1
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	return &Runner{
+		runs:        config.Runs,
+		concurrency: config.Concurrency,
+		timeout:     config.Timeout,
+	}
+}
+
+// Run 对 Agent 运行评估套件
+//
+// 单次运行的错误记录在 RunResult.Error 中并算作未通过,不会中断评估;
+// 只有上下文取消或套件无效时才返回错误
+func (r *Runner) Run(ctx context.Context, agent core.Agent, suite *Suite) (*Report, error) {
+	if agent == nil || suite == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "agent and suite are required").
+			WithComponent("agent_eval").
+			WithOperation("run")
+	}
+	seen := make(map[string]bool, len(suite.Cases))
+	for _, c := range suite.Cases {
+		if c == nil || c.Name == "" || seen[c.Name] {
+			return nil, agentErrors.New(agentErrors.CodeInvalidInput, "cases must have unique, non-empty names").
+				WithComponent("agent_eval").
+				WithOperation("run").
+				WithContext("suite", suite.Name)
+		}
+		seen[c.Name] = true
+	}
+
+	report := &Report{
+		Suite:     suite.Name,
+		Agent:     agent.Name(),
+		StartedAt: time.Now(),
+		Cases:     make([]*CaseResult, len(suite.Cases)),
+	}
+
+	type job struct {
+		caseIndex int
+		run       int
+	}
+	var jobs []job
+	for i, c := range suite.Cases {
+		runs := c.Runs
+		if runs <= 0 {
+			runs = r.runs
+		}
+		report.Cases[i] = &CaseResult{Name: c.Name, Runs: make([]*RunResult, runs)}
+		for run := 0; run < runs; run++ {
+			jobs = append(jobs, job{caseIndex: i, run: run})
+		}
+	}
+
+	jobCh := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < r.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobCh {
+				result := r.runOnce(ctx, agent, suite.Cases[j.caseIndex])
+				result.Run = j.run + 1
+				report.Cases[j.caseIndex].Runs[j.run] = result
+			}
+		}()
+	}
+
+feed:
+	for _, j := range jobs {
+		select {
+		case jobCh <- j:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobCh)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeContextCanceled, "evaluation canceled").
+			WithComponent("agent_eval").
+			WithOperation("run").
+			WithContext("suite", suite.Name)
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	report.summarize()
+	return report, nil
+}
+
+// runOnce 运行一次用例并检查结果
+func (r *Runner) runOnce(ctx context.Context, agent core.Agent, c *Case) *RunResult {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	input := cloneInput(c.Input)
+	start := time.Now()
+	output, err := agent.Invoke(ctx, input)
+	result := &RunResult{Latency: time.Since(start)}
+	if output != nil {
+		result.Answer = answerText(output)
+		result.Steps = len(output.ReasoningSteps)
+		result.ToolCalls = toolNames(output.ToolCalls)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	checks := 0
+	failures := make([]string, 0)
+	for _, criterion := range c.Answer {
+		checks++
+		passed, reason, err := criterion.Check(ctx, input, result.Answer)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: check failed: %v", criterion.Name(), err))
+		} else if !passed {
+			failures = append(failures, fmt.Sprintf("%s: %s", criterion.Name(), reason))
+		}
+	}
+
+	trajectoryFailures := checkTrajectory(c.Trajectory, output)
+	checks += trajectoryChecks(c.Trajectory)
+	failures = append(failures, trajectoryFailures...)
+
+	result.Failures = failures
+	result.Passed = len(failures) == 0
+	result.Score = 1
+	if checks > 0 {
+		result.Score = float64(checks-len(failures)) / float64(checks)
+		if result.Score < 0 {
+			result.Score = 0
+		}
+	}
+	return result
+}
+
+// trajectoryChecks 返回轨迹期望包含的检查项数
+func trajectoryChecks(t Trajectory) int {
+	checks := len(t.RequiredTools) + len(t.ForbiddenTools)
+	if t.MaxSteps > 0 {
+		checks++
+	}
+	if t.MaxToolCalls > 0 {
+		checks++
+	}
+	return checks
+}
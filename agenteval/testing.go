@@ -0,0 +1,50 @@
+package agenteval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kart-io/goagent/core"
+)
+
+// RequirePassRate 在通过率低于 threshold 时使测试失败,并输出未通过运行的原因
+//
+// 返回是否达到阈值,便于调用方决定是否继续后续断言
+func RequirePassRate(t testing.TB, report *Report, threshold float64) bool {
+	t.Helper()
+	if report == nil {
+		t.Errorf("agenteval: report is nil")
+		return false
+	}
+	if report.PassRate >= threshold {
+		return true
+	}
+
+	for _, c := range report.Cases {
+		for _, run := range c.Runs {
+			switch {
+			case run.Error != "":
+				t.Logf("%s#%d: error: %s", c.Name, run.Run, run.Error)
+			case !run.Passed:
+				for _, failure := range run.Failures {
+					t.Logf("%s#%d: %s", c.Name, run.Run, failure)
+				}
+			}
+		}
+	}
+	t.Errorf("agenteval: suite %q pass rate %.2f (%d/%d) is below threshold %.2f",
+		report.Suite, report.PassRate, report.Passed, report.TotalRuns, threshold)
+	return false
+}
+
+// RunT 在测试中运行评估套件并检查通过率,返回报告供进一步断言
+func RunT(t testing.TB, agent core.Agent, suite *Suite, config Config, threshold float64) *Report {
+	t.Helper()
+	report, err := NewRunner(config).Run(context.Background(), agent, suite)
+	if err != nil {
+		t.Fatalf("agenteval: run suite %q: %v", suite.Name, err)
+		return nil
+	}
+	RequirePassRate(t, report, threshold)
+	return report
+}
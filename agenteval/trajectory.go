@@ -0,0 +1,178 @@
+package agenteval
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/utils/jsonschema"
+)
+
+// Trajectory 执行轨迹期望
+type Trajectory struct {
+	// RequiredTools 必须出现的工具调用,每项至少匹配一次调用
+	RequiredTools []ToolExpectation
+
+	// ForbiddenTools 禁止调用的工具
+	ForbiddenTools []string
+
+	// MaxSteps 推理步数上限(AgentOutput.ReasoningSteps),0 表示不限制
+	MaxSteps int
+
+	// MaxToolCalls 工具调用次数上限,0 表示不限制
+	MaxToolCalls int
+}
+
+// ToolExpectation 期望的工具调用
+type ToolExpectation struct {
+	// Tool 工具名称
+	Tool string
+
+	// Args 参数匹配器,只检查列出的参数
+	Args map[string]ArgMatcher
+}
+
+// RequireTool 创建工具调用期望
+func RequireTool(tool string, args map[string]ArgMatcher) ToolExpectation {
+	return ToolExpectation{Tool: tool, Args: args}
+}
+
+// ArgMatcher 工具参数匹配器
+type ArgMatcher interface {
+	Match(value interface{}) bool
+	String() string
+}
+
+type argMatcher struct {
+	description string
+	match       func(value interface{}) bool
+}
+
+func (m *argMatcher) Match(value interface{}) bool { return m.match(value) }
+
+func (m *argMatcher) String() string { return m.description }
+
+// Eq 参数等于 expected,数值统一按 float64 比较
+func Eq(expected interface{}) ArgMatcher {
+	return &argMatcher{
+		description: fmt.Sprintf("= %v", expected),
+		match: func(value interface{}) bool {
+			return jsonschema.Equal(expected, value)
+		},
+	}
+}
+
+// ArgMatches 参数的文本形式匹配正则表达式
+func ArgMatches(pattern string) ArgMatcher {
+	re := regexp.MustCompile(pattern)
+	return &argMatcher{
+		description: fmt.Sprintf("~ /%s/", pattern),
+		match: func(value interface{}) bool {
+			return value != nil && re.MatchString(fmt.Sprint(value))
+		},
+	}
+}
+
+// ArgContains 参数的文本形式包含 substr(不区分大小写)
+func ArgContains(substr string) ArgMatcher {
+	return &argMatcher{
+		description: fmt.Sprintf("contains %q", substr),
+		match: func(value interface{}) bool {
+			return value != nil && strings.Contains(strings.ToLower(fmt.Sprint(value)), strings.ToLower(substr))
+		},
+	}
+}
+
+// AnyArg 参数存在即可
+func AnyArg() ArgMatcher {
+	return &argMatcher{
+		description: "present",
+		match:       func(value interface{}) bool { return value != nil },
+	}
+}
+
+// ArgFunc 使用自定义函数匹配参数
+func ArgFunc(description string, match func(value interface{}) bool) ArgMatcher {
+	return &argMatcher{description: description, match: match}
+}
+
+// checkTrajectory 检查执行轨迹,返回所有违反项
+func checkTrajectory(expect Trajectory, output *core.AgentOutput) []string {
+	if output == nil {
+		output = &core.AgentOutput{}
+	}
+	var failures []string
+
+	for _, required := range expect.RequiredTools {
+		if !hasMatchingCall(required, output.ToolCalls) {
+			failures = append(failures, "missing tool call "+required.String())
+		}
+	}
+
+	for _, forbidden := range expect.ForbiddenTools {
+		for _, call := range output.ToolCalls {
+			if call.ToolName == forbidden {
+				failures = append(failures, fmt.Sprintf("forbidden tool %q was called", forbidden))
+				break
+			}
+		}
+	}
+
+	if expect.MaxSteps > 0 && len(output.ReasoningSteps) > expect.MaxSteps {
+		failures = append(failures, fmt.Sprintf("took %d steps, limit is %d", len(output.ReasoningSteps), expect.MaxSteps))
+	}
+	if expect.MaxToolCalls > 0 && len(output.ToolCalls) > expect.MaxToolCalls {
+		failures = append(failures, fmt.Sprintf("made %d tool calls, limit is %d", len(output.ToolCalls), expect.MaxToolCalls))
+	}
+
+	return failures
+}
+
+// hasMatchingCall 判断是否有满足期望的工具调用
+func hasMatchingCall(expect ToolExpectation, calls []core.ToolCall) bool {
+	for _, call := range calls {
+		if call.ToolName != expect.Tool {
+			continue
+		}
+		matched := true
+		for name, matcher := range expect.Args {
+			if !matcher.Match(call.Input[name]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回期望的描述
+func (e ToolExpectation) String() string {
+	if len(e.Args) == 0 {
+		return e.Tool
+	}
+	names := make([]string, 0, len(e.Args))
+	for name := range e.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %s", name, e.Args[name])
+	}
+	return fmt.Sprintf("%s(%s)", e.Tool, strings.Join(parts, ", "))
+}
+
+// toolNames 返回调用的工具名称序列
+func toolNames(calls []core.ToolCall) []string {
+	names := make([]string, len(calls))
+	for i, call := range calls {
+		names[i] = call.ToolName
+	}
+	return names
+}
@@ -10,6 +10,7 @@ import (
 	agentcore "github.com/kart-io/goagent/core"
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/observability"
 )
 
 // CoTAgent implements Chain-of-Thought reasoning pattern.
@@ -90,6 +91,14 @@ func NewCoTAgent(config CoTConfig) *CoTAgent {
 
 // Invoke executes the Chain-of-Thought reasoning
 func (c *CoTAgent) Invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
+	ctx, span := observability.StartInvokeAgentSpan(ctx, c.Name(), c.Description())
+	output, err := c.invoke(ctx, input)
+	observability.EndSpan(span, err)
+	return output, err
+}
+
+// invoke performs the Chain-of-Thought reasoning without tracing the run
+func (c *CoTAgent) invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
 	startTime := time.Now()
 
 	// Trigger start callback
@@ -121,7 +130,7 @@ func (c *CoTAgent) Invoke(ctx context.Context, input *agentcore.AgentInput) (*ag
 		llm.UserMessage(prompt),
 	}
 
-	llmResp, err := c.llm.Chat(ctx, messages)
+	llmResp, err := c.chatStep(ctx, "reason", messages)
 	if err != nil {
 		return c.handleError(ctx, output, "LLM call failed", err, startTime)
 	}
@@ -150,14 +159,16 @@ func (c *CoTAgent) Invoke(ctx context.Context, input *agentcore.AgentInput) (*ag
 
 	// If tools are available and needed, execute them
 	if len(c.tools) > 0 {
-		toolResults := c.executeToolsIfNeeded(ctx, steps, output)
+		toolCtx, span := observability.StartAgentStepSpan(ctx, c.Name(), "tools")
+		toolResults := c.executeToolsIfNeeded(toolCtx, steps, output)
+		observability.EndSpan(span, nil)
 		if len(toolResults) > 0 {
 			// Re-run reasoning with tool results
 			toolContext := c.formatToolResults(toolResults)
 			messages = append(messages, llm.AssistantMessage(response))
 			messages = append(messages, llm.UserMessage(toolContext))
 
-			llmResp2, err := c.llm.Chat(ctx, messages)
+			llmResp2, err := c.chatStep(ctx, "reason with tools", messages)
 			if err == nil {
 				// Collect token usage from second LLM call
 				if llmResp2.Usage != nil {
@@ -219,6 +230,14 @@ func (c *CoTAgent) Stream(ctx context.Context, input *agentcore.AgentInput) (<-c
 	return outChan, nil
 }
 
+// chatStep calls the LLM inside an agent_step span
+func (c *CoTAgent) chatStep(ctx context.Context, step string, messages []llm.Message) (*llm.CompletionResponse, error) {
+	ctx, span := observability.StartAgentStepSpan(ctx, c.Name(), step)
+	resp, err := c.llm.Chat(ctx, messages)
+	observability.EndSpan(span, err)
+	return resp, err
+}
+
 // buildCoTPrompt builds the Chain-of-Thought prompt
 func (c *CoTAgent) buildCoTPrompt(input *agentcore.AgentInput) string {
 	var prompt strings.Builder
@@ -12,6 +12,7 @@ import (
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/observability"
 )
 
 // GoTAgent implements Graph-of-Thought reasoning pattern.
@@ -97,6 +98,16 @@ func NewGoTAgent(config GoTConfig) *GoTAgent {
 
 // Invoke executes the Graph-of-Thought reasoning
 func (g *GoTAgent) Invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
+	// Node goroutines start their spans from this context so they stay
+	// children of the invoke_agent span.
+	ctx, span := observability.StartInvokeAgentSpan(ctx, g.Name(), g.Description())
+	output, err := g.invoke(ctx, input)
+	observability.EndSpan(span, err)
+	return output, err
+}
+
+// invoke performs the Graph-of-Thought reasoning without tracing
+func (g *GoTAgent) invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
 	startTime := time.Now()
 
 	// Trigger start callback
@@ -305,7 +316,10 @@ func (g *GoTAgent) executeGraphSequential(ctx context.Context, graph []*GraphNod
 }
 
 // processNode executes a single node
-func (g *GoTAgent) processNode(ctx context.Context, node *GraphNode, input *agentcore.AgentInput, output *agentcore.AgentOutput) error {
+func (g *GoTAgent) processNode(ctx context.Context, node *GraphNode, input *agentcore.AgentInput, output *agentcore.AgentOutput) (err error) {
+	ctx, span := observability.StartAgentStepSpan(ctx, g.Name(), "node "+node.ID)
+	defer func() { observability.EndSpan(span, err) }()
+
 	node.mu.Lock()
 	node.Status = "processing"
 	node.mu.Unlock()
@@ -1,11 +1,9 @@
 package pot
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"github.com/kart-io/goagent/utils/json"
-	"os/exec"
 	"regexp"
 	"strings"
 	"sync"
@@ -15,6 +13,7 @@ import (
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/sandbox"
 )
 
 // Pre-compiled regular expressions for code extraction
@@ -59,10 +58,11 @@ type PoTConfig struct {
 	AllowImports     []string      // Allowed imports/libraries
 
 	// Execution settings
-	PythonPath    string // Path to Python interpreter
-	NodePath      string // Path to Node.js
-	DockerImage   string // Docker image for sandboxed execution
-	MaxIterations int    // Max refinement iterations
+	PythonPath    string          // Path to Python interpreter
+	NodePath      string          // Path to Node.js
+	DockerImage   string          // Docker image for sandboxed execution
+	Sandbox       sandbox.Sandbox // Sandbox running the code (default: container if DockerImage is set, else local)
+	MaxIterations int             // Max refinement iterations
 }
 
 // CodeResult represents the result of code execution
@@ -97,6 +97,14 @@ func NewPoTAgent(config PoTConfig) *PoTAgent {
 	if config.NodePath == "" {
 		config.NodePath = "node"
 	}
+	if config.Sandbox == nil {
+		limits := sandbox.WithLimits(sandbox.Limits{WallTime: config.ExecutionTimeout})
+		if config.DockerImage != "" {
+			config.Sandbox = sandbox.NewContainer(config.DockerImage, limits)
+		} else {
+			config.Sandbox = sandbox.NewLocal(limits)
+		}
+	}
 
 	// Build tools map
 	toolsByName := make(map[string]interfaces.Tool)
@@ -452,91 +460,58 @@ func (p *PoTAgent) executeCode(ctx context.Context, code string, language string
 
 // executePython executes Python code
 func (p *PoTAgent) executePython(ctx context.Context, code string) (*CodeResult, error) {
-	cmd := exec.CommandContext(ctx, p.config.PythonPath, "-c", code)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	startTime := time.Now()
-	err := cmd.Run()
-	duration := time.Since(startTime)
-
-	result := &CodeResult{
-		Output:   stdout.String(),
-		Error:    stderr.String(),
-		Duration: duration,
-	}
-
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitErr.ExitCode()
-		}
-		return result, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "python execution failed").
-			WithComponent("pot_agent").
-			WithOperation("executePython").
-			WithContext("stderr", stderr.String())
-	}
-
-	return result, nil
+	return p.runSandboxed(ctx, "python", &sandbox.Request{
+		Command: p.config.PythonPath,
+		Args:    []string{"main.py"},
+		Files:   map[string]string{"main.py": code},
+	})
 }
 
 // executeJavaScript executes JavaScript code
 func (p *PoTAgent) executeJavaScript(ctx context.Context, code string) (*CodeResult, error) {
-	cmd := exec.CommandContext(ctx, p.config.NodePath, "-e", code)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	startTime := time.Now()
-	err := cmd.Run()
-	duration := time.Since(startTime)
-
-	result := &CodeResult{
-		Output:   stdout.String(),
-		Error:    stderr.String(),
-		Duration: duration,
-	}
-
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitErr.ExitCode()
-		}
-		return result, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "javascript execution failed").
-			WithComponent("pot_agent").
-			WithOperation("executeJavaScript").
-			WithContext("stderr", stderr.String())
-	}
-
-	return result, nil
+	return p.runSandboxed(ctx, "javascript", &sandbox.Request{
+		Command: p.config.NodePath,
+		Args:    []string{"main.js"},
+		Files:   map[string]string{"main.js": code},
+	})
 }
 
 // executeGo executes Go code
 func (p *PoTAgent) executeGo(ctx context.Context, code string) (*CodeResult, error) {
-	// Go requires compilation, so we use go run with a temp file
-	// This is simplified - in production, use proper temp file handling
-	cmd := exec.CommandContext(ctx, "go", "run", "-", code)
+	return p.runSandboxed(ctx, "go", &sandbox.Request{
+		Command: "go",
+		Args:    []string{"run", "main.go"},
+		Files:   map[string]string{"main.go": code},
+	})
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	cmd.Stdin = strings.NewReader(code)
+// runSandboxed runs the program in the configured sandbox. The result is
+// never nil, so that failures can be fed back to the LLM for debugging.
+func (p *PoTAgent) runSandboxed(ctx context.Context, language string, req *sandbox.Request) (*CodeResult, error) {
+	req.Limits = &sandbox.Limits{WallTime: p.config.ExecutionTimeout}
 
 	startTime := time.Now()
-	err := cmd.Run()
-	duration := time.Since(startTime)
-
-	result := &CodeResult{
-		Output:   stdout.String(),
-		Error:    stderr.String(),
-		Duration: duration,
+	res, err := p.config.Sandbox.Run(ctx, req)
+	result := &CodeResult{Duration: time.Since(startTime), ExitCode: -1}
+	if res != nil {
+		result.Output = res.Stdout
+		result.Error = res.Stderr
+		result.ExitCode = res.ExitCode
 	}
 
 	if err != nil {
-		return result, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "go execution failed").
+		return result, agentErrors.Wrap(err, agentErrors.CodeToolExecution, language+" execution failed").
+			WithComponent("pot_agent").
+			WithOperation("execute").
+			WithContext("sandbox", p.config.Sandbox.Name()).
+			WithContext("stderr", result.Error)
+	}
+	if result.ExitCode != 0 {
+		return result, agentErrors.New(agentErrors.CodeToolExecution, language+" execution failed").
 			WithComponent("pot_agent").
-			WithOperation("executeGo").
+			WithOperation("execute").
+			WithContext("sandbox", p.config.Sandbox.Name()).
+			WithContext("exit_code", result.ExitCode).
 			WithContext("stderr", result.Error)
 	}
 
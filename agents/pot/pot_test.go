@@ -2,6 +2,7 @@ package pot
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
 	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/sandbox"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -616,3 +618,68 @@ func (tc *testCallback) OnToolEnd(ctx context.Context, toolName string, output i
 func (tc *testCallback) OnToolError(ctx context.Context, toolName string, err error) error {
 	return nil
 }
+
+// fakeSandbox records requests and returns a fixed result
+type fakeSandbox struct {
+	requests []*sandbox.Request
+	result   *sandbox.Result
+	err      error
+}
+
+func (f *fakeSandbox) Name() string { return "fake" }
+
+func (f *fakeSandbox) Close() error { return nil }
+
+func (f *fakeSandbox) Run(ctx context.Context, req *sandbox.Request) (*sandbox.Result, error) {
+	f.requests = append(f.requests, req)
+	return f.result, f.err
+}
+
+func TestPoTAgent_SandboxSelection(t *testing.T) {
+	agent := NewPoTAgent(PoTConfig{LLM: new(MockLLMClient)})
+	assert.IsType(t, &sandbox.Local{}, agent.config.Sandbox)
+
+	agent = NewPoTAgent(PoTConfig{LLM: new(MockLLMClient), DockerImage: "python:3.12-slim"})
+	assert.IsType(t, &sandbox.Container{}, agent.config.Sandbox)
+
+	fake := &fakeSandbox{}
+	agent = NewPoTAgent(PoTConfig{LLM: new(MockLLMClient), DockerImage: "python:3.12-slim", Sandbox: fake})
+	assert.Same(t, fake, agent.config.Sandbox)
+}
+
+func TestPoTAgent_ExecuteCodeInSandbox(t *testing.T) {
+	fake := &fakeSandbox{result: &sandbox.Result{Stdout: "42\n"}}
+	agent := NewPoTAgent(PoTConfig{
+		LLM:              new(MockLLMClient),
+		PythonPath:       "/usr/bin/python3",
+		ExecutionTimeout: 3 * time.Second,
+		Sandbox:          fake,
+	})
+
+	result, err := agent.executeCode(context.Background(), "print(42)", "python")
+	assert.NoError(t, err)
+	assert.Equal(t, "42\n", result.Output)
+
+	req := fake.requests[0]
+	assert.Equal(t, "/usr/bin/python3", req.Command)
+	assert.Equal(t, []string{"main.py"}, req.Args)
+	assert.Equal(t, "print(42)", req.Files["main.py"])
+	assert.Equal(t, 3*time.Second, req.Limits.WallTime)
+
+	_, err = agent.executeCode(context.Background(), "package main\nfunc main() {}", "go")
+	assert.NoError(t, err)
+	assert.Equal(t, "go", fake.requests[1].Command)
+	assert.Equal(t, []string{"run", "main.go"}, fake.requests[1].Args)
+
+	fake.result = &sandbox.Result{Stderr: "Traceback", ExitCode: 1}
+	result, err = agent.executeCode(context.Background(), "raise Exception()", "python")
+	assert.Error(t, err)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Equal(t, "Traceback", result.Error)
+
+	fake.result, fake.err = nil, errors.New("runtime missing")
+	result, err = agent.executeCode(context.Background(), "console.log(1)", "javascript")
+	assert.Error(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, -1, result.ExitCode)
+}
@@ -10,8 +10,10 @@ import (
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/observability"
 	"github.com/kart-io/goagent/parsers"
 	"github.com/kart-io/goagent/performance"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ReActAgent ReAct (Reasoning + Acting) Agent
@@ -95,8 +97,16 @@ func NewReActAgent(config ReActConfig) *ReActAgent {
 	return agent
 }
 
-// Invoke 执行 ReAct Agent（含完整回调）
+// Invoke 执行 ReAct Agent（含完整回调与追踪）
 func (r *ReActAgent) Invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
+	ctx, span := observability.StartInvokeAgentSpan(ctx, r.Name(), r.Description())
+	output, err := r.invoke(ctx, input)
+	observability.EndSpan(span, err)
+	return output, err
+}
+
+// invoke 执行 ReAct Agent 并触发回调
+func (r *ReActAgent) invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
 	startTime := time.Now()
 
 	// 触发开始回调
@@ -137,10 +147,20 @@ func (r *ReActAgent) InvokeFast(ctx context.Context, input *agentcore.AgentInput
 
 // executeCore 核心执行逻辑
 //
-// withCallbacks 参数控制是否触发回调：
+// withCallbacks 参数控制是否触发回调并为每一步创建 agent_step span：
 //   - true: 触发 LLM 和 Tool 回调（用于 Invoke）
-//   - false: 跳过所有回调（用于 InvokeFast）
-func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInput, startTime time.Time, withCallbacks bool) (*agentcore.AgentOutput, error) {
+//   - false: 跳过所有回调与追踪（用于 InvokeFast）
+func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInput, startTime time.Time, withCallbacks bool) (_ *agentcore.AgentOutput, err error) {
+	// 当前步骤的 span，在下一步开始、循环结束或返回时结束
+	var stepSpan trace.Span
+	endStep := func(err error) {
+		if stepSpan != nil {
+			observability.EndSpan(stepSpan, err)
+			stepSpan = nil
+		}
+	}
+	defer func() { endStep(err) }()
+
 	// 构建初始 prompt
 	prompt := r.buildPrompt(input)
 
@@ -161,6 +181,12 @@ func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInpu
 	scratchpad := ""
 
 	for step := 0; step < r.maxSteps; step++ {
+		endStep(nil)
+		stepCtx := ctx
+		if withCallbacks {
+			stepCtx, stepSpan = observability.StartAgentStepSpan(ctx, r.Name(), fmt.Sprintf("step %d", step+1))
+		}
+
 		// 构建当前输入
 		currentPrompt := prompt + scratchpad
 
@@ -179,7 +205,7 @@ func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInpu
 			llm.UserMessage(currentPrompt),
 		}
 
-		llmResp, err := r.llm.Chat(ctx, messages)
+		llmResp, err := r.llm.Chat(stepCtx, messages)
 		if err != nil {
 			if withCallbacks {
 				_ = r.triggerOnLLMError(ctx, err)
@@ -196,7 +222,10 @@ func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInpu
 
 		// 可选：触发 LLM 结束回调
 		if withCallbacks {
-			if err := r.triggerOnLLMEnd(ctx, llmOutput, llmResp.TokensUsed); err != nil {
+			if err := agentcore.TriggerLLMUsage(ctx, r.GetConfig().Callbacks, llmResp.Model, llmResp.Usage); err != nil {
+				return nil, err
+			}
+			if err := r.triggerOnLLMEnd(ctx, llmOutput, tokensUsed(llmResp)); err != nil {
 				return nil, err
 			}
 		}
@@ -248,9 +277,9 @@ func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInpu
 		var toolErr error
 
 		if withCallbacks {
-			observation, toolErr = r.executeTool(ctx, action, actionInput)
+			observation, toolErr = r.executeTool(ctx, stepCtx, action, actionInput)
 		} else {
-			observation, toolErr = r.executeToolFast(ctx, action, actionInput)
+			observation, toolErr = r.executeToolFast(stepCtx, action, actionInput)
 		}
 
 		// 记录工具调用
@@ -290,6 +319,7 @@ func (r *ReActAgent) executeCore(ctx context.Context, input *agentcore.AgentInpu
 			break
 		}
 	}
+	endStep(nil)
 
 	// 构建最终输出
 	if finalAnswer != "" {
@@ -344,7 +374,9 @@ func (r *ReActAgent) WithConfig(config agentcore.RunnableConfig) agentcore.Runna
 }
 
 // executeTool 执行工具（含回调）
-func (r *ReActAgent) executeTool(ctx context.Context, toolName string, input map[string]interface{}) (interface{}, error) {
+//
+// 回调使用 Agent 的 ctx，工具在 stepCtx 中执行，以便其 span 挂在当前步骤下
+func (r *ReActAgent) executeTool(ctx, stepCtx context.Context, toolName string, input map[string]interface{}) (interface{}, error) {
 	tool, ok := r.toolsByName[toolName]
 	if !ok {
 		return nil, agentErrors.New(agentErrors.CodeToolNotFound, "tool not found").
@@ -361,10 +393,10 @@ func (r *ReActAgent) executeTool(ctx context.Context, toolName string, input map
 	// 执行工具
 	toolInput := &interfaces.ToolInput{
 		Args:    input,
-		Context: ctx,
+		Context: stepCtx,
 	}
 
-	output, err := tool.Invoke(ctx, toolInput)
+	output, err := tool.Invoke(stepCtx, toolInput)
 	if err != nil {
 		_ = r.triggerOnToolError(ctx, toolName, err)
 		return nil, err
@@ -522,6 +554,14 @@ func (r *ReActAgent) triggerOnLLMEnd(ctx context.Context, output string, tokenUs
 	return nil
 }
 
+// tokensUsed 返回响应的总 token 数，优先使用详细用量
+func tokensUsed(resp *llm.CompletionResponse) int {
+	if resp.Usage != nil && resp.Usage.TotalTokens > 0 {
+		return resp.Usage.TotalTokens
+	}
+	return resp.TokensUsed
+}
+
 func (r *ReActAgent) triggerOnLLMError(ctx context.Context, err error) error {
 	config := r.GetConfig()
 	for _, cb := range config.Callbacks {
@@ -13,6 +13,10 @@ import (
 	"github.com/kart-io/goagent/llm"
 	"github.com/kart-io/goagent/llm/constants"
 	"github.com/kart-io/goagent/tools"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MockLLMClient 模拟 LLM 客户端用于测试
@@ -165,6 +169,52 @@ Final Answer: Go is a statically typed, compiled programming language designed a
 	}
 }
 
+// TestReActAgent_Spans 测试 Invoke 为运行和每一步创建 span
+func TestReActAgent_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	echoTool := tools.NewBaseTool("echo", "Echoes the input", `{"type": "object"}`,
+		func(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+			return &interfaces.ToolOutput{Result: "echoed", Success: true}, nil
+		})
+	agent := react.NewReActAgent(react.ReActConfig{
+		Name:        "SpanAgent",
+		Description: "traced agent",
+		LLM: NewMockLLMClient([]string{
+			"Thought: echo first\nAction: echo\nAction Input: {}",
+			"Thought: done\nFinal Answer: echoed",
+		}),
+		Tools:    []interfaces.Tool{echoTool},
+		MaxSteps: 5,
+	})
+
+	if _, err := agent.Invoke(context.Background(), &agentcore.AgentInput{Task: "echo"}); err != nil {
+		t.Fatalf("Agent execution failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	names := make(map[string]trace.SpanID)
+	for _, span := range spans {
+		names[span.Name()] = span.Parent().SpanID()
+	}
+	root := spans[len(spans)-1]
+	if root.Name() != "invoke_agent SpanAgent" {
+		t.Fatalf("Expected the invoke_agent span to end last, got %q", root.Name())
+	}
+	for _, step := range []string{"agent_step step 1", "agent_step step 2"} {
+		parent, ok := names[step]
+		if !ok {
+			t.Errorf("Expected span %q, got %v", step, names)
+		} else if parent != root.SpanContext().SpanID() {
+			t.Errorf("Expected %q to be a child of the invoke_agent span", step)
+		}
+	}
+}
+
 // TestAgentExecutor 测试 Agent 执行器
 func TestAgentExecutor(t *testing.T) {
 	// 创建简单工具
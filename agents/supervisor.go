@@ -8,11 +8,13 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/kart-io/goagent/core"
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/observability"
 	"github.com/kart-io/goagent/performance"
 	"github.com/kart-io/goagent/tools"
 	"github.com/kart-io/goagent/utils/json"
@@ -221,13 +223,20 @@ func (s *SupervisorAgent) Invoke(ctx context.Context, input *core.AgentInput) (*
 	startTime := time.Now()
 	s.metrics.IncrementTotalTasks()
 
+	// Sub-agent spans started from this context (including those in
+	// errgroup goroutines) become children of the supervisor span
+	ctx, span := observability.StartInvokeAgentSpan(ctx, s.Name(), s.Description())
+	defer span.End()
+
 	// Parse input into tasks
 	tasks, err := s.parseTasks(ctx, input.Task)
 	if err != nil {
 		s.metrics.IncrementFailedTasks()
-		return nil, agentErrors.Wrap(err, agentErrors.CodeAgentExecution, "failed to parse tasks").
+		err = agentErrors.Wrap(err, agentErrors.CodeAgentExecution, "failed to parse tasks").
 			WithComponent("supervisor_agent").
 			WithOperation("Invoke")
+		observability.RecordError(span, err)
+		return nil, err
 	}
 
 	// Create execution plan
@@ -399,6 +408,13 @@ func (s *SupervisorAgent) executeTask(ctx context.Context, task Task, originalIn
 		StartTime: startTime,
 	}
 
+	ctx, span := observability.StartAgentStepSpan(ctx, s.Name(), "task "+task.ID,
+		attribute.String("task.type", task.Type))
+	defer func() {
+		observability.RecordError(span, result.Error)
+		span.End()
+	}()
+
 	// Create timeout context
 	taskCtx, cancel := context.WithTimeout(ctx, s.config.SubAgentTimeout)
 	defer cancel()
@@ -414,6 +430,7 @@ func (s *SupervisorAgent) executeTask(ctx context.Context, task Task, originalIn
 	}
 
 	result.AgentName = agentName
+	span.SetAttributes(attribute.String("supervisor.sub_agent", agentName))
 
 	// Get the selected agent
 	s.mu.RLock()
@@ -473,7 +490,9 @@ func (s *SupervisorAgent) executeTask(ctx context.Context, task Task, originalIn
 			Timestamp:   time.Now(),
 		}
 		// 使用快速路径优化子 Agent 调用
-		output, err := core.TryInvokeFast(taskCtx, agent, agentInput)
+		agentCtx, agentSpan := observability.StartInvokeAgentSpan(taskCtx, agentName, agent.Description())
+		output, err := core.TryInvokeFast(agentCtx, agent, agentInput)
+		observability.EndSpan(agentSpan, err)
 		if err == nil {
 			agentOutput = output
 			execErr = nil
@@ -12,6 +12,7 @@ import (
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/observability"
 )
 
 // ToTAgent implements Tree-of-Thought reasoning pattern.
@@ -110,6 +111,14 @@ func NewToTAgent(config ToTConfig) *ToTAgent {
 
 // Invoke executes the Tree-of-Thought reasoning
 func (t *ToTAgent) Invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
+	ctx, span := observability.StartInvokeAgentSpan(ctx, t.Name(), t.Description())
+	output, err := t.invoke(ctx, input)
+	observability.EndSpan(span, err)
+	return output, err
+}
+
+// invoke performs the Tree-of-Thought reasoning without tracing the run
+func (t *ToTAgent) invoke(ctx context.Context, input *agentcore.AgentInput) (*agentcore.AgentOutput, error) {
 	startTime := time.Now()
 
 	// Trigger start callback
@@ -205,13 +214,10 @@ func (t *ToTAgent) beamSearch(ctx context.Context, root *ThoughtNode, input *age
 				return node, nil
 			}
 
-			// Generate children thoughts
-			children := t.generateThoughts(ctx, node, input, output)
+			// Generate and evaluate children thoughts
+			children := t.expandNode(ctx, node, input, output, true)
 
-			// Evaluate each child
 			for _, child := range children {
-				child.Score = t.evaluateThought(ctx, child, input)
-
 				// Prune low-score thoughts
 				if child.Score >= t.config.PruneThreshold {
 					nextBeam = append(nextBeam, child)
@@ -264,10 +270,8 @@ func (t *ToTAgent) depthFirstSearch(ctx context.Context, node *ThoughtNode, inpu
 	}
 
 	// Generate and explore children
-	children := t.generateThoughts(ctx, node, input, output)
+	children := t.expandNode(ctx, node, input, output, true)
 	for _, child := range children {
-		child.Score = t.evaluateThought(ctx, child, input)
-
 		// Skip low-score branches
 		if child.Score < t.config.PruneThreshold {
 			continue
@@ -322,10 +326,8 @@ func (t *ToTAgent) breadthFirstSearch(ctx context.Context, root *ThoughtNode, in
 		}
 
 		// Generate children
-		children := t.generateThoughts(ctx, node, input, output)
+		children := t.expandNode(ctx, node, input, output, true)
 		for _, child := range children {
-			child.Score = t.evaluateThought(ctx, child, input)
-
 			if child.Score >= t.config.PruneThreshold {
 				queue = append(queue, child)
 
@@ -357,7 +359,7 @@ func (t *ToTAgent) monteCarloSearch(ctx context.Context, root *ThoughtNode, inpu
 
 		// Expansion: expand if not fully expanded
 		if node.Depth < t.config.MaxDepth && !t.isSolution(ctx, node, input) {
-			children := t.generateThoughts(ctx, node, input, output)
+			children := t.expandNode(ctx, node, input, output, false)
 			if len(children) > 0 {
 				node = children[0] // Select first child for simulation
 			}
@@ -380,6 +382,21 @@ func (t *ToTAgent) monteCarloSearch(ctx context.Context, root *ThoughtNode, inpu
 	return t.getBestPath(root), nil
 }
 
+// expandNode generates the children of a node inside an agent_step span,
+// scoring them when score is set
+func (t *ToTAgent) expandNode(ctx context.Context, node *ThoughtNode, input *agentcore.AgentInput, output *agentcore.AgentOutput, score bool) []*ThoughtNode {
+	ctx, span := observability.StartAgentStepSpan(ctx, t.Name(), "expand "+node.ID)
+	defer observability.EndSpan(span, nil)
+
+	children := t.generateThoughts(ctx, node, input, output)
+	if score {
+		for _, child := range children {
+			child.Score = t.evaluateThought(ctx, child, input)
+		}
+	}
+	return children
+}
+
 // generateThoughts generates child thoughts for a node
 func (t *ToTAgent) generateThoughts(ctx context.Context, parent *ThoughtNode, input *agentcore.AgentInput, output *agentcore.AgentOutput) []*ThoughtNode {
 	prompt := t.buildThoughtGenerationPrompt(parent, input)
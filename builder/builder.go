@@ -12,6 +12,7 @@ import (
 	"github.com/kart-io/goagent/core/execution"
 	"github.com/kart-io/goagent/core/middleware"
 	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/guardrails"
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/llm"
 	"github.com/kart-io/goagent/store"
@@ -42,6 +43,7 @@ type AgentBuilder[C any, S core.State] struct {
 
 	// Phase 2 components
 	middlewares []middleware.Middleware
+	guardrails  *guardrails.Pipeline
 
 	// Configuration
 	config *AgentConfig
@@ -155,6 +157,14 @@ func (b *AgentBuilder[C, S]) WithMiddleware(mw ...middleware.Middleware) *AgentB
 	return b
 }
 
+// WithGuardrails checks input, output and tool results with a guardrails
+// pipeline. The pipeline middleware runs before user middleware, and every
+// tool is wrapped so that its results are checked before they reach the LLM.
+func (b *AgentBuilder[C, S]) WithGuardrails(pipeline *guardrails.Pipeline) *AgentBuilder[C, S] {
+	b.guardrails = pipeline
+	return b
+}
+
 // WithCallbacks adds callbacks for monitoring
 func (b *AgentBuilder[C, S]) WithCallbacks(callbacks ...core.Callback) *AgentBuilder[C, S] {
 	b.callbacks = append(b.callbacks, callbacks...)
@@ -290,13 +300,20 @@ func (b *AgentBuilder[C, S]) Build() (*ConfigurableAgent[C, S], error) {
 		chain.Use(middleware.NewTimingMiddleware())
 	}
 
+	// Add guardrails before user middleware
+	tools := b.tools
+	if b.guardrails != nil {
+		chain.Use(b.guardrails.Middleware())
+		tools = b.guardrails.Tools(tools)
+	}
+
 	// Add user-specified middleware
 	chain.Use(b.middlewares...)
 
 	// Create the agent
 	agent := &ConfigurableAgent[C, S]{
 		llmClient:    b.llmClient,
-		tools:        b.tools,
+		tools:        tools,
 		systemPrompt: b.systemPrompt,
 		runtime:      runtime,
 		chain:        chain,
@@ -344,6 +361,9 @@ func (b *AgentBuilder[C, S]) createHandler(runtime *execution.Runtime[C, S]) mid
 
 		// Trigger OnLLMEnd callbacks
 		if len(b.callbacks) > 0 {
+			if err := core.TriggerLLMUsage(ctx, b.callbacks, response.Model, response.Usage); err != nil {
+				return nil, err
+			}
 			for _, cb := range b.callbacks {
 				if err := cb.OnLLMEnd(ctx, response.Content, response.TokensUsed); err != nil {
 					// Log error but don't fail the request
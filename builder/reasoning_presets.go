@@ -12,6 +12,7 @@ import (
 	"github.com/kart-io/goagent/agents/tot"
 	"github.com/kart-io/goagent/core"
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/reflection"
 )
 
 // ReasoningPresets provides fluent API methods for creating agents with different reasoning patterns
@@ -253,9 +254,45 @@ func (b *AgentBuilder[C, S]) BuildReasoningAgent() core.Agent {
 	// Middleware integration needs to be implemented based on
 	// the actual middleware application pattern in GoAgent
 
+	// Wrap in a self-correction loop if Reflexion is configured
+	if cfg, ok := b.metadata["reflexion_config"].(reflection.ReflexionConfig); ok && agent != nil {
+		if cfg.Agent == nil {
+			cfg.Agent = agent
+		}
+		agent = reflection.NewReflexionAgent(cfg)
+	}
+
 	return agent
 }
 
+// WithReflexion wraps the reasoning agent in a Reflexion self-correction loop
+//
+// Each attempt is evaluated; failed attempts produce a self-critique that is
+// injected into the next attempt, up to MaxTrials. The builder's LLM client
+// is used for critiques (and for judging if no Evaluator is given).
+//
+// Example:
+//
+//	agent := NewAgentBuilder(llm).
+//	  WithChainOfThought().
+//	  WithReflexion(reflection.ReflexionConfig{
+//	    Evaluator: reflection.NewTestEvaluator(checkAnswer),
+//	    MaxTrials: 4,
+//	  }).
+//	  BuildReasoningAgent()
+func (b *AgentBuilder[C, S]) WithReflexion(config ...reflection.ReflexionConfig) *AgentBuilder[C, S] {
+	var cfg reflection.ReflexionConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.LLM == nil {
+		cfg.LLM = b.llmClient
+	}
+
+	b.metadata["reflexion_config"] = cfg
+	return b
+}
+
 // Preset configurations for quick setup
 
 // WithZeroShotCoT creates a zero-shot Chain-of-Thought agent
@@ -414,6 +451,18 @@ func (b *AgentBuilder[C, S]) WithProgramOfThought(config ...pot.PoTConfig) *Agen
 			cfg.ExecutionTimeout = provided.ExecutionTimeout
 		}
 		cfg.SafeMode = provided.SafeMode
+		if provided.PythonPath != "" {
+			cfg.PythonPath = provided.PythonPath
+		}
+		if provided.NodePath != "" {
+			cfg.NodePath = provided.NodePath
+		}
+		if provided.DockerImage != "" {
+			cfg.DockerImage = provided.DockerImage
+		}
+		if provided.Sandbox != nil {
+			cfg.Sandbox = provided.Sandbox
+		}
 		if provided.MaxIterations > 0 {
 			cfg.MaxIterations = provided.MaxIterations
 		}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/kart-io/goagent/interfaces"
 )
 
 // Callback 定义回调处理器接口
@@ -37,6 +39,29 @@ type Callback interface {
 	OnAgentFinish(ctx context.Context, output interface{}) error
 }
 
+// UsageCallback 可选的回调扩展，接收每次 LLM 调用的模型与详细 token 用量
+//
+// OnLLMEnd 只携带总 token 数，无法区分输入、输出与缓存 token。
+// 实现该接口的回调会在 OnLLMEnd 之前收到 OnLLMUsage，用于精确计费
+type UsageCallback interface {
+	OnLLMUsage(ctx context.Context, model string, usage *interfaces.TokenUsage) error
+}
+
+// TriggerLLMUsage 向实现了 UsageCallback 的回调发送 token 用量
+func TriggerLLMUsage(ctx context.Context, callbacks []Callback, model string, usage *interfaces.TokenUsage) error {
+	if usage == nil {
+		return nil
+	}
+	for _, cb := range callbacks {
+		if uc, ok := cb.(UsageCallback); ok {
+			if err := uc.OnLLMUsage(ctx, model, usage); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // AgentAction Agent 执行的操作
 type AgentAction struct {
 	Tool      string                 // 工具名称
@@ -381,13 +406,24 @@ func (t *TracingCallback) OnLLMError(ctx context.Context, err error) error {
 
 // CostTrackingCallback 成本追踪回调
 //
-// 追踪 LLM 调用的成本
+// 追踪 LLM 调用的成本。模型取自 OnLLMStart，收到 OnLLMUsage 时按该调用的
+// 总 token 数计费。按输入、输出、缓存 token 分别计价与预算控制见 cost 包
 type CostTrackingCallback struct {
 	*BaseCallback
 	totalCost   float64
 	totalTokens int
 	mu          sync.Mutex
-	pricing     map[string]float64 // model -> cost per token
+	pricing     map[string]float64            // model -> cost per token
+	pending     map[context.Context]*llmCalls // 进行中的 LLM 调用
+}
+
+// llmCalls 同一 context 上进行中的 LLM 调用
+//
+// 回调接口没有调用 ID，同一 context 上的并发调用按先进先出匹配，
+// 并对已通过 OnLLMUsage 计费的调用计数，避免相互覆盖
+type llmCalls struct {
+	models []string // OnLLMStart 记录的模型，按开始顺序排列
+	billed int      // 已通过 OnLLMUsage 计费、尚未结束的调用数
 }
 
 // NewCostTrackingCallback 创建成本追踪回调
@@ -395,26 +431,99 @@ func NewCostTrackingCallback(pricing map[string]float64) *CostTrackingCallback {
 	return &CostTrackingCallback{
 		BaseCallback: NewBaseCallback(),
 		pricing:      pricing,
+		pending:      make(map[context.Context]*llmCalls),
 	}
 }
 
-func (c *CostTrackingCallback) OnLLMEnd(ctx context.Context, output string, tokenUsage int) error {
+func (c *CostTrackingCallback) OnLLMStart(ctx context.Context, prompts []string, model string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.totalTokens += tokenUsage
+	calls := c.pending[ctx]
+	if calls == nil {
+		calls = &llmCalls{}
+		c.pending[ctx] = calls
+	}
+	calls.models = append(calls.models, model)
+	return nil
+}
 
-	// 计算成本（简化版本，实际需要根据模型）
-	// 这里假设从 context 中可以获取模型信息
-	model := "gpt-4" // 从 context 获取
-	if pricePerToken, ok := c.pricing[model]; ok {
-		cost := float64(tokenUsage) * pricePerToken
-		c.totalCost += cost
+// OnLLMUsage 实现 UsageCallback，使用响应中的模型与 token 用量计费
+func (c *CostTrackingCallback) OnLLMUsage(ctx context.Context, model string, usage *interfaces.TokenUsage) error {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	calls := c.pending[ctx]
+	if calls == nil {
+		calls = &llmCalls{}
+		c.pending[ctx] = calls
+	}
+	if model == "" && len(calls.models) > 0 {
+		model = calls.models[0]
 	}
+	calls.billed++
+	c.addLocked(model, total)
+	return nil
+}
+
+func (c *CostTrackingCallback) OnLLMEnd(ctx context.Context, output string, tokenUsage int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model, billed := c.finishLocked(ctx)
+	// 已通过 OnLLMUsage 计费
+	if billed {
+		return nil
+	}
+	c.addLocked(model, tokenUsage)
+	return nil
+}
+
+// OnLLMError 结束失败的调用，释放其记录
+func (c *CostTrackingCallback) OnLLMError(ctx context.Context, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	c.finishLocked(ctx)
 	return nil
 }
 
+// finishLocked 结束 ctx 上最早开始的调用，返回其模型以及是否已计费；
+// 没有进行中的调用时删除 ctx 的记录
+func (c *CostTrackingCallback) finishLocked(ctx context.Context) (string, bool) {
+	calls := c.pending[ctx]
+	if calls == nil {
+		return "", false
+	}
+
+	var model string
+	if len(calls.models) > 0 {
+		model = calls.models[0]
+		calls.models = calls.models[1:]
+	}
+	billed := calls.billed > 0
+	if billed {
+		calls.billed--
+	}
+	if len(calls.models) == 0 {
+		delete(c.pending, ctx)
+	}
+	return model, billed
+}
+
+// addLocked 累加 token 与成本，调用方需持有 c.mu
+func (c *CostTrackingCallback) addLocked(model string, tokens int) {
+	c.totalTokens += tokens
+	if pricePerToken, ok := c.pricing[model]; ok {
+		c.totalCost += float64(tokens) * pricePerToken
+	}
+}
+
 // GetTotalCost 获取总成本
 func (c *CostTrackingCallback) GetTotalCost() float64 {
 	c.mu.Lock()
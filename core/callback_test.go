@@ -6,6 +6,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/kart-io/goagent/interfaces"
 )
 
 // mockLogger implements Logger interface for testing callbacks
@@ -304,6 +306,56 @@ func TestNewCostTrackingCallback(t *testing.T) {
 	}
 }
 
+func TestCostTrackingCallback_UsesCallModel(t *testing.T) {
+	cb := NewCostTrackingCallback(map[string]float64{"gpt-4o-mini": 0.001})
+	ctx := context.Background()
+
+	_ = cb.OnLLMStart(ctx, nil, "gpt-4o-mini")
+	_ = cb.OnLLMEnd(ctx, "output", 10)
+	if cost := cb.GetTotalCost(); cost < 0.0099 || cost > 0.0101 {
+		t.Errorf("Expected cost 0.01, got %f", cost)
+	}
+
+	// OnLLMUsage 已计费时 OnLLMEnd 不重复计数
+	usage := &interfaces.TokenUsage{PromptTokens: 5, CompletionTokens: 15}
+	if err := TriggerLLMUsage(ctx, []Callback{cb}, "gpt-4o-mini", usage); err != nil {
+		t.Fatalf("TriggerLLMUsage failed: %v", err)
+	}
+	_ = cb.OnLLMEnd(ctx, "output", 20)
+	if cb.GetTotalTokens() != 30 {
+		t.Errorf("Expected 30 tokens, got %d", cb.GetTotalTokens())
+	}
+}
+
+func TestCostTrackingCallback_PendingCalls(t *testing.T) {
+	cb := NewCostTrackingCallback(map[string]float64{"a": 0.001, "b": 0.01})
+	ctx := context.Background()
+
+	// 失败的调用释放记录
+	_ = cb.OnLLMStart(ctx, nil, "a")
+	_ = cb.OnLLMError(ctx, errors.New("boom"))
+	if len(cb.pending) != 0 {
+		t.Errorf("Expected no pending calls after OnLLMError, got %d", len(cb.pending))
+	}
+
+	// 同一 context 上的并发调用互不覆盖
+	_ = cb.OnLLMStart(ctx, nil, "a")
+	_ = cb.OnLLMStart(ctx, nil, "b")
+	_ = cb.OnLLMUsage(ctx, "b", &interfaces.TokenUsage{TotalTokens: 10})
+	_ = cb.OnLLMUsage(ctx, "a", &interfaces.TokenUsage{TotalTokens: 100})
+	_ = cb.OnLLMEnd(ctx, "output", 10)
+	_ = cb.OnLLMEnd(ctx, "output", 100)
+	if cb.GetTotalTokens() != 110 {
+		t.Errorf("Expected 110 tokens, got %d", cb.GetTotalTokens())
+	}
+	if cost := cb.GetTotalCost(); cost < 0.1999 || cost > 0.2001 {
+		t.Errorf("Expected cost 0.2, got %f", cost)
+	}
+	if len(cb.pending) != 0 {
+		t.Errorf("Expected no pending calls, got %d", len(cb.pending))
+	}
+}
+
 func TestNewStdoutCallback(t *testing.T) {
 	cb := NewStdoutCallback(false)
 	if cb == nil {
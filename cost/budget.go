@@ -0,0 +1,160 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/observability"
+)
+
+// Budget limits spend along a dimension.
+//
+// A budget with Dimension DimensionSession and no Key applies to every
+// session separately; with a Key it applies to that session only. Calls
+// without a value for the dimension (e.g. no session in the context) are
+// not limited by it.
+type Budget struct {
+	// Name identifies the budget in decisions and metrics
+	Name string
+
+	// Dimension is what the budget is scoped to. Empty means DimensionGlobal.
+	Dimension Dimension
+
+	// Key restricts the budget to one value of the dimension
+	Key string
+
+	// Soft is the spend in USD above which calls are downgraded to
+	// DowngradeModel, or only reported if it is empty. Zero disables it.
+	Soft float64
+
+	// Hard is the spend in USD above which calls are refused. Zero disables it.
+	Hard float64
+
+	// DowngradeModel is the cheaper model used once Soft is exceeded
+	DowngradeModel string
+}
+
+// dimension returns the effective dimension of the budget
+func (b Budget) dimension() Dimension {
+	if b.Dimension == "" {
+		return DimensionGlobal
+	}
+	return b.Dimension
+}
+
+// Action is what to do with the next call
+type Action int
+
+const (
+	// ActionAllow lets the call proceed
+	ActionAllow Action = iota
+	// ActionWarn lets the call proceed but a soft limit is exceeded
+	ActionWarn
+	// ActionDowngrade lets the call proceed with a cheaper model
+	ActionDowngrade
+	// ActionAbort refuses the call
+	ActionAbort
+)
+
+// String returns the action name
+func (a Action) String() string {
+	switch a {
+	case ActionWarn:
+		return "warn"
+	case ActionDowngrade:
+		return "downgrade"
+	case ActionAbort:
+		return "abort"
+	default:
+		return "allow"
+	}
+}
+
+// Decision is the outcome of checking the budgets before a call
+type Decision struct {
+	Action Action
+
+	// Budget is the budget that caused the action
+	Budget Budget
+
+	// Key is the dimension value the budget was evaluated for
+	Key string
+
+	// Spent is the spend of Key at the time of the decision
+	Spent float64
+
+	// Model is the model to use when Action is ActionDowngrade
+	Model string
+}
+
+// Err returns a CodeLLMBudget error when the decision is to abort
+func (d Decision) Err() error {
+	if d.Action != ActionAbort {
+		return nil
+	}
+	return agentErrors.New(agentErrors.CodeLLMBudget,
+		fmt.Sprintf("budget %q exceeded: spent $%.4f of $%.4f", d.Budget.Name, d.Spent, d.Budget.Hard)).
+		WithComponent("cost_tracker").
+		WithOperation("Check").
+		WithContext("budget", d.Budget.Name).
+		WithContext("dimension", string(d.Budget.dimension())).
+		WithContext("key", d.Key)
+}
+
+// Check evaluates the budgets for the attribution in the context and
+// returns the most severe decision. Exceeded limits are reported to the
+// budget handler and to observability.Metrics.
+func (t *Tracker) Check(ctx context.Context) Decision {
+	decision := t.evaluate(ctx)
+	if decision.Action == ActionAllow {
+		return decision
+	}
+
+	if t.metrics {
+		limit := "soft"
+		if decision.Action == ActionAbort {
+			limit = "hard"
+		}
+		observability.RecordBudgetExceeded(decision.Budget.Name, limit)
+	}
+	if t.onBudget != nil {
+		t.onBudget(ctx, decision)
+	}
+	return decision
+}
+
+// evaluate returns the most severe decision without side effects
+func (t *Tracker) evaluate(ctx context.Context) Decision {
+	attribution := AttributionFromContext(ctx)
+	decision := Decision{Action: ActionAllow}
+
+	for _, budget := range t.budgets {
+		dim := budget.dimension()
+		key := attribution.Key(dim)
+		if dim != DimensionGlobal {
+			if key == "" || (budget.Key != "" && budget.Key != key) {
+				continue
+			}
+		}
+
+		spent := t.Totals(dim, key).Cost
+		action := ActionAllow
+		switch {
+		case budget.Hard > 0 && spent >= budget.Hard:
+			action = ActionAbort
+		case budget.Soft > 0 && spent >= budget.Soft && budget.DowngradeModel != "":
+			action = ActionDowngrade
+		case budget.Soft > 0 && spent >= budget.Soft:
+			action = ActionWarn
+		}
+
+		if action > decision.Action {
+			decision = Decision{Action: action, Budget: budget, Key: key, Spent: spent}
+			if action == ActionDowngrade {
+				decision.Model = budget.DowngradeModel
+			}
+		}
+	}
+	return decision
+}
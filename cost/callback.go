@@ -0,0 +1,138 @@
+package cost
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+)
+
+// Callback records LLM usage reported through agent callbacks.
+//
+// It implements core.UsageCallback, so agents that report per-call
+// interfaces.TokenUsage (react.ReActAgent, builder.AgentBuilder) are priced
+// by input, output and cached tokens; others fall back to the total token
+// count of OnLLMEnd. A call started over a hard budget is aborted by
+// returning the budget error from OnLLMStart. Callbacks cannot change the
+// model, so downgrades need LLMClient.
+type Callback struct {
+	*core.BaseCallback
+	tracker  *Tracker
+	provider string
+
+	mu      sync.Mutex
+	pending map[context.Context]*llmCalls
+}
+
+// llmCalls are the LLM calls in flight on one context. Callbacks carry no
+// call ID, so concurrent calls sharing a context are matched first in,
+// first out, and calls already priced by OnLLMUsage are counted so that
+// OnLLMEnd does not price them again.
+type llmCalls struct {
+	models []string // models from OnLLMStart, in start order
+	billed int      // calls priced by OnLLMUsage that have not ended
+}
+
+// NewCallback creates a usage callback. The provider narrows catalog
+// lookups; leave it empty to match the model across providers.
+func NewCallback(tracker *Tracker, provider string) *Callback {
+	return &Callback{
+		BaseCallback: core.NewBaseCallback(),
+		tracker:      tracker,
+		provider:     provider,
+		pending:      make(map[context.Context]*llmCalls),
+	}
+}
+
+// OnLLMStart checks the budgets and remembers the model of the call
+func (c *Callback) OnLLMStart(ctx context.Context, prompts []string, model string) error {
+	if err := c.tracker.Check(ctx).Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := c.callsLocked(ctx)
+	calls.models = append(calls.models, model)
+	return nil
+}
+
+// OnLLMUsage records the detailed usage of a call
+func (c *Callback) OnLLMUsage(ctx context.Context, model string, usage *interfaces.TokenUsage) error {
+	c.mu.Lock()
+	calls := c.callsLocked(ctx)
+	if model == "" && len(calls.models) > 0 {
+		model = calls.models[0]
+	}
+	calls.billed++
+	c.mu.Unlock()
+
+	_, decision := c.tracker.Record(ctx, c.provider, model, usage)
+	return decision.Err()
+}
+
+// OnLLMEnd records the total token count unless OnLLMUsage already did
+func (c *Callback) OnLLMEnd(ctx context.Context, output string, tokenUsage int) error {
+	c.mu.Lock()
+	model, billed := c.finishLocked(ctx)
+	c.mu.Unlock()
+
+	if billed {
+		return nil
+	}
+	_, decision := c.tracker.Record(ctx, c.provider, model, &interfaces.TokenUsage{TotalTokens: tokenUsage})
+	return decision.Err()
+}
+
+// OnLLMError ends a failed call
+func (c *Callback) OnLLMError(ctx context.Context, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finishLocked(ctx)
+	return nil
+}
+
+// callsLocked returns the calls in flight on ctx, creating the entry
+func (c *Callback) callsLocked(ctx context.Context) *llmCalls {
+	calls := c.pending[ctx]
+	if calls == nil {
+		calls = &llmCalls{}
+		c.pending[ctx] = calls
+	}
+	return calls
+}
+
+// finishLocked ends the earliest call in flight on ctx and returns its
+// model and whether OnLLMUsage priced it. The entry is removed once no call
+// is left.
+func (c *Callback) finishLocked(ctx context.Context) (string, bool) {
+	calls := c.pending[ctx]
+	if calls == nil {
+		return "", false
+	}
+
+	var model string
+	if len(calls.models) > 0 {
+		model = calls.models[0]
+		calls.models = calls.models[1:]
+	}
+	billed := calls.billed > 0
+	if billed {
+		calls.billed--
+	}
+	if len(calls.models) == 0 {
+		delete(c.pending, ctx)
+	}
+	return model, billed
+}
+
+// responseUsage returns the usage of a response, falling back to the
+// deprecated total token count
+func responseUsage(resp *llm.CompletionResponse) *interfaces.TokenUsage {
+	if resp.Usage != nil {
+		return resp.Usage
+	}
+	return &interfaces.TokenUsage{TotalTokens: resp.TokensUsed}
+}
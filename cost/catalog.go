@@ -0,0 +1,254 @@
+// Package cost accounts for LLM token usage and spend.
+//
+// A versioned Catalog holds per-provider, per-model prices for input,
+// output and cached input tokens. A Tracker prices every call from its
+// interfaces.TokenUsage, aggregates totals by session, user, agent and
+// tenant, and enforces soft and hard budgets. Calls are attributed through
+// the context:
+//
+//	tracker := cost.NewTracker(cost.WithBudget(cost.Budget{
+//		Name:           "per-session",
+//		Dimension:      cost.DimensionSession,
+//		Soft:           0.50,
+//		Hard:           2.00,
+//		DowngradeModel: "gpt-4o-mini",
+//	}))
+//	client := cost.NewLLMClient(provider, tracker)
+//
+//	ctx = cost.WithAttribution(ctx, cost.Attribution{SessionID: "s-1", TenantID: "acme"})
+//	resp, err := client.Chat(ctx, messages) // priced, aggregated and budget-checked
+//
+// Agents that report usage through callbacks can use NewCallback instead of
+// wrapping the client.
+package cost
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// tokensPerUnit is the number of tokens prices are quoted for
+const tokensPerUnit = 1_000_000
+
+// Price is the USD price per million tokens of a model
+type Price struct {
+	// Input is the price of prompt tokens
+	Input float64 `json:"input"`
+
+	// Output is the price of completion tokens
+	Output float64 `json:"output"`
+
+	// CachedInput is the price of prompt tokens served from the provider's
+	// prompt cache. Zero means cached tokens are billed at the Input rate.
+	CachedInput float64 `json:"cached_input,omitempty"`
+}
+
+// Cost returns the USD cost of a call.
+//
+// Usage that only reports a total (no prompt/completion split) is billed at
+// the output rate, so that budgets err on the side of stopping early.
+func (p Price) Cost(usage *interfaces.TokenUsage) float64 {
+	if usage == nil {
+		return 0
+	}
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return float64(usage.TotalTokens) * p.Output / tokensPerUnit
+	}
+
+	cached := usage.CachedTokens
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+	cachedRate := p.CachedInput
+	if cachedRate == 0 {
+		cachedRate = p.Input
+	}
+
+	return (float64(usage.PromptTokens-cached)*p.Input +
+		float64(cached)*cachedRate +
+		float64(usage.CompletionTokens)*p.Output) / tokensPerUnit
+}
+
+// Catalog is a versioned table of model prices per provider.
+//
+// Lookups match the model exactly first and then by the longest catalog
+// entry that prefixes the model, so "gpt-4o-2024-08-06" is priced as
+// "gpt-4o". The version is stamped on every Record so that historical spend
+// can be traced back to the prices it was computed with.
+type Catalog struct {
+	mu      sync.RWMutex
+	version string
+	prices  map[string]map[string]Price // provider -> model -> price
+}
+
+// catalogFile is the JSON layout read by LoadCatalog
+type catalogFile struct {
+	Version   string                      `json:"version"`
+	Providers map[string]map[string]Price `json:"providers"`
+}
+
+// NewCatalog creates an empty catalog
+func NewCatalog(version string) *Catalog {
+	return &Catalog{
+		version: version,
+		prices:  make(map[string]map[string]Price),
+	}
+}
+
+// LoadCatalog reads a catalog from JSON:
+//
+//	{
+//	  "version": "2025-06",
+//	  "providers": {
+//	    "openai": {"gpt-4o": {"input": 2.5, "output": 10, "cached_input": 1.25}}
+//	  }
+//	}
+func LoadCatalog(r io.Reader) (*Catalog, error) {
+	var file catalogFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidConfig, "failed to decode price catalog").
+			WithComponent("cost_catalog").
+			WithOperation("LoadCatalog")
+	}
+	if file.Version == "" {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "price catalog version is required").
+			WithComponent("cost_catalog").
+			WithOperation("LoadCatalog")
+	}
+
+	catalog := NewCatalog(file.Version)
+	for provider, models := range file.Providers {
+		for model, price := range models {
+			catalog.Set(provider, model, price)
+		}
+	}
+	return catalog, nil
+}
+
+// Version returns the catalog version
+func (c *Catalog) Version() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// Set sets the price of a model
+func (c *Catalog) Set(provider, model string, price Price) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	provider = strings.ToLower(provider)
+	if c.prices[provider] == nil {
+		c.prices[provider] = make(map[string]Price)
+	}
+	c.prices[provider][strings.ToLower(model)] = price
+}
+
+// Lookup returns the price of a model. An empty provider searches all
+// providers, which is what callbacks use since they only know the model.
+func (c *Catalog) Lookup(provider, model string) (Price, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	model = strings.ToLower(model)
+	if model == "" {
+		return Price{}, false
+	}
+	if provider != "" {
+		return lookupModel(c.prices[strings.ToLower(provider)], model)
+	}
+
+	// 按提供商名称排序，保证跨提供商查找的结果稳定
+	providers := make([]string, 0, len(c.prices))
+	for name := range c.prices {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	for _, name := range providers {
+		if price, ok := lookupModel(c.prices[name], model); ok {
+			return price, true
+		}
+	}
+	return Price{}, false
+}
+
+// lookupModel matches a model exactly, then by the longest prefix
+func lookupModel(models map[string]Price, model string) (Price, bool) {
+	if price, ok := models[model]; ok {
+		return price, true
+	}
+
+	best := ""
+	for name := range models {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return models[best], true
+}
+
+// DefaultCatalogVersion is the version of the built-in catalog
+const DefaultCatalogVersion = "2025-06"
+
+// DefaultCatalog returns a catalog with list prices of common models.
+//
+// Prices change; production deployments should load their own catalog
+// with LoadCatalog and bump its version on every update.
+func DefaultCatalog() *Catalog {
+	catalog := NewCatalog(DefaultCatalogVersion)
+	for provider, models := range map[string]map[string]Price{
+		"openai": {
+			"gpt-4o":        {Input: 2.5, Output: 10, CachedInput: 1.25},
+			"gpt-4o-mini":   {Input: 0.15, Output: 0.6, CachedInput: 0.075},
+			"gpt-4.1":       {Input: 2, Output: 8, CachedInput: 0.5},
+			"gpt-4.1-mini":  {Input: 0.4, Output: 1.6, CachedInput: 0.1},
+			"gpt-4.1-nano":  {Input: 0.1, Output: 0.4, CachedInput: 0.025},
+			"gpt-4-turbo":   {Input: 10, Output: 30},
+			"gpt-4":         {Input: 30, Output: 60},
+			"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+			"o3-mini":       {Input: 1.1, Output: 4.4, CachedInput: 0.55},
+		},
+		"anthropic": {
+			"claude-opus-4":     {Input: 15, Output: 75, CachedInput: 1.5},
+			"claude-sonnet-4":   {Input: 3, Output: 15, CachedInput: 0.3},
+			"claude-3-7-sonnet": {Input: 3, Output: 15, CachedInput: 0.3},
+			"claude-3-5-sonnet": {Input: 3, Output: 15, CachedInput: 0.3},
+			"claude-3-5-haiku":  {Input: 0.8, Output: 4, CachedInput: 0.08},
+			"claude-3-opus":     {Input: 15, Output: 75, CachedInput: 1.5},
+		},
+		"deepseek": {
+			"deepseek-chat":     {Input: 0.27, Output: 1.1, CachedInput: 0.07},
+			"deepseek-reasoner": {Input: 0.55, Output: 2.19, CachedInput: 0.14},
+		},
+		"gemini": {
+			"gemini-2.5-pro":   {Input: 1.25, Output: 10, CachedInput: 0.31},
+			"gemini-2.5-flash": {Input: 0.3, Output: 2.5, CachedInput: 0.075},
+			"gemini-2.0-flash": {Input: 0.1, Output: 0.4, CachedInput: 0.025},
+			"gemini-1.5-pro":   {Input: 1.25, Output: 5},
+			"gemini-1.5-flash": {Input: 0.075, Output: 0.3},
+		},
+		"cohere": {
+			"command-r-plus": {Input: 2.5, Output: 10},
+			"command-r":      {Input: 0.15, Output: 0.6},
+		},
+		"kimi": {
+			"moonshot-v1-8k":   {Input: 0.17, Output: 0.17},
+			"moonshot-v1-32k":  {Input: 0.33, Output: 0.33},
+			"moonshot-v1-128k": {Input: 0.83, Output: 0.83},
+		},
+	} {
+		for model, price := range models {
+			catalog.Set(provider, model, price)
+		}
+	}
+	return catalog
+}
@@ -0,0 +1,215 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+)
+
+// usageClient returns a fixed usage and records the requested models
+type usageClient struct {
+	usage  interfaces.TokenUsage
+	models []string
+}
+
+func (c *usageClient) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = "gpt-4o"
+	}
+	c.models = append(c.models, model)
+	usage := c.usage
+	return &llm.CompletionResponse{Content: "ok", Model: model, Usage: &usage}, nil
+}
+
+func (c *usageClient) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	return c.Complete(ctx, &llm.CompletionRequest{Messages: messages})
+}
+
+func (c *usageClient) Provider() constants.Provider { return constants.ProviderOpenAI }
+
+func (c *usageClient) IsAvailable() bool { return true }
+
+func TestPrice_Cost(t *testing.T) {
+	price := Price{Input: 2, Output: 10, CachedInput: 1}
+
+	cost := price.Cost(&interfaces.TokenUsage{PromptTokens: 1_000_000, CompletionTokens: 500_000, CachedTokens: 400_000})
+	assert.InDelta(t, 0.6*2+0.4*1+0.5*10, cost, 1e-9)
+
+	// 仅有总数时按输出价格计费
+	assert.InDelta(t, 10.0, price.Cost(&interfaces.TokenUsage{TotalTokens: 1_000_000}), 1e-9)
+
+	// 未配置缓存价格时按输入价格计费
+	assert.InDelta(t, 2.0, Price{Input: 2}.Cost(&interfaces.TokenUsage{PromptTokens: 1_000_000, CachedTokens: 1_000_000}), 1e-9)
+}
+
+func TestCatalog_LookupAndLoad(t *testing.T) {
+	catalog, err := LoadCatalog(strings.NewReader(`{
+		"version": "test-1",
+		"providers": {
+			"openai": {"gpt-4o": {"input": 2.5, "output": 10}, "gpt-4o-mini": {"input": 0.15, "output": 0.6}},
+			"anthropic": {"claude-3-5-haiku": {"input": 0.8, "output": 4}}
+		}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "test-1", catalog.Version())
+
+	price, ok := catalog.Lookup("openai", "gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, 0.15, price.Input, "longest prefix wins")
+
+	price, ok = catalog.Lookup("", "claude-3-5-haiku-20241022")
+	require.True(t, ok)
+	assert.Equal(t, 4.0, price.Output)
+
+	_, ok = catalog.Lookup("anthropic", "gpt-4o")
+	assert.False(t, ok)
+
+	_, err = LoadCatalog(strings.NewReader(`{"providers": {}}`))
+	assert.Equal(t, agentErrors.CodeInvalidConfig, agentErrors.GetCode(err))
+}
+
+func TestTracker_Aggregation(t *testing.T) {
+	tracker := NewTracker(WithoutMetrics())
+
+	ctx := WithAttribution(context.Background(), Attribution{SessionID: "s-1", TenantID: "acme"})
+	ctx = WithAttribution(interfaces.WithSubject(ctx, "user-1"), Attribution{AgentName: "planner"})
+
+	record, decision := tracker.Record(ctx, "openai", "gpt-4o", &interfaces.TokenUsage{PromptTokens: 1000, CompletionTokens: 100})
+	assert.Equal(t, ActionAllow, decision.Action)
+	assert.True(t, record.Priced)
+	assert.Equal(t, DefaultCatalogVersion, record.CatalogVersion)
+	assert.Equal(t, 1100, record.Usage.TotalTokens)
+	assert.InDelta(t, (1000*2.5+100*10)/1e6, record.Cost, 1e-12)
+
+	_, _ = tracker.Record(WithAttribution(context.Background(), Attribution{SessionID: "s-2", TenantID: "acme"}),
+		"openai", "unknown-model", &interfaces.TokenUsage{TotalTokens: 50})
+
+	assert.Equal(t, 2, tracker.Total().Calls)
+	assert.Equal(t, 1, tracker.Totals(DimensionSession, "s-1").Calls)
+	assert.Equal(t, 1, tracker.Totals(DimensionUser, "user-1").Calls)
+	assert.Equal(t, 1, tracker.Totals(DimensionAgent, "planner").Calls)
+	assert.Equal(t, 2, tracker.Totals(DimensionTenant, "acme").Calls)
+	assert.Equal(t, 1150, tracker.Totals(DimensionTenant, "acme").Usage.TotalTokens)
+	assert.Len(t, tracker.Breakdown(DimensionSession), 2)
+
+	tracker.Reset()
+	assert.Zero(t, tracker.Total().Calls)
+}
+
+func TestLLMClient_Budgets(t *testing.T) {
+	var decisions []Decision
+	tracker := NewTracker(
+		WithoutMetrics(),
+		WithBudget(Budget{Name: "session", Dimension: DimensionSession, Soft: 0.01, Hard: 0.02, DowngradeModel: "gpt-4o-mini"}),
+		WithBudgetHandler(func(ctx context.Context, decision Decision) {
+			decisions = append(decisions, decision)
+		}),
+	)
+	// 每次调用 gpt-4o 花费 $0.0125
+	inner := &usageClient{usage: interfaces.TokenUsage{PromptTokens: 1000, CompletionTokens: 1000}}
+	client := NewLLMClient(inner, tracker)
+	ctx := WithAttribution(context.Background(), Attribution{SessionID: "s-1"})
+
+	_, err := client.Chat(ctx, []llm.Message{llm.UserMessage("hi")})
+	require.NoError(t, err)
+
+	// 超过软限制后降级
+	_, err = client.Chat(ctx, []llm.Message{llm.UserMessage("hi")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, inner.models)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, ActionDowngrade, decisions[0].Action)
+
+	// 推高花费直到超过硬限制
+	_, _ = tracker.Record(ctx, "openai", "gpt-4o", &interfaces.TokenUsage{PromptTokens: 4000})
+	_, err = client.Complete(ctx, &llm.CompletionRequest{Messages: []llm.Message{llm.UserMessage("hi")}})
+	require.Error(t, err)
+	assert.Equal(t, agentErrors.CodeLLMBudget, agentErrors.GetCode(err))
+	assert.Len(t, inner.models, 2, "aborted call must not reach the provider")
+
+	// 其他会话不受影响
+	_, err = client.Chat(WithAttribution(context.Background(), Attribution{SessionID: "s-2"}), []llm.Message{llm.UserMessage("hi")})
+	assert.NoError(t, err)
+}
+
+func TestCallback_UsageAttribution(t *testing.T) {
+	tracker := NewTracker(WithoutMetrics(), WithBudget(Budget{Name: "global", Hard: 0.01}))
+	cb := NewCallback(tracker, "")
+	var _ core.UsageCallback = cb
+
+	ctx := context.Background()
+	require.NoError(t, cb.OnLLMStart(ctx, []string{"hi"}, "gpt-4o-mini"))
+	err := core.TriggerLLMUsage(ctx, []core.Callback{cb}, "", &interfaces.TokenUsage{PromptTokens: 1000, CompletionTokens: 2000})
+	require.NoError(t, err)
+	require.NoError(t, cb.OnLLMEnd(ctx, "ok", 3000))
+
+	total := tracker.Total()
+	assert.Equal(t, 1, total.Calls, "OnLLMEnd must not double count")
+	assert.InDelta(t, (1000*0.15+2000*0.6)/1e6, total.Cost, 1e-12)
+
+	// 没有详细用量时回退到总 token 数
+	require.NoError(t, cb.OnLLMStart(ctx, nil, "gpt-4o"))
+	err = cb.OnLLMEnd(ctx, "ok", 100_000)
+	assert.Equal(t, agentErrors.CodeLLMBudget, agentErrors.GetCode(err))
+	assert.Equal(t, 2, tracker.Total().Calls)
+
+	err = cb.OnLLMStart(ctx, nil, "gpt-4o")
+	assert.Equal(t, agentErrors.CodeLLMBudget, agentErrors.GetCode(err))
+}
+
+func TestCallback_PendingCalls(t *testing.T) {
+	tracker := NewTracker(WithoutMetrics())
+	want := NewTracker(WithoutMetrics())
+	cb := NewCallback(tracker, "")
+	ctx := context.Background()
+
+	// 失败的调用释放记录且不计费
+	require.NoError(t, cb.OnLLMStart(ctx, nil, "gpt-4o"))
+	require.NoError(t, cb.OnLLMError(ctx, errors.New("boom")))
+	assert.Empty(t, cb.pending)
+	assert.Zero(t, tracker.Total().Calls)
+
+	// 同一 context 上的并发调用按开始顺序归属各自的模型
+	require.NoError(t, cb.OnLLMStart(ctx, nil, "gpt-4o-mini"))
+	require.NoError(t, cb.OnLLMStart(ctx, nil, "gpt-4o"))
+	require.NoError(t, cb.OnLLMEnd(ctx, "a", 1000))
+	require.NoError(t, cb.OnLLMEnd(ctx, "b", 5000))
+	want.Record(ctx, "", "gpt-4o-mini", &interfaces.TokenUsage{TotalTokens: 1000})
+	want.Record(ctx, "", "gpt-4o", &interfaces.TokenUsage{TotalTokens: 5000})
+	assert.InDelta(t, want.Total().Cost, tracker.Total().Cost, 1e-12)
+
+	// 已由 OnLLMUsage 计费的调用不会在 OnLLMEnd 中重复计费
+	require.NoError(t, cb.OnLLMStart(ctx, nil, "gpt-4o-mini"))
+	require.NoError(t, cb.OnLLMStart(ctx, nil, "gpt-4o"))
+	require.NoError(t, cb.OnLLMUsage(ctx, "gpt-4o", &interfaces.TokenUsage{TotalTokens: 10}))
+	require.NoError(t, cb.OnLLMUsage(ctx, "gpt-4o-mini", &interfaces.TokenUsage{TotalTokens: 100}))
+	require.NoError(t, cb.OnLLMEnd(ctx, "a", 100))
+	require.NoError(t, cb.OnLLMEnd(ctx, "b", 10))
+	assert.Equal(t, 4, tracker.Total().Calls)
+	assert.Empty(t, cb.pending)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = cb.OnLLMStart(ctx, nil, "gpt-4o-mini")
+			_ = cb.OnLLMEnd(ctx, "ok", 10)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 24, tracker.Total().Calls)
+	assert.Empty(t, cb.pending)
+}
@@ -0,0 +1,91 @@
+package cost
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+)
+
+// LLMClient wraps an llm.Client, checking budgets before every call and
+// recording its usage afterwards.
+//
+// Calls over a hard budget fail with a CodeLLMBudget error without reaching
+// the provider. Calls over a soft budget with a DowngradeModel are sent
+// with that model instead of the requested one.
+type LLMClient struct {
+	inner   llm.Client
+	tracker *Tracker
+}
+
+// NewLLMClient wraps an LLM client
+func NewLLMClient(inner llm.Client, tracker *Tracker) *LLMClient {
+	return &LLMClient{
+		inner:   inner,
+		tracker: tracker,
+	}
+}
+
+// Complete checks the budgets, completes the request and records its usage
+func (c *LLMClient) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	decision := c.tracker.Check(ctx)
+	if err := decision.Err(); err != nil {
+		return nil, err
+	}
+	if decision.Action == ActionDowngrade && req.Model != decision.Model {
+		downgraded := *req
+		downgraded.Model = decision.Model
+		req = &downgraded
+	}
+
+	resp, err := c.inner.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.record(ctx, req.Model, resp)
+	return resp, nil
+}
+
+// Chat checks the budgets, sends the messages and records the usage.
+// A downgraded chat is sent through Complete, which carries the model.
+func (c *LLMClient) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	decision := c.tracker.Check(ctx)
+	if err := decision.Err(); err != nil {
+		return nil, err
+	}
+	if decision.Action == ActionDowngrade {
+		req := &llm.CompletionRequest{Messages: messages, Model: decision.Model}
+		resp, err := c.inner.Complete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		c.record(ctx, req.Model, resp)
+		return resp, nil
+	}
+
+	resp, err := c.inner.Chat(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	c.record(ctx, "", resp)
+	return resp, nil
+}
+
+// Provider returns the provider of the wrapped client
+func (c *LLMClient) Provider() constants.Provider {
+	return c.inner.Provider()
+}
+
+// IsAvailable checks if the wrapped client is available
+func (c *LLMClient) IsAvailable() bool {
+	return c.inner.IsAvailable()
+}
+
+// record prices the response; the model reported by the provider wins over
+// the requested one since aliases resolve to dated versions
+func (c *LLMClient) record(ctx context.Context, model string, resp *llm.CompletionResponse) {
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	c.tracker.Record(ctx, string(c.inner.Provider()), model, responseUsage(resp))
+}
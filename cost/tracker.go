@@ -0,0 +1,295 @@
+package cost
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/observability"
+)
+
+// Dimension is an axis spend is aggregated along
+type Dimension string
+
+const (
+	// DimensionGlobal aggregates all calls of the tracker
+	DimensionGlobal Dimension = "global"
+	// DimensionSession aggregates by Attribution.SessionID
+	DimensionSession Dimension = "session"
+	// DimensionUser aggregates by Attribution.UserID
+	DimensionUser Dimension = "user"
+	// DimensionAgent aggregates by Attribution.AgentName
+	DimensionAgent Dimension = "agent"
+	// DimensionTenant aggregates by Attribution.TenantID
+	DimensionTenant Dimension = "tenant"
+)
+
+// dimensions are the keyed dimensions a Record is aggregated under
+var dimensions = []Dimension{DimensionSession, DimensionUser, DimensionAgent, DimensionTenant}
+
+// Attribution identifies who a call is billed to
+type Attribution struct {
+	SessionID string `json:"session_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	AgentName string `json:"agent_name,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+}
+
+// Key returns the attribution value for a dimension
+func (a Attribution) Key(dim Dimension) string {
+	switch dim {
+	case DimensionSession:
+		return a.SessionID
+	case DimensionUser:
+		return a.UserID
+	case DimensionAgent:
+		return a.AgentName
+	case DimensionTenant:
+		return a.TenantID
+	default:
+		return ""
+	}
+}
+
+// merge fills empty fields from other
+func (a Attribution) merge(other Attribution) Attribution {
+	if a.SessionID == "" {
+		a.SessionID = other.SessionID
+	}
+	if a.UserID == "" {
+		a.UserID = other.UserID
+	}
+	if a.AgentName == "" {
+		a.AgentName = other.AgentName
+	}
+	if a.TenantID == "" {
+		a.TenantID = other.TenantID
+	}
+	return a
+}
+
+// attributionContextKey is the context key carrying the Attribution
+type attributionContextKey struct{}
+
+// WithAttribution returns a context billing calls to the attribution.
+// Empty fields are inherited from an attribution already in the context,
+// so an agent can add its name to the session set by the caller.
+func WithAttribution(ctx context.Context, attribution Attribution) context.Context {
+	return context.WithValue(ctx, attributionContextKey{}, attribution.merge(AttributionFromContext(ctx)))
+}
+
+// AttributionFromContext returns the attribution carried by the context.
+// The user defaults to the data subject set with interfaces.WithSubject.
+func AttributionFromContext(ctx context.Context) Attribution {
+	var attribution Attribution
+	if ctx == nil {
+		return attribution
+	}
+	if a, ok := ctx.Value(attributionContextKey{}).(Attribution); ok {
+		attribution = a
+	}
+	if attribution.UserID == "" {
+		attribution.UserID, _ = interfaces.SubjectFromContext(ctx)
+	}
+	return attribution
+}
+
+// Record is one priced LLM call
+type Record struct {
+	Time           time.Time             `json:"time"`
+	Provider       string                `json:"provider,omitempty"`
+	Model          string                `json:"model"`
+	Usage          interfaces.TokenUsage `json:"usage"`
+	Cost           float64               `json:"cost"`
+	Priced         bool                  `json:"priced"` // false if the model is not in the catalog
+	CatalogVersion string                `json:"catalog_version"`
+	Attribution
+}
+
+// Totals is the aggregated usage and spend of a dimension key
+type Totals struct {
+	Calls int                   `json:"calls"`
+	Usage interfaces.TokenUsage `json:"usage"`
+	Cost  float64               `json:"cost"`
+}
+
+func (t *Totals) add(record *Record) {
+	t.Calls++
+	t.Usage.Add(&record.Usage)
+	t.Cost += record.Cost
+}
+
+// Option configures a Tracker
+type Option func(*Tracker)
+
+// WithCatalog sets the price catalog. Defaults to DefaultCatalog.
+func WithCatalog(catalog *Catalog) Option {
+	return func(t *Tracker) {
+		t.catalog = catalog
+	}
+}
+
+// WithBudget adds a budget
+func WithBudget(budget Budget) Option {
+	return func(t *Tracker) {
+		t.budgets = append(t.budgets, budget)
+	}
+}
+
+// WithBudgetHandler sets a function called whenever a call is made while a
+// soft or hard limit is exceeded, e.g. to alert or log
+func WithBudgetHandler(handler func(ctx context.Context, decision Decision)) Option {
+	return func(t *Tracker) {
+		t.onBudget = handler
+	}
+}
+
+// WithRecordHandler sets a function called with every priced call, e.g. to
+// persist records for billing
+func WithRecordHandler(handler func(ctx context.Context, record Record)) Option {
+	return func(t *Tracker) {
+		t.onRecord = handler
+	}
+}
+
+// WithoutMetrics disables exporting spend to observability.Metrics
+func WithoutMetrics() Option {
+	return func(t *Tracker) {
+		t.metrics = false
+	}
+}
+
+// Tracker prices LLM calls, aggregates spend and enforces budgets.
+// It is safe for concurrent use.
+type Tracker struct {
+	catalog  *Catalog
+	budgets  []Budget
+	onBudget func(ctx context.Context, decision Decision)
+	onRecord func(ctx context.Context, record Record)
+	metrics  bool
+
+	mu     sync.RWMutex
+	global Totals
+	totals map[Dimension]map[string]*Totals
+}
+
+// NewTracker creates a tracker
+func NewTracker(opts ...Option) *Tracker {
+	t := &Tracker{
+		metrics: true,
+		totals:  make(map[Dimension]map[string]*Totals),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.catalog == nil {
+		t.catalog = DefaultCatalog()
+	}
+	for _, dim := range dimensions {
+		t.totals[dim] = make(map[string]*Totals)
+	}
+	return t
+}
+
+// Catalog returns the price catalog
+func (t *Tracker) Catalog() *Catalog {
+	return t.catalog
+}
+
+// Record prices a call and adds it to the totals of its attribution.
+//
+// The call has already happened, so it is always recorded; the returned
+// Decision tells the caller whether the next call may proceed. Unlike
+// Check it does not notify the budget handler.
+func (t *Tracker) Record(ctx context.Context, provider, model string, usage *interfaces.TokenUsage) (Record, Decision) {
+	record := Record{
+		Time:           time.Now(),
+		Provider:       provider,
+		Model:          model,
+		CatalogVersion: t.catalog.Version(),
+		Attribution:    AttributionFromContext(ctx),
+	}
+	if usage != nil {
+		record.Usage = *usage
+		if record.Usage.TotalTokens == 0 {
+			record.Usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+	}
+	if price, ok := t.catalog.Lookup(provider, model); ok {
+		record.Cost = price.Cost(&record.Usage)
+		record.Priced = true
+	}
+
+	t.mu.Lock()
+	t.global.add(&record)
+	for _, dim := range dimensions {
+		key := record.Attribution.Key(dim)
+		if key == "" {
+			continue
+		}
+		totals, ok := t.totals[dim][key]
+		if !ok {
+			totals = &Totals{}
+			t.totals[dim][key] = totals
+		}
+		totals.add(&record)
+	}
+	t.mu.Unlock()
+
+	if t.metrics {
+		cached := min(record.Usage.CachedTokens, record.Usage.PromptTokens)
+		observability.RecordLLMCost(provider, model, record.TenantID, record.AgentName, record.Cost,
+			record.Usage.PromptTokens-cached, record.Usage.CompletionTokens, cached)
+	}
+	if t.onRecord != nil {
+		t.onRecord(ctx, record)
+	}
+
+	return record, t.evaluate(ctx)
+}
+
+// Total returns the totals of all calls
+func (t *Tracker) Total() Totals {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.global
+}
+
+// Totals returns the totals of a dimension key, e.g.
+// Totals(DimensionSession, "s-1")
+func (t *Tracker) Totals(dim Dimension, key string) Totals {
+	if dim == DimensionGlobal {
+		return t.Total()
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if totals, ok := t.totals[dim][key]; ok {
+		return *totals
+	}
+	return Totals{}
+}
+
+// Breakdown returns the totals of every key of a dimension
+func (t *Tracker) Breakdown(dim Dimension) map[string]Totals {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	breakdown := make(map[string]Totals, len(t.totals[dim]))
+	for key, totals := range t.totals[dim] {
+		breakdown[key] = *totals
+	}
+	return breakdown
+}
+
+// Reset clears all totals, e.g. at the start of a billing period
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.global = Totals{}
+	for _, dim := range dimensions {
+		t.totals[dim] = make(map[string]*Totals)
+	}
+}
@@ -0,0 +1,220 @@
+package document
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// CSVLoader CSV/TSV 文件加载器
+//
+// 每一行生成一个文档,可指定内容列和映射为元数据的列
+type CSVLoader struct {
+	*BaseDocumentLoader
+	filePath        string
+	delimiter       rune
+	contentColumns  []string
+	metadataColumns map[string]string
+	noHeader        bool
+	sourceColumn    string
+}
+
+// CSVLoaderConfig CSV 加载器配置
+type CSVLoaderConfig struct {
+	FilePath string
+
+	// Delimiter 字段分隔符,为 0 时根据扩展名推断(.tsv 为制表符,否则为逗号)
+	Delimiter rune
+
+	// ContentColumns 组成文档内容的列,为空时使用所有列
+	ContentColumns []string
+
+	// MetadataColumns 列名到元数据键的映射,值为空时使用列名作为键
+	MetadataColumns map[string]string
+
+	// NoHeader 文件没有表头时设为 true,列名为 column_0、column_1...
+	NoHeader bool
+
+	// SourceColumn 用作文档 source 元数据的列(如 URL 列)
+	SourceColumn string
+
+	Metadata        map[string]interface{}
+	CallbackManager *core.CallbackManager
+}
+
+// NewCSVLoader 创建 CSV 加载器
+func NewCSVLoader(config CSVLoaderConfig) *CSVLoader {
+	if config.Delimiter == 0 {
+		config.Delimiter = ','
+		if strings.EqualFold(filepath.Ext(config.FilePath), ".tsv") {
+			config.Delimiter = '\t'
+		}
+	}
+
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]interface{})
+	}
+
+	config.Metadata["source"] = config.FilePath
+	config.Metadata["source_type"] = "csv"
+
+	return &CSVLoader{
+		BaseDocumentLoader: NewBaseDocumentLoader(config.Metadata, config.CallbackManager),
+		filePath:           config.FilePath,
+		delimiter:          config.Delimiter,
+		contentColumns:     config.ContentColumns,
+		metadataColumns:    config.MetadataColumns,
+		noHeader:           config.NoHeader,
+		sourceColumn:       config.SourceColumn,
+	}
+}
+
+// Load 加载 CSV 文件
+func (l *CSVLoader) Load(ctx context.Context) ([]*interfaces.Document, error) {
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnStart(ctx, map[string]interface{}{
+			"loader":    "csv",
+			"file_path": l.filePath,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := l.load(ctx)
+	if err != nil {
+		if l.callbackManager != nil {
+			_ = l.callbackManager.OnError(ctx, err)
+		}
+		return nil, err
+	}
+
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnEnd(ctx, map[string]interface{}{
+			"num_docs": len(docs),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// load 逐行读取并生成文档
+func (l *CSVLoader) load(ctx context.Context) ([]*interfaces.Document, error) {
+	file, err := os.Open(l.filePath)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to open csv file", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.Comma = l.delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var header []string
+	if !l.noHeader {
+		header, err = reader.Read()
+		if err == io.EOF {
+			return []*interfaces.Document{}, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(errors.CodeInvalidParam, "failed to read csv header", err)
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		}
+	}
+
+	for _, col := range append(append([]string{}, l.contentColumns...), l.sourceColumn) {
+		if col != "" && header != nil && indexOf(header, col) < 0 {
+			return nil, errors.New(errors.CodeInvalidParam, fmt.Sprintf("csv column %q not found", col))
+		}
+	}
+
+	docs := make([]*interfaces.Document, 0)
+	for row := 0; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(errors.CodeInvalidParam, fmt.Sprintf("failed to read csv row %d", row+1), err)
+		}
+
+		columns := header
+		if columns == nil || len(columns) < len(record) {
+			columns = make([]string, len(record))
+			for i := range record {
+				if header != nil && i < len(header) {
+					columns[i] = header[i]
+				} else {
+					columns[i] = fmt.Sprintf("column_%d", i)
+				}
+			}
+		}
+
+		values := make(map[string]string, len(record))
+		for i, value := range record {
+			values[columns[i]] = strings.TrimSpace(value)
+		}
+
+		contentCols := l.contentColumns
+		if len(contentCols) == 0 {
+			contentCols = columns[:len(record)]
+		}
+
+		lines := make([]string, 0, len(contentCols))
+		for _, col := range contentCols {
+			lines = append(lines, col+": "+values[col])
+		}
+
+		metadata := copyMetadata(l.GetMetadata())
+		metadata["row"] = row
+		for col, key := range l.metadataColumns {
+			if key == "" {
+				key = col
+			}
+			if value, ok := values[col]; ok {
+				metadata[key] = value
+			}
+		}
+		if l.sourceColumn != "" {
+			metadata["source"] = values[l.sourceColumn]
+		}
+
+		docs = append(docs, retrieval.NewDocument(strings.Join(lines, "\n"), metadata))
+	}
+
+	return docs, nil
+}
+
+// LoadAndSplit 加载并分割
+func (l *CSVLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([]*interfaces.Document, error) {
+	return l.BaseDocumentLoader.LoadAndSplit(ctx, l, splitter)
+}
+
+// indexOf 查找字符串在切片中的位置
+func indexOf(items []string, target string) int {
+	for i, item := range items {
+		if item == target {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,303 @@
+package document
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// DOCXLoader Word (.docx) 文件加载器
+//
+// 解压 docx 包并解析 word/document.xml,保留段落、标题和表格结构
+type DOCXLoader struct {
+	*BaseDocumentLoader
+	filePath string
+}
+
+// DOCXLoaderConfig DOCX 加载器配置
+type DOCXLoaderConfig struct {
+	FilePath        string
+	Metadata        map[string]interface{}
+	CallbackManager *core.CallbackManager
+}
+
+// NewDOCXLoader 创建 DOCX 加载器
+func NewDOCXLoader(config DOCXLoaderConfig) *DOCXLoader {
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]interface{})
+	}
+
+	config.Metadata["source"] = config.FilePath
+	config.Metadata["source_type"] = "docx"
+
+	return &DOCXLoader{
+		BaseDocumentLoader: NewBaseDocumentLoader(config.Metadata, config.CallbackManager),
+		filePath:           config.FilePath,
+	}
+}
+
+// Load 加载 DOCX 文件
+func (l *DOCXLoader) Load(ctx context.Context) ([]*interfaces.Document, error) {
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnStart(ctx, map[string]interface{}{
+			"loader":    "docx",
+			"file_path": l.filePath,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := l.load()
+	if err != nil {
+		if l.callbackManager != nil {
+			_ = l.callbackManager.OnError(ctx, err)
+		}
+		return nil, err
+	}
+
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnEnd(ctx, map[string]interface{}{
+			"num_docs":       1,
+			"content_length": len(doc.PageContent),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*interfaces.Document{doc}, nil
+}
+
+// load 解析 docx 包
+func (l *DOCXLoader) load() (*interfaces.Document, error) {
+	archive, err := zip.OpenReader(l.filePath)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "failed to open docx archive", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	var body, coreProps *zip.File
+	for _, f := range archive.File {
+		switch f.Name {
+		case "word/document.xml":
+			body = f
+		case "docProps/core.xml":
+			coreProps = f
+		}
+	}
+
+	if body == nil {
+		return nil, errors.New(errors.CodeInvalidParam, "word/document.xml not found in docx archive")
+	}
+
+	reader, err := body.Open()
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to open document.xml", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	parsed, err := parseDOCXBody(reader)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "failed to parse document.xml", err)
+	}
+
+	metadata := copyMetadata(l.GetMetadata())
+	if coreProps != nil {
+		if rc, err := coreProps.Open(); err == nil {
+			for key, value := range parseDOCXCoreProperties(rc) {
+				metadata[key] = value
+			}
+			_ = rc.Close()
+		}
+	}
+	if len(parsed.headings) > 0 {
+		metadata["headings"] = parsed.headings
+		if _, ok := metadata["title"]; !ok {
+			metadata["title"] = strings.TrimLeft(parsed.headings[0], "# ")
+		}
+	}
+	metadata["num_tables"] = parsed.tables
+
+	return retrieval.NewDocument(parsed.text, metadata), nil
+}
+
+// LoadAndSplit 加载并分割
+func (l *DOCXLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([]*interfaces.Document, error) {
+	return l.BaseDocumentLoader.LoadAndSplit(ctx, l, splitter)
+}
+
+// docxContent 解析结果
+type docxContent struct {
+	text     string
+	headings []string
+	tables   int
+}
+
+// parseDOCXBody 流式解析 WordprocessingML
+//
+// 段落(w:p)之间以空行分隔;Heading 样式的段落转换为 "#" 前缀;
+// 表格(w:tbl)的每一行输出为 "| a | b |"
+func parseDOCXBody(r io.Reader) (*docxContent, error) {
+	decoder := xml.NewDecoder(r)
+
+	result := &docxContent{}
+	var blocks []string
+	var paragraph strings.Builder
+	headingLevel := 0
+	tableDepth := 0
+	var row []string
+	var tableLines []string
+	inText := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				headingLevel = 0
+			case "pStyle":
+				headingLevel = docxHeadingLevel(xmlAttr(t, "val"))
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteByte('\t')
+			case "br", "cr":
+				paragraph.WriteByte('\n')
+			case "tbl":
+				tableDepth++
+				if tableDepth == 1 {
+					tableLines = nil
+					result.tables++
+				}
+			case "tr":
+				if tableDepth == 1 {
+					row = nil
+				}
+			case "tc":
+				if tableDepth == 1 {
+					row = append(row, "")
+				}
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				if tableDepth > 0 {
+					// 表格单元格内的段落追加到当前单元格
+					if len(row) > 0 && text != "" {
+						if row[len(row)-1] != "" {
+							row[len(row)-1] += " "
+						}
+						row[len(row)-1] += text
+					}
+					paragraph.Reset()
+					continue
+				}
+				if text == "" {
+					continue
+				}
+				if headingLevel > 0 {
+					text = strings.Repeat("#", headingLevel) + " " + text
+					result.headings = append(result.headings, text)
+				}
+				blocks = append(blocks, text)
+				paragraph.Reset()
+			case "tr":
+				if tableDepth == 1 && len(row) > 0 {
+					tableLines = append(tableLines, "| "+strings.Join(row, " | ")+" |")
+				}
+			case "tbl":
+				tableDepth--
+				if tableDepth == 0 && len(tableLines) > 0 {
+					blocks = append(blocks, strings.Join(tableLines, "\n"))
+				}
+			}
+		}
+	}
+
+	result.text = strings.Join(blocks, "\n\n")
+	return result, nil
+}
+
+// docxHeadingLevel 从段落样式推断标题级别
+func docxHeadingLevel(style string) int {
+	lower := strings.ToLower(style)
+	if lower == "title" {
+		return 1
+	}
+	if strings.HasPrefix(lower, "heading") {
+		level := strings.TrimPrefix(lower, "heading")
+		if len(level) == 1 && level[0] >= '1' && level[0] <= '6' {
+			return int(level[0] - '0')
+		}
+	}
+	return 0
+}
+
+// xmlAttr 获取属性值(忽略命名空间)
+func xmlAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseDOCXCoreProperties 解析 docProps/core.xml 中的标题、作者等信息
+func parseDOCXCoreProperties(r io.Reader) map[string]interface{} {
+	keys := map[string]string{
+		"title":          "title",
+		"creator":        "author",
+		"subject":        "subject",
+		"keywords":       "keywords",
+		"created":        "created",
+		"modified":       "modified",
+		"lastModifiedBy": "last_modified_by",
+	}
+
+	result := make(map[string]interface{})
+	decoder := xml.NewDecoder(r)
+	current := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return result
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			current = keys[t.Name.Local]
+		case xml.CharData:
+			if current != "" {
+				if value := strings.TrimSpace(string(t)); value != "" {
+					result[current] = value
+				}
+			}
+		case xml.EndElement:
+			current = ""
+		}
+	}
+}
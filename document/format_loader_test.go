@@ -0,0 +1,405 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestPDF 构造一个两页的 PDF:第一页使用 Flate 压缩的内容流,
+// 第二页使用带 ToUnicode 映射的 Type0 字体
+func buildTestPDF(t *testing.T) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write([]byte("BT /F1 12 Tf 72 720 Td (Hello ) Tj [(PDF) -300 (World)] TJ 0 -14 Td (Second \\(line\\)) Tj ET"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	cmap := "begincmap 2 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"1 beginbfchar <0001> <4E2D> endbfchar\n" +
+		"1 beginbfrange <0002> <0003> <6587> endbfrange endcmap"
+	page2 := "BT /F2 12 Tf 72 720 Td <000100020003> Tj ET"
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 8 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Test /ToUnicode 9 0 R >>",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(page2), page2),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(cmap), cmap),
+		"<< /Title (Test Report) /Author (QA) >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R /Info 10 0 R /Size 11 >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestPDFLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(path, buildTestPDF(t), 0o644))
+
+	docs, err := NewPDFLoader(PDFLoaderConfig{FilePath: path}).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "Hello PDF World\nSecond (line)", docs[0].PageContent)
+	assert.Equal(t, "中文斈", docs[1].PageContent)
+	assert.Equal(t, 1, docs[0].Metadata["page"])
+	assert.Equal(t, 2, docs[1].Metadata["page"])
+	assert.Equal(t, 2, docs[0].Metadata["total_pages"])
+	assert.Equal(t, "Test Report", docs[0].Metadata["title"])
+	assert.Equal(t, "QA", docs[0].Metadata["author"])
+
+	merged, err := NewPDFLoader(PDFLoaderConfig{FilePath: path, MergePages: true}).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, merged, 1)
+	assert.Contains(t, merged[0].PageContent, "Hello PDF World")
+	assert.Contains(t, merged[0].PageContent, "中文")
+}
+
+func TestPDFLoaderInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("not a pdf"), 0o644))
+
+	_, err := NewPDFLoader(PDFLoaderConfig{FilePath: path}).Load(context.Background())
+	assert.Error(t, err)
+}
+
+func TestParsePDFMalformedObjectStream(t *testing.T) {
+	build := func(first, offset int) []byte {
+		content := fmt.Sprintf("5 %d << /Type /Catalog >>", offset)
+		var buf bytes.Buffer
+		buf.WriteString("%PDF-1.5\n")
+		fmt.Fprintf(&buf, "1 0 obj\n<< /Type /ObjStm /N 1 /First %d /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+			first, len(content), content)
+		buf.WriteString("trailer\n<< /Root 5 0 R >>\n%%EOF\n")
+		return buf.Bytes()
+	}
+
+	_, err := parsePDF(build(-3, 0))
+	assert.Error(t, err)
+
+	_, err = parsePDF(build(4, -5))
+	assert.Error(t, err)
+
+	doc, err := parsePDF(build(4, 0))
+	require.NoError(t, err)
+	assert.NotNil(t, doc.objects[5])
+}
+
+func TestInflatePDFLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write(make([]byte, maxPDFStreamSize+1))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	_, err = inflatePDF(compressed.Bytes())
+	assert.Error(t, err)
+}
+
+func TestHTMLLoader(t *testing.T) {
+	html := `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>Guide</title>
+  <meta name="description" content="Setup guide">
+  <script>var x = 1;</script>
+</head>
+<body>
+  <nav><a href="/">Home</a> | <a href="/docs">Docs</a></nav>
+  <main>
+    <h1>Install</h1>
+    <p>Run the <b>installer</b> first.</p>
+    <ul><li>Step one</li><li>Step two</li></ul>
+    <h2>Options</h2>
+    <table>
+      <tr><th>Name</th><th>Default</th></tr>
+      <tr><td>port</td><td>8080</td></tr>
+    </table>
+  </main>
+  <footer>Copyright</footer>
+</body>
+</html>`
+
+	path := filepath.Join(t.TempDir(), "guide.html")
+	require.NoError(t, os.WriteFile(path, []byte(html), 0o644))
+
+	docs, err := NewHTMLLoader(HTMLLoaderConfig{FilePath: path}).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+
+	expected := "# Install\n\nRun the installer first.\n\n- Step one\n\n- Step two\n\n## Options\n\n" +
+		"| Name | Default |\n| port | 8080 |"
+	assert.Equal(t, expected, docs[0].PageContent)
+	assert.NotContains(t, docs[0].PageContent, "Home")
+	assert.NotContains(t, docs[0].PageContent, "Copyright")
+
+	assert.Equal(t, "Guide", docs[0].Metadata["title"])
+	assert.Equal(t, "Setup guide", docs[0].Metadata["description"])
+	assert.Equal(t, "en", docs[0].Metadata["language"])
+	assert.Equal(t, []string{"# Install", "## Options"}, docs[0].Metadata["headings"])
+	assert.Equal(t, 1, docs[0].Metadata["num_tables"])
+}
+
+func TestCSVLoader(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "faq.csv")
+	csvData := "\ufeffquestion,answer,url\n" +
+		"How to install?,\"Run make, then install\",https://a\n" +
+		"How to test?,go test,https://b\n"
+	require.NoError(t, os.WriteFile(path, []byte(csvData), 0o644))
+
+	docs, err := NewCSVLoader(CSVLoaderConfig{
+		FilePath:        path,
+		ContentColumns:  []string{"question", "answer"},
+		MetadataColumns: map[string]string{"question": "q"},
+		SourceColumn:    "url",
+	}).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "question: How to install?\nanswer: Run make, then install", docs[0].PageContent)
+	assert.Equal(t, "https://a", docs[0].Metadata["source"])
+	assert.Equal(t, "How to install?", docs[0].Metadata["q"])
+	assert.Equal(t, 1, docs[1].Metadata["row"])
+
+	_, err = NewCSVLoader(CSVLoaderConfig{
+		FilePath:       path,
+		ContentColumns: []string{"missing"},
+	}).Load(context.Background())
+	assert.Error(t, err)
+
+	tsvPath := filepath.Join(dir, "data.tsv")
+	require.NoError(t, os.WriteFile(tsvPath, []byte("a\tb\n1\t2\n"), 0o644))
+
+	docs, err = NewCSVLoader(CSVLoaderConfig{FilePath: tsvPath, NoHeader: true}).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "column_0: a\ncolumn_1: b", docs[0].PageContent)
+}
+
+// buildTestDOCX 构造包含标题、段落和表格的 docx 文件
+func buildTestDOCX(t *testing.T, path string) {
+	t.Helper()
+
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+  <w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Overview</w:t></w:r></w:p>
+  <w:p><w:r><w:t xml:space="preserve">First </w:t></w:r><w:r><w:t>paragraph.</w:t></w:r></w:p>
+  <w:tbl>
+    <w:tr><w:tc><w:p><w:r><w:t>Key</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Value</w:t></w:r></w:p></w:tc></w:tr>
+    <w:tr><w:tc><w:p><w:r><w:t>a</w:t></w:r></w:p></w:tc><w:tc><w:p/></w:tc></w:tr>
+  </w:tbl>
+  <w:p><w:r><w:t>Line</w:t><w:br/><w:t>break</w:t></w:r></w:p>
+</w:body>
+</w:document>`
+
+	core := `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"
+  xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <dc:title>Design Doc</dc:title>
+  <dc:creator>Alice</dc:creator>
+</cp:coreProperties>`
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	zw := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"word/document.xml": body,
+		"docProps/core.xml": core,
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
+
+func TestDOCXLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "design.docx")
+	buildTestDOCX(t, path)
+
+	docs, err := NewDOCXLoader(DOCXLoaderConfig{FilePath: path}).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+
+	expected := "# Overview\n\nFirst paragraph.\n\n| Key | Value |\n| a |  |\n\nLine\nbreak"
+	assert.Equal(t, expected, docs[0].PageContent)
+	assert.Equal(t, "Design Doc", docs[0].Metadata["title"])
+	assert.Equal(t, "Alice", docs[0].Metadata["author"])
+	assert.Equal(t, []string{"# Overview"}, docs[0].Metadata["headings"])
+	assert.Equal(t, 1, docs[0].Metadata["num_tables"])
+
+	notZip := filepath.Join(t.TempDir(), "bad.docx")
+	require.NoError(t, os.WriteFile(notZip, []byte("plain"), 0o644))
+	_, err = NewDOCXLoader(DOCXLoaderConfig{FilePath: notZip}).Load(context.Background())
+	assert.Error(t, err)
+}
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestGitLoader(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".gitignore":           "*.log\n/build/\nvendor/\n!keep.log\ndocs/**/draft.md\n",
+		".git/HEAD":            "ref: refs/heads/main\n",
+		".git/refs/heads/main": "abc123\n",
+		".git/config":          "[core]\n",
+		".env":                 "SECRET=1\n",
+		"main.go":              "package main\n",
+		"debug.log":            "noise\n",
+		"keep.log":             "important\n",
+		"build/out.txt":        "artifact\n",
+		"pkg/build/gen.go":     "package build\n",
+		"pkg/vendor/lib.go":    "package lib\n",
+		"pkg/.gitignore":       "*.tmp\n",
+		"pkg/cache.tmp":        "tmp\n",
+		"pkg/util.py":          "print(1)\n",
+		"docs/a/b/draft.md":    "draft\n",
+		"docs/guide.md":        "# Guide\n",
+		"image.bin":            "\x00\x01\x02",
+		"Dockerfile":           "FROM scratch\n",
+	})
+
+	docs, err := NewGitLoader(GitLoaderConfig{RepoPath: root}).Load(context.Background())
+	require.NoError(t, err)
+
+	byPath := make(map[string]map[string]interface{})
+	paths := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		p := doc.Metadata["path"].(string)
+		byPath[p] = doc.Metadata
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	assert.Equal(t, []string{"Dockerfile", "docs/guide.md", "keep.log", "main.go", "pkg/build/gen.go", "pkg/util.py"}, paths)
+	assert.Equal(t, "go", byPath["main.go"]["language"])
+	assert.Equal(t, "python", byPath["pkg/util.py"]["language"])
+	assert.Equal(t, "dockerfile", byPath["Dockerfile"]["language"])
+	assert.Equal(t, "main", byPath["main.go"]["branch"])
+	assert.Equal(t, "abc123", byPath["main.go"]["commit"])
+
+	filtered, err := NewGitLoader(GitLoaderConfig{
+		RepoPath: root,
+		Include:  []string{"*.go", "*.py"},
+		Exclude:  []string{"pkg/build/*"},
+	}).Load(context.Background())
+	require.NoError(t, err)
+	paths = paths[:0]
+	for _, doc := range filtered {
+		paths = append(paths, doc.Metadata["path"].(string))
+	}
+	sort.Strings(paths)
+	assert.Equal(t, []string{"main.go", "pkg/util.py"}, paths)
+}
+
+func TestGitIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"*.o", "a/b/c.o", false, true},
+		{"/root.txt", "root.txt", false, true},
+		{"/root.txt", "sub/root.txt", false, false},
+		{"logs/", "logs", true, true},
+		{"logs/", "logs", false, false},
+		{"a/**/z", "a/z", false, true},
+		{"a/**/z", "a/b/c/z", false, true},
+		{"**/tmp", "x/y/tmp", true, true},
+		{"doc/*.txt", "doc/a.txt", false, true},
+		{"doc/*.txt", "doc/sub/a.txt", false, false},
+		{"file[0-9].txt", "file7.txt", false, true},
+		{"\\#hash", "#hash", false, true},
+	}
+
+	for _, tt := range tests {
+		ignore := newGitIgnore()
+		ignore.addPattern(tt.pattern, "")
+		assert.Equal(t, tt.ignored, ignore.match(tt.path, tt.isDir), "pattern %q path %q", tt.pattern, tt.path)
+	}
+
+	ignore := newGitIgnore()
+	ignore.addPattern("*.md", "")
+	ignore.addPattern("!README.md", "")
+	assert.True(t, ignore.match("notes.md", false))
+	assert.False(t, ignore.match("README.md", false))
+
+	nested := newGitIgnore()
+	nested.addPattern("/gen", "pkg")
+	assert.True(t, nested.match("pkg/gen", false))
+	assert.False(t, nested.match("gen", false))
+}
+
+func TestDirectoryLoaderDispatchByExtension(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "report.pdf"), buildTestPDF(t), 0o644))
+	buildTestDOCX(t, filepath.Join(root, "design.docx"))
+	writeTestFiles(t, root, map[string]string{
+		"page.html": "<html><body><p>Hello HTML</p></body></html>",
+		"rows.csv":  "name\nalpha\nbeta\n",
+		"notes.txt": "plain text",
+		"doc.md":    "# Title\n\nBody",
+	})
+
+	docs, err := NewDirectoryLoader(DirectoryLoaderConfig{DirPath: root}).Load(context.Background())
+	require.NoError(t, err)
+
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		sourceType, _ := doc.Metadata["source_type"].(string)
+		counts[sourceType]++
+	}
+	assert.Equal(t, 2, counts["pdf"])
+	assert.Equal(t, 1, counts["docx"])
+	assert.Equal(t, 1, counts["html"])
+	assert.Equal(t, 2, counts["csv"])
+	assert.Equal(t, 1, counts["markdown"])
+	assert.Equal(t, 1, counts[""], "unknown extensions fall back to the text loader")
+
+	custom := NewDirectoryLoader(DirectoryLoaderConfig{
+		DirPath: root,
+		Glob:    "*.pdf",
+		ExtensionLoaders: map[string]func(string) DocumentLoader{
+			".pdf": func(path string) DocumentLoader {
+				return NewPDFLoader(PDFLoaderConfig{FilePath: path, MergePages: true})
+			},
+		},
+	})
+	docs, err = custom.Load(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
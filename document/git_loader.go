@@ -0,0 +1,450 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// GitLoader Git 工作区加载器
+//
+// 遍历仓库工作区中的源文件,遵循 .gitignore 和 .git/info/exclude 规则,
+// 跳过二进制文件,并记录相对路径、语言等元数据
+type GitLoader struct {
+	*BaseDocumentLoader
+	repoPath      string
+	include       []string
+	exclude       []string
+	maxFileSize   int64
+	includeHidden bool
+}
+
+// GitLoaderConfig Git 加载器配置
+type GitLoaderConfig struct {
+	RepoPath string
+
+	// Include 只加载匹配的文件(匹配文件名或相对路径的 glob),为空时加载全部
+	Include []string
+
+	// Exclude 额外排除的文件(glob),在 .gitignore 之后生效
+	Exclude []string
+
+	// MaxFileSize 单个文件的最大字节数,默认 1MB
+	MaxFileSize int64
+
+	// IncludeHidden 是否加载以 . 开头的文件和目录(.git 始终跳过)
+	IncludeHidden bool
+
+	Metadata        map[string]interface{}
+	CallbackManager *core.CallbackManager
+}
+
+// NewGitLoader 创建 Git 工作区加载器
+func NewGitLoader(config GitLoaderConfig) *GitLoader {
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = 1 << 20
+	}
+
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]interface{})
+	}
+
+	config.Metadata["repo_path"] = config.RepoPath
+	config.Metadata["source_type"] = "git"
+
+	return &GitLoader{
+		BaseDocumentLoader: NewBaseDocumentLoader(config.Metadata, config.CallbackManager),
+		repoPath:           config.RepoPath,
+		include:            config.Include,
+		exclude:            config.Exclude,
+		maxFileSize:        config.MaxFileSize,
+		includeHidden:      config.IncludeHidden,
+	}
+}
+
+// Load 加载仓库中的文件
+func (l *GitLoader) Load(ctx context.Context) ([]*interfaces.Document, error) {
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnStart(ctx, map[string]interface{}{
+			"loader":    "git",
+			"repo_path": l.repoPath,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := l.load(ctx)
+	if err != nil {
+		if l.callbackManager != nil {
+			_ = l.callbackManager.OnError(ctx, err)
+		}
+		return nil, err
+	}
+
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnEnd(ctx, map[string]interface{}{
+			"num_docs": len(docs),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// load 遍历工作区
+func (l *GitLoader) load(ctx context.Context) ([]*interfaces.Document, error) {
+	root, err := filepath.Abs(l.repoPath)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "invalid repository path", err)
+	}
+
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, errors.New(errors.CodeInvalidParam, "repository path is not a directory: "+l.repoPath)
+	}
+
+	baseMetadata := copyMetadata(l.GetMetadata())
+	if branch, commit := readGitHead(root); branch != "" || commit != "" {
+		if branch != "" {
+			baseMetadata["branch"] = branch
+		}
+		if commit != "" {
+			baseMetadata["commit"] = commit
+		}
+	}
+
+	ignore := newGitIgnore()
+	ignore.addFile(filepath.Join(root, ".git", "info", "exclude"), "")
+
+	docs := make([]*interfaces.Document, 0)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel == "." {
+				ignore.addFile(filepath.Join(p, ".gitignore"), "")
+				return nil
+			}
+			if d.Name() == ".git" || (!l.includeHidden && strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			if ignore.match(rel, true) {
+				return filepath.SkipDir
+			}
+			ignore.addFile(filepath.Join(p, ".gitignore"), rel)
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if !l.includeHidden && strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if ignore.match(rel, false) || !l.selected(rel) {
+			return nil
+		}
+
+		doc := l.loadFile(p, rel, baseMetadata)
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to walk repository", err)
+	}
+
+	return docs, nil
+}
+
+// selected 检查 Include/Exclude 规则
+func (l *GitLoader) selected(rel string) bool {
+	base := path.Base(rel)
+	for _, pattern := range l.exclude {
+		if globMatch(pattern, rel, base) {
+			return false
+		}
+	}
+	if len(l.include) == 0 {
+		return true
+	}
+	for _, pattern := range l.include {
+		if globMatch(pattern, rel, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch 匹配相对路径或文件名
+func globMatch(pattern, rel, base string) bool {
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, base)
+	return ok
+}
+
+// loadFile 读取单个文件,跳过过大和二进制文件
+func (l *GitLoader) loadFile(absPath, rel string, baseMetadata map[string]interface{}) *interfaces.Document {
+	info, err := os.Stat(absPath)
+	if err != nil || info.Size() > l.maxFileSize {
+		return nil
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil || isBinaryContent(content) {
+		return nil
+	}
+
+	metadata := copyMetadata(baseMetadata)
+	metadata["source"] = absPath
+	metadata["path"] = rel
+	metadata["file_name"] = path.Base(rel)
+	metadata["file_size"] = len(content)
+	metadata["extension"] = strings.ToLower(path.Ext(rel))
+	if lang := DetectLanguage(rel); lang != "" {
+		metadata["language"] = lang
+	}
+
+	return retrieval.NewDocument(string(content), metadata)
+}
+
+// LoadAndSplit 加载并分割
+func (l *GitLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([]*interfaces.Document, error) {
+	return l.BaseDocumentLoader.LoadAndSplit(ctx, l, splitter)
+}
+
+// isBinaryContent 根据前 8000 字节中是否存在 NUL 判断二进制文件(与 git 的启发式一致)
+func isBinaryContent(content []byte) bool {
+	probe := content
+	if len(probe) > 8000 {
+		probe = probe[:8000]
+	}
+	return bytes.IndexByte(probe, 0) >= 0
+}
+
+// languageByExtension 扩展名到语言的映射
+var languageByExtension = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript", ".java": "java",
+	".kt": "kotlin", ".kts": "kotlin", ".scala": "scala", ".rs": "rust", ".c": "c", ".h": "c",
+	".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".hh": "cpp", ".cs": "csharp",
+	".rb": "ruby", ".php": "php", ".swift": "swift", ".m": "objective-c", ".lua": "lua",
+	".sh": "shell", ".bash": "shell", ".zsh": "shell", ".ps1": "powershell", ".sql": "sql",
+	".r": "r", ".dart": "dart", ".ex": "elixir", ".exs": "elixir", ".erl": "erlang",
+	".hs": "haskell", ".clj": "clojure", ".proto": "protobuf", ".md": "markdown",
+	".markdown": "markdown", ".rst": "rst", ".html": "html", ".htm": "html", ".css": "css",
+	".scss": "scss", ".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml",
+	".xml": "xml", ".tf": "terraform", ".vue": "vue", ".svelte": "svelte",
+}
+
+// languageByFileName 特殊文件名到语言的映射
+var languageByFileName = map[string]string{
+	"Dockerfile": "dockerfile", "Makefile": "makefile", "go.mod": "go-mod", "CMakeLists.txt": "cmake",
+}
+
+// DetectLanguage 根据文件路径推断编程语言,未知时返回空字符串
+func DetectLanguage(filePath string) string {
+	base := path.Base(filepath.ToSlash(filePath))
+	if lang, ok := languageByFileName[base]; ok {
+		return lang
+	}
+	return languageByExtension[strings.ToLower(path.Ext(base))]
+}
+
+// readGitHead 读取当前分支和提交
+func readGitHead(root string) (branch, commit string) {
+	gitDir := filepath.Join(root, ".git")
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+
+	ref := strings.TrimSpace(string(head))
+	if !strings.HasPrefix(ref, "ref: ") {
+		return "", ref
+	}
+
+	ref = strings.TrimPrefix(ref, "ref: ")
+	branch = strings.TrimPrefix(ref, "refs/heads/")
+
+	if data, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return branch, strings.TrimSpace(string(data))
+	}
+
+	// 引用可能已被打包到 packed-refs
+	if data, err := os.ReadFile(filepath.Join(gitDir, "packed-refs")); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[1] == ref {
+				return branch, fields[0]
+			}
+		}
+	}
+
+	return branch, ""
+}
+
+// gitIgnoreRule 单条忽略规则
+type gitIgnoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// gitIgnore 分层的 .gitignore 规则集合
+//
+// 规则按读取顺序保存,最后一条匹配的规则决定结果(与 git 一致);
+// 被忽略的目录由调用方直接跳过,因此其中的文件无法被否定规则重新包含
+type gitIgnore struct {
+	rules []gitIgnoreRule
+}
+
+func newGitIgnore() *gitIgnore {
+	return &gitIgnore{}
+}
+
+// addFile 读取 .gitignore 文件,base 为该文件所在目录相对仓库根的路径
+func (g *gitIgnore) addFile(file, base string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		g.addPattern(line, base)
+	}
+}
+
+// addPattern 解析单行规则
+func (g *gitIgnore) addPattern(line, base string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	// 去除未转义的尾随空格
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	rule := gitIgnoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// 包含中间斜杠的模式相对于 .gitignore 所在目录锚定,否则匹配任意层级
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := gitIgnorePatternToRegexp(line)
+	prefix := ""
+	if base != "" {
+		prefix = regexp.QuoteMeta(base) + "/"
+	}
+	if anchored {
+		expr = "^" + prefix + expr + "$"
+	} else {
+		expr = "^" + prefix + "(?:.*/)?" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return
+	}
+	rule.pattern = re
+	g.rules = append(g.rules, rule)
+}
+
+// match 判断相对路径是否被忽略
+func (g *gitIgnore) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// gitIgnorePatternToRegexp 将 gitignore 通配符转换为正则表达式
+func gitIgnorePatternToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				// "**/" 匹配零或多级目录,"/**" 匹配其下所有内容
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					b.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					b.WriteString(".*")
+					i++
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package document
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+)
+
+// GoASTTextSplitter Go 语法树分割器
+//
+// 使用 go/parser 解析源码,以顶层声明(函数、方法、类型、常量和变量组)为最小单元,
+// 声明连同其文档注释保持完整;import 与包声明合并,相邻的小声明合并到 ChunkSize 以内
+type GoASTTextSplitter struct {
+	*BaseTextSplitter
+	fallback *CodeTextSplitter
+}
+
+// GoASTTextSplitterConfig Go 语法树分割器配置
+type GoASTTextSplitterConfig struct {
+	// ChunkSize 块的最大字符数,单个声明超出时整体保留,默认 2000
+	ChunkSize int
+
+	CallbackManager *core.CallbackManager
+}
+
+// NewGoASTTextSplitter 创建 Go 语法树分割器
+func NewGoASTTextSplitter(config GoASTTextSplitterConfig) *GoASTTextSplitter {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 2000
+	}
+
+	baseConfig := BaseTextSplitterConfig{
+		ChunkSize:       config.ChunkSize,
+		ChunkOverlap:    0,
+		CallbackManager: config.CallbackManager,
+		LengthFunction:  utf8.RuneCountInString,
+	}
+
+	return &GoASTTextSplitter{
+		BaseTextSplitter: NewBaseTextSplitter(baseConfig),
+		fallback: NewCodeTextSplitter(CodeTextSplitterConfig{
+			Language:  LanguageGo,
+			ChunkSize: config.ChunkSize,
+		}),
+	}
+}
+
+// goChunk 分割结果
+type goChunk struct {
+	text      string
+	symbols   []string
+	startLine int
+	endLine   int
+}
+
+// SplitText 按顶层声明分割 Go 源码
+//
+// 源码无法解析时回退到 CodeTextSplitter
+func (s *GoASTTextSplitter) SplitText(text string) ([]string, error) {
+	chunks, ok := s.splitGo(text)
+	if !ok {
+		return s.fallback.SplitText(text)
+	}
+
+	result := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		result[i] = chunk.text
+	}
+	return result, nil
+}
+
+// SplitDocuments 分割文档,并在元数据中记录符号名和行号
+func (s *GoASTTextSplitter) SplitDocuments(docs []*interfaces.Document) ([]*interfaces.Document, error) {
+	result := make([]*interfaces.Document, 0)
+
+	for _, doc := range docs {
+		chunks, ok := s.splitGo(doc.PageContent)
+		if !ok {
+			fallbackDocs, err := splitDocumentsWith([]*interfaces.Document{doc}, s.fallback.SplitText)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fallbackDocs...)
+			continue
+		}
+
+		for i, chunk := range chunks {
+			metadata := copyMetadata(doc.Metadata)
+			metadata["chunk_index"] = i
+			metadata["chunk_total"] = len(chunks)
+			metadata["source_id"] = doc.ID
+			metadata["language"] = LanguageGo
+			metadata["start_line"] = chunk.startLine
+			metadata["end_line"] = chunk.endLine
+			if len(chunk.symbols) > 0 {
+				metadata["symbols"] = chunk.symbols
+			}
+
+			result = append(result, retrieval.NewDocument(chunk.text, metadata))
+		}
+	}
+
+	return result, nil
+}
+
+// goDeclSpan 顶层声明在源码中的范围
+type goDeclSpan struct {
+	start, end int
+	symbols    []string
+}
+
+// splitGo 解析源码并按声明合并为块
+func (s *GoASTTextSplitter) splitGo(src string) ([]goChunk, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+
+	tokFile := fset.File(file.Pos())
+	offset := func(pos token.Pos) int { return tokFile.Offset(pos) }
+
+	// 包声明及其之前的构建标签、包注释作为第一个片段
+	spans := []goDeclSpan{{start: 0, end: offset(file.Name.End()), symbols: []string{"package " + file.Name.Name}}}
+
+	for _, decl := range file.Decls {
+		var symbols []string
+
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			symbols = []string{goFuncName(d)}
+		case *ast.GenDecl:
+			symbols = goGenDeclNames(d)
+		}
+
+		// 片段从上一个声明结束处开始,文档注释和游离注释归属于其后的声明
+		start := spans[len(spans)-1].end
+		end := offset(decl.End())
+
+		// 同一行的尾随注释属于该声明
+		if nl := strings.IndexByte(src[end:], '\n'); nl >= 0 {
+			if trailing := strings.TrimSpace(src[end : end+nl]); strings.HasPrefix(trailing, "//") {
+				end += nl
+			}
+		} else if strings.HasPrefix(strings.TrimSpace(src[end:]), "//") {
+			end = len(src)
+		}
+
+		// import 块与包声明合并
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			spans[0].end = end
+			continue
+		}
+
+		spans = append(spans, goDeclSpan{start: start, end: end, symbols: symbols})
+	}
+
+	// 文件末尾的注释并入最后一个片段
+	spans[len(spans)-1].end = len(src)
+
+	var chunks []goChunk
+	var current *goChunk
+	for _, span := range spans {
+		raw := src[span.start:span.end]
+		text := strings.TrimSpace(raw)
+		if text == "" {
+			continue
+		}
+		leading := len(raw) - len(strings.TrimLeft(raw, " \t\r\n"))
+		startLine := tokFile.Line(tokFile.Pos(span.start + leading))
+		endLine := tokFile.Line(tokFile.Pos(span.end))
+
+		if current != nil && s.lengthFunction(current.text)+2+s.lengthFunction(text) <= s.chunkSize {
+			current.text += "\n\n" + text
+			current.symbols = append(current.symbols, span.symbols...)
+			current.endLine = endLine
+			continue
+		}
+
+		if current != nil {
+			chunks = append(chunks, *current)
+		}
+		current = &goChunk{
+			text:      text,
+			symbols:   append([]string(nil), span.symbols...),
+			startLine: startLine,
+			endLine:   endLine,
+		}
+	}
+	if current != nil {
+		chunks = append(chunks, *current)
+	}
+
+	return chunks, true
+}
+
+// goFuncName 返回函数名,方法使用 "Type.Method" 形式
+func goFuncName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	for {
+		switch t := recv.(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.IndexExpr:
+			recv = t.X
+			continue
+		case *ast.IndexListExpr:
+			recv = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		}
+		return fn.Name.Name
+	}
+}
+
+// goGenDeclNames 返回类型、常量和变量声明中的名称
+func goGenDeclNames(decl *ast.GenDecl) []string {
+	var names []string
+	for _, spec := range decl.Specs {
+		switch sp := spec.(type) {
+		case *ast.TypeSpec:
+			names = append(names, sp.Name.Name)
+		case *ast.ValueSpec:
+			for _, name := range sp.Names {
+				if name.Name != "_" {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,321 @@
+package document
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// HTMLLoader HTML 文件加载器
+//
+// 基于 goquery 解析 DOM,提取正文、标题层级和表格
+type HTMLLoader struct {
+	*BaseDocumentLoader
+	filePath        string
+	contentSelector string
+	removeSelectors []string
+}
+
+// HTMLLoaderConfig HTML 加载器配置
+type HTMLLoaderConfig struct {
+	FilePath        string
+	ContentSelector string   // 正文选择器,为空时自动检测 main/article
+	RemoveSelectors []string // 额外需要移除的元素选择器
+	Metadata        map[string]interface{}
+	CallbackManager *core.CallbackManager
+}
+
+// defaultHTMLRemoveSelectors 默认移除的非正文元素
+var defaultHTMLRemoveSelectors = []string{
+	"script", "style", "noscript", "template", "iframe", "svg",
+	"nav", "header", "footer", "aside", "form",
+	"[role=navigation]", "[role=banner]", "[role=contentinfo]", "[aria-hidden=true]",
+}
+
+// htmlMainSelectors 正文候选选择器,按优先级排列
+var htmlMainSelectors = []string{
+	"main", "article", "[role=main]", "#content", "#main", ".content", ".post", ".article",
+}
+
+// NewHTMLLoader 创建 HTML 加载器
+func NewHTMLLoader(config HTMLLoaderConfig) *HTMLLoader {
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]interface{})
+	}
+
+	config.Metadata["source"] = config.FilePath
+	config.Metadata["source_type"] = "html"
+
+	return &HTMLLoader{
+		BaseDocumentLoader: NewBaseDocumentLoader(config.Metadata, config.CallbackManager),
+		filePath:           config.FilePath,
+		contentSelector:    config.ContentSelector,
+		removeSelectors:    config.RemoveSelectors,
+	}
+}
+
+// Load 加载 HTML 文件
+func (l *HTMLLoader) Load(ctx context.Context) ([]*interfaces.Document, error) {
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnStart(ctx, map[string]interface{}{
+			"loader":    "html",
+			"file_path": l.filePath,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(l.filePath)
+	if err != nil {
+		if l.callbackManager != nil {
+			_ = l.callbackManager.OnError(ctx, err)
+		}
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to open html file", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	extracted, err := ExtractHTML(file, HTMLExtractOptions{
+		ContentSelector: l.contentSelector,
+		RemoveSelectors: l.removeSelectors,
+	})
+	if err != nil {
+		if l.callbackManager != nil {
+			_ = l.callbackManager.OnError(ctx, err)
+		}
+		return nil, err
+	}
+
+	metadata := copyMetadata(l.GetMetadata())
+	extracted.applyMetadata(metadata)
+
+	doc := retrieval.NewDocument(extracted.Text, metadata)
+
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnEnd(ctx, map[string]interface{}{
+			"num_docs":       1,
+			"content_length": len(extracted.Text),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*interfaces.Document{doc}, nil
+}
+
+// LoadAndSplit 加载并分割
+func (l *HTMLLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([]*interfaces.Document, error) {
+	return l.BaseDocumentLoader.LoadAndSplit(ctx, l, splitter)
+}
+
+// HTMLExtractOptions HTML 提取选项
+type HTMLExtractOptions struct {
+	ContentSelector string
+	RemoveSelectors []string
+}
+
+// HTMLHeading 页面标题
+type HTMLHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// HTMLContent 从 HTML 中提取的结构化内容
+type HTMLContent struct {
+	Title       string
+	Description string
+	Language    string
+	Headings    []HTMLHeading
+	Tables      [][][]string
+	Text        string
+}
+
+// applyMetadata 写入文档元数据
+func (c *HTMLContent) applyMetadata(metadata map[string]interface{}) {
+	if c.Title != "" {
+		metadata["title"] = c.Title
+	}
+	if c.Description != "" {
+		metadata["description"] = c.Description
+	}
+	if c.Language != "" {
+		metadata["language"] = c.Language
+	}
+	if len(c.Headings) > 0 {
+		headings := make([]string, len(c.Headings))
+		for i, h := range c.Headings {
+			headings[i] = strings.Repeat("#", h.Level) + " " + h.Text
+		}
+		metadata["headings"] = headings
+	}
+	metadata["num_tables"] = len(c.Tables)
+}
+
+// ExtractHTML 从 HTML 中提取正文
+//
+// 标题转换为 Markdown 风格的 "#" 前缀,表格转换为 "|" 分隔的行,
+// 段落与列表项之间以空行/换行分隔,便于后续分割器按结构切分
+func ExtractHTML(r io.Reader, opts HTMLExtractOptions) (*HTMLContent, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "failed to parse html", err)
+	}
+
+	content := &HTMLContent{
+		Title:    strings.TrimSpace(doc.Find("title").First().Text()),
+		Language: strings.TrimSpace(doc.Find("html").AttrOr("lang", "")),
+	}
+	if desc, ok := doc.Find(`meta[name="description"]`).Attr("content"); ok {
+		content.Description = strings.TrimSpace(desc)
+	}
+
+	for _, selector := range defaultHTMLRemoveSelectors {
+		doc.Find(selector).Remove()
+	}
+	for _, selector := range opts.RemoveSelectors {
+		doc.Find(selector).Remove()
+	}
+
+	root := selectHTMLMain(doc, opts.ContentSelector)
+
+	var blocks []string
+	walkHTMLBlocks(root, content, &blocks)
+	content.Text = strings.Join(blocks, "\n\n")
+
+	if content.Title == "" && len(content.Headings) > 0 {
+		content.Title = content.Headings[0].Text
+	}
+
+	return content, nil
+}
+
+// selectHTMLMain 选择正文根节点
+func selectHTMLMain(doc *goquery.Document, selector string) *goquery.Selection {
+	if selector != "" {
+		if sel := doc.Find(selector); sel.Length() > 0 {
+			return sel.First()
+		}
+	}
+
+	for _, candidate := range htmlMainSelectors {
+		sel := doc.Find(candidate)
+		if sel.Length() > 0 && len(strings.TrimSpace(sel.First().Text())) > 0 {
+			return sel.First()
+		}
+	}
+
+	if body := doc.Find("body"); body.Length() > 0 {
+		return body
+	}
+	return doc.Selection
+}
+
+// htmlBlockTags 需要作为独立块输出的元素
+var htmlBlockTags = map[string]bool{
+	"p": true, "li": true, "pre": true, "blockquote": true, "dt": true, "dd": true,
+	"figcaption": true, "caption": true,
+}
+
+const (
+	// htmlNestedBlockSelector 块元素内部需要继续拆分的后代
+	htmlNestedBlockSelector = "p, li, table, h1, h2, h3, h4, h5, h6, pre"
+
+	// htmlContainerBlockSelector 容器元素内部的块级后代
+	htmlContainerBlockSelector = htmlNestedBlockSelector +
+		", div, section, article, ul, ol, dl, blockquote, figure, br"
+)
+
+// walkHTMLBlocks 深度优先遍历,按块级元素收集文本
+func walkHTMLBlocks(sel *goquery.Selection, content *HTMLContent, blocks *[]string) {
+	sel.Contents().Each(func(_ int, node *goquery.Selection) {
+		name := goquery.NodeName(node)
+
+		switch {
+		case name == "#text":
+			if text := collapseSpaces(node.Text()); text != "" {
+				*blocks = append(*blocks, text)
+			}
+		case len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6':
+			text := collapseSpaces(node.Text())
+			if text == "" {
+				return
+			}
+			level := int(name[1] - '0')
+			content.Headings = append(content.Headings, HTMLHeading{Level: level, Text: text})
+			*blocks = append(*blocks, strings.Repeat("#", level)+" "+text)
+		case name == "table":
+			rows := extractHTMLTable(node)
+			if len(rows) == 0 {
+				return
+			}
+			content.Tables = append(content.Tables, rows)
+			lines := make([]string, len(rows))
+			for i, row := range rows {
+				lines[i] = "| " + strings.Join(row, " | ") + " |"
+			}
+			*blocks = append(*blocks, strings.Join(lines, "\n"))
+		case name == "pre":
+			if text := strings.TrimSpace(node.Text()); text != "" {
+				*blocks = append(*blocks, text)
+			}
+		case name == "br" || name == "#comment":
+			return
+		case htmlBlockTags[name]:
+			// 块内包含嵌套块(如 li 内的 ul)时继续递归
+			if node.Find(htmlNestedBlockSelector).Length() > 0 {
+				walkHTMLBlocks(node, content, blocks)
+				return
+			}
+			text := collapseSpaces(node.Text())
+			if text == "" {
+				return
+			}
+			if name == "li" {
+				text = "- " + text
+			}
+			*blocks = append(*blocks, text)
+		default:
+			// 不含块级后代的容器整体作为一个块,避免行内元素被拆散
+			if node.Find(htmlContainerBlockSelector).Length() == 0 {
+				if text := collapseSpaces(node.Text()); text != "" {
+					*blocks = append(*blocks, text)
+				}
+				return
+			}
+			walkHTMLBlocks(node, content, blocks)
+		}
+	})
+}
+
+// extractHTMLTable 提取表格单元格
+func extractHTMLTable(table *goquery.Selection) [][]string {
+	var rows [][]string
+	table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		// 跳过嵌套表格中的行
+		if tr.Closest("table").Get(0) != table.Get(0) {
+			return
+		}
+		var cells []string
+		tr.Children().Filter("th, td").Each(func(_ int, cell *goquery.Selection) {
+			cells = append(cells, collapseSpaces(cell.Text()))
+		})
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+	return rows
+}
+
+// collapseSpaces 合并连续空白
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
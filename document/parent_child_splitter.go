@@ -0,0 +1,171 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// 父子分割相关的元数据键
+const (
+	// MetadataParentID 子块所属父块的 ID
+	MetadataParentID = "parent_id"
+
+	// MetadataChunkLevel 块的层级,取值为 ChunkLevelParent 或 ChunkLevelChild
+	MetadataChunkLevel = "chunk_level"
+
+	ChunkLevelParent = "parent"
+	ChunkLevelChild  = "child"
+)
+
+// ParentChildTextSplitter 父子分割器
+//
+// 先用父分割器切出较大的上下文块,再用子分割器将每个父块切成用于检索的小块。
+// 子块通过元数据 parent_id 指向父块,检索命中子块后可以取回完整的父块
+type ParentChildTextSplitter struct {
+	parentSplitter TextSplitter
+	childSplitter  TextSplitter
+	includeParents bool
+}
+
+// ParentChildTextSplitterConfig 父子分割器配置
+type ParentChildTextSplitterConfig struct {
+	// ParentSplitter 父块分割器,为空时整个文档作为父块
+	ParentSplitter TextSplitter
+
+	// ChildSplitter 子块分割器,默认 400 字符的递归分割器
+	ChildSplitter TextSplitter
+
+	// IncludeParents SplitDocuments 是否同时输出父块(位于各自子块之前)
+	IncludeParents bool
+}
+
+// ParentChildResult 父子分割结果
+type ParentChildResult struct {
+	Parents  []*interfaces.Document
+	Children []*interfaces.Document
+}
+
+// NewParentChildTextSplitter 创建父子分割器
+func NewParentChildTextSplitter(config ParentChildTextSplitterConfig) *ParentChildTextSplitter {
+	if config.ChildSplitter == nil {
+		config.ChildSplitter = NewRecursiveCharacterTextSplitter(RecursiveCharacterTextSplitterConfig{
+			ChunkSize:    400,
+			ChunkOverlap: 50,
+		})
+	}
+
+	return &ParentChildTextSplitter{
+		parentSplitter: config.ParentSplitter,
+		childSplitter:  config.ChildSplitter,
+		includeParents: config.IncludeParents,
+	}
+}
+
+// SplitText 返回所有子块文本
+func (s *ParentChildTextSplitter) SplitText(text string) ([]string, error) {
+	parents := []string{text}
+	if s.parentSplitter != nil {
+		var err error
+		if parents, err = s.parentSplitter.SplitText(text); err != nil {
+			return nil, err
+		}
+	}
+
+	var children []string
+	for _, parent := range parents {
+		chunks, err := s.childSplitter.SplitText(parent)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, chunks...)
+	}
+	return children, nil
+}
+
+// SplitDocuments 分割文档,默认只返回子块
+func (s *ParentChildTextSplitter) SplitDocuments(docs []*interfaces.Document) ([]*interfaces.Document, error) {
+	result, err := s.Split(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.includeParents {
+		return result.Children, nil
+	}
+
+	// 父块在前,其子块紧随其后
+	out := make([]*interfaces.Document, 0, len(result.Parents)+len(result.Children))
+	childIdx := 0
+	for _, parent := range result.Parents {
+		out = append(out, parent)
+		for childIdx < len(result.Children) && result.Children[childIdx].Metadata[MetadataParentID] == parent.ID {
+			out = append(out, result.Children[childIdx])
+			childIdx++
+		}
+	}
+	return out, nil
+}
+
+// Split 分割文档并分别返回父块和子块
+//
+// 父块 ID 由源文档 ID 和序号确定,重复分割同一文档得到相同的父块 ID
+func (s *ParentChildTextSplitter) Split(docs []*interfaces.Document) (*ParentChildResult, error) {
+	result := &ParentChildResult{
+		Parents:  make([]*interfaces.Document, 0),
+		Children: make([]*interfaces.Document, 0),
+	}
+
+	for _, doc := range docs {
+		parentTexts := []string{doc.PageContent}
+		if s.parentSplitter != nil {
+			var err error
+			if parentTexts, err = s.parentSplitter.SplitText(doc.PageContent); err != nil {
+				return nil, errors.Wrap(errors.CodeInternalError, "failed to split parent chunks", err)
+			}
+		}
+
+		for i, parentText := range parentTexts {
+			parentMetadata := copyMetadata(doc.Metadata)
+			parentMetadata["chunk_index"] = i
+			parentMetadata["chunk_total"] = len(parentTexts)
+			parentMetadata["source_id"] = doc.ID
+			parentMetadata[MetadataChunkLevel] = ChunkLevelParent
+
+			parentID := fmt.Sprintf("%s_parent_%d", doc.ID, i)
+			parent := retrieval.NewDocumentWithID(parentID, parentText, parentMetadata)
+			result.Parents = append(result.Parents, parent)
+
+			childTexts, err := s.childSplitter.SplitText(parentText)
+			if err != nil {
+				return nil, errors.Wrap(errors.CodeInternalError, "failed to split child chunks", err)
+			}
+
+			for j, childText := range childTexts {
+				childMetadata := copyMetadata(doc.Metadata)
+				childMetadata["chunk_index"] = j
+				childMetadata["chunk_total"] = len(childTexts)
+				childMetadata["source_id"] = doc.ID
+				childMetadata[MetadataParentID] = parentID
+				childMetadata[MetadataChunkLevel] = ChunkLevelChild
+
+				childID := fmt.Sprintf("%s_child_%d", parentID, j)
+				result.Children = append(result.Children, retrieval.NewDocumentWithID(childID, childText, childMetadata))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// GetChunkSize 获取子块大小
+func (s *ParentChildTextSplitter) GetChunkSize() int {
+	return s.childSplitter.GetChunkSize()
+}
+
+// GetChunkOverlap 获取子块重叠
+func (s *ParentChildTextSplitter) GetChunkOverlap() int {
+	return s.childSplitter.GetChunkOverlap()
+}
@@ -0,0 +1,155 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// PDFLoader PDF 文件加载器
+//
+// 使用纯 Go 实现提取 PDF 文本,默认每页生成一个文档并记录页码
+type PDFLoader struct {
+	*BaseDocumentLoader
+	filePath    string
+	mergePages  bool
+	pageJoiner  string
+	skipEmpties bool
+}
+
+// PDFLoaderConfig PDF 加载器配置
+type PDFLoaderConfig struct {
+	FilePath        string
+	MergePages      bool   // 是否将所有页面合并为一个文档
+	PageSeparator   string // 合并页面时的分隔符
+	KeepEmptyPages  bool   // 是否保留没有文本的页面(如扫描页)
+	Metadata        map[string]interface{}
+	CallbackManager *core.CallbackManager
+}
+
+// NewPDFLoader 创建 PDF 加载器
+func NewPDFLoader(config PDFLoaderConfig) *PDFLoader {
+	if config.PageSeparator == "" {
+		config.PageSeparator = "\n\n"
+	}
+
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]interface{})
+	}
+
+	config.Metadata["source"] = config.FilePath
+	config.Metadata["source_type"] = "pdf"
+
+	return &PDFLoader{
+		BaseDocumentLoader: NewBaseDocumentLoader(config.Metadata, config.CallbackManager),
+		filePath:           config.FilePath,
+		mergePages:         config.MergePages,
+		pageJoiner:         config.PageSeparator,
+		skipEmpties:        !config.KeepEmptyPages,
+	}
+}
+
+// Load 加载 PDF 文件
+func (l *PDFLoader) Load(ctx context.Context) ([]*interfaces.Document, error) {
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnStart(ctx, map[string]interface{}{
+			"loader":    "pdf",
+			"file_path": l.filePath,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := l.load()
+	if err != nil {
+		if l.callbackManager != nil {
+			_ = l.callbackManager.OnError(ctx, err)
+		}
+		return nil, err
+	}
+
+	// 触发回调
+	if l.callbackManager != nil {
+		if err := l.callbackManager.OnEnd(ctx, map[string]interface{}{
+			"num_docs": len(docs),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// load 解析文件并生成文档
+func (l *PDFLoader) load() ([]*interfaces.Document, error) {
+	data, err := os.ReadFile(l.filePath)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to read pdf file", err)
+	}
+
+	pdf, err := parsePDF(data)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInvalidParam, "failed to parse pdf file", err)
+	}
+
+	pages := pdf.pages()
+	info := pdf.info()
+
+	baseMetadata := copyMetadata(l.GetMetadata())
+	baseMetadata["total_pages"] = len(pages)
+	if title, ok := info["Title"]; ok {
+		baseMetadata["title"] = title
+	}
+	if author, ok := info["Author"]; ok {
+		baseMetadata["author"] = author
+	}
+
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = pdf.pageText(page)
+	}
+
+	if l.mergePages {
+		nonEmpty := make([]string, 0, len(texts))
+		for _, text := range texts {
+			if text != "" {
+				nonEmpty = append(nonEmpty, text)
+			}
+		}
+		return []*interfaces.Document{
+			retrieval.NewDocument(strings.Join(nonEmpty, l.pageJoiner), baseMetadata),
+		}, nil
+	}
+
+	docs := make([]*interfaces.Document, 0, len(pages))
+	for i, text := range texts {
+		if text == "" && l.skipEmpties {
+			continue
+		}
+		metadata := copyMetadata(baseMetadata)
+		metadata["page"] = i + 1
+		docs = append(docs, retrieval.NewDocument(text, metadata))
+	}
+
+	return docs, nil
+}
+
+// LoadAndSplit 加载并分割
+func (l *PDFLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([]*interfaces.Document, error) {
+	return l.BaseDocumentLoader.LoadAndSplit(ctx, l, splitter)
+}
+
+// copyMetadata 复制元数据,避免多个文档共享同一个 map
+func copyMetadata(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src)+4)
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,986 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// 本文件实现一个纯 Go 的 PDF 文本提取器
+//
+// 只覆盖文本提取需要的子集：对象解析、对象流、Flate/ASCIIHex/ASCII85 解码、
+// 页面树遍历、ToUnicode CMap 以及内容流中的文本操作符。
+// 不支持加密文档、扫描件（图片）和复杂的字体编码差异表。
+
+// pdfName PDF 名称对象（不含前导 /）
+type pdfName string
+
+// pdfString PDF 字符串对象（原始字节）
+type pdfString []byte
+
+// pdfRef 间接对象引用
+type pdfRef struct {
+	num int
+	gen int
+}
+
+// pdfDict PDF 字典
+type pdfDict map[pdfName]interface{}
+
+// pdfArray PDF 数组
+type pdfArray []interface{}
+
+// pdfKeyword 内容流中的操作符或未识别的关键字
+type pdfKeyword string
+
+// pdfStream 流对象
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+// pdfDocument 已解析的 PDF 文档
+type pdfDocument struct {
+	objects map[int]interface{}
+	trailer pdfDict
+}
+
+// pdfPage 页面及其继承后的资源
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pdfLexer PDF 词法分析器
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipSpace 跳过空白和注释
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+}
+
+// readRegular 读取一个常规字符序列
+func (l *pdfLexer) readRegular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// next 读取下一个对象；遇到 ] 或 >> 时返回对应的 pdfKeyword
+func (l *pdfLexer) next() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(decodePDFName(l.readRegular())), nil
+	case c == '(':
+		return l.readLiteralString(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.readDict()
+	case c == '<':
+		return l.readHexString(), nil
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), nil
+	case c == '[':
+		l.pos++
+		return l.readArray()
+	case c == ']':
+		l.pos++
+		return pdfKeyword("]"), nil
+	case c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+
+	token := l.readRegular()
+	if token == "" {
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if n, err := strconv.ParseFloat(token, 64); err == nil {
+		// 尝试识别 "num gen R" 形式的间接引用
+		if n == float64(int(n)) && !strings.Contains(token, ".") {
+			save := l.pos
+			if ref, ok := l.tryReadRef(int(n)); ok {
+				return ref, nil
+			}
+			l.pos = save
+		}
+		return n, nil
+	}
+
+	return pdfKeyword(token), nil
+}
+
+// tryReadRef 尝试读取 "gen R"
+func (l *pdfLexer) tryReadRef(num int) (pdfRef, bool) {
+	l.skipSpace()
+	gen := l.readRegular()
+	g, err := strconv.Atoi(gen)
+	if err != nil {
+		return pdfRef{}, false
+	}
+	l.skipSpace()
+	if l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+		(l.pos+1 == len(l.data) || isPDFWhitespace(l.data[l.pos+1]) || isPDFDelimiter(l.data[l.pos+1])) {
+		l.pos++
+		return pdfRef{num: num, gen: g}, true
+	}
+	return pdfRef{}, false
+}
+
+func (l *pdfLexer) readDict() (pdfDict, error) {
+	dict := make(pdfDict)
+	for {
+		key, err := l.next()
+		if err != nil {
+			return dict, err
+		}
+		if kw, ok := key.(pdfKeyword); ok && kw == ">>" {
+			return dict, nil
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			continue
+		}
+		value, err := l.next()
+		if err != nil {
+			return dict, err
+		}
+		if kw, ok := value.(pdfKeyword); ok && kw == ">>" {
+			dict[name] = nil
+			return dict, nil
+		}
+		dict[name] = value
+	}
+}
+
+func (l *pdfLexer) readArray() (pdfArray, error) {
+	arr := make(pdfArray, 0)
+	for {
+		value, err := l.next()
+		if err != nil {
+			return arr, err
+		}
+		if kw, ok := value.(pdfKeyword); ok && kw == "]" {
+			return arr, nil
+		}
+		arr = append(arr, value)
+	}
+}
+
+func (l *pdfLexer) readLiteralString() pdfString {
+	l.pos++ // (
+	var buf bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			buf.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(buf.Bytes())
+			}
+			buf.WriteByte(c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					buf.WriteByte(byte(v))
+				} else {
+					buf.WriteByte(e)
+				}
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return pdfString(buf.Bytes())
+}
+
+func (l *pdfLexer) readHexString() pdfString {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		c := l.data[l.pos]
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded := make([]byte, len(digits)/2)
+	n, _ := hex.Decode(decoded, digits)
+	return pdfString(decoded[:n])
+}
+
+// decodePDFName 处理名称中的 #xx 转义
+func decodePDFName(name string) string {
+	if !strings.Contains(name, "#") {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			if v, err := strconv.ParseUint(name[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+var (
+	pdfObjHeader     = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfTrailerMarker = []byte("trailer")
+)
+
+// parsePDF 解析 PDF 文件内容
+//
+// 顺序扫描所有 "n g obj" 定义，后出现的定义覆盖之前的（兼容增量更新），
+// 不依赖可能损坏的交叉引用表
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \r\n\t"), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+
+	doc := &pdfDocument{
+		objects: make(map[int]interface{}),
+	}
+
+	skipUntil := 0
+	for _, loc := range pdfObjHeader.FindAllSubmatchIndex(data, -1) {
+		// 忽略流数据内部偶然匹配的 "n g obj"
+		if loc[0] < skipUntil {
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		lexer := &pdfLexer{data: data, pos: loc[1]}
+		obj, err := lexer.next()
+		if err != nil {
+			continue
+		}
+
+		if dict, ok := obj.(pdfDict); ok {
+			lexer.skipSpace()
+			if bytes.HasPrefix(data[lexer.pos:], []byte("stream")) {
+				stream, end := readPDFStream(data, lexer.pos+len("stream"), dict)
+				obj = stream
+				skipUntil = end
+			}
+			if t, _ := dict["Type"].(pdfName); t == "XRef" {
+				doc.mergeTrailer(dict)
+			}
+		}
+
+		doc.objects[num] = obj
+	}
+
+	// 经典 trailer 字典
+	for idx := 0; ; {
+		i := bytes.Index(data[idx:], pdfTrailerMarker)
+		if i < 0 {
+			break
+		}
+		lexer := &pdfLexer{data: data, pos: idx + i + len(pdfTrailerMarker)}
+		if obj, err := lexer.next(); err == nil {
+			if dict, ok := obj.(pdfDict); ok {
+				doc.mergeTrailer(dict)
+			}
+		}
+		idx += i + len(pdfTrailerMarker)
+	}
+
+	if err := doc.expandObjectStreams(); err != nil {
+		return nil, err
+	}
+
+	if doc.trailer["Encrypt"] != nil {
+		return nil, fmt.Errorf("encrypted PDF documents are not supported")
+	}
+
+	return doc, nil
+}
+
+// mergeTrailer 合并 trailer 信息，后出现的值优先
+func (d *pdfDocument) mergeTrailer(dict pdfDict) {
+	if d.trailer == nil {
+		d.trailer = make(pdfDict)
+	}
+	for _, key := range []pdfName{"Root", "Info", "Encrypt"} {
+		if v, ok := dict[key]; ok {
+			d.trailer[key] = v
+		}
+	}
+}
+
+// readPDFStream 读取 stream ... endstream 之间的原始数据，并返回数据结束位置
+func readPDFStream(data []byte, pos int, dict pdfDict) (*pdfStream, int) {
+	// stream 关键字后必须跟 CRLF 或 LF
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+
+	if length, ok := dict["Length"].(float64); ok {
+		end := pos + int(length)
+		if end <= len(data) && bytes.Contains(data[end:minInt(end+32, len(data))], []byte("endstream")) {
+			return &pdfStream{dict: dict, raw: data[pos:end]}, end
+		}
+	}
+
+	// Length 为间接引用或不准确时回退到搜索 endstream
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return &pdfStream{dict: dict, raw: data[pos:]}, len(data)
+	}
+	raw := bytes.TrimRight(data[pos:pos+end], "\r\n")
+	return &pdfStream{dict: dict, raw: raw}, pos + end
+}
+
+// expandObjectStreams 展开 PDF 1.5 的对象流
+func (d *pdfDocument) expandObjectStreams() error {
+	var objStreams []*pdfStream
+	for _, obj := range d.objects {
+		if stream, ok := obj.(*pdfStream); ok {
+			if t, _ := stream.dict["Type"].(pdfName); t == "ObjStm" {
+				objStreams = append(objStreams, stream)
+			}
+		}
+	}
+
+	for _, stream := range objStreams {
+		content, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+
+		n := int(d.number(stream.dict["N"]))
+		first := int(d.number(stream.dict["First"]))
+		if first < 0 || first > len(content) {
+			return fmt.Errorf("malformed PDF object stream: /First %d out of range", first)
+		}
+
+		header := &pdfLexer{data: content[:first]}
+		for i := 0; i < n; i++ {
+			numObj, err1 := header.next()
+			offObj, err2 := header.next()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			num, _ := numObj.(float64)
+			off, _ := offObj.(float64)
+			if _, exists := d.objects[int(num)]; exists {
+				continue
+			}
+			pos := first + int(off)
+			if off < 0 || pos >= len(content) {
+				return fmt.Errorf("malformed PDF object stream: object %d offset %d out of range", int(num), int(off))
+			}
+			lexer := &pdfLexer{data: content, pos: pos}
+			if value, err := lexer.next(); err == nil {
+				d.objects[int(num)] = value
+			}
+		}
+	}
+	return nil
+}
+
+// resolve 解析间接引用
+func (d *pdfDocument) resolve(obj interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj = d.objects[ref.num]
+	}
+	return nil
+}
+
+// dict 解析为字典（流对象返回其字典）
+func (d *pdfDocument) dict(obj interface{}) pdfDict {
+	switch v := d.resolve(obj).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// number 解析为数字
+func (d *pdfDocument) number(obj interface{}) float64 {
+	v, _ := d.resolve(obj).(float64)
+	return v
+}
+
+// decodeStream 按 /Filter 解码流数据
+func (d *pdfDocument) decodeStream(stream *pdfStream) ([]byte, error) {
+	data := stream.raw
+
+	var filters []pdfName
+	switch f := d.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []pdfName{f}
+	case pdfArray:
+		for _, item := range f {
+			if name, ok := d.resolve(item).(pdfName); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	for _, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = inflatePDF(data)
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported PDF filter: %s", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// maxPDFStreamSize 单个流解压后的最大字节数，防止压缩炸弹
+const maxPDFStreamSize = 64 << 20
+
+func inflatePDF(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	// 许多 PDF 的压缩流缺少校验和，尽量返回已解压的数据
+	out, err := io.ReadAll(io.LimitReader(reader, maxPDFStreamSize+1))
+	if len(out) > maxPDFStreamSize {
+		return nil, fmt.Errorf("PDF stream exceeds %d bytes when decompressed", maxPDFStreamSize)
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data)*4/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// pages 按页面树顺序返回所有页面
+func (d *pdfDocument) pages() []pdfPage {
+	root := d.dict(d.trailer["Root"])
+	if root == nil {
+		// 没有 trailer 时查找 Catalog 对象
+		for _, obj := range d.objects {
+			if dict, ok := obj.(pdfDict); ok {
+				if t, _ := dict["Type"].(pdfName); t == "Catalog" {
+					root = dict
+					break
+				}
+			}
+		}
+	}
+	if root == nil {
+		return nil
+	}
+
+	var pages []pdfPage
+	visited := make(map[interface{}]bool)
+	var walk func(node interface{}, inherited pdfDict)
+	walk = func(node interface{}, inherited pdfDict) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+
+		dict := d.dict(node)
+		if dict == nil {
+			return
+		}
+
+		resources := inherited
+		if r := d.dict(dict["Resources"]); r != nil {
+			resources = r
+		}
+
+		kids, ok := d.resolve(dict["Kids"]).(pdfArray)
+		if t, _ := dict["Type"].(pdfName); t == "Page" || !ok {
+			pages = append(pages, pdfPage{dict: dict, resources: resources})
+			return
+		}
+
+		for _, kid := range kids {
+			walk(kid, resources)
+		}
+	}
+	walk(root["Pages"], nil)
+
+	return pages
+}
+
+// info 返回文档信息字典中的字符串字段
+func (d *pdfDocument) info() map[string]string {
+	result := make(map[string]string)
+	info := d.dict(d.trailer["Info"])
+	for key, value := range info {
+		if s, ok := d.resolve(value).(pdfString); ok {
+			if text := strings.TrimSpace(decodePDFTextString(s)); text != "" {
+				result[string(key)] = text
+			}
+		}
+	}
+	return result
+}
+
+// decodePDFTextString 解码文本字符串（UTF-16BE 带 BOM 或 PDFDocEncoding）
+func decodePDFTextString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		return decodeUTF16BE(s[2:])
+	}
+	return latin1ToString(s)
+}
+
+func decodeUTF16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+func latin1ToString(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// pdfFont 文本解码所需的字体信息
+type pdfFont struct {
+	cmap     map[string]string
+	codeLens []int
+	twoByte  bool
+}
+
+// decode 将字符串字节按字体编码转换为文本
+func (f *pdfFont) decode(s []byte) string {
+	if f == nil || (len(f.cmap) == 0 && !f.twoByte) {
+		return latin1ToString(s)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		matched := false
+		for _, n := range f.codeLens {
+			if i+n > len(s) {
+				continue
+			}
+			if text, ok := f.cmap[string(s[i:i+n])]; ok {
+				b.WriteString(text)
+				i += n
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if f.twoByte && i+1 < len(s) {
+			// 无映射的双字节编码：Identity-H 下常与 Unicode 一致
+			b.WriteRune(rune(uint16(s[i])<<8 | uint16(s[i+1])))
+			i += 2
+			continue
+		}
+		b.WriteRune(rune(s[i]))
+		i++
+	}
+	return b.String()
+}
+
+// loadFont 加载字体的 ToUnicode 映射
+func (d *pdfDocument) loadFont(obj interface{}) *pdfFont {
+	dict := d.dict(obj)
+	if dict == nil {
+		return nil
+	}
+
+	font := &pdfFont{cmap: make(map[string]string)}
+	if subtype, _ := d.resolve(dict["Subtype"]).(pdfName); subtype == "Type0" {
+		font.twoByte = true
+	}
+
+	if stream, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			parseToUnicodeCMap(data, font)
+		}
+	}
+
+	lens := make(map[int]bool)
+	for code := range font.cmap {
+		lens[len(code)] = true
+	}
+	for n := 4; n >= 1; n-- {
+		if lens[n] {
+			font.codeLens = append(font.codeLens, n)
+		}
+	}
+
+	return font
+}
+
+// parseToUnicodeCMap 解析 bfchar 和 bfrange 段
+func parseToUnicodeCMap(data []byte, font *pdfFont) {
+	lexer := &pdfLexer{data: data}
+	var operands []interface{}
+	mode := ""
+
+	for {
+		obj, err := lexer.next()
+		if err != nil {
+			return
+		}
+
+		kw, isKeyword := obj.(pdfKeyword)
+		if !isKeyword {
+			if mode != "" {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+
+		switch kw {
+		case "beginbfchar", "beginbfrange":
+			mode = string(kw)
+			operands = operands[:0]
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					font.cmap[string(src)] = decodeUTF16BE(dst)
+				}
+			}
+			mode = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 {
+					continue
+				}
+				start, end := bytesToInt(lo), bytesToInt(hi)
+				if end < start || end-start > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := []rune(decodeUTF16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for code := start; code <= end; code++ {
+						out := make([]rune, len(base))
+						copy(out, base)
+						out[len(out)-1] += rune(code - start)
+						font.cmap[string(intToBytes(code, len(lo)))] = string(out)
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && start+j <= end {
+							font.cmap[string(intToBytes(start+j, len(lo)))] = decodeUTF16BE(s)
+						}
+					}
+				}
+			}
+			mode = ""
+		}
+	}
+}
+
+func bytesToInt(b []byte) int {
+	v := 0
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+func intToBytes(v, n int) []byte {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+// pageText 提取单个页面的文本
+func (d *pdfDocument) pageText(page pdfPage) string {
+	var content []byte
+	switch c := d.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		if data, err := d.decodeStream(c); err == nil {
+			content = data
+		}
+	case pdfArray:
+		for _, item := range c {
+			if stream, ok := d.resolve(item).(*pdfStream); ok {
+				if data, err := d.decodeStream(stream); err == nil {
+					content = append(content, data...)
+					content = append(content, '\n')
+				}
+			}
+		}
+	}
+
+	fonts := make(map[pdfName]*pdfFont)
+	fontDict := d.dict(page.resources["Font"])
+
+	return extractContentText(content, func(name pdfName) *pdfFont {
+		if font, ok := fonts[name]; ok {
+			return font
+		}
+		font := d.loadFont(fontDict[name])
+		fonts[name] = font
+		return font
+	})
+}
+
+// extractContentText 解释内容流中的文本操作符
+func extractContentText(content []byte, fontFor func(pdfName) *pdfFont) string {
+	lexer := &pdfLexer{data: content}
+	var out strings.Builder
+	var operands []interface{}
+	var font *pdfFont
+
+	newline := func() {
+		s := out.String()
+		if len(s) > 0 && s[len(s)-1] != '\n' {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		s := out.String()
+		if len(s) > 0 && s[len(s)-1] != ' ' && s[len(s)-1] != '\n' {
+			out.WriteByte(' ')
+		}
+	}
+	show := func(obj interface{}) {
+		if s, ok := obj.(pdfString); ok {
+			out.WriteString(font.decode(s))
+		}
+	}
+
+	for {
+		obj, err := lexer.next()
+		if err != nil {
+			break
+		}
+
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BI":
+			// 跳过内联图像数据
+			if i := bytes.Index(content[lexer.pos:], []byte("EI")); i >= 0 {
+				lexer.pos += i + 2
+			}
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					font = fontFor(name)
+				}
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				if arr, ok := operands[len(operands)-1].(pdfArray); ok {
+					for _, item := range arr {
+						if n, ok := item.(float64); ok {
+							// 较大的负偏移通常表示单词间距
+							if n < -200 {
+								space()
+							}
+							continue
+						}
+						show(item)
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				ty, _ := operands[len(operands)-1].(float64)
+				tx, _ := operands[len(operands)-2].(float64)
+				if ty != 0 {
+					newline()
+				} else if tx != 0 {
+					space()
+				}
+			}
+		case "T*", "Tm":
+			newline()
+		case "ET":
+			newline()
+		}
+		operands = operands[:0]
+	}
+
+	return normalizePDFText(out.String())
+}
+
+// normalizePDFText 清理每行的多余空白
+func normalizePDFText(text string) string {
+	lines := strings.Split(text, "\n")
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,235 @@
+package document
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/k8s-agent/common/errors"
+)
+
+// SemanticTextSplitter 语义分割器
+//
+// 将文本切分为句子并计算嵌入,在相邻句子语义距离超过指定百分位数的位置断开,
+// 使每个块尽量只包含一个主题
+type SemanticTextSplitter struct {
+	*BaseTextSplitter
+	embedder             retrieval.Embedder
+	breakpointPercentile float64
+	bufferSize           int
+	minChunkSize         int
+	fallback             *RecursiveCharacterTextSplitter
+}
+
+// SemanticTextSplitterConfig 语义分割器配置
+type SemanticTextSplitterConfig struct {
+	Embedder retrieval.Embedder
+
+	// BreakpointPercentile 断点百分位数(0-100),距离超过该百分位的位置作为分割点,默认 95
+	BreakpointPercentile float64
+
+	// BufferSize 计算嵌入时前后各合并的句子数,用于平滑单句噪声,默认 1,负数表示不合并
+	BufferSize int
+
+	// ChunkSize 块的最大字符数,超出时使用递归分割器再次切分,默认 2000
+	ChunkSize int
+
+	// MinChunkSize 小于该字符数的块合并到前一个块,默认 0(不合并)
+	MinChunkSize int
+
+	CallbackManager *core.CallbackManager
+}
+
+// NewSemanticTextSplitter 创建语义分割器
+func NewSemanticTextSplitter(config SemanticTextSplitterConfig) *SemanticTextSplitter {
+	if config.BreakpointPercentile <= 0 || config.BreakpointPercentile > 100 {
+		config.BreakpointPercentile = 95
+	}
+	if config.BufferSize < 0 {
+		config.BufferSize = 0
+	} else if config.BufferSize == 0 {
+		config.BufferSize = 1
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 2000
+	}
+
+	baseConfig := BaseTextSplitterConfig{
+		ChunkSize:       config.ChunkSize,
+		ChunkOverlap:    0,
+		CallbackManager: config.CallbackManager,
+		LengthFunction:  utf8.RuneCountInString,
+	}
+
+	return &SemanticTextSplitter{
+		BaseTextSplitter:     NewBaseTextSplitter(baseConfig),
+		embedder:             config.Embedder,
+		breakpointPercentile: config.BreakpointPercentile,
+		bufferSize:           config.BufferSize,
+		minChunkSize:         config.MinChunkSize,
+		fallback: NewRecursiveCharacterTextSplitter(RecursiveCharacterTextSplitterConfig{
+			ChunkSize:    config.ChunkSize,
+			ChunkOverlap: 0,
+		}),
+	}
+}
+
+// SplitText 按语义分割文本
+func (s *SemanticTextSplitter) SplitText(text string) ([]string, error) {
+	return s.SplitTextWithContext(context.Background(), text)
+}
+
+// SplitTextWithContext 按语义分割文本,嵌入请求使用给定的上下文
+func (s *SemanticTextSplitter) SplitTextWithContext(ctx context.Context, text string) ([]string, error) {
+	if s.embedder == nil {
+		return nil, errors.New(errors.CodeInvalidParam, "semantic splitter requires an embedder")
+	}
+
+	sentences := splitSentences(text)
+	if len(sentences) <= 1 {
+		return s.enforceChunkSize(sentences), nil
+	}
+
+	// 每个句子与前后 bufferSize 个句子组合后再嵌入
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		start := i - s.bufferSize
+		if start < 0 {
+			start = 0
+		}
+		end := i + s.bufferSize + 1
+		if end > len(sentences) {
+			end = len(sentences)
+		}
+		windows[i] = strings.Join(sentences[start:end], " ")
+	}
+
+	vectors, err := s.embedder.Embed(ctx, windows)
+	if err != nil {
+		return nil, errors.Wrap(errors.CodeInternalError, "failed to embed sentences", err)
+	}
+	if len(vectors) != len(sentences) {
+		return nil, errors.New(errors.CodeInternalError, "embedder returned unexpected number of vectors")
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity32(vectors[i], vectors[i+1])
+	}
+	threshold := percentile(distances, s.breakpointPercentile)
+
+	var chunks []string
+	current := []string{sentences[0]}
+	for i, distance := range distances {
+		if distance > threshold {
+			chunks = append(chunks, strings.Join(current, " "))
+			current = nil
+		}
+		current = append(current, sentences[i+1])
+	}
+	chunks = append(chunks, strings.Join(current, " "))
+
+	return s.enforceChunkSize(s.mergeSmallChunks(chunks)), nil
+}
+
+// SplitDocuments 分割文档
+func (s *SemanticTextSplitter) SplitDocuments(docs []*interfaces.Document) ([]*interfaces.Document, error) {
+	return splitDocumentsWith(docs, s.SplitText)
+}
+
+// mergeSmallChunks 将过短的块合并到前一个块
+func (s *SemanticTextSplitter) mergeSmallChunks(chunks []string) []string {
+	if s.minChunkSize <= 0 {
+		return chunks
+	}
+
+	merged := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if len(merged) > 0 && s.lengthFunction(chunk) < s.minChunkSize {
+			merged[len(merged)-1] += " " + chunk
+			continue
+		}
+		merged = append(merged, chunk)
+	}
+	return merged
+}
+
+// enforceChunkSize 对超出块大小的语义块做二次切分
+func (s *SemanticTextSplitter) enforceChunkSize(chunks []string) []string {
+	result := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if s.lengthFunction(chunk) <= s.chunkSize {
+			result = append(result, chunk)
+			continue
+		}
+		parts, _ := s.fallback.SplitText(chunk)
+		result = append(result, parts...)
+	}
+	return result
+}
+
+// sentenceBoundary 句末标点(含中文标点)后的位置
+var sentenceBoundary = regexp.MustCompile(`[.!?。！？]+["'”’)]*\s+|[。！？]+|\n\s*\n`)
+
+// splitSentences 将文本切分为句子
+func splitSentences(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	var sentences []string
+	last := 0
+	for _, loc := range sentenceBoundary.FindAllStringIndex(text, -1) {
+		if sentence := strings.TrimSpace(text[last:loc[1]]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		last = loc[1]
+	}
+	if rest := strings.TrimSpace(text[last:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// percentile 计算百分位数(线性插值)
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// cosineSimilarity32 计算余弦相似度,维度不一致或零向量时返回 0
+func cosineSimilarity32(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
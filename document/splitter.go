@@ -81,11 +81,19 @@ func (s *BaseTextSplitter) GetChunkOverlap() int {
 
 // SplitDocuments 分割文档
 func (s *BaseTextSplitter) SplitDocuments(docs []*interfaces.Document) ([]*interfaces.Document, error) {
+	return splitDocumentsWith(docs, s.SplitText)
+}
+
+// splitDocumentsWith 使用指定的分割函数分割文档
+//
+// 嵌入 BaseTextSplitter 的分割器需要显式传入自身的 SplitText,
+// 否则 Go 的方法提升会调用基础实现
+func splitDocumentsWith(docs []*interfaces.Document, splitText func(string) ([]string, error)) ([]*interfaces.Document, error) {
 	result := make([]*interfaces.Document, 0)
 
 	for _, doc := range docs {
 		// 分割文本
-		chunks, err := s.SplitText(doc.PageContent)
+		chunks, err := splitText(doc.PageContent)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,202 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+func TestSemanticTextSplitter(t *testing.T) {
+	text := "Cats purr when happy. Cats sleep most of the day. Cats chase mice. " +
+		"Rockets need fuel. Rockets reach orbit. Rockets launch from pads."
+
+	splitter := NewSemanticTextSplitter(SemanticTextSplitterConfig{
+		Embedder:             mocks.NewKeywordEmbedder("cat", "rocket"),
+		BreakpointPercentile: 90,
+		BufferSize:           -1,
+	})
+
+	chunks, err := splitter.SplitText(text)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "Cats purr when happy. Cats sleep most of the day. Cats chase mice.", chunks[0])
+	assert.Equal(t, "Rockets need fuel. Rockets reach orbit. Rockets launch from pads.", chunks[1])
+
+	docs, err := splitter.SplitDocuments([]*interfaces.Document{retrieval.NewDocument(text, map[string]interface{}{"source": "s"})})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "s", docs[1].Metadata["source"])
+	assert.Equal(t, 1, docs[1].Metadata["chunk_index"])
+}
+
+func TestSemanticTextSplitterChunkSizeAndErrors(t *testing.T) {
+	embedder := mocks.NewKeywordEmbedder("a")
+	splitter := NewSemanticTextSplitter(SemanticTextSplitterConfig{Embedder: embedder, ChunkSize: 20})
+
+	// 单句超长时按块大小再次切分
+	chunks, err := splitter.SplitText(strings.Repeat("word ", 20))
+	require.NoError(t, err)
+	assert.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 20)
+	}
+	assert.Equal(t, 0, embedder.Calls(), "single sentence needs no embedding")
+
+	_, err = NewSemanticTextSplitter(SemanticTextSplitterConfig{}).SplitText("a. b.")
+	assert.Error(t, err)
+
+	failingEmbedder := mocks.NewKeywordEmbedder("a")
+	failingEmbedder.SetError(errors.New("boom"))
+	failing := NewSemanticTextSplitter(SemanticTextSplitterConfig{Embedder: failingEmbedder})
+	_, err = failing.SplitText("One. Two. Three.")
+	assert.Error(t, err)
+}
+
+func TestSplitSentencesAndPercentile(t *testing.T) {
+	assert.Equal(t, []string{"Hello world.", "How are you?", "第一句。", "第二句！", "tail"},
+		splitSentences("Hello world. How are you? 第一句。第二句！tail"))
+	assert.Nil(t, splitSentences("   "))
+
+	assert.InDelta(t, 2.5, percentile([]float64{4, 1, 3, 2}, 50), 1e-9)
+	assert.InDelta(t, 4, percentile([]float64{4, 1, 3, 2}, 100), 1e-9)
+}
+
+const goSplitterSource = `// Package demo 示例
+package demo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Greeter 问候器
+type Greeter struct {
+	name string
+}
+
+// Greet 返回问候语
+func (g *Greeter) Greet() string {
+	return fmt.Sprintf("hello %s", strings.ToUpper(g.name))
+}
+
+// MaxRetries 最大重试次数
+const MaxRetries = 3 // inline
+
+// helper 与下一段之间有游离注释
+
+func helper() {}
+`
+
+func TestGoASTTextSplitter(t *testing.T) {
+	splitter := NewGoASTTextSplitter(GoASTTextSplitterConfig{ChunkSize: 60})
+
+	chunks, err := splitter.SplitText(goSplitterSource)
+	require.NoError(t, err)
+	require.Len(t, chunks, 5)
+
+	assert.True(t, strings.HasPrefix(chunks[0], "// Package demo"))
+	assert.Contains(t, chunks[0], `"strings"`)
+	assert.True(t, strings.HasPrefix(chunks[1], "// Greeter 问候器\ntype Greeter struct"))
+	assert.True(t, strings.HasPrefix(chunks[2], "// Greet 返回问候语\nfunc (g *Greeter) Greet()"))
+	assert.True(t, strings.HasSuffix(chunks[2], "}"))
+	assert.Equal(t, "// MaxRetries 最大重试次数\nconst MaxRetries = 3 // inline", chunks[3])
+	assert.Contains(t, chunks[4], "func helper() {}")
+
+	doc := retrieval.NewDocument(goSplitterSource, map[string]interface{}{"path": "demo.go"})
+	docs, err := splitter.SplitDocuments([]*interfaces.Document{doc})
+	require.NoError(t, err)
+	require.Len(t, docs, 5)
+	assert.Equal(t, []string{"Greeter.Greet"}, docs[2].Metadata["symbols"])
+	assert.Equal(t, 14, docs[2].Metadata["start_line"])
+	assert.Equal(t, 17, docs[2].Metadata["end_line"])
+	assert.Equal(t, "demo.go", docs[2].Metadata["path"])
+	assert.Equal(t, "go", docs[2].Metadata["language"])
+
+	// 较大的块大小会合并相邻声明,但不会拆开任何声明
+	merged, err := NewGoASTTextSplitter(GoASTTextSplitterConfig{ChunkSize: 2000}).SplitText(goSplitterSource)
+	require.NoError(t, err)
+	require.Len(t, merged, 1)
+	assert.Equal(t, strings.TrimSpace(goSplitterSource), merged[0])
+}
+
+func TestGoASTTextSplitterFallback(t *testing.T) {
+	splitter := NewGoASTTextSplitter(GoASTTextSplitterConfig{ChunkSize: 50})
+
+	chunks, err := splitter.SplitText("func broken( {\n\treturn\n}\n\nfunc other() {}\n")
+	require.NoError(t, err)
+	assert.NotEmpty(t, chunks)
+}
+
+func TestParentChildTextSplitter(t *testing.T) {
+	text := "Para one has some words.\n\nPara two has other words."
+	splitter := NewParentChildTextSplitter(ParentChildTextSplitterConfig{
+		ParentSplitter: NewCharacterTextSplitter(CharacterTextSplitterConfig{
+			Separator: "\n\n", ChunkSize: 30, ChunkOverlap: 0,
+		}),
+		ChildSplitter: NewCharacterTextSplitter(CharacterTextSplitterConfig{
+			Separator: " ", ChunkSize: 10, ChunkOverlap: 0,
+		}),
+	})
+
+	doc := retrieval.NewDocumentWithID("doc1", text, map[string]interface{}{"source": "x"})
+	result, err := splitter.Split([]*interfaces.Document{doc})
+	require.NoError(t, err)
+	require.Len(t, result.Parents, 2)
+	require.NotEmpty(t, result.Children)
+
+	assert.Equal(t, "doc1_parent_0", result.Parents[0].ID)
+	assert.Equal(t, "Para one has some words.", result.Parents[0].PageContent)
+	assert.Equal(t, ChunkLevelParent, result.Parents[0].Metadata[MetadataChunkLevel])
+
+	parents := map[string]string{}
+	for _, parent := range result.Parents {
+		parents[parent.ID] = parent.PageContent
+	}
+	for _, child := range result.Children {
+		parentID, ok := child.Metadata[MetadataParentID].(string)
+		require.True(t, ok)
+		assert.Contains(t, parents[parentID], child.PageContent)
+		assert.Equal(t, ChunkLevelChild, child.Metadata[MetadataChunkLevel])
+		assert.Equal(t, "x", child.Metadata["source"])
+	}
+
+	children, err := splitter.SplitDocuments([]*interfaces.Document{doc})
+	require.NoError(t, err)
+	assert.Len(t, children, len(result.Children))
+
+	texts, err := splitter.SplitText(text)
+	require.NoError(t, err)
+	assert.Len(t, texts, len(result.Children))
+
+	withParents := NewParentChildTextSplitter(ParentChildTextSplitterConfig{
+		ParentSplitter: NewCharacterTextSplitter(CharacterTextSplitterConfig{
+			Separator: "\n\n", ChunkSize: 30, ChunkOverlap: 0,
+		}),
+		IncludeParents: true,
+	})
+	all, err := withParents.SplitDocuments([]*interfaces.Document{doc})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	assert.Equal(t, ChunkLevelParent, all[0].Metadata[MetadataChunkLevel])
+	assert.Equal(t, ChunkLevelChild, all[1].Metadata[MetadataChunkLevel])
+	assert.Equal(t, 400, withParents.GetChunkSize())
+}
+
+func TestLoadAndSplitWithStructuredSplitter(t *testing.T) {
+	path := t.TempDir() + "/demo.go"
+	writeTestFiles(t, strings.TrimSuffix(path, "/demo.go"), map[string]string{"demo.go": goSplitterSource})
+
+	loader := NewTextLoader(TextLoaderConfig{FilePath: path})
+	docs, err := loader.LoadAndSplit(context.Background(), NewGoASTTextSplitter(GoASTTextSplitterConfig{ChunkSize: 60}))
+	require.NoError(t, err)
+	assert.Len(t, docs, 5)
+	assert.Equal(t, path, docs[0].Metadata["source"])
+}
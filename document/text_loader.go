@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/kart-io/goagent/core"
 	"github.com/kart-io/goagent/interfaces"
@@ -96,7 +97,7 @@ func (l *TextLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([
 
 // DirectoryLoader 目录加载器
 //
-// 批量加载目录中的文件
+// 批量加载目录中的文件,默认按扩展名选择对应的加载器
 type DirectoryLoader struct {
 	*BaseDocumentLoader
 	dirPath   string
@@ -113,6 +114,9 @@ type DirectoryLoaderConfig struct {
 	Loader          func(string) DocumentLoader
 	Metadata        map[string]interface{}
 	CallbackManager *core.CallbackManager
+
+	// ExtensionLoaders 按扩展名(如 ".pdf")覆盖默认加载器,仅在 Loader 为空时生效
+	ExtensionLoaders map[string]func(string) DocumentLoader
 }
 
 // NewDirectoryLoader 创建目录加载器
@@ -122,11 +126,13 @@ func NewDirectoryLoader(config DirectoryLoaderConfig) *DirectoryLoader {
 	}
 
 	if config.Loader == nil {
-		// 默认使用文本加载器
+		// 默认按扩展名分发,未知类型使用文本加载器
+		overrides := config.ExtensionLoaders
 		config.Loader = func(path string) DocumentLoader {
-			return NewTextLoader(TextLoaderConfig{
-				FilePath: path,
-			})
+			if loader, ok := overrides[strings.ToLower(filepath.Ext(path))]; ok {
+				return loader(path)
+			}
+			return LoaderForFile(path)
 		}
 	}
 
@@ -222,3 +228,28 @@ func (l *DirectoryLoader) Load(ctx context.Context) ([]*interfaces.Document, err
 func (l *DirectoryLoader) LoadAndSplit(ctx context.Context, splitter TextSplitter) ([]*interfaces.Document, error) {
 	return l.BaseDocumentLoader.LoadAndSplit(ctx, l, splitter)
 }
+
+// LoaderForFile 根据文件扩展名选择加载器
+//
+// 支持 .pdf、.html/.htm、.csv/.tsv、.docx、.json/.jsonl、.md/.markdown,
+// 其余文件按纯文本加载
+func LoaderForFile(path string) DocumentLoader {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return NewPDFLoader(PDFLoaderConfig{FilePath: path})
+	case ".html", ".htm":
+		return NewHTMLLoader(HTMLLoaderConfig{FilePath: path})
+	case ".csv", ".tsv":
+		return NewCSVLoader(CSVLoaderConfig{FilePath: path})
+	case ".docx":
+		return NewDOCXLoader(DOCXLoaderConfig{FilePath: path})
+	case ".json":
+		return NewJSONLoader(JSONLoaderConfig{FilePath: path})
+	case ".jsonl":
+		return NewJSONLoader(JSONLoaderConfig{FilePath: path, JSONLines: true})
+	case ".md", ".markdown":
+		return NewMarkdownLoader(MarkdownLoaderConfig{FilePath: path})
+	default:
+		return NewTextLoader(TextLoaderConfig{FilePath: path})
+	}
+}
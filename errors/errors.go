@@ -46,6 +46,7 @@ const (
 	CodeLLMResponse  ErrorCode = "LLM_RESPONSE"
 	CodeLLMTimeout   ErrorCode = "LLM_TIMEOUT"
 	CodeLLMRateLimit ErrorCode = "LLM_RATE_LIMIT"
+	CodeLLMBudget    ErrorCode = "LLM_BUDGET_EXCEEDED"
 
 	// Context errors
 	CodeContextCanceled ErrorCode = "CONTEXT_CANCELED"
@@ -96,6 +97,28 @@ const (
 	CodeRouterNoMatch  ErrorCode = "ROUTER_NO_MATCH"
 	CodeRouterFailed   ErrorCode = "ROUTER_FAILED"
 	CodeRouterOverload ErrorCode = "ROUTER_OVERLOAD"
+
+	// Guardrail errors
+	CodeGuardrailBlocked ErrorCode = "GUARDRAIL_BLOCKED"
+	CodeGuardrailCheck   ErrorCode = "GUARDRAIL_CHECK"
+
+	// Policy errors
+	CodePolicyDenied ErrorCode = "POLICY_DENIED"
+	CodePolicyAudit  ErrorCode = "POLICY_AUDIT"
+
+	// Sandbox errors
+	CodeSandboxUnavailable ErrorCode = "SANDBOX_UNAVAILABLE"
+
+	// Filesystem errors
+	CodeFSPathEscape    ErrorCode = "FS_PATH_ESCAPE"
+	CodeFSLimitExceeded ErrorCode = "FS_LIMIT_EXCEEDED"
+	CodeFSReadOnly      ErrorCode = "FS_READ_ONLY"
+	CodeFSJournal       ErrorCode = "FS_JOURNAL"
+
+	// Secret errors
+	CodeSecretNotFound ErrorCode = "SECRET_NOT_FOUND"
+	CodeSecretStore    ErrorCode = "SECRET_STORE"
+	CodeSecretDenied   ErrorCode = "SECRET_DENIED"
 )
 
 // AgentError is the structured error type for all agent operations
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/sandbox"
 	"github.com/kart-io/goagent/toolkits"
 	"github.com/kart-io/goagent/tools"
 	"github.com/kart-io/goagent/tools/compute"
@@ -16,6 +17,9 @@ import (
 )
 
 func main() {
+	// Apply sandbox limits and exit when re-executed as the sandbox helper
+	sandbox.MaybeRunHelper()
+
 	fmt.Println("=== Tools System Examples ===")
 	fmt.Println()
 
@@ -0,0 +1,386 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/parsers"
+	"github.com/kart-io/goagent/privacy"
+	"github.com/kart-io/goagent/utils/jsonschema"
+)
+
+// PatternCheck fires when any of its regular expressions matches. Matches
+// are reported as spans so the check can redact as well as block.
+type PatternCheck struct {
+	name     string
+	reason   string
+	label    string
+	patterns []*regexp.Regexp
+}
+
+// NewPatternCheck creates a check from regular expressions
+func NewPatternCheck(name, reason string, patterns ...string) (*PatternCheck, error) {
+	check := &PatternCheck{name: name, reason: reason, label: strings.ToUpper(name)}
+	if err := check.add(patterns...); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// Name returns the check name
+func (c *PatternCheck) Name() string { return c.name }
+
+// Evaluate reports every match of the patterns
+func (c *PatternCheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	var spans []Span
+	for _, pattern := range c.patterns {
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			spans = append(spans, Span{Start: loc[0], End: loc[1], Label: c.label})
+		}
+	}
+	if len(spans) == 0 {
+		return nil, nil
+	}
+	spans = sortedSpans(spans)
+	return &Violation{
+		Check:  c.name,
+		Reason: fmt.Sprintf("%s: %q", c.reason, truncate(text[spans[0].Start:spans[0].End], 80)),
+		Score:  1,
+		Spans:  spans,
+	}, nil
+}
+
+// add compiles patterns case-insensitively
+func (c *PatternCheck) add(patterns ...string) error {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeInvalidConfig, "invalid guardrail pattern").
+				WithComponent("guardrails").
+				WithOperation("compile").
+				WithContext("check", c.name).
+				WithContext("pattern", pattern)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+	return nil
+}
+
+// Built-in prompt-injection heuristics: attempts to override, replace or
+// expose the instructions the model was given
+var injectionPatterns = []string{
+	`\b(ignore|disregard|forget|skip|override)\s+(all\s+|any\s+)?(of\s+)?(the\s+|your\s+|my\s+)?(previous|prior|above|earlier|preceding|original|system)\s+(instructions?|prompts?|messages?|rules|directions|context)`,
+	`\b(reveal|print|show|repeat|output|display|leak)\s+(me\s+)?(your|the)\s+(system\s+prompt|initial\s+(prompt|instructions)|hidden\s+(prompt|instructions)|instructions\s+above)`,
+	`\bnew\s+(system\s+)?instructions?\s*:`,
+	`</?\s*(system|assistant|im_start|im_end)\s*>`,
+	`(?m)^\s*(system|assistant)\s*:`,
+	`\bfrom\s+now\s+on,?\s+(you\s+)?(will|must|shall)\s+(only\s+)?(obey|follow|answer)`,
+}
+
+// Built-in jailbreak patterns: role-play and mode switches that try to lift
+// the model's safety rules
+var jailbreakPatterns = []string{
+	`\bdo\s+anything\s+now\b`,
+	`\b(developer|god|dan|jailbreak|unrestricted)\s+mode\b`,
+	`\bjailbr(eak|oken)\b`,
+	`\b(answer|respond|reply|act|behave|talk)\s+without\s+(any\s+)?(restrictions|filters?|limitations|censorship|rules|guidelines)`,
+	`\bpretend\s+(that\s+)?you\s+(are|have)\s+(no\s+(rules|restrictions|filters|guidelines)|not\s+bound|an?\s+(unrestricted|unfiltered|uncensored))`,
+	`\byou\s+are\s+(no\s+longer|not)\s+bound\s+by\b`,
+	`\b(unfiltered|uncensored|unrestricted)\s+(ai|assistant|model|chatbot|version)\b`,
+}
+
+// InjectionCheck detects prompt injection with heuristics and, optionally,
+// a classifier consulted when the heuristics do not fire
+type InjectionCheck struct {
+	*PatternCheck
+	classifier Judge
+	threshold  float64
+}
+
+// InjectionOption configures PromptInjection
+type InjectionOption func(*InjectionCheck)
+
+// WithInjectionPatterns adds heuristic patterns (case-insensitive regular
+// expressions); invalid patterns panic, as with regexp.MustCompile
+func WithInjectionPatterns(patterns ...string) InjectionOption {
+	return func(c *InjectionCheck) {
+		if err := c.add(patterns...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// WithInjectionClassifier consults judge when no heuristic matches; texts
+// scoring at least threshold are reported. NewLLMJudge(client,
+// InjectionCriteria) turns a small LLM into such a classifier.
+func WithInjectionClassifier(judge Judge, threshold float64) InjectionOption {
+	return func(c *InjectionCheck) {
+		c.classifier = judge
+		c.threshold = threshold
+	}
+}
+
+// PromptInjection creates the prompt-injection check
+func PromptInjection(opts ...InjectionOption) *InjectionCheck {
+	pattern, _ := NewPatternCheck("prompt_injection", "possible prompt injection", injectionPatterns...)
+	check := &InjectionCheck{PatternCheck: pattern}
+	for _, opt := range opts {
+		opt(check)
+	}
+	return check
+}
+
+// Evaluate runs the heuristics, then the classifier
+func (c *InjectionCheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	violation, err := c.PatternCheck.Evaluate(ctx, text)
+	if violation != nil || err != nil || c.classifier == nil {
+		return violation, err
+	}
+	return judged(ctx, c.Name(), c.classifier, c.threshold, text)
+}
+
+// Jailbreak creates the jailbreak pattern check
+func Jailbreak(patterns ...string) *PatternCheck {
+	check, _ := NewPatternCheck("jailbreak", "jailbreak attempt", jailbreakPatterns...)
+	if err := check.add(patterns...); err != nil {
+		panic(err)
+	}
+	return check
+}
+
+// PIICheck reports personal data found by privacy detectors
+type PIICheck struct {
+	detectors []privacy.Detector
+}
+
+// PII creates a PII leakage check; without detectors it uses
+// privacy.DefaultDetectors (emails, phone numbers, card numbers, IBANs)
+func PII(detectors ...privacy.Detector) *PIICheck {
+	if len(detectors) == 0 {
+		detectors = privacy.DefaultDetectors()
+	}
+	return &PIICheck{detectors: detectors}
+}
+
+// Name returns the check name
+func (c *PIICheck) Name() string { return "pii" }
+
+// Evaluate reports each PII match, labelled with its type
+func (c *PIICheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	matches := privacy.Detect(text, c.detectors)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	spans := make([]Span, len(matches))
+	types := make([]string, 0, len(matches))
+	seen := make(map[privacy.PIIType]bool)
+	for i, match := range matches {
+		spans[i] = Span{Start: match.Start, End: match.End, Label: string(match.Type)}
+		if !seen[match.Type] {
+			seen[match.Type] = true
+			types = append(types, string(match.Type))
+		}
+	}
+	return &Violation{
+		Check:  c.Name(),
+		Reason: "personal data found: " + strings.Join(types, ", "),
+		Score:  1,
+		Spans:  spans,
+	}, nil
+}
+
+// Topic is a named set of keywords or phrases
+type Topic struct {
+	Name     string   `json:"name" yaml:"name"`
+	Keywords []string `json:"keywords" yaml:"keywords"`
+}
+
+// TopicPolicy lists allowed and denied topics. With a non-empty Allow list a
+// text must mention at least one allowed topic.
+type TopicPolicy struct {
+	Allow []Topic `json:"allow" yaml:"allow"`
+	Deny  []Topic `json:"deny" yaml:"deny"`
+}
+
+// TopicCheck enforces a TopicPolicy with whole-word keyword matching
+type TopicCheck struct {
+	allow []compiledTopic
+	deny  []compiledTopic
+}
+
+type compiledTopic struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Topics creates a topic allow/deny check
+func Topics(policy TopicPolicy) *TopicCheck {
+	return &TopicCheck{allow: compileTopics(policy.Allow), deny: compileTopics(policy.Deny)}
+}
+
+// Name returns the check name
+func (c *TopicCheck) Name() string { return "topics" }
+
+// Evaluate reports denied topics, then texts matching no allowed topic
+func (c *TopicCheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	var spans []Span
+	var denied []string
+	for _, topic := range c.deny {
+		locs := topic.pattern.FindAllStringIndex(text, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		denied = append(denied, topic.name)
+		for _, loc := range locs {
+			spans = append(spans, Span{Start: loc[0], End: loc[1], Label: "TOPIC:" + strings.ToUpper(topic.name)})
+		}
+	}
+	if len(denied) > 0 {
+		return &Violation{Check: c.Name(), Reason: "denied topic: " + strings.Join(denied, ", "), Score: 1, Spans: sortedSpans(spans)}, nil
+	}
+
+	if len(c.allow) == 0 {
+		return nil, nil
+	}
+	for _, topic := range c.allow {
+		if topic.pattern.MatchString(text) {
+			return nil, nil
+		}
+	}
+	return &Violation{Check: c.Name(), Reason: "text is outside the allowed topics", Score: 1}, nil
+}
+
+func compileTopics(topics []Topic) []compiledTopic {
+	compiled := make([]compiledTopic, 0, len(topics))
+	for _, topic := range topics {
+		alternatives := make([]string, 0, len(topic.Keywords))
+		for _, keyword := range topic.Keywords {
+			words := strings.Fields(keyword)
+			for i, word := range words {
+				words[i] = regexp.QuoteMeta(word)
+			}
+			if len(words) > 0 {
+				alternatives = append(alternatives, strings.Join(words, `\s+`))
+			}
+		}
+		if len(alternatives) == 0 {
+			continue
+		}
+		compiled = append(compiled, compiledTopic{
+			name:    topic.Name,
+			pattern: regexp.MustCompile(`(?i)\b(` + strings.Join(alternatives, "|") + `)\b`),
+		})
+	}
+	return compiled
+}
+
+// SchemaCheck requires the text to be JSON conforming to a schema
+type SchemaCheck struct {
+	schema map[string]interface{}
+}
+
+// JSONSchema creates a JSON-schema conformance check. The text may wrap the
+// JSON in a Markdown code block. The supported schema subset is documented
+// in utils/jsonschema.
+func JSONSchema(schema map[string]interface{}) *SchemaCheck {
+	return &SchemaCheck{schema: schema}
+}
+
+// Name returns the check name
+func (c *SchemaCheck) Name() string { return "json_schema" }
+
+// Evaluate parses and validates the text
+func (c *SchemaCheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	value, err := parsers.NewJSONOutputParser[interface{}](false).Parse(ctx, text)
+	if err != nil {
+		return &Violation{Check: c.Name(), Reason: "text is not valid JSON", Score: 1}, nil
+	}
+	if violations := jsonschema.Validate(c.schema, value); len(violations) > 0 {
+		return &Violation{Check: c.Name(), Reason: strings.Join(violations, "; "), Score: 1}, nil
+	}
+	return nil, nil
+}
+
+// LengthCheck limits the length of a text in characters
+type LengthCheck struct {
+	max int
+}
+
+// MaxLength creates a length check. The overflow is reported as a span, so
+// ActionRedact truncates the text.
+func MaxLength(max int) *LengthCheck {
+	return &LengthCheck{max: max}
+}
+
+// Name returns the check name
+func (c *LengthCheck) Name() string { return "max_length" }
+
+// Evaluate reports the characters beyond the limit
+func (c *LengthCheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	length := utf8.RuneCountInString(text)
+	if length <= c.max {
+		return nil, nil
+	}
+	offset, count := len(text), 0
+	for i := range text {
+		if count == c.max {
+			offset = i
+			break
+		}
+		count++
+	}
+	return &Violation{
+		Check:  c.Name(),
+		Reason: fmt.Sprintf("text has %d characters, limit is %d", length, c.max),
+		Score:  1,
+		Spans:  []Span{{Start: offset, End: len(text), Label: "TRUNCATED"}},
+	}, nil
+}
+
+// ToxicityCheck scores texts with a pluggable judge
+type ToxicityCheck struct {
+	judge     Judge
+	threshold float64
+}
+
+// Toxicity creates a toxicity check; texts scoring at least threshold are
+// reported. NewLLMJudge(client, ToxicityCriteria) provides an LLM judge.
+func Toxicity(judge Judge, threshold float64) *ToxicityCheck {
+	return &ToxicityCheck{judge: judge, threshold: threshold}
+}
+
+// Name returns the check name
+func (c *ToxicityCheck) Name() string { return "toxicity" }
+
+// Evaluate asks the judge
+func (c *ToxicityCheck) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	return judged(ctx, c.Name(), c.judge, c.threshold, text)
+}
+
+// judged turns a judge score into a violation
+func judged(ctx context.Context, name string, judge Judge, threshold float64, text string) (*Violation, error) {
+	score, reason, err := judge.Judge(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if score < threshold {
+		return nil, nil
+	}
+	if reason == "" {
+		reason = fmt.Sprintf("score %.2f exceeds %.2f", score, threshold)
+	}
+	return &Violation{Check: name, Reason: reason, Score: score}, nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
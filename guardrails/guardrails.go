@@ -0,0 +1,170 @@
+// Package guardrails provides composable safety checks for agent input,
+// output and tool results.
+//
+// A Pipeline runs a list of rules. Each rule pairs a Check (prompt
+// injection, jailbreak patterns, PII leakage, topic lists, JSON schema,
+// length, toxicity or any custom check) with the Action to take when it
+// fires and the stages it applies to:
+//
+//	pipeline := guardrails.NewPipeline(
+//		guardrails.WithRule(guardrails.PromptInjection(), guardrails.ActionBlock, guardrails.StageInput, guardrails.StageToolOutput),
+//		guardrails.WithRule(guardrails.Jailbreak(), guardrails.ActionBlock, guardrails.StageInput),
+//		guardrails.WithRule(guardrails.PII(), guardrails.ActionRedact),
+//		guardrails.WithRule(guardrails.MaxLength(4000), guardrails.ActionRedact, guardrails.StageOutput),
+//	)
+//
+// The pipeline plugs into core/middleware.MiddlewareChain through
+// Pipeline.Middleware, into builder.AgentBuilder through WithGuardrails, and
+// wraps tools with Pipeline.Tool so that tool results are checked before they
+// re-enter the prompt.
+package guardrails
+
+import (
+	"context"
+	"strings"
+)
+
+// Action is what the pipeline does when a check fires
+type Action string
+
+// Actions
+const (
+	// ActionBlock rejects the text with a CodeGuardrailBlocked error
+	ActionBlock Action = "block"
+	// ActionRedact replaces the offending spans, or the whole text when the
+	// check reports no spans
+	ActionRedact Action = "redact"
+	// ActionRewrite replaces the text with the output of a Rewriter
+	ActionRewrite Action = "rewrite"
+	// ActionWarn lets the text through and only reports the violation
+	ActionWarn Action = "warn"
+)
+
+// Stage is the point of the agent loop a text is checked at
+type Stage string
+
+// Stages
+const (
+	StageInput      Stage = "input"
+	StageOutput     Stage = "output"
+	StageToolOutput Stage = "tool_output"
+)
+
+// Span is an offending byte range of a checked text
+type Span struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Label string `json:"label"`
+}
+
+// Violation is a failed check
+type Violation struct {
+	Check  string  `json:"check"`
+	Reason string  `json:"reason"`
+	Score  float64 `json:"score,omitempty"`
+	Spans  []Span  `json:"spans,omitempty"`
+}
+
+// Check inspects a text. It returns nil when the text passes.
+type Check interface {
+	// Name identifies the check in violations and reports
+	Name() string
+
+	// Evaluate inspects text and returns the violation, if any
+	Evaluate(ctx context.Context, text string) (*Violation, error)
+}
+
+// CheckFunc adapts a function to the Check interface
+type CheckFunc struct {
+	name string
+	fn   func(ctx context.Context, text string) (*Violation, error)
+}
+
+// NewCheckFunc creates a custom check
+func NewCheckFunc(name string, fn func(ctx context.Context, text string) (*Violation, error)) *CheckFunc {
+	return &CheckFunc{name: name, fn: fn}
+}
+
+// Name returns the check name
+func (c *CheckFunc) Name() string { return c.name }
+
+// Evaluate runs the function
+func (c *CheckFunc) Evaluate(ctx context.Context, text string) (*Violation, error) {
+	violation, err := c.fn(ctx, text)
+	if violation != nil && violation.Check == "" {
+		violation.Check = c.name
+	}
+	return violation, err
+}
+
+// Rule binds a check to an action and the stages it applies to
+type Rule struct {
+	Check  Check
+	Action Action
+	// Stages the rule applies to; empty means every stage
+	Stages []Stage
+	// Rewriter used by ActionRewrite; defaults to the pipeline rewriter
+	Rewriter Rewriter
+}
+
+// appliesTo reports whether the rule runs at stage
+func (r *Rule) appliesTo(stage Stage) bool {
+	if len(r.Stages) == 0 {
+		return true
+	}
+	for _, s := range r.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Finding is a violation together with the action taken
+type Finding struct {
+	Violation
+	Action Action `json:"action"`
+}
+
+// Report describes one pipeline pass
+type Report struct {
+	Stage Stage `json:"stage"`
+	// Source names the tool for StageToolOutput
+	Source   string    `json:"source,omitempty"`
+	Findings []Finding `json:"findings,omitempty"`
+	Blocked  bool      `json:"blocked"`
+	Modified bool      `json:"modified"`
+}
+
+// Passed reports whether no check fired
+func (r *Report) Passed() bool {
+	return len(r.Findings) == 0
+}
+
+// redact replaces the spans of text with their labels in brackets
+func redact(text string, spans []Span) string {
+	if len(spans) == 0 {
+		return "[REDACTED]"
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, span := range sortedSpans(spans) {
+		if span.Start < last {
+			// overlapping spans are covered by the previous replacement
+			if span.End > last {
+				last = span.End
+			}
+			continue
+		}
+		b.WriteString(text[last:span.Start])
+		label := span.Label
+		if label == "" {
+			label = "REDACTED"
+		}
+		b.WriteString("[" + label + "]")
+		last = span.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
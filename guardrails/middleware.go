@@ -0,0 +1,129 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/core/middleware"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// Metadata keys under which the middleware stores non-passing reports
+const (
+	MetadataInputReport  = "guardrails_input"
+	MetadataOutputReport = "guardrails_output"
+)
+
+// Middleware adapts a pipeline to core/middleware
+type Middleware struct {
+	*middleware.BaseMiddleware
+	pipeline *Pipeline
+}
+
+// Middleware returns a middleware that checks requests at StageInput and
+// string responses at StageOutput. String inputs and the Task and
+// Instruction of *core.AgentInput are checked in place; other inputs are
+// checked in their JSON form and replaced by the resulting text when a rule
+// modified it.
+func (p *Pipeline) Middleware() *Middleware {
+	return &Middleware{
+		BaseMiddleware: middleware.NewBaseMiddleware("guardrails"),
+		pipeline:       p,
+	}
+}
+
+// OnBefore checks the request input
+func (m *Middleware) OnBefore(ctx context.Context, request *middleware.MiddlewareRequest) (*middleware.MiddlewareRequest, error) {
+	var (
+		report *Report
+		err    error
+	)
+	switch input := request.Input.(type) {
+	case nil:
+		return request, nil
+	case string:
+		var text string
+		text, report, err = m.pipeline.Input(ctx, input)
+		if err == nil {
+			request.Input = text
+		}
+	case *core.AgentInput:
+		report, err = m.checkAgentInput(ctx, input)
+	default:
+		original := stringify(input)
+		var text string
+		text, report, err = m.pipeline.Input(ctx, original)
+		if err == nil && text != original {
+			request.Input = text
+		}
+	}
+
+	attach(&request.Metadata, MetadataInputReport, report)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// OnAfter checks string outputs
+func (m *Middleware) OnAfter(ctx context.Context, response *middleware.MiddlewareResponse) (*middleware.MiddlewareResponse, error) {
+	output, ok := response.Output.(string)
+	if !ok {
+		return response, nil
+	}
+	text, report, err := m.pipeline.Output(ctx, output)
+	attach(&response.Metadata, MetadataOutputReport, report)
+	if err != nil {
+		return nil, err
+	}
+	response.Output = text
+	return response, nil
+}
+
+// checkAgentInput checks Task and Instruction as one input pass
+func (m *Middleware) checkAgentInput(ctx context.Context, input *core.AgentInput) (*Report, error) {
+	merged := &Report{Stage: StageInput}
+	for _, field := range []*string{&input.Task, &input.Instruction} {
+		if *field == "" {
+			continue
+		}
+		text, report, err := m.pipeline.Input(ctx, *field)
+		merged.Findings = append(merged.Findings, report.Findings...)
+		merged.Blocked = merged.Blocked || report.Blocked
+		merged.Modified = merged.Modified || report.Modified
+		if err != nil {
+			return merged, err
+		}
+		*field = text
+	}
+	return merged, nil
+}
+
+// attach stores a non-passing report in metadata
+func attach(metadata *map[string]interface{}, key string, report *Report) {
+	if report == nil || report.Passed() {
+		return
+	}
+	if *metadata == nil {
+		*metadata = make(map[string]interface{})
+	}
+	(*metadata)[key] = report
+}
+
+// stringify renders a value as text for checking
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
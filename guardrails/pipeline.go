@@ -0,0 +1,165 @@
+package guardrails
+
+import (
+	"context"
+	"sort"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Handler receives the report of every pipeline pass with at least one
+// finding, including warnings
+type Handler func(ctx context.Context, report *Report)
+
+// Option configures a Pipeline
+type Option func(*Pipeline)
+
+// WithRule adds a rule. stages limits the rule to the given stages; without
+// stages it applies everywhere.
+func WithRule(check Check, action Action, stages ...Stage) Option {
+	return func(p *Pipeline) {
+		p.rules = append(p.rules, Rule{Check: check, Action: action, Stages: stages})
+	}
+}
+
+// WithRules adds fully specified rules
+func WithRules(rules ...Rule) Option {
+	return func(p *Pipeline) {
+		p.rules = append(p.rules, rules...)
+	}
+}
+
+// WithRewriter sets the rewriter used by ActionRewrite rules without their
+// own (default: StaticRewriter(DefaultRewriteMessage))
+func WithRewriter(rewriter Rewriter) Option {
+	return func(p *Pipeline) {
+		p.rewriter = rewriter
+	}
+}
+
+// WithHandler sets a function notified of every finding
+func WithHandler(handler Handler) Option {
+	return func(p *Pipeline) {
+		p.handler = handler
+	}
+}
+
+// WithFailOpen lets texts through when a check itself fails (for example
+// when a classifier LLM is unavailable). By default such errors are returned
+// with CodeGuardrailCheck.
+func WithFailOpen(failOpen bool) Option {
+	return func(p *Pipeline) {
+		p.failOpen = failOpen
+	}
+}
+
+// Pipeline runs rules in order; each rule sees the text as left by the
+// previous ones
+type Pipeline struct {
+	rules    []Rule
+	rewriter Rewriter
+	handler  Handler
+	failOpen bool
+}
+
+// NewPipeline creates a pipeline
+func NewPipeline(opts ...Option) *Pipeline {
+	p := &Pipeline{rewriter: StaticRewriter(DefaultRewriteMessage)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Input checks agent input
+func (p *Pipeline) Input(ctx context.Context, text string) (string, *Report, error) {
+	return p.Apply(ctx, StageInput, "", text)
+}
+
+// Output checks agent output
+func (p *Pipeline) Output(ctx context.Context, text string) (string, *Report, error) {
+	return p.Apply(ctx, StageOutput, "", text)
+}
+
+// ToolOutput checks the result of a tool before it re-enters the prompt
+func (p *Pipeline) ToolOutput(ctx context.Context, toolName, text string) (string, *Report, error) {
+	return p.Apply(ctx, StageToolOutput, toolName, text)
+}
+
+// Apply runs the rules of stage on text and returns the resulting text.
+// A blocking finding stops the pass and returns a CodeGuardrailBlocked error
+// together with the report.
+func (p *Pipeline) Apply(ctx context.Context, stage Stage, source, text string) (string, *Report, error) {
+	report := &Report{Stage: stage, Source: source}
+	defer p.notify(ctx, report)
+
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !rule.appliesTo(stage) {
+			continue
+		}
+
+		violation, err := rule.Check.Evaluate(ctx, text)
+		if err != nil {
+			if p.failOpen {
+				continue
+			}
+			return text, report, agentErrors.Wrap(err, agentErrors.CodeGuardrailCheck, "guardrail check failed").
+				WithComponent("guardrails").
+				WithOperation(string(stage)).
+				WithContext("check", rule.Check.Name())
+		}
+		if violation == nil {
+			continue
+		}
+		if violation.Check == "" {
+			violation.Check = rule.Check.Name()
+		}
+		report.Findings = append(report.Findings, Finding{Violation: *violation, Action: rule.Action})
+
+		switch rule.Action {
+		case ActionBlock:
+			report.Blocked = true
+			return text, report, agentErrors.New(agentErrors.CodeGuardrailBlocked, "blocked by guardrail: "+violation.Reason).
+				WithComponent("guardrails").
+				WithOperation(string(stage)).
+				WithContext("check", violation.Check).
+				WithContext("source", source)
+		case ActionRedact:
+			text = redact(text, violation.Spans)
+			report.Modified = true
+		case ActionRewrite:
+			rewriter := rule.Rewriter
+			if rewriter == nil {
+				rewriter = p.rewriter
+			}
+			rewritten, err := rewriter.Rewrite(ctx, text, violation)
+			if err != nil {
+				return text, report, agentErrors.Wrap(err, agentErrors.CodeGuardrailCheck, "guardrail rewrite failed").
+					WithComponent("guardrails").
+					WithOperation(string(stage)).
+					WithContext("check", violation.Check)
+			}
+			text = rewritten
+			report.Modified = true
+		}
+	}
+	return text, report, nil
+}
+
+// notify passes reports with findings to the handler
+func (p *Pipeline) notify(ctx context.Context, report *Report) {
+	if p.handler != nil && !report.Passed() {
+		p.handler(ctx, report)
+	}
+}
+
+// sortedSpans returns the spans ordered by start offset
+func sortedSpans(spans []Span) []Span {
+	sorted := make([]Span, len(spans))
+	copy(sorted, spans)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	return sorted
+}
@@ -0,0 +1,79 @@
+package guardrails
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// Tool is an interfaces.Tool whose results are checked at StageToolOutput
+// before they re-enter the prompt. This is the main defence against indirect
+// prompt injection through fetched pages, files or API responses.
+type Tool struct {
+	pipeline *Pipeline
+	inner    interfaces.Tool
+}
+
+// Tool wraps a tool
+func (p *Pipeline) Tool(inner interfaces.Tool) *Tool {
+	return &Tool{pipeline: p, inner: inner}
+}
+
+// Tools wraps every tool of the slice
+func (p *Pipeline) Tools(tools []interfaces.Tool) []interfaces.Tool {
+	wrapped := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = p.Tool(tool)
+	}
+	return wrapped
+}
+
+// Name returns the wrapped tool name
+func (t *Tool) Name() string { return t.inner.Name() }
+
+// Description returns the wrapped tool description
+func (t *Tool) Description() string { return t.inner.Description() }
+
+// ArgsSchema returns the wrapped tool schema
+func (t *Tool) ArgsSchema() string { return t.inner.ArgsSchema() }
+
+// Invoke runs the tool and checks its result. Non-string results are checked
+// in their JSON form and replaced by the resulting text when a rule modified
+// it. A blocked result is turned into a failed ToolOutput rather than an
+// error, so the agent can continue without the tainted data.
+func (t *Tool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	output, err := t.inner.Invoke(ctx, input)
+	if err != nil || output == nil || output.Result == nil {
+		return output, err
+	}
+
+	original := stringify(output.Result)
+	text, report, err := t.pipeline.ToolOutput(ctx, t.inner.Name(), original)
+	if report != nil && report.Blocked {
+		return &interfaces.ToolOutput{
+			Success:  false,
+			Error:    "tool output blocked by guardrail: " + report.Findings[len(report.Findings)-1].Reason,
+			Metadata: withReport(output.Metadata, report),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if report.Modified && text != original {
+		output.Result = text
+	}
+	if !report.Passed() {
+		output.Metadata = withReport(output.Metadata, report)
+	}
+	return output, nil
+}
+
+// withReport copies metadata and adds the report
+func withReport(metadata map[string]interface{}, report *Report) map[string]interface{} {
+	copied := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	copied["guardrails"] = report
+	return copied
+}
@@ -291,6 +291,19 @@ type Case struct {
 	// Not persisted in storage.
 	Similarity float64 `json:"similarity,omitempty"`
 
+	// Utility estimates how likely applying this case leads to success
+	// (0.0-1.0), learned from outcome feedback.
+	//
+	// Case-based memories rank search results by a blend of
+	// Similarity and Utility.
+	Utility float64 `json:"utility,omitempty"`
+
+	// SuccessCount is the number of times applying this case succeeded.
+	SuccessCount int `json:"success_count,omitempty"`
+
+	// FailureCount is the number of times applying this case failed.
+	FailureCount int `json:"failure_count,omitempty"`
+
 	// CreatedAt is when the case was first added.
 	CreatedAt time.Time `json:"created_at"`
 
@@ -303,6 +316,8 @@ type Case struct {
 	//
 	// Optional. May include:
 	//   - source: Where this case came from
+	//   - plan: Plan steps of the agent run the case was created from
+	//   - tool_trace: Tool calls of that run
 	//   - author: Who created the case
 	//   - success_rate: How often this solution works
 	//   - related_cases: IDs of related cases
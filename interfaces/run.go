@@ -0,0 +1,29 @@
+package interfaces
+
+import "context"
+
+// runIDContextKey is the context key carrying the current run ID.
+type runIDContextKey struct{}
+
+// WithRunID returns a context carrying the ID of an agent run.
+//
+// Callbacks that pair the start of a run with its end (such as
+// memory.CaseRecorder) key their state by the run ID, so concurrent runs
+// sharing a parent context do not collide. Set a distinct ID per run.
+//
+// Example:
+//
+//	ctx = interfaces.WithRunID(ctx, uuid.NewString())
+//	output, err := agent.Invoke(ctx, input)
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDContextKey{}, runID)
+}
+
+// RunIDFromContext returns the run ID carried by the context.
+func RunIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	runID, ok := ctx.Value(runIDContextKey{}).(string)
+	return runID, ok && runID != ""
+}
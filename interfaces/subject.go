@@ -0,0 +1,45 @@
+package interfaces
+
+import "context"
+
+// SubjectMetadataKey is the metadata key under which components tag stored
+// data with the data subject (end user or tenant) it belongs to.
+const SubjectMetadataKey = "subject_id"
+
+// subjectContextKey is the context key carrying the current data subject.
+type subjectContextKey struct{}
+
+// WithSubject returns a context carrying the data subject that owns the data
+// written while handling the request.
+//
+// Components that hold user data (memory, vector stores, checkpointers,
+// LLM and tool caches) use it to tag what they store, so that everything
+// belonging to a subject can later be erased with SubjectForgetter.
+//
+// Example:
+//
+//	ctx = interfaces.WithSubject(ctx, "user-42")
+//	resp, err := client.Chat(ctx, messages)
+func WithSubject(ctx context.Context, subjectID string) context.Context {
+	return context.WithValue(ctx, subjectContextKey{}, subjectID)
+}
+
+// SubjectFromContext returns the data subject carried by the context.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	subjectID, ok := ctx.Value(subjectContextKey{}).(string)
+	return subjectID, ok && subjectID != ""
+}
+
+// SubjectForgetter is implemented by components that can erase all data
+// belonging to a data subject (GDPR right to be forgotten).
+//
+// Implementations: tools.MemoryToolCache, tools.ShardedToolCache,
+// cache.MemorySemanticCache and the wrappers in the privacy package.
+type SubjectForgetter interface {
+	// ForgetSubject removes everything stored for the subject and returns
+	// the number of removed items.
+	ForgetSubject(ctx context.Context, subjectID string) (int, error)
+}
@@ -0,0 +1,20 @@
+package cache
+
+import "github.com/kart-io/goagent/observability"
+
+// RegisterMetrics 将语义缓存的统计导出为 Prometheus 指标
+//
+// 语义缓存按容量淘汰时不计数，驱逐指标恒为 0
+func RegisterMetrics(name string, c SemanticCache) {
+	observability.RegisterCacheStats(name, "llm_semantic", func() observability.CacheSnapshot {
+		stats := c.Stats()
+		if stats == nil {
+			return observability.CacheSnapshot{}
+		}
+		return observability.CacheSnapshot{
+			Hits:    stats.TotalHits,
+			Misses:  stats.TotalMisses,
+			Entries: stats.TotalEntries,
+		}
+	})
+}
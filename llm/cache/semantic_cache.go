@@ -10,6 +10,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/kart-io/goagent/interfaces"
 )
 
 // MemorySemanticCache implements SemanticCache with in-memory storage
@@ -70,8 +72,11 @@ func (c *MemorySemanticCache) Get(ctx context.Context, prompt string, model stri
 		return nil, 0, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
+	// Entries of other data subjects are never served
+	subjectID, _ := interfaces.SubjectFromContext(ctx)
+
 	c.mu.RLock()
-	entries := c.getEntriesForModel(model)
+	entries := c.getEntriesForModel(model, subjectID)
 	c.mu.RUnlock()
 
 	if len(entries) == 0 {
@@ -122,8 +127,10 @@ func (c *MemorySemanticCache) Set(ctx context.Context, prompt string, response s
 		return fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
+	subjectID, _ := interfaces.SubjectFromContext(ctx)
+
 	// Generate key
-	key := generateCacheKey(normalizedPrompt, model)
+	key := generateCacheKey(normalizedPrompt, model, subjectID)
 
 	entry := &CacheEntry{
 		Key:        key,
@@ -132,6 +139,7 @@ func (c *MemorySemanticCache) Set(ctx context.Context, prompt string, response s
 		Response:   response,
 		Model:      model,
 		TokensUsed: tokensUsed,
+		SubjectID:  subjectID,
 		CreatedAt:  time.Now(),
 		AccessedAt: time.Now(),
 		HitCount:   0,
@@ -163,6 +171,23 @@ func (c *MemorySemanticCache) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// ForgetSubject removes all entries stored for a data subject
+func (c *MemorySemanticCache) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for key, entry := range c.entries {
+		if entry.SubjectID == subjectID {
+			delete(c.entries, key)
+			c.removeFromAccessOrder(key)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
 // Clear removes all entries from cache
 func (c *MemorySemanticCache) Clear(ctx context.Context) error {
 	c.mu.Lock()
@@ -219,11 +244,14 @@ func (c *MemorySemanticCache) Close() error {
 	return nil
 }
 
-// getEntriesForModel returns entries for a specific model
-func (c *MemorySemanticCache) getEntriesForModel(model string) []*CacheEntry {
+// getEntriesForModel returns entries for a specific model visible to a data subject
+func (c *MemorySemanticCache) getEntriesForModel(model string, subjectID string) []*CacheEntry {
 	var entries []*CacheEntry
 
 	for _, entry := range c.entries {
+		if entry.SubjectID != subjectID {
+			continue
+		}
 		// Filter by model if model-specific caching is enabled
 		if c.config.ModelSpecific && entry.Model != model {
 			continue
@@ -340,9 +368,12 @@ func (c *MemorySemanticCache) cleanup() {
 	}
 }
 
-// generateCacheKey generates a unique key for a prompt and model
-func generateCacheKey(prompt string, model string) string {
+// generateCacheKey generates a unique key for a prompt, model and data subject
+func generateCacheKey(prompt string, model string, subjectID string) string {
 	data := prompt + "|" + model
+	if subjectID != "" {
+		data += "|" + subjectID
+	}
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/interfaces"
 )
 
 func TestCosineSimilarity(t *testing.T) {
@@ -423,3 +425,33 @@ func BenchmarkCosineSimilarity(b *testing.B) {
 		CosineSimilarity(a, b2)
 	}
 }
+
+func TestMemorySemanticCache_SubjectIsolation(t *testing.T) {
+	provider := NewMockEmbeddingProvider(128)
+	cache := NewMemorySemanticCache(provider, nil)
+	defer cache.Close()
+
+	alice := interfaces.WithSubject(context.Background(), "alice")
+	bob := interfaces.WithSubject(context.Background(), "bob")
+
+	require.NoError(t, cache.Set(alice, "What is my account number?", "DE89 3704 0044 0532 0130 00", "gpt-4", 10))
+
+	entry, _, err := cache.Get(bob, "What is my account number?", "gpt-4")
+	require.NoError(t, err)
+	assert.Nil(t, entry, "entries are not served to other subjects")
+
+	entry, _, err = cache.Get(alice, "What is my account number?", "gpt-4")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "alice", entry.SubjectID)
+
+	require.NoError(t, cache.Set(bob, "What is my account number?", "GB82 WEST 1234 5698 7654 32", "gpt-4", 10))
+	count, err := cache.ForgetSubject(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	entry, _, err = cache.Get(alice, "What is my account number?", "gpt-4")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	assert.Equal(t, int64(1), cache.Stats().TotalEntries)
+}
@@ -25,6 +25,10 @@ type CacheEntry struct {
 	// TokensUsed is the number of tokens consumed
 	TokensUsed int `json:"tokens_used"`
 
+	// SubjectID is the data subject the entry belongs to, taken from
+	// interfaces.WithSubject. Entries are only served to the same subject.
+	SubjectID string `json:"subject_id,omitempty"`
+
 	// CreatedAt is when this entry was created
 	CreatedAt time.Time `json:"created_at"`
 
@@ -1,11 +1,13 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/secrets"
 )
 
 // ClientFactory 用于创建 LLM 客户端的工厂接口
@@ -31,10 +33,12 @@ func NewClientWithOptions(opts ...ClientOption) (Client, error) {
 
 // PrepareConfig 准备和验证配置
 func PrepareConfig(opts *LLMOptions) error {
-	// 从环境变量补充配置
-	if opts.APIKey == "" {
-		opts.APIKey = getAPIKeyFromEnv(opts.Provider)
+	// 解析密钥句柄，或从密钥存储和环境变量补充配置
+	apiKey, err := ResolveAPIKey(context.Background(), opts.APIKey, apiKeyEnvVar(opts.Provider))
+	if err != nil {
+		return err
 	}
+	opts.APIKey = apiKey
 
 	// 验证必要的配置
 	return validateConfig(opts)
@@ -50,8 +54,44 @@ func NeedsEnhancedFeatures(opts *LLMOptions) bool {
 		opts.OrganizationID != ""
 }
 
-// getAPIKeyFromEnv 从环境变量获取 API 密钥
-func getAPIKeyFromEnv(provider constants.Provider) string {
+// ResolveAPIKey 解析 API 密钥（导出给 providers 包使用）
+//
+// 包含 {{secret:NAME}} 句柄的密钥通过默认密钥解析器解析；为空时先按环境变量名
+// 在默认密钥存储中查找，再回退到环境变量。得到的密钥会登记到默认脱敏器，
+// 以便在日志、追踪和回调中被替换为句柄
+func ResolveAPIKey(ctx context.Context, apiKey, envVar string) (string, error) {
+	resolver := secrets.Default()
+
+	switch {
+	case secrets.ContainsRef(apiKey):
+		resolved, err := resolver.Resolve(ctx, apiKey)
+		if err != nil {
+			return "", err
+		}
+		apiKey = resolved
+	case apiKey == "" && envVar != "":
+		value, found, err := resolver.Lookup(ctx, envVar)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			value = os.Getenv(envVar)
+		}
+		apiKey = value
+	}
+
+	if apiKey != "" {
+		name := envVar
+		if name == "" {
+			name = "api_key"
+		}
+		resolver.Masker().Add(name, apiKey)
+	}
+	return apiKey, nil
+}
+
+// apiKeyEnvVar 返回提供商 API 密钥对应的环境变量名
+func apiKeyEnvVar(provider constants.Provider) string {
 	envVarMap := map[constants.Provider]string{
 		constants.ProviderOpenAI:      constants.EnvOpenAIAPIKey,
 		constants.ProviderAnthropic:   constants.EnvAnthropicAPIKey,
@@ -63,10 +103,7 @@ func getAPIKeyFromEnv(provider constants.Provider) string {
 		constants.ProviderHuggingFace: constants.EnvHuggingFaceAPIKey,
 	}
 
-	if envVar, ok := envVarMap[provider]; ok {
-		return os.Getenv(envVar)
-	}
-	return ""
+	return envVarMap[provider]
 }
 
 // validateConfig 验证配置的有效性
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/secrets"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	previous := secrets.Default()
+	defer secrets.SetDefault(previous)
+
+	masker := secrets.NewMasker()
+	secrets.SetDefault(secrets.NewResolver(secrets.Map{
+		"openai":                    "sk-from-store",
+		constants.EnvDeepSeekAPIKey: "sk-deepseek-store",
+	}, secrets.WithMasker(masker)))
+	ctx := context.Background()
+
+	// 句柄从密钥存储解析
+	key, err := ResolveAPIKey(ctx, secrets.Ref("openai"), constants.EnvOpenAIAPIKey)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-from-store", key)
+
+	// 为空时按环境变量名在密钥存储中查找
+	key, err = ResolveAPIKey(ctx, "", constants.EnvDeepSeekAPIKey)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-deepseek-store", key)
+
+	// 存储中不存在时回退到环境变量
+	t.Setenv(constants.EnvKimiAPIKey, "sk-kimi-env")
+	key, err = ResolveAPIKey(ctx, "", constants.EnvKimiAPIKey)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-kimi-env", key)
+
+	// 明文密钥原样返回
+	key, err = ResolveAPIKey(ctx, "sk-plaintext", constants.EnvOpenAIAPIKey)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-plaintext", key)
+
+	// 所有密钥都登记到脱敏器
+	assert.Equal(t, "{{secret:OPENAI_API_KEY}}", masker.Mask("sk-plaintext"))
+	assert.NotContains(t, masker.Mask("sk-from-store sk-kimi-env"), "sk-")
+
+	_, err = ResolveAPIKey(ctx, secrets.Ref("missing"), constants.EnvOpenAIAPIKey)
+	assert.True(t, secrets.IsNotFound(err))
+}
+
+func TestPrepareConfig_APIKeySecret(t *testing.T) {
+	previous := secrets.Default()
+	defer secrets.SetDefault(previous)
+	secrets.SetDefault(secrets.NewResolver(secrets.Map{"anthropic": "sk-ant-store"},
+		secrets.WithMasker(secrets.NewMasker())))
+
+	opts := NewLLMOptionsWithOptions(
+		WithProvider(constants.ProviderAnthropic),
+		WithAPIKeySecret("anthropic"),
+	)
+	require.NoError(t, PrepareConfig(opts))
+	assert.Equal(t, "sk-ant-store", opts.APIKey)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/secrets"
 )
 
 // ClientOption 定义 LLM 客户端配置选项
@@ -34,6 +35,13 @@ func WithAPIKey(apiKey string) ClientOption {
 	}
 }
 
+// WithAPIKeySecret 通过密钥句柄设置 API 密钥，密钥在创建客户端时才从默认密钥解析器解析
+func WithAPIKeySecret(name string) ClientOption {
+	return func(c *LLMOptions) {
+		c.APIKey = secrets.Ref(name)
+	}
+}
+
 // WithBaseURL 设置自定义 API 端点
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *LLMOptions) {
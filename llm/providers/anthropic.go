@@ -143,6 +143,11 @@ func NewAnthropic(config *agentllm.LLMOptions) (*AnthropicProvider, error) {
 
 // Complete implements basic text completion.
 func (p *AnthropicProvider) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return p.traceCompletion(ctx, req, p.complete)
+}
+
+// complete performs the Complete request without tracing
+func (p *AnthropicProvider) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// Build Anthropic request
 	anthropicReq := p.buildRequest(req)
 
@@ -286,6 +291,13 @@ func (p *AnthropicProvider) IsAvailable() bool {
 
 // Stream implements streaming generation.
 func (p *AnthropicProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan string, error) {
+		return p.stream(ctx, prompt)
+	}, func(token string) string { return token })
+}
+
+// stream performs the Stream request without tracing
+func (p *AnthropicProvider) stream(ctx context.Context, prompt string) (<-chan string, error) {
 	tokens := make(chan string, 100)
 
 	model := p.GetModel("")
@@ -108,11 +108,14 @@ func ConfigToOptions(config *agentllm.LLMOptions) []agentllm.ClientOption {
 	return opts
 }
 
-// EnsureAPIKey validates and sets the API key, supporting environment variable fallback.
+// EnsureAPIKey validates and sets the API key, resolving secret handles and
+// supporting secret store and environment variable fallback.
 func (b *BaseProvider) EnsureAPIKey(envVar string, providerName constants.Provider) error {
-	if b.Config.APIKey == "" {
-		b.Config.APIKey = os.Getenv(envVar)
+	apiKey, err := agentllm.ResolveAPIKey(context.Background(), b.Config.APIKey, envVar)
+	if err != nil {
+		return err
 	}
+	b.Config.APIKey = apiKey
 	if b.Config.APIKey == "" {
 		return agentErrors.NewInvalidConfigError(string(providerName), constants.ErrorFieldAPIKey, fmt.Sprintf(constants.ErrAPIKeyMissing, string(providerName)))
 	}
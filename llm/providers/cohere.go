@@ -128,6 +128,11 @@ func NewCohere(config *agentllm.LLMOptions) (*CohereProvider, error) {
 
 // Complete implements basic text completion
 func (p *CohereProvider) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return p.traceCompletion(ctx, req, p.complete)
+}
+
+// complete performs the Complete request without tracing
+func (p *CohereProvider) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// Build Cohere request
 	cohereReq := p.buildRequest(req)
 
@@ -361,6 +366,13 @@ func (p *CohereProvider) IsAvailable() bool {
 
 // Stream implements streaming generation
 func (p *CohereProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan string, error) {
+		return p.stream(ctx, prompt)
+	}, func(token string) string { return token })
+}
+
+// stream performs the Stream request without tracing
+func (p *CohereProvider) stream(ctx context.Context, prompt string) (<-chan string, error) {
 	tokens := make(chan string, 100)
 
 	model := p.GetModel("")
@@ -156,6 +156,11 @@ func NewDeepSeek(config *agentllm.LLMOptions) (*DeepSeekProvider, error) {
 
 // Complete implements basic text completion
 func (p *DeepSeekProvider) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return p.traceCompletion(ctx, req, p.complete)
+}
+
+// complete performs the Complete request without tracing
+func (p *DeepSeekProvider) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// Convert messages to DeepSeek format
 	messages := make([]DeepSeekMessage, len(req.Messages))
 	for i, msg := range req.Messages {
@@ -218,6 +223,13 @@ func (p *DeepSeekProvider) Chat(ctx context.Context, messages []agentllm.Message
 
 // Stream implements streaming generation
 func (p *DeepSeekProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan string, error) {
+		return p.stream(ctx, prompt)
+	}, func(token string) string { return token })
+}
+
+// stream performs the Stream request without tracing
+func (p *DeepSeekProvider) stream(ctx context.Context, prompt string) (<-chan string, error) {
 	tokens := make(chan string, 100)
 
 	model := p.GetModel("")
@@ -280,6 +292,11 @@ func (p *DeepSeekProvider) Stream(ctx context.Context, prompt string) (<-chan st
 
 // GenerateWithTools implements tool calling
 func (p *DeepSeekProvider) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*ToolCallResponse, error) {
+	return p.traceToolCalling(ctx, prompt, tools, p.generateWithTools)
+}
+
+// generateWithTools performs the GenerateWithTools request without tracing
+func (p *DeepSeekProvider) generateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*ToolCallResponse, error) {
 	// Convert tools to DeepSeek format
 	dsTools := p.convertToolsToDeepSeek(tools)
 
@@ -341,6 +358,13 @@ func (p *DeepSeekProvider) GenerateWithTools(ctx context.Context, prompt string,
 
 // StreamWithTools implements streaming tool calls
 func (p *DeepSeekProvider) StreamWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (<-chan ToolChunk, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan ToolChunk, error) {
+		return p.streamWithTools(ctx, prompt, tools)
+	}, toolChunkContent)
+}
+
+// streamWithTools performs the StreamWithTools request without tracing
+func (p *DeepSeekProvider) streamWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (<-chan ToolChunk, error) {
 	chunks := make(chan ToolChunk, 100)
 
 	// Convert tools to DeepSeek format
@@ -442,6 +466,11 @@ func (p *DeepSeekProvider) StreamWithTools(ctx context.Context, prompt string, t
 
 // Embed generates embeddings for text
 func (p *DeepSeekProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	return p.traceEmbedding(ctx, text, p.embed)
+}
+
+// embed performs the Embed request without tracing
+func (p *DeepSeekProvider) embed(ctx context.Context, text string) ([]float64, error) {
 	// DeepSeek embeddings API
 	type EmbedRequest struct {
 		Model string   `json:"model"`
@@ -612,6 +641,18 @@ func NewDeepSeekStreaming(config *agentllm.LLMOptions) (*DeepSeekStreamingProvid
 
 // StreamWithMetadata streams tokens with additional metadata
 func (p *DeepSeekStreamingProvider) StreamWithMetadata(ctx context.Context, prompt string) (<-chan TokenWithMetadata, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan TokenWithMetadata, error) {
+		return p.streamWithMetadata(ctx, prompt)
+	}, func(token TokenWithMetadata) string {
+		if token.Type == "token" {
+			return token.Content
+		}
+		return ""
+	})
+}
+
+// streamWithMetadata performs the StreamWithMetadata request without tracing
+func (p *DeepSeekStreamingProvider) streamWithMetadata(ctx context.Context, prompt string) (<-chan TokenWithMetadata, error) {
 	tokens := make(chan TokenWithMetadata, 100)
 
 	model := p.GetModel("")
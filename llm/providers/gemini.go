@@ -89,6 +89,11 @@ func NewGemini(config *agentllm.LLMOptions) (*GeminiProvider, error) {
 
 // Complete implements basic text completion
 func (p *GeminiProvider) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return p.traceCompletion(ctx, req, p.complete)
+}
+
+// complete performs the Complete request without tracing
+func (p *GeminiProvider) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// Create a new chat session
 	cs := p.model.StartChat()
 
@@ -174,6 +179,13 @@ func (p *GeminiProvider) Chat(ctx context.Context, messages []agentllm.Message)
 
 // Stream implements streaming generation
 func (p *GeminiProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan string, error) {
+		return p.stream(ctx, prompt)
+	}, func(token string) string { return token })
+}
+
+// stream performs the Stream request without tracing
+func (p *GeminiProvider) stream(ctx context.Context, prompt string) (<-chan string, error) {
 	tokens := make(chan string, 100)
 
 	// Start a new chat session
@@ -214,6 +226,11 @@ func (p *GeminiProvider) Stream(ctx context.Context, prompt string) (<-chan stri
 
 // GenerateWithTools implements tool calling
 func (p *GeminiProvider) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*ToolCallResponse, error) {
+	return p.traceToolCalling(ctx, prompt, tools, p.generateWithTools)
+}
+
+// generateWithTools performs the GenerateWithTools request without tracing
+func (p *GeminiProvider) generateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*ToolCallResponse, error) {
 	// Convert tools to Gemini function declarations
 	functionDeclarations := p.convertToolsToFunctions(tools)
 
@@ -277,6 +294,13 @@ func (p *GeminiProvider) GenerateWithTools(ctx context.Context, prompt string, t
 
 // StreamWithTools implements streaming tool calls
 func (p *GeminiProvider) StreamWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (<-chan ToolChunk, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan ToolChunk, error) {
+		return p.streamWithTools(ctx, prompt, tools)
+	}, toolChunkContent)
+}
+
+// streamWithTools performs the StreamWithTools request without tracing
+func (p *GeminiProvider) streamWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (<-chan ToolChunk, error) {
 	chunks := make(chan ToolChunk, 100)
 
 	// Convert tools to Gemini function declarations
@@ -385,6 +409,11 @@ func (p *GeminiProvider) StreamWithTools(ctx context.Context, prompt string, too
 
 // Embed generates embeddings for text
 func (p *GeminiProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	return p.traceEmbedding(ctx, text, p.embed)
+}
+
+// embed performs the Embed request without tracing
+func (p *GeminiProvider) embed(ctx context.Context, text string) ([]float64, error) {
 	// Gemini SDK doesn't expose EmbedContent method directly
 	// This is a workaround - in production you should use the embedding API endpoint
 	// For now, return a mock embedding
@@ -471,6 +500,18 @@ func NewGeminiStreaming(config *agentllm.LLMOptions) (*GeminiStreamingProvider,
 
 // StreamWithContext streams with cancellation support
 func (p *GeminiStreamingProvider) StreamWithContext(ctx context.Context, prompt string) (<-chan StreamEvent, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan StreamEvent, error) {
+		return p.streamWithContext(ctx, prompt)
+	}, func(event StreamEvent) string {
+		if event.Type == "token" {
+			return event.Content
+		}
+		return ""
+	})
+}
+
+// streamWithContext performs the StreamWithContext request without tracing
+func (p *GeminiStreamingProvider) streamWithContext(ctx context.Context, prompt string) (<-chan StreamEvent, error) {
 	events := make(chan StreamEvent, 100)
 
 	cs := p.model.StartChat()
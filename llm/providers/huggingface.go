@@ -140,6 +140,11 @@ func NewHuggingFace(config *agentllm.LLMOptions) (*HuggingFaceProvider, error) {
 
 // Complete implements basic text completion
 func (p *HuggingFaceProvider) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return p.traceCompletion(ctx, req, p.complete)
+}
+
+// complete performs the Complete request without tracing
+func (p *HuggingFaceProvider) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// Build Hugging Face request
 	hfReq := p.buildRequest(req)
 
@@ -380,6 +385,13 @@ func (p *HuggingFaceProvider) IsAvailable() bool {
 
 // Stream implements streaming generation
 func (p *HuggingFaceProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan string, error) {
+		return p.stream(ctx, prompt)
+	}, func(token string) string { return token })
+}
+
+// stream performs the Stream request without tracing
+func (p *HuggingFaceProvider) stream(ctx context.Context, prompt string) (<-chan string, error) {
 	tokens := make(chan string, 100)
 
 	model := p.GetModel("")
@@ -121,6 +121,11 @@ type kimiError struct {
 
 // Complete 实现 llm.Client 接口的 Complete 方法
 func (c *KimiClient) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return c.traceCompletion(ctx, req, c.complete)
+}
+
+// complete performs the Complete request without tracing
+func (c *KimiClient) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// 转换消息格式
 	messages := make([]kimiMessage, len(req.Messages))
 	for i, msg := range req.Messages {
@@ -131,6 +131,11 @@ type ollamaGenerateResponse struct {
 
 // Complete 实现 llm.Client 接口的 Complete 方法
 func (c *OllamaClient) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return c.traceCompletion(ctx, req, c.complete)
+}
+
+// complete performs the Complete request without tracing
+func (c *OllamaClient) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	// 构建 prompt
 	var prompt string
 	if len(req.Messages) > 0 {
@@ -208,6 +213,11 @@ func (c *OllamaClient) Complete(ctx context.Context, req *agentllm.CompletionReq
 
 // Chat 实现 llm.Client 接口的 Chat 方法
 func (c *OllamaClient) Chat(ctx context.Context, messages []agentllm.Message) (*agentllm.CompletionResponse, error) {
+	return c.traceChat(ctx, messages, c.chat)
+}
+
+// chat performs the Chat request without tracing
+func (c *OllamaClient) chat(ctx context.Context, messages []agentllm.Message) (*agentllm.CompletionResponse, error) {
 	// 转换消息格式
 	ollamaMessages := make([]ollamaMessage, len(messages))
 	for i, msg := range messages {
@@ -64,6 +64,11 @@ func NewOpenAI(config *agentllm.LLMOptions) (*OpenAIProvider, error) {
 
 // Complete implements basic text completion
 func (p *OpenAIProvider) Complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
+	return p.traceCompletion(ctx, req, p.complete)
+}
+
+// complete performs the Complete request without tracing
+func (p *OpenAIProvider) complete(ctx context.Context, req *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error) {
 	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = openai.ChatCompletionMessage{
@@ -117,6 +122,13 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []agentllm.Message)
 
 // Stream implements streaming generation
 func (p *OpenAIProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan string, error) {
+		return p.stream(ctx, prompt)
+	}, func(token string) string { return token })
+}
+
+// stream performs the Stream request without tracing
+func (p *OpenAIProvider) stream(ctx context.Context, prompt string) (<-chan string, error) {
 	tokens := make(chan string, 100)
 
 	model := p.GetModel("")
@@ -169,6 +181,11 @@ func (p *OpenAIProvider) Stream(ctx context.Context, prompt string) (<-chan stri
 
 // GenerateWithTools implements tool calling
 func (p *OpenAIProvider) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*ToolCallResponse, error) {
+	return p.traceToolCalling(ctx, prompt, tools, p.generateWithTools)
+}
+
+// generateWithTools performs the GenerateWithTools request without tracing
+func (p *OpenAIProvider) generateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*ToolCallResponse, error) {
 	// Convert tools to OpenAI function format
 	functions := p.convertToolsToFunctions(tools)
 
@@ -223,6 +240,13 @@ func (p *OpenAIProvider) GenerateWithTools(ctx context.Context, prompt string, t
 
 // StreamWithTools implements streaming tool calls
 func (p *OpenAIProvider) StreamWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (<-chan ToolChunk, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan ToolChunk, error) {
+		return p.streamWithTools(ctx, prompt, tools)
+	}, toolChunkContent)
+}
+
+// streamWithTools performs the StreamWithTools request without tracing
+func (p *OpenAIProvider) streamWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (<-chan ToolChunk, error) {
 	chunks := make(chan ToolChunk, 100)
 	functions := p.convertToolsToFunctions(tools)
 
@@ -345,6 +369,11 @@ func (p *OpenAIProvider) StreamWithTools(ctx context.Context, prompt string, too
 
 // Embed generates embeddings for text
 func (p *OpenAIProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	return p.traceEmbedding(ctx, text, p.embed)
+}
+
+// embed performs the Embed request without tracing
+func (p *OpenAIProvider) embed(ctx context.Context, text string) ([]float64, error) {
 	textPreview := text
 	if len(text) > 100 {
 		textPreview = text[:100] + "..."
@@ -460,6 +489,18 @@ func NewOpenAIStreaming(config *agentllm.LLMOptions) (*OpenAIStreamingProvider,
 
 // StreamTokensWithMetadata streams tokens with metadata
 func (p *OpenAIStreamingProvider) StreamTokensWithMetadata(ctx context.Context, prompt string) (<-chan TokenWithMetadata, error) {
+	return traceStream(ctx, p.BaseProvider, prompt, func(ctx context.Context) (<-chan TokenWithMetadata, error) {
+		return p.streamTokensWithMetadata(ctx, prompt)
+	}, func(token TokenWithMetadata) string {
+		if token.Type == "token" {
+			return token.Content
+		}
+		return ""
+	})
+}
+
+// streamTokensWithMetadata performs the StreamTokensWithMetadata request without tracing
+func (p *OpenAIStreamingProvider) streamTokensWithMetadata(ctx context.Context, prompt string) (<-chan TokenWithMetadata, error) {
 	tokens := make(chan TokenWithMetadata, 100)
 
 	model := p.GetModel("")
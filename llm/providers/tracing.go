@@ -0,0 +1,190 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kart-io/goagent/interfaces"
+	agentllm "github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/observability"
+)
+
+// traceCompletion wraps a completion call in a GenAI chat span.
+//
+// Every provider routes its public Complete through here so that spans carry
+// gen_ai.request.model, token usage and finish reasons regardless of provider,
+// and request, duration and token metrics are recorded.
+func (b *BaseProvider) traceCompletion(ctx context.Context, req *agentllm.CompletionRequest,
+	call func(context.Context, *agentllm.CompletionRequest) (*agentllm.CompletionResponse, error),
+) (*agentllm.CompletionResponse, error) {
+	ctx, span := observability.StartGenAISpan(ctx, observability.GenAIRequest{
+		System:        b.ProviderName(),
+		Operation:     observability.GenAIOperationChat,
+		Model:         b.GetModel(req.Model),
+		MaxTokens:     b.GetMaxTokens(req.MaxTokens),
+		Temperature:   b.GetTemperature(req.Temperature),
+		TopP:          req.TopP,
+		StopSequences: req.Stop,
+		Messages:      genAIMessages(req.Messages),
+	})
+	start := time.Now()
+	resp, err := call(ctx, req)
+	result := completionResult(resp)
+	b.recordMetrics(b.GetModel(req.Model), start, result, err)
+	observability.EndGenAISpan(span, result, err)
+	return resp, err
+}
+
+// traceChat wraps a chat call that does not go through Complete
+func (b *BaseProvider) traceChat(ctx context.Context, messages []agentllm.Message,
+	call func(context.Context, []agentllm.Message) (*agentllm.CompletionResponse, error),
+) (*agentllm.CompletionResponse, error) {
+	ctx, span := observability.StartGenAISpan(ctx, observability.GenAIRequest{
+		System:      b.ProviderName(),
+		Operation:   observability.GenAIOperationChat,
+		Model:       b.GetModel(""),
+		MaxTokens:   b.GetMaxTokens(0),
+		Temperature: b.GetTemperature(0),
+		Messages:    genAIMessages(messages),
+	})
+	start := time.Now()
+	resp, err := call(ctx, messages)
+	result := completionResult(resp)
+	b.recordMetrics(b.GetModel(""), start, result, err)
+	observability.EndGenAISpan(span, result, err)
+	return resp, err
+}
+
+// traceToolCalling wraps a native tool-calling request
+func (b *BaseProvider) traceToolCalling(ctx context.Context, prompt string, tools []interfaces.Tool,
+	call func(context.Context, string, []interfaces.Tool) (*ToolCallResponse, error),
+) (*ToolCallResponse, error) {
+	ctx, span := observability.StartGenAISpan(ctx, b.promptRequest(observability.GenAIOperationChat, prompt))
+	start := time.Now()
+	resp, err := call(ctx, prompt, tools)
+
+	var result *observability.GenAIResponse
+	if resp != nil {
+		result = &observability.GenAIResponse{Model: b.GetModel(""), Content: resp.Content}
+		if len(resp.ToolCalls) > 0 {
+			result.FinishReasons = []string{"tool_calls"}
+		}
+		if resp.Usage != nil {
+			result.InputTokens = resp.Usage.PromptTokens
+			result.OutputTokens = resp.Usage.CompletionTokens
+		}
+	}
+	b.recordMetrics(b.GetModel(""), start, result, err)
+	observability.EndGenAISpan(span, result, err)
+	return resp, err
+}
+
+// traceEmbedding wraps an embedding request
+func (b *BaseProvider) traceEmbedding(ctx context.Context, text string,
+	call func(context.Context, string) ([]float64, error),
+) ([]float64, error) {
+	ctx, span := observability.StartGenAISpan(ctx, b.promptRequest(observability.GenAIOperationEmbeddings, text))
+	start := time.Now()
+	vector, err := call(ctx, text)
+	b.recordMetrics(b.GetModel(""), start, nil, err)
+	observability.EndGenAISpan(span, nil, err)
+	return vector, err
+}
+
+// promptRequest describes a single-prompt request with the configured defaults
+func (b *BaseProvider) promptRequest(operation, prompt string) observability.GenAIRequest {
+	return observability.GenAIRequest{
+		System:    b.ProviderName(),
+		Operation: operation,
+		Model:     b.GetModel(""),
+		Messages:  []observability.GenAIMessage{{Role: "user", Content: prompt}},
+	}
+}
+
+// traceStream wraps a streaming request. The span stays open until the
+// stream is drained so that it covers the whole generation; time to first
+// token and inter-token latency are recorded for every non-empty chunk.
+func traceStream[T any](ctx context.Context, b *BaseProvider, prompt string,
+	open func(context.Context) (<-chan T, error), content func(T) string,
+) (<-chan T, error) {
+	ctx, span := observability.StartGenAISpan(ctx, b.promptRequest(observability.GenAIOperationChat, prompt))
+	timer := observability.NewStreamTimer(b.ProviderName(), b.GetModel(""))
+	in, err := open(ctx)
+	if err != nil {
+		timer.Done(err)
+		observability.EndGenAISpan(span, nil, err)
+		return nil, err
+	}
+
+	out := make(chan T, cap(in))
+	go func() {
+		defer close(out)
+		var text strings.Builder
+		for item := range in {
+			if token := content(item); token != "" {
+				timer.Token()
+				text.WriteString(token)
+			}
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				for range in {
+				}
+				timer.Done(ctx.Err())
+				observability.EndGenAISpan(span, nil, ctx.Err())
+				return
+			}
+		}
+		timer.Done(nil)
+		observability.EndGenAISpan(span, &observability.GenAIResponse{
+			Model:   b.GetModel(""),
+			Content: text.String(),
+		}, nil)
+	}()
+	return out, nil
+}
+
+// recordMetrics records request, duration and token metrics of a call
+func (b *BaseProvider) recordMetrics(model string, start time.Time, result *observability.GenAIResponse, err error) {
+	provider := b.ProviderName()
+	observability.RecordLLMRequest(provider, model, observability.LLMStatus(err), time.Since(start))
+	if result != nil {
+		observability.RecordLLMTokens(provider, model, result.InputTokens, result.OutputTokens, result.CachedTokens)
+	}
+}
+
+// toolChunkContent returns the text carried by a tool streaming chunk
+func toolChunkContent(chunk ToolChunk) string {
+	if chunk.Type != "content" {
+		return ""
+	}
+	text, _ := chunk.Value.(string)
+	return text
+}
+
+// genAIMessages converts chat messages for span content events
+func genAIMessages(messages []agentllm.Message) []observability.GenAIMessage {
+	converted := make([]observability.GenAIMessage, len(messages))
+	for i, msg := range messages {
+		converted[i] = observability.GenAIMessage{Role: msg.Role, Content: msg.Content}
+	}
+	return converted
+}
+
+// completionResult extracts span attributes from a completion response
+func completionResult(resp *agentllm.CompletionResponse) *observability.GenAIResponse {
+	if resp == nil {
+		return nil
+	}
+	result := &observability.GenAIResponse{Model: resp.Model, Content: resp.Content}
+	if resp.FinishReason != "" {
+		result.FinishReasons = []string{resp.FinishReason}
+	}
+	if resp.Usage != nil {
+		result.InputTokens = resp.Usage.PromptTokens
+		result.OutputTokens = resp.Usage.CompletionTokens
+		result.CachedTokens = resp.Usage.CachedTokens
+	}
+	return result
+}
@@ -10,6 +10,7 @@ import (
 
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/policy"
 )
 
 // StandardToolBox 标准工具箱实现
@@ -26,6 +27,9 @@ type StandardToolBox struct {
 	// 权限管理器
 	permissionManager *PermissionManager
 
+	// 策略引擎（可选）
+	policyEngine *policy.Engine
+
 	// 统计信息
 	stats      *core.ToolBoxStatistics
 	statsMutex sync.RWMutex
@@ -160,6 +164,18 @@ func (tb *StandardToolBox) Execute(ctx context.Context, call *core.ToolCall) (*c
 		}
 	}
 
+	// 检查策略
+	if tb.policyEngine != nil {
+		if _, err := tb.policyEngine.Authorize(ctx, &policy.Request{
+			Subject:   call.UserID,
+			Tool:      call.ToolName,
+			Args:      call.Input,
+			SessionID: call.SessionID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// 获取工具
 	tool, err := tb.Get(call.ToolName)
 	if err != nil {
@@ -373,6 +389,11 @@ func (tb *StandardToolBox) SetPermissionManager(pm *PermissionManager) {
 	tb.permissionManager = pm
 }
 
+// SetPolicyEngine 设置策略引擎，每次调用以 UserID 为主体进行授权并审计
+func (tb *StandardToolBox) SetPolicyEngine(engine *policy.Engine) {
+	tb.policyEngine = engine
+}
+
 // SetMaxHistorySize 设置最大历史大小
 func (tb *StandardToolBox) SetMaxHistorySize(size int) {
 	tb.maxHistorySize = size
@@ -8,7 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/policy"
 )
 
 // MockTool 模拟工具
@@ -297,6 +299,49 @@ func TestPermissionManager_RateLimit(t *testing.T) {
 	assert.False(t, allowed)
 }
 
+// TestStandardToolBox_Policy 测试策略引擎授权
+func TestStandardToolBox_Policy(t *testing.T) {
+	p, err := policy.ParsePolicy([]byte(`
+rules:
+  - name: safe-input
+    effect: allow
+    subjects: [user1]
+    tools: [test_tool]
+    args:
+      - arg: input
+        one_of: [safe]
+`))
+	require.NoError(t, err)
+	audit := policy.NewMemoryAuditLog()
+	engine, err := policy.NewEngine(p, policy.WithAuditLog(audit))
+	require.NoError(t, err)
+
+	tb := NewStandardToolBox()
+	tb.SetPolicyEngine(engine)
+	require.NoError(t, tb.Register(NewMockTool("test_tool", "test")))
+
+	_, err = tb.Execute(context.Background(), &core.ToolCall{
+		ToolName: "test_tool",
+		UserID:   "user1",
+		Input:    map[string]interface{}{"input": "safe"},
+	})
+	require.NoError(t, err)
+
+	_, err = tb.Execute(context.Background(), &core.ToolCall{
+		ToolName: "test_tool",
+		UserID:   "user2",
+		Input:    map[string]interface{}{"input": "safe"},
+	})
+	require.Error(t, err)
+	assert.Equal(t, agentErrors.CodePolicyDenied, agentErrors.GetCode(err))
+
+	decisions := audit.Decisions()
+	require.Len(t, decisions, 2)
+	assert.True(t, decisions[0].Allowed)
+	assert.Equal(t, "safe-input", decisions[0].Rule)
+	assert.False(t, decisions[1].Allowed)
+}
+
 // TestJSONSchemaValidator 测试 JSON Schema 验证
 func TestJSONSchemaValidator(t *testing.T) {
 	validator := NewJSONSchemaValidator()
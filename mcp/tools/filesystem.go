@@ -5,16 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"time"
 
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // ListDirectoryTool 列出目录工具
 type ListDirectoryTool struct {
 	*core.BaseTool
+	fs *vfs.FS
 }
 
 // NewListDirectoryTool 创建列出目录工具
@@ -47,11 +49,20 @@ func NewListDirectoryTool() *ListDirectoryTool {
 			"filesystem",
 			schema,
 		),
+		fs: vfs.Host(),
 	}
 
 	return tool
 }
 
+// WithFS 设置文件系统，例如使用 vfs.New 将列出范围限制在工作区目录内
+func (t *ListDirectoryTool) WithFS(fsys *vfs.FS) *ListDirectoryTool {
+	if fsys != nil {
+		t.fs = fsys
+	}
+	return t
+}
+
 // Execute 执行工具
 func (t *ListDirectoryTool) Execute(ctx context.Context, input map[string]interface{}) (*core.ToolResult, error) {
 	startTime := time.Now()
@@ -95,7 +106,7 @@ func (t *ListDirectoryTool) Execute(ctx context.Context, input map[string]interf
 
 // listFlat 平面列出目录
 func (t *ListDirectoryTool) listFlat(path string, includeHidden bool) ([]map[string]interface{}, error) {
-	entries, err := os.ReadDir(path)
+	entries, err := t.fs.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +123,7 @@ func (t *ListDirectoryTool) listFlat(path string, includeHidden bool) ([]map[str
 		info, _ := entry.Info()
 		fileInfo := map[string]interface{}{
 			"name":     name,
-			"path":     filepath.Join(path, name),
+			"path":     t.fs.Join(path, name),
 			"is_dir":   entry.IsDir(),
 			"size":     info.Size(),
 			"modified": info.ModTime(),
@@ -128,22 +139,30 @@ func (t *ListDirectoryTool) listFlat(path string, includeHidden bool) ([]map[str
 func (t *ListDirectoryTool) listRecursive(path string, includeHidden bool) ([]map[string]interface{}, error) {
 	files := make([]map[string]interface{}, 0)
 
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+	root, err := t.fs.Path(path)
+	if err != nil {
+		return nil, err
+	}
+	err = t.fs.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		name := filepath.Base(filePath)
+		name := entry.Name()
 
 		// 跳过隐藏文件和目录
-		if !includeHidden && len(name) > 0 && name[0] == '.' {
-			if info.IsDir() {
+		if !includeHidden && len(name) > 0 && name[0] == '.' && filePath != root {
+			if entry.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		relPath, _ := filepath.Rel(path, filePath)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(root, filePath)
 		fileInfo := map[string]interface{}{
 			"name":     name,
 			"path":     filePath,
@@ -173,6 +192,7 @@ func (t *ListDirectoryTool) Validate(input map[string]interface{}) error {
 // SearchFilesTool 搜索文件工具
 type SearchFilesTool struct {
 	*core.BaseTool
+	fs *vfs.FS
 }
 
 // NewSearchFilesTool 创建搜索文件工具
@@ -208,11 +228,20 @@ func NewSearchFilesTool() *SearchFilesTool {
 			"filesystem",
 			schema,
 		),
+		fs: vfs.Host(),
 	}
 
 	return tool
 }
 
+// WithFS 设置文件系统，例如使用 vfs.New 将搜索范围限制在工作区目录内
+func (t *SearchFilesTool) WithFS(fsys *vfs.FS) *SearchFilesTool {
+	if fsys != nil {
+		t.fs = fsys
+	}
+	return t
+}
+
 // Execute 执行工具
 func (t *SearchFilesTool) Execute(ctx context.Context, input map[string]interface{}) (*core.ToolResult, error) {
 	startTime := time.Now()
@@ -228,7 +257,7 @@ func (t *SearchFilesTool) Execute(ctx context.Context, input map[string]interfac
 	matches := make([]map[string]interface{}, 0)
 	count := 0
 
-	err := filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
+	err := t.fs.WalkDir(searchPath, func(path string, entry fs.DirEntry, err error) error {
 		//nolint:nilerr // Intentionally skip errors to continue traversing
 		if err != nil {
 			return nil // 跳过错误
@@ -239,14 +268,19 @@ func (t *SearchFilesTool) Execute(ctx context.Context, input map[string]interfac
 		}
 
 		// 匹配文件名
-		matched, _ := filepath.Match(pattern, filepath.Base(path))
+		matched, _ := filepath.Match(pattern, entry.Name())
 		if !matched {
 			return nil
 		}
+		info, err := entry.Info()
+		//nolint:nilerr // Skip entries removed while walking
+		if err != nil {
+			return nil
+		}
 
 		// 如果需要搜索内容
 		if contentSearch != "" && !info.IsDir() {
-			content, err := os.ReadFile(path)
+			content, err := t.fs.ReadFile(path)
 			//nolint:nilerr // Skip files that cannot be read
 			if err != nil {
 				return nil
@@ -258,7 +292,7 @@ func (t *SearchFilesTool) Execute(ctx context.Context, input map[string]interfac
 
 		match := map[string]interface{}{
 			"path":     path,
-			"name":     filepath.Base(path),
+			"name":     entry.Name(),
 			"is_dir":   info.IsDir(),
 			"size":     info.Size(),
 			"modified": info.ModTime(),
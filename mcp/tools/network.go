@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"github.com/kart-io/goagent/utils/json"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/sandbox"
 	"github.com/kart-io/goagent/utils/httpclient"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // HTTPRequestTool HTTP 请求工具
@@ -256,9 +259,25 @@ func (t *JSONParseTool) Validate(input map[string]interface{}) error {
 	return nil
 }
 
+// DefaultShellImage 是 ShellExecuteTool 默认容器沙箱使用的镜像
+const DefaultShellImage = "alpine:3.20"
+
+// Shell 命令超时的默认值和默认上限
+const (
+	defaultShellTimeout    = 30 * time.Second
+	defaultMaxShellTimeout = 5 * time.Minute
+)
+
 // ShellExecuteTool Shell 命令执行工具
+//
+// 未设置工作区时不执行命令；通过 WithFS 设置受限文件系统后，白名单中的命令在沙箱中执行，
+// 工作目录限制在工作区内。默认沙箱为只挂载工作区根目录的容器
 type ShellExecuteTool struct {
 	*core.BaseTool
+	fs              *vfs.FS
+	sandbox         sandbox.Sandbox
+	allowedCommands map[string]bool // 命令白名单
+	maxTimeout      time.Duration   // 超时上限
 }
 
 // NewShellExecuteTool 创建 Shell 命令执行工具
@@ -277,11 +296,20 @@ func NewShellExecuteTool() *ShellExecuteTool {
 					Type: "string",
 				},
 			},
+			"work_dir": {
+				Type:        "string",
+				Description: "工作目录（相对于工作区）",
+			},
 			"timeout": {
 				Type:        "integer",
 				Description: "超时时间（秒）",
 				Default:     30,
 			},
+			"dry_run": {
+				Type:        "boolean",
+				Description: "只返回将要执行的命令，不实际执行",
+				Default:     false,
+			},
 		},
 		Required: []string{"command"},
 	}
@@ -293,6 +321,8 @@ func NewShellExecuteTool() *ShellExecuteTool {
 			"system",
 			schema,
 		),
+		allowedCommands: make(map[string]bool),
+		maxTimeout:      defaultMaxShellTimeout,
 	}
 
 	tool.SetRequiresAuth(true)
@@ -301,28 +331,201 @@ func NewShellExecuteTool() *ShellExecuteTool {
 	return tool
 }
 
+// WithFS 设置工作区
+//
+// 只有受限文件系统（vfs.New 创建）会启用命令执行。命令对文件的修改不经过 vfs，
+// 不会记录到 vfs.Journal 中，也不受 dry run 之外的 vfs 限制约束
+func (t *ShellExecuteTool) WithFS(fsys *vfs.FS) *ShellExecuteTool {
+	t.fs = fsys
+	return t
+}
+
+// WithAllowedCommands 添加允许执行的命令（白名单），未在白名单中的命令一律拒绝
+func (t *ShellExecuteTool) WithAllowedCommands(commands ...string) *ShellExecuteTool {
+	for _, cmd := range commands {
+		t.allowedCommands[cmd] = true
+	}
+	return t
+}
+
+// WithMaxTimeout 设置超时上限，请求的超时超过上限时按上限执行
+func (t *ShellExecuteTool) WithMaxTimeout(timeout time.Duration) *ShellExecuteTool {
+	if timeout > 0 {
+		t.maxTimeout = timeout
+	}
+	return t
+}
+
+// WithSandbox 设置执行沙箱
+//
+// 默认使用禁止网络访问、只挂载工作区根目录的 DefaultShellImage 容器。
+// 本地沙箱（sandbox.NewLocal）中命令可以访问整个主机文件系统，仅应在可信环境中使用
+func (t *ShellExecuteTool) WithSandbox(sb sandbox.Sandbox) *ShellExecuteTool {
+	t.sandbox = sb
+	return t
+}
+
 // Execute 执行工具
 func (t *ShellExecuteTool) Execute(ctx context.Context, input map[string]interface{}) (*core.ToolResult, error) {
 	startTime := time.Now()
 
 	command, _ := input["command"].(string)
 
-	// 注意：实际生产环境中应该使用 os/exec 包并进行严格的安全检查
-	// 这里仅作示例
+	// 未设置工作区时不执行命令
+	if t.fs == nil || !t.fs.Jailed() {
+		result := &core.ToolResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"command": command,
+				"output":  "Command execution is disabled for security reasons in this example",
+			},
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}
+
+		return result, nil
+	}
+
+	// 安全检查：命令白名单
+	if !t.allowedCommands[command] {
+		err := &core.ErrInvalidInput{Field: "command", Message: "command not allowed: " + command}
+		return &core.ToolResult{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: "COMMAND_NOT_ALLOWED",
+			Metadata: map[string]interface{}{
+				"allowed_commands": t.AllowedCommands(),
+			},
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, err
+	}
+
+	// 防止 Shell 注入
+	if strings.ContainsAny(command, ";|&`$<>") {
+		err := &core.ErrInvalidInput{Field: "command", Message: "command contains potentially dangerous characters"}
+		return &core.ToolResult{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: "INVALID_INPUT",
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, err
+	}
+
+	var args []string
+	if rawArgs, ok := input["args"].([]interface{}); ok {
+		for _, arg := range rawArgs {
+			args = append(args, fmt.Sprint(arg))
+		}
+	} else if strArgs, ok := input["args"].([]string); ok {
+		args = strArgs
+	}
+
+	// 工作目录必须位于工作区内
+	workDir, _ := input["work_dir"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	dir, err := t.fs.HostPath(workDir)
+	if err != nil {
+		return &core.ToolResult{
+			Success:   false,
+			Error:     fmt.Sprintf("invalid work_dir: %v", err),
+			ErrorCode: "INVALID_INPUT",
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, err
+	}
+
+	// dry run 模式只返回将要执行的命令
+	if dryRun, _ := input["dry_run"].(bool); dryRun || t.fs.DryRun() {
+		return &core.ToolResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"command":  command,
+				"args":     args,
+				"work_dir": workDir,
+				"dry_run":  true,
+				"output":   "Dry run: command not executed",
+			},
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	// 超时不超过配置的上限
+	timeout := defaultShellTimeout
+	if seconds, ok := input["timeout"].(float64); ok && seconds > 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout > t.maxTimeout {
+		timeout = t.maxTimeout
+	}
+
+	res, err := t.sandboxOrDefault().Run(ctx, &sandbox.Request{
+		Command: command,
+		Args:    args,
+		Dir:     dir,
+		Root:    t.fs.Dir(),
+		Limits:  &sandbox.Limits{WallTime: timeout},
+	})
+	// 命令可能修改了工作区，重新计算配额
+	t.fs.Invalidate()
+	if err != nil {
+		return &core.ToolResult{
+			Success:   false,
+			Error:     fmt.Sprintf("command failed: %v", err),
+			ErrorCode: "COMMAND_ERROR",
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, err
+	}
 
 	result := &core.ToolResult{
-		Success: true,
+		Success: res.ExitCode == 0,
 		Data: map[string]interface{}{
-			"command": command,
-			"output":  "Command execution is disabled for security reasons in this example",
+			"command":   command,
+			"args":      args,
+			"work_dir":  workDir,
+			"stdout":    res.Stdout,
+			"stderr":    res.Stderr,
+			"exit_code": res.ExitCode,
+		},
+		Metadata: map[string]interface{}{
+			"isolation": res.Isolation,
+			"truncated": res.Truncated,
+			"timeout":   timeout.String(),
 		},
 		Duration:  time.Since(startTime),
 		Timestamp: time.Now(),
 	}
+	if res.ExitCode != 0 {
+		result.Error = fmt.Sprintf("command exited with code %d", res.ExitCode)
+		result.ErrorCode = "COMMAND_FAILED"
+	}
 
 	return result, nil
 }
 
+// sandboxOrDefault 返回执行沙箱，未设置时使用 DefaultShellImage 容器
+func (t *ShellExecuteTool) sandboxOrDefault() sandbox.Sandbox {
+	if t.sandbox != nil {
+		return t.sandbox
+	}
+	return sandbox.NewContainer(DefaultShellImage)
+}
+
+// AllowedCommands 返回允许执行的命令列表
+func (t *ShellExecuteTool) AllowedCommands() []string {
+	commands := make([]string, 0, len(t.allowedCommands))
+	for cmd := range t.allowedCommands {
+		commands = append(commands, cmd)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
 // Validate 验证输入
 func (t *ShellExecuteTool) Validate(input map[string]interface{}) error {
 	command, ok := input["command"].(string)
@@ -3,16 +3,17 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // ReadFileTool 读取文件工具
 type ReadFileTool struct {
 	*core.BaseTool
+	fs *vfs.FS
 }
 
 // NewReadFileTool 创建读取文件工具
@@ -41,6 +42,7 @@ func NewReadFileTool() *ReadFileTool {
 			"filesystem",
 			schema,
 		),
+		fs: vfs.Host(),
 	}
 
 	tool.SetRequiresAuth(false)
@@ -49,6 +51,14 @@ func NewReadFileTool() *ReadFileTool {
 	return tool
 }
 
+// WithFS 设置文件系统，例如使用 vfs.New 将读取限制在工作区目录内
+func (t *ReadFileTool) WithFS(fsys *vfs.FS) *ReadFileTool {
+	if fsys != nil {
+		t.fs = fsys
+	}
+	return t
+}
+
 // Execute 执行工具
 func (t *ReadFileTool) Execute(ctx context.Context, input map[string]interface{}) (*core.ToolResult, error) {
 	startTime := time.Now()
@@ -68,7 +78,7 @@ func (t *ReadFileTool) Execute(ctx context.Context, input map[string]interface{}
 	}
 
 	// 读取文件
-	content, err := os.ReadFile(path)
+	content, err := t.fs.ReadFile(path)
 	if err != nil {
 		return &core.ToolResult{
 			Success:   false,
@@ -80,7 +90,16 @@ func (t *ReadFileTool) Execute(ctx context.Context, input map[string]interface{}
 	}
 
 	// 获取文件信息
-	fileInfo, _ := os.Stat(path)
+	fileInfo, err := t.fs.Stat(path)
+	if err != nil {
+		return &core.ToolResult{
+			Success:   false,
+			Error:     fmt.Sprintf("failed to stat file: %v", err),
+			ErrorCode: "FILE_READ_ERROR",
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, err
+	}
 
 	result := &core.ToolResult{
 		Success: true,
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // BuiltinTools 内置工具注册表
@@ -22,6 +23,20 @@ var BuiltinTools = []core.Tool{
 	NewShellExecuteTool(),
 }
 
+// WorkspaceTools 创建限制在工作区内的文件系统工具和 Shell 工具
+//
+// 所有工具共享 fsys 的路径限制、配额、dry run 模式和 Journal；
+// Shell 工具只允许执行 allowedCommands 中的命令
+func WorkspaceTools(fsys *vfs.FS, allowedCommands ...string) []core.Tool {
+	return []core.Tool{
+		NewReadFileTool().WithFS(fsys),
+		NewWriteFileTool().WithFS(fsys),
+		NewListDirectoryTool().WithFS(fsys),
+		NewSearchFilesTool().WithFS(fsys),
+		NewShellExecuteTool().WithFS(fsys).WithAllowedCommands(allowedCommands...),
+	}
+}
+
 // RegisterBuiltinTools 注册所有内置工具到工具箱
 func RegisterBuiltinTools(toolbox core.ToolBox) error {
 	for _, tool := range BuiltinTools {
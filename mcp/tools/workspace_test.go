@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/sandbox"
+	"github.com/kart-io/goagent/vfs"
+)
+
+func newWorkspace(t *testing.T, opts ...vfs.Option) *vfs.FS {
+	t.Helper()
+	fsys, err := vfs.New(t.TempDir(), opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fsys.Close() })
+	return fsys
+}
+
+func TestWorkspaceTools_FileSystem(t *testing.T) {
+	journal := vfs.NewJournal()
+	fsys := newWorkspace(t, vfs.WithJournal(journal))
+	ctx := context.Background()
+
+	write := NewWriteFileTool().WithFS(fsys)
+	_, err := write.Execute(ctx, map[string]interface{}{
+		"path":        "/src/main.go",
+		"content":     "package main\n",
+		"create_dirs": true,
+	})
+	require.NoError(t, err)
+
+	// 绝对路径也被限制在工作区内
+	result, err := NewReadFileTool().WithFS(fsys).Execute(ctx, map[string]interface{}{"path": "/src/main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", result.Data.(map[string]interface{})["content"])
+
+	_, err = NewReadFileTool().WithFS(fsys).Execute(ctx, map[string]interface{}{"path": "../../etc/passwd"})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSPathEscape))
+
+	result, err = NewSearchFilesTool().WithFS(fsys).Execute(ctx, map[string]interface{}{"path": "/", "pattern": "*.go"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Data.(map[string]interface{})["count"])
+
+	result, err = NewListDirectoryTool().WithFS(fsys).Execute(ctx, map[string]interface{}{"path": ".", "recursive": true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Data.(map[string]interface{})["count"])
+
+	// dry run 返回差异且不写入
+	result, err = write.Execute(ctx, map[string]interface{}{
+		"path":    "src/main.go",
+		"content": "package app\n",
+		"dry_run": true,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.Data.(map[string]interface{})["diff"], "-package main\n+package app\n")
+
+	require.NoError(t, journal.Rollback())
+	_, err = os.Stat(filepath.Join(fsys.Dir(), "src"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestShellExecuteTool_Workspace(t *testing.T) {
+	ctx := context.Background()
+
+	// 未设置工作区时不执行命令
+	result, err := NewShellExecuteTool().Execute(ctx, map[string]interface{}{"command": "pwd"})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Data.(map[string]interface{}), "exit_code")
+
+	if _, err := exec.LookPath("pwd"); err != nil {
+		t.Skip("pwd not available")
+	}
+	fsys := newWorkspace(t)
+	require.NoError(t, os.Mkdir(filepath.Join(fsys.Dir(), "pkg"), 0o755))
+	tool := NewShellExecuteTool().WithFS(fsys).WithAllowedCommands("pwd").WithSandbox(sandbox.NewLocal())
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"command": "pwd", "work_dir": "pkg"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, filepath.Join(fsys.Dir(), "pkg")+"\n", result.Data.(map[string]interface{})["stdout"])
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"command": "pwd", "work_dir": "../.."})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSPathEscape))
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"command": "pwd", "dry_run": true})
+	require.NoError(t, err)
+	assert.Equal(t, true, result.Data.(map[string]interface{})["dry_run"])
+}
+
+// recordingSandbox 记录收到的请求，不执行命令
+type recordingSandbox struct {
+	requests []*sandbox.Request
+}
+
+func (s *recordingSandbox) Name() string { return "recording" }
+
+func (s *recordingSandbox) Close() error { return nil }
+
+func (s *recordingSandbox) Run(ctx context.Context, req *sandbox.Request) (*sandbox.Result, error) {
+	s.requests = append(s.requests, req)
+	return &sandbox.Result{}, nil
+}
+
+func TestShellExecuteTool_Restrictions(t *testing.T) {
+	ctx := context.Background()
+	fsys := newWorkspace(t)
+	require.NoError(t, os.Mkdir(filepath.Join(fsys.Dir(), "pkg"), 0o755))
+	sb := &recordingSandbox{}
+	tool := NewShellExecuteTool().
+		WithFS(fsys).
+		WithAllowedCommands("ls").
+		WithMaxTimeout(time.Minute).
+		WithSandbox(sb)
+
+	// 白名单之外的命令被拒绝
+	result, err := tool.Execute(ctx, map[string]interface{}{"command": "rm", "args": []interface{}{"-rf", "/home"}})
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, "COMMAND_NOT_ALLOWED", result.ErrorCode)
+
+	// 带 Shell 元字符的命令被拒绝
+	tool.WithAllowedCommands("ls; rm")
+	result, err = tool.Execute(ctx, map[string]interface{}{"command": "ls; rm"})
+	require.Error(t, err)
+	assert.Equal(t, "INVALID_INPUT", result.ErrorCode)
+	assert.Empty(t, sb.requests)
+
+	// 超时不超过上限，沙箱只挂载工作区根目录
+	_, err = tool.Execute(ctx, map[string]interface{}{"command": "ls", "work_dir": "pkg", "timeout": float64(3600)})
+	require.NoError(t, err)
+	require.Len(t, sb.requests, 1)
+	req := sb.requests[0]
+	assert.Equal(t, time.Minute, req.Limits.WallTime)
+	assert.Equal(t, fsys.Dir(), req.Root)
+	assert.Equal(t, filepath.Join(fsys.Dir(), "pkg"), req.Dir)
+
+	// 默认使用容器沙箱
+	assert.Contains(t, NewShellExecuteTool().sandboxOrDefault().Name(), "docker")
+}
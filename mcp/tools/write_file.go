@@ -3,16 +3,17 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/kart-io/goagent/mcp/core"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // WriteFileTool 写入文件工具
 type WriteFileTool struct {
 	*core.BaseTool
+	fs *vfs.FS
 }
 
 // NewWriteFileTool 创建写入文件工具
@@ -39,6 +40,11 @@ func NewWriteFileTool() *WriteFileTool {
 				Description: "是否自动创建目录",
 				Default:     false,
 			},
+			"dry_run": {
+				Type:        "boolean",
+				Description: "只返回统一格式的差异（unified diff），不实际写入",
+				Default:     false,
+			},
 		},
 		Required: []string{"path", "content"},
 	}
@@ -50,6 +56,7 @@ func NewWriteFileTool() *WriteFileTool {
 			"filesystem",
 			schema,
 		),
+		fs: vfs.Host(),
 	}
 
 	tool.SetRequiresAuth(true)
@@ -58,6 +65,17 @@ func NewWriteFileTool() *WriteFileTool {
 	return tool
 }
 
+// WithFS 设置文件系统
+//
+// 使用 vfs.New 创建的文件系统会将写入限制在工作区目录内，并可通过 vfs.Journal
+// 记录所有修改，以便在运行结束后审查、提交或回滚
+func (t *WriteFileTool) WithFS(fsys *vfs.FS) *WriteFileTool {
+	if fsys != nil {
+		t.fs = fsys
+	}
+	return t
+}
+
 // Execute 执行工具
 func (t *WriteFileTool) Execute(ctx context.Context, input map[string]interface{}) (*core.ToolResult, error) {
 	startTime := time.Now()
@@ -69,11 +87,17 @@ func (t *WriteFileTool) Execute(ctx context.Context, input map[string]interface{
 		mode = "overwrite"
 	}
 	createDirs, _ := input["create_dirs"].(bool)
+	dryRun, _ := input["dry_run"].(bool)
+
+	fsys := t.fs
+	if dryRun {
+		fsys = fsys.WithDryRun(true)
+	}
 
 	// 如果需要创建目录
 	if createDirs {
 		dir := filepath.Dir(path)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
+		if _, err := fsys.MkdirAll(dir, 0o755); err != nil {
 			return &core.ToolResult{
 				Success:   false,
 				Error:     fmt.Sprintf("failed to create directories: %v", err),
@@ -85,26 +109,14 @@ func (t *WriteFileTool) Execute(ctx context.Context, input map[string]interface{
 	}
 
 	// 写入文件
+	var change *vfs.Change
 	var err error
 	if mode == "append" {
 		// 追加模式
-		var f *os.File
-		f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return &core.ToolResult{
-				Success:   false,
-				Error:     fmt.Sprintf("failed to open file: %v", err),
-				ErrorCode: "FILE_OPEN_ERROR",
-				Duration:  time.Since(startTime),
-				Timestamp: time.Now(),
-			}, err
-		}
-		defer func() { _ = f.Close() }()
-
-		_, err = f.WriteString(content)
+		change, err = fsys.AppendFile(path, []byte(content))
 	} else {
 		// 覆盖模式
-		err = os.WriteFile(path, []byte(content), 0o644)
+		change, err = fsys.WriteFile(path, []byte(content), 0o644)
 	}
 
 	if err != nil {
@@ -117,8 +129,32 @@ func (t *WriteFileTool) Execute(ctx context.Context, input map[string]interface{
 		}, err
 	}
 
+	// dry run 模式只返回差异
+	if change.DryRun {
+		return &core.ToolResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"path":    path,
+				"mode":    mode,
+				"dry_run": true,
+				"diff":    change.Diff,
+			},
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, nil
+	}
+
 	// 获取文件信息
-	fileInfo, _ := os.Stat(path)
+	fileInfo, err := fsys.Stat(path)
+	if err != nil {
+		return &core.ToolResult{
+			Success:   false,
+			Error:     fmt.Sprintf("failed to stat file: %v", err),
+			ErrorCode: "FILE_WRITE_ERROR",
+			Duration:  time.Since(startTime),
+			Timestamp: time.Now(),
+		}, err
+	}
 
 	result := &core.ToolResult{
 		Success: true,
@@ -0,0 +1,388 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Case metadata keys written for cases created from agent runs
+const (
+	CaseMetadataPlan      = "plan"
+	CaseMetadataToolTrace = "tool_trace"
+	CaseMetadataSource    = "source"
+
+	// CaseCategoryAgentRun is the category of cases created from agent runs
+	CaseCategoryAgentRun = "agent_run"
+)
+
+const (
+	// DefaultCaseUtilityWeight is the share of utility in the ranking score
+	DefaultCaseUtilityWeight = 0.3
+
+	// DefaultCaseMergeSimilarity is the similarity above which a recorded
+	// run reinforces an existing case instead of creating a new one
+	DefaultCaseMergeSimilarity = 0.95
+)
+
+// CaseToolCall is one tool invocation in the trace of a recorded run
+type CaseToolCall struct {
+	Tool    string                 `json:"tool"`
+	Input   map[string]interface{} `json:"input,omitempty"`
+	Output  string                 `json:"output,omitempty"`
+	Success bool                   `json:"success"`
+}
+
+// CaseRun describes a finished agent run that can become a case
+type CaseRun struct {
+	Input     string         `json:"input"`
+	Plan      []string       `json:"plan,omitempty"`
+	ToolTrace []CaseToolCall `json:"tool_trace,omitempty"`
+	Outcome   string         `json:"outcome"`
+	Success   bool           `json:"success"`
+}
+
+// CaseBase is an episodic case memory for case-based reasoning.
+//
+// Cases are indexed by embeddings of their title, problem and description.
+// Outcome feedback updates a utility score per case, and search ranks cases
+// by a blend of similarity and utility so that cases which worked before are
+// preferred over merely similar ones. Without an embedder, similarity falls
+// back to keyword overlap.
+type CaseBase struct {
+	mu    sync.RWMutex
+	cases map[string]*Case
+
+	embedder        Embedder
+	utilityWeight   float64
+	minSimilarity   float64
+	mergeSimilarity float64
+}
+
+// CaseBaseOption configures a CaseBase
+type CaseBaseOption func(*CaseBase)
+
+// WithCaseEmbedder sets the embedder used to index cases and queries
+func WithCaseEmbedder(embedder Embedder) CaseBaseOption {
+	return func(cb *CaseBase) {
+		cb.embedder = embedder
+	}
+}
+
+// WithUtilityWeight sets the share of utility in the ranking score (0.0-1.0).
+// 0 ranks by similarity only.
+func WithUtilityWeight(weight float64) CaseBaseOption {
+	return func(cb *CaseBase) {
+		cb.utilityWeight = math.Max(0, math.Min(1, weight))
+	}
+}
+
+// WithMinCaseSimilarity sets the similarity below which cases are not returned
+func WithMinCaseSimilarity(similarity float64) CaseBaseOption {
+	return func(cb *CaseBase) {
+		cb.minSimilarity = similarity
+	}
+}
+
+// WithCaseMergeSimilarity sets the similarity above which RecordRun
+// reinforces an existing case instead of creating a new one
+func WithCaseMergeSimilarity(similarity float64) CaseBaseOption {
+	return func(cb *CaseBase) {
+		cb.mergeSimilarity = similarity
+	}
+}
+
+// NewCaseBase creates an empty case base
+func NewCaseBase(opts ...CaseBaseOption) *CaseBase {
+	cb := &CaseBase{
+		cases:           make(map[string]*Case),
+		utilityWeight:   DefaultCaseUtilityWeight,
+		mergeSimilarity: DefaultCaseMergeSimilarity,
+	}
+	for _, opt := range opts {
+		opt(cb)
+	}
+	return cb
+}
+
+// AddCase stores a case, embedding it if it has no embedding yet.
+// The ID and timestamps of the given case are filled in.
+func (cb *CaseBase) AddCase(ctx context.Context, c *Case) error {
+	if c == nil {
+		return agentErrors.New(agentErrors.CodeInvalidInput, "case is nil").
+			WithComponent("case_base").
+			WithOperation("add_case")
+	}
+
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+	if c.SuccessCount+c.FailureCount > 0 {
+		c.Utility = caseUtility(c.SuccessCount, c.FailureCount)
+	} else if c.Utility == 0 {
+		c.Utility = caseUtility(0, 0)
+	}
+
+	if len(c.Embedding) == 0 && cb.embedder != nil {
+		embedding, err := cb.embedder.Embed(ctx, caseText(c))
+		if err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed case").
+				WithComponent("case_base").
+				WithOperation("add_case").
+				WithContext("case_id", c.ID)
+		}
+		c.Embedding = embedding
+	}
+
+	stored := *c
+	cb.mu.Lock()
+	cb.cases[c.ID] = &stored
+	cb.mu.Unlock()
+	return nil
+}
+
+// GetCase returns a copy of a case
+func (cb *CaseBase) GetCase(ctx context.Context, id string) (*Case, error) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	c, ok := cb.cases[id]
+	if !ok {
+		return nil, caseNotFound(id, "get_case")
+	}
+	copied := *c
+	return &copied, nil
+}
+
+// SearchSimilarCases returns the cases most relevant to the query, ranked by
+//
+//	(1 - utilityWeight) * similarity + utilityWeight * utility
+//
+// Similarity is set on the returned copies.
+func (cb *CaseBase) SearchSimilarCases(ctx context.Context, query string, limit int) ([]*Case, error) {
+	if strings.TrimSpace(query) == "" {
+		return []*Case{}, nil
+	}
+
+	var queryEmbedding []float64
+	if cb.embedder != nil {
+		embedding, err := cb.embedder.Embed(ctx, query)
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed case query").
+				WithComponent("case_base").
+				WithOperation("search_similar_cases")
+		}
+		queryEmbedding = embedding
+	}
+	queryTerms := caseTerms(query)
+
+	type scored struct {
+		c     *Case
+		score float64
+	}
+
+	cb.mu.RLock()
+	results := make([]scored, 0, len(cb.cases))
+	for _, c := range cb.cases {
+		var similarity float64
+		if queryEmbedding != nil && len(c.Embedding) == len(queryEmbedding) {
+			similarity = cosineSimilarity64(queryEmbedding, c.Embedding)
+		} else {
+			similarity = keywordOverlap(queryTerms, caseTerms(caseText(c)))
+		}
+		if similarity <= 0 || similarity < cb.minSimilarity {
+			continue
+		}
+
+		copied := *c
+		copied.Similarity = similarity
+		results = append(results, scored{
+			c:     &copied,
+			score: (1-cb.utilityWeight)*similarity + cb.utilityWeight*c.Utility,
+		})
+	}
+	cb.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].c.CreatedAt.After(results[j].c.CreatedAt)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	cases := make([]*Case, len(results))
+	for i, r := range results {
+		cases[i] = r.c
+	}
+	return cases, nil
+}
+
+// RecordCaseOutcome records whether applying a case succeeded and updates
+// its utility to the smoothed success rate (successes+1)/(applications+2)
+func (cb *CaseBase) RecordCaseOutcome(ctx context.Context, id string, success bool) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.cases[id]
+	if !ok {
+		return caseNotFound(id, "record_case_outcome")
+	}
+	if success {
+		c.SuccessCount++
+	} else {
+		c.FailureCount++
+	}
+	c.Utility = caseUtility(c.SuccessCount, c.FailureCount)
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// RecordRun turns a successful agent run into a case. Failed runs are
+// ignored and return nil. A run nearly identical to an existing case
+// reinforces that case as a success instead of creating a duplicate.
+func (cb *CaseBase) RecordRun(ctx context.Context, run *CaseRun) (*Case, error) {
+	if run == nil || !run.Success || strings.TrimSpace(run.Input) == "" {
+		return nil, nil
+	}
+
+	similar, err := cb.SearchSimilarCases(ctx, run.Input, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(similar) > 0 && similar[0].Similarity >= cb.mergeSimilarity {
+		if err := cb.RecordCaseOutcome(ctx, similar[0].ID, true); err != nil {
+			return nil, err
+		}
+		return cb.GetCase(ctx, similar[0].ID)
+	}
+
+	c := &Case{
+		Title:        caseTitle(run.Input),
+		Description:  strings.Join(run.Plan, "\n"),
+		Problem:      run.Input,
+		Solution:     run.Outcome,
+		Category:     CaseCategoryAgentRun,
+		SuccessCount: 1,
+		Metadata: map[string]interface{}{
+			CaseMetadataPlan:      run.Plan,
+			CaseMetadataToolTrace: run.ToolTrace,
+			CaseMetadataSource:    CaseCategoryAgentRun,
+		},
+	}
+	if err := cb.AddCase(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteCase removes a case
+func (cb *CaseBase) DeleteCase(ctx context.Context, id string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	delete(cb.cases, id)
+	return nil
+}
+
+// Clear removes all cases
+func (cb *CaseBase) Clear(ctx context.Context) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.cases = make(map[string]*Case)
+	return nil
+}
+
+// Len returns the number of cases
+func (cb *CaseBase) Len() int {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	return len(cb.cases)
+}
+
+// caseUtility is the mean of a Beta(1,1) prior updated with the outcomes
+func caseUtility(successes, failures int) float64 {
+	return float64(successes+1) / float64(successes+failures+2)
+}
+
+// caseText is the text a case is indexed by
+func caseText(c *Case) string {
+	parts := []string{c.Title, c.Problem, c.Description}
+	if len(c.Tags) > 0 {
+		parts = append(parts, strings.Join(c.Tags, " "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// caseTitle derives a short title from a run input
+func caseTitle(input string) string {
+	title := strings.TrimSpace(strings.SplitN(input, "\n", 2)[0])
+	if runes := []rune(title); len(runes) > 80 {
+		title = string(runes[:77]) + "..."
+	}
+	return title
+}
+
+// caseTerms splits text into lower-cased terms
+func caseTerms(text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms[term] = true
+	}
+	return terms
+}
+
+// keywordOverlap is the share of query terms found in the case terms
+func keywordOverlap(query, terms map[string]bool) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	matched := 0
+	for term := range query {
+		if terms[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(query))
+}
+
+// cosineSimilarity64 computes the cosine similarity of two vectors
+func cosineSimilarity64(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func caseNotFound(id, operation string) error {
+	return agentErrors.New(agentErrors.CodeStoreNotFound, fmt.Sprintf("case not found: %s", id)).
+		WithComponent("case_base").
+		WithOperation(operation).
+		WithContext("case_id", id)
+}
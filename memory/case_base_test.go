@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+func TestCaseBase_UtilityRanking(t *testing.T) {
+	ctx := context.Background()
+	embedder := mocks.NewKeywordEmbedder("database", "timeout", "network", "disk").MemoryEmbedder()
+	cb := NewCaseBase(WithCaseEmbedder(embedder), WithUtilityWeight(0.5))
+
+	exact := &Case{Title: "database timeout", Problem: "database timeout on startup"}
+	similar := &Case{Title: "database timeout", Problem: "database timeout under network load"}
+	unrelated := &Case{Title: "disk full", Problem: "disk usage alert"}
+	for _, c := range []*Case{exact, similar, unrelated} {
+		require.NoError(t, cb.AddCase(ctx, c))
+	}
+	assert.NotEmpty(t, exact.Embedding)
+	assert.InDelta(t, 0.5, exact.Utility, 1e-9)
+
+	results, err := cb.SearchSimilarCases(ctx, "database timeout", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "unrelated cases are not returned")
+	assert.Equal(t, exact.ID, results[0].ID)
+	assert.InDelta(t, 1.0, results[0].Similarity, 1e-9)
+
+	// Repeated failures push the most similar case below one that works
+	for i := 0; i < 3; i++ {
+		require.NoError(t, cb.RecordCaseOutcome(ctx, exact.ID, false))
+		require.NoError(t, cb.RecordCaseOutcome(ctx, similar.ID, true))
+	}
+	results, err = cb.SearchSimilarCases(ctx, "database timeout", 10)
+	require.NoError(t, err)
+	assert.Equal(t, similar.ID, results[0].ID)
+	assert.InDelta(t, 0.8, results[0].Utility, 1e-9)
+	assert.Equal(t, 3, results[0].SuccessCount)
+
+	assert.Error(t, cb.RecordCaseOutcome(ctx, "missing", true))
+}
+
+func TestCaseBase_KeywordFallback(t *testing.T) {
+	ctx := context.Background()
+	cb := NewCaseBase()
+
+	require.NoError(t, cb.AddCase(ctx, &Case{Title: "Reset password", Problem: "user cannot log in"}))
+	require.NoError(t, cb.AddCase(ctx, &Case{Title: "Export report", Problem: "monthly csv export"}))
+
+	results, err := cb.SearchSimilarCases(ctx, "how to reset a password", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Reset password", results[0].Title)
+}
+
+func TestCaseRecorder(t *testing.T) {
+	ctx := context.Background()
+	cb := NewCaseBase()
+	recorder := NewCaseRecorder(cb)
+
+	input := &core.AgentInput{Task: "rotate the api keys of service billing"}
+	output := &core.AgentOutput{
+		Status: "success",
+		Result: "keys rotated",
+		ReasoningSteps: []core.ReasoningStep{
+			{Step: 1, Description: "list active keys"},
+			{Step: 2, Description: "issue new key and revoke old one"},
+		},
+		ToolCalls: []core.ToolCall{
+			{ToolName: "vault", Input: map[string]interface{}{"op": "rotate"}, Output: "ok", Success: true},
+		},
+	}
+
+	require.NoError(t, recorder.OnStart(ctx, input))
+	require.NoError(t, recorder.OnAgentFinish(ctx, output))
+	require.Equal(t, 1, cb.Len())
+
+	results, err := cb.SearchSimilarCases(ctx, "rotate api keys", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	c := results[0]
+	assert.Equal(t, CaseCategoryAgentRun, c.Category)
+	assert.Equal(t, "keys rotated", c.Solution)
+	assert.Equal(t, []string{"list active keys", "issue new key and revoke old one"}, c.Metadata[CaseMetadataPlan])
+	assert.Equal(t, []CaseToolCall{{Tool: "vault", Input: map[string]interface{}{"op": "rotate"}, Output: "ok", Success: true}},
+		c.Metadata[CaseMetadataToolTrace])
+
+	// The same run again reinforces the existing case
+	require.NoError(t, recorder.OnStart(ctx, input))
+	require.NoError(t, recorder.OnAgentFinish(ctx, output))
+	assert.Equal(t, 1, cb.Len())
+	c, err = cb.GetCase(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.SuccessCount)
+
+	// Failed and aborted runs are not recorded
+	require.NoError(t, recorder.OnStart(ctx, &core.AgentInput{Task: "delete production database"}))
+	require.NoError(t, recorder.OnAgentFinish(ctx, &core.AgentOutput{Status: "failed"}))
+	require.NoError(t, recorder.OnStart(ctx, &core.AgentInput{Task: "migrate schema"}))
+	require.NoError(t, recorder.OnError(ctx, assert.AnError))
+	assert.Equal(t, 1, cb.Len())
+	assert.Equal(t, 0, recorder.Pending())
+}
+
+// failingEmbedder fails every embedding request
+type failingEmbedder struct{}
+
+func (failingEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	return nil, assert.AnError
+}
+
+func (failingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	return nil, assert.AnError
+}
+
+func TestCaseRecorder_RecordError(t *testing.T) {
+	ctx := context.Background()
+	var reported []error
+	recorder := NewCaseRecorder(NewCaseBase(WithCaseEmbedder(failingEmbedder{})),
+		WithRecordErrorHandler(func(ctx context.Context, err error) {
+			reported = append(reported, err)
+		}))
+
+	require.NoError(t, recorder.OnStart(ctx, &core.AgentInput{Task: "rotate the api keys"}))
+	require.NoError(t, recorder.OnAgentFinish(ctx, &core.AgentOutput{Status: "success", Result: "done"}),
+		"recording failures never fail the run")
+	require.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], assert.AnError)
+}
+
+func TestCaseRecorder_PendingRuns(t *testing.T) {
+	cb := NewCaseBase()
+	recorder := NewCaseRecorder(cb)
+	success := &core.AgentOutput{Status: "success", Result: "done"}
+
+	// Concurrent runs sharing a parent context are told apart by run ID
+	parent := context.Background()
+	runA := interfaces.WithRunID(parent, "run-a")
+	runB := interfaces.WithRunID(parent, "run-b")
+	require.NoError(t, recorder.OnStart(runA, &core.AgentInput{Task: "task a"}))
+	require.NoError(t, recorder.OnStart(runB, &core.AgentInput{Task: "task b"}))
+	require.NoError(t, recorder.OnError(runA, assert.AnError))
+	require.NoError(t, recorder.OnAgentFinish(runB, success))
+	results, err := cb.SearchSimilarCases(parent, "task b", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "task b", results[0].Problem)
+	assert.Equal(t, 0, recorder.Pending())
+
+	// Cancelled runs are dropped
+	ctx, cancel := context.WithCancel(parent)
+	require.NoError(t, recorder.OnStart(ctx, &core.AgentInput{Task: "cancelled"}))
+	assert.Equal(t, 1, recorder.Pending())
+	cancel()
+	assert.Eventually(t, func() bool { return recorder.Pending() == 0 }, time.Second, 10*time.Millisecond)
+
+	// Abandoned runs expire
+	now := time.Now()
+	recorder.now = func() time.Time { return now }
+	require.NoError(t, recorder.OnStart(parent, &core.AgentInput{Task: "abandoned"}))
+	now = now.Add(pendingRunTTL + time.Minute)
+	require.NoError(t, recorder.OnStart(interfaces.WithRunID(parent, "next"), &core.AgentInput{Task: "next"}))
+	assert.Equal(t, 1, recorder.Pending())
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// CaseRunFromOutput builds a CaseRun from an agent input and output.
+// The plan is taken from the reasoning steps and the tool trace from the
+// tool calls; the run counts as successful when the status is "success".
+func CaseRunFromOutput(input *core.AgentInput, output *core.AgentOutput) *CaseRun {
+	if input == nil || output == nil {
+		return nil
+	}
+
+	task := input.Task
+	if input.Instruction != "" && input.Instruction != task {
+		task = strings.TrimSpace(task + "\n" + input.Instruction)
+	}
+
+	run := &CaseRun{
+		Input:   task,
+		Outcome: output.Message,
+		Success: output.Status == "success",
+	}
+	if output.Result != nil {
+		run.Outcome = fmt.Sprint(output.Result)
+	}
+
+	for _, step := range output.ReasoningSteps {
+		text := step.Action
+		if step.Description != "" {
+			text = step.Description
+		}
+		if text != "" {
+			run.Plan = append(run.Plan, text)
+		}
+	}
+	for _, call := range output.ToolCalls {
+		var out string
+		if call.Output != nil {
+			out = fmt.Sprint(call.Output)
+		}
+		run.ToolTrace = append(run.ToolTrace, CaseToolCall{
+			Tool:    call.ToolName,
+			Input:   call.Input,
+			Output:  out,
+			Success: call.Success,
+		})
+	}
+	return run
+}
+
+// pendingRunTTL bounds how long the input of a run that never reports an
+// outcome is kept
+const pendingRunTTL = time.Hour
+
+// CaseRecorder is a callback that records successful agent runs as cases.
+//
+// Register it on an agent's callbacks; the input seen in OnStart is paired
+// with the output of OnAgentFinish of the same run. Runs are identified by
+// the run ID set with interfaces.WithRunID, or by their context when no ID
+// is set, so concurrent runs sharing a context need distinct run IDs.
+// Pending inputs are dropped when the run finishes, fails, its context is
+// cancelled, or after an hour without an outcome. Recording failures are
+// passed to the handler set with WithRecordErrorHandler and never fail the run.
+type CaseRecorder struct {
+	*core.BaseCallback
+
+	caseBase *CaseBase
+	onError  func(ctx context.Context, err error)
+
+	mu      sync.Mutex
+	pending map[runKey]*pendingRun
+	now     func() time.Time
+}
+
+// runKey identifies a run by its run ID or, without one, by its context
+type runKey struct {
+	id  string
+	ctx context.Context
+}
+
+// pendingRun holds the inputs of the started, unfinished runs of a key
+type pendingRun struct {
+	inputs  []*core.AgentInput
+	started time.Time
+	stop    func() bool // stops the cancellation hook
+}
+
+// CaseRecorderOption configures a CaseRecorder
+type CaseRecorderOption func(*CaseRecorder)
+
+// WithRecordErrorHandler sets the function called when a finished run
+// cannot be recorded as a case
+func WithRecordErrorHandler(handler func(ctx context.Context, err error)) CaseRecorderOption {
+	return func(r *CaseRecorder) {
+		r.onError = handler
+	}
+}
+
+// NewCaseRecorder creates a callback that records runs into caseBase
+func NewCaseRecorder(caseBase *CaseBase, opts ...CaseRecorderOption) *CaseRecorder {
+	r := &CaseRecorder{
+		BaseCallback: core.NewBaseCallback(),
+		caseBase:     caseBase,
+		pending:      make(map[runKey]*pendingRun),
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func keyFor(ctx context.Context) runKey {
+	if id, ok := interfaces.RunIDFromContext(ctx); ok {
+		return runKey{id: id}
+	}
+	return runKey{ctx: ctx}
+}
+
+// OnStart remembers the agent input of the run
+func (r *CaseRecorder) OnStart(ctx context.Context, input interface{}) error {
+	agentInput, ok := input.(*core.AgentInput)
+	if !ok {
+		return nil
+	}
+
+	key := keyFor(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expireLocked()
+	run := r.pending[key]
+	if run == nil {
+		run = &pendingRun{stop: func() bool { return false }}
+		if ctx.Done() != nil {
+			cancelled := run
+			run.stop = context.AfterFunc(ctx, func() { r.drop(key, cancelled) })
+		}
+		r.pending[key] = run
+	}
+	run.inputs = append(run.inputs, agentInput)
+	run.started = r.now()
+	return nil
+}
+
+// OnAgentFinish records the finished run as a case if it succeeded
+func (r *CaseRecorder) OnAgentFinish(ctx context.Context, output interface{}) error {
+	input := r.pop(ctx)
+	agentOutput, ok := output.(*core.AgentOutput)
+	if !ok || input == nil {
+		return nil
+	}
+
+	if _, err := r.caseBase.RecordRun(ctx, CaseRunFromOutput(input, agentOutput)); err != nil && r.onError != nil {
+		r.onError(ctx, agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to record case from agent run").
+			WithComponent("case_recorder").
+			WithOperation("record_run"))
+	}
+	return nil
+}
+
+// OnError drops the pending input of the failed run
+func (r *CaseRecorder) OnError(ctx context.Context, err error) error {
+	r.pop(ctx)
+	return nil
+}
+
+// Pending returns the number of runs waiting for an outcome
+func (r *CaseRecorder) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, run := range r.pending {
+		n += len(run.inputs)
+	}
+	return n
+}
+
+func (r *CaseRecorder) pop(ctx context.Context) *core.AgentInput {
+	key := keyFor(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run := r.pending[key]
+	if run == nil || len(run.inputs) == 0 {
+		return nil
+	}
+	input := run.inputs[0]
+	run.inputs = run.inputs[1:]
+	if len(run.inputs) == 0 {
+		run.stop()
+		delete(r.pending, key)
+	}
+	return input
+}
+
+// drop removes the pending inputs of a cancelled run
+func (r *CaseRecorder) drop(key runKey, run *pendingRun) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending[key] == run {
+		delete(r.pending, key)
+	}
+}
+
+// expireLocked removes runs that have not reported an outcome within
+// pendingRunTTL. The caller must hold r.mu.
+func (r *CaseRecorder) expireLocked() {
+	cutoff := r.now().Add(-pendingRunTTL)
+	for key, run := range r.pending {
+		if run.started.Before(cutoff) {
+			run.stop()
+			delete(r.pending, key)
+		}
+	}
+}
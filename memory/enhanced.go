@@ -8,7 +8,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
 	"github.com/kart-io/goagent/utils/json"
 )
 
@@ -106,6 +109,20 @@ type HierarchicalMemory struct {
 	consolidationThreshold float64
 	decayRate              float64
 	importanceThreshold    float64
+	consolidationInterval  time.Duration
+
+	// Persistence and leader election
+	persistence Persistence
+	lease       store.Lease
+	leaseName   string
+	replicaID   string
+	state       PersistedState
+
+	// Entries whose access statistics changed since the last flush,
+	// and short-term entries evicted since the last write-through
+	dirtyMu sync.Mutex
+	dirty   map[string]bool
+	evicted []string
 }
 
 // NewHierarchicalMemory creates a new hierarchical memory system
@@ -116,9 +133,36 @@ func NewHierarchicalMemory(vectorStore VectorStore, opts ...MemoryOption) *Hiera
 
 // NewHierarchicalMemoryWithContext creates a new hierarchical memory system with a parent context
 func NewHierarchicalMemoryWithContext(parentCtx context.Context, vectorStore VectorStore, opts ...MemoryOption) *HierarchicalMemory {
+	m := newHierarchicalMemory(parentCtx, vectorStore, opts...)
+	m.start()
+	return m
+}
+
+// NewPersistentHierarchicalMemory creates a hierarchical memory backed by persistence.
+//
+// Entries and maintenance state are restored from persistence before the
+// background consolidation starts, and every change is written through.
+func NewPersistentHierarchicalMemory(parentCtx context.Context, vectorStore VectorStore, persistence Persistence, opts ...MemoryOption) (*HierarchicalMemory, error) {
+	if persistence == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "persistence is required").
+			WithComponent("hierarchical_memory").
+			WithOperation("new_persistent")
+	}
+
+	m := newHierarchicalMemory(parentCtx, vectorStore, append(opts, WithPersistence(persistence))...)
+	if err := m.Reload(parentCtx); err != nil {
+		m.cancel()
+		return nil, err
+	}
+
+	m.start()
+	return m, nil
+}
+
+// newHierarchicalMemory creates a hierarchical memory without starting background work
+func newHierarchicalMemory(parentCtx context.Context, vectorStore VectorStore, opts ...MemoryOption) *HierarchicalMemory {
 	ctx, cancel := context.WithCancel(parentCtx)
 	m := &HierarchicalMemory{
-		shortTerm:              NewShortTermMemory(100),
 		longTerm:               NewLongTermMemory(vectorStore),
 		vectorStore:            vectorStore,
 		consolidator:           NewMemoryConsolidator(),
@@ -129,19 +173,29 @@ func NewHierarchicalMemoryWithContext(parentCtx context.Context, vectorStore Vec
 		consolidationThreshold: 0.7,
 		decayRate:              0.01,
 		importanceThreshold:    0.3,
+		consolidationInterval:  5 * time.Minute,
+		leaseName:              "hierarchical_memory_consolidation",
+		dirty:                  make(map[string]bool),
 	}
 
 	for _, opt := range opts {
 		opt(m)
 	}
 
-	// Start background consolidation with proper lifecycle management
-	m.wg.Add(1)
-	go m.backgroundConsolidation()
+	if m.replicaID == "" {
+		m.replicaID = uuid.NewString()
+	}
+	m.shortTerm = m.newShortTerm()
 
 	return m
 }
 
+// start starts background consolidation with proper lifecycle management
+func (m *HierarchicalMemory) start() {
+	m.wg.Add(1)
+	go m.backgroundConsolidation()
+}
+
 // MemoryOption configures memory
 type MemoryOption func(*HierarchicalMemory)
 
@@ -159,6 +213,42 @@ func WithDecayRate(rate float64) MemoryOption {
 	}
 }
 
+// WithConsolidationInterval sets how often background consolidation runs
+func WithConsolidationInterval(interval time.Duration) MemoryOption {
+	return func(m *HierarchicalMemory) {
+		if interval > 0 {
+			m.consolidationInterval = interval
+		}
+	}
+}
+
+// WithPersistence writes every change through to persistence.
+// Use NewPersistentHierarchicalMemory to also restore existing state.
+func WithPersistence(persistence Persistence) MemoryOption {
+	return func(m *HierarchicalMemory) {
+		m.persistence = persistence
+	}
+}
+
+// WithConsolidationLease makes background consolidation run only on the
+// replica holding the named lease, so replicas sharing a backend do not
+// consolidate and decay the same entries concurrently
+func WithConsolidationLease(lease store.Lease, name string) MemoryOption {
+	return func(m *HierarchicalMemory) {
+		m.lease = lease
+		if name != "" {
+			m.leaseName = name
+		}
+	}
+}
+
+// WithReplicaID sets the lease holder identity, defaulting to a random ID
+func WithReplicaID(id string) MemoryOption {
+	return func(m *HierarchicalMemory) {
+		m.replicaID = id
+	}
+}
+
 // Shutdown gracefully shuts down the memory system
 func (m *HierarchicalMemory) Shutdown(ctx context.Context) error {
 	// Signal shutdown
@@ -174,7 +264,7 @@ func (m *HierarchicalMemory) Shutdown(ctx context.Context) error {
 
 	select {
 	case <-done:
-		return nil
+		return m.release(ctx)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
@@ -201,7 +291,10 @@ func (m *HierarchicalMemory) StoreTyped(ctx context.Context, key string, value i
 		Importance:  m.calculateImportance(value),
 		Decay:       1.0,
 		Tags:        opts.Tags,
-		Metadata:    make(map[string]interface{}),
+		Metadata:    make(map[string]interface{}, len(opts.Metadata)),
+	}
+	for k, v := range opts.Metadata {
+		entry.Metadata[k] = v
 	}
 
 	// Generate embedding if vector store is available
@@ -214,15 +307,23 @@ func (m *HierarchicalMemory) StoreTyped(ctx context.Context, key string, value i
 	// }
 
 	// Store based on type
+	tier := TierShortTerm
+	var err error
 	switch memType {
 	case MemoryTypeShortTerm:
-		return m.shortTerm.Store(ctx, entry)
+		err = m.shortTerm.Store(ctx, entry)
 	case MemoryTypeLongTerm, MemoryTypeEpisodic, MemoryTypeSemantic, MemoryTypeProcedural:
-		return m.longTerm.Store(ctx, entry)
+		tier = TierLongTerm
+		err = m.longTerm.Store(ctx, entry)
 	default:
 		// Default to short-term
-		return m.shortTerm.Store(ctx, entry)
+		err = m.shortTerm.Store(ctx, entry)
 	}
+	if err != nil {
+		return err
+	}
+
+	return m.writeThrough(ctx, tier, entry)
 }
 
 // Get retrieves a value from memory
@@ -233,12 +334,14 @@ func (m *HierarchicalMemory) Get(ctx context.Context, key string) (interface{},
 	// Check short-term first
 	if entry, err := m.shortTerm.Get(ctx, key); err == nil {
 		m.updateAccess(entry)
+		m.markDirty(entry.ID)
 		return entry.Content, nil
 	}
 
 	// Check long-term
 	if entry, err := m.longTerm.Get(ctx, key); err == nil {
 		m.updateAccess(entry)
+		m.markDirty(entry.ID)
 
 		// Promote frequently accessed long-term memories to short-term
 		if entry.AccessCount > 10 {
@@ -357,6 +460,10 @@ func (m *HierarchicalMemory) Consolidate(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := m.holdLease(ctx, "consolidate"); err != nil {
+		return err
+	}
+
 	// Get important short-term memories
 	candidates := m.shortTerm.GetConsolidationCandidates(m.consolidationThreshold)
 
@@ -395,7 +502,10 @@ func (m *HierarchicalMemory) Consolidate(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	m.state.LastConsolidation = time.Now()
+	m.state.Consolidations++
+
+	return m.persistConsolidation(ctx, candidates)
 }
 
 // Forget removes old or unimportant memories
@@ -403,8 +513,12 @@ func (m *HierarchicalMemory) Forget(ctx context.Context, threshold float64) erro
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := m.holdLease(ctx, "forget"); err != nil {
+		return err
+	}
+
 	// Apply decay to all memories
-	m.applyDecay()
+	shortTermDecayed, longTermDecayed := m.applyDecay()
 
 	// Forget short-term memories below threshold
 	shortTermForgotten := m.shortTerm.Forget(threshold)
@@ -422,7 +536,9 @@ func (m *HierarchicalMemory) Forget(ctx context.Context, threshold float64) erro
 		}
 	}
 
-	return nil
+	m.state.LastForget = time.Now()
+
+	return m.persistForget(ctx, shortTermForgotten, longTermForgotten, shortTermDecayed, longTermDecayed)
 }
 
 // Associate creates associations between memories
@@ -474,7 +590,7 @@ func (m *HierarchicalMemory) Associate(ctx context.Context, id1, id2 string, str
 	entry1.Metadata[fmt.Sprintf("association_%s", id2)] = strength
 	entry2.Metadata[fmt.Sprintf("association_%s", id1)] = strength
 
-	return nil
+	return m.saveByID(ctx, id1, id2)
 }
 
 // GetAssociated retrieves associated memories
@@ -520,10 +636,11 @@ func (m *HierarchicalMemory) GetStats() *MemoryStats {
 	defer m.mu.RUnlock()
 
 	stats := &MemoryStats{
-		TotalEntries:   m.shortTerm.Size() + m.longTerm.Size(),
-		ShortTermCount: m.shortTerm.Size(),
-		LongTermCount:  m.longTerm.Size(),
-		AccessPatterns: make(map[string]int),
+		TotalEntries:      m.shortTerm.Size() + m.longTerm.Size(),
+		ShortTermCount:    m.shortTerm.Size(),
+		LongTermCount:     m.longTerm.Size(),
+		LastConsolidation: m.state.LastConsolidation,
+		AccessPatterns:    make(map[string]int),
 	}
 
 	// Count by type
@@ -568,6 +685,20 @@ func (m *HierarchicalMemory) Clear(ctx context.Context) error {
 		}
 	}
 
+	m.state = PersistedState{}
+	m.dirtyMu.Lock()
+	m.dirty = make(map[string]bool)
+	m.evicted = nil
+	m.dirtyMu.Unlock()
+
+	if m.persistence != nil {
+		if err := m.persistence.Clear(ctx); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to clear persisted memory").
+				WithComponent("hierarchical_memory").
+				WithOperation("clear")
+		}
+	}
+
 	return nil
 }
 
@@ -618,7 +749,9 @@ func (m *HierarchicalMemory) updateAccess(entry *MemoryEntry) {
 	}
 }
 
-func (m *HierarchicalMemory) applyDecay() {
+// applyDecay decays the importance of all memories and returns the entries
+// of each tier whose importance changed
+func (m *HierarchicalMemory) applyDecay() (shortTermDecayed, longTermDecayed []*MemoryEntry) {
 	// Apply decay to short-term memories
 	for _, entry := range m.shortTerm.GetAll() {
 		timeSinceAccess := time.Since(entry.LastAccess)
@@ -627,7 +760,10 @@ func (m *HierarchicalMemory) applyDecay() {
 			decayFactor = 0
 		}
 		entry.Decay = decayFactor
-		entry.Importance *= decayFactor
+		if decayed := entry.Importance * decayFactor; decayed != entry.Importance {
+			entry.Importance = decayed
+			shortTermDecayed = append(shortTermDecayed, entry)
+		}
 	}
 
 	// Apply slower decay to long-term memories
@@ -638,13 +774,18 @@ func (m *HierarchicalMemory) applyDecay() {
 			decayFactor = 0
 		}
 		entry.Decay = decayFactor
-		entry.Importance *= decayFactor
+		if decayed := entry.Importance * decayFactor; decayed != entry.Importance {
+			entry.Importance = decayed
+			longTermDecayed = append(longTermDecayed, entry)
+		}
 	}
+
+	return shortTermDecayed, longTermDecayed
 }
 
 func (m *HierarchicalMemory) backgroundConsolidation() {
 	defer m.wg.Done()
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(m.consolidationInterval)
 	defer ticker.Stop()
 
 	for {
@@ -652,11 +793,10 @@ func (m *HierarchicalMemory) backgroundConsolidation() {
 		case <-m.ctx.Done():
 			return // Clean shutdown
 		case <-ticker.C:
-			// Consolidate memories
-			_ = m.Consolidate(m.ctx)
-
-			// Forget unimportant memories
-			_ = m.Forget(m.ctx, m.importanceThreshold)
+			// Consolidate and forget memories, on the lease holder only
+			if _, err := m.RunMaintenance(m.ctx); err != nil && m.ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "background memory maintenance failed: %v\n", err)
+			}
 		}
 	}
 }
@@ -0,0 +1,449 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Flush writes access statistics changed by Get and pending evictions to persistence.
+// It is called by background maintenance and Shutdown.
+func (m *HierarchicalMemory) Flush(ctx context.Context) error {
+	if m.persistence == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.flushLocked(ctx)
+}
+
+// flushLocked is Flush with m.mu held
+func (m *HierarchicalMemory) flushLocked(ctx context.Context) error {
+	m.dirtyMu.Lock()
+	ids := make([]string, 0, len(m.dirty))
+	for id := range m.dirty {
+		ids = append(ids, id)
+	}
+	m.dirty = make(map[string]bool)
+	m.dirtyMu.Unlock()
+
+	if err := m.flushEvictions(ctx); err != nil {
+		m.markDirty(ids...)
+		return err
+	}
+	if err := m.saveByID(ctx, ids...); err != nil {
+		m.markDirty(ids...)
+		return err
+	}
+	return nil
+}
+
+// Snapshot writes the complete memory to persistence, removing persisted
+// entries that are no longer in memory. Use it to seed a new backend or to
+// repair one after failed write-throughs.
+func (m *HierarchicalMemory) Snapshot(ctx context.Context) error {
+	if m.persistence == nil {
+		return agentErrors.New(agentErrors.CodeInvalidConfig, "persistence not configured").
+			WithComponent("hierarchical_memory").
+			WithOperation("snapshot")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.dirtyMu.Lock()
+	m.dirty = make(map[string]bool)
+	m.evicted = nil
+	m.dirtyMu.Unlock()
+
+	tiers := map[MemoryTier][]*MemoryEntry{
+		TierShortTerm: m.shortTerm.GetAll(),
+		TierLongTerm:  m.longTerm.GetAll(),
+	}
+	for tier, entries := range tiers {
+		persisted, err := m.persistence.LoadEntries(ctx, tier)
+		if err != nil {
+			return persistenceError(err, "snapshot")
+		}
+
+		current := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			current[entry.ID] = true
+		}
+		var stale []string
+		for _, entry := range persisted {
+			if !current[entry.ID] {
+				stale = append(stale, entry.ID)
+			}
+		}
+
+		if err := m.persistence.DeleteEntries(ctx, tier, stale); err != nil {
+			return persistenceError(err, "snapshot")
+		}
+		if err := m.persistence.SaveEntries(ctx, tier, entries); err != nil {
+			return persistenceError(err, "snapshot")
+		}
+	}
+
+	state := m.state
+	if err := m.persistence.SaveState(ctx, &state); err != nil {
+		return persistenceError(err, "snapshot")
+	}
+	return nil
+}
+
+// Reload replaces the in-memory tiers and maintenance state with the persisted ones.
+//
+// Pending access statistics and evictions are flushed first, and writes are
+// blocked until the swap, so no local change is lost. Entries persisted in
+// both tiers (long-term memories promoted to short-term) are restored as a
+// single shared entry, as they were before the restart.
+func (m *HierarchicalMemory) Reload(ctx context.Context) error {
+	if m.persistence == nil {
+		return agentErrors.New(agentErrors.CodeInvalidConfig, "persistence not configured").
+			WithComponent("hierarchical_memory").
+			WithOperation("reload")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.flushLocked(ctx); err != nil {
+		return err
+	}
+	state, err := m.persistence.LoadState(ctx)
+	if err != nil {
+		return persistenceError(err, "reload")
+	}
+	longEntries, err := m.persistence.LoadEntries(ctx, TierLongTerm)
+	if err != nil {
+		return persistenceError(err, "reload")
+	}
+	shortEntries, err := m.persistence.LoadEntries(ctx, TierShortTerm)
+	if err != nil {
+		return persistenceError(err, "reload")
+	}
+	sortByTimestamp(longEntries)
+	sortByTimestamp(shortEntries)
+
+	m.dirtyMu.Lock()
+	m.dirty = make(map[string]bool)
+	m.evicted = nil
+	m.dirtyMu.Unlock()
+
+	// Restored entries are already in the vector store, so attach it afterwards
+	longTerm := NewLongTermMemory(nil)
+	shared := make(map[string]*MemoryEntry, len(longEntries))
+	for _, entry := range longEntries {
+		_ = longTerm.Store(ctx, entry)
+		shared[entry.ID] = entry
+	}
+	longTerm.vectorStore = m.vectorStore
+
+	shortTerm := m.newShortTerm()
+	for _, entry := range shortEntries {
+		if existing, ok := shared[entry.ID]; ok {
+			entry = existing
+		}
+		_ = shortTerm.Store(ctx, entry)
+	}
+
+	m.shortTerm = shortTerm
+	m.longTerm = longTerm
+	m.state = PersistedState{}
+	if state != nil {
+		m.state = *state
+	}
+
+	return nil
+}
+
+// RunMaintenance runs one round of background consolidation and forgetting.
+//
+// With a consolidation lease configured, only the replica holding the lease
+// consolidates; it reports whether this replica did. The lease holder reloads
+// persistence first so it acts on the other replicas' writes; the others
+// reload only after the holder has run maintenance since their last reload.
+func (m *HierarchicalMemory) RunMaintenance(ctx context.Context) (bool, error) {
+	if err := m.Flush(ctx); err != nil {
+		return false, err
+	}
+
+	if m.lease != nil {
+		acquired, err := m.acquireLease(ctx, "run_maintenance")
+		if err != nil {
+			return false, err
+		}
+
+		if m.persistence != nil {
+			reload := acquired
+			if !acquired {
+				if reload, err = m.maintainedElsewhere(ctx); err != nil {
+					return false, err
+				}
+			}
+			if reload {
+				if err := m.Reload(ctx); err != nil {
+					return false, err
+				}
+			}
+		}
+		if !acquired {
+			return false, nil
+		}
+	}
+
+	if err := m.Consolidate(ctx); err != nil {
+		return true, err
+	}
+	if err := m.Forget(ctx, m.importanceThreshold); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// acquireLease obtains or renews the consolidation lease for this replica
+func (m *HierarchicalMemory) acquireLease(ctx context.Context, operation string) (bool, error) {
+	acquired, err := m.lease.Acquire(ctx, m.leaseName, m.replicaID, 2*m.consolidationInterval)
+	if err != nil {
+		return false, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to acquire consolidation lease").
+			WithComponent("hierarchical_memory").
+			WithOperation(operation).
+			WithContext("lease", m.leaseName)
+	}
+	return acquired, nil
+}
+
+// holdLease renews the consolidation lease before maintenance writes to
+// persistence, failing when another replica has taken it over. It must be
+// called with m.mu held and before any in-memory change.
+func (m *HierarchicalMemory) holdLease(ctx context.Context, operation string) error {
+	if m.lease == nil || m.persistence == nil {
+		return nil
+	}
+
+	acquired, err := m.acquireLease(ctx, operation)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return agentErrors.New(agentErrors.CodeStateSave, "consolidation lease is held by another replica").
+			WithComponent("hierarchical_memory").
+			WithOperation(operation).
+			WithContext("lease", m.leaseName).
+			WithContext("replica_id", m.replicaID)
+	}
+	return nil
+}
+
+// maintainedElsewhere reports whether the persisted maintenance state is
+// newer than the local one, meaning another replica consolidated or forgot
+func (m *HierarchicalMemory) maintainedElsewhere(ctx context.Context) (bool, error) {
+	state, err := m.persistence.LoadState(ctx)
+	if err != nil {
+		return false, persistenceError(err, "run_maintenance")
+	}
+	if state == nil {
+		return false, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !state.LastConsolidation.Equal(m.state.LastConsolidation) ||
+		!state.LastForget.Equal(m.state.LastForget), nil
+}
+
+// release flushes pending changes and gives up the consolidation lease
+func (m *HierarchicalMemory) release(ctx context.Context) error {
+	if err := m.Flush(ctx); err != nil {
+		return err
+	}
+	if m.lease != nil {
+		if err := m.lease.Release(ctx, m.leaseName, m.replicaID); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to release consolidation lease").
+				WithComponent("hierarchical_memory").
+				WithOperation("shutdown").
+				WithContext("lease", m.leaseName)
+		}
+	}
+	return nil
+}
+
+// newShortTerm creates the short-term tier, tracking evictions for write-through
+func (m *HierarchicalMemory) newShortTerm() *ShortTermMemory {
+	shortTerm := NewShortTermMemory(m.shortTermCapacity)
+	shortTerm.onEvict = m.recordEviction
+	return shortTerm
+}
+
+// recordEviction remembers an evicted short-term entry until it is deleted from persistence
+func (m *HierarchicalMemory) recordEviction(entry *MemoryEntry) {
+	if m.persistence == nil {
+		return
+	}
+	m.dirtyMu.Lock()
+	m.evicted = append(m.evicted, entry.ID)
+	m.dirtyMu.Unlock()
+}
+
+// markDirty records entries whose access statistics changed
+func (m *HierarchicalMemory) markDirty(ids ...string) {
+	if m.persistence == nil {
+		return
+	}
+	m.dirtyMu.Lock()
+	for _, id := range ids {
+		m.dirty[id] = true
+	}
+	m.dirtyMu.Unlock()
+}
+
+// writeThrough persists entries of a tier along with pending evictions
+func (m *HierarchicalMemory) writeThrough(ctx context.Context, tier MemoryTier, entries ...*MemoryEntry) error {
+	if m.persistence == nil {
+		return nil
+	}
+	if err := m.flushEvictions(ctx); err != nil {
+		return err
+	}
+	if err := m.persistence.SaveEntries(ctx, tier, entries); err != nil {
+		return persistenceError(err, "write_through")
+	}
+	return nil
+}
+
+// flushEvictions deletes evicted short-term entries from persistence
+func (m *HierarchicalMemory) flushEvictions(ctx context.Context) error {
+	m.dirtyMu.Lock()
+	evicted := m.evicted
+	m.evicted = nil
+	m.dirtyMu.Unlock()
+
+	// Skip entries that were stored again after being evicted
+	ids := make([]string, 0, len(evicted))
+	for _, id := range evicted {
+		if _, err := m.shortTerm.Get(ctx, id); err != nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := m.persistence.DeleteEntries(ctx, TierShortTerm, ids); err != nil {
+		m.dirtyMu.Lock()
+		m.evicted = append(m.evicted, ids...)
+		m.dirtyMu.Unlock()
+		return persistenceError(err, "flush_evictions")
+	}
+	return nil
+}
+
+// saveByID persists the given entries in every tier that holds them
+func (m *HierarchicalMemory) saveByID(ctx context.Context, ids ...string) error {
+	if m.persistence == nil || len(ids) == 0 {
+		return nil
+	}
+
+	var shortEntries, longEntries []*MemoryEntry
+	for _, id := range ids {
+		if entry, err := m.shortTerm.Get(ctx, id); err == nil {
+			shortEntries = append(shortEntries, entry)
+		}
+		if entry, err := m.longTerm.Get(ctx, id); err == nil {
+			longEntries = append(longEntries, entry)
+		}
+	}
+
+	if err := m.writeThrough(ctx, TierShortTerm, shortEntries...); err != nil {
+		return err
+	}
+	return m.writeThrough(ctx, TierLongTerm, longEntries...)
+}
+
+// persistConsolidation moves consolidated entries to the long-term tier in persistence
+func (m *HierarchicalMemory) persistConsolidation(ctx context.Context, consolidated []*MemoryEntry) error {
+	if m.persistence == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(consolidated))
+	for _, entry := range consolidated {
+		ids = append(ids, entry.ID)
+	}
+
+	if err := m.writeThrough(ctx, TierLongTerm, consolidated...); err != nil {
+		return err
+	}
+	if err := m.persistence.DeleteEntries(ctx, TierShortTerm, ids); err != nil {
+		return persistenceError(err, "consolidate")
+	}
+	state := m.state
+	if err := m.persistence.SaveState(ctx, &state); err != nil {
+		return persistenceError(err, "consolidate")
+	}
+	return nil
+}
+
+// persistForget deletes forgotten entries and saves the entries whose importance decayed
+func (m *HierarchicalMemory) persistForget(ctx context.Context, shortTermForgotten, longTermForgotten []string, shortTermDecayed, longTermDecayed []*MemoryEntry) error {
+	if m.persistence == nil {
+		return nil
+	}
+
+	if err := m.persistence.DeleteEntries(ctx, TierShortTerm, shortTermForgotten); err != nil {
+		return persistenceError(err, "forget")
+	}
+	if err := m.persistence.DeleteEntries(ctx, TierLongTerm, longTermForgotten); err != nil {
+		return persistenceError(err, "forget")
+	}
+
+	// Rewriting unchanged entries would overwrite other replicas' writes
+	if err := m.writeThrough(ctx, TierShortTerm, withoutIDs(shortTermDecayed, shortTermForgotten)...); err != nil {
+		return err
+	}
+	if err := m.writeThrough(ctx, TierLongTerm, withoutIDs(longTermDecayed, longTermForgotten)...); err != nil {
+		return err
+	}
+
+	state := m.state
+	if err := m.persistence.SaveState(ctx, &state); err != nil {
+		return persistenceError(err, "forget")
+	}
+	return nil
+}
+
+// withoutIDs returns the entries whose IDs are not listed
+func withoutIDs(entries []*MemoryEntry, ids []string) []*MemoryEntry {
+	if len(ids) == 0 {
+		return entries
+	}
+
+	excluded := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		excluded[id] = true
+	}
+	kept := make([]*MemoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !excluded[entry.ID] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// persistenceError wraps an error returned by the persistence backend
+func persistenceError(err error, operation string) error {
+	return agentErrors.Wrap(err, agentErrors.CodeStateSave, "memory persistence failed").
+		WithComponent("hierarchical_memory").
+		WithOperation(operation)
+}
+
+// sortByTimestamp orders entries by creation time
+func sortByTimestamp(entries []*MemoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+}
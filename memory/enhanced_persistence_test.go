@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storememory "github.com/kart-io/goagent/store/memory"
+)
+
+func TestPersistentHierarchicalMemory_Restore(t *testing.T) {
+	ctx := context.Background()
+	persistence := NewStorePersistence(storememory.New())
+
+	hm, err := NewPersistentHierarchicalMemory(ctx, nil, persistence)
+	require.NoError(t, err)
+
+	require.NoError(t, hm.Store(ctx, "deploy", "deploys happen on tuesdays", StoreOptions{Tags: []string{"ops"}}))
+	require.NoError(t, hm.StoreTyped(ctx, "oncall", "alice is on call", MemoryTypeSemantic, StoreOptions{}))
+	for i := 0; i < 3; i++ {
+		_, err := hm.Get(ctx, "deploy")
+		require.NoError(t, err)
+	}
+	require.NoError(t, hm.Associate(ctx, "deploy", "oncall", 0.8))
+	require.NoError(t, hm.Shutdown(ctx))
+
+	restored, err := NewPersistentHierarchicalMemory(ctx, nil, persistence)
+	require.NoError(t, err)
+	defer func() { _ = restored.Shutdown(ctx) }()
+
+	value, err := restored.Get(ctx, "oncall")
+	require.NoError(t, err)
+	assert.Equal(t, "alice is on call", value)
+
+	entry, err := restored.shortTerm.Get(ctx, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, 3, entry.AccessCount, "access statistics are flushed on shutdown")
+	assert.Equal(t, []string{"ops"}, entry.Tags)
+
+	associated, err := restored.GetAssociated(ctx, "deploy", 10)
+	require.NoError(t, err)
+	require.Len(t, associated, 1)
+	assert.Equal(t, "oncall", associated[0].ID)
+
+	_, err = NewPersistentHierarchicalMemory(ctx, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestPersistentHierarchicalMemory_ConsolidationState(t *testing.T) {
+	ctx := context.Background()
+	persistence := NewStorePersistence(storememory.New(), "agents", "ops")
+
+	hm, err := NewPersistentHierarchicalMemory(ctx, nil, persistence, WithShortTermCapacity(2))
+	require.NoError(t, err)
+	defer func() { _ = hm.Shutdown(ctx) }()
+
+	require.NoError(t, hm.Store(ctx, "frequent", "looked up often", StoreOptions{}))
+	for i := 0; i < 6; i++ {
+		_, err := hm.Get(ctx, "frequent")
+		require.NoError(t, err)
+	}
+	require.NoError(t, hm.Consolidate(ctx))
+
+	longTerm, err := persistence.LoadEntries(ctx, TierLongTerm)
+	require.NoError(t, err)
+	require.Len(t, longTerm, 1)
+	assert.Equal(t, "frequent", longTerm[0].ID)
+	assert.Equal(t, MemoryTypeLongTerm, longTerm[0].Type)
+	assert.Equal(t, 6, longTerm[0].AccessCount)
+
+	// Evicted short-term entries are removed from persistence
+	require.NoError(t, hm.Store(ctx, "a", "first", StoreOptions{}))
+	require.NoError(t, hm.Store(ctx, "b", "second", StoreOptions{}))
+	require.NoError(t, hm.Store(ctx, "c", "third", StoreOptions{}))
+	shortTerm, err := persistence.LoadEntries(ctx, TierShortTerm)
+	require.NoError(t, err)
+	assert.Len(t, shortTerm, 2)
+
+	state, err := persistence.LoadState(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, 1, state.Consolidations)
+
+	restored, err := NewPersistentHierarchicalMemory(ctx, nil, persistence, WithShortTermCapacity(2))
+	require.NoError(t, err)
+	defer func() { _ = restored.Shutdown(ctx) }()
+	assert.Equal(t, hm.GetStats().LastConsolidation.Unix(), restored.GetStats().LastConsolidation.Unix())
+	assert.Equal(t, 3, restored.GetStats().TotalEntries)
+
+	require.NoError(t, restored.Clear(ctx))
+	longTerm, err = persistence.LoadEntries(ctx, TierLongTerm)
+	require.NoError(t, err)
+	assert.Empty(t, longTerm)
+}
+
+func TestPersistentHierarchicalMemory_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	// Memory populated before persistence was configured
+	hm := newHierarchicalMemory(ctx, nil)
+	require.NoError(t, hm.Store(ctx, "a", "first", StoreOptions{}))
+	assert.Error(t, hm.Snapshot(ctx))
+
+	persistence := NewStorePersistence(storememory.New())
+	require.NoError(t, persistence.SaveEntries(ctx, TierShortTerm, []*MemoryEntry{{ID: "stale"}}))
+	hm.persistence = persistence
+	require.NoError(t, hm.Snapshot(ctx))
+
+	entries, err := persistence.LoadEntries(ctx, TierShortTerm)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "a", entries[0].ID)
+}
+
+func TestPersistentHierarchicalMemory_ReloadKeepsLocalChanges(t *testing.T) {
+	ctx := context.Background()
+	hm, err := NewPersistentHierarchicalMemory(ctx, nil, NewStorePersistence(storememory.New()),
+		WithShortTermCapacity(100))
+	require.NoError(t, err)
+	defer func() { _ = hm.Shutdown(ctx) }()
+
+	require.NoError(t, hm.Store(ctx, "deploy", "deploys happen on tuesdays", StoreOptions{}))
+	for i := 0; i < 2; i++ {
+		_, err := hm.Get(ctx, "deploy")
+		require.NoError(t, err)
+	}
+	require.NoError(t, hm.Reload(ctx))
+	entry, err := hm.shortTerm.Get(ctx, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.AccessCount, "unflushed access statistics survive a reload")
+
+	// Writes racing with reloads are never dropped
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			assert.NoError(t, hm.Store(ctx, fmt.Sprintf("note-%d", i), "note", StoreOptions{}))
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, hm.Reload(ctx))
+	}
+	wg.Wait()
+	require.NoError(t, hm.Reload(ctx))
+	for i := 0; i < 50; i++ {
+		_, err := hm.Get(ctx, fmt.Sprintf("note-%d", i))
+		assert.NoError(t, err)
+	}
+}
+
+func TestPersistentHierarchicalMemory_ConsolidationLease(t *testing.T) {
+	ctx := context.Background()
+	backend := storememory.New()
+	persistence := NewStorePersistence(backend)
+
+	replicaA, err := NewPersistentHierarchicalMemory(ctx, nil, persistence,
+		WithConsolidationLease(backend, ""), WithReplicaID("replica-a"))
+	require.NoError(t, err)
+	replicaB, err := NewPersistentHierarchicalMemory(ctx, nil, persistence,
+		WithConsolidationLease(backend, ""), WithReplicaID("replica-b"))
+	require.NoError(t, err)
+	defer func() { _ = replicaB.Shutdown(ctx) }()
+
+	ran, err := replicaA.RunMaintenance(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	// Replica B writes an entry, which the leader picks up on its next round
+	require.NoError(t, replicaB.Store(ctx, "frequent", "looked up often", StoreOptions{}))
+	for i := 0; i < 6; i++ {
+		_, err := replicaB.Get(ctx, "frequent")
+		require.NoError(t, err)
+	}
+
+	ran, err = replicaB.RunMaintenance(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran, "only the lease holder consolidates")
+
+	ran, err = replicaA.RunMaintenance(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	_, err = replicaA.longTerm.Get(ctx, "frequent")
+	require.NoError(t, err, "the leader consolidated the follower's entry")
+
+	// Followers reload the leader's changes
+	_, err = replicaB.RunMaintenance(ctx)
+	require.NoError(t, err)
+	_, err = replicaB.longTerm.Get(ctx, "frequent")
+	assert.NoError(t, err)
+
+	// Shutdown releases the lease so another replica takes over
+	require.NoError(t, replicaA.Shutdown(ctx))
+	ran, err = replicaB.RunMaintenance(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+}
+
+func TestPersistentHierarchicalMemory_MaintenanceWrites(t *testing.T) {
+	ctx := context.Background()
+	backend := storememory.New()
+	persistence := NewStorePersistence(backend)
+
+	leader, err := NewPersistentHierarchicalMemory(ctx, nil, persistence, WithDecayRate(0),
+		WithConsolidationLease(backend, ""), WithReplicaID("leader"))
+	require.NoError(t, err)
+	defer func() { _ = leader.Shutdown(ctx) }()
+	follower, err := NewPersistentHierarchicalMemory(ctx, nil, persistence, WithDecayRate(0),
+		WithConsolidationLease(backend, ""), WithReplicaID("follower"))
+	require.NoError(t, err)
+	defer func() { _ = follower.Shutdown(ctx) }()
+
+	ran, err := leader.RunMaintenance(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	_, err = follower.RunMaintenance(ctx)
+	require.NoError(t, err)
+
+	// Another replica updates an entry the leader also holds
+	require.NoError(t, leader.Store(ctx, "deploy", "deploys happen on tuesdays", StoreOptions{}))
+	entries, err := persistence.LoadEntries(ctx, TierShortTerm)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entries[0].AccessCount = 5
+	require.NoError(t, persistence.SaveEntries(ctx, TierShortTerm, entries))
+
+	// Followers skip the reload until the leader has run maintenance
+	_, err = follower.RunMaintenance(ctx)
+	require.NoError(t, err)
+	_, err = follower.shortTerm.Get(ctx, "deploy")
+	assert.Error(t, err, "follower reloaded without new maintenance")
+
+	// Without decay no importance changes, so forgetting rewrites nothing
+	require.NoError(t, leader.Forget(ctx, 0))
+	entries, err = persistence.LoadEntries(ctx, TierShortTerm)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 5, entries[0].AccessCount, "unchanged entries are not overwritten")
+
+	_, err = follower.RunMaintenance(ctx)
+	require.NoError(t, err)
+	entry, err := follower.shortTerm.Get(ctx, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, 5, entry.AccessCount)
+}
+
+func TestPersistentHierarchicalMemory_LostLease(t *testing.T) {
+	ctx := context.Background()
+	backend := storememory.New()
+	persistence := NewStorePersistence(backend)
+
+	hm, err := NewPersistentHierarchicalMemory(ctx, nil, persistence,
+		WithConsolidationLease(backend, "maintenance"), WithReplicaID("replica-a"))
+	require.NoError(t, err)
+	defer func() { _ = hm.Shutdown(ctx) }()
+
+	require.NoError(t, hm.Store(ctx, "frequent", "looked up often", StoreOptions{}))
+	for i := 0; i < 6; i++ {
+		_, err := hm.Get(ctx, "frequent")
+		require.NoError(t, err)
+	}
+	ran, err := hm.RunMaintenance(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	require.NoError(t, hm.Store(ctx, "note", "looked up often", StoreOptions{}))
+	for i := 0; i < 6; i++ {
+		_, err := hm.Get(ctx, "note")
+		require.NoError(t, err)
+	}
+
+	// The lease expires and another replica takes it over
+	require.NoError(t, backend.Release(ctx, "maintenance", "replica-a"))
+	acquired, err := backend.Acquire(ctx, "maintenance", "replica-b", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	assert.Error(t, hm.Consolidate(ctx))
+	assert.Error(t, hm.Forget(ctx, 1))
+	_, err = hm.shortTerm.Get(ctx, "note")
+	assert.NoError(t, err, "memory is unchanged when the lease is lost")
+	longTerm, err := persistence.LoadEntries(ctx, TierLongTerm)
+	require.NoError(t, err)
+	require.Len(t, longTerm, 1)
+	assert.Equal(t, "frequent", longTerm[0].ID)
+}
@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+)
+
+// DefaultCommunityPrompt 默认社区摘要提示词
+const DefaultCommunityPrompt = `Write a concise summary (at most 3 sentences) of the following group of related entities.
+Describe what the group is about and the most important relationships.
+
+Entities: %s
+
+Facts:
+%s
+
+Summary:`
+
+// maxSummaryFacts 摘要中最多列出的事实数
+const maxSummaryFacts = 20
+
+// DetectCommunities 使用标签传播发现社区
+//
+// 关系视为无向边,权重为关系权重;节点按 ID 顺序更新、平票取字典序最小的标签,
+// 因此相同的图总是得到相同的划分。返回的每个社区按实体 ID 排序
+func DetectCommunities(sub *Subgraph, maxIterations int) [][]string {
+	if maxIterations <= 0 {
+		maxIterations = 20
+	}
+
+	neighbors := make(map[string]map[string]float64, len(sub.Entities))
+	for id := range sub.Entities {
+		neighbors[id] = make(map[string]float64)
+	}
+	for _, r := range sub.Relations {
+		if neighbors[r.Source] == nil || neighbors[r.Target] == nil || r.Source == r.Target {
+			continue
+		}
+		neighbors[r.Source][r.Target] += r.Weight
+		neighbors[r.Target][r.Source] += r.Weight
+	}
+
+	nodes := make([]string, 0, len(neighbors))
+	labels := make(map[string]string, len(neighbors))
+	for id := range neighbors {
+		nodes = append(nodes, id)
+		labels[id] = id
+	}
+	sort.Strings(nodes)
+
+	for i := 0; i < maxIterations; i++ {
+		changed := false
+		for _, node := range nodes {
+			if len(neighbors[node]) == 0 {
+				continue
+			}
+
+			weights := make(map[string]float64)
+			for neighbor, w := range neighbors[node] {
+				weights[labels[neighbor]] += w
+			}
+
+			best := labels[node]
+			bestWeight := weights[best]
+			for label, w := range weights {
+				if w > bestWeight || (w == bestWeight && label < best) {
+					best, bestWeight = label, w
+				}
+			}
+			if best != labels[node] {
+				labels[node] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, node := range nodes {
+		groups[labels[node]] = append(groups[labels[node]], node)
+	}
+
+	communities := make([][]string, 0, len(groups))
+	for _, members := range groups {
+		communities = append(communities, members)
+	}
+	sort.Slice(communities, func(i, j int) bool {
+		if len(communities[i]) != len(communities[j]) {
+			return len(communities[i]) > len(communities[j])
+		}
+		return communities[i][0] < communities[j][0]
+	})
+	return communities
+}
+
+// CommunitySummarizer 社区摘要生成器
+type CommunitySummarizer interface {
+	Summarize(ctx context.Context, entities []*Entity, relations []*Relation) (string, error)
+}
+
+// LLMCommunitySummarizer 基于 LLM 的社区摘要生成器
+type LLMCommunitySummarizer struct {
+	client llm.Client
+
+	// Prompt 自定义提示词,占位符依次为实体列表和事实列表
+	Prompt string
+}
+
+// NewLLMCommunitySummarizer 创建 LLM 社区摘要生成器
+func NewLLMCommunitySummarizer(client llm.Client) *LLMCommunitySummarizer {
+	return &LLMCommunitySummarizer{client: client, Prompt: DefaultCommunityPrompt}
+}
+
+// Summarize 实现 CommunitySummarizer 接口
+func (s *LLMCommunitySummarizer) Summarize(ctx context.Context, entities []*Entity, relations []*Relation) (string, error) {
+	names := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		names = append(names, entity.Name)
+	}
+
+	response, err := s.client.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(fmt.Sprintf(s.Prompt, strings.Join(names, ", "), FormatFacts(relations, entityNames(entities), maxSummaryFacts))),
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "community summary failed").
+			WithComponent("knowledge_graph").
+			WithOperation("summarize_community")
+	}
+	return strings.TrimSpace(response.Content), nil
+}
+
+// FactSummarizer 不调用 LLM 的摘要生成器,直接列出实体和事实
+type FactSummarizer struct{}
+
+// Summarize 实现 CommunitySummarizer 接口
+func (FactSummarizer) Summarize(_ context.Context, entities []*Entity, relations []*Relation) (string, error) {
+	names := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		names = append(names, entity.Name)
+	}
+	return "Entities: " + strings.Join(names, ", ") + "\n" + FormatFacts(relations, entityNames(entities), maxSummaryFacts), nil
+}
+
+// FormatFacts 将关系格式化为每行一条的事实,names 用于把实体 ID 还原为名称
+func FormatFacts(relations []*Relation, names map[string]string, limit int) string {
+	lines := make([]string, 0, len(relations))
+	for _, r := range relations {
+		if limit > 0 && len(lines) >= limit {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("- %s %s %s", displayName(names, r.Source), r.Type, displayName(names, r.Target)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// entityNames 实体 ID 到名称的映射
+func entityNames(entities []*Entity) map[string]string {
+	names := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		names[entity.ID] = entity.Name
+	}
+	return names
+}
+
+// displayName 返回实体名称,未知时返回 ID
+func displayName(names map[string]string, id string) string {
+	if name := names[id]; name != "" {
+		return name
+	}
+	return id
+}
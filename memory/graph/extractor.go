@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// 来源类型
+const (
+	SourceTypeDocument     = "document"
+	SourceTypeConversation = "conversation"
+)
+
+// Source 待抽取的文本来源
+type Source struct {
+	// ID 来源标识,写入关系的 Provenance
+	ID string
+
+	// Type 来源类型
+	Type string
+
+	// Text 文本内容
+	Text string
+}
+
+// DocumentSource 创建文档来源
+func DocumentSource(id, text string) Source {
+	return Source{ID: id, Type: SourceTypeDocument, Text: text}
+}
+
+// ConversationSource 将对话消息转换为来源,每条消息一行 "role: content"
+func ConversationSource(id string, messages []llm.Message) Source {
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		lines = append(lines, msg.Role+": "+msg.Content)
+	}
+	return Source{ID: id, Type: SourceTypeConversation, Text: strings.Join(lines, "\n")}
+}
+
+// Extractor 三元组抽取器接口
+type Extractor interface {
+	Extract(ctx context.Context, source Source) ([]*Triple, error)
+}
+
+// DefaultExtractionPrompt 默认三元组抽取提示词
+//
+// 占位符依次为:实体类型说明、三元组上限、文本
+const DefaultExtractionPrompt = `Extract a knowledge graph from the text below.
+Identify entities%s and the relations between them.
+Return at most %d triples. Use short canonical entity names and snake_case relation types.
+For each triple quote the sentence from the text that supports it as evidence.
+
+Text:
+%s
+
+Respond with a JSON array only, e.g.
+[{"subject": "Alice", "subject_type": "person", "predicate": "works_at", "object": "Acme", "object_type": "organization", "evidence": "Alice works at Acme."}]`
+
+// LLMExtractor 基于 LLM 的三元组抽取器
+type LLMExtractor struct {
+	client llm.Client
+
+	// EntityTypes 限定实体类型,为空时不限制
+	EntityTypes []string
+
+	// MaxTriples 单次抽取的三元组上限,默认 30
+	MaxTriples int
+
+	// Prompt 自定义提示词,格式与 DefaultExtractionPrompt 相同
+	Prompt string
+}
+
+// NewLLMExtractor 创建 LLM 三元组抽取器
+func NewLLMExtractor(client llm.Client) *LLMExtractor {
+	return &LLMExtractor{
+		client:     client,
+		MaxTriples: 30,
+		Prompt:     DefaultExtractionPrompt,
+	}
+}
+
+// extractedTriple LLM 返回的单条三元组
+type extractedTriple struct {
+	Subject     string `json:"subject"`
+	SubjectType string `json:"subject_type"`
+	Predicate   string `json:"predicate"`
+	Object      string `json:"object"`
+	ObjectType  string `json:"object_type"`
+	Evidence    string `json:"evidence"`
+}
+
+// Extract 实现 Extractor 接口
+//
+// 缺少主体、关系或客体的条目以及自环会被丢弃
+func (e *LLMExtractor) Extract(ctx context.Context, source Source) ([]*Triple, error) {
+	if e.client == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "extractor LLM client is not configured").
+			WithComponent("graph_extractor").
+			WithOperation("extract")
+	}
+	if strings.TrimSpace(source.Text) == "" {
+		return []*Triple{}, nil
+	}
+
+	typeHint := ""
+	if len(e.EntityTypes) > 0 {
+		typeHint = " of type " + strings.Join(e.EntityTypes, ", ")
+	}
+	maxTriples := e.MaxTriples
+	if maxTriples <= 0 {
+		maxTriples = 30
+	}
+
+	response, err := e.client.Complete(ctx, &llm.CompletionRequest{
+		Messages:    []llm.Message{llm.UserMessage(fmt.Sprintf(e.Prompt, typeHint, maxTriples, source.Text))},
+		Temperature: 0,
+	})
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "triple extraction failed").
+			WithComponent("graph_extractor").
+			WithOperation("extract").
+			WithContext("source_id", source.ID)
+	}
+
+	items, err := parseExtractedTriples(response.Content)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMResponse, "failed to parse extracted triples").
+			WithComponent("graph_extractor").
+			WithOperation("extract").
+			WithContext("source_id", source.ID)
+	}
+
+	now := time.Now()
+	triples := make([]*Triple, 0, len(items))
+	for _, item := range items {
+		subject := NewEntity(item.Subject, item.SubjectType)
+		object := NewEntity(item.Object, item.ObjectType)
+		predicate := NormalizeType(item.Predicate)
+		if subject.ID == "" || object.ID == "" || predicate == "" || subject.ID == object.ID {
+			continue
+		}
+
+		triples = append(triples, &Triple{
+			Subject:   subject,
+			Predicate: predicate,
+			Object:    object,
+			Provenance: Provenance{
+				SourceID:    source.ID,
+				SourceType:  source.Type,
+				Evidence:    strings.TrimSpace(item.Evidence),
+				ExtractedAt: now,
+			},
+		})
+		if len(triples) >= maxTriples {
+			break
+		}
+	}
+	return triples, nil
+}
+
+// parseExtractedTriples 解析 LLM 返回的 JSON 数组,兼容代码块和说明文字
+func parseExtractedTriples(text string) ([]extractedTriple, error) {
+	start := strings.Index(text, "[")
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array found")
+	}
+
+	var items []extractedTriple
+	if err := json.Unmarshal([]byte(text[start:end+1]), &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,296 @@
+// Package graph 提供知识图谱记忆
+//
+// 由 LLM 从对话和文档中抽取 (实体, 关系, 实体) 三元组并记录来源,
+// 存入可插拔的图存储(内存邻接表或 PostgreSQL 表),
+// 支持 k 跳邻域扩展和社区摘要,供 retrieval.GraphRetriever 使用
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Entity 图谱实体
+type Entity struct {
+	// ID 实体标识,默认为规范化后的名称
+	ID string `json:"id"`
+
+	// Name 实体名称
+	Name string `json:"name"`
+
+	// Type 实体类型,如 person、organization、service
+	Type string `json:"type,omitempty"`
+
+	// Aliases 别名,用于实体链接
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Description 描述
+	Description string `json:"description,omitempty"`
+
+	// Properties 附加属性
+	Properties map[string]interface{} `json:"properties,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewEntity 创建实体,ID 为规范化后的名称
+func NewEntity(name, entityType string) *Entity {
+	return &Entity{
+		ID:   NormalizeName(name),
+		Name: strings.TrimSpace(name),
+		Type: NormalizeType(entityType),
+	}
+}
+
+// Merge 合并同一实体的新信息
+//
+// 别名取并集,非空的类型、描述和属性以 other 为准
+func (e *Entity) Merge(other *Entity) {
+	if other == nil {
+		return
+	}
+	if e.Name == "" {
+		e.Name = other.Name
+	}
+	if other.Type != "" {
+		e.Type = other.Type
+	}
+	if other.Description != "" {
+		e.Description = other.Description
+	}
+
+	aliases := make(map[string]bool, len(e.Aliases))
+	for _, alias := range e.Aliases {
+		aliases[alias] = true
+	}
+	for _, alias := range other.Aliases {
+		if !aliases[alias] {
+			aliases[alias] = true
+			e.Aliases = append(e.Aliases, alias)
+		}
+	}
+	if other.Name != "" && NormalizeName(other.Name) != e.ID && !aliases[other.Name] {
+		e.Aliases = append(e.Aliases, other.Name)
+	}
+
+	if len(other.Properties) > 0 && e.Properties == nil {
+		e.Properties = make(map[string]interface{}, len(other.Properties))
+	}
+	for k, v := range other.Properties {
+		e.Properties[k] = v
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = other.CreatedAt
+	}
+	if other.UpdatedAt.After(e.UpdatedAt) {
+		e.UpdatedAt = other.UpdatedAt
+	}
+}
+
+// Clone 深拷贝实体
+func (e *Entity) Clone() *Entity {
+	clone := *e
+	clone.Aliases = append([]string(nil), e.Aliases...)
+	if e.Properties != nil {
+		clone.Properties = make(map[string]interface{}, len(e.Properties))
+		for k, v := range e.Properties {
+			clone.Properties[k] = v
+		}
+	}
+	return &clone
+}
+
+// Names 返回实体名称和别名的规范化形式
+func (e *Entity) Names() []string {
+	names := []string{e.ID}
+	if name := NormalizeName(e.Name); name != e.ID {
+		names = append(names, name)
+	}
+	for _, alias := range e.Aliases {
+		names = append(names, NormalizeName(alias))
+	}
+	return names
+}
+
+// Provenance 关系来源
+type Provenance struct {
+	// SourceID 来源标识,如文档 ID、会话 ID 或记忆 ID
+	SourceID string `json:"source_id"`
+
+	// SourceType 来源类型,如 document、conversation
+	SourceType string `json:"source_type,omitempty"`
+
+	// Evidence 支持该关系的原文片段
+	Evidence string `json:"evidence,omitempty"`
+
+	// ExtractedAt 抽取时间
+	ExtractedAt time.Time `json:"extracted_at"`
+}
+
+// Relation 实体之间的有向关系
+type Relation struct {
+	// ID 关系标识,由源实体、关系类型和目标实体决定
+	ID string `json:"id"`
+
+	// Source 源实体 ID
+	Source string `json:"source"`
+
+	// Target 目标实体 ID
+	Target string `json:"target"`
+
+	// Type 关系类型,如 works_at、depends_on
+	Type string `json:"type"`
+
+	// Weight 关系权重,等于支持该关系的来源数(至少为 1)
+	Weight float64 `json:"weight"`
+
+	// Provenance 关系来源
+	Provenance []Provenance `json:"provenance,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewRelation 创建关系
+func NewRelation(source, relationType, target string) *Relation {
+	r := &Relation{
+		Source: source,
+		Target: target,
+		Type:   NormalizeType(relationType),
+		Weight: 1,
+	}
+	r.ID = RelationID(r.Source, r.Type, r.Target)
+	return r
+}
+
+// Merge 合并同一关系的来源
+func (r *Relation) Merge(other *Relation) {
+	if other == nil {
+		return
+	}
+
+	for _, p := range other.Provenance {
+		if !r.hasProvenance(p) {
+			r.Provenance = append(r.Provenance, p)
+		}
+	}
+	r.updateWeight()
+
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = other.CreatedAt
+	}
+	if other.UpdatedAt.After(r.UpdatedAt) {
+		r.UpdatedAt = other.UpdatedAt
+	}
+}
+
+// Clone 深拷贝关系
+func (r *Relation) Clone() *Relation {
+	clone := *r
+	clone.Provenance = append([]Provenance(nil), r.Provenance...)
+	return &clone
+}
+
+// RemoveSource 移除指定来源,返回剩余来源数
+func (r *Relation) RemoveSource(sourceID string) int {
+	kept := r.Provenance[:0]
+	for _, p := range r.Provenance {
+		if p.SourceID != sourceID {
+			kept = append(kept, p)
+		}
+	}
+	r.Provenance = kept
+	r.updateWeight()
+	return len(r.Provenance)
+}
+
+// hasProvenance 是否已记录相同来源
+func (r *Relation) hasProvenance(p Provenance) bool {
+	for _, existing := range r.Provenance {
+		if existing.SourceID == p.SourceID && existing.Evidence == p.Evidence {
+			return true
+		}
+	}
+	return false
+}
+
+// updateWeight 按来源数更新权重
+func (r *Relation) updateWeight() {
+	r.Weight = float64(len(r.Provenance))
+	if r.Weight < 1 {
+		r.Weight = 1
+	}
+}
+
+// Triple 抽取结果:(主体, 关系, 客体) 及其来源
+type Triple struct {
+	Subject    *Entity    `json:"subject"`
+	Predicate  string     `json:"predicate"`
+	Object     *Entity    `json:"object"`
+	Provenance Provenance `json:"provenance"`
+}
+
+// Relation 将三元组转换为关系
+func (t *Triple) Relation() *Relation {
+	r := NewRelation(t.Subject.ID, t.Predicate, t.Object.ID)
+	if t.Provenance.SourceID != "" || t.Provenance.Evidence != "" {
+		r.Provenance = []Provenance{t.Provenance}
+	}
+	return r
+}
+
+// Community 社区:联系紧密的一组实体及其摘要
+type Community struct {
+	ID        string    `json:"id"`
+	EntityIDs []string  `json:"entity_ids"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Clone 深拷贝社区
+func (c *Community) Clone() *Community {
+	clone := *c
+	clone.EntityIDs = append([]string(nil), c.EntityIDs...)
+	return &clone
+}
+
+// Subgraph 子图
+type Subgraph struct {
+	// Entities 子图中的实体,按 ID 索引
+	Entities map[string]*Entity `json:"entities"`
+
+	// Relations 子图中的关系
+	Relations []*Relation `json:"relations"`
+
+	// Depth 实体到种子实体的跳数,种子为 0
+	Depth map[string]int `json:"depth,omitempty"`
+}
+
+// NewSubgraph 创建空子图
+func NewSubgraph() *Subgraph {
+	return &Subgraph{
+		Entities:  make(map[string]*Entity),
+		Relations: make([]*Relation, 0),
+		Depth:     make(map[string]int),
+	}
+}
+
+// NormalizeName 规范化实体名称:小写并合并空白
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// NormalizeType 规范化类型名称:小写并以下划线连接
+func NormalizeType(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "_")
+}
+
+// RelationID 计算关系 ID
+func RelationID(source, relationType, target string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + relationType + "\x00" + target))
+	return hex.EncodeToString(sum[:16])
+}
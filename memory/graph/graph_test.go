@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+const extractionResponse = "```json\n" + `[
+	{"subject": "Alice", "subject_type": "Person", "predicate": "works at", "object": "Acme", "object_type": "organization", "evidence": "Alice works at Acme."},
+	{"subject": "Acme", "predicate": "operates", "object": "Payments API", "evidence": "Acme operates the Payments API."},
+	{"subject": "Payments API", "predicate": "depends_on", "object": "Postgres", "evidence": "The Payments API depends on Postgres."},
+	{"subject": "Bob", "predicate": "likes", "object": "Bob"},
+	{"subject": "", "predicate": "knows", "object": "Carol"}
+]` + "\n```"
+
+func newTestGraph(t *testing.T) *KnowledgeGraph {
+	t.Helper()
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		if strings.Contains(prompt, "Extract a knowledge graph") {
+			return extractionResponse
+		}
+		return "summary"
+	}))
+	return NewKnowledgeGraph(Config{Extractor: NewLLMExtractor(client)})
+}
+
+func TestLLMExtractorAndIngest(t *testing.T) {
+	ctx := context.Background()
+	kg := newTestGraph(t)
+
+	triples, err := kg.Ingest(ctx, DocumentSource("doc-1", "Alice works at Acme. Acme operates the Payments API."))
+	require.NoError(t, err)
+	require.Len(t, triples, 3, "self loops and incomplete triples are dropped")
+	assert.Equal(t, "works_at", triples[0].Predicate)
+	assert.Equal(t, "person", triples[0].Subject.Type)
+	assert.Equal(t, "doc-1", triples[0].Provenance.SourceID)
+	assert.Equal(t, SourceTypeDocument, triples[0].Provenance.SourceType)
+
+	// 同一关系来自第二个来源时合并证据并提高权重
+	_, err = kg.IngestConversation(ctx, "chat-1", []llm.Message{
+		llm.UserMessage("Alice works at Acme."),
+	})
+	require.NoError(t, err)
+
+	sub, err := kg.Query(ctx, []string{"ALICE"}, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, sub.Relations, 1)
+	assert.Equal(t, 2.0, sub.Relations[0].Weight)
+	assert.Len(t, sub.Relations[0].Provenance, 2)
+	assert.Equal(t, 1, sub.Depth["acme"])
+
+	require.NoError(t, kg.Store().DeleteSource(ctx, "chat-1"))
+	sub, err = kg.Query(ctx, []string{"alice"}, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, sub.Relations, 1)
+	assert.Equal(t, 1.0, sub.Relations[0].Weight)
+
+	_, err = NewKnowledgeGraph(Config{}).Ingest(ctx, DocumentSource("x", "text"))
+	assert.Error(t, err)
+}
+
+func TestInMemoryStoreNeighborhood(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+
+	require.NoError(t, store.UpsertEntities(ctx, []*Entity{
+		{ID: "k8s", Name: "Kubernetes", Aliases: []string{"K8s"}},
+	}))
+	require.NoError(t, store.UpsertRelations(ctx, []*Relation{
+		NewRelation("k8s", "schedules", "pod"),
+		NewRelation("pod", "runs", "container"),
+		NewRelation("container", "uses", "image"),
+		NewRelation("k8s", "stores_state_in", "etcd"),
+	}))
+
+	found, err := store.FindEntities(ctx, []string{"kubernetes", "k8s", "unknown"})
+	require.NoError(t, err)
+	require.Len(t, found, 1, "name and alias resolve to the same entity")
+
+	sub, err := store.Neighborhood(ctx, []string{"k8s"}, 2, 0)
+	require.NoError(t, err)
+	assert.Len(t, sub.Relations, 3)
+	assert.Equal(t, 2, sub.Depth["container"])
+	_, ok := sub.Entities["image"]
+	assert.False(t, ok, "image is three hops away")
+
+	limited, err := store.Neighborhood(ctx, []string{"k8s"}, 2, 2)
+	require.NoError(t, err)
+	assert.Len(t, limited.Relations, 2)
+}
+
+func TestBuildCommunities(t *testing.T) {
+	ctx := context.Background()
+	kg := NewKnowledgeGraph(Config{})
+	require.NoError(t, kg.AddTriples(ctx, []*Triple{
+		{Subject: NewEntity("Alice", "person"), Predicate: "works_at", Object: NewEntity("Acme", "org")},
+		{Subject: NewEntity("Bob", "person"), Predicate: "works_at", Object: NewEntity("Acme", "org")},
+		{Subject: NewEntity("Redis", "service"), Predicate: "replicates_to", Object: NewEntity("Redis Replica", "service")},
+	}))
+
+	communities, err := kg.BuildCommunities(ctx)
+	require.NoError(t, err)
+	require.Len(t, communities, 2)
+	assert.Equal(t, []string{"acme", "alice", "bob"}, communities[0].EntityIDs)
+	assert.Contains(t, communities[0].Summary, "- Alice works_at Acme")
+
+	stored, err := kg.Store().GetCommunities(ctx, []string{"redis"})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, communities[1].ID, stored[0].ID)
+
+	assert.Equal(t, DetectCommunities(&Subgraph{Entities: map[string]*Entity{}}, 0), [][]string{})
+}
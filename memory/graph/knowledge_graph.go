@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+)
+
+// Config 知识图谱配置
+type Config struct {
+	// Store 图存储,默认使用内存存储
+	Store Store
+
+	// Extractor 三元组抽取器,Ingest 时必需
+	Extractor Extractor
+
+	// Summarizer 社区摘要生成器,默认 FactSummarizer
+	Summarizer CommunitySummarizer
+
+	// MinCommunitySize 生成摘要的最小社区规模,默认 2
+	MinCommunitySize int
+}
+
+// KnowledgeGraph 知识图谱记忆
+//
+// 组合抽取器、图存储和社区摘要生成器
+type KnowledgeGraph struct {
+	store            Store
+	extractor        Extractor
+	summarizer       CommunitySummarizer
+	minCommunitySize int
+}
+
+// NewKnowledgeGraph 创建知识图谱
+func NewKnowledgeGraph(config Config) *KnowledgeGraph {
+	if config.Store == nil {
+		config.Store = NewInMemoryStore()
+	}
+	if config.Summarizer == nil {
+		config.Summarizer = FactSummarizer{}
+	}
+	if config.MinCommunitySize <= 0 {
+		config.MinCommunitySize = 2
+	}
+
+	return &KnowledgeGraph{
+		store:            config.Store,
+		extractor:        config.Extractor,
+		summarizer:       config.Summarizer,
+		minCommunitySize: config.MinCommunitySize,
+	}
+}
+
+// Store 返回图存储
+func (g *KnowledgeGraph) Store() Store {
+	return g.store
+}
+
+// AddTriples 写入三元组
+func (g *KnowledgeGraph) AddTriples(ctx context.Context, triples []*Triple) error {
+	entities := make([]*Entity, 0, len(triples)*2)
+	relations := make([]*Relation, 0, len(triples))
+	for _, triple := range triples {
+		if triple == nil || triple.Subject == nil || triple.Object == nil {
+			continue
+		}
+		entities = append(entities, triple.Subject, triple.Object)
+		relations = append(relations, triple.Relation())
+	}
+
+	if err := g.store.UpsertEntities(ctx, entities); err != nil {
+		return err
+	}
+	return g.store.UpsertRelations(ctx, relations)
+}
+
+// Ingest 从来源抽取三元组并写入图谱
+func (g *KnowledgeGraph) Ingest(ctx context.Context, source Source) ([]*Triple, error) {
+	if g.extractor == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "extractor is not configured").
+			WithComponent("knowledge_graph").
+			WithOperation("ingest")
+	}
+
+	triples, err := g.extractor.Extract(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.AddTriples(ctx, triples); err != nil {
+		return nil, err
+	}
+	return triples, nil
+}
+
+// IngestConversation 从对话中抽取三元组
+func (g *KnowledgeGraph) IngestConversation(ctx context.Context, conversationID string, messages []llm.Message) ([]*Triple, error) {
+	return g.Ingest(ctx, ConversationSource(conversationID, messages))
+}
+
+// Reingest 重新抽取来源:先移除该来源的旧证据再写入,用于文档更新
+func (g *KnowledgeGraph) Reingest(ctx context.Context, source Source) ([]*Triple, error) {
+	if err := g.store.DeleteSource(ctx, source.ID); err != nil {
+		return nil, err
+	}
+	return g.Ingest(ctx, source)
+}
+
+// BuildCommunities 发现社区并生成摘要,替换存储中的全部社区
+func (g *KnowledgeGraph) BuildCommunities(ctx context.Context) ([]*Community, error) {
+	snapshot, err := g.store.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	communities := make([]*Community, 0)
+	for _, members := range DetectCommunities(snapshot, 0) {
+		if len(members) < g.minCommunitySize {
+			continue
+		}
+
+		memberSet := make(map[string]bool, len(members))
+		entities := make([]*Entity, 0, len(members))
+		for _, id := range members {
+			memberSet[id] = true
+			entities = append(entities, snapshot.Entities[id])
+		}
+		relations := make([]*Relation, 0)
+		for _, r := range snapshot.Relations {
+			if memberSet[r.Source] && memberSet[r.Target] {
+				relations = append(relations, r)
+			}
+		}
+
+		summary, err := g.summarizer.Summarize(ctx, entities, relations)
+		if err != nil {
+			return nil, err
+		}
+
+		communities = append(communities, &Community{
+			ID:        fmt.Sprintf("community-%d", len(communities)+1),
+			EntityIDs: members,
+			Summary:   summary,
+			CreatedAt: now,
+		})
+	}
+
+	if err := g.store.SaveCommunities(ctx, communities); err != nil {
+		return nil, err
+	}
+	return communities, nil
+}
+
+// Query 按名称链接实体并返回 hops 跳邻域
+func (g *KnowledgeGraph) Query(ctx context.Context, names []string, hops, limit int) (*Subgraph, error) {
+	entities, err := g.store.FindEntities(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		seeds = append(seeds, entity.ID)
+	}
+	sort.Strings(seeds)
+	return g.store.Neighborhood(ctx, seeds, hops, limit)
+}
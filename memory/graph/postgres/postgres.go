@@ -0,0 +1,664 @@
+// Package postgres provides a PostgreSQL-backed graph.Store.
+package postgres
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/memory/graph"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// Store is a PostgreSQL-backed implementation of graph.Store.
+//
+// The graph is stored in plain tables:
+//   - entities: one row per entity, aliases and properties as JSONB
+//   - entity_names: normalized name/alias -> entity, used for entity linking
+//   - relations: one row per (source, type, target) with JSONB provenance
+//   - relation_sources: relation -> source ID, used by DeleteSource
+//   - communities / community_members: community summaries and membership
+type Store struct {
+	db     *gorm.DB
+	config *Config
+}
+
+type entityModel struct {
+	ID          string         `gorm:"primaryKey"`
+	Name        string         `gorm:"not null"`
+	Type        string         `gorm:"index"`
+	Aliases     datatypes.JSON `gorm:"type:jsonb"`
+	Description string
+	Properties  datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt   time.Time      `gorm:"not null"`
+	UpdatedAt   time.Time      `gorm:"not null"`
+}
+
+type entityNameModel struct {
+	Name     string `gorm:"primaryKey"`
+	EntityID string `gorm:"primaryKey"`
+}
+
+type relationModel struct {
+	ID         string         `gorm:"primaryKey"`
+	SourceID   string         `gorm:"index;not null"`
+	TargetID   string         `gorm:"index;not null"`
+	Type       string         `gorm:"index;not null"`
+	Weight     float64        `gorm:"not null"`
+	Provenance datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt  time.Time      `gorm:"not null"`
+	UpdatedAt  time.Time      `gorm:"not null"`
+}
+
+type relationSourceModel struct {
+	RelationID string `gorm:"primaryKey"`
+	SourceID   string `gorm:"primaryKey;index"`
+}
+
+type communityModel struct {
+	ID        string         `gorm:"primaryKey"`
+	EntityIDs datatypes.JSON `gorm:"type:jsonb"`
+	Summary   string
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+type communityMemberModel struct {
+	CommunityID string `gorm:"primaryKey"`
+	EntityID    string `gorm:"primaryKey;index"`
+}
+
+// New creates a new PostgreSQL-backed graph store
+func New(config *Config) (*Store, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	db, err := gorm.Open(postgres.Open(config.DSN), &gorm.Config{
+		Logger: logger.Default.LogMode(config.LogLevel),
+	})
+	if err != nil {
+		return nil, agentErrors.NewStoreConnectionError("postgres", config.DSN, err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to get SQL database").WithComponent("graph_postgres_store").WithOperation("new")
+	}
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+
+	return NewFromDB(db, config)
+}
+
+// NewFromDB creates a Store from an existing GORM DB
+func NewFromDB(db *gorm.DB, config *Config) (*Store, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	s := &Store{db: db, config: config}
+	if config.AutoMigrate {
+		if err := s.migrate(); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to migrate database").WithComponent("graph_postgres_store").WithOperation("new_from_db")
+		}
+	}
+	return s, nil
+}
+
+// migrate creates the graph tables
+func (s *Store) migrate() error {
+	models := map[string]interface{}{
+		"entities":          &entityModel{},
+		"entity_names":      &entityNameModel{},
+		"relations":         &relationModel{},
+		"relation_sources":  &relationSourceModel{},
+		"communities":       &communityModel{},
+		"community_members": &communityMemberModel{},
+	}
+	for name, model := range models {
+		if err := s.db.Table(s.table(name)).AutoMigrate(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// table returns the prefixed table name
+func (s *Store) table(name string) string {
+	return s.config.TablePrefix + name
+}
+
+// UpsertEntities inserts or merges entities
+func (s *Store) UpsertEntities(ctx context.Context, entities []*graph.Entity) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.upsertEntities(tx, entities, time.Now())
+	})
+}
+
+// upsertEntities merges entities with existing rows inside a transaction
+func (s *Store) upsertEntities(tx *gorm.DB, entities []*graph.Entity, now time.Time) error {
+	merged := make(map[string]*graph.Entity)
+	order := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		if entity == nil || entity.ID == "" {
+			continue
+		}
+		if existing, ok := merged[entity.ID]; ok {
+			existing.Merge(entity)
+			continue
+		}
+		merged[entity.ID] = entity.Clone()
+		order = append(order, entity.ID)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	existing, err := s.loadEntities(tx, order)
+	if err != nil {
+		return s.wrap(err, "upsert_entities", "failed to load entities")
+	}
+
+	rows := make([]entityModel, 0, len(order))
+	names := make([]entityNameModel, 0, len(order))
+	for _, id := range order {
+		entity := merged[id]
+		if current, ok := existing[id]; ok {
+			current.Merge(entity)
+			entity = current
+		}
+		if entity.CreatedAt.IsZero() {
+			entity.CreatedAt = now
+		}
+		entity.UpdatedAt = now
+
+		row, err := toEntityModel(entity)
+		if err != nil {
+			return s.wrapSerialization(err, "upsert_entities")
+		}
+		rows = append(rows, row)
+		for _, name := range entity.Names() {
+			names = append(names, entityNameModel{Name: name, EntityID: entity.ID})
+		}
+	}
+
+	if err := tx.Table(s.table("entities")).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+		return s.wrap(err, "upsert_entities", "failed to save entities")
+	}
+	if err := tx.Table(s.table("entity_names")).Clauses(clause.OnConflict{DoNothing: true}).Create(&names).Error; err != nil {
+		return s.wrap(err, "upsert_entities", "failed to save entity names")
+	}
+	return nil
+}
+
+// UpsertRelations inserts or merges relations, creating missing endpoint entities
+func (s *Store) UpsertRelations(ctx context.Context, relations []*graph.Relation) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		merged := make(map[string]*graph.Relation)
+		order := make([]string, 0, len(relations))
+		endpoints := make([]string, 0, len(relations)*2)
+		for _, relation := range relations {
+			if relation == nil || relation.Source == "" || relation.Target == "" {
+				continue
+			}
+			if relation.ID == "" {
+				relation.ID = graph.RelationID(relation.Source, relation.Type, relation.Target)
+			}
+			if existing, ok := merged[relation.ID]; ok {
+				existing.Merge(relation)
+				continue
+			}
+			clone := relation.Clone()
+			clone.Provenance = nil
+			clone.Merge(relation)
+			merged[relation.ID] = clone
+			order = append(order, relation.ID)
+			endpoints = append(endpoints, relation.Source, relation.Target)
+		}
+		if len(order) == 0 {
+			return nil
+		}
+
+		known, err := s.loadEntities(tx, endpoints)
+		if err != nil {
+			return s.wrap(err, "upsert_relations", "failed to load entities")
+		}
+		missing := make([]*graph.Entity, 0)
+		for _, id := range endpoints {
+			if _, ok := known[id]; !ok {
+				missing = append(missing, &graph.Entity{ID: id, Name: id})
+				known[id] = nil
+			}
+		}
+		if err := s.upsertEntities(tx, missing, now); err != nil {
+			return err
+		}
+
+		existing, err := s.loadRelations(tx.Where("id IN ?", order))
+		if err != nil {
+			return s.wrap(err, "upsert_relations", "failed to load relations")
+		}
+		current := make(map[string]*graph.Relation, len(existing))
+		for _, relation := range existing {
+			current[relation.ID] = relation
+		}
+
+		rows := make([]relationModel, 0, len(order))
+		sources := make([]relationSourceModel, 0)
+		for _, id := range order {
+			relation := merged[id]
+			if stored, ok := current[id]; ok {
+				stored.Merge(relation)
+				relation = stored
+			}
+			if relation.CreatedAt.IsZero() {
+				relation.CreatedAt = now
+			}
+			relation.UpdatedAt = now
+
+			row, err := toRelationModel(relation)
+			if err != nil {
+				return s.wrapSerialization(err, "upsert_relations")
+			}
+			rows = append(rows, row)
+			for _, p := range relation.Provenance {
+				if p.SourceID != "" {
+					sources = append(sources, relationSourceModel{RelationID: id, SourceID: p.SourceID})
+				}
+			}
+		}
+
+		if err := tx.Table(s.table("relations")).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+			return s.wrap(err, "upsert_relations", "failed to save relations")
+		}
+		if len(sources) > 0 {
+			if err := tx.Table(s.table("relation_sources")).Clauses(clause.OnConflict{DoNothing: true}).Create(&sources).Error; err != nil {
+				return s.wrap(err, "upsert_relations", "failed to save relation sources")
+			}
+		}
+		return nil
+	})
+}
+
+// GetEntities returns entities by ID in the requested order
+func (s *Store) GetEntities(ctx context.Context, ids []string) ([]*graph.Entity, error) {
+	entities, err := s.loadEntities(s.db.WithContext(ctx), ids)
+	if err != nil {
+		return nil, s.wrap(err, "get_entities", "failed to load entities")
+	}
+
+	result := make([]*graph.Entity, 0, len(entities))
+	for _, id := range ids {
+		if entity, ok := entities[id]; ok {
+			result = append(result, entity)
+			delete(entities, id)
+		}
+	}
+	return result, nil
+}
+
+// FindEntities looks up entities by normalized name or alias
+func (s *Store) FindEntities(ctx context.Context, names []string) ([]*graph.Entity, error) {
+	if len(names) == 0 {
+		return []*graph.Entity{}, nil
+	}
+
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, graph.NormalizeName(name))
+	}
+
+	var rows []entityNameModel
+	if err := s.db.WithContext(ctx).Table(s.table("entity_names")).
+		Where("name IN ?", normalized).Order("entity_id").Find(&rows).Error; err != nil {
+		return nil, s.wrap(err, "find_entities", "failed to look up entity names")
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.EntityID)
+	}
+	return s.GetEntities(ctx, ids)
+}
+
+// Neighborhood expands hops levels from the seed entities
+func (s *Store) Neighborhood(ctx context.Context, seeds []string, hops, limit int) (*graph.Subgraph, error) {
+	db := s.db.WithContext(ctx)
+
+	sub := graph.NewSubgraph()
+	seedEntities, err := s.loadEntities(db, seeds)
+	if err != nil {
+		return nil, s.wrap(err, "neighborhood", "failed to load seed entities")
+	}
+	frontier := make([]string, 0, len(seedEntities))
+	for _, id := range seeds {
+		if entity, ok := seedEntities[id]; ok {
+			if _, added := sub.Entities[id]; !added {
+				sub.Entities[id] = entity
+				sub.Depth[id] = 0
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for hop := 1; hop <= hops && len(frontier) > 0; hop++ {
+		candidates, err := s.loadRelations(db.Where("source_id IN ? OR target_id IN ?", frontier, frontier).
+			Order("weight DESC").Order("id"))
+		if err != nil {
+			return nil, s.wrap(err, "neighborhood", "failed to load relations")
+		}
+
+		next := make([]string, 0)
+		for _, relation := range candidates {
+			if seen[relation.ID] {
+				continue
+			}
+			seen[relation.ID] = true
+			if limit > 0 && len(sub.Relations) >= limit {
+				break
+			}
+			sub.Relations = append(sub.Relations, relation)
+			for _, id := range []string{relation.Source, relation.Target} {
+				if _, ok := sub.Depth[id]; !ok {
+					sub.Depth[id] = hop
+					next = append(next, id)
+				}
+			}
+		}
+
+		entities, err := s.loadEntities(db, next)
+		if err != nil {
+			return nil, s.wrap(err, "neighborhood", "failed to load entities")
+		}
+		for id, entity := range entities {
+			sub.Entities[id] = entity
+		}
+		if limit > 0 && len(sub.Relations) >= limit {
+			break
+		}
+		frontier = next
+	}
+
+	return sub, nil
+}
+
+// Snapshot returns the full graph
+func (s *Store) Snapshot(ctx context.Context) (*graph.Subgraph, error) {
+	db := s.db.WithContext(ctx)
+
+	var rows []entityModel
+	if err := db.Table(s.table("entities")).Find(&rows).Error; err != nil {
+		return nil, s.wrap(err, "snapshot", "failed to load entities")
+	}
+	relations, err := s.loadRelations(db.Order("weight DESC").Order("id"))
+	if err != nil {
+		return nil, s.wrap(err, "snapshot", "failed to load relations")
+	}
+
+	sub := graph.NewSubgraph()
+	for _, row := range rows {
+		entity, err := row.toEntity()
+		if err != nil {
+			return nil, s.wrapSerialization(err, "snapshot")
+		}
+		sub.Entities[entity.ID] = entity
+	}
+	sub.Relations = relations
+	return sub, nil
+}
+
+// SaveCommunities replaces all communities
+func (s *Store) SaveCommunities(ctx context.Context, communities []*graph.Community) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(s.table("community_members")).Where("1 = 1").Delete(&communityMemberModel{}).Error; err != nil {
+			return s.wrap(err, "save_communities", "failed to clear community members")
+		}
+		if err := tx.Table(s.table("communities")).Where("1 = 1").Delete(&communityModel{}).Error; err != nil {
+			return s.wrap(err, "save_communities", "failed to clear communities")
+		}
+		if len(communities) == 0 {
+			return nil
+		}
+
+		rows := make([]communityModel, 0, len(communities))
+		members := make([]communityMemberModel, 0)
+		for _, community := range communities {
+			entityIDs, err := json.Marshal(community.EntityIDs)
+			if err != nil {
+				return s.wrapSerialization(err, "save_communities")
+			}
+			rows = append(rows, communityModel{
+				ID:        community.ID,
+				EntityIDs: entityIDs,
+				Summary:   community.Summary,
+				CreatedAt: community.CreatedAt,
+			})
+			for _, id := range community.EntityIDs {
+				members = append(members, communityMemberModel{CommunityID: community.ID, EntityID: id})
+			}
+		}
+
+		if err := tx.Table(s.table("communities")).Create(&rows).Error; err != nil {
+			return s.wrap(err, "save_communities", "failed to save communities")
+		}
+		if len(members) > 0 {
+			if err := tx.Table(s.table("community_members")).Create(&members).Error; err != nil {
+				return s.wrap(err, "save_communities", "failed to save community members")
+			}
+		}
+		return nil
+	})
+}
+
+// GetCommunities returns communities containing any of the given entities
+func (s *Store) GetCommunities(ctx context.Context, entityIDs []string) ([]*graph.Community, error) {
+	if len(entityIDs) == 0 {
+		return []*graph.Community{}, nil
+	}
+
+	db := s.db.WithContext(ctx)
+	var rows []communityModel
+	err := db.Table(s.table("communities")).
+		Where("id IN (?)", db.Table(s.table("community_members")).Select("community_id").Where("entity_id IN ?", entityIDs)).
+		Order("id").Find(&rows).Error
+	if err != nil {
+		return nil, s.wrap(err, "get_communities", "failed to load communities")
+	}
+
+	result := make([]*graph.Community, 0, len(rows))
+	for _, row := range rows {
+		community := &graph.Community{ID: row.ID, Summary: row.Summary, CreatedAt: row.CreatedAt}
+		if len(row.EntityIDs) > 0 {
+			if err := json.Unmarshal(row.EntityIDs, &community.EntityIDs); err != nil {
+				return nil, s.wrapSerialization(err, "get_communities")
+			}
+		}
+		result = append(result, community)
+	}
+	return result, nil
+}
+
+// DeleteSource removes the evidence of a source; relations left without provenance are deleted
+func (s *Store) DeleteSource(ctx context.Context, sourceID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		relations, err := s.loadRelations(tx.Where("id IN (?)",
+			tx.Table(s.table("relation_sources")).Select("relation_id").Where("source_id = ?", sourceID)))
+		if err != nil {
+			return s.wrap(err, "delete_source", "failed to load relations")
+		}
+
+		for _, relation := range relations {
+			if relation.RemoveSource(sourceID) == 0 {
+				if err := tx.Table(s.table("relations")).Where("id = ?", relation.ID).Delete(&relationModel{}).Error; err != nil {
+					return s.wrap(err, "delete_source", "failed to delete relation")
+				}
+				continue
+			}
+
+			row, err := toRelationModel(relation)
+			if err != nil {
+				return s.wrapSerialization(err, "delete_source")
+			}
+			if err := tx.Table(s.table("relations")).Where("id = ?", relation.ID).
+				Updates(map[string]interface{}{"weight": row.Weight, "provenance": row.Provenance}).Error; err != nil {
+				return s.wrap(err, "delete_source", "failed to update relation")
+			}
+		}
+
+		if err := tx.Table(s.table("relation_sources")).Where("source_id = ?", sourceID).Delete(&relationSourceModel{}).Error; err != nil {
+			return s.wrap(err, "delete_source", "failed to delete relation sources")
+		}
+		return nil
+	})
+}
+
+// loadEntities loads entities by ID
+func (s *Store) loadEntities(db *gorm.DB, ids []string) (map[string]*graph.Entity, error) {
+	result := make(map[string]*graph.Entity, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []entityModel
+	if err := db.Table(s.table("entities")).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		entity, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		result[entity.ID] = entity
+	}
+	return result, nil
+}
+
+// loadRelations loads relations matching the prepared query
+func (s *Store) loadRelations(query *gorm.DB) ([]*graph.Relation, error) {
+	var rows []relationModel
+	if err := query.Table(s.table("relations")).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	relations := make([]*graph.Relation, 0, len(rows))
+	for _, row := range rows {
+		relation, err := row.toRelation()
+		if err != nil {
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	sort.SliceStable(relations, func(i, j int) bool {
+		if relations[i].Weight != relations[j].Weight {
+			return relations[i].Weight > relations[j].Weight
+		}
+		return relations[i].ID < relations[j].ID
+	})
+	return relations, nil
+}
+
+// wrap wraps a database error
+func (s *Store) wrap(err error, operation, message string) error {
+	if _, ok := err.(*agentErrors.AgentError); ok {
+		return err
+	}
+	return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, message).
+		WithComponent("graph_postgres_store").
+		WithOperation(operation)
+}
+
+// wrapSerialization wraps a JSON encoding error
+func (s *Store) wrapSerialization(err error, operation string) error {
+	return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to serialize graph data").
+		WithComponent("graph_postgres_store").
+		WithOperation(operation)
+}
+
+func toEntityModel(e *graph.Entity) (entityModel, error) {
+	aliases, err := json.Marshal(e.Aliases)
+	if err != nil {
+		return entityModel{}, err
+	}
+	properties, err := json.Marshal(e.Properties)
+	if err != nil {
+		return entityModel{}, err
+	}
+	return entityModel{
+		ID:          e.ID,
+		Name:        e.Name,
+		Type:        e.Type,
+		Aliases:     aliases,
+		Description: e.Description,
+		Properties:  properties,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}, nil
+}
+
+func (m entityModel) toEntity() (*graph.Entity, error) {
+	entity := &graph.Entity{
+		ID:          m.ID,
+		Name:        m.Name,
+		Type:        m.Type,
+		Description: m.Description,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if len(m.Aliases) > 0 {
+		if err := json.Unmarshal(m.Aliases, &entity.Aliases); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Properties) > 0 {
+		if err := json.Unmarshal(m.Properties, &entity.Properties); err != nil {
+			return nil, err
+		}
+	}
+	return entity, nil
+}
+
+func toRelationModel(r *graph.Relation) (relationModel, error) {
+	provenance, err := json.Marshal(r.Provenance)
+	if err != nil {
+		return relationModel{}, err
+	}
+	return relationModel{
+		ID:         r.ID,
+		SourceID:   r.Source,
+		TargetID:   r.Target,
+		Type:       r.Type,
+		Weight:     r.Weight,
+		Provenance: provenance,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}, nil
+}
+
+func (m relationModel) toRelation() (*graph.Relation, error) {
+	relation := &graph.Relation{
+		ID:        m.ID,
+		Source:    m.SourceID,
+		Target:    m.TargetID,
+		Type:      m.Type,
+		Weight:    m.Weight,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if len(m.Provenance) > 0 {
+		if err := json.Unmarshal(m.Provenance, &relation.Provenance); err != nil {
+			return nil, err
+		}
+	}
+	return relation, nil
+}
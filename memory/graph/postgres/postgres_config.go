@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+// Config holds configuration for the PostgreSQL graph store
+type Config struct {
+	// DSN is the PostgreSQL Data Source Name
+	// Example: "host=localhost user=postgres password=secret dbname=agent port=5432 sslmode=disable"
+	DSN string
+
+	// TablePrefix is prepended to all graph table names
+	TablePrefix string
+
+	// MaxIdleConns is the maximum number of idle connections
+	MaxIdleConns int
+
+	// MaxOpenConns is the maximum number of open connections
+	MaxOpenConns int
+
+	// ConnMaxLifetime is the maximum lifetime of a connection
+	ConnMaxLifetime time.Duration
+
+	// LogLevel is the GORM log level
+	LogLevel logger.LogLevel
+
+	// AutoMigrate enables automatic table creation
+	AutoMigrate bool
+}
+
+// DefaultConfig returns default PostgreSQL graph store configuration
+func DefaultConfig() *Config {
+	return &Config{
+		DSN:             "host=localhost user=postgres password=postgres dbname=agent port=5432 sslmode=disable",
+		TablePrefix:     "graph_",
+		MaxIdleConns:    10,
+		MaxOpenConns:    100,
+		ConnMaxLifetime: time.Hour,
+		LogLevel:        logger.Silent,
+		AutoMigrate:     true,
+	}
+}
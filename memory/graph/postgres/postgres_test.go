@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/kart-io/goagent/memory/graph"
+)
+
+// setupTestStore uses SQLite so the table logic can be tested without a PostgreSQL server
+func setupTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	config := DefaultConfig()
+	store, err := NewFromDB(db, config)
+	require.NoError(t, err)
+	return store
+}
+
+func TestNew(t *testing.T) {
+	// This test would require a real PostgreSQL instance
+	t.Skip("Requires real PostgreSQL connection")
+}
+
+func TestStore_UpsertAndNeighborhood(t *testing.T) {
+	ctx := context.Background()
+	store := setupTestStore(t)
+
+	require.NoError(t, store.UpsertEntities(ctx, []*graph.Entity{
+		{ID: "k8s", Name: "Kubernetes", Aliases: []string{"K8s"}, Properties: map[string]interface{}{"cncf": true}},
+	}))
+	require.NoError(t, store.UpsertEntities(ctx, []*graph.Entity{
+		{ID: "k8s", Name: "Kubernetes", Description: "container orchestrator"},
+	}))
+
+	schedules := graph.NewRelation("k8s", "schedules", "pod")
+	schedules.Provenance = []graph.Provenance{{SourceID: "doc-1", Evidence: "k8s schedules pods"}}
+	require.NoError(t, store.UpsertRelations(ctx, []*graph.Relation{
+		schedules,
+		graph.NewRelation("pod", "runs", "container"),
+		graph.NewRelation("container", "uses", "image"),
+	}))
+
+	again := graph.NewRelation("k8s", "schedules", "pod")
+	again.Provenance = []graph.Provenance{{SourceID: "doc-2", Evidence: "pods are scheduled by k8s"}}
+	require.NoError(t, store.UpsertRelations(ctx, []*graph.Relation{again}))
+
+	found, err := store.FindEntities(ctx, []string{"K8S", "kubernetes"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "container orchestrator", found[0].Description)
+	assert.Equal(t, true, found[0].Properties["cncf"])
+
+	sub, err := store.Neighborhood(ctx, []string{"k8s"}, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, sub.Relations, 2)
+	assert.Equal(t, 2.0, sub.Relations[0].Weight)
+	assert.Equal(t, 2, sub.Depth["container"])
+	assert.Equal(t, "pod", sub.Entities["pod"].Name, "missing endpoints are created")
+
+	require.NoError(t, store.DeleteSource(ctx, "doc-2"))
+	sub, err = store.Neighborhood(ctx, []string{"k8s"}, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, sub.Relations, 1)
+	assert.Equal(t, 1.0, sub.Relations[0].Weight)
+
+	require.NoError(t, store.DeleteSource(ctx, "doc-1"))
+	sub, err = store.Neighborhood(ctx, []string{"k8s"}, 1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, sub.Relations)
+}
+
+func TestStore_Communities(t *testing.T) {
+	ctx := context.Background()
+	store := setupTestStore(t)
+
+	kg := graph.NewKnowledgeGraph(graph.Config{Store: store})
+	require.NoError(t, kg.AddTriples(ctx, []*graph.Triple{
+		{Subject: graph.NewEntity("Alice", "person"), Predicate: "works_at", Object: graph.NewEntity("Acme", "org")},
+		{Subject: graph.NewEntity("Redis", "service"), Predicate: "replicates_to", Object: graph.NewEntity("Replica", "service")},
+	}))
+
+	communities, err := kg.BuildCommunities(ctx)
+	require.NoError(t, err)
+	require.Len(t, communities, 2)
+
+	stored, err := store.GetCommunities(ctx, []string{"alice"})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, []string{"acme", "alice"}, stored[0].EntityIDs)
+	assert.NotEmpty(t, stored[0].Summary)
+
+	// 重新生成时替换旧社区
+	require.NoError(t, store.SaveCommunities(ctx, nil))
+	stored, err = store.GetCommunities(ctx, []string{"alice"})
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
@@ -0,0 +1,311 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store 图存储接口
+type Store interface {
+	// UpsertEntities 新增或合并实体
+	UpsertEntities(ctx context.Context, entities []*Entity) error
+
+	// UpsertRelations 新增或合并关系,来源取并集
+	UpsertRelations(ctx context.Context, relations []*Relation) error
+
+	// GetEntities 按 ID 获取实体,不存在的 ID 被忽略
+	GetEntities(ctx context.Context, ids []string) ([]*Entity, error)
+
+	// FindEntities 按名称或别名查找实体,names 会先规范化
+	FindEntities(ctx context.Context, names []string) ([]*Entity, error)
+
+	// Neighborhood 从种子实体出发扩展 hops 跳邻域,最多返回 limit 条关系(<= 0 不限制)
+	Neighborhood(ctx context.Context, seeds []string, hops, limit int) (*Subgraph, error)
+
+	// Snapshot 返回完整图谱,用于社区发现
+	Snapshot(ctx context.Context) (*Subgraph, error)
+
+	// SaveCommunities 替换全部社区
+	SaveCommunities(ctx context.Context, communities []*Community) error
+
+	// GetCommunities 返回包含任一给定实体的社区
+	GetCommunities(ctx context.Context, entityIDs []string) ([]*Community, error)
+
+	// DeleteSource 移除某个来源的全部证据,没有剩余来源的关系被删除
+	DeleteSource(ctx context.Context, sourceID string) error
+}
+
+// InMemoryStore 基于邻接表的内存图存储
+type InMemoryStore struct {
+	mu          sync.RWMutex
+	entities    map[string]*Entity
+	names       map[string]map[string]bool // 规范化名称 -> 实体 ID
+	relations   map[string]*Relation
+	adjacency   map[string]map[string]bool // 实体 ID -> 关系 ID(出边和入边)
+	communities []*Community
+}
+
+// NewInMemoryStore 创建内存图存储
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		entities:    make(map[string]*Entity),
+		names:       make(map[string]map[string]bool),
+		relations:   make(map[string]*Relation),
+		adjacency:   make(map[string]map[string]bool),
+		communities: make([]*Community, 0),
+	}
+}
+
+// UpsertEntities 实现 Store 接口
+func (s *InMemoryStore) UpsertEntities(_ context.Context, entities []*Entity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, entity := range entities {
+		if entity == nil || entity.ID == "" {
+			continue
+		}
+		s.upsertEntity(entity, now)
+	}
+	return nil
+}
+
+// upsertEntity 合并单个实体,调用方持有写锁
+func (s *InMemoryStore) upsertEntity(entity *Entity, now time.Time) {
+	existing, ok := s.entities[entity.ID]
+	if !ok {
+		existing = entity.Clone()
+		if existing.CreatedAt.IsZero() {
+			existing.CreatedAt = now
+		}
+		s.entities[entity.ID] = existing
+	} else {
+		existing.Merge(entity)
+	}
+	existing.UpdatedAt = now
+
+	for _, name := range existing.Names() {
+		if s.names[name] == nil {
+			s.names[name] = make(map[string]bool)
+		}
+		s.names[name][existing.ID] = true
+	}
+}
+
+// UpsertRelations 实现 Store 接口
+//
+// 关系引用的实体不存在时会以 ID 作为名称自动创建
+func (s *InMemoryStore) UpsertRelations(_ context.Context, relations []*Relation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, relation := range relations {
+		if relation == nil || relation.Source == "" || relation.Target == "" {
+			continue
+		}
+		if relation.ID == "" {
+			relation.ID = RelationID(relation.Source, relation.Type, relation.Target)
+		}
+
+		for _, id := range []string{relation.Source, relation.Target} {
+			if _, ok := s.entities[id]; !ok {
+				s.upsertEntity(&Entity{ID: id, Name: id}, now)
+			}
+		}
+
+		existing, ok := s.relations[relation.ID]
+		if !ok {
+			existing = relation.Clone()
+			existing.Provenance = nil
+			if existing.CreatedAt.IsZero() {
+				existing.CreatedAt = now
+			}
+			s.relations[relation.ID] = existing
+			s.link(existing)
+		}
+		existing.Merge(relation)
+		existing.UpdatedAt = now
+	}
+	return nil
+}
+
+// GetEntities 实现 Store 接口
+func (s *InMemoryStore) GetEntities(_ context.Context, ids []string) ([]*Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Entity, 0, len(ids))
+	for _, id := range ids {
+		if entity, ok := s.entities[id]; ok {
+			result = append(result, entity.Clone())
+		}
+	}
+	return result, nil
+}
+
+// FindEntities 实现 Store 接口
+func (s *InMemoryStore) FindEntities(_ context.Context, names []string) ([]*Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	result := make([]*Entity, 0)
+	for _, name := range names {
+		ids := make([]string, 0, len(s.names[NormalizeName(name)]))
+		for id := range s.names[NormalizeName(name)] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, s.entities[id].Clone())
+			}
+		}
+	}
+	return result, nil
+}
+
+// Neighborhood 实现 Store 接口
+//
+// 按广度优先逐跳扩展,同一跳内优先选择权重更高的关系
+func (s *InMemoryStore) Neighborhood(_ context.Context, seeds []string, hops, limit int) (*Subgraph, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub := NewSubgraph()
+	frontier := make([]string, 0, len(seeds))
+	for _, id := range seeds {
+		if entity, ok := s.entities[id]; ok {
+			if _, added := sub.Entities[id]; !added {
+				sub.Entities[id] = entity.Clone()
+				sub.Depth[id] = 0
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for hop := 1; hop <= hops && len(frontier) > 0; hop++ {
+		candidates := make([]*Relation, 0)
+		for _, id := range frontier {
+			for relID := range s.adjacency[id] {
+				if !seen[relID] {
+					seen[relID] = true
+					candidates = append(candidates, s.relations[relID])
+				}
+			}
+		}
+		sortRelations(candidates)
+
+		next := make([]string, 0)
+		for _, relation := range candidates {
+			if limit > 0 && len(sub.Relations) >= limit {
+				return sub, nil
+			}
+			sub.Relations = append(sub.Relations, relation.Clone())
+			for _, id := range []string{relation.Source, relation.Target} {
+				if _, ok := sub.Entities[id]; !ok {
+					sub.Entities[id] = s.entities[id].Clone()
+					sub.Depth[id] = hop
+					next = append(next, id)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return sub, nil
+}
+
+// Snapshot 实现 Store 接口
+func (s *InMemoryStore) Snapshot(_ context.Context) (*Subgraph, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub := NewSubgraph()
+	for id, entity := range s.entities {
+		sub.Entities[id] = entity.Clone()
+	}
+	for _, relation := range s.relations {
+		sub.Relations = append(sub.Relations, relation.Clone())
+	}
+	sortRelations(sub.Relations)
+	return sub, nil
+}
+
+// SaveCommunities 实现 Store 接口
+func (s *InMemoryStore) SaveCommunities(_ context.Context, communities []*Community) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.communities = make([]*Community, 0, len(communities))
+	for _, community := range communities {
+		s.communities = append(s.communities, community.Clone())
+	}
+	return nil
+}
+
+// GetCommunities 实现 Store 接口
+func (s *InMemoryStore) GetCommunities(_ context.Context, entityIDs []string) ([]*Community, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(entityIDs))
+	for _, id := range entityIDs {
+		wanted[id] = true
+	}
+
+	result := make([]*Community, 0)
+	for _, community := range s.communities {
+		for _, id := range community.EntityIDs {
+			if wanted[id] {
+				result = append(result, community.Clone())
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// DeleteSource 实现 Store 接口
+func (s *InMemoryStore) DeleteSource(_ context.Context, sourceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, relation := range s.relations {
+		if len(relation.Provenance) == 0 {
+			continue
+		}
+		if relation.RemoveSource(sourceID) == 0 {
+			delete(s.relations, id)
+			delete(s.adjacency[relation.Source], id)
+			delete(s.adjacency[relation.Target], id)
+		}
+	}
+	return nil
+}
+
+// link 建立邻接关系,调用方持有写锁
+func (s *InMemoryStore) link(relation *Relation) {
+	for _, id := range []string{relation.Source, relation.Target} {
+		if s.adjacency[id] == nil {
+			s.adjacency[id] = make(map[string]bool)
+		}
+		s.adjacency[id][relation.ID] = true
+	}
+}
+
+// sortRelations 按权重降序、ID 升序排序,保证结果稳定
+func sortRelations(relations []*Relation) {
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].Weight != relations[j].Weight {
+			return relations[i].Weight > relations[j].Weight
+		}
+		return relations[i].ID < relations[j].ID
+	})
+}
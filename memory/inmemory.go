@@ -20,25 +20,45 @@ type InMemoryManager struct {
 	storeMu sync.RWMutex
 
 	// 案例存储
-	cases   map[string]*Case
-	casesMu sync.RWMutex
+	caseBase *CaseBase
 
 	// 配置
 	config *Config
 }
 
+// ManagerOption 内存记忆管理器选项
+type ManagerOption func(*InMemoryManager)
+
+// WithCaseBase 设置案例库（默认使用无嵌入器的 CaseBase，按关键词匹配）
+func WithCaseBase(caseBase *CaseBase) ManagerOption {
+	return func(m *InMemoryManager) {
+		if caseBase != nil {
+			m.caseBase = caseBase
+		}
+	}
+}
+
 // NewInMemoryManager 创建内存记忆管理器
-func NewInMemoryManager(config *Config) *InMemoryManager {
+func NewInMemoryManager(config *Config, opts ...ManagerOption) *InMemoryManager {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
-	return &InMemoryManager{
+	m := &InMemoryManager{
 		conversations: make(map[string][]*Conversation),
 		store:         make(map[string]interface{}),
-		cases:         make(map[string]*Case),
+		caseBase:      NewCaseBase(),
 		config:        config,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// CaseBase 返回底层案例库
+func (m *InMemoryManager) CaseBase() *CaseBase {
+	return m.caseBase
 }
 
 // AddConversation 添加对话
@@ -119,53 +139,28 @@ func (m *InMemoryManager) AddCase(ctx context.Context, caseMemory *Case) error {
 		return errors.New("case is nil")
 	}
 
-	// 生成 ID
-	if caseMemory.ID == "" {
-		caseMemory.ID = uuid.New().String()
-	}
+	return m.caseBase.AddCase(ctx, caseMemory)
+}
 
-	// 设置时间戳
-	now := time.Now()
-	if caseMemory.CreatedAt.IsZero() {
-		caseMemory.CreatedAt = now
+// DeleteCase 删除案例
+func (m *InMemoryManager) DeleteCase(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("case id is required")
 	}
-	caseMemory.UpdatedAt = now
-
-	m.casesMu.Lock()
-	defer m.casesMu.Unlock()
 
-	m.cases[caseMemory.ID] = caseMemory
-	return nil
+	return m.caseBase.DeleteCase(ctx, id)
 }
 
 // SearchSimilarCases 搜索相似案例
 //
-// 注意：这是一个简单的文本匹配实现，实际应用中应使用向量相似度搜索
+// 按相似度与效用的加权分数排序；案例库配置了嵌入器时使用向量相似度，否则按关键词重叠匹配
 func (m *InMemoryManager) SearchSimilarCases(ctx context.Context, query string, limit int) ([]*Case, error) {
-	if query == "" {
-		return []*Case{}, nil
-	}
-
-	m.casesMu.RLock()
-	defer m.casesMu.RUnlock()
-
-	// 简单的文本匹配（实际应使用向量搜索）
-	results := make([]*Case, 0)
-	for _, c := range m.cases {
-		// 简单检查：如果查询字符串出现在案例的描述或问题中
-		if contains(c.Description, query) || contains(c.Problem, query) || contains(c.Title, query) {
-			caseCopy := *c
-			caseCopy.Similarity = 0.8 // 模拟相似度
-			results = append(results, &caseCopy)
-		}
-	}
-
-	// 应用 limit
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
-	}
+	return m.caseBase.SearchSimilarCases(ctx, query, limit)
+}
 
-	return results, nil
+// RecordCaseOutcome 记录应用案例的结果，更新案例效用
+func (m *InMemoryManager) RecordCaseOutcome(ctx context.Context, id string, success bool) error {
+	return m.caseBase.RecordCaseOutcome(ctx, id, success)
 }
 
 // Store 存储键值对
@@ -212,7 +207,7 @@ func (m *InMemoryManager) Delete(ctx context.Context, key string) error {
 }
 
 // Clear 清空所有记忆
-// Lock acquisition order: convMu -> storeMu -> casesMu (always maintain this order to prevent deadlock)
+// Lock acquisition order: convMu -> storeMu -> case base (always maintain this order to prevent deadlock)
 func (m *InMemoryManager) Clear(ctx context.Context) error {
 	// Acquire locks in consistent order to prevent deadlock
 	m.convMu.Lock()
@@ -221,19 +216,9 @@ func (m *InMemoryManager) Clear(ctx context.Context) error {
 	m.storeMu.Lock()
 	defer m.storeMu.Unlock()
 
-	m.casesMu.Lock()
-	defer m.casesMu.Unlock()
-
 	// Clear all data
 	m.conversations = make(map[string][]*Conversation)
 	m.store = make(map[string]interface{})
-	m.cases = make(map[string]*Case)
-
-	return nil
-}
 
-// contains 检查字符串包含（不区分大小写的简单实现）
-func contains(s, substr string) bool {
-	// 简化实现，实际应使用 strings.Contains 或更复杂的匹配
-	return len(s) > 0 && len(substr) > 0
+	return m.caseBase.Clear(ctx)
 }
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// MemoryTier identifies the tier of a hierarchical memory that holds an entry
+type MemoryTier string
+
+const (
+	TierShortTerm MemoryTier = "short_term"
+	TierLongTerm  MemoryTier = "long_term"
+)
+
+// PersistedState holds the maintenance state of a hierarchical memory
+type PersistedState struct {
+	LastConsolidation time.Time `json:"last_consolidation"`
+	LastForget        time.Time `json:"last_forget"`
+	Consolidations    int       `json:"consolidations"`
+}
+
+// Persistence is the storage backend of a HierarchicalMemory.
+//
+// Entries are written through incrementally per tier, so an entry promoted
+// to short-term memory is stored once in each tier. Implementations must be
+// safe for concurrent use.
+type Persistence interface {
+	// SaveEntries upserts entries in the given tier
+	SaveEntries(ctx context.Context, tier MemoryTier, entries []*MemoryEntry) error
+
+	// DeleteEntries removes entries from the given tier
+	DeleteEntries(ctx context.Context, tier MemoryTier, ids []string) error
+
+	// LoadEntries returns all entries of the given tier
+	LoadEntries(ctx context.Context, tier MemoryTier) ([]*MemoryEntry, error)
+
+	// SaveState stores the maintenance state
+	SaveState(ctx context.Context, state *PersistedState) error
+
+	// LoadState returns the maintenance state, or nil if none was saved
+	LoadState(ctx context.Context) (*PersistedState, error)
+
+	// Clear removes all entries and state
+	Clear(ctx context.Context) error
+}
+
+// StorePersistence implements Persistence on top of a store.Store.
+//
+// Each entry is stored as a JSON document under <namespace>/<tier>, and the
+// state under <namespace>/state. Entry content goes through a JSON round
+// trip, so structured content is restored as maps and slices rather than
+// the original Go types.
+type StorePersistence struct {
+	store     store.Store
+	namespace []string
+}
+
+// NewStorePersistence creates a store-backed persistence.
+// The namespace defaults to "hierarchical_memory".
+func NewStorePersistence(s store.Store, namespace ...string) *StorePersistence {
+	if len(namespace) == 0 {
+		namespace = []string{"hierarchical_memory"}
+	}
+	return &StorePersistence{
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// SaveEntries upserts entries in the given tier
+func (p *StorePersistence) SaveEntries(ctx context.Context, tier MemoryTier, entries []*MemoryEntry) error {
+	ns := p.tierNamespace(tier)
+	for _, entry := range entries {
+		doc, err := toDocument(entry)
+		if err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode memory entry").
+				WithComponent("memory_persistence").
+				WithOperation("save_entries").
+				WithContext("entry_id", entry.ID)
+		}
+		if err := p.store.Put(ctx, ns, entry.ID, doc); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to save memory entry").
+				WithComponent("memory_persistence").
+				WithOperation("save_entries").
+				WithContext("tier", string(tier)).
+				WithContext("entry_id", entry.ID)
+		}
+	}
+	return nil
+}
+
+// DeleteEntries removes entries from the given tier
+func (p *StorePersistence) DeleteEntries(ctx context.Context, tier MemoryTier, ids []string) error {
+	ns := p.tierNamespace(tier)
+	for _, id := range ids {
+		if err := p.store.Delete(ctx, ns, id); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to delete memory entry").
+				WithComponent("memory_persistence").
+				WithOperation("delete_entries").
+				WithContext("tier", string(tier)).
+				WithContext("entry_id", id)
+		}
+	}
+	return nil
+}
+
+// LoadEntries returns all entries of the given tier
+func (p *StorePersistence) LoadEntries(ctx context.Context, tier MemoryTier) ([]*MemoryEntry, error) {
+	values, err := p.store.Search(ctx, p.tierNamespace(tier), nil)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to load memory entries").
+			WithComponent("memory_persistence").
+			WithOperation("load_entries").
+			WithContext("tier", string(tier))
+	}
+
+	entries := make([]*MemoryEntry, 0, len(values))
+	for _, value := range values {
+		var entry MemoryEntry
+		if err := fromDocument(value.Value, &entry); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode memory entry").
+				WithComponent("memory_persistence").
+				WithOperation("load_entries").
+				WithContext("entry_id", value.Key)
+		}
+		if entry.ID == "" {
+			entry.ID = value.Key
+		}
+		if entry.Metadata == nil {
+			entry.Metadata = make(map[string]interface{})
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// SaveState stores the maintenance state
+func (p *StorePersistence) SaveState(ctx context.Context, state *PersistedState) error {
+	doc, err := toDocument(state)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode memory state").
+			WithComponent("memory_persistence").
+			WithOperation("save_state")
+	}
+	if err := p.store.Put(ctx, p.stateNamespace(), "state", doc); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to save memory state").
+			WithComponent("memory_persistence").
+			WithOperation("save_state")
+	}
+	return nil
+}
+
+// LoadState returns the maintenance state, or nil if none was saved
+func (p *StorePersistence) LoadState(ctx context.Context) (*PersistedState, error) {
+	values, err := p.store.Search(ctx, p.stateNamespace(), nil)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to load memory state").
+			WithComponent("memory_persistence").
+			WithOperation("load_state")
+	}
+
+	for _, value := range values {
+		if value.Key != "state" {
+			continue
+		}
+		var state PersistedState
+		if err := fromDocument(value.Value, &state); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode memory state").
+				WithComponent("memory_persistence").
+				WithOperation("load_state")
+		}
+		return &state, nil
+	}
+	return nil, nil
+}
+
+// Clear removes all entries and state
+func (p *StorePersistence) Clear(ctx context.Context) error {
+	for _, ns := range [][]string{p.tierNamespace(TierShortTerm), p.tierNamespace(TierLongTerm), p.stateNamespace()} {
+		if err := p.store.Clear(ctx, ns); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to clear memory persistence").
+				WithComponent("memory_persistence").
+				WithOperation("clear")
+		}
+	}
+	return nil
+}
+
+// tierNamespace returns the namespace of a tier
+func (p *StorePersistence) tierNamespace(tier MemoryTier) []string {
+	return append(append([]string(nil), p.namespace...), string(tier))
+}
+
+// stateNamespace returns the namespace of the maintenance state
+func (p *StorePersistence) stateNamespace() []string {
+	return append(append([]string(nil), p.namespace...), "state")
+}
+
+// toDocument converts a value into a JSON document so every store backend
+// keeps a detached copy rather than a pointer to live data
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// fromDocument decodes a stored document into v
+func fromDocument(doc interface{}, v interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
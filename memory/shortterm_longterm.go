@@ -16,6 +16,9 @@ type ShortTermMemory struct {
 	order    []string // Maintain insertion order
 	capacity int
 	mu       sync.RWMutex
+
+	// onEvict is called with the entry evicted to make room, under the lock
+	onEvict func(entry *MemoryEntry)
 }
 
 // NewShortTermMemory creates a new short-term memory
@@ -228,6 +231,9 @@ func (m *ShortTermMemory) evictLRU() {
 	}
 
 	if evictID != "" {
+		if m.onEvict != nil {
+			m.onEvict(m.entries[evictID])
+		}
 		delete(m.entries, evictID)
 
 		// Remove from order
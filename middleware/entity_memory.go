@@ -0,0 +1,499 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kart-io/goagent/core"
+	coremiddleware "github.com/kart-io/goagent/core/middleware"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/store"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// EntityUser is the entity name used for facts about the user themselves
+const EntityUser = "user"
+
+// Metadata keys used to carry the turn from OnBefore to OnAfter
+const (
+	entityMemoryUserKey  = "entity_memory_user"
+	entityMemoryInputKey = "entity_memory_input"
+	entityMemoryFactsKey = "entity_memory_facts"
+)
+
+// DefaultEntityExtractionPrompt is the prompt used to extract facts from a turn.
+// It receives the known facts, the user message and the assistant response.
+const DefaultEntityExtractionPrompt = `You maintain a memory of facts about the user and the named entities (people, organizations, projects, places) they talk about.
+
+Known facts:
+%s
+
+Conversation turn:
+User: %s
+Assistant: %s
+
+Extract facts stated or clearly implied in this turn that are worth remembering in future conversations:
+preferences, identifiers (name, email, account, location, timezone), roles and relationships between entities.
+Use the entity "user" for the user themselves. Reuse the attribute names of known facts when updating them.
+Do not repeat known facts that did not change and do not record facts about the assistant.
+
+Respond with a JSON array only, for example:
+[{"entity": "user", "attribute": "preferred_language", "value": "Go", "confidence": 0.9}]
+Return [] when there is nothing to remember.`
+
+// EntityFact is a remembered fact about the user or a named entity
+type EntityFact struct {
+	// Entity is the entity the fact is about, "user" for the user
+	Entity string `json:"entity"`
+
+	// Attribute names the fact, e.g. preferred_language, employer, manager
+	Attribute string `json:"attribute"`
+
+	// Value is the current value
+	Value string `json:"value"`
+
+	// Confidence of the extraction, 0-1
+	Confidence float64 `json:"confidence"`
+
+	// Mentions counts how many turns confirmed the value
+	Mentions int `json:"mentions"`
+
+	// Previous is the value this fact replaced, if any
+	Previous string `json:"previous,omitempty"`
+
+	// UpdatedAt is when the value was last confirmed or changed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Key returns the storage key of the fact: one value per entity and attribute
+func (f *EntityFact) Key() string {
+	return normalizeFactPart(f.Entity) + "|" + normalizeFactPart(f.Attribute)
+}
+
+// String formats the fact for prompts
+func (f *EntityFact) String() string {
+	return fmt.Sprintf("%s: %s = %s", f.Entity, f.Attribute, f.Value)
+}
+
+// EntityMemoryConfig configures the entity memory middleware
+type EntityMemoryConfig struct {
+	// Store persists facts (required)
+	Store store.LangGraphStore
+
+	// LLMClient extracts facts after each turn (required)
+	LLMClient llm.Client
+
+	// Namespace returns the namespace of a user's facts,
+	// defaults to ["entity_memory", userID]
+	Namespace func(userID string) []string
+
+	// MaxFacts limits the facts injected into the prompt, default 10
+	MaxFacts int
+
+	// MinConfidence drops extracted facts below this confidence, default 0.5
+	MinConfidence float64
+
+	// ConflictMargin is how much less confident a newer contradicting value
+	// may be and still replace the current one, default 0.2
+	ConflictMargin float64
+
+	// Prompt overrides DefaultEntityExtractionPrompt
+	Prompt string
+
+	// OnError receives failures to load or update the facts. Memory is best
+	// effort, so they never fail the turn; by default they are dropped.
+	OnError func(ctx context.Context, err error)
+}
+
+// EntityMemoryMiddleware remembers facts about the user and the entities
+// they mention across sessions.
+//
+// OnBefore prepends a compact block of relevant facts to the input (the task
+// of an AgentInput);
+// OnAfter extracts new facts from the turn with an LLM and merges them into
+// the user's namespace, resolving contradictions by recency and confidence.
+// The user is identified by "user_id" in the request state or metadata.
+type EntityMemoryMiddleware struct {
+	*coremiddleware.BaseMiddleware
+	store          store.LangGraphStore
+	llmClient      llm.Client
+	namespace      func(userID string) []string
+	maxFacts       int
+	minConfidence  float64
+	conflictMargin float64
+	prompt         string
+	onError        func(ctx context.Context, err error)
+}
+
+// NewEntityMemoryMiddleware creates an entity memory middleware
+func NewEntityMemoryMiddleware(config EntityMemoryConfig) (*EntityMemoryMiddleware, error) {
+	if config.Store == nil {
+		return nil, agentErrors.NewInvalidConfigError("entity_memory", "store", "store is required")
+	}
+	if config.LLMClient == nil {
+		return nil, agentErrors.NewInvalidConfigError("entity_memory", "llm_client", "LLM client is required")
+	}
+
+	if config.Namespace == nil {
+		config.Namespace = func(userID string) []string {
+			return []string{"entity_memory", userID}
+		}
+	}
+	if config.MaxFacts <= 0 {
+		config.MaxFacts = 10
+	}
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = 0.5
+	}
+	if config.ConflictMargin <= 0 {
+		config.ConflictMargin = 0.2
+	}
+	if config.Prompt == "" {
+		config.Prompt = DefaultEntityExtractionPrompt
+	}
+
+	return &EntityMemoryMiddleware{
+		BaseMiddleware: coremiddleware.NewBaseMiddleware("entity-memory"),
+		store:          config.Store,
+		llmClient:      config.LLMClient,
+		namespace:      config.Namespace,
+		maxFacts:       config.MaxFacts,
+		minConfidence:  config.MinConfidence,
+		conflictMargin: config.ConflictMargin,
+		prompt:         config.Prompt,
+		onError:        config.OnError,
+	}, nil
+}
+
+// OnBefore injects the facts relevant to the input
+func (m *EntityMemoryMiddleware) OnBefore(ctx context.Context, request *coremiddleware.MiddlewareRequest) (*coremiddleware.MiddlewareRequest, error) {
+	userID := requestUserID(request)
+	text := inputText(request.Input)
+	if text == "" {
+		return request, nil
+	}
+
+	facts, err := m.Facts(ctx, userID)
+	if err != nil {
+		// Memory is best effort; the turn continues without it
+		m.reportError(ctx, err, "load", userID)
+		facts = nil
+	}
+	relevant := m.relevantFacts(facts, text)
+
+	if request.Metadata == nil {
+		request.Metadata = make(map[string]interface{})
+	}
+	request.Metadata[entityMemoryUserKey] = userID
+	request.Metadata[entityMemoryInputKey] = text
+	request.Metadata[entityMemoryFactsKey] = relevant
+
+	if len(relevant) > 0 {
+		block := FormatEntityFacts(relevant)
+		switch input := request.Input.(type) {
+		case string:
+			request.Input = block + "\n\n" + input
+		case *core.AgentInput:
+			// Agents build their prompts from the task; the caller's input
+			// is left untouched
+			withFacts := *input
+			withFacts.Task = block + "\n\n" + input.Task
+			withFacts.Context = make(map[string]interface{}, len(input.Context)+1)
+			for k, v := range input.Context {
+				withFacts.Context[k] = v
+			}
+			withFacts.Context["entity_facts"] = block
+			request.Input = &withFacts
+		}
+	}
+
+	return request, nil
+}
+
+// OnAfter extracts facts from the completed turn
+func (m *EntityMemoryMiddleware) OnAfter(ctx context.Context, response *coremiddleware.MiddlewareResponse) (*coremiddleware.MiddlewareResponse, error) {
+	if response == nil || response.Metadata == nil {
+		return response, nil
+	}
+	userID, _ := response.Metadata[entityMemoryUserKey].(string)
+	input, _ := response.Metadata[entityMemoryInputKey].(string)
+	if userID == "" || input == "" {
+		return response, nil
+	}
+	known, _ := response.Metadata[entityMemoryFactsKey].([]*EntityFact)
+
+	facts, err := m.Extract(ctx, input, outputText(response.Output), known)
+	if err == nil {
+		err = m.Remember(ctx, userID, facts...)
+	}
+	if err != nil {
+		// Extraction failures must not fail the turn
+		m.reportError(ctx, err, "update", userID)
+	}
+
+	return response, nil
+}
+
+// reportError passes a best-effort failure to the OnError handler
+func (m *EntityMemoryMiddleware) reportError(ctx context.Context, err error, operation, userID string) {
+	if m.onError == nil {
+		return
+	}
+	m.onError(ctx, agentErrors.Wrap(err, agentErrors.CodeMiddlewareExecution, "entity memory "+operation+" failed").
+		WithComponent("entity_memory").
+		WithOperation(operation).
+		WithContext("user_id", userID))
+}
+
+// Extract asks the LLM for facts stated in a turn
+func (m *EntityMemoryMiddleware) Extract(ctx context.Context, input, output string, known []*EntityFact) ([]*EntityFact, error) {
+	knownText := "(none)"
+	if len(known) > 0 {
+		knownText = FormatEntityFacts(known)
+	}
+
+	response, err := m.llmClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(fmt.Sprintf(m.prompt, knownText, input, output)),
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "entity extraction failed").
+			WithComponent("entity_memory").
+			WithOperation("extract")
+	}
+
+	content := response.Content
+	start, end := strings.Index(content, "["), strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return nil, nil
+	}
+
+	var facts []*EntityFact
+	if err := json.Unmarshal([]byte(content[start:end+1]), &facts); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMResponse, "invalid entity extraction output").
+			WithComponent("entity_memory").
+			WithOperation("extract")
+	}
+
+	valid := facts[:0]
+	for _, fact := range facts {
+		if fact == nil || strings.TrimSpace(fact.Entity) == "" || strings.TrimSpace(fact.Attribute) == "" || strings.TrimSpace(fact.Value) == "" {
+			continue
+		}
+		if fact.Confidence == 0 {
+			fact.Confidence = m.minConfidence
+		}
+		if fact.Confidence < m.minConfidence {
+			continue
+		}
+		valid = append(valid, fact)
+	}
+	return valid, nil
+}
+
+// Remember merges facts into a user's memory.
+//
+// A fact confirming the current value raises its confidence and mention
+// count. A contradicting value replaces the current one unless it is less
+// confident by more than the conflict margin.
+func (m *EntityMemoryMiddleware) Remember(ctx context.Context, userID string, facts ...*EntityFact) error {
+	ns := m.namespace(userID)
+	now := time.Now()
+
+	for _, fact := range facts {
+		incoming := *fact
+		incoming.Entity = strings.TrimSpace(incoming.Entity)
+		incoming.Attribute = normalizeFactPart(incoming.Attribute)
+		incoming.Value = strings.TrimSpace(incoming.Value)
+		if incoming.UpdatedAt.IsZero() {
+			incoming.UpdatedAt = now
+		}
+
+		err := m.store.Update(ctx, ns, incoming.Key(), func(current *store.StoreValue) (*store.StoreValue, error) {
+			existing, _ := decodeEntityFact(current.Value)
+			current.Value = resolveFact(existing, &incoming, m.conflictMargin)
+			return current, nil
+		})
+		if err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to save entity fact").
+				WithComponent("entity_memory").
+				WithOperation("remember").
+				WithContext("user_id", userID).
+				WithContext("key", incoming.Key())
+		}
+	}
+	return nil
+}
+
+// Facts returns all remembered facts of a user, most recent first
+func (m *EntityMemoryMiddleware) Facts(ctx context.Context, userID string) ([]*EntityFact, error) {
+	ns := m.namespace(userID)
+	keys, err := m.store.List(ctx, ns)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to list entity facts").
+			WithComponent("entity_memory").
+			WithOperation("facts").
+			WithContext("user_id", userID)
+	}
+
+	facts := make([]*EntityFact, 0, len(keys))
+	for _, key := range keys {
+		value, err := m.store.Get(ctx, ns, key)
+		if err != nil {
+			continue // Expired or concurrently deleted
+		}
+		if fact, ok := decodeEntityFact(value.Value); ok {
+			facts = append(facts, fact)
+		}
+	}
+
+	sort.SliceStable(facts, func(i, j int) bool {
+		return facts[i].UpdatedAt.After(facts[j].UpdatedAt)
+	})
+	return facts, nil
+}
+
+// Forget removes a fact about an entity
+func (m *EntityMemoryMiddleware) Forget(ctx context.Context, userID, entity, attribute string) error {
+	fact := &EntityFact{Entity: entity, Attribute: attribute}
+	if err := m.store.Delete(ctx, m.namespace(userID), fact.Key()); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to delete entity fact").
+			WithComponent("entity_memory").
+			WithOperation("forget").
+			WithContext("user_id", userID)
+	}
+	return nil
+}
+
+// relevantFacts selects the facts to inject: facts about the user and about
+// entities mentioned in the input, most recent first, up to MaxFacts
+func (m *EntityMemoryMiddleware) relevantFacts(facts []*EntityFact, input string) []*EntityFact {
+	lower := strings.ToLower(input)
+	relevant := make([]*EntityFact, 0, m.maxFacts)
+	for _, fact := range facts {
+		if len(relevant) >= m.maxFacts {
+			break
+		}
+		entity := strings.ToLower(fact.Entity)
+		if entity == EntityUser || strings.Contains(lower, entity) {
+			relevant = append(relevant, fact)
+		}
+	}
+	return relevant
+}
+
+// FormatEntityFacts formats facts as a compact prompt block
+func FormatEntityFacts(facts []*EntityFact) string {
+	var sb strings.Builder
+	sb.WriteString("Known facts about the user and mentioned entities:")
+	for _, fact := range facts {
+		sb.WriteString("\n- ")
+		sb.WriteString(fact.String())
+	}
+	return sb.String()
+}
+
+// resolveFact merges an incoming fact with the stored one
+func resolveFact(existing, incoming *EntityFact, margin float64) *EntityFact {
+	if existing == nil {
+		incoming.Mentions = 1
+		incoming.Previous = ""
+		return incoming
+	}
+
+	if strings.EqualFold(existing.Value, incoming.Value) {
+		existing.Mentions++
+		if incoming.Confidence > existing.Confidence {
+			existing.Confidence = incoming.Confidence
+		}
+		if incoming.UpdatedAt.After(existing.UpdatedAt) {
+			existing.UpdatedAt = incoming.UpdatedAt
+		}
+		return existing
+	}
+
+	// Contradiction: the newer value wins unless it is much less confident
+	if incoming.UpdatedAt.Before(existing.UpdatedAt) || incoming.Confidence+margin < existing.Confidence {
+		return existing
+	}
+	incoming.Mentions = 1
+	incoming.Previous = existing.Value
+	return incoming
+}
+
+// decodeEntityFact converts a stored value into a fact
+func decodeEntityFact(value interface{}) (*EntityFact, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case *EntityFact:
+		clone := *v
+		return &clone, true
+	case EntityFact:
+		return &v, true
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var fact EntityFact
+	if err := json.Unmarshal(data, &fact); err != nil || fact.Attribute == "" {
+		return nil, false
+	}
+	return &fact, true
+}
+
+// requestUserID extracts the user ID from request state or metadata
+func requestUserID(request *coremiddleware.MiddlewareRequest) string {
+	if request.State != nil {
+		if userID, ok := request.State.Get("user_id"); ok {
+			return fmt.Sprintf("%v", userID)
+		}
+	}
+	if request.Metadata != nil {
+		if userID, ok := request.Metadata["user_id"]; ok {
+			return fmt.Sprintf("%v", userID)
+		}
+	}
+	return "default"
+}
+
+// inputText extracts the user message from a request input
+func inputText(input interface{}) string {
+	switch v := input.(type) {
+	case string:
+		return v
+	case *core.AgentInput:
+		return strings.TrimSpace(v.Task + "\n" + v.Instruction)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// outputText extracts the assistant message from a response output
+func outputText(output interface{}) string {
+	switch v := output.(type) {
+	case string:
+		return v
+	case *core.AgentOutput:
+		return fmt.Sprintf("%v", v.Result)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// normalizeFactPart lowercases and joins words with underscores
+func normalizeFactPart(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "_")
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	coremiddleware "github.com/kart-io/goagent/core/middleware"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+)
+
+func TestEntityMemoryMiddleware_Turns(t *testing.T) {
+	ctx := context.Background()
+	backend := store.NewInMemoryLangGraphStore()
+	extractor := &MockLLMClient{Response: "```json\n" + `[
+		{"entity": "user", "attribute": "preferred language", "value": "Go", "confidence": 0.9},
+		{"entity": "Alice", "attribute": "role", "value": "user's manager", "confidence": 0.8},
+		{"entity": "user", "attribute": "mood", "value": "tired", "confidence": 0.2}
+	]` + "\n```"}
+
+	mw, err := NewEntityMemoryMiddleware(EntityMemoryConfig{Store: backend, LLMClient: extractor})
+	require.NoError(t, err)
+
+	var prompts []string
+	chain := coremiddleware.NewMiddlewareChain(func(ctx context.Context, req *coremiddleware.MiddlewareRequest) (*coremiddleware.MiddlewareResponse, error) {
+		prompts = append(prompts, req.Input.(string))
+		return &coremiddleware.MiddlewareResponse{Output: "Noted.", Metadata: req.Metadata}, nil
+	}).Use(mw)
+
+	run := func(input string) {
+		_, err := chain.Execute(ctx, &coremiddleware.MiddlewareRequest{
+			Input:    input,
+			Metadata: map[string]interface{}{"user_id": "u-1"},
+		})
+		require.NoError(t, err)
+	}
+
+	run("I mostly write Go. My manager Alice wants a report.")
+	assert.Equal(t, "I mostly write Go. My manager Alice wants a report.", prompts[0], "nothing known yet")
+
+	facts, err := mw.Facts(ctx, "u-1")
+	require.NoError(t, err)
+	require.Len(t, facts, 2, "low-confidence facts are dropped")
+
+	extractor.Response = "[]"
+	run("What should I name the module?")
+	assert.True(t, strings.HasPrefix(prompts[1], "Known facts about the user and mentioned entities:\n- user: preferred_language = Go\n\n"))
+	assert.NotContains(t, prompts[1], "Alice", "facts about unmentioned entities are not injected")
+
+	run("Should I cc alice?")
+	assert.Contains(t, prompts[2], "Alice: role = user's manager")
+
+	other, err := mw.Facts(ctx, "u-2")
+	require.NoError(t, err)
+	assert.Empty(t, other, "facts are stored per user")
+
+	_, err = NewEntityMemoryMiddleware(EntityMemoryConfig{Store: backend})
+	assert.Error(t, err)
+}
+
+func TestEntityMemoryMiddleware_Contradictions(t *testing.T) {
+	ctx := context.Background()
+	mw, err := NewEntityMemoryMiddleware(EntityMemoryConfig{
+		Store:     store.NewInMemoryLangGraphStore(),
+		LLMClient: &MockLLMClient{},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	fact := func(value string, confidence float64, at time.Time) *EntityFact {
+		return &EntityFact{Entity: "user", Attribute: "employer", Value: value, Confidence: confidence, UpdatedAt: at}
+	}
+
+	require.NoError(t, mw.Remember(ctx, "u-1", fact("Acme", 0.9, now.Add(-time.Hour))))
+	require.NoError(t, mw.Remember(ctx, "u-1", fact("acme", 0.7, now.Add(-time.Minute))))
+
+	facts, err := mw.Facts(ctx, "u-1")
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	assert.Equal(t, 2, facts[0].Mentions, "confirmations are counted")
+	assert.Equal(t, 0.9, facts[0].Confidence)
+
+	// A stale or much less confident contradiction is ignored
+	require.NoError(t, mw.Remember(ctx, "u-1", fact("Globex", 0.9, now.Add(-2*time.Hour))))
+	require.NoError(t, mw.Remember(ctx, "u-1", fact("Initech", 0.5, now)))
+	facts, _ = mw.Facts(ctx, "u-1")
+	assert.Equal(t, "Acme", facts[0].Value)
+
+	// A newer, comparably confident value wins
+	require.NoError(t, mw.Remember(ctx, "u-1", fact("Globex", 0.8, now)))
+	facts, _ = mw.Facts(ctx, "u-1")
+	assert.Equal(t, "Globex", facts[0].Value)
+	assert.Equal(t, "Acme", facts[0].Previous)
+
+	require.NoError(t, mw.Forget(ctx, "u-1", "User", "Employer"))
+	facts, _ = mw.Facts(ctx, "u-1")
+	assert.Empty(t, facts)
+}
+
+func TestEntityMemoryMiddleware_AgentInput(t *testing.T) {
+	ctx := context.Background()
+	mw, err := NewEntityMemoryMiddleware(EntityMemoryConfig{
+		Store:     store.NewInMemoryLangGraphStore(),
+		LLMClient: &MockLLMClient{Response: "[]"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mw.Remember(ctx, "default", &EntityFact{Entity: "user", Attribute: "timezone", Value: "CET", Confidence: 1}))
+
+	input := &core.AgentInput{Task: "schedule a meeting", Context: map[string]interface{}{"channel": "chat"}}
+	req, err := mw.OnBefore(ctx, &coremiddleware.MiddlewareRequest{Input: input})
+	require.NoError(t, err)
+	assert.Equal(t, "schedule a meeting", req.Metadata[entityMemoryInputKey])
+
+	// The facts reach the task every agent prompts with
+	injected := req.Input.(*core.AgentInput)
+	assert.True(t, strings.HasPrefix(injected.Task, "Known facts about the user and mentioned entities:\n- user: timezone = CET\n"))
+	assert.True(t, strings.HasSuffix(injected.Task, "\n\nschedule a meeting"))
+	assert.Contains(t, injected.Context["entity_facts"], "user: timezone = CET")
+	assert.Equal(t, "chat", injected.Context["channel"])
+
+	assert.Equal(t, "schedule a meeting", input.Task, "the caller's input is not modified")
+	assert.NotContains(t, input.Context, "entity_facts")
+}
+
+func TestEntityMemoryMiddleware_Errors(t *testing.T) {
+	ctx := context.Background()
+	var errs []error
+	mw, err := NewEntityMemoryMiddleware(EntityMemoryConfig{
+		Store:     store.NewInMemoryLangGraphStore(),
+		LLMClient: &MockLLMClient{Error: errors.New("provider down")},
+		OnError:   func(ctx context.Context, err error) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+
+	chain := coremiddleware.NewMiddlewareChain(func(ctx context.Context, req *coremiddleware.MiddlewareRequest) (*coremiddleware.MiddlewareResponse, error) {
+		return &coremiddleware.MiddlewareResponse{Output: "Noted.", Metadata: req.Metadata}, nil
+	}).Use(mw)
+
+	_, err = chain.Execute(ctx, &coremiddleware.MiddlewareRequest{Input: "I live in Berlin"})
+	require.NoError(t, err, "extraction failures do not fail the turn")
+	require.Len(t, errs, 1)
+	assert.Equal(t, agentErrors.CodeMiddlewareExecution, agentErrors.GetCode(errs[0]))
+	assert.Contains(t, errs[0].Error(), "provider down")
+}
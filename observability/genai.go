@@ -0,0 +1,385 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	agentcore "github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/secrets"
+)
+
+// GenAIInstrumentationName GenAI 自动埋点使用的 instrumentation scope 名称
+const GenAIInstrumentationName = "github.com/kart-io/goagent"
+
+// OpenTelemetry GenAI 语义约定属性
+//
+// 参考 https://opentelemetry.io/docs/specs/semconv/gen-ai/
+const (
+	AttrGenAISystem                = "gen_ai.system"
+	AttrGenAIOperationName         = "gen_ai.operation.name"
+	AttrGenAIRequestModel          = "gen_ai.request.model"
+	AttrGenAIRequestMaxTokens      = "gen_ai.request.max_tokens"
+	AttrGenAIRequestTemperature    = "gen_ai.request.temperature"
+	AttrGenAIRequestTopP           = "gen_ai.request.top_p"
+	AttrGenAIRequestStopSequences  = "gen_ai.request.stop_sequences"
+	AttrGenAIResponseModel         = "gen_ai.response.model"
+	AttrGenAIResponseFinishReasons = "gen_ai.response.finish_reasons"
+	AttrGenAIUsageInputTokens      = "gen_ai.usage.input_tokens"
+	AttrGenAIUsageOutputTokens     = "gen_ai.usage.output_tokens"
+	AttrGenAIToolName              = "gen_ai.tool.name"
+	AttrGenAIToolCallID            = "gen_ai.tool.call.id"
+	AttrGenAIToolDescription       = "gen_ai.tool.description"
+	AttrGenAIAgentName             = "gen_ai.agent.name"
+	AttrGenAIAgentDescription      = "gen_ai.agent.description"
+	AttrGenAIDataSourceID          = "gen_ai.data_source.id"
+	AttrErrorType                  = "error.type"
+
+	// 以下属性不在 GenAI 约定内,用于描述检索与 Agent 步骤
+	AttrRetrievalTopK          = "retrieval.top_k"
+	AttrRetrievalDocumentCount = "retrieval.documents.count"
+	AttrAgentStep              = "agent.step"
+)
+
+// GenAI 操作名称
+const (
+	GenAIOperationChat           = "chat"
+	GenAIOperationTextCompletion = "text_completion"
+	GenAIOperationEmbeddings     = "embeddings"
+	GenAIOperationExecuteTool    = "execute_tool"
+	GenAIOperationInvokeAgent    = "invoke_agent"
+	GenAIOperationRetrieve       = "retrieve"
+	GenAIOperationAgentStep      = "agent_step"
+)
+
+// GenAI 内容事件名称,仅在开启内容采集时记录
+const (
+	GenAIEventSystemMessage    = "gen_ai.system.message"
+	GenAIEventUserMessage      = "gen_ai.user.message"
+	GenAIEventAssistantMessage = "gen_ai.assistant.message"
+	GenAIEventToolMessage      = "gen_ai.tool.message"
+	GenAIEventChoice           = "gen_ai.choice"
+)
+
+// GenAIContentCaptureEnv 控制是否采集提示词与补全内容的环境变量
+const GenAIContentCaptureEnv = "OTEL_INSTRUMENTATION_GENAI_CAPTURE_MESSAGE_CONTENT"
+
+var captureContent atomic.Bool
+
+func init() {
+	enabled, _ := strconv.ParseBool(os.Getenv(GenAIContentCaptureEnv))
+	captureContent.Store(enabled)
+}
+
+// SetGenAIContentCapture 设置是否在 span 事件中记录提示词、补全、工具参数等内容
+//
+// 内容可能包含敏感信息,默认关闭,也可以通过 OTEL_INSTRUMENTATION_GENAI_CAPTURE_MESSAGE_CONTENT=true 开启
+func SetGenAIContentCapture(enabled bool) {
+	captureContent.Store(enabled)
+}
+
+// GenAIContentCaptureEnabled 是否采集内容
+func GenAIContentCaptureEnabled() bool {
+	return captureContent.Load()
+}
+
+// GenAIMessage 请求中的一条消息
+type GenAIMessage struct {
+	Role    string
+	Content string
+}
+
+// GenAIRequest LLM 请求信息
+type GenAIRequest struct {
+	System        string // 提供商,如 openai、anthropic
+	Operation     string // chat、text_completion、embeddings
+	Model         string
+	MaxTokens     int
+	Temperature   float64
+	TopP          float64
+	StopSequences []string
+	Messages      []GenAIMessage
+}
+
+// GenAIResponse LLM 响应信息
+type GenAIResponse struct {
+	Model         string
+	FinishReasons []string
+	InputTokens   int
+	OutputTokens  int
+	CachedTokens  int // 包含在 InputTokens 中,仅用于指标
+	Content       string
+}
+
+// StartGenAISpan 按 GenAI 语义约定启动 LLM 调用 span,span 名称为 "{operation} {model}"
+func StartGenAISpan(ctx context.Context, req GenAIRequest) (context.Context, trace.Span) {
+	if req.Operation == "" {
+		req.Operation = GenAIOperationChat
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAIOperationName, req.Operation),
+		attribute.String(AttrGenAISystem, req.System),
+		attribute.String(AttrGenAIRequestModel, req.Model),
+	}
+	if req.MaxTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIRequestMaxTokens, req.MaxTokens))
+	}
+	if req.Temperature > 0 {
+		attrs = append(attrs, attribute.Float64(AttrGenAIRequestTemperature, req.Temperature))
+	}
+	if req.TopP > 0 {
+		attrs = append(attrs, attribute.Float64(AttrGenAIRequestTopP, req.TopP))
+	}
+	if len(req.StopSequences) > 0 {
+		attrs = append(attrs, attribute.StringSlice(AttrGenAIRequestStopSequences, req.StopSequences))
+	}
+
+	name := req.Operation
+	if req.Model != "" {
+		name += " " + req.Model
+	}
+	ctx, span := genAITracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	if GenAIContentCaptureEnabled() {
+		for _, msg := range req.Messages {
+			span.AddEvent(messageEventName(msg.Role), trace.WithAttributes(
+				attribute.String(AttrGenAISystem, req.System),
+				contentAttribute(msg.Content),
+			))
+		}
+	}
+	return ctx, span
+}
+
+// EndGenAISpan 记录响应属性与错误并结束 span
+func EndGenAISpan(span trace.Span, resp *GenAIResponse, err error) {
+	if resp != nil {
+		if resp.Model != "" {
+			span.SetAttributes(attribute.String(AttrGenAIResponseModel, resp.Model))
+		}
+		if len(resp.FinishReasons) > 0 {
+			span.SetAttributes(attribute.StringSlice(AttrGenAIResponseFinishReasons, resp.FinishReasons))
+		}
+		if resp.InputTokens > 0 {
+			span.SetAttributes(attribute.Int(AttrGenAIUsageInputTokens, resp.InputTokens))
+		}
+		if resp.OutputTokens > 0 {
+			span.SetAttributes(attribute.Int(AttrGenAIUsageOutputTokens, resp.OutputTokens))
+		}
+		if GenAIContentCaptureEnabled() && resp.Content != "" {
+			attrs := []attribute.KeyValue{contentAttribute(resp.Content)}
+			if len(resp.FinishReasons) > 0 {
+				attrs = append(attrs, attribute.String("finish_reason", resp.FinishReasons[0]))
+			}
+			span.AddEvent(GenAIEventChoice, trace.WithAttributes(attrs...))
+		}
+	}
+	EndSpan(span, err)
+}
+
+// StartGenAIToolSpan 启动工具执行 span,span 名称为 "execute_tool {tool}"
+func StartGenAIToolSpan(ctx context.Context, toolName, description, callID string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAIOperationName, GenAIOperationExecuteTool),
+		attribute.String(AttrGenAIToolName, toolName),
+		// 保留旧属性,兼容已有的仪表盘
+		attribute.String("tool.name", toolName),
+	}
+	if description != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIToolDescription, description))
+	}
+	if callID != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIToolCallID, callID))
+	}
+	return genAITracer().Start(ctx, GenAIOperationExecuteTool+" "+toolName,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// StartRetrievalSpan 启动检索 span,span 名称为 "retrieve {retriever}"
+func StartRetrievalSpan(ctx context.Context, retriever, query string, topK int) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAIOperationName, GenAIOperationRetrieve),
+		attribute.String(AttrGenAIDataSourceID, retriever),
+	}
+	if topK > 0 {
+		attrs = append(attrs, attribute.Int(AttrRetrievalTopK, topK))
+	}
+	ctx, span := genAITracer().Start(ctx, GenAIOperationRetrieve+" "+retriever,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+	AddGenAIContentEvent(span, "gen_ai.retrieval.query", query)
+	return ctx, span
+}
+
+// StartInvokeAgentSpan 启动 Agent 调用 span,span 名称为 "invoke_agent {agent}"
+func StartInvokeAgentSpan(ctx context.Context, agentName, description string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAIOperationName, GenAIOperationInvokeAgent),
+		attribute.String(AttrGenAIAgentName, agentName),
+	}
+	if description != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIAgentDescription, description))
+	}
+	return genAITracer().Start(ctx, GenAIOperationInvokeAgent+" "+agentName,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// StartAgentStepSpan 启动 Agent 步骤 span,span 名称为 "agent_step {step}"
+//
+// 在 goroutine 中执行的步骤应使用父 Agent span 所在的上下文,以保持父子关系
+func StartAgentStepSpan(ctx context.Context, agentName, step string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String(AttrGenAIOperationName, GenAIOperationAgentStep),
+		attribute.String(AttrGenAIAgentName, agentName),
+		attribute.String(AttrAgentStep, step),
+	)
+	return genAITracer().Start(ctx, GenAIOperationAgentStep+" "+step,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// genAITracer 每次从全局 TracerProvider 获取 tracer,以便后续替换 provider 生效
+func genAITracer() trace.Tracer {
+	return otel.Tracer(GenAIInstrumentationName)
+}
+
+// AddGenAIContentEvent 在开启内容采集时添加内容事件
+func AddGenAIContentEvent(span trace.Span, name, content string) {
+	if content == "" || !GenAIContentCaptureEnabled() {
+		return
+	}
+	span.AddEvent(name, trace.WithAttributes(contentAttribute(content)))
+}
+
+// contentAttribute 返回内容属性，已注册的密钥值会被替换为句柄
+func contentAttribute(content string) attribute.KeyValue {
+	return attribute.String("content", secrets.Mask(content))
+}
+
+// EndSpan 记录错误(error.type 与状态)并结束 span,错误信息中的密钥值会被屏蔽
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		err = secrets.MaskError(err)
+		span.SetAttributes(attribute.String(AttrErrorType, errorType(err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// messageEventName 返回消息角色对应的事件名称
+func messageEventName(role string) string {
+	switch role {
+	case "system":
+		return GenAIEventSystemMessage
+	case "assistant":
+		return GenAIEventAssistantMessage
+	case "tool", "function":
+		return GenAIEventToolMessage
+	default:
+		return GenAIEventUserMessage
+	}
+}
+
+// errorType 返回错误类型,优先使用错误码
+func errorType(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return string(agentErrors.GetCode(err))
+}
+
+// CoreTracer 将 core.Tracer 接口桥接到 OpenTelemetry
+//
+// 使 core.TracingCallback 产生的 span 与自动埋点的 GenAI span 位于同一条链路中,
+// 并将 model、token_usage 等属性映射为 GenAI 语义约定属性
+type CoreTracer struct{}
+
+// NewCoreTracer 创建 core.Tracer 适配器
+func NewCoreTracer() *CoreTracer {
+	return &CoreTracer{}
+}
+
+// StartSpan 实现 core.Tracer 接口
+func (t *CoreTracer) StartSpan(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, agentcore.Span) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for key, value := range attrs {
+		kvs = append(kvs, coreAttribute(key, value))
+	}
+	ctx, span := genAITracer().Start(ctx, name, trace.WithAttributes(kvs...))
+	return ctx, &coreSpan{span: span}
+}
+
+// coreSpan 将 trace.Span 适配为 core.Span
+type coreSpan struct {
+	span trace.Span
+}
+
+func (s *coreSpan) End() {
+	s.span.End()
+}
+
+func (s *coreSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(coreAttribute(key, value))
+}
+
+func (s *coreSpan) SetStatus(code agentcore.StatusCode, description string) {
+	if code == agentcore.StatusCodeError {
+		s.span.SetStatus(codes.Error, description)
+		return
+	}
+	s.span.SetStatus(codes.Ok, description)
+}
+
+func (s *coreSpan) RecordError(err error) {
+	err = secrets.MaskError(err)
+	s.span.SetAttributes(attribute.String(AttrErrorType, errorType(err)))
+	s.span.RecordError(err)
+}
+
+// coreAttribute 转换 core 回调属性,已知键映射为 GenAI 属性
+func coreAttribute(key string, value interface{}) attribute.KeyValue {
+	switch key {
+	case "model":
+		key = AttrGenAIRequestModel
+	case "token_usage":
+		key = "gen_ai.usage.total_tokens"
+	case "tool", "tool_name":
+		key = AttrGenAIToolName
+	}
+
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
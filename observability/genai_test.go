@@ -0,0 +1,188 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	agentcore "github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/secrets"
+)
+
+func setupGenAITracing(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func spanAttrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestGenAISpan_Attributes(t *testing.T) {
+	recorder := setupGenAITracing(t)
+	SetGenAIContentCapture(false)
+
+	_, span := StartGenAISpan(context.Background(), GenAIRequest{
+		System:        "openai",
+		Model:         "gpt-4o",
+		MaxTokens:     256,
+		Temperature:   0.3,
+		StopSequences: []string{"END"},
+		Messages:      []GenAIMessage{{Role: "user", Content: "secret prompt"}},
+	})
+	EndGenAISpan(span, &GenAIResponse{
+		Model:         "gpt-4o-2024",
+		FinishReasons: []string{"stop"},
+		InputTokens:   12,
+		OutputTokens:  34,
+		Content:       "secret answer",
+	}, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "chat gpt-4o", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+
+	attrs := spanAttrs(spans[0])
+	assert.Equal(t, "chat", attrs[AttrGenAIOperationName].AsString())
+	assert.Equal(t, "openai", attrs[AttrGenAISystem].AsString())
+	assert.Equal(t, "gpt-4o", attrs[AttrGenAIRequestModel].AsString())
+	assert.Equal(t, int64(256), attrs[AttrGenAIRequestMaxTokens].AsInt64())
+	assert.Equal(t, []string{"END"}, attrs[AttrGenAIRequestStopSequences].AsStringSlice())
+	assert.Equal(t, "gpt-4o-2024", attrs[AttrGenAIResponseModel].AsString())
+	assert.Equal(t, []string{"stop"}, attrs[AttrGenAIResponseFinishReasons].AsStringSlice())
+	assert.Equal(t, int64(12), attrs[AttrGenAIUsageInputTokens].AsInt64())
+	assert.Equal(t, int64(34), attrs[AttrGenAIUsageOutputTokens].AsInt64())
+
+	// 默认不采集内容
+	assert.Empty(t, spans[0].Events())
+}
+
+func TestGenAISpan_ContentCapture(t *testing.T) {
+	recorder := setupGenAITracing(t)
+	SetGenAIContentCapture(true)
+	t.Cleanup(func() { SetGenAIContentCapture(false) })
+
+	_, span := StartGenAISpan(context.Background(), GenAIRequest{
+		System: "anthropic",
+		Model:  "claude",
+		Messages: []GenAIMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: "hello"},
+		},
+	})
+	EndGenAISpan(span, &GenAIResponse{Content: "hi", FinishReasons: []string{"end_turn"}}, nil)
+
+	events := recorder.Ended()[0].Events()
+	require.Len(t, events, 3)
+	assert.Equal(t, GenAIEventSystemMessage, events[0].Name)
+	assert.Equal(t, GenAIEventUserMessage, events[1].Name)
+	assert.Equal(t, GenAIEventChoice, events[2].Name)
+	assert.Contains(t, events[2].Attributes, attribute.String("content", "hi"))
+}
+
+func TestGenAISpan_MasksSecrets(t *testing.T) {
+	recorder := setupGenAITracing(t)
+	SetGenAIContentCapture(true)
+	t.Cleanup(func() { SetGenAIContentCapture(false) })
+	secrets.Register("genai_test_key", "sk-genai-test-0001")
+
+	_, span := StartGenAISpan(context.Background(), GenAIRequest{
+		System:   "openai",
+		Model:    "gpt-4o",
+		Messages: []GenAIMessage{{Role: "user", Content: "use sk-genai-test-0001"}},
+	})
+	EndGenAISpan(span, &GenAIResponse{Content: "sent sk-genai-test-0001"}, errors.New("rejected sk-genai-test-0001"))
+
+	ended := recorder.Ended()[0]
+	for _, event := range ended.Events() {
+		for _, kv := range event.Attributes {
+			assert.NotContains(t, kv.Value.Emit(), "sk-genai-test-0001", event.Name)
+		}
+	}
+	assert.Contains(t, ended.Events()[0].Attributes, attribute.String("content", "use {{secret:genai_test_key}}"))
+	assert.NotContains(t, ended.Status().Description, "sk-genai-test-0001")
+}
+
+func TestEndSpan_ErrorType(t *testing.T) {
+	recorder := setupGenAITracing(t)
+
+	_, span := StartGenAIToolSpan(context.Background(), "search", "web search", "call_1")
+	EndSpan(span, agentErrors.New(agentErrors.CodeToolExecution, "boom"))
+
+	_, span = StartRetrievalSpan(context.Background(), "vector", "query", 3)
+	EndSpan(span, context.DeadlineExceeded)
+
+	_, span = StartInvokeAgentSpan(context.Background(), "planner", "")
+	EndSpan(span, errors.New("plain"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "execute_tool search", spans[0].Name())
+	assert.Equal(t, "call_1", spanAttrs(spans[0])[AttrGenAIToolCallID].AsString())
+	assert.Equal(t, string(agentErrors.CodeToolExecution), spanAttrs(spans[0])[AttrErrorType].AsString())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+	assert.Equal(t, "retrieve vector", spans[1].Name())
+	assert.Equal(t, "timeout", spanAttrs(spans[1])[AttrErrorType].AsString())
+
+	assert.Equal(t, "invoke_agent planner", spans[2].Name())
+	assert.Equal(t, string(agentErrors.CodeInternal), spanAttrs(spans[2])[AttrErrorType].AsString())
+}
+
+func TestAgentStepSpan_ParentChild(t *testing.T) {
+	recorder := setupGenAITracing(t)
+
+	ctx, parent := StartInvokeAgentSpan(context.Background(), "supervisor", "")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, child := StartAgentStepSpan(ctx, "supervisor", "task 1")
+		EndSpan(child, nil)
+	}()
+	<-done
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, "task 1", spanAttrs(spans[0])[AttrAgentStep].AsString())
+}
+
+func TestCoreTracer_TracingCallback(t *testing.T) {
+	recorder := setupGenAITracing(t)
+
+	cb := agentcore.NewTracingCallback(NewCoreTracer())
+	ctx := context.Background()
+	require.NoError(t, cb.OnLLMStart(ctx, []string{"hi"}, "gpt-4o"))
+	require.NoError(t, cb.OnLLMEnd(ctx, "hello", 42))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	attrs := spanAttrs(spans[0])
+	assert.Equal(t, "gpt-4o", attrs[AttrGenAIRequestModel].AsString())
+	assert.Equal(t, int64(42), attrs["gen_ai.usage.total_tokens"].AsInt64())
+	assert.Equal(t, codes.Ok, spans[0].Status().Code)
+}
@@ -0,0 +1,143 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kart-io/goagent/llm"
+)
+
+// LLM 调用状态
+const (
+	LLMStatusSuccess  = "success"
+	LLMStatusError    = "error"
+	LLMStatusCanceled = "canceled"
+)
+
+// RecordLLMRequest 记录一次 LLM 请求
+func RecordLLMRequest(provider, model, status string, duration time.Duration) {
+	m := GetMetrics()
+	m.LLMRequests.WithLabelValues(provider, model, status).Inc()
+	m.LLMDuration.WithLabelValues(provider, model).Observe(duration.Seconds())
+}
+
+// RecordLLMTokens 记录 token 数，cachedTokens 包含在 inputTokens 中
+func RecordLLMTokens(provider, model string, inputTokens, outputTokens, cachedTokens int) {
+	m := GetMetrics()
+	if inputTokens > 0 {
+		m.LLMTokens.WithLabelValues(provider, model, "input").Add(float64(inputTokens))
+	}
+	if outputTokens > 0 {
+		m.LLMTokens.WithLabelValues(provider, model, "output").Add(float64(outputTokens))
+	}
+	if cachedTokens > 0 {
+		m.LLMTokens.WithLabelValues(provider, model, "cached_input").Add(float64(cachedTokens))
+	}
+}
+
+// LLMStatus 根据错误返回请求状态
+func LLMStatus(err error) string {
+	switch {
+	case err == nil:
+		return LLMStatusSuccess
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return LLMStatusCanceled
+	default:
+		return LLMStatusError
+	}
+}
+
+// StreamTimer 记录一次流式请求的首 token 延迟与 token 间延迟
+//
+// 每收到一个 token 调用 Token，流结束时调用 Done
+type StreamTimer struct {
+	provider string
+	model    string
+	start    time.Time
+	last     time.Time
+	once     sync.Once
+}
+
+// NewStreamTimer 在发起流式请求时创建计时器
+func NewStreamTimer(provider, model string) *StreamTimer {
+	return &StreamTimer{
+		provider: provider,
+		model:    model,
+		start:    time.Now(),
+	}
+}
+
+// Token 记录收到一个 token
+func (t *StreamTimer) Token() {
+	now := time.Now()
+	m := GetMetrics()
+	if t.last.IsZero() {
+		m.LLMTimeToFirstToken.WithLabelValues(t.provider, t.model).Observe(now.Sub(t.start).Seconds())
+	} else {
+		m.LLMInterTokenLatency.WithLabelValues(t.provider, t.model).Observe(now.Sub(t.last).Seconds())
+	}
+	t.last = now
+}
+
+// Done 记录请求结束，重复调用只记录一次
+func (t *StreamTimer) Done(err error) {
+	t.once.Do(func() {
+		RecordLLMRequest(t.provider, t.model, LLMStatus(err), time.Since(t.start))
+	})
+}
+
+// InstrumentedStreamClient 为 llm.StreamClient 记录请求、token 与流式延迟指标
+type InstrumentedStreamClient struct {
+	llm.StreamClient
+}
+
+// NewInstrumentedStreamClient 创建带指标的流式客户端
+//
+// Complete 与 Chat 直接透传；llm/providers 中的提供商已自动记录这两类调用
+func NewInstrumentedStreamClient(client llm.StreamClient) *InstrumentedStreamClient {
+	return &InstrumentedStreamClient{StreamClient: client}
+}
+
+// CompleteStream 流式补全
+func (c *InstrumentedStreamClient) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (<-chan *llm.StreamChunk, error) {
+	timer := NewStreamTimer(string(c.Provider()), req.Model)
+	chunks, err := c.StreamClient.CompleteStream(ctx, req)
+	return c.observe(timer, chunks, err)
+}
+
+// ChatStream 流式对话
+func (c *InstrumentedStreamClient) ChatStream(ctx context.Context, messages []llm.Message) (<-chan *llm.StreamChunk, error) {
+	timer := NewStreamTimer(string(c.Provider()), "")
+	chunks, err := c.StreamClient.ChatStream(ctx, messages)
+	return c.observe(timer, chunks, err)
+}
+
+// observe 转发流式块并记录指标
+func (c *InstrumentedStreamClient) observe(timer *StreamTimer, in <-chan *llm.StreamChunk, err error) (<-chan *llm.StreamChunk, error) {
+	if err != nil {
+		timer.Done(err)
+		return nil, err
+	}
+
+	out := make(chan *llm.StreamChunk, cap(in))
+	go func() {
+		defer close(out)
+		var streamErr error
+		for chunk := range in {
+			switch {
+			case chunk.Error != nil:
+				streamErr = chunk.Error
+			case chunk.Delta != "":
+				timer.Token()
+			}
+			if chunk.Usage != nil {
+				RecordLLMTokens(timer.provider, timer.model, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens, 0)
+			}
+			out <- chunk
+		}
+		timer.Done(streamErr)
+	}()
+	return out, nil
+}
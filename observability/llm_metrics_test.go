@@ -0,0 +1,140 @@
+package observability
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/cache"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+)
+
+// fakeStreamClient streams a fixed sequence of deltas without delays
+type fakeStreamClient struct {
+	deltas []string
+}
+
+func (c *fakeStreamClient) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return &llm.CompletionResponse{Content: strings.Join(c.deltas, "")}, nil
+}
+
+func (c *fakeStreamClient) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	return c.Complete(ctx, nil)
+}
+
+func (c *fakeStreamClient) Provider() constants.Provider { return constants.Provider("fake") }
+
+func (c *fakeStreamClient) IsAvailable() bool { return true }
+
+func (c *fakeStreamClient) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (<-chan *llm.StreamChunk, error) {
+	out := make(chan *llm.StreamChunk, len(c.deltas)+1)
+	for i, delta := range c.deltas {
+		out <- &llm.StreamChunk{Delta: delta, Index: i}
+	}
+	out <- &llm.StreamChunk{Done: true, Usage: &llm.Usage{PromptTokens: 7, CompletionTokens: len(c.deltas)}}
+	close(out)
+	return out, nil
+}
+
+func (c *fakeStreamClient) ChatStream(ctx context.Context, messages []llm.Message) (<-chan *llm.StreamChunk, error) {
+	return c.CompleteStream(ctx, &llm.CompletionRequest{Messages: messages})
+}
+
+// sampleCount returns the number of observations of a histogram series
+func sampleCount(t *testing.T, vec *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+	observer, err := vec.GetMetricWithLabelValues(labels...)
+	require.NoError(t, err)
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestInstrumentedStreamClient_RecordsLatencies(t *testing.T) {
+	m := GetMetrics()
+	client := NewInstrumentedStreamClient(&fakeStreamClient{deltas: []string{"a", "b", "c"}})
+
+	chunks, err := client.CompleteStream(context.Background(), &llm.CompletionRequest{Model: "stream-model"})
+	require.NoError(t, err)
+	for range chunks {
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.LLMRequests.WithLabelValues("fake", "stream-model", LLMStatusSuccess)))
+	assert.Equal(t, 7.0, testutil.ToFloat64(m.LLMTokens.WithLabelValues("fake", "stream-model", "input")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.LLMTokens.WithLabelValues("fake", "stream-model", "output")))
+	assert.Equal(t, uint64(1), sampleCount(t, m.LLMTimeToFirstToken, "fake", "stream-model"))
+	assert.Equal(t, uint64(2), sampleCount(t, m.LLMInterTokenLatency, "fake", "stream-model"))
+	assert.Equal(t, uint64(1), sampleCount(t, m.LLMDuration, "fake", "stream-model"))
+}
+
+func TestLLMStatus(t *testing.T) {
+	assert.Equal(t, LLMStatusSuccess, LLMStatus(nil))
+	assert.Equal(t, LLMStatusCanceled, LLMStatus(context.Canceled))
+	assert.Equal(t, LLMStatusError, LLMStatus(io.EOF))
+}
+
+func TestStatsCollector_CachesAndPools(t *testing.T) {
+	RegisterCacheStats("test-cache", "tool", func() CacheSnapshot {
+		return CacheSnapshot{Hits: 3, Misses: 1, Evictions: 2, Entries: 5}
+	})
+	RegisterPoolStats("test-pool", func() PoolSnapshot {
+		return PoolSnapshot{Total: 4, Active: 3, Idle: 1, MaxSize: 10, Utilization: 0.3}
+	})
+	t.Cleanup(func() {
+		UnregisterCacheStats("test-cache")
+		UnregisterPoolStats("test-pool")
+	})
+
+	server := httptest.NewServer(MetricsHandler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	assert.Contains(t, text, `cache_hits{cache="test-cache",kind="tool"} 3`)
+	assert.Contains(t, text, `cache_evictions{cache="test-cache",kind="tool"} 2`)
+	assert.Contains(t, text, `cache_hit_ratio{cache="test-cache",kind="tool"} 0.75`)
+	assert.Contains(t, text, `agent_pool_active{pool="test-pool"} 3`)
+	assert.Contains(t, text, `agent_pool_utilization_ratio{pool="test-pool"} 0.3`)
+
+	UnregisterCacheStats("test-cache")
+	assert.Zero(t, testutil.CollectAndCount(statsCollectorInstance, "cache_hits"))
+}
+
+func TestRegisterCache(t *testing.T) {
+	c := cache.NewInMemoryCache(10, time.Minute, time.Minute)
+	defer c.Close()
+	RegisterCache("test-general", c)
+	t.Cleanup(func() { UnregisterCacheStats("test-general") })
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "k", "v", 0))
+	_, _ = c.Get(ctx, "k")
+	_, _ = c.Get(ctx, "missing")
+
+	server := httptest.NewServer(MetricsHandler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	assert.Contains(t, text, `cache_hits{cache="test-general",kind="general"} 1`)
+	assert.Contains(t, text, `cache_misses{cache="test-general",kind="general"} 1`)
+	assert.Contains(t, text, `cache_entries{cache="test-general",kind="general"} 1`)
+}
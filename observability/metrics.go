@@ -1,11 +1,13 @@
 package observability
 
 import (
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Metrics Agent 监控指标
@@ -31,6 +33,18 @@ type Metrics struct {
 
 	// 并发执行指标
 	ConcurrentExecutions prometheus.Gauge
+
+	// LLM 调用指标
+	LLMRequests          *prometheus.CounterVec
+	LLMDuration          *prometheus.HistogramVec
+	LLMTimeToFirstToken  *prometheus.HistogramVec
+	LLMInterTokenLatency *prometheus.HistogramVec
+	LLMTokens            *prometheus.CounterVec
+
+	// LLM 成本指标
+	LLMCost           *prometheus.CounterVec
+	LLMCostTokens     *prometheus.CounterVec
+	LLMBudgetExceeded *prometheus.CounterVec
 }
 
 var (
@@ -137,7 +151,73 @@ func GetMetrics() *Metrics {
 					Help: "Current number of concurrent agent executions",
 				},
 			),
+
+			// LLM 调用指标
+			LLMRequests: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "llm_requests_total",
+					Help: "Total number of LLM requests",
+				},
+				[]string{"provider", "model", "status"},
+			),
+			LLMDuration: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "llm_request_duration_seconds",
+					Help:    "LLM request duration in seconds, until the last token for streams",
+					Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 120},
+				},
+				[]string{"provider", "model"},
+			),
+			LLMTimeToFirstToken: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "llm_time_to_first_token_seconds",
+					Help:    "Time from a streaming request to its first token in seconds",
+					Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+				},
+				[]string{"provider", "model"},
+			),
+			LLMInterTokenLatency: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "llm_inter_token_latency_seconds",
+					Help:    "Time between consecutive streamed tokens in seconds",
+					Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+				},
+				[]string{"provider", "model"},
+			),
+			LLMTokens: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "llm_tokens_total",
+					Help: "Total number of LLM tokens by type (input, output, cached_input)",
+				},
+				[]string{"provider", "model", "type"},
+			),
+
+			// LLM 成本指标
+			LLMCost: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "llm_cost_usd_total",
+					Help: "Total LLM spend in USD, priced with the cost catalog",
+				},
+				[]string{"provider", "model", "tenant", "agent_name"},
+			),
+			LLMCostTokens: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "llm_cost_tokens_total",
+					Help: "Total tokens attributed by the cost tracker",
+				},
+				[]string{"provider", "model", "tenant", "agent_name", "type"},
+			),
+			LLMBudgetExceeded: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "llm_budget_exceeded_total",
+					Help: "Number of LLM calls that exceeded a soft or hard budget",
+				},
+				[]string{"budget", "limit"},
+			),
 		}
+
+		// 缓存与池的统计在抓取时读取
+		prometheus.MustRegister(statsCollectorInstance)
 	})
 
 	return metricsInstance
@@ -200,3 +280,28 @@ func DecrementConcurrentExecutions() {
 	m := GetMetrics()
 	m.ConcurrentExecutions.Dec()
 }
+
+// RecordLLMCost 记录一次 LLM 调用的成本与 token 数
+//
+// 会话与用户的基数过高，不作为标签，按会话、用户的汇总见 cost.Tracker
+func RecordLLMCost(provider, model, tenant, agentName string, cost float64, inputTokens, outputTokens, cachedTokens int) {
+	m := GetMetrics()
+	m.LLMCost.WithLabelValues(provider, model, tenant, agentName).Add(cost)
+	m.LLMCostTokens.WithLabelValues(provider, model, tenant, agentName, "input").Add(float64(inputTokens))
+	m.LLMCostTokens.WithLabelValues(provider, model, tenant, agentName, "output").Add(float64(outputTokens))
+	m.LLMCostTokens.WithLabelValues(provider, model, tenant, agentName, "cached_input").Add(float64(cachedTokens))
+}
+
+// RecordBudgetExceeded 记录预算超限，limit 为 soft 或 hard
+func RecordBudgetExceeded(budget, limit string) {
+	m := GetMetrics()
+	m.LLMBudgetExceeded.WithLabelValues(budget, limit).Inc()
+}
+
+// MetricsHandler 返回暴露所有指标的 HTTP handler，可挂载到 /metrics
+//
+//	http.Handle("/metrics", observability.MetricsHandler())
+func MetricsHandler() http.Handler {
+	GetMetrics()
+	return promhttp.Handler()
+}
@@ -0,0 +1,187 @@
+package observability
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kart-io/goagent/cache"
+)
+
+// CacheSnapshot 缓存统计快照，由各缓存包的 CacheStats 转换而来
+type CacheSnapshot struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int64
+}
+
+// HitRatio 返回命中率（0.0 - 1.0）
+func (s CacheSnapshot) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// PoolSnapshot 池统计快照，由 performance.PoolStats 转换而来
+type PoolSnapshot struct {
+	Total       int
+	Active      int
+	Idle        int
+	MaxSize     int
+	Utilization float64 // 0.0 - 1.0
+	WaitCount   int64
+}
+
+// cacheSource 已注册的缓存
+type cacheSource struct {
+	kind  string
+	stats func() CacheSnapshot
+}
+
+// statsCollector 在每次抓取时读取已注册的缓存与池统计
+//
+// 统计由各组件自行累计，这里只做桥接，因此使用 Collector 而不是在每次命中时更新指标
+type statsCollector struct {
+	mu     sync.RWMutex
+	caches map[string]cacheSource
+	pools  map[string]func() PoolSnapshot
+
+	cacheHits      *prometheus.Desc
+	cacheMisses    *prometheus.Desc
+	cacheEvictions *prometheus.Desc
+	cacheEntries   *prometheus.Desc
+	cacheHitRatio  *prometheus.Desc
+
+	poolSize        *prometheus.Desc
+	poolActive      *prometheus.Desc
+	poolIdle        *prometheus.Desc
+	poolMaxSize     *prometheus.Desc
+	poolUtilization *prometheus.Desc
+	poolWaits       *prometheus.Desc
+}
+
+var statsCollectorInstance = newStatsCollector()
+
+func newStatsCollector() *statsCollector {
+	cacheLabels := []string{"cache", "kind"}
+	poolLabels := []string{"pool"}
+	return &statsCollector{
+		caches: make(map[string]cacheSource),
+		pools:  make(map[string]func() PoolSnapshot),
+
+		cacheHits:      prometheus.NewDesc("cache_hits", "Number of cache hits", cacheLabels, nil),
+		cacheMisses:    prometheus.NewDesc("cache_misses", "Number of cache misses", cacheLabels, nil),
+		cacheEvictions: prometheus.NewDesc("cache_evictions", "Number of cache evictions", cacheLabels, nil),
+		cacheEntries:   prometheus.NewDesc("cache_entries", "Current number of cache entries", cacheLabels, nil),
+		cacheHitRatio:  prometheus.NewDesc("cache_hit_ratio", "Cache hit ratio (0-1)", cacheLabels, nil),
+
+		poolSize:        prometheus.NewDesc("agent_pool_size", "Current number of agents in the pool", poolLabels, nil),
+		poolActive:      prometheus.NewDesc("agent_pool_active", "Number of agents in use", poolLabels, nil),
+		poolIdle:        prometheus.NewDesc("agent_pool_idle", "Number of idle agents", poolLabels, nil),
+		poolMaxSize:     prometheus.NewDesc("agent_pool_max_size", "Maximum pool size", poolLabels, nil),
+		poolUtilization: prometheus.NewDesc("agent_pool_utilization_ratio", "Pool utilisation (0-1)", poolLabels, nil),
+		poolWaits:       prometheus.NewDesc("agent_pool_waits", "Number of acquisitions that had to wait", poolLabels, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.cacheHits, c.cacheMisses, c.cacheEvictions, c.cacheEntries, c.cacheHitRatio,
+		c.poolSize, c.poolActive, c.poolIdle, c.poolMaxSize, c.poolUtilization, c.poolWaits,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect 实现 prometheus.Collector
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, name := range sortedKeys(c.caches) {
+		source := c.caches[name]
+		s := source.stats()
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, name, source.kind)
+		}
+		gauge(c.cacheHits, float64(s.Hits))
+		gauge(c.cacheMisses, float64(s.Misses))
+		gauge(c.cacheEvictions, float64(s.Evictions))
+		gauge(c.cacheEntries, float64(s.Entries))
+		gauge(c.cacheHitRatio, s.HitRatio())
+	}
+
+	for _, name := range sortedKeys(c.pools) {
+		s := c.pools[name]()
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, name)
+		}
+		gauge(c.poolSize, float64(s.Total))
+		gauge(c.poolActive, float64(s.Active))
+		gauge(c.poolIdle, float64(s.Idle))
+		gauge(c.poolMaxSize, float64(s.MaxSize))
+		gauge(c.poolUtilization, s.Utilization)
+		gauge(c.poolWaits, float64(s.WaitCount))
+	}
+}
+
+// RegisterCacheStats 注册缓存统计来源，name 在所有缓存中唯一，重复注册会替换
+//
+// kind 描述缓存类型，如 tool、llm_semantic、general、agent_result。
+// 通常通过各缓存包的 RegisterMetrics 辅助函数或 RegisterCache 调用
+func RegisterCacheStats(name, kind string, stats func() CacheSnapshot) {
+	GetMetrics()
+	statsCollectorInstance.mu.Lock()
+	defer statsCollectorInstance.mu.Unlock()
+	statsCollectorInstance.caches[name] = cacheSource{kind: kind, stats: stats}
+}
+
+// RegisterCache 将通用缓存（cache.Cache）的命中、未命中与驱逐统计导出为 Prometheus 指标
+func RegisterCache(name string, c cache.Cache) {
+	RegisterCacheStats(name, "general", func() CacheSnapshot {
+		stats := c.GetStats()
+		return CacheSnapshot{
+			Hits:      stats.Hits,
+			Misses:    stats.Misses,
+			Evictions: stats.Evictions,
+			Entries:   stats.Size,
+		}
+	})
+}
+
+// UnregisterCacheStats 移除缓存统计来源，缓存关闭时调用
+func UnregisterCacheStats(name string) {
+	statsCollectorInstance.mu.Lock()
+	defer statsCollectorInstance.mu.Unlock()
+	delete(statsCollectorInstance.caches, name)
+}
+
+// RegisterPoolStats 注册池统计来源，name 在所有池中唯一，重复注册会替换
+func RegisterPoolStats(name string, stats func() PoolSnapshot) {
+	GetMetrics()
+	statsCollectorInstance.mu.Lock()
+	defer statsCollectorInstance.mu.Unlock()
+	statsCollectorInstance.pools[name] = stats
+}
+
+// UnregisterPoolStats 移除池统计来源，池关闭时调用
+func UnregisterPoolStats(name string) {
+	statsCollectorInstance.mu.Lock()
+	defer statsCollectorInstance.mu.Unlock()
+	delete(statsCollectorInstance.pools, name)
+}
+
+// sortedKeys 返回排序后的键，保证输出稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,36 @@
+package performance
+
+import "github.com/kart-io/goagent/observability"
+
+// PoolStatsProvider 提供池统计信息，AgentPool 与 OptimizedAgentPool 均实现
+type PoolStatsProvider interface {
+	Stats() PoolStats
+}
+
+// RegisterPoolMetrics 将 Agent 池的利用率导出为 Prometheus 指标
+func RegisterPoolMetrics(name string, pool PoolStatsProvider) {
+	observability.RegisterPoolStats(name, func() observability.PoolSnapshot {
+		stats := pool.Stats()
+		return observability.PoolSnapshot{
+			Total:       stats.TotalCount,
+			Active:      stats.ActiveCount,
+			Idle:        stats.IdleCount,
+			MaxSize:     stats.MaxSize,
+			Utilization: stats.UtilizationPct / 100,
+			WaitCount:   stats.WaitCount,
+		}
+	})
+}
+
+// RegisterCacheMetrics 将 CachedAgent 的结果缓存统计导出为 Prometheus 指标
+func RegisterCacheMetrics(name string, agent *CachedAgent) {
+	observability.RegisterCacheStats(name, "agent_result", func() observability.CacheSnapshot {
+		stats := agent.Stats()
+		return observability.CacheSnapshot{
+			Hits:      stats.Hits,
+			Misses:    stats.Misses,
+			Evictions: stats.Evictions,
+			Entries:   int64(stats.Size),
+		}
+	})
+}
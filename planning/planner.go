@@ -147,6 +147,10 @@ type SmartPlanner struct {
 	maxPlanDepth int
 	maxRetries   int
 	timeout      time.Duration
+
+	// Case seeding
+	seedFromCases     bool
+	seedMinSimilarity float64
 }
 
 // NewSmartPlanner creates a new smart planner
@@ -203,6 +207,27 @@ func WithOptimizer(optimizer PlanOptimizer) PlannerOption {
 	}
 }
 
+// WithCaseSeeding seeds new plans with the steps of the best matching past
+// case when its similarity is at least minSimilarity. The seed case ID is
+// kept in plan.Context["seed_case_id"] so that RecordOutcome can feed the
+// result back to memory.
+func WithCaseSeeding(minSimilarity float64) PlannerOption {
+	return func(p *SmartPlanner) {
+		p.seedFromCases = true
+		p.seedMinSimilarity = minSimilarity
+	}
+}
+
+// PlanContextSeedCaseID is the plan context key holding the ID of the case
+// a plan was seeded from
+const PlanContextSeedCaseID = "seed_case_id"
+
+// caseOutcomeRecorder is implemented by memories that learn case utility
+// from outcome feedback, such as memory.InMemoryManager
+type caseOutcomeRecorder interface {
+	RecordCaseOutcome(ctx context.Context, id string, success bool) error
+}
+
 // CreatePlan creates a plan for achieving a goal
 func (p *SmartPlanner) CreatePlan(ctx context.Context, goal string, constraints PlanConstraints) (*Plan, error) {
 	// Retrieve relevant past plans from memory
@@ -231,6 +256,9 @@ func (p *SmartPlanner) CreatePlan(ctx context.Context, goal string, constraints
 
 	// Parse and structure the plan
 	plan := p.parsePlan(response.Content, goal)
+	if p.seedFromCases {
+		p.seedPlan(plan, similarPlans)
+	}
 
 	// Apply planning strategy
 	strategy := p.selectStrategy(goal, constraints)
@@ -390,6 +418,31 @@ func (p *SmartPlanner) AddValidator(validator PlanValidator) {
 	p.validators = append(p.validators, validator)
 }
 
+// RecordOutcome reports whether executing a plan succeeded. If the plan was
+// seeded from a case and memory supports outcome feedback, the utility of
+// that case is updated so that future retrieval prefers cases that work.
+func (p *SmartPlanner) RecordOutcome(ctx context.Context, plan *Plan, success bool) error {
+	if plan == nil {
+		return nil
+	}
+	caseID, _ := plan.Context[PlanContextSeedCaseID].(string)
+	if caseID == "" {
+		return nil
+	}
+	recorder, ok := p.memory.(caseOutcomeRecorder)
+	if !ok {
+		return nil
+	}
+	if err := recorder.RecordCaseOutcome(ctx, caseID, success); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to record case outcome").
+			WithComponent("smart_planner").
+			WithOperation("record_outcome").
+			WithContext("plan_id", plan.ID).
+			WithContext("case_id", caseID)
+	}
+	return nil
+}
+
 // Helper methods
 
 func (p *SmartPlanner) retrieveSimilarPlans(ctx context.Context, goal string) ([]*Plan, error) {
@@ -402,11 +455,21 @@ func (p *SmartPlanner) retrieveSimilarPlans(ctx context.Context, goal string) ([
 	plans := make([]*Plan, 0, len(cases))
 	for _, c := range cases {
 		// Create a simplified plan from the case
+		steps := caseSteps(c)
 		plan := &Plan{
 			ID:       c.ID,
 			Goal:     c.Problem,
 			Strategy: c.Solution,
+			Steps:    steps,
 			Status:   PlanStatusCompleted,
+			Context: map[string]interface{}{
+				"case_id":    c.ID,
+				"similarity": c.Similarity,
+			},
+			Metrics: &PlanMetrics{
+				TotalSteps:  len(steps),
+				SuccessRate: c.Utility,
+			},
 		}
 		plans = append(plans, plan)
 	}
@@ -414,6 +477,59 @@ func (p *SmartPlanner) retrieveSimilarPlans(ctx context.Context, goal string) ([
 	return plans, nil
 }
 
+// caseSteps converts the recorded plan of a case into pending steps
+func caseSteps(c *interfaces.Case) []*Step {
+	var descriptions []string
+	switch plan := c.Metadata["plan"].(type) {
+	case []string:
+		descriptions = plan
+	case []interface{}:
+		for _, item := range plan {
+			if text, ok := item.(string); ok {
+				descriptions = append(descriptions, text)
+			}
+		}
+	}
+
+	steps := make([]*Step, 0, len(descriptions))
+	for i, description := range descriptions {
+		steps = append(steps, &Step{
+			ID:          fmt.Sprintf("step_%d", i+1),
+			Name:        description,
+			Description: description,
+			Type:        StepTypeAction,
+			Priority:    i + 1,
+			Status:      StatusPending,
+		})
+	}
+	return steps
+}
+
+// seedPlan replaces the generated steps with those of the best matching case
+func (p *SmartPlanner) seedPlan(plan *Plan, similarPlans []*Plan) {
+	if len(similarPlans) == 0 {
+		return
+	}
+	best := similarPlans[0]
+	similarity, _ := best.Context["similarity"].(float64)
+	if len(best.Steps) == 0 || similarity < p.seedMinSimilarity {
+		return
+	}
+
+	steps := make([]*Step, len(best.Steps))
+	for i, step := range best.Steps {
+		copied := *step
+		copied.Status = StatusPending
+		copied.Result = nil
+		steps[i] = &copied
+	}
+	plan.Steps = steps
+	if plan.Strategy == "" {
+		plan.Strategy = best.Strategy
+	}
+	plan.Context[PlanContextSeedCaseID] = best.ID
+}
+
 func (p *SmartPlanner) buildPlanPrompt(goal string, constraints PlanConstraints, similarPlans []*Plan) string {
 	prompt := fmt.Sprintf("Create a detailed plan to achieve the following goal:\n\nGoal: %s\n\n", goal)
 
@@ -427,8 +543,12 @@ func (p *SmartPlanner) buildPlanPrompt(goal string, constraints PlanConstraints,
 	if len(similarPlans) > 0 {
 		prompt += "\nSimilar successful plans for reference:\n"
 		for _, plan := range similarPlans {
+			var successRate float64
+			if plan.Metrics != nil {
+				successRate = plan.Metrics.SuccessRate
+			}
 			prompt += fmt.Sprintf("- %s (Strategy: %s, Steps: %d, Success Rate: %.2f%%)\n",
-				plan.Goal, plan.Strategy, len(plan.Steps), plan.Metrics.SuccessRate*100)
+				plan.Goal, plan.Strategy, len(plan.Steps), successRate*100)
 		}
 	}
 
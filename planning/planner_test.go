@@ -10,6 +10,7 @@ import (
 
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/memory"
 )
 
 // TestNewSmartPlanner tests the creation of a SmartPlanner
@@ -466,3 +467,43 @@ func BenchmarkSmartPlanner_ValidatePlan(b *testing.B) {
 		_, _, _ = planner.ValidatePlan(ctx, plan)
 	}
 }
+
+// TestSmartPlanner_CaseSeeding tests seeding plans from past cases and outcome feedback
+func TestSmartPlanner_CaseSeeding(t *testing.T) {
+	ctx := context.Background()
+	llmClient := &MockLLMClient{
+		CompleteFn: func(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+			return &llm.CompletionResponse{Content: "Strategy: decomposition"}, nil
+		},
+	}
+	mem := memory.NewInMemoryManager(nil)
+	seed, err := mem.CaseBase().RecordRun(ctx, &memory.CaseRun{
+		Input:   "deploy the billing service",
+		Plan:    []string{"build image", "run migrations", "roll out"},
+		Outcome: "deployed",
+		Success: true,
+	})
+	require.NoError(t, err)
+
+	planner := NewSmartPlanner(llmClient, mem, WithCaseSeeding(0.5))
+	plan, err := planner.CreatePlan(ctx, "deploy the billing service", PlanConstraints{})
+	require.NoError(t, err)
+	assert.Equal(t, seed.ID, plan.Context[PlanContextSeedCaseID])
+
+	descriptions := make([]string, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		descriptions = append(descriptions, step.Description)
+	}
+	assert.Equal(t, []string{"build image", "run migrations", "roll out"}, descriptions)
+
+	require.NoError(t, planner.RecordOutcome(ctx, plan, false))
+	updated, err := mem.CaseBase().GetCase(ctx, seed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.FailureCount)
+	assert.InDelta(t, 0.5, updated.Utility, 1e-9)
+
+	// Without seeding the generated steps are kept
+	plan, err = NewSmartPlanner(llmClient, mem).CreatePlan(ctx, "deploy the billing service", PlanConstraints{})
+	require.NoError(t, err)
+	assert.NotContains(t, plan.Context, PlanContextSeedCaseID)
+}
@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kart-io/goagent/core"
+)
+
+// Approver decides require_approval calls
+type Approver interface {
+	Approve(ctx context.Context, decision *Decision) (*Approval, error)
+}
+
+// ApproverFunc adapts a function to the Approver interface
+type ApproverFunc func(ctx context.Context, decision *Decision) (*Approval, error)
+
+// Approve calls the function
+func (f ApproverFunc) Approve(ctx context.Context, decision *Decision) (*Approval, error) {
+	return f(ctx, decision)
+}
+
+// InterruptApprover puts approvals to a human through core.InterruptManager.
+// The call blocks until RespondToInterrupt is called, the interrupt is
+// cancelled or times out, or ctx is done; everything but an approving
+// response denies the call.
+type InterruptApprover struct {
+	manager *core.InterruptManager
+	timeout time.Duration
+}
+
+// NewInterruptApprover creates an approver. timeout bounds the wait; zero
+// uses the InterruptManager default for high-priority interrupts.
+func NewInterruptApprover(manager *core.InterruptManager, timeout time.Duration) *InterruptApprover {
+	return &InterruptApprover{manager: manager, timeout: timeout}
+}
+
+// Approve creates an approval interrupt and waits for the response
+func (a *InterruptApprover) Approve(ctx context.Context, decision *Decision) (*Approval, error) {
+	interrupt := &core.Interrupt{
+		Type:     core.InterruptTypeApproval,
+		Priority: core.InterruptPriorityHigh,
+		Message:  fmt.Sprintf("Approve call of tool %q by %q? %s", decision.Tool, decision.Subject, decision.Reason),
+		Context: map[string]interface{}{
+			"subject": decision.Subject,
+			"tool":    decision.Tool,
+			"args":    decision.Args,
+			"rule":    decision.Rule,
+		},
+		Metadata: map[string]interface{}{"source": "policy"},
+	}
+	if a.timeout > 0 {
+		expires := time.Now().Add(a.timeout)
+		interrupt.ExpiresAt = &expires
+	}
+
+	created, response, err := a.manager.CreateInterrupt(ctx, interrupt)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return &Approval{ID: created.ID, Reason: "approval cancelled"}, nil
+	}
+	return &Approval{
+		ID:       created.ID,
+		Approved: response.Approved,
+		By:       response.RespondedBy,
+		Reason:   response.Reason,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// AuditLog records policy decisions. A failing Record denies the call, so
+// that no tool runs without a trace.
+type AuditLog interface {
+	Record(ctx context.Context, decision *Decision) error
+}
+
+// AuditLogFunc adapts a function to the AuditLog interface
+type AuditLogFunc func(ctx context.Context, decision *Decision) error
+
+// Record calls the function
+func (f AuditLogFunc) Record(ctx context.Context, decision *Decision) error {
+	return f(ctx, decision)
+}
+
+// MemoryAuditLog keeps decisions in memory, mostly for tests and for
+// inspecting recent decisions
+type MemoryAuditLog struct {
+	mu        sync.RWMutex
+	decisions []*Decision
+}
+
+// NewMemoryAuditLog creates an in-memory audit log
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+// Record stores the decision
+func (l *MemoryAuditLog) Record(ctx context.Context, decision *Decision) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.decisions = append(l.decisions, decision)
+	return nil
+}
+
+// Decisions returns the recorded decisions in order
+func (l *MemoryAuditLog) Decisions() []*Decision {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	decisions := make([]*Decision, len(l.decisions))
+	copy(decisions, l.decisions)
+	return decisions
+}
+
+// JSONLAuditLog appends one JSON decision per line to a file
+type JSONLAuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONLAuditLog creates an audit log backed by the file at path, creating
+// parent directories as needed
+func NewJSONLAuditLog(path string) (*JSONLAuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodePolicyAudit, "failed to create audit log directory").
+			WithComponent("policy").
+			WithOperation("open_audit").
+			WithContext("path", path)
+	}
+	return &JSONLAuditLog{path: path}, nil
+}
+
+// Record appends the decision
+func (l *JSONLAuditLog) Record(ctx context.Context, decision *Decision) error {
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodePolicyAudit, "failed to encode policy decision").
+			WithComponent("policy").
+			WithOperation("record").
+			WithContext("tool", decision.Tool)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return l.ioError(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return l.ioError(err)
+	}
+	return nil
+}
+
+func (l *JSONLAuditLog) ioError(err error) error {
+	return agentErrors.Wrap(err, agentErrors.CodePolicyAudit, "failed to write audit log").
+		WithComponent("policy").
+		WithOperation("record").
+		WithContext("path", l.path)
+}
@@ -0,0 +1,258 @@
+package policy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Request is a tool call to authorize
+type Request struct {
+	Subject   string                 `json:"subject"`
+	Tool      string                 `json:"tool"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"`
+	// Time of the call; zero means now
+	Time time.Time `json:"time"`
+}
+
+// Approval is the answer to a require_approval decision
+type Approval struct {
+	ID       string `json:"id,omitempty"`
+	Approved bool   `json:"approved"`
+	By       string `json:"by,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Decision is the evaluated outcome for a request
+type Decision struct {
+	Request
+	// Effect is what the policy decided
+	Effect Effect `json:"effect"`
+	// Rule is the name of the deciding rule; empty for the default
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Approval is set for require_approval decisions that were put to an
+	// approver
+	Approval *Approval `json:"approval,omitempty"`
+	// Allowed is the final outcome
+	Allowed bool `json:"allowed"`
+}
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// WithAuditLog records every decision in log
+func WithAuditLog(log AuditLog) Option {
+	return func(e *Engine) {
+		e.audit = log
+	}
+}
+
+// WithApprover resolves require_approval decisions. Without an approver such
+// calls are denied.
+func WithApprover(approver Approver) Option {
+	return func(e *Engine) {
+		e.approver = approver
+	}
+}
+
+// WithClock overrides the time source
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) {
+		e.now = now
+	}
+}
+
+// Engine evaluates a policy and enforces its decisions
+type Engine struct {
+	mu       sync.RWMutex
+	policy   *compiledPolicy
+	audit    AuditLog
+	approver Approver
+	now      func() time.Time
+
+	quotaMu sync.Mutex
+	quotas  map[string]*quotaWindow
+}
+
+type quotaWindow struct {
+	start time.Time
+	count int
+}
+
+// NewEngine creates an engine for policy
+func NewEngine(policy *Policy, opts ...Option) (*Engine, error) {
+	e := &Engine{now: time.Now, quotas: make(map[string]*quotaWindow)}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if err := e.SetPolicy(policy); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetPolicy replaces the policy, e.g. after the file changed. Quota counters
+// are kept for rules with the same name.
+func (e *Engine) SetPolicy(policy *Policy) error {
+	compiled, err := compile(policy)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.policy = compiled
+	e.mu.Unlock()
+	return nil
+}
+
+// Evaluate decides a request without side effects: no approval is requested,
+// no quota is consumed and nothing is audited
+func (e *Engine) Evaluate(req *Request) *Decision {
+	decision, _ := e.evaluate(req)
+	return decision
+}
+
+// evaluate returns the decision and the deciding rule
+func (e *Engine) evaluate(req *Request) (*Decision, *compiledRule) {
+	if req.Time.IsZero() {
+		req.Time = e.now()
+	}
+	e.mu.RLock()
+	policy := e.policy
+	e.mu.RUnlock()
+
+	for _, rule := range policy.rules {
+		if !rule.matches(req) {
+			continue
+		}
+		decision := &Decision{Request: *req, Effect: rule.Effect, Rule: rule.Name, Reason: rule.Reason}
+		if decision.Reason == "" {
+			decision.Reason = "matched rule " + rule.Name
+		}
+		if rule.Quota != nil && rule.Effect != EffectDeny && e.quotaUsed(rule, req) {
+			decision.Effect = EffectDeny
+			decision.Reason = "quota of rule " + rule.Name + " exceeded"
+		}
+		return decision, rule
+	}
+	return &Decision{Request: *req, Effect: policy.fallback, Reason: "no rule matched"}, nil
+}
+
+// Authorize decides a request, asks for approval when required, consumes
+// quota and records the decision. It returns a CodePolicyDenied error unless
+// the call may proceed.
+func (e *Engine) Authorize(ctx context.Context, req *Request) (*Decision, error) {
+	decision, rule := e.evaluate(req)
+
+	switch decision.Effect {
+	case EffectAllow:
+		decision.Allowed = e.consume(rule, req)
+		if !decision.Allowed {
+			decision.Effect = EffectDeny
+			decision.Reason = "quota of rule " + rule.Name + " exceeded"
+		}
+	case EffectRequireApproval:
+		if e.approver == nil {
+			decision.Reason = "approval required but no approver is configured"
+			break
+		}
+		approval, err := e.approver.Approve(ctx, decision)
+		if err != nil {
+			approval = &Approval{Reason: err.Error()}
+		}
+		decision.Approval = approval
+		if approval.Approved {
+			decision.Allowed = e.consume(rule, req)
+			if !decision.Allowed {
+				decision.Reason = "quota of rule " + rule.Name + " exceeded"
+			}
+		}
+	}
+
+	if e.audit != nil {
+		if err := e.audit.Record(ctx, decision); err != nil {
+			return decision, agentErrors.Wrap(err, agentErrors.CodePolicyAudit, "failed to record policy decision").
+				WithComponent("policy").
+				WithOperation("authorize").
+				WithContext("tool", req.Tool).
+				WithContext("subject", req.Subject)
+		}
+	}
+
+	if !decision.Allowed {
+		return decision, denied(decision)
+	}
+	return decision, nil
+}
+
+// denied builds the error for a refused call
+func denied(d *Decision) error {
+	reason := d.Reason
+	if d.Approval != nil && !d.Approval.Approved {
+		reason = "approval rejected"
+		if d.Approval.Reason != "" {
+			reason += ": " + d.Approval.Reason
+		}
+	}
+	return agentErrors.New(agentErrors.CodePolicyDenied, "tool call denied by policy: "+reason).
+		WithComponent("policy").
+		WithOperation("authorize").
+		WithContext("tool", d.Tool).
+		WithContext("subject", d.Subject).
+		WithContext("rule", d.Rule)
+}
+
+// quotaKey identifies the counter of a rule for a request
+func quotaKey(rule *compiledRule, req *Request) string {
+	if rule.Quota.Scope == "global" {
+		return rule.Name
+	}
+	return rule.Name + "\x00" + req.Subject
+}
+
+// quotaUsed reports whether the rule's quota is exhausted for the request
+func (e *Engine) quotaUsed(rule *compiledRule, req *Request) bool {
+	e.quotaMu.Lock()
+	defer e.quotaMu.Unlock()
+	window := e.quotas[quotaKey(rule, req)]
+	return window != nil && req.Time.Sub(window.start) < rule.Quota.Per && window.count >= rule.Quota.Max
+}
+
+// consume takes one call from the rule's quota; it fails when the quota is
+// exhausted
+func (e *Engine) consume(rule *compiledRule, req *Request) bool {
+	if rule == nil || rule.Quota == nil {
+		return true
+	}
+	e.quotaMu.Lock()
+	defer e.quotaMu.Unlock()
+	key := quotaKey(rule, req)
+	window := e.quotas[key]
+	if window == nil || req.Time.Sub(window.start) >= rule.Quota.Per {
+		window = &quotaWindow{start: req.Time}
+		e.quotas[key] = window
+	}
+	if window.count >= rule.Quota.Max {
+		return false
+	}
+	window.count++
+	return true
+}
+
+// subjectKey is the context key of the calling subject
+type subjectKey struct{}
+
+// WithSubject attaches the calling subject (user, tenant or agent name) to
+// ctx for tool decorators
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the subject attached by WithSubject
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
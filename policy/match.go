@@ -0,0 +1,368 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// compiledPolicy is a validated policy ready for evaluation
+type compiledPolicy struct {
+	fallback Effect
+	rules    []*compiledRule
+}
+
+type compiledRule struct {
+	*Rule
+	args   []compiledPredicate
+	window *compiledWindow
+}
+
+type compiledPredicate struct {
+	*ArgPredicate
+	pattern  *regexp.Regexp
+	prefixes []string
+	verbs    map[string]bool
+}
+
+type compiledWindow struct {
+	days       map[time.Weekday]bool
+	start, end int // minutes since midnight
+	whole      bool
+	location   *time.Location
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// compile validates p
+func compile(p *Policy) (*compiledPolicy, error) {
+	compiled := &compiledPolicy{fallback: p.Default}
+	if compiled.fallback == "" {
+		compiled.fallback = EffectDeny
+	}
+	if !validEffect(compiled.fallback) {
+		return nil, invalid("invalid default effect %q", p.Default)
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if !validEffect(rule.Effect) {
+			return nil, invalid("rule %s: invalid effect %q", rule.Name, rule.Effect)
+		}
+		for _, pattern := range append(append([]string{}, rule.Subjects...), rule.Tools...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, invalid("rule %s: invalid glob %q", rule.Name, pattern)
+			}
+		}
+		if q := rule.Quota; q != nil {
+			if q.Max <= 0 || q.Per <= 0 {
+				return nil, invalid("rule %s: quota needs positive max and per", rule.Name)
+			}
+			if q.Scope != "" && q.Scope != "subject" && q.Scope != "global" {
+				return nil, invalid("rule %s: invalid quota scope %q", rule.Name, q.Scope)
+			}
+		}
+
+		cr := &compiledRule{Rule: rule}
+		for j := range rule.Args {
+			pred, err := compilePredicate(&rule.Args[j])
+			if err != nil {
+				return nil, invalid("rule %s: %v", rule.Name, err)
+			}
+			cr.args = append(cr.args, pred)
+		}
+		if rule.When != nil {
+			window, err := compileWindow(rule.When)
+			if err != nil {
+				return nil, invalid("rule %s: %v", rule.Name, err)
+			}
+			cr.window = window
+		}
+		compiled.rules = append(compiled.rules, cr)
+	}
+	return compiled, nil
+}
+
+func validEffect(effect Effect) bool {
+	return effect == EffectAllow || effect == EffectDeny || effect == EffectRequireApproval
+}
+
+func invalid(format string, args ...interface{}) error {
+	return agentErrors.New(agentErrors.CodeInvalidConfig, fmt.Sprintf(format, args...)).
+		WithComponent("policy").
+		WithOperation("compile")
+}
+
+func compilePredicate(p *ArgPredicate) (compiledPredicate, error) {
+	pred := compiledPredicate{ArgPredicate: p}
+	if p.Arg == "" {
+		return pred, fmt.Errorf("argument predicate without arg")
+	}
+	if p.Pattern != "" {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return pred, fmt.Errorf("invalid pattern for %s: %w", p.Arg, err)
+		}
+		pred.pattern = re
+	}
+	for _, host := range p.Hosts {
+		if _, err := path.Match(host, ""); err != nil {
+			return pred, fmt.Errorf("invalid host pattern %q", host)
+		}
+	}
+	for _, prefix := range p.PathPrefix {
+		pred.prefixes = append(pred.prefixes, filepath.Clean(prefix))
+	}
+	if len(p.SQLVerbs) > 0 {
+		pred.verbs = make(map[string]bool, len(p.SQLVerbs))
+		for _, verb := range p.SQLVerbs {
+			pred.verbs[strings.ToLower(verb)] = true
+		}
+	}
+	return pred, nil
+}
+
+func compileWindow(w *TimeWindow) (*compiledWindow, error) {
+	window := &compiledWindow{location: time.Local}
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q", w.Timezone)
+		}
+		window.location = loc
+	}
+	if len(w.Days) > 0 {
+		window.days = make(map[time.Weekday]bool, len(w.Days))
+		for _, day := range w.Days {
+			weekday, ok := dayNames[strings.ToLower(day)[:min(3, len(day))]]
+			if !ok {
+				return nil, fmt.Errorf("invalid day %q", day)
+			}
+			window.days[weekday] = true
+		}
+	}
+	if w.Start == "" && w.End == "" {
+		window.whole = true
+		return window, nil
+	}
+	var err error
+	if window.start, err = minutes(w.Start, 0); err != nil {
+		return nil, err
+	}
+	if window.end, err = minutes(w.End, 24*60); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// minutes parses "15:04" into minutes since midnight
+func minutes(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// matches reports whether the rule applies to the request
+func (r *compiledRule) matches(req *Request) bool {
+	if !matchAny(r.Subjects, req.Subject) || !matchAny(r.Tools, req.Tool) {
+		return false
+	}
+	if r.window != nil && !r.window.contains(req.Time) {
+		return false
+	}
+	for i := range r.args {
+		if !r.args[i].matches(req.Args) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny matches value against glob patterns; no patterns match everything
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *compiledWindow) contains(t time.Time) bool {
+	t = t.In(w.location)
+	if w.days != nil && !w.days[t.Weekday()] {
+		return false
+	}
+	if w.whole {
+		return true
+	}
+	now := t.Hour()*60 + t.Minute()
+	if w.start <= w.end {
+		return now >= w.start && now < w.end
+	}
+	return now >= w.start || now < w.end
+}
+
+func (p *compiledPredicate) matches(args map[string]interface{}) bool {
+	value, ok := lookup(args, p.Arg)
+	result := ok && p.check(value)
+	if p.Not {
+		return !result
+	}
+	return result
+}
+
+// check applies the constraints to a value, element-wise for lists
+func (p *compiledPredicate) check(value interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		if len(list) == 0 {
+			return false
+		}
+		for _, item := range list {
+			if !p.check(item) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(p.OneOf) > 0 && !oneOf(p.OneOf, value) {
+		return false
+	}
+	if p.pattern == nil && p.prefixes == nil && p.Hosts == nil && p.verbs == nil {
+		return true
+	}
+	text, ok := value.(string)
+	if !ok {
+		return false
+	}
+	if p.pattern != nil && !p.pattern.MatchString(text) {
+		return false
+	}
+	if p.prefixes != nil && !underPrefix(p.prefixes, text) {
+		return false
+	}
+	if p.Hosts != nil && !hostAllowed(p.Hosts, text) {
+		return false
+	}
+	if p.verbs != nil && !verbsAllowed(p.verbs, text) {
+		return false
+	}
+	return true
+}
+
+// lookup resolves a dotted argument name
+func lookup(args map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := args[name]; ok {
+		return value, true
+	}
+	current := interface{}(args)
+	for _, part := range strings.Split(name, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func oneOf(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) || fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// underPrefix reports whether the cleaned path lies under one of the
+// prefixes; ".." segments are resolved before comparing
+func underPrefix(prefixes []string, value string) bool {
+	cleaned := filepath.Clean(value)
+	for _, prefix := range prefixes {
+		if cleaned == prefix {
+			return true
+		}
+		base := prefix
+		if !strings.HasSuffix(base, string(filepath.Separator)) {
+			base += string(filepath.Separator)
+		}
+		if strings.HasPrefix(cleaned, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed reports whether the URL host matches one of the patterns
+func hostAllowed(patterns []string, value string) bool {
+	u, err := url.Parse(value)
+	if err == nil && u.Host == "" {
+		u, err = url.Parse("//" + value)
+	}
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	sqlComments = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+	sqlWord     = regexp.MustCompile(`^[A-Za-z]+`)
+	sqlDML      = regexp.MustCompile(`(?i)\b(insert|update|delete|merge)\b`)
+)
+
+// verbsAllowed reports whether every statement starts with an allowed verb.
+// WITH statements take the verb of their main query.
+func verbsAllowed(verbs map[string]bool, sql string) bool {
+	sql = sqlComments.ReplaceAllString(sql, " ")
+	found := false
+	for _, statement := range strings.Split(sql, ";") {
+		statement = strings.TrimLeft(strings.TrimSpace(statement), "(")
+		if statement == "" {
+			continue
+		}
+		found = true
+		verb := strings.ToLower(sqlWord.FindString(statement))
+		if verb == "with" {
+			verb = "select"
+			if dml := sqlDML.FindString(statement); dml != "" {
+				verb = strings.ToLower(dml)
+			}
+		}
+		if !verbs[verb] {
+			return false
+		}
+	}
+	return found
+}
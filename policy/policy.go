@@ -0,0 +1,142 @@
+// Package policy authorizes tool calls against declarative rules.
+//
+// A Policy is an ordered list of rules loaded from YAML. The first rule whose
+// subjects, tools, argument predicates and time window match a call decides
+// it; calls matching no rule get the policy default (deny unless set):
+//
+//	default: deny
+//	rules:
+//	  - name: workspace-files
+//	    effect: allow
+//	    tools: [read_file, write_file]
+//	    args:
+//	      - arg: path
+//	        path_prefix: [/workspace]
+//	  - name: internal-apis
+//	    effect: allow
+//	    tools: [http_request]
+//	    args:
+//	      - arg: url
+//	        hosts: ["*.internal.example.com"]
+//	    quota: {max: 100, per: 1h}
+//	  - name: production-writes
+//	    effect: require_approval
+//	    subjects: ["ops-*"]
+//	    tools: [sql_exec]
+//	    when: {days: [mon, tue, wed, thu, fri], start: "09:00", end: "18:00"}
+//
+// An Engine evaluates the policy, asks an Approver (typically backed by
+// core.InterruptManager) for require_approval decisions and records every
+// decision in an AuditLog. Engine.Tool decorates an interfaces.Tool,
+// Engine.MCPTool an MCP tool, and toolbox.StandardToolBox.SetPolicyEngine
+// enforces the policy for a whole MCP toolbox. The subject of a call is
+// taken from the context (WithSubject).
+package policy
+
+import (
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Effect is the outcome of a rule
+type Effect string
+
+// Effects
+const (
+	EffectAllow           Effect = "allow"
+	EffectDeny            Effect = "deny"
+	EffectRequireApproval Effect = "require_approval"
+)
+
+// Policy is an ordered rule set
+type Policy struct {
+	// Default applies when no rule matches; empty means deny
+	Default Effect `json:"default,omitempty" yaml:"default,omitempty"`
+	Rules   []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule matches tool calls and decides them. Empty Subjects or Tools match
+// everything; Subjects and Tools are glob patterns as in path.Match.
+type Rule struct {
+	Name     string         `json:"name" yaml:"name"`
+	Effect   Effect         `json:"effect" yaml:"effect"`
+	Subjects []string       `json:"subjects,omitempty" yaml:"subjects,omitempty"`
+	Tools    []string       `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Args     []ArgPredicate `json:"args,omitempty" yaml:"args,omitempty"`
+	When     *TimeWindow    `json:"when,omitempty" yaml:"when,omitempty"`
+	Quota    *Quota         `json:"quota,omitempty" yaml:"quota,omitempty"`
+	// Reason is reported with the decision
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// ArgPredicate constrains one argument. All constraints that are set must
+// hold; list-valued arguments must satisfy them element-wise. A missing
+// argument never matches (and therefore always matches when Not is set).
+type ArgPredicate struct {
+	// Arg is the argument name; dots address nested objects ("options.path")
+	Arg string `json:"arg" yaml:"arg"`
+	// PathPrefix requires a cleaned file path under one of the prefixes
+	PathPrefix []string `json:"path_prefix,omitempty" yaml:"path_prefix,omitempty"`
+	// Hosts requires a URL whose host matches one of the glob patterns
+	Hosts []string `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	// SQLVerbs requires every statement of a SQL text to start with one of
+	// the verbs (select, insert, update, delete, ...)
+	SQLVerbs []string `json:"sql_verbs,omitempty" yaml:"sql_verbs,omitempty"`
+	// Pattern is a regular expression the value must match
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// OneOf lists the allowed values
+	OneOf []interface{} `json:"one_of,omitempty" yaml:"one_of,omitempty"`
+	// Not inverts the predicate
+	Not bool `json:"not,omitempty" yaml:"not,omitempty"`
+}
+
+// TimeWindow limits a rule to days of the week and a time of day range.
+// An End before Start spans midnight.
+type TimeWindow struct {
+	// Days are three-letter English day names; empty means every day
+	Days []string `json:"days,omitempty" yaml:"days,omitempty"`
+	// Start and End are "15:04" times; both empty means the whole day
+	Start string `json:"start,omitempty" yaml:"start,omitempty"`
+	End   string `json:"end,omitempty" yaml:"end,omitempty"`
+	// Timezone is an IANA name; empty means local time
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+}
+
+// Quota limits how often a rule may allow calls. Once the quota is used up
+// the rule denies until the window rolls over.
+type Quota struct {
+	Max int           `json:"max" yaml:"max"`
+	Per time.Duration `json:"per" yaml:"per"`
+	// Scope is "subject" (default, one counter per subject) or "global"
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty"`
+}
+
+// ParsePolicy parses a YAML (or JSON) policy
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidConfig, "failed to parse policy").
+			WithComponent("policy").
+			WithOperation("parse")
+	}
+	if _, err := compile(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadPolicy reads a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidConfig, "failed to read policy").
+			WithComponent("policy").
+			WithOperation("load").
+			WithContext("path", path)
+	}
+	return ParsePolicy(data)
+}
@@ -0,0 +1,248 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+	mcpcore "github.com/kart-io/goagent/mcp/core"
+)
+
+const testPolicy = `
+default: deny
+rules:
+  - name: no-secrets
+    effect: deny
+    tools: [read_file]
+    args:
+      - arg: path
+        pattern: '\.env$'
+    reason: secrets are off limits
+  - name: workspace-files
+    effect: allow
+    tools: [read_file, write_file]
+    args:
+      - arg: path
+        path_prefix: [/workspace]
+  - name: internal-apis
+    effect: allow
+    tools: [http_*]
+    args:
+      - arg: request.url
+        hosts: ["*.internal.example.com", api.example.com]
+    quota: {max: 2, per: 1h}
+  - name: readonly-sql
+    effect: allow
+    subjects: [analyst-*]
+    tools: [sql]
+    args:
+      - arg: query
+        sql_verbs: [select]
+  - name: office-hours-writes
+    effect: require_approval
+    subjects: [ops-*]
+    tools: [sql]
+    when: {days: [mon, tue, wed, thu, fri], start: "09:00", end: "18:00", timezone: UTC}
+`
+
+// monday10 is a Monday at 10:00 UTC
+var monday10 = time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+
+func newTestEngine(t *testing.T, opts ...Option) *Engine {
+	t.Helper()
+	p, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	engine, err := NewEngine(p, append([]Option{WithClock(func() time.Time { return monday10 })}, opts...)...)
+	require.NoError(t, err)
+	return engine
+}
+
+func TestEvaluate(t *testing.T) {
+	engine := newTestEngine(t)
+
+	tests := []struct {
+		name   string
+		req    Request
+		effect Effect
+		rule   string
+	}{
+		{"path under prefix", Request{Tool: "read_file", Args: map[string]interface{}{"path": "/workspace/src/main.go"}}, EffectAllow, "workspace-files"},
+		{"path traversal", Request{Tool: "read_file", Args: map[string]interface{}{"path": "/workspace/../etc/passwd"}}, EffectDeny, ""},
+		{"sibling prefix", Request{Tool: "read_file", Args: map[string]interface{}{"path": "/workspace2/a"}}, EffectDeny, ""},
+		{"earlier deny wins", Request{Tool: "read_file", Args: map[string]interface{}{"path": "/workspace/.env"}}, EffectDeny, "no-secrets"},
+		{"missing arg", Request{Tool: "write_file"}, EffectDeny, ""},
+		{"allowed host", Request{Tool: "http_get", Args: map[string]interface{}{"request": map[string]interface{}{"url": "https://billing.internal.example.com/v1"}}}, EffectAllow, "internal-apis"},
+		{"foreign host", Request{Tool: "http_get", Args: map[string]interface{}{"request": map[string]interface{}{"url": "https://evil.example.org"}}}, EffectDeny, ""},
+		{"select", Request{Subject: "analyst-1", Tool: "sql", Args: map[string]interface{}{"query": "-- report\nSELECT * FROM orders"}}, EffectAllow, "readonly-sql"},
+		{"cte select", Request{Subject: "analyst-1", Tool: "sql", Args: map[string]interface{}{"query": "WITH x AS (SELECT 1) SELECT * FROM x"}}, EffectAllow, "readonly-sql"},
+		{"stacked delete", Request{Subject: "analyst-1", Tool: "sql", Args: map[string]interface{}{"query": "SELECT 1; DELETE FROM orders"}}, EffectDeny, ""},
+		{"ops in office hours", Request{Subject: "ops-1", Tool: "sql", Args: map[string]interface{}{"query": "DELETE FROM orders"}}, EffectRequireApproval, "office-hours-writes"},
+		{"ops at night", Request{Subject: "ops-1", Tool: "sql", Time: monday10.Add(12 * time.Hour)}, EffectDeny, ""},
+		{"ops on saturday", Request{Subject: "ops-1", Tool: "sql", Time: monday10.AddDate(0, 0, 5)}, EffectDeny, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			decision := engine.Evaluate(&req)
+			assert.Equal(t, tt.effect, decision.Effect)
+			assert.Equal(t, tt.rule, decision.Rule)
+		})
+	}
+}
+
+func TestAuthorizeQuotaAndAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "decisions.jsonl")
+	audit, err := NewJSONLAuditLog(path)
+	require.NoError(t, err)
+	engine := newTestEngine(t, WithAuditLog(audit))
+
+	req := func(subject string) *Request {
+		return &Request{Subject: subject, Tool: "http_post", Args: map[string]interface{}{"request": map[string]interface{}{"url": "api.example.com/x"}}}
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		decision, err := engine.Authorize(ctx, req("alice"))
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+	decision, err := engine.Authorize(ctx, req("alice"))
+	require.Error(t, err)
+	assert.Equal(t, agentErrors.CodePolicyDenied, agentErrors.GetCode(err))
+	assert.Contains(t, decision.Reason, "quota")
+
+	// quotas are per subject by default
+	_, err = engine.Authorize(ctx, req("bob"))
+	require.NoError(t, err)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var lines []Decision
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var d Decision
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &d))
+		lines = append(lines, d)
+	}
+	require.Len(t, lines, 4)
+	assert.False(t, lines[2].Allowed)
+	assert.Equal(t, "bob", lines[3].Subject)
+}
+
+func TestAuthorizeApproval(t *testing.T) {
+	sqlReq := func() *Request {
+		return &Request{Subject: "ops-1", Tool: "sql", Args: map[string]interface{}{"query": "DELETE FROM orders"}}
+	}
+
+	t.Run("no approver", func(t *testing.T) {
+		_, err := newTestEngine(t).Authorize(context.Background(), sqlReq())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no approver")
+	})
+
+	t.Run("interrupt manager", func(t *testing.T) {
+		manager := core.NewInterruptManager(nil)
+		manager.OnInterruptCreated(func(interrupt *core.Interrupt) {
+			approved := interrupt.Context["subject"] == "ops-1"
+			go func() {
+				_ = manager.RespondToInterrupt(interrupt.ID, &core.InterruptResponse{Approved: approved, RespondedBy: "lead"})
+			}()
+		})
+		audit := NewMemoryAuditLog()
+		engine := newTestEngine(t, WithApprover(NewInterruptApprover(manager, time.Second)), WithAuditLog(audit))
+
+		decision, err := engine.Authorize(context.Background(), sqlReq())
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		require.NotNil(t, decision.Approval)
+		assert.Equal(t, "lead", decision.Approval.By)
+		assert.NotEmpty(t, decision.Approval.ID)
+
+		other := sqlReq()
+		other.Subject = "ops-2"
+		_, err = engine.Authorize(context.Background(), other)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "approval rejected")
+		assert.Len(t, audit.Decisions(), 2)
+	})
+
+	t.Run("approver error", func(t *testing.T) {
+		approver := ApproverFunc(func(ctx context.Context, decision *Decision) (*Approval, error) {
+			return nil, context.DeadlineExceeded
+		})
+		decision, err := newTestEngine(t, WithApprover(approver)).Authorize(context.Background(), sqlReq())
+		require.Error(t, err)
+		assert.False(t, decision.Allowed)
+	})
+}
+
+func TestAuditFailureDenies(t *testing.T) {
+	failing := AuditLogFunc(func(ctx context.Context, decision *Decision) error {
+		return os.ErrPermission
+	})
+	_, err := newTestEngine(t, WithAuditLog(failing)).Authorize(context.Background(),
+		&Request{Tool: "read_file", Args: map[string]interface{}{"path": "/workspace/a"}})
+	require.Error(t, err)
+	assert.Equal(t, agentErrors.CodePolicyAudit, agentErrors.GetCode(err))
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for _, doc := range []string{
+		"rules: [{name: a, effect: maybe}]",
+		"default: sometimes",
+		"rules: [{name: a, effect: allow, args: [{arg: x, pattern: '('}]}]",
+		"rules: [{name: a, effect: allow, when: {start: '9am'}}]",
+		"rules: [{name: a, effect: allow, quota: {max: 0, per: 1h}}]",
+		"rules: [{name: a, effect: allow, tools: ['[']}]",
+	} {
+		_, err := ParsePolicy([]byte(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+type echoTool struct{ calls int }
+
+func (t *echoTool) Name() string        { return "read_file" }
+func (t *echoTool) Description() string { return "reads a file" }
+func (t *echoTool) ArgsSchema() string  { return `{"type":"object"}` }
+
+func (t *echoTool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	t.calls++
+	return &interfaces.ToolOutput{Result: input.Args["path"], Success: true}, nil
+}
+
+func TestToolDecorators(t *testing.T) {
+	audit := NewMemoryAuditLog()
+	engine := newTestEngine(t, WithAuditLog(audit))
+	inner := &echoTool{}
+	tool := engine.Tools([]interfaces.Tool{inner})[0]
+
+	ctx := WithSubject(context.Background(), "alice")
+	out, err := tool.Invoke(ctx, &interfaces.ToolInput{Args: map[string]interface{}{"path": "/workspace/a.txt"}})
+	require.NoError(t, err)
+	assert.Equal(t, "/workspace/a.txt", out.Result)
+
+	_, err = tool.Invoke(context.Background(), &interfaces.ToolInput{Args: map[string]interface{}{"path": "/etc/passwd"}, CallerID: "bob"})
+	require.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+
+	decisions := audit.Decisions()
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "alice", decisions[0].Subject)
+	assert.Equal(t, "bob", decisions[1].Subject)
+
+	mcpTool := engine.MCPTool(mcpcore.NewBaseTool("write_file", "writes a file", "filesystem", &mcpcore.ToolSchema{Type: "object"}))
+	_, err = mcpTool.Execute(ctx, map[string]interface{}{"path": "/tmp/x"})
+	require.Error(t, err)
+	assert.Equal(t, agentErrors.CodePolicyDenied, agentErrors.GetCode(err))
+}
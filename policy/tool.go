@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/interfaces"
+	mcpcore "github.com/kart-io/goagent/mcp/core"
+)
+
+// Tool is an interfaces.Tool that authorizes every call before running it
+type Tool struct {
+	engine *Engine
+	inner  interfaces.Tool
+}
+
+// Tool decorates a tool with policy enforcement. The subject is taken from
+// the call context (WithSubject), falling back to ToolInput.CallerID.
+func (e *Engine) Tool(inner interfaces.Tool) *Tool {
+	return &Tool{engine: e, inner: inner}
+}
+
+// Tools decorates every tool of the slice
+func (e *Engine) Tools(tools []interfaces.Tool) []interfaces.Tool {
+	wrapped := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = e.Tool(tool)
+	}
+	return wrapped
+}
+
+// Name returns the wrapped tool name
+func (t *Tool) Name() string { return t.inner.Name() }
+
+// Description returns the wrapped tool description
+func (t *Tool) Description() string { return t.inner.Description() }
+
+// ArgsSchema returns the wrapped tool schema
+func (t *Tool) ArgsSchema() string { return t.inner.ArgsSchema() }
+
+// Invoke authorizes the call and runs the tool; denied calls return a
+// CodePolicyDenied error without running it
+func (t *Tool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	req := &Request{Subject: SubjectFromContext(ctx), Tool: t.inner.Name()}
+	if input != nil {
+		req.Args = input.Args
+		if req.Subject == "" {
+			req.Subject = input.CallerID
+		}
+	}
+	if _, err := t.engine.Authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	return t.inner.Invoke(ctx, input)
+}
+
+// MCPTool is an MCP tool that authorizes every call before running it
+type MCPTool struct {
+	mcpcore.Tool
+	engine *Engine
+}
+
+// MCPTool decorates an MCP tool with policy enforcement. The subject is taken
+// from the call context (WithSubject).
+func (e *Engine) MCPTool(inner mcpcore.Tool) *MCPTool {
+	return &MCPTool{Tool: inner, engine: e}
+}
+
+// Execute authorizes the call and runs the tool
+func (t *MCPTool) Execute(ctx context.Context, input map[string]interface{}) (*mcpcore.ToolResult, error) {
+	req := &Request{Subject: SubjectFromContext(ctx), Tool: t.Name(), Args: input}
+	if _, err := t.engine.Authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	return t.Tool.Execute(ctx, input)
+}
@@ -0,0 +1,62 @@
+package privacy
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/core/checkpoint"
+	agentstate "github.com/kart-io/goagent/core/state"
+)
+
+// Checkpointer wraps a checkpoint.Checkpointer, recording which threads
+// belong to the subject of the request so their checkpoints can be erased
+type Checkpointer struct {
+	inner checkpoint.Checkpointer
+	guard
+}
+
+// NewCheckpointer wraps a checkpointer
+func NewCheckpointer(inner checkpoint.Checkpointer, opts ...Option) *Checkpointer {
+	return &Checkpointer{
+		inner: inner,
+		guard: newGuard("private_checkpointer", nil, opts),
+	}
+}
+
+// Save persists the state of a thread and records the thread for the subject
+func (c *Checkpointer) Save(ctx context.Context, threadID string, state agentstate.State) error {
+	if err := c.inner.Save(ctx, threadID, state); err != nil {
+		return err
+	}
+	return c.track(ctx, threadID)
+}
+
+// Load retrieves the saved state of a thread
+func (c *Checkpointer) Load(ctx context.Context, threadID string) (agentstate.State, error) {
+	return c.inner.Load(ctx, threadID)
+}
+
+// List returns information about all saved checkpoints
+func (c *Checkpointer) List(ctx context.Context) ([]checkpoint.CheckpointInfo, error) {
+	return c.inner.List(ctx)
+}
+
+// Delete removes the checkpoint of a thread
+func (c *Checkpointer) Delete(ctx context.Context, threadID string) error {
+	return c.inner.Delete(ctx, threadID)
+}
+
+// Exists checks if a checkpoint exists for a thread
+func (c *Checkpointer) Exists(ctx context.Context, threadID string) (bool, error) {
+	return c.inner.Exists(ctx, threadID)
+}
+
+// ForgetSubject deletes the checkpoints of the threads saved for the subject
+func (c *Checkpointer) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	return c.forget(ctx, subjectID, func(threadID string) error {
+		exists, err := c.inner.Exists(ctx, threadID)
+		if err != nil || !exists {
+			return err
+		}
+		return c.inner.Delete(ctx, threadID)
+	})
+}
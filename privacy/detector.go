@@ -0,0 +1,314 @@
+// Package privacy provides privacy controls for agent memory and LLM calls.
+//
+// It detects personally identifiable information (PII) with pluggable
+// detectors, masks it or replaces it with reversible pseudonymization
+// tokens before it reaches memory, vector stores and LLMs, and erases all
+// data of a data subject across components with ForgetSubject.
+//
+// Data is attributed to a subject through interfaces.WithSubject:
+//
+//	ctx = interfaces.WithSubject(ctx, "user-42")
+//	_ = mem.AddConversation(ctx, conv) // redacted and tagged with user-42
+//
+//	report, err := eraser.ForgetSubject(ctx, "user-42")
+package privacy
+
+import (
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PIIType identifies a kind of personal data
+type PIIType string
+
+const (
+	// PIIEmail is an email address
+	PIIEmail PIIType = "EMAIL"
+	// PIIPhone is a phone number
+	PIIPhone PIIType = "PHONE"
+	// PIICreditCard is a payment card number
+	PIICreditCard PIIType = "CREDIT_CARD"
+	// PIIIBAN is an international bank account number
+	PIIIBAN PIIType = "IBAN"
+)
+
+// Match is a piece of PII found in a text
+type Match struct {
+	Type  PIIType `json:"type"`
+	Value string  `json:"value"`
+	Start int     `json:"start"`
+	End   int     `json:"end"`
+}
+
+// Detector finds PII of one type in text
+type Detector interface {
+	// Type returns the PII type the detector finds
+	Type() PIIType
+
+	// Detect returns the matches found in text, in order of appearance
+	Detect(text string) []Match
+}
+
+// RegexDetector detects PII with a regular expression and an optional
+// validator that rejects false positives (e.g. failed checksums)
+type RegexDetector struct {
+	piiType  PIIType
+	pattern  *regexp.Regexp
+	validate func(value string) bool
+	// standsAlone rejects matches that are part of a longer token, which
+	// RE2 cannot express without lookbehind
+	standsAlone func(text string, start, end int) bool
+}
+
+// NewRegexDetector creates a detector from a regular expression.
+// validate may be nil to accept every match.
+func NewRegexDetector(piiType PIIType, pattern string, validate func(value string) bool) (*RegexDetector, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexDetector{
+		piiType:  piiType,
+		pattern:  re,
+		validate: validate,
+	}, nil
+}
+
+// Type returns the PII type the detector finds
+func (d *RegexDetector) Type() PIIType {
+	return d.piiType
+}
+
+// Detect returns the validated matches found in text
+func (d *RegexDetector) Detect(text string) []Match {
+	var matches []Match
+	for _, loc := range d.pattern.FindAllStringIndex(text, -1) {
+		value := text[loc[0]:loc[1]]
+		if d.validate != nil && !d.validate(value) {
+			continue
+		}
+		if d.standsAlone != nil && !d.standsAlone(text, loc[0], loc[1]) {
+			continue
+		}
+		matches = append(matches, Match{
+			Type:  d.piiType,
+			Value: value,
+			Start: loc[0],
+			End:   loc[1],
+		})
+	}
+	return matches
+}
+
+var (
+	emailPattern      = `[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`
+	phonePattern      = `(?:\+\d{1,3}[\s.\-]?(?:\(\d{1,4}\)[\s.\-]?)?|\(\d{1,4}\)[\s.\-]?|\b)\d{2,4}(?:[\s.\-]?\d{2,4}){2,4}\b`
+	creditCardPattern = `\b(?:\d[ \-]?){12,18}\d\b`
+	ibanPattern       = `\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`
+	isoDatePattern    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	ipv4Pattern       = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}$`)
+)
+
+// EmailDetector detects email addresses
+func EmailDetector() *RegexDetector {
+	return mustRegexDetector(PIIEmail, emailPattern, nil)
+}
+
+// PhoneDetector detects phone numbers with 7 to 15 digits (the E.164 range)
+// that stand alone and start with a + or parentheses, or are written in the
+// national layout ending in a 3-digit and a 4-digit group with one repeated
+// separator, such as 415.555.2671
+func PhoneDetector() *RegexDetector {
+	detector := mustRegexDetector(PIIPhone, phonePattern, validPhone)
+	detector.standsAlone = phoneStandsAlone
+	return detector
+}
+
+// CreditCardDetector detects payment card numbers passing the Luhn check
+func CreditCardDetector() *RegexDetector {
+	return mustRegexDetector(PIICreditCard, creditCardPattern, ValidLuhn)
+}
+
+// IBANDetector detects IBANs passing the ISO 13616 mod-97 check
+func IBANDetector() *RegexDetector {
+	return mustRegexDetector(PIIIBAN, ibanPattern, ValidIBAN)
+}
+
+// DefaultDetectors returns the built-in detectors.
+// Checksum-validated detectors come first so they win overlapping matches.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		CreditCardDetector(),
+		IBANDetector(),
+		EmailDetector(),
+		PhoneDetector(),
+	}
+}
+
+// Detect runs the detectors over text and returns non-overlapping matches
+// ordered by position. When matches overlap, the earlier detector wins.
+func Detect(text string, detectors []Detector) []Match {
+	var accepted []Match
+	for _, detector := range detectors {
+		for _, match := range detector.Detect(text) {
+			overlaps := false
+			for _, existing := range accepted {
+				if match.Start < existing.End && existing.Start < match.End {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				accepted = append(accepted, match)
+			}
+		}
+	}
+
+	sort.Slice(accepted, func(i, j int) bool {
+		return accepted[i].Start < accepted[j].Start
+	})
+	return accepted
+}
+
+// ValidLuhn reports whether a card number (spaces and dashes allowed)
+// has 13 to 19 digits and passes the Luhn checksum
+func ValidLuhn(value string) bool {
+	digits := keepDigits(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ValidIBAN reports whether value (spaces allowed) is a well-formed IBAN
+// passing the mod-97 checksum
+func ValidIBAN(value string) bool {
+	iban := strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// Move the country code and check digits to the end and convert
+	// letters to numbers (A=10 ... Z=35)
+	var numeric strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			numeric.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			numeric.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(numeric.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validPhone rejects numbers outside the E.164 digit range, ISO dates and
+// dotted-quad IP addresses. Without a + or parentheses, runs of digit groups
+// are more often times, IDs or amounts than phone numbers, so the groups must
+// be split by one repeated separator and end in a 3-digit and a 4-digit group.
+func validPhone(value string) bool {
+	digits := keepDigits(value)
+	if len(digits) < 7 || len(digits) > 15 || len(digits) == len(value) {
+		return false
+	}
+	if isoDatePattern.MatchString(value) || ipv4Pattern.MatchString(value) {
+		return false
+	}
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "(") {
+		return true
+	}
+	return nationalPhone(value)
+}
+
+// nationalPhone reports whether value is digit groups split by a single
+// repeated separator, ending in a 3-digit and a 4-digit group
+func nationalPhone(value string) bool {
+	var groups []int
+	var separator rune
+	length := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			length++
+			continue
+		}
+		if length == 0 || (separator != 0 && r != separator) {
+			return false
+		}
+		separator = r
+		groups = append(groups, length)
+		length = 0
+	}
+	groups = append(groups, length)
+
+	n := len(groups)
+	return n >= 3 && groups[n-2] == 3 && groups[n-1] == 4
+}
+
+// phoneStandsAlone rejects phone matches glued to a word or continuing a
+// longer dotted or dashed token, such as 555-2671 in x415-555-2671
+func phoneStandsAlone(text string, start, end int) bool {
+	at := func(i int) byte {
+		if i < 0 || i >= len(text) {
+			return ' '
+		}
+		return text[i]
+	}
+	if isWordByte(at(start-1)) || isWordByte(at(end)) {
+		return false
+	}
+	if prev := at(start - 1); (prev == '-' || prev == '.') && isWordByte(at(start-2)) {
+		return false
+	}
+	if next := at(end); (next == '-' || next == '.') && isWordByte(at(end+1)) {
+		return false
+	}
+	return true
+}
+
+// isWordByte reports whether b is an ASCII letter, digit or underscore
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// keepDigits strips everything but digits
+func keepDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func mustRegexDetector(piiType PIIType, pattern string, validate func(string) bool) *RegexDetector {
+	detector, err := NewRegexDetector(piiType, pattern, validate)
+	if err != nil {
+		panic(err)
+	}
+	return detector
+}
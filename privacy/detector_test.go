@@ -0,0 +1,86 @@
+package privacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidators(t *testing.T) {
+	assert.True(t, ValidLuhn("4111 1111 1111 1111"))
+	assert.True(t, ValidLuhn("5500-0000-0000-0004"))
+	assert.False(t, ValidLuhn("4111 1111 1111 1112"))
+	assert.False(t, ValidLuhn("1234"))
+
+	assert.True(t, ValidIBAN("DE89 3704 0044 0532 0130 00"))
+	assert.True(t, ValidIBAN("GB82WEST12345698765432"))
+	assert.False(t, ValidIBAN("DE89 3704 0044 0532 0130 01"))
+	assert.False(t, ValidIBAN("DE89"))
+}
+
+func TestDetect(t *testing.T) {
+	text := "Mail jane.doe@example.com or call +1 415-555-2671. " +
+		"Card 4111 1111 1111 1111, IBAN DE89 3704 0044 0532 0130 00, " +
+		"order 4111 1111 1111 1112 placed on 2024-01-15."
+
+	matches := Detect(text, DefaultDetectors())
+
+	found := make(map[PIIType][]string)
+	for _, match := range matches {
+		assert.Equal(t, match.Value, text[match.Start:match.End])
+		found[match.Type] = append(found[match.Type], match.Value)
+	}
+	assert.Equal(t, []string{"jane.doe@example.com"}, found[PIIEmail])
+	assert.Equal(t, []string{"+1 415-555-2671"}, found[PIIPhone])
+	assert.Equal(t, []string{"4111 1111 1111 1111"}, found[PIICreditCard])
+	assert.Equal(t, []string{"DE89 3704 0044 0532 0130 00"}, found[PIIIBAN])
+
+	for i := 1; i < len(matches); i++ {
+		assert.LessOrEqual(t, matches[i-1].End, matches[i].Start, "matches do not overlap")
+	}
+}
+
+func TestPhoneDetector(t *testing.T) {
+	detector := PhoneDetector()
+	for _, phone := range []string{
+		"+1 415-555-2671",
+		"(415) 555-2671",
+		"415.555.2671",
+		"+14155552671",
+		"+49 (30) 1234567",
+	} {
+		matches := detector.Detect("call " + phone + " today")
+		if assert.Len(t, matches, 1, phone) {
+			assert.Equal(t, phone, matches[0].Value)
+		}
+	}
+
+	for _, text := range []string{
+		"server 192.168.10.100 is down",
+		"order 4155552671 shipped",
+		"ref x415-555-2671",
+		"key abc+1 415-555-2671",
+		"code 415-555-2671abc",
+		"build 2024-01-15",
+		"id 12345678901234567890",
+		"at 12:30:45 10 20 30",
+		"PR #1234 5678 9012",
+		"scores 10 20 30 40",
+		"mixed 415-555.2671",
+	} {
+		assert.Empty(t, detector.Detect(text), text)
+	}
+}
+
+func TestRegexDetector_Custom(t *testing.T) {
+	detector, err := NewRegexDetector("EMPLOYEE_ID", `\bEMP-\d{6}\b`, nil)
+	require.NoError(t, err)
+
+	matches := Detect("ticket from EMP-004211", append(DefaultDetectors(), detector))
+	require.Len(t, matches, 1)
+	assert.Equal(t, PIIType("EMPLOYEE_ID"), matches[0].Type)
+
+	_, err = NewRegexDetector("BROKEN", `(`, nil)
+	assert.Error(t, err)
+}
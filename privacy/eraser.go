@@ -0,0 +1,151 @@
+package privacy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// ForgetterFunc adapts a function to interfaces.SubjectForgetter
+type ForgetterFunc func(ctx context.Context, subjectID string) (int, error)
+
+// ForgetSubject calls f
+func (f ForgetterFunc) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	return f(ctx, subjectID)
+}
+
+// ForgetResult is the outcome of erasing a subject from one component
+type ForgetResult struct {
+	Component string        `json:"component"`
+	Removed   int           `json:"removed"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// ForgetReport is the audit record of a ForgetSubject request
+type ForgetReport struct {
+	SubjectID    string          `json:"subject_id"`
+	StartedAt    time.Time       `json:"started_at"`
+	CompletedAt  time.Time       `json:"completed_at"`
+	Results      []*ForgetResult `json:"results"`
+	TotalRemoved int             `json:"total_removed"`
+}
+
+// Succeeded reports whether every component erased the subject
+func (r *ForgetReport) Succeeded() bool {
+	for _, result := range r.Results {
+		if result.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the components that failed to erase the subject
+func (r *ForgetReport) Failed() []string {
+	var failed []string
+	for _, result := range r.Results {
+		if result.Error != "" {
+			failed = append(failed, result.Component)
+		}
+	}
+	return failed
+}
+
+// Eraser fans a right-to-be-forgotten request out to every registered
+// component holding user data: memory managers, vector stores,
+// checkpointers, LLM and tool caches and token vaults.
+//
+// Example:
+//
+//	eraser := privacy.NewEraser().
+//	    Register("memory", privateMemory).
+//	    Register("documents", privateVectorStore).
+//	    Register("checkpoints", privateCheckpointer).
+//	    Register("llm_cache", semanticCache).
+//	    Register("tool_cache", toolCache).
+//	    Register("tokens", vault)
+//
+//	report, err := eraser.ForgetSubject(ctx, "user-42")
+type Eraser struct {
+	mu         sync.RWMutex
+	components []string
+	forgetters map[string]interfaces.SubjectForgetter
+}
+
+// NewEraser creates an eraser without components
+func NewEraser() *Eraser {
+	return &Eraser{
+		forgetters: make(map[string]interfaces.SubjectForgetter),
+	}
+}
+
+// Register adds a component under a name used in the audit report.
+// Registering a name again replaces the component. Components are erased
+// in registration order, so register token vaults last to keep tokens
+// resolvable until the data using them is gone.
+func (e *Eraser) Register(name string, forgetter interfaces.SubjectForgetter) *Eraser {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.forgetters[name]; !exists {
+		e.components = append(e.components, name)
+	}
+	e.forgetters[name] = forgetter
+	return e
+}
+
+// ForgetSubject erases the subject from every registered component.
+//
+// All components are attempted even if some fail; the report records the
+// outcome of each, and an error is returned when any component failed.
+func (e *Eraser) ForgetSubject(ctx context.Context, subjectID string) (*ForgetReport, error) {
+	if subjectID == "" {
+		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "subject id is required").
+			WithComponent("eraser").
+			WithOperation("forget_subject")
+	}
+
+	e.mu.RLock()
+	components := append([]string(nil), e.components...)
+	forgetters := make(map[string]interfaces.SubjectForgetter, len(e.forgetters))
+	for name, forgetter := range e.forgetters {
+		forgetters[name] = forgetter
+	}
+	e.mu.RUnlock()
+
+	report := &ForgetReport{
+		SubjectID: subjectID,
+		StartedAt: time.Now(),
+		Results:   make([]*ForgetResult, 0, len(components)),
+	}
+
+	for _, name := range components {
+		start := time.Now()
+		removed, err := forgetters[name].ForgetSubject(ctx, subjectID)
+
+		result := &ForgetResult{
+			Component: name,
+			Removed:   removed,
+			Duration:  time.Since(start),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		report.Results = append(report.Results, result)
+		report.TotalRemoved += removed
+	}
+	report.CompletedAt = time.Now()
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, agentErrors.New(agentErrors.CodeStateSave, "failed to erase subject from some components").
+			WithComponent("eraser").
+			WithOperation("forget_subject").
+			WithContext("subject_id", subjectID).
+			WithContext("failed_components", failed)
+	}
+	return report, nil
+}
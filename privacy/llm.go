@@ -0,0 +1,90 @@
+package privacy
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+)
+
+// LLMClient wraps an llm.Client, redacting PII in messages before they are
+// sent to the provider. With WithRestore, pseudonymization tokens in the
+// response are replaced with the original values.
+type LLMClient struct {
+	inner llm.Client
+	guard
+}
+
+// NewLLMClient wraps an LLM client
+func NewLLMClient(inner llm.Client, redactor *Redactor, opts ...Option) *LLMClient {
+	return &LLMClient{
+		inner: inner,
+		guard: newGuard("private_llm", redactor, opts),
+	}
+}
+
+// Complete redacts the request messages and completes them
+func (c *LLMClient) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	redacted := *req
+	messages, err := c.redactMessages(ctx, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	redacted.Messages = messages
+
+	resp, err := c.inner.Complete(ctx, &redacted)
+	if err != nil {
+		return nil, err
+	}
+	return c.restoreResponse(ctx, resp)
+}
+
+// Chat redacts the messages and sends them
+func (c *LLMClient) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	redacted, err := c.redactMessages(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.inner.Chat(ctx, redacted)
+	if err != nil {
+		return nil, err
+	}
+	return c.restoreResponse(ctx, resp)
+}
+
+// Provider returns the provider of the wrapped client
+func (c *LLMClient) Provider() constants.Provider {
+	return c.inner.Provider()
+}
+
+// IsAvailable checks if the wrapped client is available
+func (c *LLMClient) IsAvailable() bool {
+	return c.inner.IsAvailable()
+}
+
+func (c *LLMClient) redactMessages(ctx context.Context, messages []llm.Message) ([]llm.Message, error) {
+	redacted := make([]llm.Message, len(messages))
+	for i, msg := range messages {
+		content, err := c.redact(ctx, msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		msg.Content = content
+		redacted[i] = msg
+	}
+	return redacted, nil
+}
+
+func (c *LLMClient) restoreResponse(ctx context.Context, resp *llm.CompletionResponse) (*llm.CompletionResponse, error) {
+	if resp == nil || !c.opts.restore {
+		return resp, nil
+	}
+	restored := *resp
+	content, err := c.restore(ctx, resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	restored.Content = content
+	return &restored, nil
+}
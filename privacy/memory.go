@@ -0,0 +1,190 @@
+package privacy
+
+import (
+	"context"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// Prefixes of the refs the memory manager records in its subject index
+const (
+	sessionRef = "session:"
+	caseRef    = "case:"
+	keyRef     = "key:"
+)
+
+// caseDeleter is implemented by memory managers that can delete single cases
+type caseDeleter interface {
+	DeleteCase(ctx context.Context, id string) error
+}
+
+// MemoryManager wraps an interfaces.MemoryManager, redacting PII before
+// conversations, cases and string values are stored and recording which
+// sessions, cases and keys belong to the subject of the request
+type MemoryManager struct {
+	inner interfaces.MemoryManager
+	guard
+}
+
+// NewMemoryManager wraps a memory manager.
+// A nil redactor disables redaction and only tracks subjects.
+func NewMemoryManager(inner interfaces.MemoryManager, redactor *Redactor, opts ...Option) *MemoryManager {
+	return &MemoryManager{
+		inner: inner,
+		guard: newGuard("private_memory", redactor, opts),
+	}
+}
+
+// AddConversation redacts the conversation content and tags it with the subject
+func (m *MemoryManager) AddConversation(ctx context.Context, conv *interfaces.Conversation) error {
+	if conv == nil {
+		return m.inner.AddConversation(ctx, conv)
+	}
+
+	redacted := *conv
+	content, err := m.redact(ctx, conv.Content)
+	if err != nil {
+		return err
+	}
+	redacted.Content = content
+	redacted.Metadata = tagSubject(ctx, conv.Metadata)
+
+	if err := m.inner.AddConversation(ctx, &redacted); err != nil {
+		return err
+	}
+	conv.ID = redacted.ID
+	return m.track(ctx, sessionRef+conv.SessionID)
+}
+
+// GetConversationHistory returns the history, restoring tokens if enabled
+func (m *MemoryManager) GetConversationHistory(ctx context.Context, sessionID string, limit int) ([]*interfaces.Conversation, error) {
+	history, err := m.inner.GetConversationHistory(ctx, sessionID, limit)
+	if err != nil || !m.opts.restore {
+		return history, err
+	}
+
+	restored := make([]*interfaces.Conversation, len(history))
+	for i, conv := range history {
+		c := *conv
+		if c.Content, err = m.restore(ctx, conv.Content); err != nil {
+			return nil, err
+		}
+		restored[i] = &c
+	}
+	return restored, nil
+}
+
+// ClearConversation clears a session
+func (m *MemoryManager) ClearConversation(ctx context.Context, sessionID string) error {
+	return m.inner.ClearConversation(ctx, sessionID)
+}
+
+// AddCase redacts the case text fields
+func (m *MemoryManager) AddCase(ctx context.Context, caseMemory *interfaces.Case) error {
+	if caseMemory == nil {
+		return m.inner.AddCase(ctx, caseMemory)
+	}
+
+	redacted := *caseMemory
+	for _, field := range []*string{&redacted.Title, &redacted.Description, &redacted.Problem, &redacted.Solution} {
+		value, err := m.redact(ctx, *field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+
+	if err := m.inner.AddCase(ctx, &redacted); err != nil {
+		return err
+	}
+	caseMemory.ID = redacted.ID
+	return m.track(ctx, caseRef+caseMemory.ID)
+}
+
+// SearchSimilarCases redacts the query so it matches redacted cases
+func (m *MemoryManager) SearchSimilarCases(ctx context.Context, query string, limit int) ([]*interfaces.Case, error) {
+	redactedQuery, err := m.redact(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cases, err := m.inner.SearchSimilarCases(ctx, redactedQuery, limit)
+	if err != nil || !m.opts.restore {
+		return cases, err
+	}
+
+	restored := make([]*interfaces.Case, len(cases))
+	for i, caseMemory := range cases {
+		c := *caseMemory
+		for _, field := range []*string{&c.Title, &c.Description, &c.Problem, &c.Solution} {
+			if *field, err = m.restore(ctx, *field); err != nil {
+				return nil, err
+			}
+		}
+		restored[i] = &c
+	}
+	return restored, nil
+}
+
+// Store stores a value, redacting string values
+func (m *MemoryManager) Store(ctx context.Context, key string, value interface{}) error {
+	if text, ok := value.(string); ok {
+		redacted, err := m.redact(ctx, text)
+		if err != nil {
+			return err
+		}
+		value = redacted
+	}
+
+	if err := m.inner.Store(ctx, key, value); err != nil {
+		return err
+	}
+	return m.track(ctx, keyRef+key)
+}
+
+// Retrieve returns a value, restoring tokens in string values if enabled
+func (m *MemoryManager) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	value, err := m.inner.Retrieve(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if text, ok := value.(string); ok {
+		return m.restore(ctx, text)
+	}
+	return value, nil
+}
+
+// Delete removes a value
+func (m *MemoryManager) Delete(ctx context.Context, key string) error {
+	return m.inner.Delete(ctx, key)
+}
+
+// Clear removes all memory
+func (m *MemoryManager) Clear(ctx context.Context) error {
+	return m.inner.Clear(ctx)
+}
+
+// ForgetSubject clears the sessions and deletes the cases and keys written
+// for the subject. Deleting cases requires the wrapped manager to implement
+// DeleteCase(ctx, id), as memory.InMemoryManager does.
+func (m *MemoryManager) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	return m.forget(ctx, subjectID, func(ref string) error {
+		switch {
+		case strings.HasPrefix(ref, sessionRef):
+			return m.inner.ClearConversation(ctx, strings.TrimPrefix(ref, sessionRef))
+		case strings.HasPrefix(ref, keyRef):
+			return m.inner.Delete(ctx, strings.TrimPrefix(ref, keyRef))
+		case strings.HasPrefix(ref, caseRef):
+			deleter, ok := m.inner.(caseDeleter)
+			if !ok {
+				return agentErrors.New(agentErrors.CodeNotImplemented, "memory manager cannot delete cases").
+					WithComponent("private_memory").
+					WithOperation("forget_subject")
+			}
+			return deleter.DeleteCase(ctx, strings.TrimPrefix(ref, caseRef))
+		}
+		return nil
+	})
+}
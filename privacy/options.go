@@ -0,0 +1,133 @@
+package privacy
+
+import (
+	"context"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// options configures the privacy wrappers
+type options struct {
+	index   SubjectIndex
+	restore bool
+}
+
+// Option configures a privacy wrapper
+type Option func(*options)
+
+// WithSubjectIndex sets where the wrapper records which items belong to
+// which subject. Defaults to an in-memory index; use a StoreSubjectIndex
+// with a distinct namespace per wrapper to erase data written before a restart.
+func WithSubjectIndex(index SubjectIndex) Option {
+	return func(o *options) {
+		o.index = index
+	}
+}
+
+// WithRestore restores pseudonymization tokens in data read back through
+// the wrapper (memory reads, search results and LLM responses)
+func WithRestore() Option {
+	return func(o *options) {
+		o.restore = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.index == nil {
+		o.index = NewInMemorySubjectIndex()
+	}
+	return o
+}
+
+// guard holds what every privacy wrapper needs: redaction on the way in,
+// token restoration on the way out and the subject index
+type guard struct {
+	component string
+	redactor  *Redactor
+	opts      *options
+}
+
+func newGuard(component string, redactor *Redactor, opts []Option) guard {
+	return guard{
+		component: component,
+		redactor:  redactor,
+		opts:      newOptions(opts),
+	}
+}
+
+// redact redacts text when a redactor is configured
+func (g guard) redact(ctx context.Context, text string) (string, error) {
+	if g.redactor == nil || text == "" {
+		return text, nil
+	}
+	return g.redactor.RedactString(ctx, text)
+}
+
+// restore restores tokens in text when restoration is enabled
+func (g guard) restore(ctx context.Context, text string) (string, error) {
+	if g.redactor == nil || !g.opts.restore || text == "" {
+		return text, nil
+	}
+	return g.redactor.Restore(ctx, text)
+}
+
+// track records refs under the subject of the context
+func (g guard) track(ctx context.Context, refs ...string) error {
+	subjectID, ok := interfaces.SubjectFromContext(ctx)
+	if !ok || len(refs) == 0 {
+		return nil
+	}
+	return g.opts.index.Add(ctx, subjectID, refs...)
+}
+
+// forget calls remove for every ref of the subject and clears the index
+// once all of them are removed
+func (g guard) forget(ctx context.Context, subjectID string, remove func(ref string) error) (int, error) {
+	refs, err := g.opts.index.Refs(ctx, subjectID)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var firstErr error
+	failed := 0
+	for _, ref := range refs {
+		if err := remove(ref); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+			continue
+		}
+		removed++
+	}
+	if firstErr != nil {
+		// Keep the index so the erasure can be retried
+		return removed, agentErrors.Wrap(firstErr, agentErrors.CodeStateSave, "failed to erase subject data").
+			WithComponent(g.component).
+			WithOperation("forget_subject").
+			WithContext("subject_id", subjectID).
+			WithContext("failed", failed)
+	}
+
+	return removed, g.opts.index.Remove(ctx, subjectID)
+}
+
+// tagSubject returns a copy of metadata tagged with the subject of the context
+func tagSubject(ctx context.Context, metadata map[string]interface{}) map[string]interface{} {
+	subjectID, ok := interfaces.SubjectFromContext(ctx)
+	if !ok {
+		return metadata
+	}
+	tagged := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		tagged[k] = v
+	}
+	tagged[interfaces.SubjectMetadataKey] = subjectID
+	return tagged
+}
@@ -0,0 +1,178 @@
+package privacy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core/checkpoint"
+	agentstate "github.com/kart-io/goagent/core/state"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/cache"
+	"github.com/kart-io/goagent/memory"
+	"github.com/kart-io/goagent/retrieval"
+	storememory "github.com/kart-io/goagent/store/memory"
+	"github.com/kart-io/goagent/testing/mocks"
+	"github.com/kart-io/goagent/tools"
+)
+
+func TestRedactor(t *testing.T) {
+	ctx := interfaces.WithSubject(context.Background(), "alice")
+	text := "I am alice@example.com, card 4111 1111 1111 1111"
+
+	masker, err := NewRedactor()
+	require.NoError(t, err)
+	masked, matches, err := masker.Redact(ctx, text)
+	require.NoError(t, err)
+	assert.Equal(t, "I am [EMAIL], card [CREDIT_CARD]", masked)
+	assert.Len(t, matches, 2)
+
+	_, err = NewRedactor(WithPseudonymization(nil))
+	assert.Error(t, err)
+
+	vault := NewStoreTokenVault(storememory.New())
+	pseudonymizer, err := NewRedactor(WithPseudonymization(vault), WithTokenSecret([]byte("secret")))
+	require.NoError(t, err)
+
+	pseudonymized, err := pseudonymizer.RedactString(ctx, text)
+	require.NoError(t, err)
+	assert.NotContains(t, pseudonymized, "alice@example.com")
+	assert.Regexp(t, `^I am \[EMAIL_[0-9a-f]{12}\], card \[CREDIT_CARD_[0-9a-f]{12}\]$`, pseudonymized)
+
+	again, err := pseudonymizer.RedactString(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(pseudonymized, "I am "+again), "tokens are deterministic per subject")
+
+	restored, err := pseudonymizer.Restore(ctx, pseudonymized)
+	require.NoError(t, err)
+	assert.Equal(t, text, restored)
+
+	// Tokens cannot be resolved by another subject
+	other := interfaces.WithSubject(context.Background(), "bob")
+	notRestored, err := pseudonymizer.Restore(other, pseudonymized)
+	require.NoError(t, err)
+	assert.Equal(t, pseudonymized, notRestored)
+
+	// Forgetting the subject makes the tokens irreversible
+	count, err := vault.ForgetSubject(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	notRestored, err = pseudonymizer.Restore(ctx, pseudonymized)
+	require.NoError(t, err)
+	assert.Equal(t, pseudonymized, notRestored)
+}
+
+func TestLLMClient(t *testing.T) {
+	ctx := interfaces.WithSubject(context.Background(), "alice")
+	redactor, err := NewRedactor(WithPseudonymization(NewInMemoryTokenVault()))
+	require.NoError(t, err)
+
+	inner := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string { return "noted: " + prompt }))
+	client := NewLLMClient(inner, redactor, WithRestore())
+
+	resp, err := client.Chat(ctx, []llm.Message{llm.UserMessage("reach me at +1 415-555-2671")})
+	require.NoError(t, err)
+
+	prompts := inner.GetPrompts()
+	require.Len(t, prompts, 1)
+	assert.NotContains(t, prompts[0], "415-555-2671", "the provider never sees the phone number")
+	assert.Equal(t, "noted: reach me at +1 415-555-2671", resp.Content)
+}
+
+func TestForgetSubject(t *testing.T) {
+	alice := interfaces.WithSubject(context.Background(), "alice")
+	bob := interfaces.WithSubject(context.Background(), "bob")
+
+	vault := NewInMemoryTokenVault()
+	redactor, err := NewRedactor(WithPseudonymization(vault))
+	require.NoError(t, err)
+
+	inMemory := memory.NewInMemoryManager(memory.DefaultConfig())
+	mem := NewMemoryManager(inMemory, redactor, WithRestore())
+	docs := retrieval.NewMockVectorStore()
+	vectors := NewVectorStore(docs, redactor)
+	checkpoints := NewCheckpointer(checkpoint.NewInMemorySaver())
+	semanticCache := cache.NewMemorySemanticCache(cache.NewMockEmbeddingProvider(16), nil)
+	defer semanticCache.Close()
+	toolCache := tools.NewMemoryToolCache(tools.MemoryCacheConfig{Capacity: 10})
+	defer toolCache.Close()
+
+	conv := &interfaces.Conversation{SessionID: "alice-session", Role: "user", Content: "my email is alice@example.com"}
+	require.NoError(t, mem.AddConversation(alice, conv))
+	require.NoError(t, mem.AddConversation(bob, &interfaces.Conversation{SessionID: "bob-session", Role: "user", Content: "hi"}))
+	require.NoError(t, mem.AddCase(alice, &interfaces.Case{Title: "refund", Problem: "card 4111 1111 1111 1111 charged twice"}))
+	require.NoError(t, mem.Store(alice, "alice_email", "alice@example.com"))
+
+	stored, err := inMemory.GetConversationHistory(alice, "alice-session", 10)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.NotContains(t, stored[0].Content, "alice@example.com", "memory only holds the token")
+	assert.Equal(t, "alice", stored[0].Metadata[interfaces.SubjectMetadataKey])
+
+	history, err := mem.GetConversationHistory(alice, "alice-session", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "my email is alice@example.com", history[0].Content)
+
+	require.NoError(t, vectors.AddDocuments(alice, []*interfaces.Document{{PageContent: "IBAN DE89 3704 0044 0532 0130 00"}}))
+	require.NoError(t, vectors.AddDocuments(bob, []*interfaces.Document{{ID: "bob-doc", PageContent: "public notes"}}))
+	require.NoError(t, checkpoints.Save(alice, "alice-thread", agentstate.NewAgentState()))
+	require.NoError(t, semanticCache.Set(alice, "what is my email?", "alice@example.com", "gpt-4", 5))
+	require.NoError(t, toolCache.Set(alice, "lookup:abc@alice", &tools.ToolOutput{Result: "alice"}, 0))
+
+	eraser := NewEraser().
+		Register("memory", mem).
+		Register("documents", vectors).
+		Register("checkpoints", checkpoints).
+		Register("llm_cache", semanticCache).
+		Register("tool_cache", toolCache).
+		Register("tokens", vault)
+
+	report, err := eraser.ForgetSubject(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.True(t, report.Succeeded())
+	assert.Equal(t, "alice", report.SubjectID)
+	require.Len(t, report.Results, 6)
+
+	removed := make(map[string]int)
+	for _, result := range report.Results {
+		removed[result.Component] = result.Removed
+	}
+	assert.Equal(t, map[string]int{
+		"memory":      3,
+		"documents":   1,
+		"checkpoints": 1,
+		"llm_cache":   1,
+		"tool_cache":  1,
+		"tokens":      3,
+	}, removed)
+	assert.Equal(t, 10, report.TotalRemoved)
+
+	history, err = inMemory.GetConversationHistory(alice, "alice-session", 10)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	_, err = inMemory.Retrieve(alice, "alice_email")
+	assert.Error(t, err)
+	exists, err := checkpoints.Exists(alice, "alice-thread")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Len(t, docs.GetAllDocuments(), 1, "other subjects keep their documents")
+
+	bobHistory, err := inMemory.GetConversationHistory(bob, "bob-session", 10)
+	require.NoError(t, err)
+	assert.Len(t, bobHistory, 1)
+
+	// Failed components are reported and the erasure can be retried
+	failing := ForgetterFunc(func(ctx context.Context, subjectID string) (int, error) {
+		return 0, assert.AnError
+	})
+	report, err = eraser.Register("search_index", failing).ForgetSubject(context.Background(), "bob")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"search_index"}, report.Failed())
+
+	_, err = eraser.ForgetSubject(context.Background(), "")
+	assert.Error(t, err)
+}
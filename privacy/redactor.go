@@ -0,0 +1,188 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// RedactionMode controls how detected PII is replaced
+type RedactionMode int
+
+const (
+	// RedactMask replaces PII with its type, e.g. "[EMAIL]". It is irreversible.
+	RedactMask RedactionMode = iota
+
+	// RedactPseudonymize replaces PII with tokens such as "[EMAIL_3f9a2c1d0b7e]"
+	// that can be restored through the token vault
+	RedactPseudonymize
+)
+
+// tokenPattern matches pseudonymization tokens
+var tokenPattern = regexp.MustCompile(`\[[A-Z][A-Z0-9_]*_[0-9a-f]{12}\]`)
+
+// Redactor detects PII and masks or pseudonymizes it
+type Redactor struct {
+	detectors []Detector
+	mode      RedactionMode
+	vault     TokenVault
+	secret    []byte
+}
+
+// RedactorOption configures a Redactor
+type RedactorOption func(*Redactor)
+
+// WithDetectors replaces the default detectors.
+// Append to DefaultDetectors() to add custom detectors.
+func WithDetectors(detectors ...Detector) RedactorOption {
+	return func(r *Redactor) {
+		r.detectors = detectors
+	}
+}
+
+// WithPseudonymization switches to reversible tokens stored in the vault
+func WithPseudonymization(vault TokenVault) RedactorOption {
+	return func(r *Redactor) {
+		r.mode = RedactPseudonymize
+		r.vault = vault
+	}
+}
+
+// WithTokenSecret sets the key tokens are derived from.
+//
+// The same value always maps to the same token for a subject, so the LLM
+// can still tell values apart. Set a stable secret when tokens are kept
+// in a persistent vault; by default a random secret is generated per Redactor.
+func WithTokenSecret(secret []byte) RedactorOption {
+	return func(r *Redactor) {
+		r.secret = secret
+	}
+}
+
+// NewRedactor creates a redactor using the default detectors in mask mode
+func NewRedactor(opts ...RedactorOption) (*Redactor, error) {
+	r := &Redactor{
+		detectors: DefaultDetectors(),
+		mode:      RedactMask,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == RedactPseudonymize && r.vault == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "pseudonymization requires a token vault").
+			WithComponent("redactor").
+			WithOperation("create")
+	}
+	if len(r.secret) == 0 {
+		r.secret = make([]byte, 32)
+		if _, err := rand.Read(r.secret); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to generate token secret").
+				WithComponent("redactor").
+				WithOperation("create")
+		}
+	}
+
+	return r, nil
+}
+
+// Mode returns the redaction mode
+func (r *Redactor) Mode() RedactionMode {
+	return r.mode
+}
+
+// Detect returns the PII found in text
+func (r *Redactor) Detect(text string) []Match {
+	return Detect(text, r.detectors)
+}
+
+// Redact replaces the PII in text and returns the redacted text with the
+// replaced matches. Tokens are issued to the subject from interfaces.WithSubject.
+func (r *Redactor) Redact(ctx context.Context, text string) (string, []Match, error) {
+	matches := r.Detect(text)
+	if len(matches) == 0 {
+		return text, nil, nil
+	}
+
+	subjectID, _ := interfaces.SubjectFromContext(ctx)
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(text[last:match.Start])
+
+		replacement := "[" + string(match.Type) + "]"
+		if r.mode == RedactPseudonymize {
+			replacement = r.token(subjectID, match)
+			if err := r.vault.Put(ctx, subjectID, replacement, match.Value); err != nil {
+				return "", nil, agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to store pseudonymization token").
+					WithComponent("redactor").
+					WithOperation("redact").
+					WithContext("pii_type", string(match.Type))
+			}
+		}
+
+		b.WriteString(replacement)
+		last = match.End
+	}
+	b.WriteString(text[last:])
+
+	return b.String(), matches, nil
+}
+
+// RedactString is Redact without the matches
+func (r *Redactor) RedactString(ctx context.Context, text string) (string, error) {
+	redacted, _, err := r.Redact(ctx, text)
+	return redacted, err
+}
+
+// Restore replaces pseudonymization tokens with the original values.
+//
+// Only tokens issued to the subject from interfaces.WithSubject are
+// resolved; other tokens, including those of forgotten subjects, are left as is.
+func (r *Redactor) Restore(ctx context.Context, text string) (string, error) {
+	if r.vault == nil {
+		return text, nil
+	}
+
+	subjectID, _ := interfaces.SubjectFromContext(ctx)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range tokenPattern.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		value, ok, err := r.vault.Get(ctx, subjectID, token)
+		if err != nil {
+			return "", agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to resolve pseudonymization token").
+				WithComponent("redactor").
+				WithOperation("restore")
+		}
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(value)
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+
+	return b.String(), nil
+}
+
+// token derives a deterministic token for a value of the subject
+func (r *Redactor) token(subjectID string, match Match) string {
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write([]byte(subjectID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(match.Type))
+	mac.Write([]byte{0})
+	mac.Write([]byte(match.Value))
+	return "[" + string(match.Type) + "_" + hex.EncodeToString(mac.Sum(nil))[:12] + "]"
+}
@@ -0,0 +1,132 @@
+package privacy
+
+import (
+	"context"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+)
+
+// SubjectIndex records which stored items (session IDs, document IDs,
+// thread IDs, keys) belong to a data subject, for components that cannot
+// enumerate their data by subject themselves
+type SubjectIndex interface {
+	// Add records refs as belonging to the subject
+	Add(ctx context.Context, subjectID string, refs ...string) error
+
+	// Refs returns the refs recorded for the subject
+	Refs(ctx context.Context, subjectID string) ([]string, error)
+
+	// Remove forgets the refs recorded for the subject
+	Remove(ctx context.Context, subjectID string) error
+}
+
+// InMemorySubjectIndex is a process-local SubjectIndex
+type InMemorySubjectIndex struct {
+	mu   sync.RWMutex
+	refs map[string]map[string]bool
+}
+
+// NewInMemorySubjectIndex creates an in-memory subject index
+func NewInMemorySubjectIndex() *InMemorySubjectIndex {
+	return &InMemorySubjectIndex{
+		refs: make(map[string]map[string]bool),
+	}
+}
+
+// Add records refs as belonging to the subject
+func (i *InMemorySubjectIndex) Add(ctx context.Context, subjectID string, refs ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.refs[subjectID] == nil {
+		i.refs[subjectID] = make(map[string]bool)
+	}
+	for _, ref := range refs {
+		i.refs[subjectID][ref] = true
+	}
+	return nil
+}
+
+// Refs returns the refs recorded for the subject
+func (i *InMemorySubjectIndex) Refs(ctx context.Context, subjectID string) ([]string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	refs := make([]string, 0, len(i.refs[subjectID]))
+	for ref := range i.refs[subjectID] {
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// Remove forgets the refs recorded for the subject
+func (i *InMemorySubjectIndex) Remove(ctx context.Context, subjectID string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.refs, subjectID)
+	return nil
+}
+
+// StoreSubjectIndex implements SubjectIndex on top of a store.Store so the
+// index survives restarts
+type StoreSubjectIndex struct {
+	store     store.Store
+	namespace []string
+}
+
+// NewStoreSubjectIndex creates a store-backed subject index.
+// Use a distinct namespace per indexed component.
+func NewStoreSubjectIndex(s store.Store, namespace ...string) *StoreSubjectIndex {
+	if len(namespace) == 0 {
+		namespace = []string{"privacy_subjects"}
+	}
+	return &StoreSubjectIndex{
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// Add records refs as belonging to the subject
+func (i *StoreSubjectIndex) Add(ctx context.Context, subjectID string, refs ...string) error {
+	for _, ref := range refs {
+		if err := i.store.Put(ctx, i.subjectNamespace(subjectID), ref, true); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to index subject data").
+				WithComponent("subject_index").
+				WithOperation("add").
+				WithContext("subject_id", subjectID)
+		}
+	}
+	return nil
+}
+
+// Refs returns the refs recorded for the subject
+func (i *StoreSubjectIndex) Refs(ctx context.Context, subjectID string) ([]string, error) {
+	refs, err := i.store.List(ctx, i.subjectNamespace(subjectID))
+	if err != nil && !agentErrors.IsCode(err, agentErrors.CodeStoreNotFound) {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to load subject index").
+			WithComponent("subject_index").
+			WithOperation("refs").
+			WithContext("subject_id", subjectID)
+	}
+	return refs, nil
+}
+
+// Remove forgets the refs recorded for the subject
+func (i *StoreSubjectIndex) Remove(ctx context.Context, subjectID string) error {
+	if err := i.store.Clear(ctx, i.subjectNamespace(subjectID)); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to clear subject index").
+			WithComponent("subject_index").
+			WithOperation("remove").
+			WithContext("subject_id", subjectID)
+	}
+	return nil
+}
+
+func (i *StoreSubjectIndex) subjectNamespace(subjectID string) []string {
+	namespace := make([]string, 0, len(i.namespace)+1)
+	namespace = append(namespace, i.namespace...)
+	return append(namespace, subjectID)
+}
@@ -0,0 +1,148 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+)
+
+// TokenVault stores the original values behind pseudonymization tokens.
+//
+// Tokens are scoped to a data subject: a token can only be resolved for the
+// subject it was issued to, which keeps tenants from de-pseudonymizing each
+// other's data.
+type TokenVault interface {
+	// Put stores the value behind a token
+	Put(ctx context.Context, subjectID, token, value string) error
+
+	// Get returns the value behind a token issued to the subject
+	Get(ctx context.Context, subjectID, token string) (string, bool, error)
+
+	// ForgetSubject removes all tokens issued to the subject, making them
+	// irreversible, and returns the number of removed tokens
+	ForgetSubject(ctx context.Context, subjectID string) (int, error)
+}
+
+// InMemoryTokenVault is a process-local TokenVault
+type InMemoryTokenVault struct {
+	mu     sync.RWMutex
+	tokens map[string]map[string]string
+}
+
+// NewInMemoryTokenVault creates an in-memory token vault
+func NewInMemoryTokenVault() *InMemoryTokenVault {
+	return &InMemoryTokenVault{
+		tokens: make(map[string]map[string]string),
+	}
+}
+
+// Put stores the value behind a token
+func (v *InMemoryTokenVault) Put(ctx context.Context, subjectID, token, value string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.tokens[subjectID] == nil {
+		v.tokens[subjectID] = make(map[string]string)
+	}
+	v.tokens[subjectID][token] = value
+	return nil
+}
+
+// Get returns the value behind a token issued to the subject
+func (v *InMemoryTokenVault) Get(ctx context.Context, subjectID, token string) (string, bool, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	value, ok := v.tokens[subjectID][token]
+	return value, ok, nil
+}
+
+// ForgetSubject removes all tokens issued to the subject
+func (v *InMemoryTokenVault) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	count := len(v.tokens[subjectID])
+	delete(v.tokens, subjectID)
+	return count, nil
+}
+
+// StoreTokenVault implements TokenVault on top of a store.Store so tokens
+// survive restarts and are shared between replicas
+type StoreTokenVault struct {
+	store     store.Store
+	namespace []string
+}
+
+// NewStoreTokenVault creates a store-backed token vault.
+// The namespace defaults to "privacy_tokens"; tokens of each subject are
+// kept in a sub-namespace named after the subject.
+func NewStoreTokenVault(s store.Store, namespace ...string) *StoreTokenVault {
+	if len(namespace) == 0 {
+		namespace = []string{"privacy_tokens"}
+	}
+	return &StoreTokenVault{
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// Put stores the value behind a token
+func (v *StoreTokenVault) Put(ctx context.Context, subjectID, token, value string) error {
+	if err := v.store.Put(ctx, v.subjectNamespace(subjectID), token, value); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to save pseudonymization token").
+			WithComponent("token_vault").
+			WithOperation("put").
+			WithContext("subject_id", subjectID)
+	}
+	return nil
+}
+
+// Get returns the value behind a token issued to the subject
+func (v *StoreTokenVault) Get(ctx context.Context, subjectID, token string) (string, bool, error) {
+	stored, err := v.store.Get(ctx, v.subjectNamespace(subjectID), token)
+	if err != nil {
+		if agentErrors.IsCode(err, agentErrors.CodeStoreNotFound) {
+			return "", false, nil
+		}
+		return "", false, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to load pseudonymization token").
+			WithComponent("token_vault").
+			WithOperation("get").
+			WithContext("subject_id", subjectID)
+	}
+	if value, ok := stored.Value.(string); ok {
+		return value, true, nil
+	}
+	return fmt.Sprint(stored.Value), true, nil
+}
+
+// ForgetSubject removes all tokens issued to the subject
+func (v *StoreTokenVault) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	namespace := v.subjectNamespace(subjectID)
+	tokens, err := v.store.List(ctx, namespace)
+	if err != nil {
+		if agentErrors.IsCode(err, agentErrors.CodeStoreNotFound) {
+			return 0, nil
+		}
+		return 0, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to list pseudonymization tokens").
+			WithComponent("token_vault").
+			WithOperation("forget_subject").
+			WithContext("subject_id", subjectID)
+	}
+	if err := v.store.Clear(ctx, namespace); err != nil {
+		return 0, agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to delete pseudonymization tokens").
+			WithComponent("token_vault").
+			WithOperation("forget_subject").
+			WithContext("subject_id", subjectID)
+	}
+	return len(tokens), nil
+}
+
+func (v *StoreTokenVault) subjectNamespace(subjectID string) []string {
+	namespace := make([]string, 0, len(v.namespace)+1)
+	namespace = append(namespace, v.namespace...)
+	return append(namespace, subjectID)
+}
@@ -0,0 +1,111 @@
+package privacy
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// VectorStore wraps an interfaces.VectorStore, redacting documents before
+// they are embedded and recording which documents belong to the subject
+// of the request
+type VectorStore struct {
+	inner interfaces.VectorStore
+	guard
+}
+
+// NewVectorStore wraps a vector store.
+// A nil redactor disables redaction and only tracks subjects.
+func NewVectorStore(inner interfaces.VectorStore, redactor *Redactor, opts ...Option) *VectorStore {
+	return &VectorStore{
+		inner: inner,
+		guard: newGuard("private_vector_store", redactor, opts),
+	}
+}
+
+// SimilaritySearch redacts the query so it matches redacted documents
+func (s *VectorStore) SimilaritySearch(ctx context.Context, query string, topK int) ([]*interfaces.Document, error) {
+	redacted, err := s.redact(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.inner.SimilaritySearch(ctx, redacted, topK)
+	if err != nil {
+		return nil, err
+	}
+	return s.restoreDocuments(ctx, docs)
+}
+
+// SimilaritySearchWithScore redacts the query so it matches redacted documents
+func (s *VectorStore) SimilaritySearchWithScore(ctx context.Context, query string, topK int) ([]*interfaces.Document, error) {
+	redacted, err := s.redact(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.inner.SimilaritySearchWithScore(ctx, redacted, topK)
+	if err != nil {
+		return nil, err
+	}
+	return s.restoreDocuments(ctx, docs)
+}
+
+// AddDocuments redacts the documents and tags them with the subject.
+// Documents without an ID get one so they can be erased later.
+func (s *VectorStore) AddDocuments(ctx context.Context, docs []*interfaces.Document) error {
+	redacted := make([]*interfaces.Document, len(docs))
+	ids := make([]string, 0, len(docs))
+	for i, doc := range docs {
+		d := *doc
+		if d.ID == "" {
+			d.ID = uuid.New().String()
+			doc.ID = d.ID
+		}
+		content, err := s.redact(ctx, doc.PageContent)
+		if err != nil {
+			return err
+		}
+		d.PageContent = content
+		d.Metadata = tagSubject(ctx, doc.Metadata)
+
+		redacted[i] = &d
+		ids = append(ids, d.ID)
+	}
+
+	if err := s.inner.AddDocuments(ctx, redacted); err != nil {
+		return err
+	}
+	return s.track(ctx, ids...)
+}
+
+// Delete removes documents by their IDs
+func (s *VectorStore) Delete(ctx context.Context, ids []string) error {
+	return s.inner.Delete(ctx, ids)
+}
+
+// ForgetSubject deletes the documents added for the subject
+func (s *VectorStore) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	return s.forget(ctx, subjectID, func(id string) error {
+		return s.inner.Delete(ctx, []string{id})
+	})
+}
+
+// restoreDocuments restores tokens in search results if enabled
+func (s *VectorStore) restoreDocuments(ctx context.Context, docs []*interfaces.Document) ([]*interfaces.Document, error) {
+	if !s.opts.restore {
+		return docs, nil
+	}
+
+	restored := make([]*interfaces.Document, len(docs))
+	for i, doc := range docs {
+		d := *doc
+		content, err := s.restore(ctx, doc.PageContent)
+		if err != nil {
+			return nil, err
+		}
+		d.PageContent = content
+		restored[i] = &d
+	}
+	return restored, nil
+}
@@ -1,10 +1,14 @@
 package reflection
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"sort"
 	"sync"
 	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
 )
 
 // LearningModel manages and applies learned knowledge
@@ -14,6 +18,11 @@ type LearningModel struct {
 	applications  map[string]int     // Track how often each learning is applied
 	effectiveness map[string]float64 // Track effectiveness of each learning
 	mu            sync.RWMutex
+
+	// Persistence: learnings are written through, application counts are
+	// batched until Flush because they change on every read
+	persistence LearningStore
+	dirty       map[string]bool
 }
 
 // NewLearningModel creates a new learning model
@@ -23,7 +32,37 @@ func NewLearningModel() *LearningModel {
 		categories:    make(map[string][]*LearningPoint),
 		applications:  make(map[string]int),
 		effectiveness: make(map[string]float64),
+		dirty:         make(map[string]bool),
+	}
+}
+
+// NewPersistentLearningModel creates a learning model backed by a learning store,
+// restoring previously saved learnings and their tracking data
+func NewPersistentLearningModel(ctx context.Context, persistence LearningStore) (*LearningModel, error) {
+	if persistence == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "learning store is required").
+			WithComponent("learning_model").
+			WithOperation("new_persistent")
+	}
+
+	records, err := persistence.LoadLearnings(ctx)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to restore learning model").
+			WithComponent("learning_model").
+			WithOperation("new_persistent")
 	}
+
+	m := NewLearningModel()
+	for _, record := range records {
+		learning := record.Learning
+		m.learnings[record.ID] = &learning
+		m.applications[record.ID] = record.Applications
+		m.effectiveness[record.ID] = record.Effectiveness
+	}
+	m.rebuildCategoryIndex()
+	m.persistence = persistence
+
+	return m, nil
 }
 
 // AddLearning adds a new learning to the model
@@ -43,6 +82,8 @@ func (m *LearningModel) AddLearning(learning LearningPoint) {
 	// Initialize tracking
 	m.applications[id] = 0
 	m.effectiveness[id] = learning.Confidence
+
+	m.writeThrough("add_learning", id)
 }
 
 // UpdateWithLearnings updates the model with multiple learnings
@@ -54,8 +95,9 @@ func (m *LearningModel) UpdateWithLearnings(learnings []LearningPoint) {
 
 // GetRelevantLearnings retrieves learnings relevant to the given context
 func (m *LearningModel) GetRelevantLearnings(context interface{}) []LearningPoint {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	// Write lock: applying learnings updates the application counters
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	var relevant []LearningPoint
 	contextStr := fmt.Sprintf("%v", context)
@@ -132,6 +174,8 @@ func (m *LearningModel) UpdateEffectiveness(learningID string, success bool) {
 	} else {
 		m.effectiveness[learningID] = current + alpha*(0.0-current)
 	}
+
+	m.writeThrough("update_effectiveness", learningID)
 }
 
 // GetStatistics returns statistics about the learning model
@@ -173,21 +217,94 @@ func (m *LearningModel) PruneLowEffectiveness(threshold float64) int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	pruned := 0
+	var pruned []string
 	for id, effectiveness := range m.effectiveness {
 		if effectiveness < threshold && m.applications[id] > 10 {
 			// Only prune if it's been applied enough times to judge
 			delete(m.learnings, id)
 			delete(m.applications, id)
 			delete(m.effectiveness, id)
-			pruned++
+			delete(m.dirty, id)
+			pruned = append(pruned, id)
 		}
 	}
 
 	// Rebuild category index
 	m.rebuildCategoryIndex()
 
-	return pruned
+	if m.persistence != nil && len(pruned) > 0 {
+		if err := m.persistence.DeleteLearnings(context.Background(), pruned); err != nil {
+			// Persistence failure is logged but does not undo the prune
+			fmt.Fprintf(os.Stderr, "learning store delete failed during prune: %v\n", err)
+		}
+	}
+
+	return len(pruned)
+}
+
+// Flush writes application counts accumulated since the last flush to the learning store
+func (m *LearningModel) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.persistence == nil || len(m.dirty) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(m.dirty))
+	for id := range m.dirty {
+		ids = append(ids, id)
+	}
+	if err := m.persistence.SaveLearnings(ctx, m.records(ids)); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to flush learning model").
+			WithComponent("learning_model").
+			WithOperation("flush")
+	}
+	m.dirty = make(map[string]bool)
+	return nil
+}
+
+// Snapshot writes every learning to the learning store and removes
+// persisted learnings that are no longer in the model
+func (m *LearningModel) Snapshot(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.persistence == nil {
+		return agentErrors.New(agentErrors.CodeInvalidConfig, "learning store not configured").
+			WithComponent("learning_model").
+			WithOperation("snapshot")
+	}
+
+	persisted, err := m.persistence.LoadLearnings(ctx)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to load persisted learnings").
+			WithComponent("learning_model").
+			WithOperation("snapshot")
+	}
+	var stale []string
+	for _, record := range persisted {
+		if _, ok := m.learnings[record.ID]; !ok {
+			stale = append(stale, record.ID)
+		}
+	}
+
+	ids := make([]string, 0, len(m.learnings))
+	for id := range m.learnings {
+		ids = append(ids, id)
+	}
+	if err := m.persistence.DeleteLearnings(ctx, stale); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to remove stale learnings").
+			WithComponent("learning_model").
+			WithOperation("snapshot")
+	}
+	if err := m.persistence.SaveLearnings(ctx, m.records(ids)); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to save learnings").
+			WithComponent("learning_model").
+			WithOperation("snapshot")
+	}
+	m.dirty = make(map[string]bool)
+	return nil
 }
 
 // Helper methods
@@ -224,6 +341,45 @@ func (m *LearningModel) calculateRelevanceScore(learning *LearningPoint, context
 func (m *LearningModel) trackApplication(learning *LearningPoint) {
 	id := m.generateLearningID(*learning)
 	m.applications[id]++
+	if m.persistence != nil {
+		m.dirty[id] = true
+	}
+}
+
+// writeThrough saves a learning to the learning store, called with the lock held
+func (m *LearningModel) writeThrough(operation, id string) {
+	if m.persistence == nil {
+		return
+	}
+	if _, ok := m.learnings[id]; !ok {
+		return
+	}
+
+	if err := m.persistence.SaveLearnings(context.Background(), m.records([]string{id})); err != nil {
+		// Keep the learning dirty so the next Flush retries
+		m.dirty[id] = true
+		fmt.Fprintf(os.Stderr, "learning store write failed (operation=%s, id=%s): %v\n", operation, id, err)
+		return
+	}
+	delete(m.dirty, id)
+}
+
+// records builds persisted records for the given learnings, called with the lock held
+func (m *LearningModel) records(ids []string) []*LearningRecord {
+	records := make([]*LearningRecord, 0, len(ids))
+	for _, id := range ids {
+		learning, ok := m.learnings[id]
+		if !ok {
+			continue
+		}
+		records = append(records, &LearningRecord{
+			ID:            id,
+			Learning:      *learning,
+			Applications:  m.applications[id],
+			Effectiveness: m.effectiveness[id],
+		})
+	}
+	return records
 }
 
 func (m *LearningModel) rebuildCategoryIndex() {
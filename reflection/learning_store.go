@@ -0,0 +1,125 @@
+package reflection
+
+import (
+	"context"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// LearningRecord is the persisted form of a learning and its tracking data
+type LearningRecord struct {
+	ID            string        `json:"id"`
+	Learning      LearningPoint `json:"learning"`
+	Applications  int           `json:"applications"`
+	Effectiveness float64       `json:"effectiveness"`
+}
+
+// LearningStore persists the learnings of a LearningModel
+type LearningStore interface {
+	// SaveLearnings upserts learning records
+	SaveLearnings(ctx context.Context, records []*LearningRecord) error
+
+	// DeleteLearnings removes learning records
+	DeleteLearnings(ctx context.Context, ids []string) error
+
+	// LoadLearnings returns all learning records
+	LoadLearnings(ctx context.Context) ([]*LearningRecord, error)
+}
+
+// KVLearningStore implements LearningStore on top of a store.Store,
+// storing one JSON document per learning
+type KVLearningStore struct {
+	store     store.Store
+	namespace []string
+}
+
+// NewKVLearningStore creates a store-backed learning store.
+// The namespace defaults to "learning_model".
+func NewKVLearningStore(s store.Store, namespace ...string) *KVLearningStore {
+	if len(namespace) == 0 {
+		namespace = []string{"learning_model"}
+	}
+	return &KVLearningStore{
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// SaveLearnings upserts learning records
+func (s *KVLearningStore) SaveLearnings(ctx context.Context, records []*LearningRecord) error {
+	for _, record := range records {
+		doc, err := recordDocument(record)
+		if err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode learning").
+				WithComponent("learning_store").
+				WithOperation("save_learnings").
+				WithContext("learning_id", record.ID)
+		}
+		if err := s.store.Put(ctx, s.namespace, record.ID, doc); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to save learning").
+				WithComponent("learning_store").
+				WithOperation("save_learnings").
+				WithContext("learning_id", record.ID)
+		}
+	}
+	return nil
+}
+
+// DeleteLearnings removes learning records
+func (s *KVLearningStore) DeleteLearnings(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := s.store.Delete(ctx, s.namespace, id); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to delete learning").
+				WithComponent("learning_store").
+				WithOperation("delete_learnings").
+				WithContext("learning_id", id)
+		}
+	}
+	return nil
+}
+
+// LoadLearnings returns all learning records
+func (s *KVLearningStore) LoadLearnings(ctx context.Context) ([]*LearningRecord, error) {
+	values, err := s.store.Search(ctx, s.namespace, nil)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to load learnings").
+			WithComponent("learning_store").
+			WithOperation("load_learnings")
+	}
+
+	records := make([]*LearningRecord, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value.Value)
+		if err == nil {
+			var record LearningRecord
+			if err = json.Unmarshal(data, &record); err == nil {
+				if record.ID == "" {
+					record.ID = value.Key
+				}
+				records = append(records, &record)
+				continue
+			}
+		}
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode learning").
+			WithComponent("learning_store").
+			WithOperation("load_learnings").
+			WithContext("learning_id", value.Key)
+	}
+	return records, nil
+}
+
+// recordDocument converts a record into a JSON document so every store
+// backend keeps a detached copy
+func recordDocument(record *LearningRecord) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,66 @@
+package reflection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storememory "github.com/kart-io/goagent/store/memory"
+)
+
+func TestPersistentLearningModel(t *testing.T) {
+	ctx := context.Background()
+	learningStore := NewKVLearningStore(storememory.New())
+
+	model, err := NewPersistentLearningModel(ctx, learningStore)
+	require.NoError(t, err)
+
+	learning := LearningPoint{
+		Lesson:        "retry idempotent calls",
+		Context:       "deploy",
+		Category:      "reliability",
+		Applicability: 0.9,
+		Confidence:    0.8,
+		Timestamp:     time.Now(),
+	}
+	model.AddLearning(learning)
+	id := model.generateLearningID(learning)
+	model.UpdateEffectiveness(id, true)
+
+	relevant := model.GetRelevantLearnings("deploy")
+	require.Len(t, relevant, 1)
+
+	records, err := learningStore.LoadLearnings(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, 0, records[0].Applications, "applications are batched until Flush")
+	assert.InDelta(t, 0.82, records[0].Effectiveness, 1e-9)
+
+	require.NoError(t, model.Flush(ctx))
+
+	restored, err := NewPersistentLearningModel(ctx, learningStore)
+	require.NoError(t, err)
+	stats := restored.GetStatistics()
+	assert.Equal(t, 1, stats["total_learnings"])
+	assert.Equal(t, 1, stats["total_applications"])
+	assert.InDelta(t, 0.82, stats["average_effectiveness"], 1e-9)
+	require.Len(t, restored.GetLearningsByCategory("reliability"), 1)
+	assert.Equal(t, "retry idempotent calls", restored.GetLearningsByCategory("reliability")[0].Lesson)
+
+	// Pruned learnings are removed from the store
+	restored.applications[id] = 11
+	for i := 0; i < 20; i++ {
+		restored.UpdateEffectiveness(id, false)
+	}
+	assert.Equal(t, 1, restored.PruneLowEffectiveness(0.5))
+	records, err = learningStore.LoadLearnings(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = NewPersistentLearningModel(ctx, nil)
+	assert.Error(t, err)
+	assert.Error(t, NewLearningModel().Snapshot(ctx))
+}
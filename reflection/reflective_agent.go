@@ -196,6 +196,13 @@ func WithLearningThreshold(threshold float64) ReflectionOption {
 	}
 }
 
+// WithLearningModel sets the learning model, e.g. one created by NewPersistentLearningModel
+func WithLearningModel(model *LearningModel) ReflectionOption {
+	return func(a *SelfReflectiveAgent) {
+		a.learningModel = model
+	}
+}
+
 // Shutdown gracefully shuts down the agent
 func (a *SelfReflectiveAgent) Shutdown(ctx context.Context) error {
 	// Signal shutdown
@@ -211,7 +218,8 @@ func (a *SelfReflectiveAgent) Shutdown(ctx context.Context) error {
 
 	select {
 	case <-done:
-		return nil
+		// Persist learning application counts
+		return a.learningModel.Flush(ctx)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
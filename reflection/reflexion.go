@@ -0,0 +1,372 @@
+package reflection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/memory"
+)
+
+// Context key under which retried inputs carry the critiques of earlier trials
+const ReflexionCritiquesKey = "reflexion_critiques"
+
+// ReflexionConfig configures a ReflexionAgent
+type ReflexionConfig struct {
+	Name        string
+	Description string
+
+	// Agent is the agent whose attempts are evaluated and retried
+	Agent core.Agent
+
+	// Evaluator judges each attempt. Defaults to an LLM judge using LLM.
+	Evaluator TrialEvaluator
+
+	// LLM writes the self-critiques after failed trials. Without it the
+	// evaluator feedback is used as the critique.
+	LLM llm.Client
+
+	// Memory stores critiques as episodic memories so that later runs of the
+	// same task start with them (optional)
+	Memory memory.EnhancedMemory
+
+	// MaxTrials is the maximum number of attempts (default 3)
+	MaxTrials int
+}
+
+// ReflexionAgent wraps an agent in a Reflexion-style self-correction loop.
+//
+// Each trial runs the inner agent and evaluates its output. On failure the
+// agent writes a verbal self-critique of what went wrong, stores it in
+// episodic memory, and retries with all critiques so far appended to the
+// task, up to MaxTrials. Failures to store a critique are reported to the
+// OnError callbacks without ending the run. The full trajectory of attempts, evaluations and
+// critiques is returned in AgentOutput.ReasoningSteps.
+//
+// When every trial fails the last output is returned with status "failed"
+// and a nil error; errors are only returned for cancellation or when the
+// evaluator itself fails.
+type ReflexionAgent struct {
+	*core.BaseAgent
+	config ReflexionConfig
+}
+
+// NewReflexionAgent creates a Reflexion agent
+func NewReflexionAgent(config ReflexionConfig) *ReflexionAgent {
+	if config.MaxTrials <= 0 {
+		config.MaxTrials = 3
+	}
+	if config.Evaluator == nil && config.LLM != nil {
+		config.Evaluator = NewLLMJudgeEvaluator(config.LLM, "", 0.7)
+	}
+	if config.Name == "" {
+		config.Name = "reflexion"
+		if config.Agent != nil {
+			config.Name = "reflexion_" + config.Agent.Name()
+		}
+	}
+	if config.Description == "" {
+		config.Description = "Retries tasks with self-critique until they pass evaluation"
+	}
+
+	capabilities := []string{"reflexion", "self-correction"}
+	if config.Agent != nil {
+		capabilities = append(capabilities, config.Agent.Capabilities()...)
+	}
+
+	return &ReflexionAgent{
+		BaseAgent: core.NewBaseAgent(config.Name, config.Description, capabilities),
+		config:    config,
+	}
+}
+
+// Invoke runs the self-correction loop
+func (a *ReflexionAgent) Invoke(ctx context.Context, input *core.AgentInput) (*core.AgentOutput, error) {
+	startTime := time.Now()
+
+	if a.config.Agent == nil || a.config.Evaluator == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "reflexion agent requires an inner agent and an evaluator (or LLM)").
+			WithComponent("reflexion_agent").
+			WithOperation("invoke")
+	}
+
+	if err := a.triggerOnStart(ctx, input); err != nil {
+		return nil, err
+	}
+
+	output := &core.AgentOutput{
+		ReasoningSteps: make([]core.ReasoningStep, 0),
+		ToolCalls:      make([]core.ToolCall, 0),
+		Metadata:       make(map[string]interface{}),
+		TokenUsage:     &interfaces.TokenUsage{},
+	}
+
+	critiques := a.loadCritiques(ctx, input.Task)
+	var (
+		last       *core.AgentOutput
+		evaluation *TrialEvaluation
+		trials     int
+	)
+
+	for trial := 1; trial <= a.config.MaxTrials; trial++ {
+		trials = trial
+		trialStart := time.Now()
+
+		result, err := a.config.Agent.Invoke(ctx, a.trialInput(input, critiques))
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return a.handleError(ctx, output, startTime, agentErrors.Wrap(ctxErr, agentErrors.CodeContextCanceled, "reflexion interrupted").
+				WithComponent("reflexion_agent").
+				WithOperation("invoke").
+				WithContext("trial", trial))
+		}
+		if result != nil {
+			last = result
+			output.ToolCalls = append(output.ToolCalls, result.ToolCalls...)
+			output.TokenUsage.Add(result.TokenUsage)
+			for _, step := range result.ReasoningSteps {
+				step.Step = len(output.ReasoningSteps) + 1
+				step.Action = fmt.Sprintf("trial %d: %s", trial, step.Action)
+				output.ReasoningSteps = append(output.ReasoningSteps, step)
+			}
+		}
+
+		if err != nil {
+			evaluation = &TrialEvaluation{Success: false, Feedback: fmt.Sprintf("the attempt failed with error: %v", err)}
+		} else {
+			evaluation, err = a.config.Evaluator.Evaluate(ctx, input, result)
+			if err != nil {
+				return a.handleError(ctx, output, startTime, agentErrors.Wrap(err, agentErrors.CodeAgentExecution, "trial evaluation failed").
+					WithComponent("reflexion_agent").
+					WithOperation("evaluate").
+					WithContext("trial", trial))
+			}
+		}
+
+		a.addStep(output, fmt.Sprintf("trial %d: evaluate", trial), "Evaluate the attempt", evaluation.Feedback, evaluation.Success, time.Since(trialStart))
+		if evaluation.Success {
+			break
+		}
+		if trial == a.config.MaxTrials {
+			break
+		}
+
+		reflectStart := time.Now()
+		critique := a.critique(ctx, input, result, evaluation, critiques)
+		critiques = append(critiques, critique)
+		if err := a.storeCritique(ctx, input.Task, trial, critique, evaluation); err != nil {
+			// The critique still reaches the next trial; only later runs miss it
+			_ = a.triggerOnError(ctx, err)
+		}
+		a.addStep(output, fmt.Sprintf("trial %d: reflect", trial), "Self-critique of the failed attempt", critique, true, time.Since(reflectStart))
+	}
+
+	if last != nil {
+		output.Result = last.Result
+		output.Message = last.Message
+	}
+	output.Status = "success"
+	if !evaluation.Success {
+		output.Status = "failed"
+		output.Message = fmt.Sprintf("task failed evaluation after %d trials: %s", trials, evaluation.Feedback)
+	}
+	output.Timestamp = time.Now()
+	output.Latency = time.Since(startTime)
+	output.Metadata["reflexion_trials"] = trials
+	output.Metadata["reflexion_success"] = evaluation.Success
+	output.Metadata["reflexion_score"] = evaluation.Score
+	output.Metadata["reflexion_critiques"] = critiques
+
+	if err := a.triggerOnFinish(ctx, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// Stream executes the loop and emits the final output
+func (a *ReflexionAgent) Stream(ctx context.Context, input *core.AgentInput) (<-chan core.StreamChunk[*core.AgentOutput], error) {
+	outChan := make(chan core.StreamChunk[*core.AgentOutput], 1)
+
+	go func() {
+		defer close(outChan)
+
+		output, err := a.Invoke(ctx, input)
+		outChan <- core.StreamChunk[*core.AgentOutput]{
+			Data:  output,
+			Error: err,
+			Done:  true,
+		}
+	}()
+
+	return outChan, nil
+}
+
+// WithCallbacks adds callback handlers
+func (a *ReflexionAgent) WithCallbacks(callbacks ...core.Callback) core.Runnable[*core.AgentInput, *core.AgentOutput] {
+	newAgent := *a
+	newAgent.BaseAgent = a.BaseAgent.WithCallbacks(callbacks...).(*core.BaseAgent)
+	return &newAgent
+}
+
+// WithConfig configures the agent
+func (a *ReflexionAgent) WithConfig(config core.RunnableConfig) core.Runnable[*core.AgentInput, *core.AgentOutput] {
+	newAgent := *a
+	newAgent.BaseAgent = a.BaseAgent.WithConfig(config).(*core.BaseAgent)
+	return &newAgent
+}
+
+// trialInput injects the critiques of earlier trials into a copy of the
+// input. They are appended to the task, which every agent builds its prompt
+// from, and kept under ReflexionCritiquesKey in the context.
+func (a *ReflexionAgent) trialInput(input *core.AgentInput, critiques []string) *core.AgentInput {
+	if len(critiques) == 0 {
+		return input
+	}
+
+	trial := *input
+	trial.Context = make(map[string]interface{}, len(input.Context)+1)
+	for k, v := range input.Context {
+		trial.Context[k] = v
+	}
+	trial.Context[ReflexionCritiquesKey] = append([]string(nil), critiques...)
+
+	var sb strings.Builder
+	sb.WriteString(input.Task)
+	sb.WriteString("\n\nReflections on previous failed attempts at this task:\n")
+	for i, critique := range critiques {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, critique)
+	}
+	sb.WriteString("Use these reflections to avoid repeating the same mistakes.")
+	trial.Task = strings.TrimSpace(sb.String())
+	return &trial
+}
+
+// critique writes a verbal self-critique of a failed attempt
+func (a *ReflexionAgent) critique(ctx context.Context, input *core.AgentInput, output *core.AgentOutput, evaluation *TrialEvaluation, previous []string) string {
+	if a.config.LLM == nil {
+		return evaluation.Feedback
+	}
+
+	prompt := fmt.Sprintf(`You attempted a task and the attempt was judged a failure.
+
+Task: %s
+
+Your attempt:
+%s
+
+Evaluation feedback: %s
+`, taskText(input), outputText(output), evaluation.Feedback)
+	if len(previous) > 0 {
+		prompt += "\nEarlier reflections:\n- " + strings.Join(previous, "\n- ") + "\n"
+	}
+	prompt += `
+In a few sentences, diagnose what went wrong and state concretely what you will do differently on the next attempt.`
+
+	resp, err := a.config.LLM.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{llm.UserMessage(prompt)},
+	})
+	if err != nil || strings.TrimSpace(resp.Content) == "" {
+		// Fall back to the raw feedback rather than failing the run
+		return evaluation.Feedback
+	}
+	return strings.TrimSpace(resp.Content)
+}
+
+// loadCritiques returns critiques stored for the task by earlier runs
+func (a *ReflexionAgent) loadCritiques(ctx context.Context, task string) []string {
+	if a.config.Memory == nil || task == "" {
+		return nil
+	}
+
+	entries, err := a.config.Memory.GetByType(ctx, memory.MemoryTypeEpisodic, 100)
+	if err != nil {
+		return nil
+	}
+	var critiques []string
+	for _, entry := range entries {
+		if entry.Metadata["reflexion_task"] != task {
+			continue
+		}
+		if critique, ok := entry.Content.(string); ok {
+			critiques = append(critiques, critique)
+		}
+	}
+	return critiques
+}
+
+// storeCritique stores a critique as an episodic memory
+func (a *ReflexionAgent) storeCritique(ctx context.Context, task string, trial int, critique string, evaluation *TrialEvaluation) error {
+	if a.config.Memory == nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("reflexion_%d", time.Now().UnixNano())
+	err := a.config.Memory.StoreTyped(ctx, key, critique, memory.MemoryTypeEpisodic, memory.StoreOptions{
+		Tags: []string{"reflexion", "critique"},
+		Metadata: map[string]interface{}{
+			"reflexion_task": task,
+			"trial":          trial,
+			"score":          evaluation.Score,
+			"feedback":       evaluation.Feedback,
+		},
+	})
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeAgentExecution, "failed to store reflexion critique").
+			WithComponent("reflexion_agent").
+			WithOperation("store_critique").
+			WithContext("trial", trial)
+	}
+	return nil
+}
+
+func (a *ReflexionAgent) addStep(output *core.AgentOutput, action, description, result string, success bool, duration time.Duration) {
+	output.ReasoningSteps = append(output.ReasoningSteps, core.ReasoningStep{
+		Step:        len(output.ReasoningSteps) + 1,
+		Action:      action,
+		Description: description,
+		Result:      result,
+		Duration:    duration,
+		Success:     success,
+	})
+}
+
+func (a *ReflexionAgent) handleError(ctx context.Context, output *core.AgentOutput, startTime time.Time, err error) (*core.AgentOutput, error) {
+	output.Status = "failed"
+	output.Message = err.Error()
+	output.Timestamp = time.Now()
+	output.Latency = time.Since(startTime)
+
+	_ = a.triggerOnError(ctx, err)
+	return output, err
+}
+
+func (a *ReflexionAgent) triggerOnStart(ctx context.Context, input *core.AgentInput) error {
+	for _, cb := range a.GetConfig().Callbacks {
+		if err := cb.OnStart(ctx, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ReflexionAgent) triggerOnFinish(ctx context.Context, output *core.AgentOutput) error {
+	for _, cb := range a.GetConfig().Callbacks {
+		if err := cb.OnAgentFinish(ctx, output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ReflexionAgent) triggerOnError(ctx context.Context, err error) error {
+	for _, cb := range a.GetConfig().Callbacks {
+		if cbErr := cb.OnError(ctx, err); cbErr != nil {
+			return cbErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package reflection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/memory"
+	"github.com/kart-io/goagent/parsers"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+// scriptedAgent answers with the next scripted result and records its inputs
+type scriptedAgent struct {
+	*core.BaseAgent
+	results []string
+	inputs  []*core.AgentInput
+}
+
+func newScriptedAgent(results ...string) *scriptedAgent {
+	return &scriptedAgent{
+		BaseAgent: core.NewBaseAgent("scripted", "scripted test agent", []string{"test"}),
+		results:   results,
+	}
+}
+
+func (a *scriptedAgent) Invoke(ctx context.Context, input *core.AgentInput) (*core.AgentOutput, error) {
+	a.inputs = append(a.inputs, input)
+	result := a.results[(len(a.inputs)-1)%len(a.results)]
+	return &core.AgentOutput{
+		Result:         result,
+		Status:         "success",
+		ReasoningSteps: []core.ReasoningStep{{Step: 1, Action: "answer", Result: result, Success: true}},
+		ToolCalls:      []core.ToolCall{{ToolName: "calculator", Success: true}},
+	}, nil
+}
+
+func expectAnswer(want string) TrialEvaluator {
+	return NewTestEvaluator(func(ctx context.Context, result string) error {
+		if result != want {
+			return fmt.Errorf("expected %s, got %s", want, result)
+		}
+		return nil
+	})
+}
+
+func TestReflexionAgent(t *testing.T) {
+	ctx := context.Background()
+	inner := newScriptedAgent("41", "42")
+	critic := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		return "I made an off-by-one error; add one more."
+	}))
+	mem := memory.NewHierarchicalMemory(nil)
+	defer func() { _ = mem.Shutdown(ctx) }()
+
+	agent := NewReflexionAgent(ReflexionConfig{
+		Agent:     inner,
+		Evaluator: expectAnswer("42"),
+		LLM:       critic,
+		Memory:    mem,
+	})
+	assert.Equal(t, "reflexion_scripted", agent.Name())
+
+	output, err := agent.Invoke(ctx, &core.AgentInput{Task: "what is 6 x 7?"})
+	require.NoError(t, err)
+	assert.Equal(t, "success", output.Status)
+	assert.Equal(t, "42", output.Result)
+	assert.Equal(t, 2, output.Metadata["reflexion_trials"])
+	assert.Len(t, output.ToolCalls, 2)
+
+	// The retry carries the critique of the first attempt
+	require.Len(t, inner.inputs, 2)
+	assert.Contains(t, inner.inputs[1].Task, "what is 6 x 7?")
+	assert.Contains(t, inner.inputs[1].Task, "off-by-one")
+	assert.Equal(t, []string{"I made an off-by-one error; add one more."}, inner.inputs[1].Context[ReflexionCritiquesKey])
+	assert.Equal(t, "what is 6 x 7?", inner.inputs[0].Task, "the caller's input is not modified")
+
+	actions := make([]string, len(output.ReasoningSteps))
+	for i, step := range output.ReasoningSteps {
+		actions[i] = step.Action
+		assert.Equal(t, i+1, step.Step)
+	}
+	assert.Equal(t, []string{
+		"trial 1: answer", "trial 1: evaluate", "trial 1: reflect",
+		"trial 2: answer", "trial 2: evaluate",
+	}, actions)
+	assert.Equal(t, "expected 42, got 41", output.ReasoningSteps[1].Result)
+	assert.False(t, output.ReasoningSteps[1].Success)
+
+	// Critiques are kept in episodic memory and reused by later runs
+	stored, err := mem.GetByType(ctx, memory.MemoryTypeEpisodic, 10)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "what is 6 x 7?", stored[0].Metadata["reflexion_task"])
+
+	inner.inputs = nil
+	_, err = agent.Invoke(ctx, &core.AgentInput{Task: "what is 6 x 7?"})
+	require.NoError(t, err)
+	assert.Contains(t, inner.inputs[0].Task, "off-by-one")
+}
+
+func TestReflexionAgent_ExhaustsTrials(t *testing.T) {
+	inner := newScriptedAgent("not json")
+	agent := NewReflexionAgent(ReflexionConfig{
+		Agent:     inner,
+		Evaluator: NewParserEvaluator[map[string]interface{}](parsers.NewJSONOutputParser[map[string]interface{}](false)),
+		MaxTrials: 2,
+	})
+
+	output, err := agent.Invoke(context.Background(), &core.AgentInput{Task: "return a JSON object"})
+	require.NoError(t, err)
+	assert.Equal(t, "failed", output.Status)
+	assert.Equal(t, "not json", output.Result)
+	assert.Equal(t, 2, output.Metadata["reflexion_trials"])
+	assert.Len(t, inner.inputs, 2)
+	// Without an LLM the evaluator feedback is the critique
+	assert.Contains(t, inner.inputs[1].Task, "output is not valid")
+
+	_, err = NewReflexionAgent(ReflexionConfig{Agent: inner}).Invoke(context.Background(), &core.AgentInput{Task: "x"})
+	assert.Error(t, err, "an evaluator or LLM is required")
+}
+
+// failingMemory fails to store critiques
+type failingMemory struct {
+	memory.EnhancedMemory
+}
+
+func (m *failingMemory) StoreTyped(ctx context.Context, key string, value interface{}, memType memory.MemoryType, opts memory.StoreOptions) error {
+	return errors.New("disk full")
+}
+
+func (m *failingMemory) GetByType(ctx context.Context, memType memory.MemoryType, limit int) ([]*memory.MemoryEntry, error) {
+	return nil, nil
+}
+
+// errorRecorder records the errors reported to callbacks
+type errorRecorder struct {
+	*core.BaseCallback
+	errs []error
+}
+
+func (r *errorRecorder) OnError(ctx context.Context, err error) error {
+	r.errs = append(r.errs, err)
+	return nil
+}
+
+func TestReflexionAgent_StoreFailure(t *testing.T) {
+	inner := newScriptedAgent("41", "42")
+	recorder := &errorRecorder{BaseCallback: core.NewBaseCallback()}
+	agent := NewReflexionAgent(ReflexionConfig{
+		Agent:     inner,
+		Evaluator: expectAnswer("42"),
+		Memory:    &failingMemory{},
+	}).WithCallbacks(recorder)
+
+	output, err := agent.Invoke(context.Background(), &core.AgentInput{Task: "what is 6 x 7?"})
+	require.NoError(t, err, "a critique that cannot be stored does not fail the run")
+	assert.Equal(t, "success", output.Status)
+	require.Len(t, recorder.errs, 1)
+	assert.Contains(t, recorder.errs[0].Error(), "disk full")
+}
+
+func TestLLMJudgeEvaluator(t *testing.T) {
+	judge := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		if strings.Contains(prompt, "Answer:\nParis") {
+			return "```json\n{\"success\": true, \"score\": 0.9, \"feedback\": \"correct\"}\n```"
+		}
+		return `{"success": true, "score": 0.4, "feedback": "vague"}`
+	}))
+	evaluator := NewLLMJudgeEvaluator(judge, "", 0.7)
+	input := &core.AgentInput{Task: "capital of France?"}
+
+	verdict, err := evaluator.Evaluate(context.Background(), input, &core.AgentOutput{Result: "Paris"})
+	require.NoError(t, err)
+	assert.True(t, verdict.Success)
+	assert.Equal(t, "correct", verdict.Feedback)
+
+	verdict, err = evaluator.Evaluate(context.Background(), input, &core.AgentOutput{Result: "somewhere in Europe"})
+	require.NoError(t, err)
+	assert.False(t, verdict.Success, "scores below the threshold fail")
+}
@@ -0,0 +1,159 @@
+package reflection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// TrialEvaluation is the verdict of a TrialEvaluator on one attempt
+type TrialEvaluation struct {
+	Success  bool    `json:"success"`
+	Score    float64 `json:"score"`    // 0-1
+	Feedback string  `json:"feedback"` // Why the attempt failed or passed
+}
+
+// TrialEvaluator decides whether an agent output solves the task
+type TrialEvaluator interface {
+	Evaluate(ctx context.Context, input *core.AgentInput, output *core.AgentOutput) (*TrialEvaluation, error)
+}
+
+// TrialEvaluatorFunc adapts a function to the TrialEvaluator interface
+type TrialEvaluatorFunc func(ctx context.Context, input *core.AgentInput, output *core.AgentOutput) (*TrialEvaluation, error)
+
+// Evaluate calls f
+func (f TrialEvaluatorFunc) Evaluate(ctx context.Context, input *core.AgentInput, output *core.AgentOutput) (*TrialEvaluation, error) {
+	return f(ctx, input, output)
+}
+
+// NewTestEvaluator creates an evaluator from a unit-test style callback.
+// The attempt passes when test returns nil; the error text becomes the feedback.
+func NewTestEvaluator(test func(ctx context.Context, result string) error) TrialEvaluator {
+	return TrialEvaluatorFunc(func(ctx context.Context, input *core.AgentInput, output *core.AgentOutput) (*TrialEvaluation, error) {
+		if err := test(ctx, outputText(output)); err != nil {
+			return &TrialEvaluation{Success: false, Score: 0, Feedback: err.Error()}, nil
+		}
+		return &TrialEvaluation{Success: true, Score: 1, Feedback: "all checks passed"}, nil
+	})
+}
+
+// OutputParser is the part of an output parser used by a parser evaluator.
+// Every parsers.OutputParser implements it.
+type OutputParser[T any] interface {
+	Parse(ctx context.Context, text string) (T, error)
+	GetFormatInstructions() string
+	GetType() string
+}
+
+// NewParserEvaluator creates an evaluator that passes when the output can be
+// parsed by parser, e.g. a JSON or structured output parser
+func NewParserEvaluator[T any](parser OutputParser[T]) TrialEvaluator {
+	return TrialEvaluatorFunc(func(ctx context.Context, input *core.AgentInput, output *core.AgentOutput) (*TrialEvaluation, error) {
+		if _, err := parser.Parse(ctx, outputText(output)); err != nil {
+			return &TrialEvaluation{
+				Success:  false,
+				Score:    0,
+				Feedback: fmt.Sprintf("output is not valid %s: %v\nExpected format:\n%s", parser.GetType(), err, parser.GetFormatInstructions()),
+			}, nil
+		}
+		return &TrialEvaluation{Success: true, Score: 1, Feedback: "output parsed successfully"}, nil
+	})
+}
+
+// LLMJudgeEvaluator asks an LLM to judge an attempt against criteria
+type LLMJudgeEvaluator struct {
+	llm       llm.Client
+	criteria  string
+	threshold float64
+}
+
+// NewLLMJudgeEvaluator creates an LLM judge. An attempt passes when the judge
+// says so and its score is at least threshold.
+func NewLLMJudgeEvaluator(llmClient llm.Client, criteria string, threshold float64) *LLMJudgeEvaluator {
+	return &LLMJudgeEvaluator{
+		llm:       llmClient,
+		criteria:  criteria,
+		threshold: threshold,
+	}
+}
+
+// Evaluate implements TrialEvaluator
+func (e *LLMJudgeEvaluator) Evaluate(ctx context.Context, input *core.AgentInput, output *core.AgentOutput) (*TrialEvaluation, error) {
+	criteria := e.criteria
+	if criteria == "" {
+		criteria = "The answer fully and correctly solves the task."
+	}
+
+	prompt := fmt.Sprintf(`You are judging whether an answer solves a task.
+
+Task: %s
+
+Answer:
+%s
+
+Criteria: %s
+
+Respond with JSON only:
+{"success": true or false, "score": number between 0 and 1, "feedback": "what is wrong or missing, or why it passes"}`,
+		taskText(input), outputText(output), criteria)
+
+	resp, err := e.llm.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{llm.UserMessage(prompt)},
+	})
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "judge request failed").
+			WithComponent("reflexion_agent").
+			WithOperation("evaluate")
+	}
+
+	verdict, err := parseVerdict(resp.Content)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMResponse, "failed to parse judge verdict").
+			WithComponent("reflexion_agent").
+			WithOperation("evaluate")
+	}
+	verdict.Success = verdict.Success && verdict.Score >= e.threshold
+	return verdict, nil
+}
+
+// parseVerdict extracts the JSON verdict from a judge response
+func parseVerdict(text string) (*TrialEvaluation, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, agentErrors.New(agentErrors.CodeLLMResponse, "no JSON object found in response")
+	}
+
+	verdict := &TrialEvaluation{}
+	if err := json.Unmarshal([]byte(text[start:end+1]), verdict); err != nil {
+		return nil, err
+	}
+	return verdict, nil
+}
+
+// taskText is the task description of an agent input
+func taskText(input *core.AgentInput) string {
+	if input.Instruction == "" || input.Instruction == input.Task {
+		return input.Task
+	}
+	return strings.TrimSpace(input.Task + "\n" + input.Instruction)
+}
+
+// outputText is the result of an agent output as text
+func outputText(output *core.AgentOutput) string {
+	if output == nil {
+		return ""
+	}
+	if output.Result != nil {
+		if s, ok := output.Result.(string); ok {
+			return s
+		}
+		return fmt.Sprint(output.Result)
+	}
+	return output.Message
+}
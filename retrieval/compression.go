@@ -0,0 +1,521 @@
+package retrieval
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/sync/errgroup"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+)
+
+// MetadataSpans 压缩后文档元数据中记录保留片段位置的键
+//
+// 值为 []TextSpan,偏移量相对于压缩前的原始文档内容
+const MetadataSpans = "spans"
+
+// excerptSeparator 压缩后片段之间的分隔符
+const excerptSeparator = "\n...\n"
+
+// TextSpan 文本片段在原始文档中的字符区间 [Start, End)
+//
+// 偏移量按字符(rune)计算,便于前端直接高亮
+type TextSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// DocumentCompressor 文档压缩器
+//
+// 根据查询裁剪检索到的文档,只保留相关片段
+type DocumentCompressor interface {
+	CompressDocuments(ctx context.Context, docs []*Document, query string) ([]*Document, error)
+}
+
+// DocumentSpans 返回文档内容对应的原始文档区间
+//
+// 未经压缩的文档返回覆盖全文的单个区间
+func DocumentSpans(doc *Document) []TextSpan {
+	if spans, ok := doc.Metadata[MetadataSpans].([]TextSpan); ok {
+		return spans
+	}
+	return []TextSpan{{Start: 0, End: len([]rune(doc.PageContent))}}
+}
+
+// excerptSegment 压缩文档内容中的一段及其在原文中的区间
+type excerptSegment struct {
+	current  TextSpan
+	original TextSpan
+}
+
+// excerptSegments 计算文档内容各段与原文区间的对应关系
+func excerptSegments(doc *Document) []excerptSegment {
+	spans, ok := doc.Metadata[MetadataSpans].([]TextSpan)
+	if !ok {
+		n := len([]rune(doc.PageContent))
+		return []excerptSegment{{current: TextSpan{0, n}, original: TextSpan{0, n}}}
+	}
+
+	sepLen := len([]rune(excerptSeparator))
+	segments := make([]excerptSegment, len(spans))
+	offset := 0
+	for i, span := range spans {
+		length := span.End - span.Start
+		segments[i] = excerptSegment{
+			current:  TextSpan{offset, offset + length},
+			original: span,
+		}
+		offset += length + sepLen
+	}
+	return segments
+}
+
+// excerpt 根据保留区间(相对于当前内容)构造压缩后的文档
+//
+// 返回的文档保留原 ID 和元数据,MetadataSpans 记录相对于原始文档的区间
+func excerpt(doc *Document, keep []TextSpan) *Document {
+	runes := []rune(doc.PageContent)
+
+	// 将保留区间切分到各段并去除首尾空白
+	type piece struct {
+		segment  int
+		current  TextSpan
+		original TextSpan
+	}
+	pieces := make([]piece, 0, len(keep))
+	for i, seg := range excerptSegments(doc) {
+		for _, k := range keep {
+			start := max(k.Start, seg.current.Start)
+			end := min(k.End, seg.current.End)
+			for start < end && unicode.IsSpace(runes[start]) {
+				start++
+			}
+			for end > start && unicode.IsSpace(runes[end-1]) {
+				end--
+			}
+			if start >= end {
+				continue
+			}
+			offset := seg.original.Start - seg.current.Start
+			pieces = append(pieces, piece{
+				segment:  i,
+				current:  TextSpan{start, end},
+				original: TextSpan{start + offset, end + offset},
+			})
+		}
+	}
+	sort.Slice(pieces, func(i, j int) bool {
+		return pieces[i].current.Start < pieces[j].current.Start
+	})
+
+	// 合并同一段内重叠或相邻的区间
+	merged := make([]piece, 0, len(pieces))
+	for _, p := range pieces {
+		if n := len(merged); n > 0 && merged[n-1].segment == p.segment && p.current.Start <= merged[n-1].current.End {
+			if p.current.End > merged[n-1].current.End {
+				merged[n-1].current.End = p.current.End
+				merged[n-1].original.End = p.original.End
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+
+	spans := make([]TextSpan, len(merged))
+	parts := make([]string, len(merged))
+	for i, p := range merged {
+		spans[i] = p.original
+		parts[i] = string(runes[p.current.Start:p.current.End])
+	}
+
+	result := doc.Clone()
+	result.PageContent = strings.Join(parts, excerptSeparator)
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataSpans] = spans
+	return result
+}
+
+// sentenceSpans 按句子切分文本,返回各句子的字符区间
+func sentenceSpans(text string) []TextSpan {
+	runes := []rune(text)
+	spans := make([]TextSpan, 0)
+	start := 0
+	for i, r := range runes {
+		end := -1
+		switch r {
+		case '\n', '。', '！', '？', '；':
+			end = i + 1
+		case '.', '!', '?':
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+				end = i + 1
+			}
+		}
+		if end < 0 {
+			continue
+		}
+		if strings.TrimSpace(string(runes[start:end])) != "" {
+			spans = append(spans, TextSpan{start, end})
+		}
+		start = end
+	}
+	if start < len(runes) && strings.TrimSpace(string(runes[start:])) != "" {
+		spans = append(spans, TextSpan{start, len(runes)})
+	}
+	return spans
+}
+
+// defaultExtractorPrompt 默认的相关内容抽取提示词
+const defaultExtractorPrompt = `Given the following question and context, extract any part of the context *AS IS* that is relevant to answer the question.
+Copy sentences verbatim, one per line. If none of the context is relevant return NO_OUTPUT.
+
+Question: {{.Question}}
+
+Context:
+>>>
+{{.Context}}
+>>>
+
+Extracted relevant parts:`
+
+// noOutputMarker LLM 表示无相关内容的标记
+const noOutputMarker = "NO_OUTPUT"
+
+// LLMChainExtractor 使用 LLM 抽取文档中与查询相关的原文片段
+//
+// 抽取结果会在原文中重新定位,无法定位的片段将被丢弃,避免引入 LLM 改写的内容
+type LLMChainExtractor struct {
+	// LLMClient LLM 客户端
+	LLMClient llm.Client
+
+	// Prompt 抽取提示词
+	Prompt string
+
+	// Concurrency 并发数
+	Concurrency int
+}
+
+// NewLLMChainExtractor 创建 LLM 抽取器
+func NewLLMChainExtractor(llmClient llm.Client) *LLMChainExtractor {
+	return &LLMChainExtractor{
+		LLMClient:   llmClient,
+		Prompt:      defaultExtractorPrompt,
+		Concurrency: 4,
+	}
+}
+
+// CompressDocuments 抽取每个文档的相关片段,没有相关内容的文档被移除
+func (e *LLMChainExtractor) CompressDocuments(ctx context.Context, docs []*Document, query string) ([]*Document, error) {
+	results := make([]*Document, len(docs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	if e.Concurrency > 0 {
+		g.SetLimit(e.Concurrency)
+	}
+	for i, doc := range docs {
+		g.Go(func() error {
+			compressed, err := e.extract(gctx, doc, query)
+			if err != nil {
+				return agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "failed to extract relevant content").
+					WithComponent("llm_chain_extractor").
+					WithOperation("compress_documents").
+					WithContext("document_id", doc.ID)
+			}
+			results[i] = compressed
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return compactDocuments(results), nil
+}
+
+// extract 抽取单个文档
+func (e *LLMChainExtractor) extract(ctx context.Context, doc *Document, query string) (*Document, error) {
+	prompt := strings.ReplaceAll(e.Prompt, "{{.Question}}", query)
+	prompt = strings.ReplaceAll(prompt, "{{.Context}}", doc.PageContent)
+
+	response, err := e.LLMClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(prompt),
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := strings.TrimSpace(response.Content)
+	if output == "" || strings.Contains(output, noOutputMarker) {
+		return nil, nil
+	}
+
+	keep := make([]TextSpan, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if idx := strings.Index(doc.PageContent, line); idx >= 0 {
+			start := len([]rune(doc.PageContent[:idx]))
+			keep = append(keep, TextSpan{start, start + len([]rune(line))})
+		}
+	}
+	if len(keep) == 0 {
+		return nil, nil
+	}
+
+	return excerpt(doc, keep), nil
+}
+
+// EmbeddingsFilter 基于嵌入相似度的句子过滤器
+//
+// 将文档切分为句子,只保留与查询相似度不低于阈值的句子
+type EmbeddingsFilter struct {
+	// Embedder 嵌入器
+	Embedder Embedder
+
+	// SimilarityThreshold 相似度阈值
+	SimilarityThreshold float64
+
+	// KeepTopN 每个文档最多保留的句子数,0 表示不限制
+	KeepTopN int
+}
+
+// NewEmbeddingsFilter 创建嵌入相似度过滤器
+func NewEmbeddingsFilter(embedder Embedder, threshold float64) *EmbeddingsFilter {
+	return &EmbeddingsFilter{
+		Embedder:            embedder,
+		SimilarityThreshold: threshold,
+	}
+}
+
+// CompressDocuments 过滤每个文档中的无关句子,没有相关句子的文档被移除
+func (f *EmbeddingsFilter) CompressDocuments(ctx context.Context, docs []*Document, query string) ([]*Document, error) {
+	queryVector, err := f.Embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed query").
+			WithComponent("embeddings_filter").
+			WithOperation("compress_documents")
+	}
+
+	results := make([]*Document, 0, len(docs))
+	for _, doc := range docs {
+		spans := sentenceSpans(doc.PageContent)
+		if len(spans) == 0 {
+			continue
+		}
+
+		runes := []rune(doc.PageContent)
+		sentences := make([]string, len(spans))
+		for i, span := range spans {
+			sentences[i] = string(runes[span.Start:span.End])
+		}
+
+		vectors, err := f.Embedder.Embed(ctx, sentences)
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed sentences").
+				WithComponent("embeddings_filter").
+				WithOperation("compress_documents").
+				WithContext("document_id", doc.ID)
+		}
+
+		type scored struct {
+			span  TextSpan
+			score float64
+		}
+		kept := make([]scored, 0, len(spans))
+		for i, vec := range vectors {
+			sim, err := cosineSimilarity(queryVector, vec)
+			if err != nil || float64(sim) < f.SimilarityThreshold {
+				continue
+			}
+			kept = append(kept, scored{spans[i], float64(sim)})
+		}
+		if len(kept) == 0 {
+			continue
+		}
+
+		if f.KeepTopN > 0 && len(kept) > f.KeepTopN {
+			// 按分数保留前 N 句,excerpt 会恢复原文顺序
+			sort.SliceStable(kept, func(i, j int) bool {
+				return kept[i].score > kept[j].score
+			})
+			kept = kept[:f.KeepTopN]
+		}
+
+		keep := make([]TextSpan, len(kept))
+		for i, k := range kept {
+			keep[i] = k.span
+		}
+		results = append(results, excerpt(doc, keep))
+	}
+
+	return results, nil
+}
+
+// RedundantFilter 冗余文档过滤器
+//
+// 移除与排名更靠前的文档嵌入相似度超过阈值的文档
+type RedundantFilter struct {
+	// Embedder 嵌入器
+	Embedder Embedder
+
+	// SimilarityThreshold 判定为冗余的相似度阈值
+	SimilarityThreshold float64
+}
+
+// NewRedundantFilter 创建冗余过滤器,threshold <= 0 时默认 0.95
+func NewRedundantFilter(embedder Embedder, threshold float64) *RedundantFilter {
+	if threshold <= 0 {
+		threshold = 0.95
+	}
+	return &RedundantFilter{
+		Embedder:            embedder,
+		SimilarityThreshold: threshold,
+	}
+}
+
+// CompressDocuments 移除冗余文档,保持原有顺序
+func (f *RedundantFilter) CompressDocuments(ctx context.Context, docs []*Document, query string) ([]*Document, error) {
+	if len(docs) < 2 {
+		return docs, nil
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := f.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed documents").
+			WithComponent("redundant_filter").
+			WithOperation("compress_documents")
+	}
+
+	results := make([]*Document, 0, len(docs))
+	keptVectors := make([][]float32, 0, len(docs))
+	for i, doc := range docs {
+		redundant := false
+		for _, kept := range keptVectors {
+			if sim, err := cosineSimilarity(vectors[i], kept); err == nil && float64(sim) >= f.SimilarityThreshold {
+				redundant = true
+				break
+			}
+		}
+		if redundant {
+			continue
+		}
+		results = append(results, doc)
+		keptVectors = append(keptVectors, vectors[i])
+	}
+	return results, nil
+}
+
+// CompressorPipeline 按顺序执行多个压缩器
+type CompressorPipeline struct {
+	Compressors []DocumentCompressor
+}
+
+// NewCompressorPipeline 创建压缩器管道
+func NewCompressorPipeline(compressors ...DocumentCompressor) *CompressorPipeline {
+	return &CompressorPipeline{Compressors: compressors}
+}
+
+// CompressDocuments 依次执行所有压缩器
+func (p *CompressorPipeline) CompressDocuments(ctx context.Context, docs []*Document, query string) ([]*Document, error) {
+	var err error
+	for _, compressor := range p.Compressors {
+		if len(docs) == 0 {
+			break
+		}
+		docs, err = compressor.CompressDocuments(ctx, docs, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// ContextualCompressionRetriever 上下文压缩检索器
+//
+// 对基础检索器的结果执行压缩,只返回与查询相关的片段
+type ContextualCompressionRetriever struct {
+	*BaseRetriever
+
+	// Retriever 基础检索器
+	Retriever Retriever
+
+	// Compressor 文档压缩器
+	Compressor DocumentCompressor
+}
+
+// NewContextualCompressionRetriever 创建上下文压缩检索器
+func NewContextualCompressionRetriever(
+	baseRetriever Retriever,
+	compressor DocumentCompressor,
+	config RetrieverConfig,
+) *ContextualCompressionRetriever {
+	retriever := &ContextualCompressionRetriever{
+		BaseRetriever: NewBaseRetriever(),
+		Retriever:     baseRetriever,
+		Compressor:    compressor,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "contextual_compression_retriever"
+	}
+
+	return retriever
+}
+
+// GetRelevantDocuments 检索并压缩文档
+func (c *ContextualCompressionRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return c.traceRetrieval(ctx, query, c.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (c *ContextualCompressionRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	docs, err := c.Retriever.GetRelevantDocuments(ctx, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "base retrieval failed").
+			WithComponent("contextual_compression_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	compressed, err := c.Compressor.CompressDocuments(ctx, docs, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInternal, "document compression failed").
+			WithComponent("contextual_compression_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+
+	return c.LimitTopK(c.FilterByScore(compressed)), nil
+}
+
+// compactDocuments 移除 nil 文档
+func compactDocuments(docs []*Document) []*Document {
+	result := make([]*Document, 0, len(docs))
+	for _, doc := range docs {
+		if doc != nil {
+			result = append(result, doc)
+		}
+	}
+	return result
+}
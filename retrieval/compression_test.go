@@ -0,0 +1,183 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+const compressionText = "Kubernetes schedules pods. Redis caches sessions. Postgres stores orders. Redis evicts keys with LRU."
+
+func spanText(content string, span TextSpan) string {
+	return string([]rune(content)[span.Start:span.End])
+}
+
+func TestEmbeddingsFilterKeepsRelevantSentences(t *testing.T) {
+	ctx := context.Background()
+	embedder := mocks.NewKeywordEmbedder("kubernetes", "redis", "postgres")
+	doc := NewDocumentWithID("doc", compressionText, map[string]interface{}{"source": "ops.md"})
+
+	compressed, err := NewEmbeddingsFilter(embedder, 0.9).CompressDocuments(ctx, []*Document{doc}, "redis")
+	require.NoError(t, err)
+	require.Len(t, compressed, 1)
+
+	got := compressed[0]
+	assert.Equal(t, "doc", got.ID)
+	assert.Equal(t, "ops.md", got.Metadata["source"])
+	assert.Equal(t, "Redis caches sessions."+excerptSeparator+"Redis evicts keys with LRU.", got.PageContent)
+
+	spans := DocumentSpans(got)
+	require.Len(t, spans, 2)
+	assert.Equal(t, "Redis caches sessions.", spanText(compressionText, spans[0]))
+	assert.Equal(t, "Redis evicts keys with LRU.", spanText(compressionText, spans[1]))
+	assert.Equal(t, compressionText, doc.PageContent, "input is not modified")
+
+	// 再次压缩时区间仍然相对于原文
+	filter := NewEmbeddingsFilter(embedder, 0.5)
+	filter.KeepTopN = 1
+	again, err := filter.CompressDocuments(ctx, compressed, "redis")
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, []TextSpan{spans[0]}, DocumentSpans(again[0]))
+
+	none, err := NewEmbeddingsFilter(embedder, 0.9).CompressDocuments(ctx, []*Document{doc}, "mysql")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestLLMChainExtractor(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		if strings.Contains(prompt, "中文") {
+			return "NO_OUTPUT"
+		}
+		return "Postgres stores orders.\nThis line was invented by the model."
+	}))
+
+	docs := []*Document{
+		NewDocumentWithID("a", compressionText, nil),
+		NewDocumentWithID("b", "中文文档内容", nil),
+	}
+	compressed, err := NewLLMChainExtractor(client).CompressDocuments(ctx, docs, "where are orders stored?")
+	require.NoError(t, err)
+	require.Len(t, compressed, 1)
+	assert.Equal(t, "Postgres stores orders.", compressed[0].PageContent, "only verbatim spans are kept")
+	assert.Equal(t, "Postgres stores orders.", spanText(compressionText, DocumentSpans(compressed[0])[0]))
+}
+
+func TestRedundantFilterAndPipeline(t *testing.T) {
+	ctx := context.Background()
+	embedder := mocks.NewKeywordEmbedder("redis", "postgres")
+	docs := []*Document{
+		NewDocumentWithID("1", "Redis caches sessions.", nil),
+		NewDocumentWithID("2", "Redis is used for caching.", nil),
+		NewDocumentWithID("3", "Postgres stores orders.", nil),
+	}
+
+	filtered, err := NewRedundantFilter(embedder, 0).CompressDocuments(ctx, docs, "")
+	require.NoError(t, err)
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "1", filtered[0].ID)
+	assert.Equal(t, "3", filtered[1].ID)
+
+	pipeline := NewCompressorPipeline(NewEmbeddingsFilter(embedder, 0.9), NewRedundantFilter(embedder, 0))
+	compressed, err := pipeline.CompressDocuments(ctx, docs, "redis")
+	require.NoError(t, err)
+	require.Len(t, compressed, 1)
+	assert.Equal(t, "1", compressed[0].ID)
+
+	vs := newKeywordStore("redis", "postgres")
+	require.NoError(t, vs.AddDocuments(ctx, docs))
+	retriever := NewContextualCompressionRetriever(NewVectorStoreRetriever(vs, RetrieverConfig{TopK: 3}), pipeline, RetrieverConfig{})
+	result, err := retriever.GetRelevantDocuments(ctx, "redis")
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Contains(t, result[0].PageContent, "Redis")
+}
+
+func newCitationChain(t *testing.T, respond func(prompt string) string) *RAGChain {
+	t.Helper()
+	ctx := context.Background()
+	vs := newKeywordStore("redis", "postgres", "kubernetes")
+	require.NoError(t, vs.AddDocuments(ctx, []*Document{
+		NewDocumentWithID("cache", "Redis caches sessions. Kubernetes schedules pods.", map[string]interface{}{"source": "cache.md"}),
+		NewDocumentWithID("db", "Postgres stores orders.", map[string]interface{}{"source": "db.md"}),
+	}))
+
+	retriever, err := NewRAGRetriever(RAGRetrieverConfig{VectorStore: vs, TopK: 2})
+	require.NoError(t, err)
+	return NewRAGChain(retriever, mocks.NewMockLLMClient(mocks.WithResponder(respond))).
+		WithCompressor(NewEmbeddingsFilter(mocks.NewKeywordEmbedder("redis", "postgres", "kubernetes"), 0.5))
+}
+
+func TestRAGChainRunWithCitations(t *testing.T) {
+	ctx := context.Background()
+	var answerPrompt string
+	chain := newCitationChain(t, func(prompt string) string {
+		if strings.Contains(prompt, "Respond with a JSON array") {
+			return "```json\n" + `[{"sentence": 1, "verdict": "supported", "reason": "stated in [1]"},
+				{"sentence": 2, "verdict": "supported"},
+				{"sentence": 3, "verdict": "unsupported", "reason": "not in sources"}]` + "\n```"
+		}
+		answerPrompt = prompt
+		return "Sessions are cached in Redis [1]. Orders live in Postgres. [2][7] Backups run hourly."
+	})
+
+	answer, err := chain.RunWithCitations(ctx, "redis and postgres")
+	require.NoError(t, err)
+
+	assert.Contains(t, answerPrompt, "Redis caches sessions.")
+	assert.NotContains(t, answerPrompt, "Kubernetes", "compressor trims irrelevant sentences")
+
+	require.Len(t, answer.Sentences, 3)
+	assert.Equal(t, "Sessions are cached in Redis.", answer.Sentences[0].Text)
+	assert.Equal(t, []int{2}, answer.Sentences[1].Citations, "trailing markers attach to the previous sentence; out-of-range [7] is dropped")
+	assert.Empty(t, answer.Sentences[2].Citations)
+	assert.Equal(t, GroundednessUnsupported, answer.Sentences[2].Verdict)
+	assert.False(t, answer.Grounded())
+	assert.Len(t, answer.UnsupportedSentences(), 1)
+
+	require.Len(t, answer.Citations, 2)
+	for _, citation := range answer.Citations {
+		doc := answer.Documents[citation.Index-1]
+		assert.Equal(t, doc.ID, citation.DocumentID)
+		require.NotEmpty(t, citation.Spans)
+	}
+	cacheCitation := answer.Citations[0]
+	if cacheCitation.DocumentID != "cache" {
+		cacheCitation = answer.Citations[1]
+	}
+	assert.Equal(t, "cache.md", cacheCitation.Source)
+	assert.Equal(t, []TextSpan{{Start: 0, End: 22}}, cacheCitation.Spans)
+}
+
+func TestRAGChainRunWithCitationsLexicalFallback(t *testing.T) {
+	ctx := context.Background()
+	chain := newCitationChain(t, func(prompt string) string {
+		if strings.Contains(prompt, "Respond with a JSON array") {
+			return "I think everything is fine."
+		}
+		if strings.Contains(prompt, "[1] Redis") {
+			return "Redis caches sessions [1]. Postgres replicates to Mars [2]."
+		}
+		return "Redis caches sessions [2]. Postgres replicates to Mars [1]."
+	})
+
+	answer, err := chain.RunWithCitations(ctx, "redis and postgres")
+	require.NoError(t, err)
+	require.Len(t, answer.Sentences, 2)
+	assert.Equal(t, GroundednessSupported, answer.Sentences[0].Verdict)
+	assert.NotEqual(t, GroundednessSupported, answer.Sentences[1].Verdict)
+
+	_, err = NewRAGChain(chain.retriever, nil).RunWithCitations(ctx, "redis")
+	assert.Error(t, err)
+
+	output, err := NewRAGChain(chain.retriever, nil).WithCompressor(chain.compressor).Run(ctx, "redis")
+	require.NoError(t, err)
+	assert.NotContains(t, output, "Kubernetes")
+}
@@ -0,0 +1,184 @@
+package retrieval
+
+import (
+	"context"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// DocStore 原始文档存储
+//
+// ParentDocumentRetriever 和 MultiVectorRetriever 在向量存储中索引子块或摘要,
+// 通过 DocStore 按 ID 取回完整的原始文档
+type DocStore interface {
+	// MGet 批量获取文档,结果与 ids 一一对应,不存在的文档为 nil
+	MGet(ctx context.Context, ids []string) ([]*Document, error)
+
+	// MSet 批量保存文档,文档 ID 不能为空
+	MSet(ctx context.Context, docs []*Document) error
+
+	// MDelete 批量删除文档
+	MDelete(ctx context.Context, ids []string) error
+}
+
+// InMemoryDocStore 内存文档存储
+type InMemoryDocStore struct {
+	docs map[string]*Document
+	mu   sync.RWMutex
+}
+
+// NewInMemoryDocStore 创建内存文档存储
+func NewInMemoryDocStore() *InMemoryDocStore {
+	return &InMemoryDocStore{
+		docs: make(map[string]*Document),
+	}
+}
+
+// MGet 批量获取文档(返回副本)
+func (s *InMemoryDocStore) MGet(ctx context.Context, ids []string) ([]*Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Document, len(ids))
+	for i, id := range ids {
+		if doc, ok := s.docs[id]; ok {
+			result[i] = doc.Clone()
+		}
+	}
+	return result, nil
+}
+
+// MSet 批量保存文档
+func (s *InMemoryDocStore) MSet(ctx context.Context, docs []*Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return agentErrors.New(agentErrors.CodeInvalidInput, "document ID is required").
+				WithComponent("docstore").
+				WithOperation("mset")
+		}
+		s.docs[doc.ID] = doc.Clone()
+	}
+	return nil
+}
+
+// MDelete 批量删除文档
+func (s *InMemoryDocStore) MDelete(ctx context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.docs, id)
+	}
+	return nil
+}
+
+// Len 返回文档数量
+func (s *InMemoryDocStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
+
+// KVDocStore 基于 store.Store 的文档存储
+//
+// 可使用 Redis、PostgreSQL 等持久化后端
+type KVDocStore struct {
+	store     store.Store
+	namespace []string
+}
+
+// NewKVDocStore 创建基于 store.Store 的文档存储,namespace 默认为 ["retrieval", "docstore"]
+func NewKVDocStore(s store.Store, namespace ...string) *KVDocStore {
+	if len(namespace) == 0 {
+		namespace = []string{"retrieval", "docstore"}
+	}
+	return &KVDocStore{
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// MGet 批量获取文档
+func (s *KVDocStore) MGet(ctx context.Context, ids []string) ([]*Document, error) {
+	result := make([]*Document, len(ids))
+	for i, id := range ids {
+		value, err := s.store.Get(ctx, s.namespace, id)
+		if agentErrors.IsCode(err, agentErrors.CodeStoreNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to load document").
+				WithComponent("docstore").
+				WithOperation("mget").
+				WithContext("document_id", id)
+		}
+		if value == nil {
+			continue
+		}
+
+		doc, err := decodeStoredDocument(value.Value)
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode document").
+				WithComponent("docstore").
+				WithOperation("mget").
+				WithContext("document_id", id)
+		}
+		result[i] = doc
+	}
+	return result, nil
+}
+
+// MSet 批量保存文档
+func (s *KVDocStore) MSet(ctx context.Context, docs []*Document) error {
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return agentErrors.New(agentErrors.CodeInvalidInput, "document ID is required").
+				WithComponent("docstore").
+				WithOperation("mset")
+		}
+		if err := s.store.Put(ctx, s.namespace, doc.ID, doc.Clone()); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to save document").
+				WithComponent("docstore").
+				WithOperation("mset").
+				WithContext("document_id", doc.ID)
+		}
+	}
+	return nil
+}
+
+// MDelete 批量删除文档
+func (s *KVDocStore) MDelete(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		err := s.store.Delete(ctx, s.namespace, id)
+		if err != nil && !agentErrors.IsCode(err, agentErrors.CodeStoreNotFound) {
+			return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to delete document").
+				WithComponent("docstore").
+				WithOperation("mdelete").
+				WithContext("document_id", id)
+		}
+	}
+	return nil
+}
+
+// decodeStoredDocument 解码存储的文档
+func decodeStoredDocument(value interface{}) (*Document, error) {
+	if doc, ok := value.(*Document); ok {
+		return doc.Clone(), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
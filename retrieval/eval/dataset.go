@@ -0,0 +1,98 @@
+// Package eval 提供 RAG 评估工具
+//
+// 从 JSONL 数据集加载问题、参考答案和相关文档 ID,
+// 对任意 Retriever 和 RAGChain 计算检索指标(recall@k、MRR、nDCG)
+// 以及由评审模型给出的忠实度、答案相关性和上下文精确度,
+// 并生成 JSON / Markdown 报告与基线对比结果
+package eval
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// Example 评估样例
+type Example struct {
+	// ID 样例标识,为空时使用行号
+	ID string `json:"id"`
+
+	// Question 问题
+	Question string `json:"question"`
+
+	// ReferenceAnswer 参考答案
+	ReferenceAnswer string `json:"reference_answer,omitempty"`
+
+	// RelevantDocIDs 相关文档 ID
+	RelevantDocIDs []string `json:"relevant_doc_ids,omitempty"`
+
+	// Metadata 附加信息
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Dataset 评估数据集
+type Dataset struct {
+	Name     string     `json:"name"`
+	Examples []*Example `json:"examples"`
+}
+
+// LoadDataset 从 JSONL 文件加载数据集,数据集名称为文件名(不含扩展名)
+func LoadDataset(path string) (*Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidInput, "failed to open dataset").
+			WithComponent("rag_eval").
+			WithOperation("load_dataset").
+			WithContext("path", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ReadDataset(file, name)
+}
+
+// ReadDataset 从 JSONL 读取数据集,忽略空行和以 # 开头的注释行
+func ReadDataset(r io.Reader, name string) (*Dataset, error) {
+	dataset := &Dataset{Name: name, Examples: make([]*Example, 0)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		example := &Example{}
+		if err := json.Unmarshal([]byte(text), example); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidInput, "invalid dataset line").
+				WithComponent("rag_eval").
+				WithOperation("read_dataset").
+				WithContext("line", line)
+		}
+		if strings.TrimSpace(example.Question) == "" {
+			return nil, agentErrors.New(agentErrors.CodeInvalidInput, "dataset example is missing question").
+				WithComponent("rag_eval").
+				WithOperation("read_dataset").
+				WithContext("line", line)
+		}
+		if example.ID == "" {
+			example.ID = "line-" + itoa(line)
+		}
+		dataset.Examples = append(dataset.Examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidInput, "failed to read dataset").
+			WithComponent("rag_eval").
+			WithOperation("read_dataset")
+	}
+
+	return dataset, nil
+}
@@ -0,0 +1,162 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+// answererFunc 函数形式的 Answerer
+type answererFunc func(ctx context.Context, query string) (string, error)
+
+func (f answererFunc) Run(ctx context.Context, query string) (string, error) {
+	return f(ctx, query)
+}
+
+func newOpsRetriever(t *testing.T) retrieval.Retriever {
+	t.Helper()
+	embedder := mocks.NewKeywordEmbedder("redis", "postgres", "kubernetes", "eviction", "orders")
+	vs := retrieval.NewMemoryVectorStore(retrieval.MemoryVectorStoreConfig{Embedder: embedder})
+	require.NoError(t, vs.AddDocuments(context.Background(), []*retrieval.Document{
+		retrieval.NewDocumentWithID("redis-eviction", "Redis eviction is controlled by maxmemory-policy.", nil),
+		retrieval.NewDocumentWithID("redis-persistence", "Redis persistence uses AOF.", nil),
+		retrieval.NewDocumentWithID("postgres-orders", "Postgres keeps orders in the orders table.", nil),
+		retrieval.NewDocumentWithID("postgres-schema", "The Postgres schema is migrated on deploy.", nil),
+	}))
+	return retrieval.NewVectorStoreRetriever(vs, retrieval.RetrieverConfig{TopK: 2})
+}
+
+func newJudgeLLM() *mocks.MockLLMClient {
+	return mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		switch {
+		case strings.Contains(prompt, "atomic factual claims"):
+			return "```json\n" + `[{"claim": "a", "supported": true}, {"claim": "b", "supported": false}]` + "\n```"
+		case strings.Contains(prompt, "directly addresses"):
+			return `Sure: {"score": 0.9, "reason": "on topic"}`
+		case strings.Contains(prompt, "Reference answer"):
+			return `[{"chunk": 1, "relevant": false}, {"chunk": 2, "relevant": true}]`
+		}
+		return "Generated answer."
+	}))
+}
+
+func TestRetrievalMetrics(t *testing.T) {
+	retrieved := []string{"a", "b", "a", "c", "d"}
+	relevant := []string{"c", "e"}
+
+	assert.InDelta(t, 0.5, RecallAtK(retrieved, relevant, 3), 1e-9, "duplicates do not occupy ranks")
+	assert.InDelta(t, 0.0, RecallAtK(retrieved, relevant, 2), 1e-9)
+	assert.InDelta(t, 1.0/3, ReciprocalRank(retrieved, relevant), 1e-9)
+	assert.InDelta(t, 0.0, ReciprocalRank(retrieved, nil), 1e-9)
+	assert.InDelta(t, 1.0, NDCGAtK([]string{"c", "e"}, relevant, 2), 1e-9)
+	assert.InDelta(t, 0.5/(1+1/1.5849625007211563), NDCGAtK(retrieved, relevant, 3), 1e-9)
+	assert.InDelta(t, (1.0/2+2.0/3)/2, AveragePrecision([]bool{false, true, true}), 1e-9)
+}
+
+func TestLoadDataset(t *testing.T) {
+	dataset, err := LoadDataset(filepath.Join("testdata", "ops.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, "ops", dataset.Name)
+	require.Len(t, dataset.Examples, 3)
+	assert.Equal(t, []string{"postgres-orders", "postgres-schema"}, dataset.Examples[1].RelevantDocIDs)
+
+	_, err = ReadDataset(strings.NewReader("{\"question\": \"ok\"}\n{bad"), "broken")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line")
+
+	dataset, err = ReadDataset(strings.NewReader(`{"question": "no id"}`), "anon")
+	require.NoError(t, err)
+	assert.Equal(t, "line-1", dataset.Examples[0].ID)
+}
+
+func TestEvaluatorRun(t *testing.T) {
+	ctx := context.Background()
+	dataset, err := LoadDataset(filepath.Join("testdata", "ops.jsonl"))
+	require.NoError(t, err)
+
+	retriever := newOpsRetriever(t)
+	ragRetriever, err := retrieval.NewRAGRetriever(retrieval.RAGRetrieverConfig{Retriever: retriever, TopK: 2})
+	require.NoError(t, err)
+
+	judgeLLM := newJudgeLLM()
+	evaluator, err := NewEvaluator(Config{
+		Retriever: retriever,
+		Chain:     retrieval.NewRAGChain(ragRetriever, judgeLLM),
+		Judge:     NewLLMJudge(judgeLLM),
+		K:         2,
+	})
+	require.NoError(t, err)
+
+	report, err := evaluator.Run(ctx, dataset)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 3)
+	assert.Equal(t, 0, report.Failed)
+
+	cache := report.Results[0]
+	assert.Equal(t, "redis-eviction", cache.RetrievedIDs[0])
+	assert.InDelta(t, 1.0, cache.Metrics[MetricMRR], 1e-9)
+	assert.Equal(t, "Generated answer.", cache.Answer)
+
+	assert.InDelta(t, 2.0/3, report.Summary[MetricRecall], 1e-9, "pods has no relevant document in the store")
+	assert.InDelta(t, 0.5, report.Summary[MetricFaithfulness], 1e-9)
+	assert.InDelta(t, 0.9, report.Summary[MetricAnswerRelevance], 1e-9)
+	assert.InDelta(t, 0.5, report.Summary[MetricContextPrecision], 1e-9)
+
+	var md bytes.Buffer
+	require.NoError(t, report.WriteMarkdown(&md))
+	assert.Contains(t, md.String(), "| recall@k | 0.6667 |")
+	assert.Contains(t, md.String(), "| orders |")
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, report.SaveJSON(path))
+	baseline, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report.Summary, baseline.Summary)
+	assert.False(t, report.Compare(baseline, 0).Regressed())
+
+	baseline.Summary[MetricRecall] = 0.9
+	baseline.Summary[MetricMRR] = report.Summary[MetricMRR] + 0.01
+	comparison := report.Compare(baseline, 0.05)
+	require.Len(t, comparison.Regressions(), 1)
+	assert.Equal(t, MetricRecall, comparison.Regressions()[0].Name)
+
+	md.Reset()
+	require.NoError(t, comparison.WriteMarkdown(&md))
+	assert.Contains(t, md.String(), "REGRESSED")
+}
+
+func TestEvaluatorRecordsExampleErrors(t *testing.T) {
+	dataset, err := LoadDataset(filepath.Join("testdata", "ops.jsonl"))
+	require.NoError(t, err)
+
+	evaluator, err := NewEvaluator(Config{
+		Retriever: newOpsRetriever(t),
+		Chain: answererFunc(func(_ context.Context, query string) (string, error) {
+			if strings.Contains(query, "kubernetes") {
+				return "", errors.New("model unavailable")
+			}
+			return "ok", nil
+		}),
+	})
+	require.NoError(t, err)
+
+	report, err := evaluator.Run(context.Background(), dataset)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "model unavailable", report.Results[2].Error)
+	assert.InDelta(t, 1.0, report.Summary[MetricRecall], 1e-9, "failed examples are excluded from the summary")
+	_, judged := report.Summary[MetricFaithfulness]
+	assert.False(t, judged, "no judge configured")
+
+	_, err = NewEvaluator(Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package eval
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/retrieval"
+)
+
+// Answerer 根据问题生成答案,*retrieval.RAGChain 实现了该接口
+type Answerer interface {
+	Run(ctx context.Context, query string) (string, error)
+}
+
+// Config 评估器配置
+type Config struct {
+	// Retriever 被评估的检索器(必需)
+	Retriever retrieval.Retriever
+
+	// Chain 生成答案的链,为空时只计算检索指标
+	Chain Answerer
+
+	// Judge 生成质量评审,为空时跳过忠实度、答案相关性和上下文精确度
+	Judge Judge
+
+	// K 检索指标的截断位置,默认 5
+	K int
+
+	// Concurrency 并发评估的样例数,默认 4
+	Concurrency int
+}
+
+// Evaluator RAG 评估器
+type Evaluator struct {
+	retriever   retrieval.Retriever
+	chain       Answerer
+	judge       Judge
+	k           int
+	concurrency int
+}
+
+// NewEvaluator 创建评估器
+func NewEvaluator(config Config) (*Evaluator, error) {
+	if config.Retriever == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "retriever is required").
+			WithComponent("rag_eval").
+			WithOperation("new_evaluator")
+	}
+
+	if config.K <= 0 {
+		config.K = 5
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+
+	return &Evaluator{
+		retriever:   config.Retriever,
+		chain:       config.Chain,
+		judge:       config.Judge,
+		k:           config.K,
+		concurrency: config.Concurrency,
+	}, nil
+}
+
+// Run 在数据集上运行评估
+//
+// 单个样例失败时记录在结果的 Error 字段中并从汇总中排除,不会中断整次评估;
+// 只有上下文取消时才返回错误
+func (e *Evaluator) Run(ctx context.Context, dataset *Dataset) (*Report, error) {
+	if dataset == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "dataset is nil").
+			WithComponent("rag_eval").
+			WithOperation("run")
+	}
+
+	report := &Report{
+		Dataset:   dataset.Name,
+		K:         e.k,
+		StartedAt: time.Now(),
+		Results:   make([]*ExampleResult, len(dataset.Examples)),
+	}
+
+	sem := make(chan struct{}, e.concurrency)
+	var wg sync.WaitGroup
+	for i, example := range dataset.Examples {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(i int, example *Example) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			report.Results[i] = e.evaluate(ctx, example)
+		}(i, example)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	report.summarize()
+	return report, nil
+}
+
+// evaluate 评估单个样例
+func (e *Evaluator) evaluate(ctx context.Context, example *Example) *ExampleResult {
+	result := &ExampleResult{
+		ID:       example.ID,
+		Question: example.Question,
+		Metrics:  make(map[string]float64),
+	}
+
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	docs, err := e.retriever.GetRelevantDocuments(ctx, example.Question)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.RetrievedIDs = make([]string, 0, len(docs))
+	for _, doc := range docs {
+		result.RetrievedIDs = append(result.RetrievedIDs, doc.ID)
+	}
+
+	if len(example.RelevantDocIDs) > 0 {
+		result.Metrics[MetricRecall] = RecallAtK(result.RetrievedIDs, example.RelevantDocIDs, e.k)
+		result.Metrics[MetricMRR] = ReciprocalRank(result.RetrievedIDs, example.RelevantDocIDs)
+		result.Metrics[MetricNDCG] = NDCGAtK(result.RetrievedIDs, example.RelevantDocIDs, e.k)
+	}
+
+	if e.judge != nil && example.ReferenceAnswer != "" {
+		score, err := e.judge.ContextPrecision(ctx, example.Question, example.ReferenceAnswer, docs)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Metrics[MetricContextPrecision] = score.Score
+	}
+
+	if e.chain == nil {
+		return result
+	}
+
+	answer, err := e.chain.Run(ctx, example.Question)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Answer = answer
+
+	if e.judge == nil {
+		return result
+	}
+
+	faithfulness, err := e.judge.Faithfulness(ctx, example.Question, answer, docs)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Metrics[MetricFaithfulness] = faithfulness.Score
+
+	relevance, err := e.judge.AnswerRelevance(ctx, example.Question, answer)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Metrics[MetricAnswerRelevance] = relevance.Score
+
+	return result
+}
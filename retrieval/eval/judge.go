@@ -0,0 +1,247 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// JudgeScore 评审得分,取值范围 [0, 1]
+type JudgeScore struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Judge 生成质量评审接口
+//
+// 实现可以调用 LLM,也可以是本地规则,CI 中通常使用 mock 客户端
+type Judge interface {
+	// Faithfulness 答案中的陈述有多少能被上下文支持
+	Faithfulness(ctx context.Context, question, answer string, contexts []*retrieval.Document) (JudgeScore, error)
+
+	// AnswerRelevance 答案是否直接回答了问题
+	AnswerRelevance(ctx context.Context, question, answer string) (JudgeScore, error)
+
+	// ContextPrecision 相关上下文是否排在前面
+	ContextPrecision(ctx context.Context, question, reference string, contexts []*retrieval.Document) (JudgeScore, error)
+}
+
+// DefaultFaithfulnessPrompt 默认忠实度评审提示词
+const DefaultFaithfulnessPrompt = `You are evaluating whether an answer is faithful to the given context.
+Break the answer into atomic factual claims and decide for each claim whether it can be inferred from the context.
+
+Context:
+%s
+
+Question: %s
+Answer: %s
+
+Respond with a JSON array only, e.g. [{"claim": "...", "supported": true}].`
+
+// DefaultAnswerRelevancePrompt 默认答案相关性评审提示词
+const DefaultAnswerRelevancePrompt = `You are evaluating whether an answer directly addresses a question.
+Score 1 when the answer is complete and on topic, 0 when it is unrelated or evasive.
+
+Question: %s
+Answer: %s
+
+Respond with a JSON object only, e.g. {"score": 0.8, "reason": "..."}.`
+
+// DefaultContextPrecisionPrompt 默认上下文精确度评审提示词
+const DefaultContextPrecisionPrompt = `You are evaluating retrieved context chunks for a question.
+For each numbered chunk decide whether it is useful for arriving at the reference answer.
+
+Question: %s
+Reference answer: %s
+
+Chunks:
+%s
+
+Respond with a JSON array only, e.g. [{"chunk": 1, "relevant": true}].`
+
+// LLMJudge 基于 LLM 的评审
+//
+// 评审模型与被测模型相互独立,可以使用本地模型或 mock 客户端
+type LLMJudge struct {
+	client llm.Client
+
+	// Temperature 评审温度,默认 0 以保证可复现
+	Temperature float64
+}
+
+// NewLLMJudge 创建 LLM 评审
+func NewLLMJudge(client llm.Client) *LLMJudge {
+	return &LLMJudge{client: client}
+}
+
+// Faithfulness 实现 Judge 接口
+//
+// 得分为被上下文支持的陈述占比,答案没有陈述时得分为 1
+func (j *LLMJudge) Faithfulness(ctx context.Context, question, answer string, contexts []*retrieval.Document) (JudgeScore, error) {
+	prompt := fmt.Sprintf(DefaultFaithfulnessPrompt, formatChunks(contexts), question, answer)
+	output, err := j.complete(ctx, prompt, MetricFaithfulness)
+	if err != nil {
+		return JudgeScore{}, err
+	}
+
+	var claims []struct {
+		Claim     string `json:"claim"`
+		Supported bool   `json:"supported"`
+	}
+	if err := decodeJSON(output, "[", "]", &claims); err != nil {
+		return JudgeScore{}, judgeParseError(err, MetricFaithfulness, output)
+	}
+	if len(claims) == 0 {
+		return JudgeScore{Score: 1, Reason: "no claims"}, nil
+	}
+
+	supported := 0
+	unsupported := make([]string, 0)
+	for _, claim := range claims {
+		if claim.Supported {
+			supported++
+		} else {
+			unsupported = append(unsupported, claim.Claim)
+		}
+	}
+
+	score := JudgeScore{Score: float64(supported) / float64(len(claims))}
+	if len(unsupported) > 0 {
+		score.Reason = "unsupported: " + strings.Join(unsupported, "; ")
+	}
+	return score, nil
+}
+
+// AnswerRelevance 实现 Judge 接口
+func (j *LLMJudge) AnswerRelevance(ctx context.Context, question, answer string) (JudgeScore, error) {
+	prompt := fmt.Sprintf(DefaultAnswerRelevancePrompt, question, answer)
+	output, err := j.complete(ctx, prompt, MetricAnswerRelevance)
+	if err != nil {
+		return JudgeScore{}, err
+	}
+
+	var score JudgeScore
+	if err := decodeJSON(output, "{", "}", &score); err != nil {
+		return JudgeScore{}, judgeParseError(err, MetricAnswerRelevance, output)
+	}
+	score.Score = clamp(score.Score)
+	return score, nil
+}
+
+// ContextPrecision 实现 Judge 接口
+//
+// 按 RAGAS 的定义计算相关块的平均精确度:相关块越靠前得分越高
+func (j *LLMJudge) ContextPrecision(ctx context.Context, question, reference string, contexts []*retrieval.Document) (JudgeScore, error) {
+	if len(contexts) == 0 {
+		return JudgeScore{Reason: "no context"}, nil
+	}
+
+	prompt := fmt.Sprintf(DefaultContextPrecisionPrompt, question, reference, formatChunks(contexts))
+	output, err := j.complete(ctx, prompt, MetricContextPrecision)
+	if err != nil {
+		return JudgeScore{}, err
+	}
+
+	var verdicts []struct {
+		Chunk    int  `json:"chunk"`
+		Relevant bool `json:"relevant"`
+	}
+	if err := decodeJSON(output, "[", "]", &verdicts); err != nil {
+		return JudgeScore{}, judgeParseError(err, MetricContextPrecision, output)
+	}
+
+	relevant := make([]bool, len(contexts))
+	for _, verdict := range verdicts {
+		if verdict.Chunk >= 1 && verdict.Chunk <= len(contexts) {
+			relevant[verdict.Chunk-1] = verdict.Relevant
+		}
+	}
+	return JudgeScore{Score: AveragePrecision(relevant)}, nil
+}
+
+// AveragePrecision 计算按排名给出的相关性判定的平均精确度
+func AveragePrecision(relevant []bool) float64 {
+	hits := 0
+	sum := 0.0
+	for i, ok := range relevant {
+		if ok {
+			hits++
+			sum += float64(hits) / float64(i+1)
+		}
+	}
+	if hits == 0 {
+		return 0
+	}
+	return sum / float64(hits)
+}
+
+// complete 调用评审模型
+func (j *LLMJudge) complete(ctx context.Context, prompt, metric string) (string, error) {
+	if j.client == nil {
+		return "", agentErrors.New(agentErrors.CodeInvalidConfig, "judge LLM client is not configured").
+			WithComponent("rag_eval").
+			WithOperation("judge").
+			WithContext("metric", metric)
+	}
+
+	response, err := j.client.Complete(ctx, &llm.CompletionRequest{
+		Messages:    []llm.Message{llm.UserMessage(prompt)},
+		Temperature: j.Temperature,
+	})
+	if err != nil {
+		return "", agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "judge request failed").
+			WithComponent("rag_eval").
+			WithOperation("judge").
+			WithContext("metric", metric)
+	}
+	return response.Content, nil
+}
+
+// formatChunks 为上下文块编号
+func formatChunks(docs []*retrieval.Document) string {
+	var b strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%d] %s", i+1, doc.PageContent)
+	}
+	return b.String()
+}
+
+// decodeJSON 从模型输出中截取第一个 open 到最后一个 close 之间的 JSON 并解析
+//
+// 兼容 Markdown 代码块和前后的说明文字
+func decodeJSON(text, open, close string, v interface{}) error {
+	start := strings.Index(text, open)
+	end := strings.LastIndex(text, close)
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON found in judge output")
+	}
+	return json.Unmarshal([]byte(text[start:end+1]), v)
+}
+
+// judgeParseError 构造评审输出解析错误
+func judgeParseError(err error, metric, output string) error {
+	return agentErrors.Wrap(err, agentErrors.CodeLLMResponse, "failed to parse judge output").
+		WithComponent("rag_eval").
+		WithOperation("judge").
+		WithContext("metric", metric).
+		WithContext("output", output)
+}
+
+// clamp 将得分限制在 [0, 1]
+func clamp(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
@@ -0,0 +1,122 @@
+package eval
+
+import (
+	"math"
+	"strconv"
+)
+
+// 指标名称
+const (
+	MetricRecall           = "recall@k"
+	MetricMRR              = "mrr"
+	MetricNDCG             = "ndcg@k"
+	MetricFaithfulness     = "faithfulness"
+	MetricAnswerRelevance  = "answer_relevance"
+	MetricContextPrecision = "context_precision"
+)
+
+// metricOrder 报告中指标的展示顺序
+var metricOrder = []string{
+	MetricRecall,
+	MetricMRR,
+	MetricNDCG,
+	MetricFaithfulness,
+	MetricAnswerRelevance,
+	MetricContextPrecision,
+}
+
+// RecallAtK 前 k 个检索结果中命中的相关文档比例
+//
+// relevant 为空时返回 0;k <= 0 时使用全部结果
+func RecallAtK(retrieved, relevant []string, k int) float64 {
+	if len(relevant) == 0 {
+		return 0
+	}
+
+	relevantSet := toSet(relevant)
+	hits := 0
+	for _, id := range topK(dedupe(retrieved), k) {
+		if relevantSet[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(relevantSet))
+}
+
+// ReciprocalRank 第一个相关文档排名的倒数,没有命中时返回 0
+//
+// 对数据集取平均即为 MRR
+func ReciprocalRank(retrieved, relevant []string) float64 {
+	relevantSet := toSet(relevant)
+	for i, id := range dedupe(retrieved) {
+		if relevantSet[id] {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// NDCGAtK 前 k 个结果的归一化折损累计增益(二元相关性)
+func NDCGAtK(retrieved, relevant []string, k int) float64 {
+	if len(relevant) == 0 {
+		return 0
+	}
+
+	relevantSet := toSet(relevant)
+	results := topK(dedupe(retrieved), k)
+
+	dcg := 0.0
+	for i, id := range results {
+		if relevantSet[id] {
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+	}
+
+	ideal := len(relevantSet)
+	if k > 0 && ideal > k {
+		ideal = k
+	}
+	idcg := 0.0
+	for i := 0; i < ideal; i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+	if idcg == 0 {
+		return 0
+	}
+	return dcg / idcg
+}
+
+// toSet 转换为集合
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// dedupe 去除重复 ID,保留首次出现的位置
+func dedupe(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// topK 截取前 k 个元素
+func topK(ids []string, k int) []string {
+	if k > 0 && len(ids) > k {
+		return ids[:k]
+	}
+	return ids
+}
+
+// itoa 整数转字符串
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,255 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// ExampleResult 单个样例的评估结果
+type ExampleResult struct {
+	ID           string             `json:"id"`
+	Question     string             `json:"question"`
+	RetrievedIDs []string           `json:"retrieved_ids,omitempty"`
+	Answer       string             `json:"answer,omitempty"`
+	Metrics      map[string]float64 `json:"metrics"`
+	Latency      time.Duration      `json:"latency"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// Report 评估报告
+type Report struct {
+	Dataset   string             `json:"dataset"`
+	K         int                `json:"k"`
+	StartedAt time.Time          `json:"started_at"`
+	Duration  time.Duration      `json:"duration"`
+	Summary   map[string]float64 `json:"summary"`
+	Failed    int                `json:"failed"`
+	Results   []*ExampleResult   `json:"results"`
+}
+
+// summarize 对成功样例的各项指标取平均
+//
+// 某个指标只对计算了该指标的样例取平均,例如没有相关文档标注的样例不参与 recall
+func (r *Report) summarize() {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	r.Failed = 0
+	for _, result := range r.Results {
+		if result.Error != "" {
+			r.Failed++
+			continue
+		}
+		for name, value := range result.Metrics {
+			sums[name] += value
+			counts[name]++
+		}
+	}
+
+	r.Summary = make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		r.Summary[name] = sum / float64(counts[name])
+	}
+}
+
+// MetricNames 返回报告中出现的指标名称,内置指标在前,其余按字母排序
+func (r *Report) MetricNames() []string {
+	names := make([]string, 0, len(r.Summary))
+	for _, name := range metricOrder {
+		if _, ok := r.Summary[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	extra := make([]string, 0)
+	for name := range r.Summary {
+		if !containsString(metricOrder, name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// WriteJSON 以 JSON 格式写出报告
+func (r *Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to marshal report").
+			WithComponent("rag_eval").
+			WithOperation("write_json")
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// SaveJSON 将报告保存为 JSON 文件,可作为后续对比的基线
+func (r *Report) SaveJSON(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to create report file").
+			WithComponent("rag_eval").
+			WithOperation("save_json").
+			WithContext("path", path)
+	}
+	defer func() { _ = file.Close() }()
+	return r.WriteJSON(file)
+}
+
+// LoadReport 从 JSON 文件加载报告
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidInput, "failed to read report").
+			WithComponent("rag_eval").
+			WithOperation("load_report").
+			WithContext("path", path)
+	}
+
+	report := &Report{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidInput, "invalid report").
+			WithComponent("rag_eval").
+			WithOperation("load_report").
+			WithContext("path", path)
+	}
+	return report, nil
+}
+
+// WriteMarkdown 以 Markdown 格式写出报告
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	names := r.MetricNames()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# RAG Evaluation: %s\n\n", r.Dataset)
+	fmt.Fprintf(&b, "- Examples: %d (failed: %d)\n", len(r.Results), r.Failed)
+	fmt.Fprintf(&b, "- K: %d\n", r.K)
+	fmt.Fprintf(&b, "- Duration: %s\n\n", r.Duration.Round(time.Millisecond))
+
+	b.WriteString("## Summary\n\n| Metric | Score |\n| --- | --- |\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "| %s | %.4f |\n", name, r.Summary[name])
+	}
+
+	b.WriteString("\n## Examples\n\n| ID |")
+	for _, name := range names {
+		fmt.Fprintf(&b, " %s |", name)
+	}
+	b.WriteString(" Error |\n|" + strings.Repeat(" --- |", len(names)+2) + "\n")
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "| %s |", markdownCell(result.ID))
+		for _, name := range names {
+			if value, ok := result.Metrics[name]; ok {
+				fmt.Fprintf(&b, " %.4f |", value)
+			} else {
+				b.WriteString(" - |")
+			}
+		}
+		fmt.Fprintf(&b, " %s |\n", markdownCell(result.Error))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// scoreEpsilon 忽略浮点误差导致的微小下降
+const scoreEpsilon = 1e-9
+
+// MetricDelta 单个指标相对基线的变化
+type MetricDelta struct {
+	Name      string  `json:"name"`
+	Baseline  float64 `json:"baseline"`
+	Current   float64 `json:"current"`
+	Delta     float64 `json:"delta"`
+	Regressed bool    `json:"regressed"`
+}
+
+// Comparison 报告与基线的对比结果
+type Comparison struct {
+	Tolerance float64       `json:"tolerance"`
+	Deltas    []MetricDelta `json:"deltas"`
+}
+
+// Compare 将报告与基线对比
+//
+// 所有指标都是越高越好,下降超过 tolerance 视为回归;
+// 只比较两份报告中都存在的指标
+func (r *Report) Compare(baseline *Report, tolerance float64) *Comparison {
+	comparison := &Comparison{Tolerance: tolerance, Deltas: make([]MetricDelta, 0)}
+	if baseline == nil {
+		return comparison
+	}
+
+	for _, name := range r.MetricNames() {
+		base, ok := baseline.Summary[name]
+		if !ok {
+			continue
+		}
+		current := r.Summary[name]
+		delta := current - base
+		comparison.Deltas = append(comparison.Deltas, MetricDelta{
+			Name:      name,
+			Baseline:  base,
+			Current:   current,
+			Delta:     delta,
+			Regressed: delta < -tolerance-scoreEpsilon,
+		})
+	}
+	return comparison
+}
+
+// Regressed 是否存在回归的指标
+func (c *Comparison) Regressed() bool {
+	return len(c.Regressions()) > 0
+}
+
+// Regressions 返回回归的指标
+func (c *Comparison) Regressions() []MetricDelta {
+	regressions := make([]MetricDelta, 0)
+	for _, delta := range c.Deltas {
+		if delta.Regressed {
+			regressions = append(regressions, delta)
+		}
+	}
+	return regressions
+}
+
+// WriteMarkdown 以 Markdown 格式写出对比结果
+func (c *Comparison) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Baseline Comparison (tolerance %.4f)\n\n", c.Tolerance)
+	b.WriteString("| Metric | Baseline | Current | Delta | Status |\n| --- | --- | --- | --- | --- |\n")
+	for _, delta := range c.Deltas {
+		status := "ok"
+		if delta.Regressed {
+			status = "REGRESSED"
+		}
+		fmt.Fprintf(&b, "| %s | %.4f | %.4f | %+.4f | %s |\n",
+			delta.Name, delta.Baseline, delta.Current, delta.Delta, status)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell 转义表格单元格内容
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+// containsString 判断切片是否包含字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
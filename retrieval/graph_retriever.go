@@ -0,0 +1,309 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/memory/graph"
+)
+
+// MetadataSourceKnowledgeGraph 图谱生成文档的 source 元数据取值
+const MetadataSourceKnowledgeGraph = "knowledge_graph"
+
+// defaultEntityLinkingPrompt 默认实体识别提示词
+const defaultEntityLinkingPrompt = `List the named entities (people, organizations, products, services, places, concepts) mentioned in the question below.
+Output one entity name per line and nothing else.
+
+Question: %s`
+
+// maxLinkingNGram 实体链接时枚举的最长词组
+const maxLinkingNGram = 4
+
+// linkingTokenPattern 实体链接时的分词规则
+var linkingTokenPattern = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}_\-.]*`)
+
+// GraphRetrieverConfig 图谱检索器配置
+type GraphRetrieverConfig struct {
+	// Store 图存储(必需)
+	Store graph.Store
+
+	// LLMClient 用于从查询中识别实体,为空时只使用词组匹配
+	LLMClient llm.Client
+
+	// VectorRetriever 向量检索器,结果与图谱结果融合
+	VectorRetriever Retriever
+
+	// Hops 邻域扩展跳数,默认 2
+	Hops int
+
+	// MaxRelations 最多使用的关系数,默认 30
+	MaxRelations int
+
+	// IncludeCommunities 是否加入种子实体所在社区的摘要
+	IncludeCommunities bool
+
+	// Fusion 排名融合方法,默认 RRF
+	Fusion *RankFusion
+
+	RetrieverConfig
+}
+
+// GraphRetriever 基于知识图谱的检索器 (GraphRAG)
+//
+// 检索流程:实体链接 -> k 跳邻域扩展 -> 社区摘要,
+// 每条关系生成一个事实文档,再与向量检索结果通过 RankFusion 合并
+type GraphRetriever struct {
+	*BaseRetriever
+
+	store              graph.Store
+	llmClient          llm.Client
+	vectorRetriever    Retriever
+	hops               int
+	maxRelations       int
+	includeCommunities bool
+	fusion             *RankFusion
+}
+
+// NewGraphRetriever 创建图谱检索器
+func NewGraphRetriever(config GraphRetrieverConfig) (*GraphRetriever, error) {
+	if config.Store == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "graph store is required").
+			WithComponent("graph_retriever").
+			WithOperation("new")
+	}
+
+	if config.Hops <= 0 {
+		config.Hops = 2
+	}
+	if config.MaxRelations <= 0 {
+		config.MaxRelations = 30
+	}
+	if config.Fusion == nil {
+		config.Fusion = NewRankFusion("rrf")
+	}
+
+	retriever := &GraphRetriever{
+		BaseRetriever:      NewBaseRetriever(),
+		store:              config.Store,
+		llmClient:          config.LLMClient,
+		vectorRetriever:    config.VectorRetriever,
+		hops:               config.Hops,
+		maxRelations:       config.MaxRelations,
+		includeCommunities: config.IncludeCommunities,
+		fusion:             config.Fusion,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "graph_retriever"
+	}
+
+	return retriever, nil
+}
+
+// GetRelevantDocuments 检索相关文档
+func (g *GraphRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return g.traceRetrieval(ctx, query, g.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (g *GraphRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	seeds, err := g.LinkEntities(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := make([][]*Document, 0, 3)
+
+	if len(seeds) > 0 {
+		seedIDs := make([]string, 0, len(seeds))
+		for _, entity := range seeds {
+			seedIDs = append(seedIDs, entity.ID)
+		}
+
+		sub, err := g.store.Neighborhood(ctx, seedIDs, g.hops, g.maxRelations)
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "graph neighborhood expansion failed").
+				WithComponent("graph_retriever").
+				WithOperation("get_relevant_documents").
+				WithContext("query", query)
+		}
+		if facts := factDocuments(sub); len(facts) > 0 {
+			rankings = append(rankings, facts)
+		}
+
+		if g.includeCommunities {
+			communities, err := g.store.GetCommunities(ctx, seedIDs)
+			if err != nil {
+				return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "failed to load community summaries").
+					WithComponent("graph_retriever").
+					WithOperation("get_relevant_documents").
+					WithContext("query", query)
+			}
+			if docs := communityDocuments(communities, seedIDs); len(docs) > 0 {
+				rankings = append(rankings, docs)
+			}
+		}
+	}
+
+	if g.vectorRetriever != nil {
+		docs, err := g.vectorRetriever.GetRelevantDocuments(ctx, query)
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "vector retrieval failed").
+				WithComponent("graph_retriever").
+				WithOperation("get_relevant_documents").
+				WithContext("query", query)
+		}
+		if len(docs) > 0 {
+			rankings = append(rankings, docs)
+		}
+	}
+
+	var results []*Document
+	switch len(rankings) {
+	case 0:
+		return []*Document{}, nil
+	case 1:
+		results = g.FilterByScore(rankings[0])
+	default:
+		// 融合后的分数与原始分数不在同一尺度,不再按 MinScore 过滤
+		results = g.fusion.Fuse(rankings)
+	}
+
+	return g.LimitTopK(results), nil
+}
+
+// LinkEntities 将查询链接到图谱中的实体
+//
+// 枚举查询中最多 4 个词的词组按名称和别名匹配;
+// 配置了 LLM 时还会加入 LLM 识别出的实体名称
+func (g *GraphRetriever) LinkEntities(ctx context.Context, query string) ([]*graph.Entity, error) {
+	candidates := queryNGrams(query, maxLinkingNGram)
+
+	if g.llmClient != nil {
+		response, err := g.llmClient.Complete(ctx, &llm.CompletionRequest{
+			Messages:    []llm.Message{llm.UserMessage(fmt.Sprintf(defaultEntityLinkingPrompt, query))},
+			Temperature: 0,
+		})
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "entity linking failed").
+				WithComponent("graph_retriever").
+				WithOperation("link_entities").
+				WithContext("query", query)
+		}
+		candidates = append(parseLines(response.Content, 0), candidates...)
+	}
+
+	entities, err := g.store.FindEntities(ctx, candidates)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "entity lookup failed").
+			WithComponent("graph_retriever").
+			WithOperation("link_entities").
+			WithContext("query", query)
+	}
+	return entities, nil
+}
+
+// factDocuments 将子图中的关系转换为事实文档
+//
+// 文档顺序与存储返回的关系顺序一致(按跳数、权重),分数为 1/跳数
+func factDocuments(sub *graph.Subgraph) []*Document {
+	names := make(map[string]string, len(sub.Entities))
+	for id, entity := range sub.Entities {
+		names[id] = entity.Name
+	}
+	name := func(id string) string {
+		if n := names[id]; n != "" {
+			return n
+		}
+		return id
+	}
+
+	docs := make([]*Document, 0, len(sub.Relations))
+	for _, relation := range sub.Relations {
+		hop := sub.Depth[relation.Source]
+		if d := sub.Depth[relation.Target]; d > hop {
+			hop = d
+		}
+		if hop < 1 {
+			hop = 1
+		}
+
+		content := fmt.Sprintf("%s %s %s", name(relation.Source), relation.Type, name(relation.Target))
+		sources := make([]string, 0, len(relation.Provenance))
+		for _, p := range relation.Provenance {
+			sources = append(sources, p.SourceID)
+		}
+		if len(relation.Provenance) > 0 && relation.Provenance[0].Evidence != "" {
+			content += "\nEvidence: " + relation.Provenance[0].Evidence
+		}
+
+		doc := NewDocumentWithID("graph:relation:"+relation.ID, content, map[string]interface{}{
+			"source":        MetadataSourceKnowledgeGraph,
+			"relation_type": relation.Type,
+			"subject":       relation.Source,
+			"object":        relation.Target,
+			"hop":           hop,
+			"weight":        relation.Weight,
+			"source_ids":    sources,
+		})
+		doc.Score = 1 / float64(hop)
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// communityDocuments 将社区摘要转换为文档,分数为社区包含的种子实体比例
+func communityDocuments(communities []*graph.Community, seeds []string) []*Document {
+	seedSet := make(map[string]bool, len(seeds))
+	for _, id := range seeds {
+		seedSet[id] = true
+	}
+
+	docs := make([]*Document, 0, len(communities))
+	for _, community := range communities {
+		if strings.TrimSpace(community.Summary) == "" {
+			continue
+		}
+		matched := 0
+		for _, id := range community.EntityIDs {
+			if seedSet[id] {
+				matched++
+			}
+		}
+
+		doc := NewDocumentWithID("graph:community:"+community.ID, community.Summary, map[string]interface{}{
+			"source":       MetadataSourceKnowledgeGraph,
+			"community_id": community.ID,
+			"entity_ids":   community.EntityIDs,
+		})
+		doc.Score = float64(matched) / float64(len(seeds))
+		docs = append(docs, doc)
+	}
+	DocumentCollection(docs).SortByScore()
+	return docs
+}
+
+// queryNGrams 枚举查询中长度不超过 maxN 的连续词组
+func queryNGrams(query string, maxN int) []string {
+	tokens := linkingTokenPattern.FindAllString(query, -1)
+	for i, token := range tokens {
+		tokens[i] = strings.TrimRight(token, ".-")
+	}
+
+	grams := make([]string, 0, len(tokens)*maxN)
+	for n := maxN; n >= 1; n-- {
+		for i := 0; i+n <= len(tokens); i++ {
+			grams = append(grams, strings.Join(tokens[i:i+n], " "))
+		}
+	}
+	return grams
+}
@@ -0,0 +1,121 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/memory/graph"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+func newTestKnowledgeGraph(t *testing.T) *graph.KnowledgeGraph {
+	t.Helper()
+	ctx := context.Background()
+	kg := graph.NewKnowledgeGraph(graph.Config{})
+
+	triple := func(subject, predicate, object, source, evidence string) *graph.Triple {
+		return &graph.Triple{
+			Subject:    graph.NewEntity(subject, ""),
+			Predicate:  predicate,
+			Object:     graph.NewEntity(object, ""),
+			Provenance: graph.Provenance{SourceID: source, Evidence: evidence},
+		}
+	}
+	require.NoError(t, kg.AddTriples(ctx, []*graph.Triple{
+		triple("Payments API", "depends_on", "Postgres", "arch.md", "The Payments API stores data in Postgres."),
+		triple("Postgres", "replicates_to", "Standby DB", "arch.md", ""),
+		triple("Standby DB", "located_in", "Frankfurt", "dr.md", ""),
+		triple("Search Service", "depends_on", "Elasticsearch", "search.md", ""),
+	}))
+	_, err := kg.BuildCommunities(ctx)
+	require.NoError(t, err)
+	return kg
+}
+
+func TestGraphRetrieverNeighborhood(t *testing.T) {
+	ctx := context.Background()
+	kg := newTestKnowledgeGraph(t)
+
+	retriever, err := NewGraphRetriever(GraphRetrieverConfig{
+		Store:           kg.Store(),
+		RetrieverConfig: RetrieverConfig{TopK: 10},
+	})
+	require.NoError(t, err)
+
+	docs, err := retriever.GetRelevantDocuments(ctx, "What does the payments api depend on?")
+	require.NoError(t, err)
+	require.Len(t, docs, 2, "two hops from the payments api")
+	assert.Equal(t, "Payments API depends_on Postgres\nEvidence: The Payments API stores data in Postgres.", docs[0].PageContent)
+	assert.Equal(t, 1.0, docs[0].Score)
+	assert.Equal(t, MetadataSourceKnowledgeGraph, docs[0].Metadata["source"])
+	assert.Equal(t, []string{"arch.md"}, docs[0].Metadata["source_ids"])
+	assert.Equal(t, "Postgres replicates_to Standby DB", docs[1].PageContent)
+	assert.Equal(t, 0.5, docs[1].Score)
+
+	none, err := retriever.GetRelevantDocuments(ctx, "unrelated question")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	_, err = NewGraphRetriever(GraphRetrieverConfig{})
+	assert.Error(t, err)
+}
+
+func TestGraphRetrieverFusionAndLinking(t *testing.T) {
+	ctx := context.Background()
+	kg := newTestKnowledgeGraph(t)
+
+	vs := newKeywordStore("standby", "frankfurt")
+	require.NoError(t, vs.AddDocuments(ctx, []*Document{
+		NewDocumentWithID("dr.md", "The standby database runs in Frankfurt.", nil),
+	}))
+
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		if strings.Contains(prompt, "List the named entities") {
+			return "- Standby DB\n- Mars"
+		}
+		return ""
+	}))
+
+	retriever, err := NewGraphRetriever(GraphRetrieverConfig{
+		Store:              kg.Store(),
+		LLMClient:          client,
+		VectorRetriever:    NewVectorStoreRetriever(vs, RetrieverConfig{TopK: 1}),
+		Hops:               1,
+		IncludeCommunities: true,
+		RetrieverConfig:    RetrieverConfig{TopK: 10},
+	})
+	require.NoError(t, err)
+
+	seeds, err := retriever.LinkEntities(ctx, "where is the replica?")
+	require.NoError(t, err)
+	require.Len(t, seeds, 1, "the LLM resolves entities the query does not name verbatim")
+	assert.Equal(t, "standby db", seeds[0].ID)
+
+	docs, err := retriever.GetRelevantDocuments(ctx, "where is the replica?")
+	require.NoError(t, err)
+
+	ids := make(map[string]bool)
+	for _, doc := range docs {
+		ids[doc.ID] = true
+	}
+	assert.True(t, ids["dr.md"], "vector results are fused in")
+	assert.Len(t, docs, 4, "two facts, one community summary and one vector result")
+
+	var community *Document
+	for _, doc := range docs {
+		if strings.HasPrefix(doc.ID, "graph:community:") {
+			community = doc
+		}
+	}
+	require.NotNil(t, community)
+	assert.Contains(t, community.PageContent, "Frankfurt")
+	assert.NotContains(t, community.PageContent, "Elasticsearch")
+}
+
+func TestQueryNGrams(t *testing.T) {
+	assert.Equal(t, []string{"Payments API v2", "Payments API", "API v2", "Payments", "API", "v2"}, queryNGrams("Payments API v2?", 3))
+}
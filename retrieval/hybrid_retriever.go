@@ -66,6 +66,11 @@ func NewHybridRetriever(
 
 // GetRelevantDocuments 检索相关文档
 func (h *HybridRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return h.traceRetrieval(ctx, query, h.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (h *HybridRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
 	// 并发执行两种检索
 	type result struct {
 		docs []*Document
@@ -0,0 +1,204 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+)
+
+// VectorSearcher 支持按向量检索的向量存储
+//
+// MemoryVectorStore 和 QdrantVectorStore 均实现了该接口
+type VectorSearcher interface {
+	SearchByVector(ctx context.Context, queryVector []float32, topK int) ([]*Document, error)
+}
+
+// defaultHyDEPrompt 默认的假设文档生成提示词
+const defaultHyDEPrompt = `Please write a short passage that answers the question.
+The passage should read like an excerpt from a reference document, stating facts directly.
+
+Question: {{.Question}}
+
+Passage:`
+
+// HyDERetriever 假设文档嵌入检索器 (Hypothetical Document Embeddings)
+//
+// 先让 LLM 生成问题的假设答案,再用假设答案而非原始问题检索。
+// 假设答案与真实文档的表述更接近,能改善短问题的召回
+type HyDERetriever struct {
+	*BaseRetriever
+
+	// VectorStore 向量存储
+	VectorStore VectorStore
+
+	// LLMClient LLM 客户端(用于生成假设文档)
+	LLMClient llm.Client
+
+	// Embedder 嵌入器(可选)
+	//
+	// 设置且 VectorStore 实现 VectorSearcher 时,对所有假设文档的向量取平均后检索;
+	// 否则分别用每个假设文档的文本检索并合并结果
+	Embedder Embedder
+
+	// NumHypotheses 生成的假设文档数量
+	NumHypotheses int
+
+	// IncludeQuery 是否将原始问题与假设文档一起参与检索
+	IncludeQuery bool
+
+	// Prompt 假设文档生成提示词
+	Prompt string
+}
+
+// NewHyDERetriever 创建 HyDE 检索器
+func NewHyDERetriever(
+	vectorStore VectorStore,
+	llmClient llm.Client,
+	config RetrieverConfig,
+) *HyDERetriever {
+	retriever := &HyDERetriever{
+		BaseRetriever: NewBaseRetriever(),
+		VectorStore:   vectorStore,
+		LLMClient:     llmClient,
+		NumHypotheses: 1,
+		Prompt:        defaultHyDEPrompt,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "hyde_retriever"
+	}
+
+	return retriever
+}
+
+// GetRelevantDocuments 检索相关文档
+func (h *HyDERetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return h.traceRetrieval(ctx, query, h.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (h *HyDERetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	texts, err := h.generateHypotheses(ctx, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "failed to generate hypothetical documents").
+			WithComponent("hyde_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+	if h.IncludeQuery {
+		texts = append(texts, query)
+	}
+
+	var docs []*Document
+	if searcher, ok := h.VectorStore.(VectorSearcher); ok && h.Embedder != nil {
+		docs, err = h.searchByMeanVector(ctx, searcher, texts)
+	} else {
+		docs, err = h.searchByTexts(ctx, texts)
+	}
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "hyde search failed").
+			WithComponent("hyde_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+
+	return h.LimitTopK(h.FilterByScore(docs)), nil
+}
+
+// generateHypotheses 生成假设文档
+func (h *HyDERetriever) generateHypotheses(ctx context.Context, query string) ([]string, error) {
+	prompt := strings.ReplaceAll(h.Prompt, "{{.Question}}", query)
+
+	n := h.NumHypotheses
+	if n <= 0 {
+		n = 1
+	}
+
+	hypotheses := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		response, err := h.LLMClient.Complete(ctx, &llm.CompletionRequest{
+			Messages: []llm.Message{
+				llm.UserMessage(prompt),
+			},
+			Temperature: 0.7,
+			MaxTokens:   500,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if text := strings.TrimSpace(response.Content); text != "" {
+			hypotheses = append(hypotheses, text)
+		}
+	}
+
+	if len(hypotheses) == 0 {
+		// LLM 未返回内容时退化为普通检索
+		hypotheses = append(hypotheses, query)
+	}
+	return hypotheses, nil
+}
+
+// searchByMeanVector 使用平均向量检索
+func (h *HyDERetriever) searchByMeanVector(ctx context.Context, searcher VectorSearcher, texts []string) ([]*Document, error) {
+	vectors, err := h.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	mean := make([]float32, h.Embedder.Dimensions())
+	for _, vec := range vectors {
+		for i := 0; i < len(mean) && i < len(vec); i++ {
+			mean[i] += vec[i]
+		}
+	}
+	for i := range mean {
+		mean[i] /= float32(len(vectors))
+	}
+
+	return searcher.SearchByVector(ctx, mean, h.TopK)
+}
+
+// searchByTexts 分别检索每段文本,按文档取最高分合并
+func (h *HyDERetriever) searchByTexts(ctx context.Context, texts []string) ([]*Document, error) {
+	lists := make([][]*Document, 0, len(texts))
+	for _, text := range texts {
+		docs, err := h.VectorStore.SimilaritySearchWithScore(ctx, text, h.TopK)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, docs)
+	}
+
+	return mergeByMaxScore(lists...), nil
+}
+
+// WithEmbedder 设置嵌入器
+func (h *HyDERetriever) WithEmbedder(embedder Embedder) *HyDERetriever {
+	h.Embedder = embedder
+	return h
+}
+
+// WithNumHypotheses 设置假设文档数量
+func (h *HyDERetriever) WithNumHypotheses(num int) *HyDERetriever {
+	h.NumHypotheses = num
+	return h
+}
+
+// WithIncludeQuery 设置是否包含原始问题
+func (h *HyDERetriever) WithIncludeQuery(include bool) *HyDERetriever {
+	h.IncludeQuery = include
+	return h
+}
+
+// WithPrompt 设置假设文档生成提示词
+func (h *HyDERetriever) WithPrompt(prompt string) *HyDERetriever {
+	h.Prompt = prompt
+	return h
+}
@@ -0,0 +1,500 @@
+package retrieval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// IndexMode 索引模式
+type IndexMode string
+
+const (
+	// IndexModeIncremental 增量模式:只写入变化的块,并清理本次涉及来源中的过期块
+	IndexModeIncremental IndexMode = "incremental"
+
+	// IndexModeFull 全量模式:在增量基础上删除本次未出现的来源的所有块
+	IndexModeFull IndexMode = "full"
+
+	// IndexModeDryRun 演练模式:按全量模式生成计划,但不写入任何数据
+	IndexModeDryRun IndexMode = "dry_run"
+)
+
+// 索引相关的元数据键
+const (
+	// MetadataContentHash 块内容哈希
+	MetadataContentHash = "content_hash"
+)
+
+// indexerIDNamespace 生成确定性块 ID 的 UUID 命名空间
+var indexerIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("github.com/kart-io/goagent/retrieval/indexer"))
+
+// VectorAdder 支持直接写入预计算向量的向量存储
+//
+// MemoryVectorStore 和 QdrantVectorStore 实现了该接口
+type VectorAdder interface {
+	Add(ctx context.Context, docs []*Document, vectors [][]float32) error
+}
+
+// IndexerConfig 索引器配置
+type IndexerConfig struct {
+	// VectorStore 目标向量存储
+	VectorStore VectorStore
+
+	// RecordManager 索引记录管理器
+	RecordManager *RecordManager
+
+	// Embedder 嵌入器,设置且向量存储实现 VectorAdder 时由索引器并发计算向量,
+	// 否则交给向量存储的 AddDocuments 处理
+	Embedder Embedder
+
+	// SourceIDKey 元数据中表示来源的键,默认 "source"
+	SourceIDKey string
+
+	// BatchSize 每批写入的块数量,默认 64
+	BatchSize int
+
+	// Concurrency 并发批次数,默认 4
+	Concurrency int
+
+	// MaxRetries 单个批次的最大重试次数,默认 3
+	MaxRetries int
+
+	// RetryDelay 首次重试前的等待时间,之后按指数增长,默认 200ms
+	RetryDelay time.Duration
+
+	// Callbacks 进度回调
+	//
+	// 开始和结束时触发 OnChainStart/OnChainEnd("indexer"),
+	// 每个批次完成后触发 OnChainEnd("indexer.batch", IndexProgress),失败时触发 OnChainError
+	Callbacks []core.Callback
+}
+
+// IndexResult 索引结果
+type IndexResult struct {
+	Mode IndexMode `json:"mode"`
+
+	// NumAdded 新写入的块数量
+	NumAdded int `json:"num_added"`
+
+	// NumSkipped 内容未变化而跳过的块数量(含同一来源内的重复块)
+	NumSkipped int `json:"num_skipped"`
+
+	// NumDeleted 删除的过期块数量
+	NumDeleted int `json:"num_deleted"`
+
+	// AddedIDs 写入的块 ID
+	AddedIDs []string `json:"added_ids,omitempty"`
+
+	// DeletedIDs 删除的块 ID
+	DeletedIDs []string `json:"deleted_ids,omitempty"`
+
+	// SourcesUpdated 内容发生变化的来源
+	SourcesUpdated []string `json:"sources_updated,omitempty"`
+
+	// SourcesDeleted 被整体删除的来源(仅全量模式)
+	SourcesDeleted []string `json:"sources_deleted,omitempty"`
+
+	Duration time.Duration `json:"duration"`
+}
+
+// IndexProgress 批次进度
+type IndexProgress struct {
+	Stage string `json:"stage"` // "add" 或 "delete"
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+}
+
+// Indexer 增量索引器
+//
+// 根据内容哈希为每个块生成确定性 ID,并通过 RecordManager 记录每个来源已索引的块,
+// 重复运行 加载 → 分割 → 索引 流程时不会产生重复块
+type Indexer struct {
+	vectorStore   VectorStore
+	recordManager *RecordManager
+	embedder      Embedder
+	sourceIDKey   string
+	batchSize     int
+	concurrency   int
+	maxRetries    int
+	retryDelay    time.Duration
+	callbacks     []core.Callback
+}
+
+// NewIndexer 创建索引器
+func NewIndexer(config IndexerConfig) (*Indexer, error) {
+	if config.VectorStore == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "vector store is required").
+			WithComponent("indexer").
+			WithOperation("new")
+	}
+	if config.RecordManager == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "record manager is required").
+			WithComponent("indexer").
+			WithOperation("new")
+	}
+	if config.SourceIDKey == "" {
+		config.SourceIDKey = "source"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 64
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 200 * time.Millisecond
+	}
+
+	return &Indexer{
+		vectorStore:   config.VectorStore,
+		recordManager: config.RecordManager,
+		embedder:      config.Embedder,
+		sourceIDKey:   config.SourceIDKey,
+		batchSize:     config.BatchSize,
+		concurrency:   config.Concurrency,
+		maxRetries:    config.MaxRetries,
+		retryDelay:    config.RetryDelay,
+		callbacks:     config.Callbacks,
+	}, nil
+}
+
+// indexPlan 索引计划
+type indexPlan struct {
+	toAdd          []*Document
+	toDelete       []string
+	records        []*SourceRecord
+	deletedSources []string
+	skipped        int
+	updated        []string
+}
+
+// Index 索引文档
+//
+// docs 通常是分割后的块,每个块的元数据中必须包含 SourceIDKey 指定的来源。
+// 全量模式和演练模式要求 docs 是完整的语料,未出现的来源会被视为已删除
+func (ix *Indexer) Index(ctx context.Context, docs []*Document, mode IndexMode) (*IndexResult, error) {
+	if mode == "" {
+		mode = IndexModeIncremental
+	}
+	if mode != IndexModeIncremental && mode != IndexModeFull && mode != IndexModeDryRun {
+		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "unknown index mode").
+			WithComponent("indexer").
+			WithOperation("index").
+			WithContext("mode", string(mode))
+	}
+
+	start := time.Now()
+	ix.trigger(func(cb core.Callback) error {
+		return cb.OnChainStart(ctx, "indexer", map[string]interface{}{
+			"mode":     string(mode),
+			"num_docs": len(docs),
+		})
+	})
+
+	result, err := ix.index(ctx, docs, mode)
+	if err != nil {
+		ix.trigger(func(cb core.Callback) error {
+			return cb.OnChainError(ctx, "indexer", err)
+		})
+		return nil, err
+	}
+
+	result.Duration = time.Since(start)
+	ix.trigger(func(cb core.Callback) error {
+		return cb.OnChainEnd(ctx, "indexer", result)
+	})
+
+	return result, nil
+}
+
+// index 生成计划并执行
+func (ix *Indexer) index(ctx context.Context, docs []*Document, mode IndexMode) (*IndexResult, error) {
+	plan, err := ix.plan(ctx, docs, mode != IndexModeIncremental)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IndexResult{
+		Mode:           mode,
+		NumAdded:       len(plan.toAdd),
+		NumSkipped:     plan.skipped,
+		NumDeleted:     len(plan.toDelete),
+		AddedIDs:       make([]string, len(plan.toAdd)),
+		DeletedIDs:     plan.toDelete,
+		SourcesUpdated: plan.updated,
+		SourcesDeleted: plan.deletedSources,
+	}
+	for i, doc := range plan.toAdd {
+		result.AddedIDs[i] = doc.ID
+	}
+
+	if mode == IndexModeDryRun {
+		return result, nil
+	}
+
+	if err := ix.addDocuments(ctx, plan.toAdd); err != nil {
+		return nil, err
+	}
+	if err := ix.deleteDocuments(ctx, plan.toDelete); err != nil {
+		return nil, err
+	}
+
+	// 向量存储写入成功后再更新记录,失败时下次运行会重新处理这些来源
+	for _, record := range plan.records {
+		if err := ix.recordManager.Put(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	for _, sourceID := range plan.deletedSources {
+		if err := ix.recordManager.Delete(ctx, sourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// plan 对比记录生成索引计划
+func (ix *Indexer) plan(ctx context.Context, docs []*Document, cleanup bool) (*indexPlan, error) {
+	bySource := make(map[string][]*Document)
+	order := make([]string, 0)
+	for i, doc := range docs {
+		sourceID, ok := doc.Metadata[ix.sourceIDKey].(string)
+		if !ok || sourceID == "" {
+			return nil, agentErrors.New(agentErrors.CodeInvalidInput, "document is missing source id").
+				WithComponent("indexer").
+				WithOperation("plan").
+				WithContext("source_id_key", ix.sourceIDKey).
+				WithContext("doc_index", i)
+		}
+		if _, seen := bySource[sourceID]; !seen {
+			order = append(order, sourceID)
+		}
+		bySource[sourceID] = append(bySource[sourceID], doc)
+	}
+
+	plan := &indexPlan{}
+	now := time.Now()
+
+	for _, sourceID := range order {
+		existing, err := ix.recordManager.Get(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		oldChunks := map[string]string{}
+		if existing != nil {
+			oldChunks = existing.Chunks
+		}
+
+		newChunks := make(map[string]string)
+		changed := false
+		for _, doc := range bySource[sourceID] {
+			hash := ContentHash(doc.PageContent)
+			id := ChunkID(sourceID, hash)
+			if _, dup := newChunks[id]; dup {
+				plan.skipped++
+				continue
+			}
+			newChunks[id] = hash
+
+			if _, ok := oldChunks[id]; ok {
+				plan.skipped++
+				continue
+			}
+
+			chunk := doc.Clone()
+			chunk.ID = id
+			chunk.Metadata[MetadataContentHash] = hash
+			plan.toAdd = append(plan.toAdd, chunk)
+			changed = true
+		}
+
+		for id := range oldChunks {
+			if _, ok := newChunks[id]; !ok {
+				plan.toDelete = append(plan.toDelete, id)
+				changed = true
+			}
+		}
+
+		if changed {
+			plan.updated = append(plan.updated, sourceID)
+			plan.records = append(plan.records, &SourceRecord{
+				SourceID:  sourceID,
+				Chunks:    newChunks,
+				UpdatedAt: now,
+			})
+		}
+	}
+
+	if cleanup {
+		sources, err := ix.recordManager.ListSources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, sourceID := range sources {
+			if _, ok := bySource[sourceID]; ok {
+				continue
+			}
+			record, err := ix.recordManager.Get(ctx, sourceID)
+			if err != nil {
+				return nil, err
+			}
+			if record != nil {
+				plan.toDelete = append(plan.toDelete, record.ChunkIDs()...)
+			}
+			plan.deletedSources = append(plan.deletedSources, sourceID)
+		}
+	}
+
+	sort.Strings(plan.toDelete)
+	return plan, nil
+}
+
+// addDocuments 分批并发写入
+func (ix *Indexer) addDocuments(ctx context.Context, docs []*Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	batches := splitBatches(docs, ix.batchSize)
+	adder, canAddVectors := ix.vectorStore.(VectorAdder)
+	useEmbedder := ix.embedder != nil && canAddVectors
+
+	var mu sync.Mutex
+	done := 0
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(ix.concurrency)
+	for i, batch := range batches {
+		g.Go(func() error {
+			err := ix.retry(gctx, func() error {
+				if !useEmbedder {
+					return ix.vectorStore.AddDocuments(gctx, batch)
+				}
+
+				texts := make([]string, len(batch))
+				for j, doc := range batch {
+					texts[j] = doc.PageContent
+				}
+				vectors, err := ix.embedder.Embed(gctx, texts)
+				if err != nil {
+					return agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed batch")
+				}
+				return adder.Add(gctx, batch, vectors)
+			})
+			if err != nil {
+				return agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to index batch").
+					WithComponent("indexer").
+					WithOperation("add_documents").
+					WithContext("batch", i).
+					WithContext("batch_size", len(batch))
+			}
+
+			mu.Lock()
+			done += len(batch)
+			progress := IndexProgress{Stage: "add", Done: done, Total: len(docs)}
+			mu.Unlock()
+			ix.trigger(func(cb core.Callback) error {
+				return cb.OnChainEnd(ctx, "indexer.batch", progress)
+			})
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// deleteDocuments 分批删除过期块
+func (ix *Indexer) deleteDocuments(ctx context.Context, ids []string) error {
+	for start := 0; start < len(ids); start += ix.batchSize {
+		end := start + ix.batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch := ids[start:end]
+		if err := ix.retry(ctx, func() error {
+			return ix.vectorStore.Delete(ctx, batch)
+		}); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "failed to delete stale chunks").
+				WithComponent("indexer").
+				WithOperation("delete_documents").
+				WithContext("num_ids", len(batch))
+		}
+
+		progress := IndexProgress{Stage: "delete", Done: end, Total: len(ids)}
+		ix.trigger(func(cb core.Callback) error {
+			return cb.OnChainEnd(ctx, "indexer.batch", progress)
+		})
+	}
+	return nil
+}
+
+// retry 按指数退避重试
+func (ix *Indexer) retry(ctx context.Context, fn func() error) error {
+	delay := ix.retryDelay
+	var err error
+	for attempt := 0; attempt <= ix.maxRetries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt == ix.maxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("after %d attempts: %w", ix.maxRetries+1, err)
+}
+
+// trigger 触发回调,回调错误不影响索引
+func (ix *Indexer) trigger(fn func(core.Callback) error) {
+	for _, cb := range ix.callbacks {
+		_ = fn(cb)
+	}
+}
+
+// splitBatches 按大小切分批次
+func splitBatches(docs []*Document, size int) [][]*Document {
+	batches := make([][]*Document, 0, (len(docs)+size-1)/size)
+	for start := 0; start < len(docs); start += size {
+		end := start + size
+		if end > len(docs) {
+			end = len(docs)
+		}
+		batches = append(batches, docs[start:end])
+	}
+	return batches
+}
+
+// ContentHash 计算内容哈希
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChunkID 根据来源和内容哈希生成确定性的块 ID(UUID v5 格式,兼容 Qdrant)
+func ChunkID(sourceID, contentHash string) string {
+	return uuid.NewSHA1(indexerIDNamespace, []byte(sourceID+"\x00"+contentHash)).String()
+}
@@ -0,0 +1,222 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	storememory "github.com/kart-io/goagent/store/memory"
+)
+
+// flakyEmbedder 前 failures 次调用返回错误
+type flakyEmbedder struct {
+	*SimpleEmbedder
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	fail := e.calls <= e.failures
+	e.mu.Unlock()
+	if fail {
+		return nil, errors.New("temporary failure")
+	}
+	return e.SimpleEmbedder.Embed(ctx, texts)
+}
+
+// progressCallback 记录批次进度
+type progressCallback struct {
+	*core.BaseCallback
+	mu       sync.Mutex
+	progress []IndexProgress
+	result   *IndexResult
+	err      error
+}
+
+func (c *progressCallback) OnChainEnd(_ context.Context, name string, output interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch v := output.(type) {
+	case IndexProgress:
+		c.progress = append(c.progress, v)
+	case *IndexResult:
+		c.result = v
+	}
+	return nil
+}
+
+func (c *progressCallback) OnChainError(_ context.Context, _ string, err error) error {
+	c.err = err
+	return nil
+}
+
+func chunkDocs(source string, contents ...string) []*Document {
+	docs := make([]*Document, len(contents))
+	for i, content := range contents {
+		docs[i] = NewDocument(content, map[string]interface{}{"source": source})
+	}
+	return docs
+}
+
+func newTestIndexer(t *testing.T, config IndexerConfig) (*Indexer, *MemoryVectorStore) {
+	t.Helper()
+	vs := NewMemoryVectorStore(MemoryVectorStoreConfig{})
+	if config.VectorStore == nil {
+		config.VectorStore = vs
+	}
+	if config.RecordManager == nil {
+		config.RecordManager = NewRecordManager(storememory.New())
+	}
+	config.RetryDelay = time.Millisecond
+	indexer, err := NewIndexer(config)
+	require.NoError(t, err)
+	return indexer, vs
+}
+
+func TestIndexerIncremental(t *testing.T) {
+	ctx := context.Background()
+	indexer, vs := newTestIndexer(t, IndexerConfig{BatchSize: 2})
+
+	docs := append(chunkDocs("a.md", "alpha one", "alpha two", "alpha one"), chunkDocs("b.md", "beta")...)
+	result, err := indexer.Index(ctx, docs, IndexModeIncremental)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.NumAdded)
+	assert.Equal(t, 1, result.NumSkipped, "duplicate chunk within a source is stored once")
+	assert.Equal(t, 3, vs.Count())
+	assert.Equal(t, []string{"a.md", "b.md"}, result.SourcesUpdated)
+
+	// 重新加载相同内容不会产生重复
+	result, err = indexer.Index(ctx, append(chunkDocs("a.md", "alpha one", "alpha two"), chunkDocs("b.md", "beta")...), IndexModeIncremental)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.NumAdded)
+	assert.Equal(t, 3, result.NumSkipped)
+	assert.Empty(t, result.SourcesUpdated)
+	assert.Equal(t, 3, vs.Count())
+
+	// 只修改一个块:新增一个、删除一个
+	result, err = indexer.Index(ctx, chunkDocs("a.md", "alpha one", "alpha two edited"), IndexModeIncremental)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NumAdded)
+	assert.Equal(t, 1, result.NumDeleted)
+	assert.Equal(t, []string{ChunkID("a.md", ContentHash("alpha two"))}, result.DeletedIDs)
+	assert.Equal(t, 3, vs.Count(), "b.md is untouched in incremental mode")
+
+	stored, err := vs.Get(ctx, ChunkID("a.md", ContentHash("alpha two edited")))
+	require.NoError(t, err)
+	assert.Equal(t, ContentHash("alpha two edited"), stored.Metadata[MetadataContentHash])
+}
+
+func TestIndexerFullAndDryRun(t *testing.T) {
+	ctx := context.Background()
+	indexer, vs := newTestIndexer(t, IndexerConfig{})
+
+	_, err := indexer.Index(ctx, append(chunkDocs("a.md", "alpha"), chunkDocs("b.md", "beta 1", "beta 2")...), IndexModeFull)
+	require.NoError(t, err)
+	require.Equal(t, 3, vs.Count())
+
+	next := append(chunkDocs("a.md", "alpha"), chunkDocs("c.md", "gamma")...)
+
+	plan, err := indexer.Index(ctx, next, IndexModeDryRun)
+	require.NoError(t, err)
+	assert.Equal(t, IndexModeDryRun, plan.Mode)
+	assert.Equal(t, 1, plan.NumAdded)
+	assert.Equal(t, 2, plan.NumDeleted)
+	assert.Equal(t, []string{"b.md"}, plan.SourcesDeleted)
+	assert.Equal(t, 3, vs.Count(), "dry run must not write")
+
+	result, err := indexer.Index(ctx, next, IndexModeFull)
+	require.NoError(t, err)
+	assert.Equal(t, plan.AddedIDs, result.AddedIDs)
+	assert.Equal(t, plan.DeletedIDs, result.DeletedIDs)
+	assert.Equal(t, 2, vs.Count())
+
+	sources, err := indexer.recordManager.ListSources(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.md", "c.md"}, sources)
+}
+
+func TestIndexerEmbedderRetryAndProgress(t *testing.T) {
+	ctx := context.Background()
+	embedder := &flakyEmbedder{SimpleEmbedder: NewSimpleEmbedder(16), failures: 1}
+	callback := &progressCallback{BaseCallback: core.NewBaseCallback()}
+
+	indexer, vs := newTestIndexer(t, IndexerConfig{
+		Embedder:    embedder,
+		BatchSize:   2,
+		Concurrency: 1,
+		Callbacks:   []core.Callback{callback},
+	})
+
+	docs := chunkDocs("a.md", "one", "two", "three", "four", "five")
+	result, err := indexer.Index(ctx, docs, IndexModeIncremental)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.NumAdded)
+	assert.Equal(t, 5, vs.Count())
+	assert.Equal(t, 4, embedder.calls, "three batches plus one retry")
+
+	require.Len(t, callback.progress, 3)
+	assert.Equal(t, IndexProgress{Stage: "add", Done: 5, Total: 5}, callback.progress[2])
+	assert.Same(t, result, callback.result)
+}
+
+func TestIndexerErrors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewIndexer(IndexerConfig{})
+	assert.Error(t, err)
+
+	embedder := &flakyEmbedder{SimpleEmbedder: NewSimpleEmbedder(8), failures: 100}
+	callback := &progressCallback{BaseCallback: core.NewBaseCallback()}
+	indexer, vs := newTestIndexer(t, IndexerConfig{
+		Embedder:   embedder,
+		MaxRetries: 2,
+		Callbacks:  []core.Callback{callback},
+	})
+
+	_, err = indexer.Index(ctx, chunkDocs("a.md", "one"), IndexModeIncremental)
+	require.Error(t, err)
+	assert.Equal(t, 3, embedder.calls)
+	assert.Error(t, callback.err)
+	assert.Equal(t, 0, vs.Count())
+
+	// 写入失败时不保存记录,下次运行会重试
+	sources, err := indexer.recordManager.ListSources(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, sources)
+
+	_, err = indexer.Index(ctx, []*Document{NewDocument("no source", nil)}, IndexModeIncremental)
+	assert.Error(t, err)
+
+	_, err = indexer.Index(ctx, chunkDocs("a.md", "one"), IndexMode("bogus"))
+	assert.Error(t, err)
+}
+
+func TestRecordManagerDecodesSerializedRecords(t *testing.T) {
+	ctx := context.Background()
+	s := storememory.New()
+	manager := NewRecordManager(s, "custom")
+
+	record, err := manager.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	// 模拟持久化存储经 JSON 往返后的值
+	require.NoError(t, s.Put(ctx, []string{"custom"}, "a.md", map[string]interface{}{
+		"source_id": "a.md",
+		"chunks":    map[string]interface{}{"id-2": "h2", "id-1": "h1"},
+	}))
+
+	record, err = manager.Get(ctx, "a.md")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, []string{"id-1", "id-2"}, record.ChunkIDs())
+}
@@ -57,6 +57,11 @@ func NewKeywordRetriever(docs []*Document, config RetrieverConfig) *KeywordRetri
 
 // GetRelevantDocuments 检索相关文档
 func (k *KeywordRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return k.traceRetrieval(ctx, query, k.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (k *KeywordRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
 	if len(k.Documents) == 0 {
 		return []*Document{}, nil
 	}
@@ -0,0 +1,279 @@
+package retrieval
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// FilterOperator 元数据过滤操作符
+type FilterOperator string
+
+const (
+	// 比较操作符
+	FilterEq       FilterOperator = "eq"
+	FilterNe       FilterOperator = "ne"
+	FilterGt       FilterOperator = "gt"
+	FilterGte      FilterOperator = "gte"
+	FilterLt       FilterOperator = "lt"
+	FilterLte      FilterOperator = "lte"
+	FilterIn       FilterOperator = "in"
+	FilterNin      FilterOperator = "nin"
+	FilterContains FilterOperator = "contains"
+
+	// 逻辑操作符
+	FilterAnd FilterOperator = "and"
+	FilterOr  FilterOperator = "or"
+	FilterNot FilterOperator = "not"
+)
+
+// isLogical 是否为逻辑操作符
+func (op FilterOperator) isLogical() bool {
+	return op == FilterAnd || op == FilterOr || op == FilterNot
+}
+
+// isComparison 是否为比较操作符
+func (op FilterOperator) isComparison() bool {
+	switch op {
+	case FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterIn, FilterNin, FilterContains:
+		return true
+	}
+	return false
+}
+
+// MetadataFilter 元数据过滤条件
+//
+// 比较条件使用 Attribute 和 Value,逻辑条件(and/or/not)使用 Filters 组合子条件
+type MetadataFilter struct {
+	Operator  FilterOperator    `json:"operator"`
+	Attribute string            `json:"attribute,omitempty"`
+	Value     interface{}       `json:"value,omitempty"`
+	Filters   []*MetadataFilter `json:"filters,omitempty"`
+}
+
+// AttributeInfo 可过滤的元数据属性声明
+type AttributeInfo struct {
+	// Name 元数据键
+	Name string `json:"name"`
+
+	// Type 属性类型,如 string、integer、float、boolean、list[string]
+	Type string `json:"type"`
+
+	// Description 属性说明,提供给 LLM 理解语义
+	Description string `json:"description"`
+}
+
+// Match 判断元数据是否满足过滤条件,nil 过滤条件匹配所有文档
+func (f *MetadataFilter) Match(metadata map[string]interface{}) bool {
+	if f == nil {
+		return true
+	}
+
+	switch f.Operator {
+	case FilterAnd:
+		for _, sub := range f.Filters {
+			if !sub.Match(metadata) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, sub := range f.Filters {
+			if sub.Match(metadata) {
+				return true
+			}
+		}
+		return len(f.Filters) == 0
+	case FilterNot:
+		for _, sub := range f.Filters {
+			if sub.Match(metadata) {
+				return false
+			}
+		}
+		return true
+	}
+
+	actual, ok := metadata[f.Attribute]
+	if !ok {
+		// 缺失的属性只满足否定类条件
+		return f.Operator == FilterNe || f.Operator == FilterNin
+	}
+
+	switch f.Operator {
+	case FilterEq:
+		return valuesEqual(actual, f.Value)
+	case FilterNe:
+		return !valuesEqual(actual, f.Value)
+	case FilterGt:
+		cmp, ok := compareValues(actual, f.Value)
+		return ok && cmp > 0
+	case FilterGte:
+		cmp, ok := compareValues(actual, f.Value)
+		return ok && cmp >= 0
+	case FilterLt:
+		cmp, ok := compareValues(actual, f.Value)
+		return ok && cmp < 0
+	case FilterLte:
+		cmp, ok := compareValues(actual, f.Value)
+		return ok && cmp <= 0
+	case FilterIn:
+		return containsValue(f.Value, actual)
+	case FilterNin:
+		return !containsValue(f.Value, actual)
+	case FilterContains:
+		if s, ok := actual.(string); ok {
+			return strings.Contains(strings.ToLower(s), strings.ToLower(fmt.Sprint(f.Value)))
+		}
+		return containsValue(actual, f.Value)
+	}
+	return false
+}
+
+// Validate 校验过滤条件,attributes 非空时只允许使用声明过的属性
+func (f *MetadataFilter) Validate(attributes []AttributeInfo) error {
+	if f == nil {
+		return nil
+	}
+
+	switch {
+	case f.Operator.isLogical():
+		if len(f.Filters) == 0 {
+			return invalidFilter(f, "logical operator requires sub-filters")
+		}
+		for _, sub := range f.Filters {
+			if sub == nil {
+				return invalidFilter(f, "sub-filter must not be null")
+			}
+			if err := sub.Validate(attributes); err != nil {
+				return err
+			}
+		}
+		return nil
+	case f.Operator.isComparison():
+		if f.Attribute == "" {
+			return invalidFilter(f, "comparison requires an attribute")
+		}
+		if len(attributes) > 0 && !hasAttribute(attributes, f.Attribute) {
+			return invalidFilter(f, "attribute is not declared")
+		}
+		if (f.Operator == FilterIn || f.Operator == FilterNin) && !isList(f.Value) {
+			return invalidFilter(f, "in/nin requires a list value")
+		}
+		return nil
+	}
+	return invalidFilter(f, "unknown operator")
+}
+
+// invalidFilter 创建过滤条件校验错误
+func invalidFilter(f *MetadataFilter, msg string) error {
+	return agentErrors.New(agentErrors.CodeInvalidInput, "invalid metadata filter: "+msg).
+		WithComponent("metadata_filter").
+		WithOperation("validate").
+		WithContext("operator", string(f.Operator)).
+		WithContext("attribute", f.Attribute)
+}
+
+// hasAttribute 属性是否已声明
+func hasAttribute(attributes []AttributeInfo, name string) bool {
+	for _, attr := range attributes {
+		if attr.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isList 值是否为切片或数组
+func isList(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	kind := reflect.TypeOf(v).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// containsValue list 中是否存在与 v 相等的元素
+func containsValue(list, v interface{}) bool {
+	if !isList(list) {
+		return false
+	}
+	rv := reflect.ValueOf(list)
+	for i := 0; i < rv.Len(); i++ {
+		if valuesEqual(rv.Index(i).Interface(), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual 比较两个值是否相等,数值按 float64 比较
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return fa == fb
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.EqualFold(sa, sb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues 比较数值或字符串,第二个返回值表示是否可比较
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if !okA || !okB {
+		return 0, false
+	}
+	return strings.Compare(sa, sb), true
+}
+
+// toFloat 将数值类型转换为 float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
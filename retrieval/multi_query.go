@@ -66,6 +66,11 @@ Generate {{.NumQueries}} alternative questions (one per line):`
 
 // GetRelevantDocuments 检索相关文档
 func (m *MultiQueryRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return m.traceRetrieval(ctx, query, m.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (m *MultiQueryRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
 	// 1. 生成查询变体
 	queries, err := m.generateQueries(ctx, query)
 	if err != nil {
@@ -227,6 +232,11 @@ func NewEnsembleRetriever(
 
 // GetRelevantDocuments 检索相关文档
 func (e *EnsembleRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return e.traceRetrieval(ctx, query, e.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (e *EnsembleRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
 	if len(e.Retrievers) == 0 {
 		return []*Document{}, nil
 	}
@@ -0,0 +1,328 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+)
+
+// DefaultMultiVectorIDKey 向量元数据中指向原始文档的键
+const DefaultMultiVectorIDKey = "doc_id"
+
+// MetadataVectorType 向量元数据中表示向量类型的键
+const MetadataVectorType = "vector_type"
+
+// 多向量索引的向量类型
+const (
+	VectorTypeSummary  = "summary"
+	VectorTypeQuestion = "question"
+	VectorTypeOriginal = "original"
+)
+
+// DefaultSummaryPrompt 默认摘要提示词,{document} 为文档内容占位符
+const DefaultSummaryPrompt = `Summarize the following document in a few sentences. Keep key entities, numbers and terms.
+
+Document:
+{document}
+
+Summary:`
+
+// DefaultQuestionsPrompt 默认假设问题提示词,{num} 为问题数量,{document} 为文档内容
+const DefaultQuestionsPrompt = `Generate {num} distinct questions that the following document can answer.
+Output one question per line without numbering.
+
+Document:
+{document}
+
+Questions:`
+
+// listPrefixPattern 匹配 LLM 输出中的列表前缀("1." "2)" "-" "*")
+var listPrefixPattern = regexp.MustCompile(`^\s*(?:\d+[.)、]|[-*•])\s*`)
+
+// MultiVectorRetriever 多向量检索器
+//
+// 为每个原始文档生成多种表示(LLM 摘要、假设问题、原文)并分别索引,
+// 检索时命中任一表示都返回完整的原始文档
+type MultiVectorRetriever struct {
+	*BaseRetriever
+
+	// VectorStore 表示向量存储
+	VectorStore VectorStore
+
+	// DocStore 原始文档存储
+	DocStore DocStore
+
+	// LLMClient 用于生成摘要和假设问题
+	LLMClient llm.Client
+
+	// GenerateSummaries 是否生成摘要
+	GenerateSummaries bool
+
+	// NumQuestions 每个文档生成的假设问题数量,0 表示不生成
+	NumQuestions int
+
+	// IncludeOriginal 是否同时索引原文
+	IncludeOriginal bool
+
+	// IDKey 向量元数据中原始文档 ID 的键
+	IDKey string
+
+	// SearchK 每次检索的向量数量,默认 TopK 的 4 倍
+	SearchK int
+
+	// SummaryPrompt 摘要提示词
+	SummaryPrompt string
+
+	// QuestionsPrompt 假设问题提示词
+	QuestionsPrompt string
+
+	// Concurrency 生成表示时的并发数
+	Concurrency int
+}
+
+// MultiVectorRetrieverConfig 多向量检索器配置
+type MultiVectorRetrieverConfig struct {
+	VectorStore       VectorStore
+	DocStore          DocStore
+	LLMClient         llm.Client
+	GenerateSummaries bool
+	NumQuestions      int
+	IncludeOriginal   bool
+	IDKey             string
+	SearchK           int
+	SummaryPrompt     string
+	QuestionsPrompt   string
+	Concurrency       int
+	RetrieverConfig
+}
+
+// NewMultiVectorRetriever 创建多向量检索器
+func NewMultiVectorRetriever(config MultiVectorRetrieverConfig) (*MultiVectorRetriever, error) {
+	if config.VectorStore == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "vector store is required").
+			WithComponent("multi_vector_retriever").
+			WithOperation("create")
+	}
+	if (config.GenerateSummaries || config.NumQuestions > 0) && config.LLMClient == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "llm client is required to generate summaries or questions").
+			WithComponent("multi_vector_retriever").
+			WithOperation("create")
+	}
+	if config.DocStore == nil {
+		config.DocStore = NewInMemoryDocStore()
+	}
+	if config.IDKey == "" {
+		config.IDKey = DefaultMultiVectorIDKey
+	}
+	if config.NumQuestions < 0 {
+		config.NumQuestions = 0
+	}
+	if config.SummaryPrompt == "" {
+		config.SummaryPrompt = DefaultSummaryPrompt
+	}
+	if config.QuestionsPrompt == "" {
+		config.QuestionsPrompt = DefaultQuestionsPrompt
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+
+	retriever := &MultiVectorRetriever{
+		BaseRetriever:     NewBaseRetriever(),
+		VectorStore:       config.VectorStore,
+		DocStore:          config.DocStore,
+		LLMClient:         config.LLMClient,
+		GenerateSummaries: config.GenerateSummaries,
+		NumQuestions:      config.NumQuestions,
+		IncludeOriginal:   config.IncludeOriginal,
+		IDKey:             config.IDKey,
+		SearchK:           config.SearchK,
+		SummaryPrompt:     config.SummaryPrompt,
+		QuestionsPrompt:   config.QuestionsPrompt,
+		Concurrency:       config.Concurrency,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "multi_vector_retriever"
+	}
+
+	return retriever, nil
+}
+
+// AddDocuments 生成表示并索引文档
+//
+// 原始文档保存到 DocStore,摘要、假设问题和原文(按配置)写入向量存储;
+// 文档 ID 为空时自动生成
+func (m *MultiVectorRetriever) AddDocuments(ctx context.Context, docs []*Document) error {
+	if !m.GenerateSummaries && m.NumQuestions == 0 && !m.IncludeOriginal {
+		return agentErrors.New(agentErrors.CodeInvalidConfig, "no vector representation is enabled").
+			WithComponent("multi_vector_retriever").
+			WithOperation("add_documents")
+	}
+
+	for _, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = generateID()
+		}
+	}
+
+	vectors := make([][]*Document, len(docs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(m.Concurrency)
+	for i, doc := range docs {
+		g.Go(func() error {
+			generated, err := m.representations(gctx, doc)
+			if err != nil {
+				return err
+			}
+			vectors[i] = generated
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	flat := make([]*Document, 0, len(docs))
+	for _, vs := range vectors {
+		flat = append(flat, vs...)
+	}
+
+	return m.AddVectors(ctx, docs, flat)
+}
+
+// AddVectors 索引自定义的表示向量
+//
+// vectors 的元数据中必须包含 IDKey 指向的原始文档 ID
+func (m *MultiVectorRetriever) AddVectors(ctx context.Context, docs, vectors []*Document) error {
+	for i, vec := range vectors {
+		if _, ok := vec.Metadata[m.IDKey].(string); !ok {
+			return agentErrors.New(agentErrors.CodeInvalidInput, "vector document is missing source id").
+				WithComponent("multi_vector_retriever").
+				WithOperation("add_vectors").
+				WithContext("id_key", m.IDKey).
+				WithContext("vector_index", i)
+		}
+	}
+
+	if err := m.DocStore.MSet(ctx, docs); err != nil {
+		return err
+	}
+
+	if err := m.VectorStore.AddDocuments(ctx, vectors); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to index vectors").
+			WithComponent("multi_vector_retriever").
+			WithOperation("add_vectors").
+			WithContext("num_vectors", len(vectors))
+	}
+	return nil
+}
+
+// GetRelevantDocuments 检索表示向量并返回对应的原始文档
+func (m *MultiVectorRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return m.traceRetrieval(ctx, query, m.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (m *MultiVectorRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	searchK := m.SearchK
+	if searchK <= 0 {
+		searchK = m.TopK * 4
+	}
+
+	docs, err := searchAndResolve(ctx, m.VectorStore, m.DocStore, query, searchK, m.IDKey)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "multi vector retrieval failed").
+			WithComponent("multi_vector_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+
+	return m.LimitTopK(m.FilterByScore(docs)), nil
+}
+
+// representations 生成单个文档的表示向量
+func (m *MultiVectorRetriever) representations(ctx context.Context, doc *Document) ([]*Document, error) {
+	result := make([]*Document, 0, m.NumQuestions+2)
+	newVector := func(vectorType, content string, index int) *Document {
+		metadata := make(map[string]interface{}, len(doc.Metadata)+2)
+		for k, v := range doc.Metadata {
+			metadata[k] = v
+		}
+		metadata[m.IDKey] = doc.ID
+		metadata[MetadataVectorType] = vectorType
+		return NewDocumentWithID(fmt.Sprintf("%s_%s_%d", doc.ID, vectorType, index), content, metadata)
+	}
+
+	if m.IncludeOriginal {
+		result = append(result, newVector(VectorTypeOriginal, doc.PageContent, 0))
+	}
+
+	if m.GenerateSummaries {
+		summary, err := m.complete(ctx, strings.ReplaceAll(m.SummaryPrompt, "{document}", doc.PageContent))
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "failed to generate summary").
+				WithComponent("multi_vector_retriever").
+				WithOperation("generate_summary").
+				WithContext("document_id", doc.ID)
+		}
+		if summary = strings.TrimSpace(summary); summary != "" {
+			result = append(result, newVector(VectorTypeSummary, summary, 0))
+		}
+	}
+
+	if m.NumQuestions > 0 {
+		prompt := strings.ReplaceAll(m.QuestionsPrompt, "{num}", fmt.Sprintf("%d", m.NumQuestions))
+		prompt = strings.ReplaceAll(prompt, "{document}", doc.PageContent)
+		output, err := m.complete(ctx, prompt)
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "failed to generate questions").
+				WithComponent("multi_vector_retriever").
+				WithOperation("generate_questions").
+				WithContext("document_id", doc.ID)
+		}
+		for i, question := range parseLines(output, m.NumQuestions) {
+			result = append(result, newVector(VectorTypeQuestion, question, i))
+		}
+	}
+
+	return result, nil
+}
+
+// complete 调用 LLM 生成文本
+func (m *MultiVectorRetriever) complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := m.LLMClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{llm.UserMessage(prompt)},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// parseLines 将 LLM 输出按行拆分并去除列表前缀,最多返回 limit 行
+func parseLines(output string, limit int) []string {
+	lines := make([]string, 0, limit)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(listPrefixPattern.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if limit > 0 && len(lines) >= limit {
+			break
+		}
+	}
+	return lines
+}
@@ -0,0 +1,415 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// DefaultParentIDKey 子块元数据中指向父文档的键
+//
+// 与 document.ParentChildTextSplitter 使用的键一致
+const DefaultParentIDKey = "parent_id"
+
+// ChunkSplitter 文本分割器
+//
+// document 包中的所有 TextSplitter 都满足该接口
+type ChunkSplitter interface {
+	SplitText(text string) ([]string, error)
+}
+
+// ParentDocumentRetriever 父文档检索器
+//
+// 在向量存储中索引较小的子块以获得精确的匹配,检索时根据子块元数据中的父文档 ID
+// 从 DocStore 取回完整的父文档,兼顾检索精度与上下文完整性
+type ParentDocumentRetriever struct {
+	*BaseRetriever
+
+	// VectorStore 子块向量存储
+	VectorStore VectorStore
+
+	// DocStore 父文档存储
+	DocStore DocStore
+
+	// ChildSplitter 子块分割器
+	ChildSplitter ChunkSplitter
+
+	// ParentSplitter 父块分割器,为空时原始文档整体作为父文档
+	ParentSplitter ChunkSplitter
+
+	// IDKey 子块元数据中父文档 ID 的键
+	IDKey string
+
+	// SearchK 每次检索的子块数量,默认 TopK 的 4 倍
+	SearchK int
+}
+
+// ParentDocumentRetrieverConfig 父文档检索器配置
+type ParentDocumentRetrieverConfig struct {
+	VectorStore    VectorStore
+	DocStore       DocStore
+	ChildSplitter  ChunkSplitter
+	ParentSplitter ChunkSplitter
+	IDKey          string
+	SearchK        int
+	RetrieverConfig
+}
+
+// NewParentDocumentRetriever 创建父文档检索器
+func NewParentDocumentRetriever(config ParentDocumentRetrieverConfig) (*ParentDocumentRetriever, error) {
+	if config.VectorStore == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "vector store is required").
+			WithComponent("parent_document_retriever").
+			WithOperation("create")
+	}
+	if config.DocStore == nil {
+		config.DocStore = NewInMemoryDocStore()
+	}
+	if config.IDKey == "" {
+		config.IDKey = DefaultParentIDKey
+	}
+
+	retriever := &ParentDocumentRetriever{
+		BaseRetriever:  NewBaseRetriever(),
+		VectorStore:    config.VectorStore,
+		DocStore:       config.DocStore,
+		ChildSplitter:  config.ChildSplitter,
+		ParentSplitter: config.ParentSplitter,
+		IDKey:          config.IDKey,
+		SearchK:        config.SearchK,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "parent_document_retriever"
+	}
+
+	return retriever, nil
+}
+
+// 父文档元数据的键,与 document.ParentChildTextSplitter 一致
+const (
+	metadataSourceID   = "source_id"
+	metadataChunkIndex = "chunk_index"
+	metadataChunkTotal = "chunk_total"
+	// metadataChildTotal 父文档的子块数量,用于重新添加时删除旧的子块
+	metadataChildTotal = "child_total"
+)
+
+// AddDocuments 分割并索引文档
+//
+// 父文档保存到 DocStore,子块写入向量存储,调用方的文档不会被修改。
+// 文档 ID 必须稳定:再次添加相同 ID 的文档会替换其之前的父文档和子块;
+// ID 为空时生成随机 ID,每次添加都作为新文档。生成的父文档 ID 与 DocStore 中
+// 其他文档的 ID 冲突时返回错误
+func (p *ParentDocumentRetriever) AddDocuments(ctx context.Context, docs []*Document) error {
+	if p.ChildSplitter == nil {
+		return agentErrors.New(agentErrors.CodeInvalidConfig, "child splitter is required").
+			WithComponent("parent_document_retriever").
+			WithOperation("add_documents")
+	}
+
+	sourceIDs := make([]string, 0, len(docs))
+	parents := make([]*Document, 0, len(docs))
+	for _, doc := range docs {
+		sourceID := doc.ID
+		if sourceID == "" {
+			sourceID = generateID()
+		}
+		sourceIDs = append(sourceIDs, sourceID)
+
+		split, err := p.splitParents(doc, sourceID)
+		if err != nil {
+			return err
+		}
+		parents = append(parents, split...)
+	}
+
+	children := make([]*Document, 0, len(parents))
+	for _, parent := range parents {
+		texts, err := p.ChildSplitter.SplitText(parent.PageContent)
+		if err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to split child chunks").
+				WithComponent("parent_document_retriever").
+				WithOperation("add_documents").
+				WithContext("document_id", parent.ID)
+		}
+		parent.Metadata[metadataChildTotal] = len(texts)
+		for i, text := range texts {
+			child := parent.Clone()
+			child.ID = childChunkID(parent.ID, i)
+			child.PageContent = text
+			delete(child.Metadata, metadataChildTotal)
+			child.Metadata[p.IDKey] = parent.ID
+			children = append(children, child)
+		}
+	}
+
+	staleParents, staleChildren, err := p.previousChunks(ctx, sourceIDs, parents, children)
+	if err != nil {
+		return err
+	}
+	if err := p.AddSplitDocuments(ctx, parents, children); err != nil {
+		return err
+	}
+
+	if len(staleChildren) > 0 {
+		if err := p.VectorStore.Delete(ctx, staleChildren); err != nil {
+			return agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to delete previous child chunks").
+				WithComponent("parent_document_retriever").
+				WithOperation("add_documents").
+				WithContext("num_children", len(staleChildren))
+		}
+	}
+	if len(staleParents) > 0 {
+		return p.DocStore.MDelete(ctx, staleParents)
+	}
+	return nil
+}
+
+// splitParents 生成文档的父文档,元数据中记录来源文档 ID
+func (p *ParentDocumentRetriever) splitParents(doc *Document, sourceID string) ([]*Document, error) {
+	if p.ParentSplitter == nil {
+		parent := doc.Clone()
+		parent.ID = sourceID
+		parent.Metadata[metadataSourceID] = sourceID
+		return []*Document{parent}, nil
+	}
+
+	texts, err := p.ParentSplitter.SplitText(doc.PageContent)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to split parent documents").
+			WithComponent("parent_document_retriever").
+			WithOperation("add_documents").
+			WithContext("document_id", sourceID)
+	}
+	parents := make([]*Document, 0, len(texts))
+	for i, text := range texts {
+		parent := doc.Clone()
+		parent.ID = parentChunkID(sourceID, i)
+		parent.PageContent = text
+		parent.Metadata[metadataSourceID] = sourceID
+		parent.Metadata[metadataChunkIndex] = i
+		parent.Metadata[metadataChunkTotal] = len(texts)
+		parents = append(parents, parent)
+	}
+	return parents, nil
+}
+
+// previousChunks 查找来源文档之前添加的父文档和子块中不再使用的部分
+//
+// 新父文档的 ID 已被其他来源的文档占用,或同一批次中 ID 重复时返回错误
+func (p *ParentDocumentRetriever) previousChunks(ctx context.Context, sourceIDs []string, parents, children []*Document) ([]string, []string, error) {
+	owners := make(map[string]string, len(parents))
+	for _, parent := range parents {
+		if _, dup := owners[parent.ID]; dup {
+			return nil, nil, collisionError(parent.ID)
+		}
+		owners[parent.ID] = parent.Metadata[metadataSourceID].(string)
+	}
+
+	// 旧版本的父文档位于来源 ID(未分割)或从 <source>_parent_0 开始的连续 ID
+	probe := make([]string, 0, 2*len(sourceIDs)+len(parents))
+	for _, sourceID := range sourceIDs {
+		probe = append(probe, sourceID, parentChunkID(sourceID, 0))
+	}
+	for _, parent := range parents {
+		probe = append(probe, parent.ID)
+	}
+	found, err := p.DocStore.MGet(ctx, probe)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previous := make(map[string]*Document)
+	var more []string
+	for i, doc := range found {
+		if doc == nil {
+			continue
+		}
+		source, _ := doc.Metadata[metadataSourceID].(string)
+		if owner, ok := owners[probe[i]]; ok && owner != source {
+			return nil, nil, collisionError(probe[i])
+		}
+		if i >= 2*len(sourceIDs) || source != sourceIDs[i/2] {
+			continue
+		}
+		previous[doc.ID] = doc
+		if i%2 == 1 {
+			for j := 1; j < metadataInt(doc, metadataChunkTotal); j++ {
+				more = append(more, parentChunkID(source, j))
+			}
+		}
+	}
+	if len(more) > 0 {
+		found, err = p.DocStore.MGet(ctx, more)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, doc := range found {
+			if doc != nil {
+				previous[doc.ID] = doc
+			}
+		}
+	}
+
+	current := make(map[string]bool, len(children))
+	for _, child := range children {
+		current[child.ID] = true
+	}
+	var staleParents, staleChildren []string
+	for id, doc := range previous {
+		if _, ok := owners[id]; !ok {
+			staleParents = append(staleParents, id)
+		}
+		for i := 0; i < metadataInt(doc, metadataChildTotal); i++ {
+			if childID := childChunkID(id, i); !current[childID] {
+				staleChildren = append(staleChildren, childID)
+			}
+		}
+	}
+	sort.Strings(staleParents)
+	sort.Strings(staleChildren)
+	return staleParents, staleChildren, nil
+}
+
+// collisionError 父文档 ID 冲突错误
+func collisionError(id string) error {
+	return agentErrors.New(agentErrors.CodeInvalidInput, "parent document id collides with another document").
+		WithComponent("parent_document_retriever").
+		WithOperation("add_documents").
+		WithContext("document_id", id)
+}
+
+// parentChunkID 父块 ID
+func parentChunkID(sourceID string, i int) string {
+	return fmt.Sprintf("%s_parent_%d", sourceID, i)
+}
+
+// childChunkID 子块 ID
+func childChunkID(parentID string, i int) string {
+	return fmt.Sprintf("%s_child_%d", parentID, i)
+}
+
+// metadataInt 读取整数元数据,兼容 JSON 反序列化得到的 float64
+func metadataInt(doc *Document, key string) int {
+	switch v := doc.Metadata[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// AddSplitDocuments 索引已经分割好的父文档和子块
+//
+// 子块元数据中必须包含 IDKey 指向的父文档 ID,
+// 可直接使用 document.ParentChildTextSplitter.Split 的结果
+func (p *ParentDocumentRetriever) AddSplitDocuments(ctx context.Context, parents, children []*Document) error {
+	for i, child := range children {
+		if _, ok := child.Metadata[p.IDKey].(string); !ok {
+			return agentErrors.New(agentErrors.CodeInvalidInput, "child chunk is missing parent id").
+				WithComponent("parent_document_retriever").
+				WithOperation("add_split_documents").
+				WithContext("id_key", p.IDKey).
+				WithContext("child_index", i)
+		}
+	}
+
+	if err := p.DocStore.MSet(ctx, parents); err != nil {
+		return err
+	}
+
+	if err := p.VectorStore.AddDocuments(ctx, children); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to index child chunks").
+			WithComponent("parent_document_retriever").
+			WithOperation("add_split_documents").
+			WithContext("num_children", len(children))
+	}
+	return nil
+}
+
+// GetRelevantDocuments 检索子块并返回对应的父文档
+func (p *ParentDocumentRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return p.traceRetrieval(ctx, query, p.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (p *ParentDocumentRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	docs, err := searchAndResolve(ctx, p.VectorStore, p.DocStore, query, p.searchK(), p.IDKey)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "parent document retrieval failed").
+			WithComponent("parent_document_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+
+	return p.LimitTopK(p.FilterByScore(docs)), nil
+}
+
+// searchK 计算子块检索数量
+func (p *ParentDocumentRetriever) searchK() int {
+	if p.SearchK > 0 {
+		return p.SearchK
+	}
+	return p.TopK * 4
+}
+
+// searchAndResolve 检索向量并按 idKey 解析原始文档
+//
+// 原始文档的分数取其命中向量中的最高分,结果按分数降序排列(同分时保持命中顺序)
+func searchAndResolve(ctx context.Context, vectorStore VectorStore, docStore DocStore, query string, k int, idKey string) ([]*Document, error) {
+	hits, err := vectorStore.SimilaritySearchWithScore(ctx, query, k)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(hits))
+	scores := make(map[string]float64, len(hits))
+	for _, hit := range hits {
+		id, ok := hit.Metadata[idKey].(string)
+		if !ok || id == "" {
+			continue
+		}
+		if best, seen := scores[id]; seen {
+			if hit.Score > best {
+				scores[id] = hit.Score
+			}
+			continue
+		}
+		ids = append(ids, id)
+		scores[id] = hit.Score
+	}
+
+	if len(ids) == 0 {
+		return []*Document{}, nil
+	}
+
+	parents, err := docStore.MGet(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Document, 0, len(parents))
+	for i, parent := range parents {
+		if parent == nil {
+			continue
+		}
+		parent.Score = scores[ids[i]]
+		results = append(results, parent)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
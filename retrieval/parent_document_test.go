@@ -0,0 +1,208 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	storememory "github.com/kart-io/goagent/store/memory"
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+// lineSplitter 按行分割
+type lineSplitter struct{}
+
+func (lineSplitter) SplitText(text string) ([]string, error) {
+	return strings.Split(text, "\n"), nil
+}
+
+func newKeywordStore(keywords ...string) *MemoryVectorStore {
+	return NewMemoryVectorStore(MemoryVectorStoreConfig{Embedder: mocks.NewKeywordEmbedder(keywords...)})
+}
+
+func TestParentDocumentRetriever(t *testing.T) {
+	ctx := context.Background()
+	vs := newKeywordStore("kubernetes", "postgres", "redis")
+	docStore := NewInMemoryDocStore()
+
+	retriever, err := NewParentDocumentRetriever(ParentDocumentRetrieverConfig{
+		VectorStore:   vs,
+		DocStore:      docStore,
+		ChildSplitter: lineSplitter{},
+		RetrieverConfig: RetrieverConfig{
+			TopK: 2,
+		},
+	})
+	require.NoError(t, err)
+
+	ops := NewDocumentWithID("ops", "Deploy with kubernetes\nScale the redis cache", map[string]interface{}{"team": "sre"})
+	db := NewDocumentWithID("db", "Tune postgres indexes\nVacuum postgres nightly", nil)
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{ops, db}))
+
+	assert.Equal(t, 4, vs.Count())
+	assert.Equal(t, 2, docStore.Len())
+
+	docs, err := retriever.GetRelevantDocuments(ctx, "how to configure redis")
+	require.NoError(t, err)
+	require.NotEmpty(t, docs)
+	assert.Equal(t, "ops", docs[0].ID)
+	assert.Equal(t, ops.PageContent, docs[0].PageContent, "full parent is returned")
+	assert.Equal(t, "sre", docs[0].Metadata["team"])
+	assert.Len(t, docs, 2, "both child hits of db collapse into one parent")
+
+	_, err = NewParentDocumentRetriever(ParentDocumentRetrieverConfig{})
+	assert.Error(t, err)
+}
+
+func TestParentDocumentRetrieverWithParentSplitter(t *testing.T) {
+	ctx := context.Background()
+	vs := newKeywordStore("kubernetes", "postgres")
+
+	retriever, err := NewParentDocumentRetriever(ParentDocumentRetrieverConfig{
+		VectorStore:    vs,
+		DocStore:       NewKVDocStore(storememory.New()),
+		ChildSplitter:  lineSplitter{},
+		ParentSplitter: splitterFunc(func(text string) ([]string, error) { return strings.Split(text, "\n\n"), nil }),
+	})
+	require.NoError(t, err)
+
+	doc := NewDocumentWithID("guide", "kubernetes a\nkubernetes b\n\npostgres a\npostgres b", nil)
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{doc}))
+
+	docs, err := retriever.GetRelevantDocuments(ctx, "postgres")
+	require.NoError(t, err)
+	require.NotEmpty(t, docs)
+	assert.Equal(t, "guide_parent_1", docs[0].ID)
+	assert.Equal(t, "postgres a\npostgres b", docs[0].PageContent)
+
+	err = retriever.AddSplitDocuments(ctx, nil, []*Document{NewDocument("orphan", nil)})
+	assert.Error(t, err, "children without parent id are rejected")
+}
+
+func TestParentDocumentRetrieverReingest(t *testing.T) {
+	ctx := context.Background()
+	vs := newKeywordStore("kubernetes", "postgres", "redis")
+	docStore := NewKVDocStore(storememory.New())
+	retriever, err := NewParentDocumentRetriever(ParentDocumentRetrieverConfig{
+		VectorStore:    vs,
+		DocStore:       docStore,
+		ChildSplitter:  lineSplitter{},
+		ParentSplitter: splitterFunc(func(text string) ([]string, error) { return strings.Split(text, "\n\n"), nil }),
+	})
+	require.NoError(t, err)
+
+	anonymous := &Document{PageContent: "redis a"}
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{anonymous}))
+	assert.Empty(t, anonymous.ID, "generated ids are not written back")
+	assert.Empty(t, anonymous.Metadata)
+	vs.Clear()
+
+	guide := NewDocumentWithID("guide", "kubernetes a\nkubernetes b\n\npostgres a\npostgres b", nil)
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{guide}))
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{guide}))
+	assert.Equal(t, 4, vs.Count(), "re-ingesting replaces the previous chunks")
+
+	guide = NewDocumentWithID("guide", "redis only", nil)
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{guide}))
+	assert.Equal(t, 1, vs.Count(), "stale child chunks are deleted")
+	parents, err := docStore.MGet(ctx, []string{"guide_parent_0", "guide_parent_1"})
+	require.NoError(t, err)
+	require.NotNil(t, parents[0])
+	assert.Equal(t, "redis only", parents[0].PageContent)
+	assert.Nil(t, parents[1], "stale parents are deleted")
+
+	// 生成的父文档 ID 不能覆盖其他文档
+	require.NoError(t, docStore.MSet(ctx, []*Document{NewDocumentWithID("notes_parent_0", "unrelated", nil)}))
+	err = retriever.AddDocuments(ctx, []*Document{NewDocumentWithID("notes", "postgres c", nil)})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeInvalidInput))
+	err = retriever.AddDocuments(ctx, []*Document{NewDocumentWithID("dup", "a", nil), NewDocumentWithID("dup", "b", nil)})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeInvalidInput), "duplicate ids in one batch")
+}
+
+type splitterFunc func(text string) ([]string, error)
+
+func (f splitterFunc) SplitText(text string) ([]string, error) { return f(text) }
+
+func TestMultiVectorRetriever(t *testing.T) {
+	ctx := context.Background()
+	vs := newKeywordStore("billing", "refund", "latency", "outage")
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		switch {
+		case strings.Contains(prompt, "Summarize") && strings.Contains(prompt, "invoice"):
+			return "Billing policy overview."
+		case strings.Contains(prompt, "Summarize"):
+			return "Incident report about an outage."
+		case strings.Contains(prompt, "invoice"):
+			return "1. How do I get a refund?\n2. When is billing charged?\n3. extra question"
+		default:
+			return "- What caused the latency spike?\n- How long was the outage?"
+		}
+	}))
+
+	retriever, err := NewMultiVectorRetriever(MultiVectorRetrieverConfig{
+		VectorStore:       vs,
+		LLMClient:         client,
+		GenerateSummaries: true,
+		NumQuestions:      2,
+		RetrieverConfig:   RetrieverConfig{TopK: 1},
+	})
+	require.NoError(t, err)
+
+	invoice := NewDocumentWithID("invoice", "Customers receive an invoice on the first day of each month.", nil)
+	incident := NewDocumentWithID("incident", "On March 3rd the API was degraded for 40 minutes.", nil)
+	require.NoError(t, retriever.AddDocuments(ctx, []*Document{invoice, incident}))
+
+	assert.Len(t, client.GetRequestHistory(), 4)
+	assert.Equal(t, 6, vs.Count(), "one summary and two questions per document")
+
+	question, err := vs.Get(ctx, "invoice_question_0")
+	require.NoError(t, err)
+	assert.Equal(t, "How do I get a refund?", question.PageContent)
+	assert.Equal(t, VectorTypeQuestion, question.Metadata[MetadataVectorType])
+	assert.Equal(t, "invoice", question.Metadata[DefaultMultiVectorIDKey])
+
+	docs, err := retriever.GetRelevantDocuments(ctx, "refund request")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, invoice.PageContent, docs[0].PageContent, "original document is returned")
+
+	docs, err = retriever.GetRelevantDocuments(ctx, "latency")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "incident", docs[0].ID)
+}
+
+func TestMultiVectorRetrieverConfigErrors(t *testing.T) {
+	_, err := NewMultiVectorRetriever(MultiVectorRetrieverConfig{VectorStore: newKeywordStore("a"), GenerateSummaries: true})
+	assert.Error(t, err, "llm client required")
+
+	retriever, err := NewMultiVectorRetriever(MultiVectorRetrieverConfig{VectorStore: newKeywordStore("a")})
+	require.NoError(t, err)
+	assert.Error(t, retriever.AddDocuments(context.Background(), []*Document{NewDocument("x", nil)}))
+}
+
+func TestRAGChainWithCustomRetriever(t *testing.T) {
+	ctx := context.Background()
+	parent, err := NewParentDocumentRetriever(ParentDocumentRetrieverConfig{
+		VectorStore:   newKeywordStore("redis", "postgres"),
+		ChildSplitter: lineSplitter{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, parent.AddDocuments(ctx, []*Document{
+		NewDocumentWithID("cache", "Redis is the cache\nIt stores sessions", nil),
+	}))
+
+	ragRetriever, err := NewRAGRetriever(RAGRetrieverConfig{Retriever: parent})
+	require.NoError(t, err)
+
+	chain := NewRAGChain(ragRetriever, nil)
+	output, err := chain.Run(ctx, "redis")
+	require.NoError(t, err)
+	assert.Contains(t, output, "It stores sessions")
+
+	assert.Error(t, ragRetriever.AddDocuments(ctx, nil), "no vector store configured")
+}
@@ -0,0 +1,227 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/testing/mocks"
+)
+
+// plainVectorStore 只暴露 VectorStore 接口,用于覆盖不支持过滤和向量检索的存储
+type plainVectorStore struct {
+	VectorStore
+}
+
+func newTransformStore(t *testing.T) *MemoryVectorStore {
+	t.Helper()
+	vs := newKeywordStore("refund", "billing", "latency", "outage", "policy")
+	require.NoError(t, vs.AddDocuments(context.Background(), []*Document{
+		NewDocumentWithID("refund", "Refund requests are processed within 14 days", map[string]interface{}{"year": 2024, "team": "billing"}),
+		NewDocumentWithID("policy", "Company policy overview for billing and refund", map[string]interface{}{"year": 2021, "team": "billing"}),
+		NewDocumentWithID("outage", "Latency outage postmortem", map[string]interface{}{"year": 2024, "team": "sre", "tags": []interface{}{"incident", "api"}}),
+	}))
+	return vs
+}
+
+func TestHyDERetriever(t *testing.T) {
+	ctx := context.Background()
+	vs := newTransformStore(t)
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(string) string {
+		return "Refunds are paid back within two weeks."
+	}))
+
+	retriever := NewHyDERetriever(vs, client, RetrieverConfig{TopK: 1})
+	docs, err := retriever.GetRelevantDocuments(ctx, "can I get my money back?")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "refund", docs[0].ID, "hypothetical answer is embedded instead of the query")
+
+	// 向量平均路径
+	retriever = NewHyDERetriever(vs, client, RetrieverConfig{TopK: 1}).
+		WithEmbedder(mocks.NewKeywordEmbedder("refund", "billing", "latency", "outage", "policy")).
+		WithNumHypotheses(2).
+		WithIncludeQuery(true)
+	docs, err = retriever.GetRelevantDocuments(ctx, "billing policy")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "policy", docs[0].ID)
+	assert.Len(t, client.GetRequestHistory(), 3)
+
+	failing := mocks.NewMockLLMClient()
+	failing.SetError(true, "llm down")
+	_, err = NewHyDERetriever(vs, failing, RetrieverConfig{}).GetRelevantDocuments(ctx, "refund")
+	assert.Error(t, err)
+}
+
+func TestStepBackRetriever(t *testing.T) {
+	ctx := context.Background()
+	base := NewVectorStoreRetriever(newTransformStore(t), RetrieverConfig{TopK: 1})
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		assert.Contains(t, prompt, "Question: how fast is a refund?")
+		return "Step-back question: What is the company policy?\nextra"
+	}))
+
+	retriever := NewStepBackRetriever(base, client, RetrieverConfig{TopK: 5})
+	question, err := retriever.StepBackQuestion(ctx, "how fast is a refund?")
+	require.NoError(t, err)
+	assert.Equal(t, "What is the company policy?", question)
+
+	docs, err := retriever.GetRelevantDocuments(ctx, "how fast is a refund?")
+	require.NoError(t, err)
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	assert.ElementsMatch(t, []string{"refund", "policy"}, ids, "results of both questions are merged")
+}
+
+func TestSelfQueryRetriever(t *testing.T) {
+	ctx := context.Background()
+	attributes := []AttributeInfo{
+		{Name: "year", Type: "integer", Description: "publication year"},
+		{Name: "team", Type: "string", Description: "owning team"},
+		{Name: "tags", Type: "list[string]", Description: "labels"},
+	}
+	response := "```json\n" + `{"query": "refund", "filter": {"operator": "and", "filters": [
+		{"operator": "gte", "attribute": "year", "value": 2022},
+		{"operator": "eq", "attribute": "team", "value": "billing"}]}, "limit": 0}` + "\n```"
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		assert.Contains(t, prompt, "- year (integer): publication year")
+		return response
+	}))
+
+	for name, vs := range map[string]VectorStore{
+		"filtered store": newTransformStore(t),
+		"post filter":    plainVectorStore{newTransformStore(t)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			retriever, err := NewSelfQueryRetriever(SelfQueryRetrieverConfig{
+				VectorStore:      vs,
+				LLMClient:        client,
+				DocumentContents: "support articles",
+				Attributes:       attributes,
+			})
+			require.NoError(t, err)
+
+			docs, err := retriever.GetRelevantDocuments(ctx, "refund articles from billing since 2022")
+			require.NoError(t, err)
+			require.Len(t, docs, 1)
+			assert.Equal(t, "refund", docs[0].ID)
+		})
+	}
+
+	response = `{"query": "", "filter": {"operator": "eq", "attribute": "owner", "value": "x"}}`
+	retriever, err := NewSelfQueryRetriever(SelfQueryRetrieverConfig{
+		VectorStore: newTransformStore(t),
+		LLMClient:   client,
+		Attributes:  attributes,
+	})
+	require.NoError(t, err)
+	_, err = retriever.GetRelevantDocuments(ctx, "anything by x")
+	assert.Error(t, err, "undeclared attribute is rejected")
+
+	response = "I cannot answer"
+	_, err = retriever.ParseQuery(ctx, "anything")
+	assert.Error(t, err)
+
+	response = `{"query": "", "filter": {"operator": "contains", "attribute": "tags", "value": "incident"}, "limit": 1}`
+	retriever.EnableLimit = true
+	structured, err := retriever.ParseQuery(ctx, "incidents")
+	require.NoError(t, err)
+	assert.Equal(t, "incidents", structured.Query, "empty semantic query falls back to the question")
+	docs, err := retriever.Execute(ctx, structured)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "outage", docs[0].ID)
+
+	retriever.MaxLimit = 2
+	docs, err = retriever.Execute(ctx, &StructuredQuery{Query: "incidents", Limit: 1000})
+	require.NoError(t, err)
+	assert.Len(t, docs, 2, "requested limit is clamped to MaxLimit")
+}
+
+func TestMetadataFilterMatch(t *testing.T) {
+	metadata := map[string]interface{}{
+		"year":  2023,
+		"score": float32(0.5),
+		"team":  "Billing",
+		"tags":  []string{"a", "b"},
+		"draft": false,
+	}
+
+	tests := []struct {
+		name   string
+		filter *MetadataFilter
+		want   bool
+	}{
+		{"nil", nil, true},
+		{"eq int vs float", &MetadataFilter{Operator: FilterEq, Attribute: "year", Value: 2023.0}, true},
+		{"eq case insensitive", &MetadataFilter{Operator: FilterEq, Attribute: "team", Value: "billing"}, true},
+		{"ne missing", &MetadataFilter{Operator: FilterNe, Attribute: "owner", Value: "x"}, true},
+		{"eq missing", &MetadataFilter{Operator: FilterEq, Attribute: "owner", Value: "x"}, false},
+		{"gt", &MetadataFilter{Operator: FilterGt, Attribute: "year", Value: 2022}, true},
+		{"lte float32", &MetadataFilter{Operator: FilterLte, Attribute: "score", Value: 0.5}, true},
+		{"lt string", &MetadataFilter{Operator: FilterLt, Attribute: "team", Value: "C"}, true},
+		{"gt incomparable", &MetadataFilter{Operator: FilterGt, Attribute: "team", Value: 1}, false},
+		{"in", &MetadataFilter{Operator: FilterIn, Attribute: "year", Value: []interface{}{2022.0, 2023.0}}, true},
+		{"nin", &MetadataFilter{Operator: FilterNin, Attribute: "year", Value: []int{2022}}, true},
+		{"contains list", &MetadataFilter{Operator: FilterContains, Attribute: "tags", Value: "b"}, true},
+		{"contains string", &MetadataFilter{Operator: FilterContains, Attribute: "team", Value: "bill"}, true},
+		{"bool", &MetadataFilter{Operator: FilterEq, Attribute: "draft", Value: false}, true},
+		{"or", &MetadataFilter{Operator: FilterOr, Filters: []*MetadataFilter{
+			{Operator: FilterEq, Attribute: "year", Value: 1999},
+			{Operator: FilterEq, Attribute: "draft", Value: false},
+		}}, true},
+		{"not", &MetadataFilter{Operator: FilterNot, Filters: []*MetadataFilter{
+			{Operator: FilterEq, Attribute: "draft", Value: false},
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(metadata))
+		})
+	}
+
+	assert.Error(t, (&MetadataFilter{Operator: "like", Attribute: "team"}).Validate(nil))
+	assert.Error(t, (&MetadataFilter{Operator: FilterIn, Attribute: "team", Value: "x"}).Validate(nil))
+	assert.Error(t, (&MetadataFilter{Operator: FilterAnd}).Validate(nil))
+	assert.NoError(t, (&MetadataFilter{Operator: FilterEq, Attribute: "team", Value: "x"}).Validate(nil))
+}
+
+func TestQueryTransformRetrieversCompose(t *testing.T) {
+	ctx := context.Background()
+	vs := newTransformStore(t)
+	client := mocks.NewMockLLMClient(mocks.WithResponder(func(prompt string) string {
+		switch {
+		case strings.Contains(prompt, "Passage:"):
+			return "Latency outage details."
+		case strings.Contains(prompt, "Step-back question:"):
+			return "What is the refund policy?"
+		}
+		return `{"query": "refund", "filter": null}`
+	}))
+
+	hyde := NewHyDERetriever(vs, client, RetrieverConfig{TopK: 2})
+	stepBack := NewStepBackRetriever(NewVectorStoreRetriever(vs, RetrieverConfig{TopK: 2}), client, RetrieverConfig{TopK: 2})
+	selfQuery, err := NewSelfQueryRetriever(SelfQueryRetrieverConfig{VectorStore: vs, LLMClient: client, RetrieverConfig: RetrieverConfig{TopK: 2}})
+	require.NoError(t, err)
+
+	ensemble := NewEnsembleRetriever([]Retriever{hyde, stepBack, selfQuery}, []float64{0.4, 0.3, 0.3}, RetrieverConfig{TopK: 3})
+	docs, err := ensemble.GetRelevantDocuments(ctx, "what happened last week?")
+	require.NoError(t, err)
+	assert.NotEmpty(t, docs)
+
+	reranking := NewRerankingRetriever(selfQuery, NewCrossEncoderReranker("local", 1), 2, RetrieverConfig{TopK: 1})
+	docs, err = reranking.GetRelevantDocuments(ctx, "refund")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+
+	var _ Retriever = hyde
+	var _ Retriever = stepBack
+	var _ Retriever = selfQuery
+}
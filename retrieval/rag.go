@@ -19,6 +19,9 @@ type RAGRetriever struct {
 	// Embedder 嵌入器（可选，如果 VectorStore 不支持）
 	embedder Embedder
 
+	// retriever 自定义检索器（可选，设置后替代向量存储检索）
+	retriever Retriever
+
 	// TopK 返回的最大文档数
 	topK int
 
@@ -36,6 +39,7 @@ type RAGRetriever struct {
 type RAGRetrieverConfig struct {
 	VectorStore      VectorStore
 	Embedder         Embedder
+	Retriever        Retriever // 可选，如 ParentDocumentRetriever、MultiVectorRetriever
 	TopK             int
 	ScoreThreshold   float32
 	IncludeMetadata  bool
@@ -44,7 +48,7 @@ type RAGRetrieverConfig struct {
 
 // NewRAGRetriever 创建 RAG 检索器
 func NewRAGRetriever(config RAGRetrieverConfig) (*RAGRetriever, error) {
-	if config.VectorStore == nil {
+	if config.VectorStore == nil && config.Retriever == nil {
 		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "vector store is required").
 			WithComponent("rag_engine").
 			WithOperation("create")
@@ -65,6 +69,7 @@ func NewRAGRetriever(config RAGRetrieverConfig) (*RAGRetriever, error) {
 	return &RAGRetriever{
 		vectorStore:      config.VectorStore,
 		embedder:         config.Embedder,
+		retriever:        config.Retriever,
 		topK:             config.TopK,
 		scoreThreshold:   config.ScoreThreshold,
 		includeMetadata:  config.IncludeMetadata,
@@ -74,8 +79,7 @@ func NewRAGRetriever(config RAGRetrieverConfig) (*RAGRetriever, error) {
 
 // Retrieve 检索相关文档
 func (r *RAGRetriever) Retrieve(ctx context.Context, query string) ([]*Document, error) {
-	// 从向量存储检索文档
-	docs, err := r.vectorStore.SimilaritySearch(ctx, query, r.topK)
+	docs, err := r.search(ctx, query)
 	if err != nil {
 		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "failed to search documents").
 			WithComponent("rag_engine").
@@ -107,6 +111,22 @@ func (r *RAGRetriever) Retrieve(ctx context.Context, query string) ([]*Document,
 	return docs, nil
 }
 
+// search 从自定义检索器或向量存储检索文档
+func (r *RAGRetriever) search(ctx context.Context, query string) ([]*Document, error) {
+	if r.retriever == nil {
+		return r.vectorStore.SimilaritySearch(ctx, query, r.topK)
+	}
+
+	docs, err := r.retriever.GetRelevantDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) > r.topK {
+		docs = docs[:r.topK]
+	}
+	return docs, nil
+}
+
 // RetrieveAndFormat 检索并格式化为 Prompt
 //
 // 使用指定的模板格式化检索到的文档
@@ -116,8 +136,15 @@ func (r *RAGRetriever) RetrieveAndFormat(ctx context.Context, query string, temp
 		return "", err
 	}
 
+	return r.FormatDocuments(query, docs, template), nil
+}
+
+// FormatDocuments 使用模板格式化文档
+//
+// 模板支持 {query}、{documents}、{num_docs} 占位符，为空时使用默认格式
+func (r *RAGRetriever) FormatDocuments(query string, docs []*Document, template string) string {
 	if len(docs) == 0 {
-		return "", nil
+		return ""
 	}
 
 	// 如果没有提供模板，使用默认格式
@@ -136,14 +163,11 @@ func (r *RAGRetriever) RetrieveAndFormat(ctx context.Context, query string, temp
 	result = strings.ReplaceAll(result, "{documents}", strings.Join(formattedDocs, "\n\n"))
 	result = strings.ReplaceAll(result, "{num_docs}", fmt.Sprintf("%d", len(docs)))
 
-	return result, nil
+	return result
 }
 
-// RetrieveWithContext 检索并构建上下文
-//
-// 返回格式化的上下文字符串，可直接用于 LLM 提示
-func (r *RAGRetriever) RetrieveWithContext(ctx context.Context, query string) (string, error) {
-	template := `Based on the following context, please answer the question.
+// contextTemplate 问答上下文模板
+const contextTemplate = `Based on the following context, please answer the question.
 
 Context:
 {documents}
@@ -152,11 +176,20 @@ Question: {query}
 
 Answer:`
 
-	return r.RetrieveAndFormat(ctx, query, template)
+// RetrieveWithContext 检索并构建上下文
+//
+// 返回格式化的上下文字符串，可直接用于 LLM 提示
+func (r *RAGRetriever) RetrieveWithContext(ctx context.Context, query string) (string, error) {
+	return r.RetrieveAndFormat(ctx, query, contextTemplate)
 }
 
 // AddDocuments 添加文档到向量存储
 func (r *RAGRetriever) AddDocuments(ctx context.Context, docs []*Document) error {
+	if r.vectorStore == nil {
+		return agentErrors.New(agentErrors.CodeInvalidConfig, "vector store is not configured").
+			WithComponent("rag_engine").
+			WithOperation("add_documents")
+	}
 	return r.vectorStore.AddDocuments(ctx, docs)
 }
 
@@ -220,6 +253,12 @@ func (r *RAGRetriever) formatDocument(doc *Document, index int) string {
 type RAGChain struct {
 	retriever *RAGRetriever
 	llmClient llm.Client
+
+	// compressor 上下文压缩器（可选）
+	compressor DocumentCompressor
+
+	// groundednessChecker 引用模式下的句子级依据校验器（可选）
+	groundednessChecker GroundednessChecker
 }
 
 // NewRAGChain 创建 RAG 链
@@ -250,12 +289,9 @@ func NewRAGChain(retriever *RAGRetriever, llmClient llm.Client) *RAGChain {
 //   - error: 错误信息
 func (c *RAGChain) Run(ctx context.Context, query string) (string, error) {
 	// 1. 检索相关文档
-	docs, err := c.retriever.Retrieve(ctx, query)
+	docs, err := c.retrieve(ctx, query, "run")
 	if err != nil {
-		return "", agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "retrieval failed").
-			WithComponent("rag_chain").
-			WithOperation("run").
-			WithContext("query", query)
+		return "", err
 	}
 
 	if len(docs) == 0 {
@@ -263,13 +299,7 @@ func (c *RAGChain) Run(ctx context.Context, query string) (string, error) {
 	}
 
 	// 2. 格式化上下文
-	contextPrompt, err := c.retriever.RetrieveWithContext(ctx, query)
-	if err != nil {
-		return "", agentErrors.Wrap(err, agentErrors.CodeInternal, "failed to format context").
-			WithComponent("rag_chain").
-			WithOperation("run").
-			WithContext("query", query)
-	}
+	contextPrompt := c.retriever.FormatDocuments(query, docs, contextTemplate)
 
 	// 3. 如果没有 LLM 客户端，返回格式化的上下文
 	if c.llmClient == nil {
@@ -293,6 +323,38 @@ func (c *RAGChain) Run(ctx context.Context, query string) (string, error) {
 	return response.Content, nil
 }
 
+// WithCompressor 设置上下文压缩器
+//
+// 检索到的文档在进入提示词之前先经过压缩，只保留与问题相关的片段
+func (c *RAGChain) WithCompressor(compressor DocumentCompressor) *RAGChain {
+	c.compressor = compressor
+	return c
+}
+
+// retrieve 检索并压缩文档
+func (c *RAGChain) retrieve(ctx context.Context, query, operation string) ([]*Document, error) {
+	docs, err := c.retriever.Retrieve(ctx, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "retrieval failed").
+			WithComponent("rag_chain").
+			WithOperation(operation).
+			WithContext("query", query)
+	}
+
+	if c.compressor == nil || len(docs) == 0 {
+		return docs, nil
+	}
+
+	docs, err = c.compressor.CompressDocuments(ctx, docs, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInternal, "context compression failed").
+			WithComponent("rag_chain").
+			WithOperation(operation).
+			WithContext("query", query)
+	}
+	return docs, nil
+}
+
 // RAGMultiQueryRetriever RAG 多查询检索器
 //
 // 生成多个相关查询并合并结果，提高召回率
@@ -0,0 +1,483 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// GroundednessVerdict 句子依据判定
+type GroundednessVerdict string
+
+const (
+	// GroundednessSupported 句子完全由引用来源支持
+	GroundednessSupported GroundednessVerdict = "supported"
+
+	// GroundednessPartial 句子部分由引用来源支持
+	GroundednessPartial GroundednessVerdict = "partial"
+
+	// GroundednessUnsupported 句子没有来源支持
+	GroundednessUnsupported GroundednessVerdict = "unsupported"
+)
+
+// Citation 答案中的编号引用
+type Citation struct {
+	// Index 引用编号(从 1 开始),与答案中的 [n] 对应
+	Index int `json:"index"`
+
+	// DocumentID 来源文档 ID
+	DocumentID string `json:"document_id"`
+
+	// Source 来源(文档元数据中的 source)
+	Source string `json:"source,omitempty"`
+
+	// Spans 引用内容在原始文档中的字符区间
+	Spans []TextSpan `json:"spans"`
+
+	// Quote 提供给 LLM 的引用内容
+	Quote string `json:"quote"`
+}
+
+// SentenceGrounding 答案句子及其依据
+type SentenceGrounding struct {
+	// Text 句子内容(已去除引用标记)
+	Text string `json:"text"`
+
+	// Span 句子在答案中的字符区间(包含引用标记)
+	Span TextSpan `json:"span"`
+
+	// Citations 句子引用的编号
+	Citations []int `json:"citations"`
+
+	// Verdict 依据判定
+	Verdict GroundednessVerdict `json:"verdict"`
+
+	// Reason 判定理由
+	Reason string `json:"reason,omitempty"`
+}
+
+// CitedAnswer 带引用的结构化答案
+type CitedAnswer struct {
+	// Query 用户问题
+	Query string `json:"query"`
+
+	// Answer 答案文本(保留 [n] 引用标记)
+	Answer string `json:"answer"`
+
+	// Citations 答案实际引用的来源,按编号排序
+	Citations []Citation `json:"citations"`
+
+	// Sentences 逐句依据判定
+	Sentences []SentenceGrounding `json:"sentences"`
+
+	// Documents 提供给 LLM 的全部来源,第 i 个对应编号 i+1
+	Documents []*Document `json:"documents"`
+}
+
+// Grounded 是否所有句子都有来源支持
+func (a *CitedAnswer) Grounded() bool {
+	return len(a.UnsupportedSentences()) == 0
+}
+
+// UnsupportedSentences 返回没有来源支持的句子
+func (a *CitedAnswer) UnsupportedSentences() []SentenceGrounding {
+	result := make([]SentenceGrounding, 0)
+	for _, s := range a.Sentences {
+		if s.Verdict == GroundednessUnsupported {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// GroundednessChecker 句子级依据校验器
+type GroundednessChecker interface {
+	// CheckGroundedness 为每个句子填写 Verdict 和 Reason,docs 的第 i 个对应编号 i+1
+	CheckGroundedness(ctx context.Context, sentences []SentenceGrounding, docs []*Document) ([]SentenceGrounding, error)
+}
+
+// citedAnswerPrompt 带引用回答的提示词
+const citedAnswerPrompt = `Answer the question using only the numbered sources below.
+After every sentence, cite the sources that support it with their numbers in square brackets, e.g. [1] or [1][3].
+If the sources do not contain the answer, say that you don't know.
+
+Sources:
+{sources}
+
+Question: {query}
+
+Answer:`
+
+// citationPattern 匹配 [1]、[1, 2] 形式的引用标记
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// spaceBeforePunctPattern 匹配去除引用标记后标点前多余的空白
+var spaceBeforePunctPattern = regexp.MustCompile(`\s+([.,;:!?。，；：！？])`)
+
+// leadingCitationPattern 匹配句首的引用标记
+var leadingCitationPattern = regexp.MustCompile(`^\s*(?:\[\d+(?:\s*,\s*\d+)*\]\s*)+`)
+
+// WithGroundednessChecker 设置依据校验器,默认使用 LLMGroundednessChecker
+func (c *RAGChain) WithGroundednessChecker(checker GroundednessChecker) *RAGChain {
+	c.groundednessChecker = checker
+	return c
+}
+
+// RunWithCitations 执行 RAG 链并返回带引用的结构化答案
+//
+// 答案中的 [n] 标记对应 Citations 中的编号,每个句子附带依据判定,
+// 便于界面展示来源并标记没有依据的陈述
+func (c *RAGChain) RunWithCitations(ctx context.Context, query string) (*CitedAnswer, error) {
+	if c.llmClient == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "llm client is required for cited answers").
+			WithComponent("rag_chain").
+			WithOperation("run_with_citations")
+	}
+
+	docs, err := c.retrieve(ctx, query, "run_with_citations")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CitedAnswer{
+		Query:     query,
+		Citations: []Citation{},
+		Sentences: []SentenceGrounding{},
+		Documents: docs,
+	}
+	if len(docs) == 0 {
+		result.Answer = "No relevant documents found."
+		return result, nil
+	}
+
+	prompt := strings.ReplaceAll(citedAnswerPrompt, "{sources}", formatSources(docs))
+	prompt = strings.ReplaceAll(prompt, "{query}", query)
+
+	response, err := c.llmClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(prompt),
+		},
+	})
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "LLM generation failed").
+			WithComponent("rag_chain").
+			WithOperation("run_with_citations").
+			WithContext("query", query)
+	}
+
+	result.Answer = strings.TrimSpace(response.Content)
+	result.Sentences = parseCitedSentences(result.Answer, len(docs))
+	result.Citations = buildCitations(result.Sentences, docs)
+
+	checker := c.groundednessChecker
+	if checker == nil {
+		checker = NewLLMGroundednessChecker(c.llmClient)
+	}
+	sentences, err := checker.CheckGroundedness(ctx, result.Sentences, docs)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInternal, "groundedness check failed").
+			WithComponent("rag_chain").
+			WithOperation("run_with_citations").
+			WithContext("query", query)
+	}
+	result.Sentences = sentences
+
+	return result, nil
+}
+
+// formatSources 将文档格式化为编号来源列表
+func formatSources(docs []*Document) string {
+	var sb strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("[%d] %s", i+1, doc.PageContent))
+	}
+	return sb.String()
+}
+
+// parseCitedSentences 切分答案句子并解析引用标记
+//
+// 句首的引用标记(如句号之后的 "[2]")归入前一个句子,超出范围的编号被忽略
+func parseCitedSentences(answer string, numDocs int) []SentenceGrounding {
+	runes := []rune(answer)
+	sentences := make([]SentenceGrounding, 0)
+
+	for _, span := range sentenceSpans(answer) {
+		raw := string(runes[span.Start:span.End])
+
+		if n := len(sentences); n > 0 {
+			if lead := leadingCitationPattern.FindString(raw); lead != "" {
+				sentences[n-1].Citations = mergeCitationIndexes(sentences[n-1].Citations, extractCitations(lead, numDocs))
+				sentences[n-1].Span.End = span.Start + len([]rune(strings.TrimRightFunc(lead, unicode.IsSpace)))
+				raw = raw[len(lead):]
+				span.Start += len([]rune(lead))
+			}
+		}
+		citations := extractCitations(raw, numDocs)
+
+		text := strings.TrimSpace(citationPattern.ReplaceAllString(raw, ""))
+		text = spaceBeforePunctPattern.ReplaceAllString(strings.Join(strings.Fields(text), " "), "$1")
+		if strings.TrimFunc(text, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSpace(r) }) == "" {
+			if n := len(sentences); n > 0 {
+				sentences[n-1].Citations = mergeCitationIndexes(sentences[n-1].Citations, citations)
+				sentences[n-1].Span.End = span.End
+			}
+			continue
+		}
+
+		sentences = append(sentences, SentenceGrounding{
+			Text:      text,
+			Span:      span,
+			Citations: mergeCitationIndexes(nil, citations),
+		})
+	}
+	return sentences
+}
+
+// extractCitations 提取文本中有效的引用编号
+func extractCitations(text string, numDocs int) []int {
+	citations := make([]int, 0)
+	for _, match := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, part := range strings.Split(match[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err == nil && n >= 1 && n <= numDocs {
+				citations = append(citations, n)
+			}
+		}
+	}
+	return citations
+}
+
+// mergeCitationIndexes 合并引用编号并去重排序
+func mergeCitationIndexes(a, b []int) []int {
+	seen := make(map[int]bool, len(a)+len(b))
+	result := make([]int, 0, len(a)+len(b))
+	for _, n := range append(append([]int{}, a...), b...) {
+		if !seen[n] {
+			seen[n] = true
+			result = append(result, n)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// buildCitations 根据句子中的引用编号构建引用列表
+func buildCitations(sentences []SentenceGrounding, docs []*Document) []Citation {
+	indexes := make([]int, 0)
+	for _, s := range sentences {
+		indexes = mergeCitationIndexes(indexes, s.Citations)
+	}
+
+	citations := make([]Citation, 0, len(indexes))
+	for _, n := range indexes {
+		doc := docs[n-1]
+		source, _ := doc.Metadata["source"].(string)
+		citations = append(citations, Citation{
+			Index:      n,
+			DocumentID: doc.ID,
+			Source:     source,
+			Spans:      DocumentSpans(doc),
+			Quote:      doc.PageContent,
+		})
+	}
+	return citations
+}
+
+// groundednessPrompt 依据判定提示词
+const groundednessPrompt = `You are checking whether each sentence of an answer is supported by the numbered sources.
+For every sentence, decide:
+- "supported": every claim is stated in the sources
+- "partial": some claims are stated in the sources, others are not
+- "unsupported": the sources do not state the claims
+
+Sources:
+{sources}
+
+Sentences:
+{sentences}
+
+Respond with a JSON array only, one object per sentence:
+[{"sentence": 1, "verdict": "supported", "reason": "..."}]`
+
+// LLMGroundednessChecker 使用 LLM 判定句子依据
+//
+// LLM 输出无法解析时退化为 LexicalGroundednessChecker
+type LLMGroundednessChecker struct {
+	// LLMClient LLM 客户端
+	LLMClient llm.Client
+
+	// Fallback 输出无法解析时使用的校验器
+	Fallback GroundednessChecker
+}
+
+// NewLLMGroundednessChecker 创建 LLM 依据校验器
+func NewLLMGroundednessChecker(llmClient llm.Client) *LLMGroundednessChecker {
+	return &LLMGroundednessChecker{
+		LLMClient: llmClient,
+		Fallback:  NewLexicalGroundednessChecker(0),
+	}
+}
+
+// CheckGroundedness 判定每个句子的依据
+func (g *LLMGroundednessChecker) CheckGroundedness(ctx context.Context, sentences []SentenceGrounding, docs []*Document) ([]SentenceGrounding, error) {
+	if len(sentences) == 0 {
+		return sentences, nil
+	}
+
+	var list strings.Builder
+	for i, s := range sentences {
+		list.WriteString(fmt.Sprintf("%d. %s", i+1, s.Text))
+		if len(s.Citations) > 0 {
+			list.WriteString(fmt.Sprintf(" (cites %v)", s.Citations))
+		}
+		list.WriteString("\n")
+	}
+
+	prompt := strings.ReplaceAll(groundednessPrompt, "{sources}", formatSources(docs))
+	prompt = strings.ReplaceAll(prompt, "{sentences}", strings.TrimRight(list.String(), "\n"))
+
+	response, err := g.LLMClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(prompt),
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "groundedness judgement failed").
+			WithComponent("llm_groundedness_checker").
+			WithOperation("check_groundedness")
+	}
+
+	verdicts, ok := parseVerdicts(response.Content)
+	if !ok && g.Fallback != nil {
+		return g.Fallback.CheckGroundedness(ctx, sentences, docs)
+	}
+
+	result := make([]SentenceGrounding, len(sentences))
+	copy(result, sentences)
+	for i := range result {
+		if v, found := verdicts[i+1]; found {
+			result[i].Verdict = v.Verdict
+			result[i].Reason = v.Reason
+			continue
+		}
+		result[i].Verdict = GroundednessUnsupported
+		result[i].Reason = "no verdict returned"
+	}
+	return result, nil
+}
+
+// judgedVerdict LLM 返回的单条判定
+type judgedVerdict struct {
+	Sentence int                 `json:"sentence"`
+	Verdict  GroundednessVerdict `json:"verdict"`
+	Reason   string              `json:"reason"`
+}
+
+// parseVerdicts 解析 LLM 返回的判定数组
+func parseVerdicts(text string) (map[int]judgedVerdict, bool) {
+	start := strings.Index(text, "[")
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, false
+	}
+
+	var items []judgedVerdict
+	if err := json.Unmarshal([]byte(text[start:end+1]), &items); err != nil {
+		return nil, false
+	}
+
+	verdicts := make(map[int]judgedVerdict, len(items))
+	for _, item := range items {
+		switch item.Verdict {
+		case GroundednessSupported, GroundednessPartial, GroundednessUnsupported:
+			verdicts[item.Sentence] = item
+		default:
+			return nil, false
+		}
+	}
+	return verdicts, true
+}
+
+// LexicalGroundednessChecker 基于词汇重叠的依据校验器
+//
+// 计算句子词汇在引用来源中出现的比例,不调用 LLM,适合离线和测试场景
+type LexicalGroundednessChecker struct {
+	// Threshold 判定为 supported 的最低重叠比例,一半以上判定为 partial
+	Threshold float64
+}
+
+// NewLexicalGroundednessChecker 创建词汇重叠校验器,threshold <= 0 时默认 0.6
+func NewLexicalGroundednessChecker(threshold float64) *LexicalGroundednessChecker {
+	if threshold <= 0 {
+		threshold = 0.6
+	}
+	return &LexicalGroundednessChecker{Threshold: threshold}
+}
+
+// CheckGroundedness 判定每个句子的依据,没有引用的句子判定为 unsupported
+func (l *LexicalGroundednessChecker) CheckGroundedness(ctx context.Context, sentences []SentenceGrounding, docs []*Document) ([]SentenceGrounding, error) {
+	result := make([]SentenceGrounding, len(sentences))
+	copy(result, sentences)
+
+	for i := range result {
+		if len(result[i].Citations) == 0 {
+			result[i].Verdict = GroundednessUnsupported
+			result[i].Reason = "no citation"
+			continue
+		}
+
+		sourceTokens := make(map[string]bool)
+		for _, n := range result[i].Citations {
+			for _, token := range groundingTokens(docs[n-1].PageContent) {
+				sourceTokens[token] = true
+			}
+		}
+
+		tokens := groundingTokens(result[i].Text)
+		if len(tokens) == 0 {
+			result[i].Verdict = GroundednessSupported
+			continue
+		}
+		matched := 0
+		for _, token := range tokens {
+			if sourceTokens[token] {
+				matched++
+			}
+		}
+
+		overlap := float64(matched) / float64(len(tokens))
+		switch {
+		case overlap >= l.Threshold:
+			result[i].Verdict = GroundednessSupported
+		case overlap >= l.Threshold/2:
+			result[i].Verdict = GroundednessPartial
+		default:
+			result[i].Verdict = GroundednessUnsupported
+		}
+		result[i].Reason = fmt.Sprintf("lexical overlap %.2f", overlap)
+	}
+	return result, nil
+}
+
+// groundingTokens 提取用于重叠计算的词汇,中文按单字切分
+func groundingTokens(text string) []string {
+	tokens := simpleTokenize(text)
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			tokens = append(tokens, string(r))
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,141 @@
+package retrieval
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/store"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// DefaultRecordNamespace 记录管理器默认命名空间
+var DefaultRecordNamespace = []string{"retrieval", "records"}
+
+// SourceRecord 单个来源的索引记录
+type SourceRecord struct {
+	// SourceID 来源标识(通常是文件路径或 URL)
+	SourceID string `json:"source_id"`
+
+	// Chunks 块 ID 到内容哈希的映射
+	Chunks map[string]string `json:"chunks"`
+
+	// UpdatedAt 最近一次索引时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChunkIDs 返回排序后的块 ID
+func (r *SourceRecord) ChunkIDs() []string {
+	ids := make([]string, 0, len(r.Chunks))
+	for id := range r.Chunks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// RecordManager 索引记录管理器
+//
+// 在 store.Store 中保存 来源 ID → 块 ID 及内容哈希 的映射,
+// 使重复索引时可以跳过未变化的块并清理过期的块
+type RecordManager struct {
+	store     store.Store
+	namespace []string
+}
+
+// NewRecordManager 创建记录管理器,namespace 为空时使用 DefaultRecordNamespace
+func NewRecordManager(s store.Store, namespace ...string) *RecordManager {
+	if len(namespace) == 0 {
+		namespace = DefaultRecordNamespace
+	}
+	return &RecordManager{
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// Get 获取来源记录,不存在时返回 nil
+func (m *RecordManager) Get(ctx context.Context, sourceID string) (*SourceRecord, error) {
+	value, err := m.store.Get(ctx, m.namespace, sourceID)
+	if agentErrors.IsCode(err, agentErrors.CodeStoreNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to load source record").
+			WithComponent("record_manager").
+			WithOperation("get").
+			WithContext("source_id", sourceID)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	record, err := decodeSourceRecord(value.Value)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode source record").
+			WithComponent("record_manager").
+			WithOperation("get").
+			WithContext("source_id", sourceID)
+	}
+	return record, nil
+}
+
+// Put 保存来源记录
+func (m *RecordManager) Put(ctx context.Context, record *SourceRecord) error {
+	if err := m.store.Put(ctx, m.namespace, record.SourceID, record); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to save source record").
+			WithComponent("record_manager").
+			WithOperation("put").
+			WithContext("source_id", record.SourceID)
+	}
+	return nil
+}
+
+// Delete 删除来源记录
+func (m *RecordManager) Delete(ctx context.Context, sourceID string) error {
+	if err := m.store.Delete(ctx, m.namespace, sourceID); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to delete source record").
+			WithComponent("record_manager").
+			WithOperation("delete").
+			WithContext("source_id", sourceID)
+	}
+	return nil
+}
+
+// ListSources 列出所有已索引的来源
+func (m *RecordManager) ListSources(ctx context.Context) ([]string, error) {
+	keys, err := m.store.List(ctx, m.namespace)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to list source records").
+			WithComponent("record_manager").
+			WithOperation("list")
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// decodeSourceRecord 解码记录
+//
+// 内存存储直接返回 *SourceRecord,持久化存储经 JSON 往返后返回 map
+func decodeSourceRecord(value interface{}) (*SourceRecord, error) {
+	switch v := value.(type) {
+	case *SourceRecord:
+		return v, nil
+	case SourceRecord:
+		return &v, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	record := &SourceRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	if record.Chunks == nil {
+		record.Chunks = make(map[string]string)
+	}
+	return record, nil
+}
@@ -2,13 +2,20 @@ package retrieval
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"net/http"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 
 	coheregov2 "github.com/cohere-ai/cohere-go/v2"
 	cohereclient "github.com/cohere-ai/cohere-go/v2/client"
+	cohereoption "github.com/cohere-ai/cohere-go/v2/option"
 
 	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
 )
 
 // Reranker 重排序器接口
@@ -47,6 +54,11 @@ type CrossEncoderReranker struct {
 
 	// TopN 返回前 N 个文档
 	TopN int
+
+	// Client 交叉编码器客户端
+	//
+	// 为 nil 时退化为基于词汇重叠的本地评分，仅用于测试和离线开发
+	Client CrossEncoderClient
 }
 
 // NewCrossEncoderReranker 创建交叉编码器重排序器
@@ -58,35 +70,67 @@ func NewCrossEncoderReranker(model string, topN int) *CrossEncoderReranker {
 	}
 }
 
+// WithClient 设置交叉编码器客户端
+func (c *CrossEncoderReranker) WithClient(client CrossEncoderClient) *CrossEncoderReranker {
+	c.Client = client
+	return c
+}
+
 // Rerank 重新排序文档
 func (c *CrossEncoderReranker) Rerank(ctx context.Context, query string, docs []*Document) ([]*Document, error) {
 	if len(docs) == 0 {
 		return docs, nil
 	}
 
-	// 模拟交叉编码器评分
-	// 实际应该调用真实的模型 API
+	scores, err := c.score(ctx, query, docs)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "cross encoder scoring failed").
+			WithComponent("cross_encoder_reranker").
+			WithOperation("rerank").
+			WithContext("model", c.Model).
+			WithContext("num_docs", len(docs))
+	}
+
 	scored := make([]*Document, len(docs))
 	for i, doc := range docs {
 		docCopy := doc.Clone()
-		// 模拟评分：基于内容相似度
-		docCopy.Score = c.calculateRelevanceScore(query, doc.PageContent)
+		docCopy.Score = scores[i]
 		scored[i] = docCopy
 	}
 
-	// 排序
-	collection := DocumentCollection(scored)
-	collection.SortByScore()
+	return finalizeReranked(scored, c.TopN), nil
+}
+
+// score 计算每个文档的归一化分数
+func (c *CrossEncoderReranker) score(ctx context.Context, query string, docs []*Document) ([]float64, error) {
+	if c.Client == nil {
+		scores := make([]float64, len(docs))
+		for i, doc := range docs {
+			scores[i] = c.calculateRelevanceScore(query, doc.PageContent)
+		}
+		return scores, nil
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
 
-	// 返回 top-N
-	if c.TopN > 0 && len(collection) > c.TopN {
-		collection = collection[:c.TopN]
+	scores, err := c.Client.Score(ctx, query, texts)
+	if err != nil {
+		return nil, err
 	}
 
-	return collection, nil
+	if len(scores) != len(docs) {
+		return nil, agentErrors.New(agentErrors.CodeRetrievalSearch, "cross encoder returned mismatched score count").
+			WithContext("expected", len(docs)).
+			WithContext("actual", len(scores))
+	}
+
+	return scores, nil
 }
 
-// calculateRelevanceScore 计算相关性分数（模拟）
+// calculateRelevanceScore 计算相关性分数（未配置客户端时的本地近似）
 func (c *CrossEncoderReranker) calculateRelevanceScore(query, content string) float64 {
 	// 简单的基于关键词匹配的模拟
 	queryWords := tokenize(query)
@@ -115,35 +159,80 @@ func (c *CrossEncoderReranker) calculateRelevanceScore(query, content string) fl
 
 // LLMReranker LLM 重排序器
 //
-// 使用 LLM 对文档进行相关性判断和排序
+// 使用 LLM 进行 listwise 重排序：每次将一个窗口内的文档编号后交给 LLM，
+// 解析其返回的排列（如 "[2] > [1] > [3]"），并按滑动窗口从后向前合并，
+// 使排名靠后的相关文档也能逐步前移
 type LLMReranker struct {
 	*BaseReranker
 
 	// LLMClient LLM 客户端
-	// LLMClient llm.LLMClient
+	//
+	// 为 nil 时退化为基于词汇重叠的本地评分，仅用于测试和离线开发
+	LLMClient llm.Client
 
 	// TopN 返回前 N 个文档
 	TopN int
 
 	// Prompt 提示词模板
+	//
+	// 支持 {{.Query}}、{{.Documents}} 和 {{.Count}} 占位符
 	Prompt string
+
+	// WindowSize 单次 LLM 调用排序的文档数
+	WindowSize int
+
+	// StepSize 滑动窗口的步长
+	StepSize int
+
+	// MaxContextChars 单次调用中文档内容的总字符预算
+	//
+	// 每个文档按预算均分截断，避免超出模型上下文
+	MaxContextChars int
 }
 
 // NewLLMReranker 创建 LLM 重排序器
 func NewLLMReranker(topN int) *LLMReranker {
 	return &LLMReranker{
-		BaseReranker: NewBaseReranker("llm_reranker"),
-		TopN:         topN,
-		Prompt:       defaultRerankPrompt,
+		BaseReranker:    NewBaseReranker("llm_reranker"),
+		TopN:            topN,
+		Prompt:          defaultRerankPrompt,
+		WindowSize:      10,
+		StepSize:        5,
+		MaxContextChars: 8000,
 	}
 }
 
-const defaultRerankPrompt = `Given a query and a document, rate the relevance of the document to the query on a scale of 0-10.
+const defaultRerankPrompt = `You are ranking search results by their relevance to a query.
 
 Query: {{.Query}}
-Document: {{.Document}}
 
-Relevance score (0-10):`
+The following {{.Count}} passages are each labelled with an identifier in square brackets:
+
+{{.Documents}}
+
+Rank all {{.Count}} passages from most to least relevant to the query.
+Answer only with the identifiers in order, separated by " > ", for example: [2] > [1] > [3]
+
+Ranking:`
+
+// WithLLMClient 设置 LLM 客户端
+func (l *LLMReranker) WithLLMClient(client llm.Client) *LLMReranker {
+	l.LLMClient = client
+	return l
+}
+
+// WithWindow 设置滑动窗口大小和步长
+func (l *LLMReranker) WithWindow(size, step int) *LLMReranker {
+	l.WindowSize = size
+	l.StepSize = step
+	return l
+}
+
+// WithContextBudget 设置单次调用的文档字符预算
+func (l *LLMReranker) WithContextBudget(maxChars int) *LLMReranker {
+	l.MaxContextChars = maxChars
+	return l
+}
 
 // Rerank 重新排序文档
 func (l *LLMReranker) Rerank(ctx context.Context, query string, docs []*Document) ([]*Document, error) {
@@ -151,30 +240,158 @@ func (l *LLMReranker) Rerank(ctx context.Context, query string, docs []*Document
 		return docs, nil
 	}
 
-	// 模拟 LLM 评分
 	scored := make([]*Document, len(docs))
-	for i, doc := range docs {
-		docCopy := doc.Clone()
-		// 模拟评分（实际应该调用 LLM）
-		docCopy.Score = l.simulateLLMScore(query, doc.PageContent)
-		scored[i] = docCopy
+
+	if l.LLMClient == nil {
+		for i, doc := range docs {
+			docCopy := doc.Clone()
+			docCopy.Score = l.simulateLLMScore(query, doc.PageContent)
+			scored[i] = docCopy
+		}
+		return finalizeReranked(scored, l.TopN), nil
 	}
 
-	// 排序
-	collection := DocumentCollection(scored)
-	collection.SortByScore()
+	order, err := l.slidingWindowRank(ctx, query, docs)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "LLM listwise reranking failed").
+			WithComponent("llm_reranker").
+			WithOperation("rerank").
+			WithContext("query", query).
+			WithContext("num_docs", len(docs))
+	}
+
+	// 按最终名次赋予 (0, 1] 区间内的分数
+	n := float64(len(order))
+	for rank, idx := range order {
+		docCopy := docs[idx].Clone()
+		docCopy.Score = 1.0 - float64(rank)/n
+		scored[rank] = docCopy
+	}
+
+	return finalizeReranked(scored, l.TopN), nil
+}
+
+// slidingWindowRank 从后向前滑动窗口，逐窗口调用 LLM 排序
+func (l *LLMReranker) slidingWindowRank(ctx context.Context, query string, docs []*Document) ([]int, error) {
+	windowSize := l.WindowSize
+	if windowSize <= 1 {
+		windowSize = 10
+	}
+	step := l.StepSize
+	if step <= 0 || step > windowSize {
+		step = windowSize
+	}
+
+	order := make([]int, len(docs))
+	for i := range order {
+		order[i] = i
+	}
+
+	end := len(order)
+	for {
+		start := end - windowSize
+		if start < 0 {
+			start = 0
+		}
+
+		window := order[start:end]
+		permutation, err := l.rankWindow(ctx, query, docs, window)
+		if err != nil {
+			return nil, err
+		}
+
+		reordered := make([]int, len(window))
+		for i, p := range permutation {
+			reordered[i] = window[p]
+		}
+		copy(order[start:end], reordered)
+
+		if start == 0 {
+			break
+		}
+		end -= step
+	}
+
+	return order, nil
+}
+
+// rankWindow 调用 LLM 对窗口内文档排序，返回窗口内的相对排列
+func (l *LLMReranker) rankWindow(ctx context.Context, query string, docs []*Document, window []int) ([]int, error) {
+	if len(window) == 1 {
+		return []int{0}, nil
+	}
+
+	perDoc := 0
+	if l.MaxContextChars > 0 {
+		perDoc = l.MaxContextChars / len(window)
+	}
+
+	passages := make([]string, len(window))
+	for i, idx := range window {
+		passages[i] = fmt.Sprintf("[%d] %s", i+1, truncateRunes(docs[idx].PageContent, perDoc))
+	}
 
-	// 返回 top-N
-	if l.TopN > 0 && len(collection) > l.TopN {
-		collection = collection[:l.TopN]
+	prompt := strings.ReplaceAll(l.Prompt, "{{.Query}}", query)
+	prompt = strings.ReplaceAll(prompt, "{{.Documents}}", strings.Join(passages, "\n\n"))
+	prompt = strings.ReplaceAll(prompt, "{{.Count}}", fmt.Sprintf("%d", len(window)))
+
+	response, err := l.LLMClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(prompt),
+		},
+		Temperature: 0,
+		MaxTokens:   8 * len(window),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRankPermutation(response.Content, len(window)), nil
+}
+
+// parseRankPermutation 解析 LLM 返回的排列
+//
+// 提取文本中的编号（1-based），忽略越界和重复编号，
+// 缺失的编号按原顺序追加到末尾，保证结果总是 0..n-1 的完整排列
+func parseRankPermutation(text string, n int) []int {
+	permutation := make([]int, 0, n)
+	seen := make(map[int]bool, n)
+
+	for _, match := range rankIDPattern.FindAllString(text, -1) {
+		id, err := strconv.Atoi(match)
+		if err != nil || id < 1 || id > n || seen[id-1] {
+			continue
+		}
+		seen[id-1] = true
+		permutation = append(permutation, id-1)
 	}
 
-	return collection, nil
+	for i := 0; i < n; i++ {
+		if !seen[i] {
+			permutation = append(permutation, i)
+		}
+	}
+
+	return permutation
 }
 
-// simulateLLMScore 模拟 LLM 评分
+// rankIDPattern 匹配排列中的文档编号
+var rankIDPattern = regexp.MustCompile(`\d+`)
+
+// truncateRunes 按字符数截断文本，maxChars <= 0 表示不截断
+func truncateRunes(text string, maxChars int) string {
+	if maxChars <= 0 {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	return string(runes[:maxChars]) + "..."
+}
+
+// simulateLLMScore 未配置 LLM 时的本地近似评分
 func (l *LLMReranker) simulateLLMScore(query, content string) float64 {
-	// 简单的模拟逻辑
 	queryWords := tokenize(query)
 	contentWords := tokenize(content)
 
@@ -329,17 +546,49 @@ type CohereReranker struct {
 	client *cohereclient.Client
 }
 
+// CohereRerankerOption Cohere 重排序器选项
+type CohereRerankerOption func(*cohereRerankerOptions)
+
+// cohereRerankerOptions Cohere 客户端可选配置
+type cohereRerankerOptions struct {
+	baseURL     string
+	httpClient  *http.Client
+	maxAttempts uint
+}
+
+// WithCohereBaseURL 设置 Cohere API 地址（用于代理或兼容服务）
+func WithCohereBaseURL(baseURL string) CohereRerankerOption {
+	return func(o *cohereRerankerOptions) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithCohereHTTPClient 设置自定义 HTTP 客户端
+func WithCohereHTTPClient(client *http.Client) CohereRerankerOption {
+	return func(o *cohereRerankerOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithCohereMaxAttempts 设置最大请求次数（包含首次请求）
+func WithCohereMaxAttempts(attempts uint) CohereRerankerOption {
+	return func(o *cohereRerankerOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
 // NewCohereReranker 创建 Cohere 重排序器
 //
 // 参数:
 //   - apiKey: Cohere API 密钥
 //   - model: 模型名称（可选，默认为 "rerank-english-v2.0"）
 //   - topN: 返回前 N 个文档
+//   - opts: 可选的客户端配置
 //
 // 返回:
 //   - *CohereReranker: Cohere 重排序器实例
 //   - error: 错误信息
-func NewCohereReranker(apiKey, model string, topN int) (*CohereReranker, error) {
+func NewCohereReranker(apiKey, model string, topN int, opts ...CohereRerankerOption) (*CohereReranker, error) {
 	if apiKey == "" {
 		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "Cohere API key is required").
 			WithComponent("cohere_reranker").
@@ -350,7 +599,23 @@ func NewCohereReranker(apiKey, model string, topN int) (*CohereReranker, error)
 		model = "rerank-english-v2.0"
 	}
 
-	client := cohereclient.NewClient(cohereclient.WithToken(apiKey))
+	options := &cohereRerankerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	clientOpts := []cohereoption.RequestOption{cohereclient.WithToken(apiKey)}
+	if options.baseURL != "" {
+		clientOpts = append(clientOpts, cohereclient.WithBaseURL(options.baseURL))
+	}
+	if options.httpClient != nil {
+		clientOpts = append(clientOpts, cohereclient.WithHTTPClient(options.httpClient))
+	}
+	if options.maxAttempts > 0 {
+		clientOpts = append(clientOpts, cohereclient.WithMaxAttempts(options.maxAttempts))
+	}
+
+	client := cohereclient.NewClient(clientOpts...)
 
 	return &CohereReranker{
 		BaseReranker: NewBaseReranker("cohere"),
@@ -407,19 +672,22 @@ func (c *CohereReranker) Rerank(ctx context.Context, query string, docs []*Docum
 
 	// 转换结果
 	if response == nil || response.Results == nil {
-		return docs, nil
+		return nil, agentErrors.New(agentErrors.CodeRetrievalSearch, "Cohere rerank API returned no results").
+			WithComponent("cohere_reranker").
+			WithOperation("rerank").
+			WithContext("num_docs", len(docs))
 	}
 
 	rerankedDocs := make([]*Document, 0, len(response.Results))
 	for _, result := range response.Results {
-		if result.Index < len(docs) {
+		if result.Index >= 0 && result.Index < len(docs) {
 			doc := docs[result.Index].Clone()
-			doc.Score = result.RelevanceScore
+			doc.Score = math.Max(0, math.Min(1, result.RelevanceScore))
 			rerankedDocs = append(rerankedDocs, doc)
 		}
 	}
 
-	return rerankedDocs, nil
+	return finalizeReranked(rerankedDocs, c.TopN), nil
 }
 
 // RerankingRetriever 带重排序的检索器
@@ -461,6 +729,11 @@ func NewRerankingRetriever(
 
 // GetRelevantDocuments 检索并重排序文档
 func (r *RerankingRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return r.traceRetrieval(ctx, query, r.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (r *RerankingRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
 	// 1. 使用基础检索器获取候选文档
 	docs, err := r.Retriever.GetRelevantDocuments(ctx, query)
 	if err != nil {
@@ -0,0 +1,356 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/httpclient"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// CrossEncoderClient 交叉编码器评分客户端接口
+//
+// 对 (query, text) 对进行联合编码并返回相关性分数
+type CrossEncoderClient interface {
+	// Score 计算每个文本与查询的相关性分数
+	//
+	// 返回的分数顺序与 texts 一一对应，取值范围为 [0, 1]
+	Score(ctx context.Context, query string, texts []string) ([]float64, error)
+}
+
+// RerankAPIFormat 重排序服务的接口格式
+type RerankAPIFormat string
+
+const (
+	// RerankAPIFormatTEI HuggingFace text-embeddings-inference 格式
+	//
+	// 请求: {"query": "...", "texts": ["..."]}
+	// 响应: [{"index": 0, "score": 0.98}]
+	RerankAPIFormatTEI RerankAPIFormat = "tei"
+
+	// RerankAPIFormatInfinity Infinity / Jina / Cohere 兼容格式
+	//
+	// 请求: {"query": "...", "documents": ["..."], "model": "..."}
+	// 响应: {"results": [{"index": 0, "relevance_score": 0.98}]}
+	RerankAPIFormatInfinity RerankAPIFormat = "infinity"
+)
+
+// RerankScoreScale 重排序服务返回分数的取值尺度
+type RerankScoreScale string
+
+const (
+	// RerankScoreProbability 服务返回 [0, 1] 内的概率（默认）
+	//
+	// TEI 默认对 logits 应用 sigmoid，Infinity / Jina / Cohere 返回 relevance_score 概率
+	RerankScoreProbability RerankScoreScale = "probability"
+
+	// RerankScoreLogit 服务返回原始 logits，由客户端统一应用 sigmoid
+	//
+	// TEI 格式下会在请求中设置 raw_scores=true
+	RerankScoreLogit RerankScoreScale = "logit"
+)
+
+// HTTPCrossEncoderConfig HTTP 交叉编码器配置
+type HTTPCrossEncoderConfig struct {
+	// Endpoint rerank 接口地址，如 http://localhost:8080/rerank
+	//
+	// 未以 /rerank 结尾时自动追加
+	Endpoint string
+
+	// Model 模型名称（Infinity 格式必需，TEI 格式忽略）
+	Model string
+
+	// Format 接口格式，默认 TEI
+	Format RerankAPIFormat
+
+	// APIKey 可选的 Bearer Token
+	APIKey string
+
+	// Timeout 请求超时时间
+	Timeout time.Duration
+
+	// RetryCount 重试次数
+	RetryCount int
+
+	// BatchSize 单次请求的最大文本数，超过时分批请求
+	BatchSize int
+
+	// Truncate 是否让服务端截断超长输入（仅 TEI）
+	Truncate bool
+
+	// ScoreScale 服务返回分数的尺度，默认 RerankScoreProbability
+	ScoreScale RerankScoreScale
+}
+
+// HTTPCrossEncoderClient 基于 HTTP 的交叉编码器客户端
+//
+// 支持 TEI 和 Infinity 风格的 /rerank 服务
+type HTTPCrossEncoderClient struct {
+	config HTTPCrossEncoderConfig
+	client *httpclient.Client
+}
+
+// NewHTTPCrossEncoderClient 创建 HTTP 交叉编码器客户端
+func NewHTTPCrossEncoderClient(config HTTPCrossEncoderConfig) (*HTTPCrossEncoderClient, error) {
+	if config.Endpoint == "" {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "rerank endpoint is required").
+			WithComponent("cross_encoder_client").
+			WithOperation("create")
+	}
+
+	if config.Format == "" {
+		config.Format = RerankAPIFormatTEI
+	}
+
+	if config.Format != RerankAPIFormatTEI && config.Format != RerankAPIFormatInfinity {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "unsupported rerank API format").
+			WithComponent("cross_encoder_client").
+			WithOperation("create").
+			WithContext("format", string(config.Format))
+	}
+
+	if config.ScoreScale == "" {
+		config.ScoreScale = RerankScoreProbability
+	}
+
+	if config.ScoreScale != RerankScoreProbability && config.ScoreScale != RerankScoreLogit {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "unsupported rerank score scale").
+			WithComponent("cross_encoder_client").
+			WithOperation("create").
+			WithContext("score_scale", string(config.ScoreScale))
+	}
+
+	if !strings.HasSuffix(strings.TrimRight(config.Endpoint, "/"), "/rerank") {
+		config.Endpoint = strings.TrimRight(config.Endpoint, "/") + "/rerank"
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = 32
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if config.APIKey != "" {
+		headers["Authorization"] = "Bearer " + config.APIKey
+	}
+
+	client := httpclient.NewClient(&httpclient.Config{
+		Timeout:    config.Timeout,
+		RetryCount: config.RetryCount,
+		Headers:    headers,
+	})
+
+	return &HTTPCrossEncoderClient{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// teiRerankRequest TEI 请求体
+type teiRerankRequest struct {
+	Query     string   `json:"query"`
+	Texts     []string `json:"texts"`
+	RawScores bool     `json:"raw_scores"`
+	Truncate  bool     `json:"truncate,omitempty"`
+}
+
+// infinityRerankRequest Infinity 请求体
+type infinityRerankRequest struct {
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	Model           string   `json:"model,omitempty"`
+	ReturnDocuments bool     `json:"return_documents"`
+}
+
+// rerankResult 统一的单条结果
+type rerankResult struct {
+	Index          int      `json:"index"`
+	Score          *float64 `json:"score,omitempty"`
+	RelevanceScore *float64 `json:"relevance_score,omitempty"`
+}
+
+// value 返回结果分数，结果不含分数字段时返回 false
+func (r rerankResult) value() (float64, bool) {
+	if r.RelevanceScore != nil {
+		return *r.RelevanceScore, true
+	}
+	if r.Score != nil {
+		return *r.Score, true
+	}
+	return 0, false
+}
+
+// Score 计算相关性分数
+//
+// 按 ScoreScale 将分数归一化到 [0, 1]；响应缺少任一文本的分数时返回错误
+func (h *HTTPCrossEncoderClient) Score(ctx context.Context, query string, texts []string) ([]float64, error) {
+	scores := make([]float64, len(texts))
+
+	for start := 0; start < len(texts); start += h.config.BatchSize {
+		end := start + h.config.BatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		results, err := h.scoreBatch(ctx, query, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		seen := make([]bool, end-start)
+		for _, result := range results {
+			if result.Index < 0 || result.Index >= end-start {
+				return nil, agentErrors.New(agentErrors.CodeRetrievalSearch, "rerank response index out of range").
+					WithComponent("cross_encoder_client").
+					WithOperation("score").
+					WithContext("index", result.Index).
+					WithContext("batch_size", end-start)
+			}
+
+			value, ok := result.value()
+			if !ok {
+				continue
+			}
+
+			score, err := h.normalizeScore(value, start+result.Index)
+			if err != nil {
+				return nil, err
+			}
+			scores[start+result.Index] = score
+			seen[result.Index] = true
+		}
+
+		for i, ok := range seen {
+			if !ok {
+				return nil, agentErrors.New(agentErrors.CodeRetrievalSearch, "rerank response is missing results").
+					WithComponent("cross_encoder_client").
+					WithOperation("score").
+					WithContext("index", start+i).
+					WithContext("batch_size", end-start)
+			}
+		}
+	}
+
+	return scores, nil
+}
+
+// normalizeScore 按配置的尺度将分数归一化到 [0, 1]
+//
+// 概率尺度下超出 [0, 1] 的分数说明服务返回的是 logits，此时返回错误而不是逐批猜测
+func (h *HTTPCrossEncoderClient) normalizeScore(score float64, index int) (float64, error) {
+	if h.config.ScoreScale == RerankScoreLogit {
+		return 1.0 / (1.0 + math.Exp(-score)), nil
+	}
+
+	if score < 0 || score > 1 {
+		return 0, agentErrors.New(agentErrors.CodeRetrievalSearch, "rerank score out of [0, 1], set ScoreScale to logit for raw scores").
+			WithComponent("cross_encoder_client").
+			WithOperation("score").
+			WithContext("index", index).
+			WithContext("score", score)
+	}
+
+	return score, nil
+}
+
+// scoreBatch 发送单批请求
+func (h *HTTPCrossEncoderClient) scoreBatch(ctx context.Context, query string, texts []string) ([]rerankResult, error) {
+	var body interface{}
+	switch h.config.Format {
+	case RerankAPIFormatInfinity:
+		body = infinityRerankRequest{
+			Query:     query,
+			Documents: texts,
+			Model:     h.config.Model,
+		}
+	default:
+		body = teiRerankRequest{
+			Query:     query,
+			Texts:     texts,
+			RawScores: h.config.ScoreScale == RerankScoreLogit,
+			Truncate:  h.config.Truncate,
+		}
+	}
+
+	resp, err := h.client.R().
+		SetContext(ctx).
+		SetBody(body).
+		Post(h.config.Endpoint)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "rerank request failed").
+			WithComponent("cross_encoder_client").
+			WithOperation("score").
+			WithContext("endpoint", h.config.Endpoint)
+	}
+
+	if !resp.IsSuccess() {
+		return nil, agentErrors.New(agentErrors.CodeRetrievalSearch,
+			fmt.Sprintf("rerank API error (status %d): %s", resp.StatusCode(), resp.String())).
+			WithComponent("cross_encoder_client").
+			WithOperation("score").
+			WithContext("endpoint", h.config.Endpoint)
+	}
+
+	return parseRerankResponse(resp.Body())
+}
+
+// parseRerankResponse 解析 TEI 数组或 {"results": [...]} 对象格式的响应
+func parseRerankResponse(data []byte) ([]rerankResult, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	var results []rerankResult
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeParserInvalidJSON, "failed to parse rerank response").
+				WithComponent("cross_encoder_client").
+				WithOperation("parse_response")
+		}
+		return results, nil
+	}
+
+	var wrapped struct {
+		Results []rerankResult `json:"results"`
+	}
+	if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeParserInvalidJSON, "failed to parse rerank response").
+			WithComponent("cross_encoder_client").
+			WithOperation("parse_response")
+	}
+
+	return wrapped.Results, nil
+}
+
+// NewHTTPCrossEncoderReranker 创建调用 HTTP 服务的交叉编码器重排序器
+func NewHTTPCrossEncoderReranker(config HTTPCrossEncoderConfig, topN int) (*CrossEncoderReranker, error) {
+	client, err := NewHTTPCrossEncoderClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	reranker := NewCrossEncoderReranker(config.Model, topN)
+	reranker.Client = client
+
+	return reranker, nil
+}
+
+// finalizeReranked 按分数排序并截取前 N 个文档
+func finalizeReranked(scored []*Document, topN int) []*Document {
+	collection := DocumentCollection(scored)
+	collection.SortByScore()
+
+	if topN > 0 && len(collection) > topN {
+		collection = collection[:topN]
+	}
+
+	return collection
+}
@@ -2,7 +2,16 @@ package retrieval
 
 import (
 	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/testing/mocks"
+	"github.com/kart-io/goagent/utils/json"
 )
 
 // TestBaseRerankerNoop tests base reranker (no-op)
@@ -489,3 +498,354 @@ func TestRankFusionEmptyRankings(t *testing.T) {
 
 	fusion.Fuse(rankings)
 }
+
+// TestHTTPCrossEncoderRerankerTEI tests the TEI-style /rerank backend
+func TestHTTPCrossEncoderRerankerTEI(t *testing.T) {
+	var received teiRerankRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rerank" {
+			t.Errorf("Expected path /rerank, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		_, _ = w.Write([]byte(`[{"index":2,"score":0.95},{"index":0,"score":0.40},{"index":1,"score":0.05}]`))
+	}))
+	defer server.Close()
+
+	reranker, err := NewHTTPCrossEncoderReranker(HTTPCrossEncoderConfig{Endpoint: server.URL}, 2)
+	if err != nil {
+		t.Fatalf("Failed to create reranker: %v", err)
+	}
+
+	docs := []*Document{
+		{ID: "a", PageContent: "alpha"},
+		{ID: "b", PageContent: "beta"},
+		{ID: "c", PageContent: "gamma"},
+	}
+
+	results, err := reranker.Rerank(context.Background(), "query", docs)
+	if err != nil {
+		t.Fatalf("Reranking failed: %v", err)
+	}
+
+	if received.Query != "query" || len(received.Texts) != 3 {
+		t.Errorf("Unexpected request payload: %+v", received)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].ID != "c" || results[1].ID != "a" {
+		t.Errorf("Unexpected order: %s, %s", results[0].ID, results[1].ID)
+	}
+
+	if results[0].Score != 0.95 {
+		t.Errorf("Expected score 0.95, got %f", results[0].Score)
+	}
+}
+
+// TestHTTPCrossEncoderRerankerInfinityBatches tests the Infinity format with batching and logit normalization
+func TestHTTPCrossEncoderRerankerInfinityBatches(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req infinityRerankRequest
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		if req.Model != "bge-reranker" {
+			t.Errorf("Expected model bge-reranker, got %s", req.Model)
+		}
+
+		results := make([]string, len(req.Documents))
+		for i, doc := range req.Documents {
+			score := "-3.0"
+			if strings.Contains(doc, "match") {
+				score = "4.0"
+			}
+			results[i] = `{"index":` + string(rune('0'+i)) + `,"relevance_score":` + score + `}`
+		}
+		_, _ = w.Write([]byte(`{"results":[` + strings.Join(results, ",") + `]}`))
+	}))
+	defer server.Close()
+
+	reranker, err := NewHTTPCrossEncoderReranker(HTTPCrossEncoderConfig{
+		Endpoint:   server.URL + "/",
+		Model:      "bge-reranker",
+		Format:     RerankAPIFormatInfinity,
+		BatchSize:  2,
+		ScoreScale: RerankScoreLogit,
+	}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create reranker: %v", err)
+	}
+
+	docs := []*Document{
+		{ID: "1", PageContent: "nothing"},
+		{ID: "2", PageContent: "nothing"},
+		{ID: "3", PageContent: "a match"},
+	}
+
+	results, err := reranker.Rerank(context.Background(), "query", docs)
+	if err != nil {
+		t.Fatalf("Reranking failed: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected 2 batched requests, got %d", requests)
+	}
+
+	if results[0].ID != "3" {
+		t.Errorf("Expected doc 3 first, got %s", results[0].ID)
+	}
+
+	expected := map[string]float64{
+		"1": 1.0 / (1.0 + math.Exp(3.0)),
+		"2": 1.0 / (1.0 + math.Exp(3.0)),
+		"3": 1.0 / (1.0 + math.Exp(-4.0)),
+	}
+	for _, doc := range results {
+		if math.Abs(doc.Score-expected[doc.ID]) > 1e-9 {
+			t.Errorf("Expected score %f for doc %s, got %f", expected[doc.ID], doc.ID, doc.Score)
+		}
+	}
+}
+
+// TestHTTPCrossEncoderClientScoreScale tests that raw TEI scores are requested and normalized consistently across batches
+func TestHTTPCrossEncoderClientScoreScale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req teiRerankRequest
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		if !req.RawScores {
+			t.Error("Expected raw_scores to be requested for logit scale")
+		}
+
+		// Both batches contain a logit inside [0, 1]; it must be normalized the same way
+		results := make([]string, len(req.Texts))
+		for i, text := range req.Texts {
+			score := "0.5"
+			if text == "high" {
+				score = "6.0"
+			}
+			results[i] = `{"index":` + string(rune('0'+i)) + `,"score":` + score + `}`
+		}
+		_, _ = w.Write([]byte(`[` + strings.Join(results, ",") + `]`))
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPCrossEncoderClient(HTTPCrossEncoderConfig{
+		Endpoint:   server.URL,
+		BatchSize:  2,
+		ScoreScale: RerankScoreLogit,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	scores, err := client.Score(context.Background(), "query", []string{"mid", "high", "mid"})
+	if err != nil {
+		t.Fatalf("Scoring failed: %v", err)
+	}
+
+	mid := 1.0 / (1.0 + math.Exp(-0.5))
+	if math.Abs(scores[0]-mid) > 1e-9 || math.Abs(scores[2]-mid) > 1e-9 {
+		t.Errorf("Expected both mid scores to be %f, got %v", mid, scores)
+	}
+}
+
+// TestHTTPCrossEncoderClientErrors tests configuration and server errors
+func TestHTTPCrossEncoderClientErrors(t *testing.T) {
+	if _, err := NewHTTPCrossEncoderClient(HTTPCrossEncoderConfig{}); err == nil {
+		t.Error("Expected error for missing endpoint")
+	}
+
+	if _, err := NewHTTPCrossEncoderClient(HTTPCrossEncoderConfig{Endpoint: "http://x", Format: "grpc"}); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+
+	if _, err := NewHTTPCrossEncoderClient(HTTPCrossEncoderConfig{Endpoint: "http://x", ScoreScale: "percent"}); err == nil {
+		t.Error("Expected error for unsupported score scale")
+	}
+
+	responses := map[string]string{
+		"missing":      `[{"index":0,"score":0.9}]`,
+		"no score":     `[{"index":0,"score":0.9},{"index":1}]`,
+		"out of range": `[{"index":0,"score":0.9},{"index":1,"score":3.2}]`,
+	}
+	for name, response := range responses {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			client, err := NewHTTPCrossEncoderClient(HTTPCrossEncoderConfig{Endpoint: server.URL})
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			if _, err := client.Score(context.Background(), "q", []string{"a", "b"}); err == nil {
+				t.Error("Expected error for incomplete rerank response")
+			}
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	reranker, err := NewHTTPCrossEncoderReranker(HTTPCrossEncoderConfig{Endpoint: server.URL}, 2)
+	if err != nil {
+		t.Fatalf("Failed to create reranker: %v", err)
+	}
+
+	if _, err := reranker.Rerank(context.Background(), "q", []*Document{{ID: "1", PageContent: "x"}}); err == nil {
+		t.Error("Expected error from failing server")
+	}
+}
+
+// TestLLMRerankerListwise tests listwise ranking with a sliding window
+func TestLLMRerankerListwise(t *testing.T) {
+	client := mocks.NewMockLLMClient()
+	// Window 1 covers docs 2..4, window 2 covers docs 0..2 after the first reorder
+	client.SetResponses(
+		llm.CompletionResponse{Content: "[3] > [1] > [2]"},
+		llm.CompletionResponse{Content: "[3] > [2] > [1]"},
+	)
+
+	reranker := NewLLMReranker(0).WithLLMClient(client).WithWindow(3, 2)
+
+	docs := []*Document{
+		{ID: "0", PageContent: "zero"},
+		{ID: "1", PageContent: "one"},
+		{ID: "2", PageContent: "two"},
+		{ID: "3", PageContent: "three"},
+		{ID: "4", PageContent: "four"},
+	}
+
+	results, err := reranker.Rerank(context.Background(), "query", docs)
+	if err != nil {
+		t.Fatalf("Reranking failed: %v", err)
+	}
+
+	// After window 1: [0 1 4 2 3]; window 2 reorders [0 1 4] -> [4 1 0]
+	expected := []string{"4", "1", "0", "2", "3"}
+	for i, id := range expected {
+		if results[i].ID != id {
+			t.Fatalf("Position %d: expected %s, got %s", i, id, results[i].ID)
+		}
+	}
+
+	if results[0].Score != 1.0 || results[4].Score <= 0 {
+		t.Errorf("Unexpected scores: first=%f last=%f", results[0].Score, results[4].Score)
+	}
+
+	if len(client.GetRequestHistory()) != 2 {
+		t.Errorf("Expected 2 LLM calls, got %d", len(client.GetRequestHistory()))
+	}
+}
+
+// TestLLMRerankerContextBudget tests per-document truncation against the budget
+func TestLLMRerankerContextBudget(t *testing.T) {
+	client := mocks.NewMockLLMClient()
+	client.SetResponses(llm.CompletionResponse{Content: "[2] > [1]"})
+
+	reranker := NewLLMReranker(1).WithLLMClient(client).WithContextBudget(20)
+
+	docs := []*Document{
+		{ID: "a", PageContent: strings.Repeat("x", 100)},
+		{ID: "b", PageContent: strings.Repeat("y", 100)},
+	}
+
+	results, err := reranker.Rerank(context.Background(), "query", docs)
+	if err != nil {
+		t.Fatalf("Reranking failed: %v", err)
+	}
+
+	if len(results) != 1 || results[0].ID != "b" {
+		t.Fatalf("Expected only doc b, got %v", results)
+	}
+
+	prompt := client.GetRequestHistory()[0].Messages[0].Content
+	if strings.Contains(prompt, strings.Repeat("x", 11)) {
+		t.Error("Expected document content to be truncated to the context budget")
+	}
+}
+
+// TestLLMRerankerError tests LLM failure propagation
+func TestLLMRerankerError(t *testing.T) {
+	client := mocks.NewMockLLMClient()
+	client.SetError(true, "boom")
+
+	reranker := NewLLMReranker(2).WithLLMClient(client)
+	docs := []*Document{{ID: "a", PageContent: "a"}, {ID: "b", PageContent: "b"}}
+
+	if _, err := reranker.Rerank(context.Background(), "q", docs); err == nil {
+		t.Error("Expected error from failing LLM")
+	}
+}
+
+// TestParseRankPermutation tests permutation parsing edge cases
+func TestParseRankPermutation(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		n        int
+		expected []int
+	}{
+		{"well formed", "[2] > [3] > [1]", 3, []int{1, 2, 0}},
+		{"missing ids appended", "[3]", 3, []int{2, 0, 1}},
+		{"duplicates and out of range", "[2] > [2] > [9] > [1]", 3, []int{1, 0, 2}},
+		{"no ids", "I cannot rank these", 2, []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRankPermutation(tt.text, tt.n)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+// TestCohereRerankerAPI tests the Cohere reranker against a fake API server
+func TestCohereRerankerAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/rerank") {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Missing bearer token")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"x","results":[{"index":1,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`))
+	}))
+	defer server.Close()
+
+	reranker, err := NewCohereReranker("key", "rerank-v3.5", 1, WithCohereBaseURL(server.URL), WithCohereMaxAttempts(1))
+	if err != nil {
+		t.Fatalf("Failed to create reranker: %v", err)
+	}
+
+	docs := []*Document{
+		{ID: "a", PageContent: "first"},
+		{ID: "b", PageContent: "second"},
+	}
+
+	results, err := reranker.Rerank(context.Background(), "query", docs)
+	if err != nil {
+		t.Fatalf("Reranking failed: %v", err)
+	}
+
+	if len(results) != 1 || results[0].ID != "b" || results[0].Score != 0.9 {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
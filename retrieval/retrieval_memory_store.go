@@ -145,6 +145,26 @@ func (m *MemoryVectorStore) Search(ctx context.Context, query string, topK int)
 
 // SearchByVector 通过向量搜索
 func (m *MemoryVectorStore) SearchByVector(ctx context.Context, queryVector []float32, topK int) ([]*Document, error) {
+	return m.searchByVector(queryVector, topK, nil)
+}
+
+// SimilaritySearchWithFilter 带元数据过滤的相似度搜索
+//
+// 先过滤再排序,保证返回 topK 个满足条件的文档
+func (m *MemoryVectorStore) SimilaritySearchWithFilter(ctx context.Context, query string, topK int, filter *MetadataFilter) ([]*Document, error) {
+	queryVector, err := m.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalEmbedding, "failed to embed query").
+			WithComponent("memory_store").
+			WithOperation("search_with_filter").
+			WithContext("query", query)
+	}
+
+	return m.searchByVector(queryVector, topK, filter)
+}
+
+// searchByVector 通过向量搜索满足过滤条件的文档
+func (m *MemoryVectorStore) searchByVector(queryVector []float32, topK int, filter *MetadataFilter) ([]*Document, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -161,6 +181,10 @@ func (m *MemoryVectorStore) SearchByVector(ctx context.Context, queryVector []fl
 	scores := make([]docScore, 0, len(m.documents))
 
 	for _, docWithVec := range m.documents {
+		if !filter.Match(docWithVec.Document.Metadata) {
+			continue
+		}
+
 		score, err := m.calculateSimilarity(queryVector, docWithVec.Vector)
 		if err != nil {
 			continue // 跳过错误的向量
@@ -2,8 +2,12 @@ package retrieval
 
 import (
 	"context"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/observability"
 )
 
 // Retriever 定义检索器接口
@@ -126,6 +130,18 @@ func (r *BaseRetriever) GetRelevantDocuments(ctx context.Context, query string)
 	return []*Document{}, nil
 }
 
+// traceRetrieval 在 retrieve span 中执行检索
+//
+// 各检索器的 GetRelevantDocuments 通过它记录检索器名称、TopK 与返回文档数,
+// 组合检索器（集成、重排、多查询等）内部的检索会成为子 span
+func (r *BaseRetriever) traceRetrieval(ctx context.Context, query string, retrieve func(context.Context, string) ([]*Document, error)) ([]*Document, error) {
+	ctx, span := observability.StartRetrievalSpan(ctx, r.Name, query, r.TopK)
+	docs, err := retrieve(ctx, query)
+	span.SetAttributes(attribute.Int(observability.AttrRetrievalDocumentCount, len(docs)))
+	observability.EndSpan(span, err)
+	return docs, err
+}
+
 // FilterByScore 按分数过滤文档
 func (r *BaseRetriever) FilterByScore(docs []*Document) []*Document {
 	if r.MinScore <= 0.0 {
@@ -170,3 +186,28 @@ func DefaultRetrieverConfig() RetrieverConfig {
 		Name:     "retriever",
 	}
 }
+
+// mergeByMaxScore 合并多组检索结果,按文档 ID 去重并取最高分,结果按分数降序排列
+func mergeByMaxScore(lists ...[]*Document) []*Document {
+	merged := make(map[string]*Document)
+	results := make([]*Document, 0)
+
+	for _, docs := range lists {
+		for _, doc := range docs {
+			if existing, ok := merged[doc.ID]; ok {
+				if doc.Score > existing.Score {
+					existing.Score = doc.Score
+				}
+				continue
+			}
+			docCopy := doc.Clone()
+			merged[doc.ID] = docCopy
+			results = append(results, docCopy)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
@@ -0,0 +1,291 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// FilteredVectorStore 支持元数据过滤的向量存储
+//
+// 未实现该接口的向量存储会先多取 FetchK 个结果,再在本地按过滤条件筛选
+type FilteredVectorStore interface {
+	SimilaritySearchWithFilter(ctx context.Context, query string, topK int, filter *MetadataFilter) ([]*Document, error)
+}
+
+// StructuredQuery 结构化查询
+type StructuredQuery struct {
+	// Query 用于语义检索的文本
+	Query string `json:"query"`
+
+	// Filter 元数据过滤条件,为空表示不过滤
+	Filter *MetadataFilter `json:"filter,omitempty"`
+
+	// Limit 问题中明确要求的结果数量,0 表示未指定
+	Limit int `json:"limit,omitempty"`
+}
+
+// defaultSelfQueryMaxLimit 默认的结果数量上限
+const defaultSelfQueryMaxLimit = 50
+
+// defaultSelfQueryPrompt 默认的自查询提示词
+const defaultSelfQueryPrompt = `Your goal is to structure the user's query to match the request schema below.
+
+The documents are: {{.Contents}}
+
+Filterable metadata attributes:
+{{.Attributes}}
+
+Respond with a JSON object of the form:
+{"query": string, "filter": object or null, "limit": integer}
+
+- "query" is the text to compare to document contents. Remove any words that only express filter conditions.
+- "filter" is a condition on metadata attributes:
+  comparison: {"operator": "eq|ne|gt|gte|lt|lte|in|nin|contains", "attribute": name, "value": value}
+  logical: {"operator": "and|or|not", "filters": [condition, ...]}
+  Only use the attributes listed above. Use null when no filter applies.
+- "limit" is the number of documents the user asked for, or 0.
+
+User query: {{.Question}}
+
+JSON:`
+
+// SelfQueryRetriever 自查询检索器
+//
+// 由 LLM 将自然语言问题转换为语义查询文本和元数据过滤条件,
+// 再在向量存储上执行带过滤的相似度检索
+type SelfQueryRetriever struct {
+	*BaseRetriever
+
+	// VectorStore 向量存储
+	VectorStore VectorStore
+
+	// LLMClient LLM 客户端(用于生成结构化查询)
+	LLMClient llm.Client
+
+	// DocumentContents 文档内容描述
+	DocumentContents string
+
+	// Attributes 可过滤的元数据属性
+	Attributes []AttributeInfo
+
+	// FetchK 向量存储不支持过滤时预取的结果数量,默认 TopK 的 10 倍
+	FetchK int
+
+	// EnableLimit 是否使用问题中要求的结果数量
+	EnableLimit bool
+
+	// MaxLimit 问题中要求的结果数量上限,超出时截断,默认 50
+	MaxLimit int
+
+	// Prompt 自查询提示词
+	Prompt string
+}
+
+// SelfQueryRetrieverConfig 自查询检索器配置
+type SelfQueryRetrieverConfig struct {
+	VectorStore      VectorStore
+	LLMClient        llm.Client
+	DocumentContents string
+	Attributes       []AttributeInfo
+	FetchK           int
+	EnableLimit      bool
+	MaxLimit         int
+	Prompt           string
+	RetrieverConfig
+}
+
+// NewSelfQueryRetriever 创建自查询检索器
+func NewSelfQueryRetriever(config SelfQueryRetrieverConfig) (*SelfQueryRetriever, error) {
+	if config.VectorStore == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "vector store is required").
+			WithComponent("self_query_retriever").
+			WithOperation("create")
+	}
+	if config.LLMClient == nil {
+		return nil, agentErrors.New(agentErrors.CodeInvalidConfig, "llm client is required").
+			WithComponent("self_query_retriever").
+			WithOperation("create")
+	}
+	if config.Prompt == "" {
+		config.Prompt = defaultSelfQueryPrompt
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = defaultSelfQueryMaxLimit
+	}
+
+	retriever := &SelfQueryRetriever{
+		BaseRetriever:    NewBaseRetriever(),
+		VectorStore:      config.VectorStore,
+		LLMClient:        config.LLMClient,
+		DocumentContents: config.DocumentContents,
+		Attributes:       config.Attributes,
+		FetchK:           config.FetchK,
+		EnableLimit:      config.EnableLimit,
+		MaxLimit:         config.MaxLimit,
+		Prompt:           config.Prompt,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "self_query_retriever"
+	}
+
+	return retriever, nil
+}
+
+// GetRelevantDocuments 检索相关文档
+func (s *SelfQueryRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return s.traceRetrieval(ctx, query, s.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (s *SelfQueryRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	structured, err := s.ParseQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := s.Execute(ctx, structured)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "self-query search failed").
+			WithComponent("self_query_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query).
+			WithContext("semantic_query", structured.Query)
+	}
+	return docs, nil
+}
+
+// ParseQuery 使用 LLM 将自然语言问题转换为结构化查询
+func (s *SelfQueryRetriever) ParseQuery(ctx context.Context, query string) (*StructuredQuery, error) {
+	response, err := s.LLMClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(s.buildPrompt(query)),
+		},
+		Temperature: 0,
+		MaxTokens:   500,
+	})
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "failed to generate structured query").
+			WithComponent("self_query_retriever").
+			WithOperation("parse_query").
+			WithContext("query", query)
+	}
+
+	structured, err := parseStructuredQuery(response.Content)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMResponse, "failed to parse structured query").
+			WithComponent("self_query_retriever").
+			WithOperation("parse_query").
+			WithContext("query", query).
+			WithContext("response", response.Content)
+	}
+
+	if err := structured.Filter.Validate(s.Attributes); err != nil {
+		return nil, err
+	}
+
+	// 问题只包含过滤条件时使用原始问题做语义检索
+	if strings.TrimSpace(structured.Query) == "" {
+		structured.Query = query
+	}
+	return structured, nil
+}
+
+// Execute 执行结构化查询
+func (s *SelfQueryRetriever) Execute(ctx context.Context, structured *StructuredQuery) ([]*Document, error) {
+	k := s.TopK
+	if s.EnableLimit && structured.Limit > 0 {
+		k = structured.Limit
+		// 数量由 LLM 给出,不能超过配置的上限
+		if s.MaxLimit > 0 && k > s.MaxLimit {
+			k = s.MaxLimit
+		}
+	}
+
+	var docs []*Document
+	var err error
+	if filtered, ok := s.VectorStore.(FilteredVectorStore); ok {
+		docs, err = filtered.SimilaritySearchWithFilter(ctx, structured.Query, k, structured.Filter)
+	} else {
+		docs, err = s.searchAndFilter(ctx, structured, k)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	docs = s.FilterByScore(docs)
+	if k > 0 && len(docs) > k {
+		docs = docs[:k]
+	}
+	return docs, nil
+}
+
+// searchAndFilter 预取结果后在本地过滤
+func (s *SelfQueryRetriever) searchAndFilter(ctx context.Context, structured *StructuredQuery, k int) ([]*Document, error) {
+	fetchK := k
+	if structured.Filter != nil {
+		fetchK = s.FetchK
+		if fetchK <= 0 {
+			fetchK = k * 10
+		}
+	}
+
+	docs, err := s.VectorStore.SimilaritySearchWithScore(ctx, structured.Query, fetchK)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Document, 0, len(docs))
+	for _, doc := range docs {
+		if structured.Filter.Match(doc.Metadata) {
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+// buildPrompt 构建提示词
+func (s *SelfQueryRetriever) buildPrompt(query string) string {
+	var attrs strings.Builder
+	for _, attr := range s.Attributes {
+		attrs.WriteString(fmt.Sprintf("- %s (%s): %s\n", attr.Name, attr.Type, attr.Description))
+	}
+	if attrs.Len() == 0 {
+		attrs.WriteString("(none)\n")
+	}
+
+	contents := s.DocumentContents
+	if contents == "" {
+		contents = "text documents"
+	}
+
+	prompt := strings.ReplaceAll(s.Prompt, "{{.Contents}}", contents)
+	prompt = strings.ReplaceAll(prompt, "{{.Attributes}}", strings.TrimRight(attrs.String(), "\n"))
+	prompt = strings.ReplaceAll(prompt, "{{.Question}}", query)
+	return prompt
+}
+
+// parseStructuredQuery 从 LLM 输出中解析结构化查询,兼容 Markdown 代码块
+func parseStructuredQuery(text string) (*StructuredQuery, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, agentErrors.New(agentErrors.CodeLLMResponse, "no JSON object found in response")
+	}
+
+	structured := &StructuredQuery{}
+	if err := json.Unmarshal([]byte(text[start:end+1]), structured); err != nil {
+		return nil, err
+	}
+	return structured, nil
+}
@@ -0,0 +1,140 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/llm"
+)
+
+// defaultStepBackPrompt 默认的退一步问题生成提示词
+const defaultStepBackPrompt = `You are an expert at world knowledge. Your task is to step back and paraphrase
+a question to a more generic step-back question, which is easier to answer and
+retrieves the background knowledge needed for the original question.
+
+Examples:
+Question: Which team did Thierry Henry play for in 2007?
+Step-back question: Which teams did Thierry Henry play for in his career?
+
+Question: Could the members of The Police perform lawful arrests?
+Step-back question: What can the members of The Police do?
+
+Question: {{.Question}}
+Step-back question:`
+
+// StepBackRetriever 退一步检索器 (Step-Back Prompting)
+//
+// 使用 LLM 将具体问题抽象为更一般的问题,
+// 同时检索原始问题和抽象问题并合并结果,补充回答所需的背景知识
+type StepBackRetriever struct {
+	*BaseRetriever
+
+	// Retriever 基础检索器
+	Retriever Retriever
+
+	// LLMClient LLM 客户端(用于生成抽象问题)
+	LLMClient llm.Client
+
+	// Prompt 抽象问题生成提示词
+	Prompt string
+}
+
+// NewStepBackRetriever 创建退一步检索器
+func NewStepBackRetriever(
+	baseRetriever Retriever,
+	llmClient llm.Client,
+	config RetrieverConfig,
+) *StepBackRetriever {
+	retriever := &StepBackRetriever{
+		BaseRetriever: NewBaseRetriever(),
+		Retriever:     baseRetriever,
+		LLMClient:     llmClient,
+		Prompt:        defaultStepBackPrompt,
+	}
+
+	if config.TopK > 0 {
+		retriever.TopK = config.TopK
+	}
+	retriever.MinScore = config.MinScore
+	retriever.Name = config.Name
+	if retriever.Name == "" {
+		retriever.Name = "step_back_retriever"
+	}
+
+	return retriever
+}
+
+// GetRelevantDocuments 检索相关文档
+func (s *StepBackRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return s.traceRetrieval(ctx, query, s.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (s *StepBackRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	stepBack, err := s.StepBackQuestion(ctx, query)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeLLMRequest, "failed to generate step-back question").
+			WithComponent("step_back_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query)
+	}
+
+	queries := []string{query}
+	if stepBack != "" && !strings.EqualFold(stepBack, query) {
+		queries = append(queries, stepBack)
+	}
+
+	results := make([][]*Document, len(queries))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, q := range queries {
+		g.Go(func() error {
+			docs, err := s.Retriever.GetRelevantDocuments(gctx, q)
+			if err != nil {
+				return err
+			}
+			results[i] = docs
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeRetrievalSearch, "step-back retrieval failed").
+			WithComponent("step_back_retriever").
+			WithOperation("get_relevant_documents").
+			WithContext("query", query).
+			WithContext("step_back_query", stepBack)
+	}
+
+	docs := mergeByMaxScore(results...)
+	return s.LimitTopK(s.FilterByScore(docs)), nil
+}
+
+// StepBackQuestion 生成抽象问题
+func (s *StepBackRetriever) StepBackQuestion(ctx context.Context, query string) (string, error) {
+	response, err := s.LLMClient.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			llm.UserMessage(strings.ReplaceAll(s.Prompt, "{{.Question}}", query)),
+		},
+		Temperature: 0,
+		MaxTokens:   200,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// 只取第一行,去掉可能重复输出的前缀
+	text := strings.TrimSpace(response.Content)
+	if idx := strings.Index(text, "\n"); idx >= 0 {
+		text = text[:idx]
+	}
+	text = strings.TrimPrefix(text, "Step-back question:")
+	return strings.TrimSpace(text), nil
+}
+
+// WithPrompt 设置抽象问题生成提示词
+func (s *StepBackRetriever) WithPrompt(prompt string) *StepBackRetriever {
+	s.Prompt = prompt
+	return s
+}
@@ -68,6 +68,11 @@ func NewVectorStoreRetriever(vectorStore VectorStore, config RetrieverConfig) *V
 
 // GetRelevantDocuments 检索相关文档
 func (v *VectorStoreRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
+	return v.traceRetrieval(ctx, query, v.getRelevantDocuments)
+}
+
+// getRelevantDocuments 执行检索
+func (v *VectorStoreRetriever) getRelevantDocuments(ctx context.Context, query string) ([]*Document, error) {
 	var docs []*Document
 	var err error
 
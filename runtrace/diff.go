@@ -0,0 +1,130 @@
+package runtrace
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ChangeType describes how a node differs between two runs
+type ChangeType string
+
+// Change types
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// FieldDiff is a field that differs between two aligned nodes
+type FieldDiff struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+}
+
+// DiffEntry is a node that differs between two runs
+type DiffEntry struct {
+	Path   string      `json:"path"`
+	Change ChangeType  `json:"change"`
+	Kind   NodeKind    `json:"kind"`
+	Name   string      `json:"name"`
+	A      *Node       `json:"a,omitempty"`
+	B      *Node       `json:"b,omitempty"`
+	Fields []FieldDiff `json:"fields,omitempty"`
+}
+
+// Diff compares two runs.
+//
+// Nodes are aligned by their path in the tree, built from the kind, name and
+// occurrence index among siblings of each step (e.g.
+// "run/agent#0/tool:search#1"), so a re-run of the same agent lines up even
+// though node IDs differ. Input, output, error, model and token usage are
+// compared; timings are not. Entries follow the order of run a, followed by
+// the nodes only present in run b. The returned nodes are shallow copies
+// without children.
+func Diff(a, b *Run) []DiffEntry {
+	nodesA, orderA := indexNodes(a.Root)
+	nodesB, orderB := indexNodes(b.Root)
+
+	var entries []DiffEntry
+	for _, path := range orderA {
+		nodeA := nodesA[path]
+		nodeB, ok := nodesB[path]
+		if !ok {
+			entries = append(entries, DiffEntry{Path: path, Change: ChangeRemoved, Kind: nodeA.Kind, Name: nodeA.Name, A: leaf(nodeA)})
+			continue
+		}
+		if fields := compareNodes(nodeA, nodeB); len(fields) > 0 {
+			entries = append(entries, DiffEntry{
+				Path: path, Change: ChangeChanged, Kind: nodeA.Kind, Name: nodeA.Name,
+				A: leaf(nodeA), B: leaf(nodeB), Fields: fields,
+			})
+		}
+	}
+	for _, path := range orderB {
+		if _, ok := nodesA[path]; !ok {
+			nodeB := nodesB[path]
+			entries = append(entries, DiffEntry{Path: path, Change: ChangeAdded, Kind: nodeB.Kind, Name: nodeB.Name, B: leaf(nodeB)})
+		}
+	}
+	return entries
+}
+
+// indexNodes maps every node of the tree to its alignment path
+func indexNodes(root *Node) (map[string]*Node, []string) {
+	nodes := make(map[string]*Node)
+	var order []string
+	if root == nil {
+		return nodes, order
+	}
+
+	var visit func(node *Node, path string)
+	visit = func(node *Node, path string) {
+		nodes[path] = node
+		order = append(order, path)
+		seen := make(map[string]int)
+		for _, child := range node.Children {
+			// LLM and agent nodes are named after their model and task; align
+			// them by position so that such changes show up as changed fields
+			segment := string(child.Kind)
+			if child.Name != "" && child.Kind != KindLLM && child.Kind != KindAgent {
+				segment += ":" + child.Name
+			}
+			index := seen[segment]
+			seen[segment]++
+			visit(child, fmt.Sprintf("%s/%s#%d", path, segment, index))
+		}
+	}
+	visit(root, string(root.Kind))
+	return nodes, order
+}
+
+// compareNodes returns the fields that differ between two aligned nodes
+func compareNodes(a, b *Node) []FieldDiff {
+	var fields []FieldDiff
+	add := func(field string, valueA, valueB interface{}) {
+		if !reflect.DeepEqual(valueA, valueB) {
+			fields = append(fields, FieldDiff{Field: field, A: valueA, B: valueB})
+		}
+	}
+	add("input", a.Input, b.Input)
+	add("output", a.Output, b.Output)
+	add("error", a.Error, b.Error)
+	add("model", a.Model, b.Model)
+	add("total_tokens", totalTokens(a), totalTokens(b))
+	return fields
+}
+
+func totalTokens(node *Node) int {
+	if node.Usage == nil {
+		return 0
+	}
+	return node.Usage.TotalTokens
+}
+
+// leaf returns a copy of the node without children
+func leaf(node *Node) *Node {
+	copied := *node
+	copied.Children = nil
+	return &copied
+}
@@ -0,0 +1,104 @@
+package runtrace
+
+import (
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/testing/cassette"
+)
+
+// Fixture exports the LLM and tool calls of a run as a cassette, so a
+// recorded production run can be replayed deterministically in tests with
+// cassette.New(t, path).
+//
+// LLM nodes recorded by Recorder.LLM replay exactly. Nodes only seen through
+// callbacks carry the prompts rather than the messages sent; they are
+// exported as Chat calls with one user message per prompt, which is what
+// react.ReActAgent sends. Retriever and agent nodes have no cassette
+// counterpart and are skipped.
+func Fixture(run *Run) *cassette.Cassette {
+	fixture := &cassette.Cassette{
+		Version:    cassette.Version,
+		RecordedAt: run.StartTime,
+	}
+	if run.Root == nil {
+		return fixture
+	}
+
+	run.Root.Walk(func(node *Node, _ int) {
+		var ix *cassette.Interaction
+		switch node.Kind {
+		case KindLLM:
+			ix = llmInteraction(node)
+		case KindTool:
+			ix = toolInteraction(node)
+		default:
+			return
+		}
+		ix.Duration = node.Duration()
+		if node.Output == nil {
+			ix.Error = node.Error
+		}
+		fixture.Interactions = append(fixture.Interactions, ix)
+	})
+	return fixture
+}
+
+// llmInteraction converts an LLM node into a Complete or Chat interaction
+func llmInteraction(node *Node) *cassette.Interaction {
+	ix := &cassette.Interaction{Kind: cassette.KindChat, Request: node.Input}
+	switch node.Metadata["call"] {
+	case callComplete:
+		ix.Kind = cassette.KindComplete
+	case callChat:
+	default:
+		var messages []llm.Message
+		if prompts, ok := node.Input.([]interface{}); ok {
+			for _, prompt := range prompts {
+				if text, ok := prompt.(string); ok {
+					messages = append(messages, llm.UserMessage(text))
+				}
+			}
+		}
+		ix.Request = toGeneric(chatRequest{Messages: messages})
+	}
+
+	switch output := node.Output.(type) {
+	case nil:
+	case map[string]interface{}:
+		ix.Response = output
+	default:
+		resp := &llm.CompletionResponse{Model: node.Model, Usage: node.Usage}
+		if text, ok := output.(string); ok {
+			resp.Content = text
+		}
+		ix.Response = toGeneric(resp)
+	}
+	return ix
+}
+
+// toolInteraction converts a tool node into a tool interaction
+func toolInteraction(node *Node) *cassette.Interaction {
+	ix := &cassette.Interaction{Kind: cassette.KindTool, Target: node.Name}
+
+	request, ok := node.Input.(map[string]interface{})
+	if _, hasArgs := request["args"]; !ok || !hasArgs {
+		args, _ := node.Input.(map[string]interface{})
+		request = toGeneric(toolRequest{Args: args}).(map[string]interface{})
+	}
+	ix.Request = request
+
+	if node.Output == nil {
+		return ix
+	}
+	if output, ok := node.Output.(map[string]interface{}); ok {
+		if _, ok := output["success"]; ok {
+			ix.Response = output
+			return ix
+		}
+	}
+	ix.Response = map[string]interface{}{
+		"result":  node.Output,
+		"success": node.Error == "",
+		"error":   node.Error,
+	}
+	return ix
+}
@@ -0,0 +1,383 @@
+package runtrace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// nodeKey is the context key of the innermost node started by a wrapper
+type nodeKey struct{}
+
+// RunHandler is called after a run finished and was persisted. err is the
+// error returned by the store, if any.
+type RunHandler func(run *Run, err error)
+
+// Option configures a Recorder
+type Option func(*Recorder)
+
+// WithRunHandler sets a function called for every finished run
+func WithRunHandler(handler RunHandler) Option {
+	return func(r *Recorder) {
+		r.onRun = handler
+	}
+}
+
+// Recorder builds run trees from agent callbacks and wrapped components.
+//
+// Callbacks only carry a context, so the recorder keeps a stack of open
+// nodes per context: OnStart/OnChainStart/OnLLMStart/OnToolStart push a node
+// and the matching end or error callback pops it. Wrappers store their node
+// in the context passed to the wrapped component instead, so nested calls
+// attach to it. A node opened without any parent starts a new run, which is
+// saved to the store when that node closes.
+//
+// Agents call OnAgentFinish or OnEnd, not both; either closes the innermost
+// open agent node of the context.
+type Recorder struct {
+	*core.BaseCallback
+	store Store
+	onRun RunHandler
+
+	mu     sync.Mutex
+	stacks map[context.Context][]*Node
+	runs   map[*Node]*Run // open runs by root node
+}
+
+// NewRecorder creates a recorder that saves finished runs to store. store
+// may be nil when runs are only consumed through WithRunHandler.
+func NewRecorder(store Store, opts ...Option) *Recorder {
+	r := &Recorder{
+		BaseCallback: core.NewBaseCallback(),
+		store:        store,
+		stacks:       make(map[context.Context][]*Node),
+		runs:         make(map[*Node]*Run),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// StartRun opens a named run explicitly. Everything recorded with the
+// returned context, or contexts derived from it, becomes part of the run
+// until EndRun is called. Without StartRun each top-level agent invocation
+// is recorded as its own run.
+func (r *Recorder) StartRun(ctx context.Context, name string, input interface{}) (context.Context, *Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	root := r.open(nil, KindRun, name, input)
+	return context.WithValue(ctx, nodeKey{}, root), r.runs[root]
+}
+
+// EndRun closes a run started with StartRun and saves it to the store
+func (r *Recorder) EndRun(ctx context.Context, run *Run, output interface{}, err error) error {
+	r.mu.Lock()
+	finished := r.close(run.Root, output, err)
+	r.mu.Unlock()
+	return r.persist(ctx, finished)
+}
+
+// Start opens a custom node under the innermost node of ctx, for steps that
+// are neither callbacks nor wrapped components. Pass the returned context to
+// nested calls and close the node with End.
+func (r *Recorder) Start(ctx context.Context, kind NodeKind, name string, input interface{}) (context.Context, *Node) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node := r.open(r.parentOf(ctx), kind, name, input)
+	return context.WithValue(ctx, nodeKey{}, node), node
+}
+
+// End closes a node opened with Start
+func (r *Recorder) End(ctx context.Context, node *Node, output interface{}, err error) {
+	r.mu.Lock()
+	finished := r.close(node, output, err)
+	r.mu.Unlock()
+	_ = r.persist(ctx, finished)
+}
+
+// Runs returns the runs that are still being recorded
+func (r *Recorder) Runs() []*Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make([]*Run, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// OnStart opens an agent node
+func (r *Recorder) OnStart(ctx context.Context, input interface{}) error {
+	r.push(ctx, KindAgent, agentName(input), input)
+	return nil
+}
+
+// OnEnd closes the innermost agent node
+func (r *Recorder) OnEnd(ctx context.Context, output interface{}) error {
+	r.pop(ctx, KindAgent, "", output, nil)
+	return nil
+}
+
+// OnAgentFinish closes the innermost agent node
+func (r *Recorder) OnAgentFinish(ctx context.Context, output interface{}) error {
+	r.pop(ctx, KindAgent, "", output, nil)
+	return nil
+}
+
+// OnError closes the innermost agent node with an error
+func (r *Recorder) OnError(ctx context.Context, err error) error {
+	r.pop(ctx, KindAgent, "", nil, err)
+	return nil
+}
+
+// OnAgentAction records the action on the innermost agent node
+func (r *Recorder) OnAgentAction(ctx context.Context, action *core.AgentAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if node := r.innermost(ctx, KindAgent, ""); node != nil {
+		if node.Metadata == nil {
+			node.Metadata = make(map[string]interface{})
+		}
+		actions, _ := node.Metadata["actions"].([]interface{})
+		node.Metadata["actions"] = append(actions, toGeneric(action))
+	}
+	return nil
+}
+
+// OnLLMStart opens an LLM node with the prompts as input
+func (r *Recorder) OnLLMStart(ctx context.Context, prompts []string, model string) error {
+	node := r.push(ctx, KindLLM, model, prompts)
+	r.mu.Lock()
+	node.Model = model
+	r.mu.Unlock()
+	return nil
+}
+
+// OnLLMUsage stores the detailed usage of the open LLM node
+func (r *Recorder) OnLLMUsage(ctx context.Context, model string, usage *interfaces.TokenUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if node := r.innermost(ctx, KindLLM, ""); node != nil {
+		copied := *usage
+		node.Usage = &copied
+		if model != "" {
+			node.Model = model
+		}
+	}
+	return nil
+}
+
+// OnLLMEnd closes the LLM node, keeping the total token count unless
+// OnLLMUsage already reported the detailed usage
+func (r *Recorder) OnLLMEnd(ctx context.Context, output string, tokenUsage int) error {
+	r.mu.Lock()
+	if node := r.innermost(ctx, KindLLM, ""); node != nil && node.Usage == nil && tokenUsage > 0 {
+		node.Usage = &interfaces.TokenUsage{TotalTokens: tokenUsage}
+	}
+	r.mu.Unlock()
+	r.pop(ctx, KindLLM, "", output, nil)
+	return nil
+}
+
+// OnLLMError closes the LLM node with an error
+func (r *Recorder) OnLLMError(ctx context.Context, err error) error {
+	r.pop(ctx, KindLLM, "", nil, err)
+	return nil
+}
+
+// OnChainStart opens a chain node
+func (r *Recorder) OnChainStart(ctx context.Context, chainName string, input interface{}) error {
+	r.push(ctx, KindChain, chainName, input)
+	return nil
+}
+
+// OnChainEnd closes the chain node
+func (r *Recorder) OnChainEnd(ctx context.Context, chainName string, output interface{}) error {
+	r.pop(ctx, KindChain, chainName, output, nil)
+	return nil
+}
+
+// OnChainError closes the chain node with an error
+func (r *Recorder) OnChainError(ctx context.Context, chainName string, err error) error {
+	r.pop(ctx, KindChain, chainName, nil, err)
+	return nil
+}
+
+// OnToolStart opens a tool node
+func (r *Recorder) OnToolStart(ctx context.Context, toolName string, input interface{}) error {
+	r.push(ctx, KindTool, toolName, input)
+	return nil
+}
+
+// OnToolEnd closes the tool node
+func (r *Recorder) OnToolEnd(ctx context.Context, toolName string, output interface{}) error {
+	r.pop(ctx, KindTool, toolName, output, nil)
+	return nil
+}
+
+// OnToolError closes the tool node with an error
+func (r *Recorder) OnToolError(ctx context.Context, toolName string, err error) error {
+	r.pop(ctx, KindTool, toolName, nil, err)
+	return nil
+}
+
+// push opens a node under the innermost node of ctx and makes it the top of
+// the context's stack
+func (r *Recorder) push(ctx context.Context, kind NodeKind, name string, input interface{}) *Node {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node := r.open(r.parentOf(ctx), kind, name, input)
+	r.stacks[ctx] = append(r.stacks[ctx], node)
+	return node
+}
+
+// pop closes the innermost open node of the given kind (and name, if set)
+// on the context's stack, together with any node left open above it
+func (r *Recorder) pop(ctx context.Context, kind NodeKind, name string, output interface{}, err error) {
+	r.mu.Lock()
+	stack := r.stacks[ctx]
+	index := -1
+	for i := len(stack) - 1; i >= 0; i-- {
+		if matches(stack[i], kind, name) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	for i := len(stack) - 1; i > index; i-- {
+		r.close(stack[i], nil, nil)
+	}
+	finished := r.close(stack[index], output, err)
+	if index == 0 {
+		delete(r.stacks, ctx)
+	} else {
+		r.stacks[ctx] = stack[:index]
+	}
+	r.mu.Unlock()
+
+	_ = r.persist(ctx, finished)
+}
+
+// innermost returns the innermost open node of the given kind reachable from
+// ctx: first the context's stack, then the node stored by a wrapper
+func (r *Recorder) innermost(ctx context.Context, kind NodeKind, name string) *Node {
+	stack := r.stacks[ctx]
+	for i := len(stack) - 1; i >= 0; i-- {
+		if matches(stack[i], kind, name) {
+			return stack[i]
+		}
+	}
+	if node, ok := ctx.Value(nodeKey{}).(*Node); ok && node.open() && matches(node, kind, name) {
+		return node
+	}
+	return nil
+}
+
+// parentOf returns the node new steps of ctx attach to, nil for a new run
+func (r *Recorder) parentOf(ctx context.Context) *Node {
+	if stack := r.stacks[ctx]; len(stack) > 0 {
+		return stack[len(stack)-1]
+	}
+	if node, ok := ctx.Value(nodeKey{}).(*Node); ok && node.open() {
+		return node
+	}
+	return nil
+}
+
+// open creates a node; a node without parent starts a new run
+func (r *Recorder) open(parent *Node, kind NodeKind, name string, input interface{}) *Node {
+	node := &Node{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Name:      name,
+		Input:     toGeneric(input),
+		StartTime: time.Now(),
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, node)
+		return node
+	}
+
+	if name == "" {
+		name = string(kind)
+	}
+	r.runs[node] = &Run{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Status:    StatusRunning,
+		StartTime: node.StartTime,
+		Root:      node,
+	}
+	return node
+}
+
+// close ends a node and returns its run if the node was the run's root
+func (r *Recorder) close(node *Node, output interface{}, err error) *Run {
+	if !node.open() {
+		return nil
+	}
+	node.EndTime = time.Now()
+	// output recorded by a wrapper is richer than the callback output
+	if output != nil && node.Output == nil {
+		node.Output = toGeneric(output)
+	}
+	if err != nil {
+		node.Error = errorString(err)
+	}
+
+	run, ok := r.runs[node]
+	if !ok {
+		return nil
+	}
+	delete(r.runs, node)
+	run.finish(node.EndTime)
+	return run
+}
+
+// persist saves a finished run and notifies the handler. A recording failure
+// must not abort the agent, so callbacks ignore the returned error.
+func (r *Recorder) persist(ctx context.Context, run *Run) error {
+	if run == nil {
+		return nil
+	}
+	var err error
+	if r.store != nil {
+		err = r.store.Save(ctx, run)
+	}
+	if r.onRun != nil {
+		r.onRun(run, err)
+	}
+	return err
+}
+
+// matches reports whether node is an open node of the given kind and name
+func matches(node *Node, kind NodeKind, name string) bool {
+	return node.open() && node.Kind == kind && (name == "" || node.Name == name)
+}
+
+// agentName derives a node name from an agent input
+func agentName(input interface{}) string {
+	if agentInput, ok := input.(*core.AgentInput); ok && agentInput.Task != "" {
+		return truncate(agentInput.Task, 60)
+	}
+	return string(KindAgent)
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,198 @@
+// Package runtrace records agent executions as hierarchical run trees and
+// serves them through a small local viewer.
+//
+// A Recorder is a core.Callback: add it to an agent's callbacks and every
+// invocation is captured as a Run whose tree holds each LLM prompt and
+// response, tool input and output, retriever result, timing and token usage.
+// Components that do not emit callbacks (plain llm.Client calls, retrievers)
+// are captured by wrapping them with Recorder.LLM, Recorder.Tool and
+// Recorder.Retriever. Finished runs are persisted to a Store (JSONL or
+// SQLite):
+//
+//	store, _ := runtrace.NewJSONLStore("runs.jsonl")
+//	rec := runtrace.NewRecorder(store)
+//	agent := react.NewReActAgent(react.ReActConfig{
+//		LLM:   rec.LLM(client),
+//		Tools: rec.Tools(tools),
+//	}).WithCallbacks(rec)
+//	http.ListenAndServe("localhost:8080", runtrace.NewViewer(store))
+//
+// The viewer lists runs, renders their trees, diffs two runs and exports a
+// run as a testing/cassette fixture for deterministic replay.
+package runtrace
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/secrets"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// NodeKind is the type of a step in a run tree
+type NodeKind string
+
+// Node kinds
+const (
+	KindRun       NodeKind = "run"
+	KindAgent     NodeKind = "agent"
+	KindChain     NodeKind = "chain"
+	KindLLM       NodeKind = "llm"
+	KindTool      NodeKind = "tool"
+	KindRetriever NodeKind = "retriever"
+	KindCustom    NodeKind = "custom"
+)
+
+// Status is the outcome of a run
+type Status string
+
+// Run statuses
+const (
+	StatusRunning Status = "running"
+	StatusSuccess Status = "success"
+	StatusError   Status = "error"
+)
+
+// Node is a single step of a run.
+//
+// Input and Output hold the JSON form of the original values so that stored
+// runs are detached from live objects and render the same after reloading.
+type Node struct {
+	ID        string                 `json:"id"`
+	Kind      NodeKind               `json:"kind"`
+	Name      string                 `json:"name"`
+	Input     interface{}            `json:"input,omitempty"`
+	Output    interface{}            `json:"output,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Model     string                 `json:"model,omitempty"`
+	Usage     *interfaces.TokenUsage `json:"usage,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	StartTime time.Time              `json:"start_time"`
+	EndTime   time.Time              `json:"end_time"`
+	Children  []*Node                `json:"children,omitempty"`
+}
+
+// Duration returns the time spent in the node, zero while it is still open
+func (n *Node) Duration() time.Duration {
+	if n.EndTime.IsZero() {
+		return 0
+	}
+	return n.EndTime.Sub(n.StartTime)
+}
+
+// Walk visits the node and its descendants in depth-first order
+func (n *Node) Walk(fn func(node *Node, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *Node) walk(fn func(node *Node, depth int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// open reports whether the node has not finished yet
+func (n *Node) open() bool {
+	return n.EndTime.IsZero()
+}
+
+// Run is a recorded execution with its step tree
+type Run struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Status    Status                `json:"status"`
+	Error     string                `json:"error,omitempty"`
+	StartTime time.Time             `json:"start_time"`
+	EndTime   time.Time             `json:"end_time"`
+	Usage     interfaces.TokenUsage `json:"usage"`
+	Root      *Node                 `json:"root"`
+}
+
+// Summary returns the list view of the run
+func (r *Run) Summary() *RunSummary {
+	summary := &RunSummary{
+		ID:        r.ID,
+		Name:      r.Name,
+		Status:    r.Status,
+		Error:     r.Error,
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+		Usage:     r.Usage,
+	}
+	if !r.EndTime.IsZero() {
+		summary.DurationMs = r.EndTime.Sub(r.StartTime).Milliseconds()
+	}
+	if r.Root != nil {
+		r.Root.Walk(func(node *Node, _ int) {
+			summary.Nodes++
+			switch node.Kind {
+			case KindLLM:
+				summary.LLMCalls++
+			case KindTool:
+				summary.ToolCalls++
+			}
+		})
+	}
+	return summary
+}
+
+// RunSummary is the list view of a run
+type RunSummary struct {
+	ID         string                `json:"id"`
+	Name       string                `json:"name"`
+	Status     Status                `json:"status"`
+	Error      string                `json:"error,omitempty"`
+	StartTime  time.Time             `json:"start_time"`
+	EndTime    time.Time             `json:"end_time"`
+	DurationMs int64                 `json:"duration_ms"`
+	Nodes      int                   `json:"nodes"`
+	LLMCalls   int                   `json:"llm_calls"`
+	ToolCalls  int                   `json:"tool_calls"`
+	Usage      interfaces.TokenUsage `json:"usage"`
+}
+
+// finish closes the run, closing any node left open by an aborted step and
+// summing the token usage of its LLM nodes
+func (r *Run) finish(end time.Time) {
+	r.EndTime = end
+	r.Usage = interfaces.TokenUsage{}
+	r.Root.Walk(func(node *Node, _ int) {
+		if node.open() {
+			node.EndTime = end
+		}
+		if node.Kind == KindLLM && node.Usage != nil {
+			r.Usage.Add(node.Usage)
+		}
+	})
+	r.Error = r.Root.Error
+	if r.Error != "" {
+		r.Status = StatusError
+	} else {
+		r.Status = StatusSuccess
+	}
+}
+
+// toGeneric converts v into its JSON form (maps, slices and scalars). Values
+// that cannot be encoded are kept as their string representation. Known
+// secret values are replaced by their handles.
+func toGeneric(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return secrets.Mask(fmt.Sprintf("%v", v))
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return secrets.Mask(string(data))
+	}
+	return secrets.MaskValue(generic)
+}
+
+// errorString returns the message of err with known secret values masked
+func errorString(err error) string {
+	return secrets.Mask(err.Error())
+}
@@ -0,0 +1,256 @@
+package runtrace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/testing/cassette"
+	"github.com/kart-io/goagent/testing/mocks"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// newFakeClient answers one call with content and a fixed usage
+func newFakeClient(content string) *mocks.MockLLMClient {
+	client := mocks.NewMockLLMClient()
+	client.SetResponses(llm.CompletionResponse{
+		Content: content,
+		Model:   "gpt-4o",
+		Usage:   &interfaces.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	})
+	return client
+}
+
+// fakeTool echoes its query argument
+type fakeTool struct{}
+
+func (fakeTool) Name() string        { return "search" }
+func (fakeTool) Description() string { return "search the web" }
+func (fakeTool) ArgsSchema() string  { return `{"type":"object"}` }
+
+func (fakeTool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	return &interfaces.ToolOutput{Result: "results for " + input.Args["query"].(string), Success: true}, nil
+}
+
+// runAgent replays the callback sequence of react.ReActAgent around wrapped
+// components: one LLM call followed by one tool call
+func runAgent(t *testing.T, rec *Recorder, ctx context.Context, answer string) {
+	t.Helper()
+	client := rec.LLM(newFakeClient(answer))
+	tool := rec.Tool(fakeTool{})
+	callbacks := []core.Callback{rec}
+
+	require.NoError(t, rec.OnStart(ctx, &core.AgentInput{Task: "find the weather"}))
+
+	require.NoError(t, rec.OnLLMStart(ctx, []string{"find the weather"}, ""))
+	resp, err := client.Chat(ctx, []llm.Message{llm.UserMessage("find the weather")})
+	require.NoError(t, err)
+	require.NoError(t, core.TriggerLLMUsage(ctx, callbacks, resp.Model, resp.Usage))
+	require.NoError(t, rec.OnLLMEnd(ctx, resp.Content, resp.Usage.TotalTokens))
+
+	args := map[string]interface{}{"query": "weather"}
+	require.NoError(t, rec.OnToolStart(ctx, "search", args))
+	output, err := tool.Invoke(ctx, &interfaces.ToolInput{Args: args})
+	require.NoError(t, err)
+	require.NoError(t, rec.OnToolEnd(ctx, "search", output.Result))
+
+	require.NoError(t, rec.OnAgentFinish(ctx, &core.AgentOutput{Result: answer}))
+}
+
+func TestRecorder_CallbacksAndWrappers(t *testing.T) {
+	store, err := NewJSONLStore(filepath.Join(t.TempDir(), "runs.jsonl"))
+	require.NoError(t, err)
+	var finished []*Run
+	rec := NewRecorder(store, WithRunHandler(func(run *Run, err error) {
+		assert.NoError(t, err)
+		finished = append(finished, run)
+	}))
+
+	runAgent(t, rec, context.Background(), "sunny")
+
+	require.Len(t, finished, 1)
+	assert.Empty(t, rec.Runs())
+	run, err := store.Get(context.Background(), finished[0].ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusSuccess, run.Status)
+	assert.Equal(t, "find the weather", run.Name)
+	assert.Equal(t, 15, run.Usage.TotalTokens)
+	require.Equal(t, KindAgent, run.Root.Kind)
+	require.Len(t, run.Root.Children, 2, "wrapped calls must be merged into the callback nodes")
+
+	llmNode := run.Root.Children[0]
+	assert.Equal(t, KindLLM, llmNode.Kind)
+	assert.Equal(t, "gpt-4o", llmNode.Model)
+	assert.Equal(t, callChat, llmNode.Metadata["call"])
+	assert.Equal(t, 10, llmNode.Usage.PromptTokens)
+	assert.Equal(t, "sunny", llmNode.Output.(map[string]interface{})["content"])
+
+	toolNode := run.Root.Children[1]
+	assert.Equal(t, KindTool, toolNode.Kind)
+	assert.Equal(t, "search", toolNode.Name)
+	assert.Equal(t, "results for weather", toolNode.Output.(map[string]interface{})["result"])
+	assert.False(t, toolNode.EndTime.Before(toolNode.StartTime))
+}
+
+func TestRecorder_ExplicitRun(t *testing.T) {
+	rec := NewRecorder(nil)
+	ctx, run := rec.StartRun(context.Background(), "nightly", "input")
+
+	// wrapped components nest under custom nodes
+	stepCtx, step := rec.Start(ctx, KindCustom, "plan", nil)
+	_, err := rec.LLM(newFakeClient("plan")).Complete(stepCtx, &llm.CompletionRequest{Model: "gpt-4o"})
+	require.NoError(t, err)
+	rec.End(stepCtx, step, "planned", nil)
+
+	// callback nodes left open are closed with the run
+	require.NoError(t, rec.OnToolStart(ctx, "search", nil))
+	require.Len(t, rec.Runs(), 1)
+
+	require.NoError(t, rec.EndRun(ctx, run, nil, errors.New("boom")))
+	assert.Equal(t, StatusError, run.Status)
+	assert.Equal(t, "boom", run.Error)
+	require.Len(t, run.Root.Children, 2)
+	assert.Equal(t, KindLLM, run.Root.Children[0].Children[0].Kind)
+	assert.False(t, run.Root.Children[1].EndTime.IsZero())
+	assert.Equal(t, 4, run.Summary().Nodes)
+}
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	jsonl, err := NewJSONLStore(filepath.Join(dir, "runs.jsonl"))
+	require.NoError(t, err)
+	sqlite, err := NewSQLiteStore(filepath.Join(dir, "runs.db"))
+	require.NoError(t, err)
+	defer sqlite.Close()
+
+	for name, store := range map[string]Store{"jsonl": jsonl, "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var ids []string
+			rec := NewRecorder(store, WithRunHandler(func(run *Run, err error) {
+				require.NoError(t, err)
+				ids = append(ids, run.ID)
+			}))
+			runAgent(t, rec, ctx, "sunny")
+			runAgent(t, rec, ctx, "rainy")
+			require.Len(t, ids, 2)
+
+			summaries, err := store.List(ctx, 0)
+			require.NoError(t, err)
+			require.Len(t, summaries, 2)
+			assert.Equal(t, ids[1], summaries[0].ID, "newest first")
+			assert.Equal(t, 1, summaries[0].ToolCalls)
+
+			summaries, err = store.List(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, summaries, 1)
+
+			require.NoError(t, store.Delete(ctx, ids[0]))
+			_, err = store.Get(ctx, ids[0])
+			assert.Equal(t, agentErrors.CodeStoreNotFound, agentErrors.GetCode(err))
+			run, err := store.Get(ctx, ids[1])
+			require.NoError(t, err)
+			assert.Equal(t, "rainy", run.Root.Children[0].Output.(map[string]interface{})["content"])
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var runs []*Run
+	rec := NewRecorder(nil, WithRunHandler(func(run *Run, err error) {
+		runs = append(runs, run)
+	}))
+	runAgent(t, rec, context.Background(), "sunny")
+	runAgent(t, rec, context.Background(), "rainy")
+	require.Len(t, runs, 2)
+
+	assert.Empty(t, Diff(runs[0], runs[0]))
+
+	// the second run calls the tool once more
+	runs[1].Root.Children = append(runs[1].Root.Children, &Node{Kind: KindTool, Name: "search"})
+	entries := Diff(runs[0], runs[1])
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, ChangeChanged, entries[0].Change)
+	assert.Equal(t, "agent", entries[0].Path)
+	assert.Equal(t, ChangeChanged, entries[1].Change)
+	assert.Equal(t, "agent/llm#0", entries[1].Path)
+	assert.Equal(t, "output", entries[1].Fields[0].Field)
+	assert.Equal(t, ChangeAdded, entries[2].Change)
+	assert.Equal(t, "agent/tool:search#1", entries[2].Path)
+}
+
+func TestFixture_Replays(t *testing.T) {
+	var run *Run
+	rec := NewRecorder(nil, WithRunHandler(func(r *Run, err error) { run = r }))
+	runAgent(t, rec, context.Background(), "sunny")
+	require.NotNil(t, run)
+
+	fixture := Fixture(run)
+	require.Len(t, fixture.Interactions, 2)
+	path := filepath.Join(t.TempDir(), "fixture.yaml")
+	require.NoError(t, fixture.Save(path))
+
+	replay := cassette.New(t, path, cassette.WithMode(cassette.ModeReplay))
+	resp, err := replay.Client(nil).Chat(context.Background(), []llm.Message{llm.UserMessage("find the weather")})
+	require.NoError(t, err)
+	assert.Equal(t, "sunny", resp.Content)
+
+	output, err := replay.Tool(fakeTool{}).Invoke(context.Background(), &interfaces.ToolInput{Args: map[string]interface{}{"query": "weather"}})
+	require.NoError(t, err)
+	assert.Equal(t, "results for weather", output.Result)
+}
+
+func TestViewer(t *testing.T) {
+	store, err := NewJSONLStore(filepath.Join(t.TempDir(), "runs.jsonl"))
+	require.NoError(t, err)
+	var ids []string
+	rec := NewRecorder(store, WithRunHandler(func(run *Run, err error) { ids = append(ids, run.ID) }))
+	runAgent(t, rec, context.Background(), "sunny")
+	runAgent(t, rec, context.Background(), "rainy")
+
+	server := httptest.NewServer(NewViewer(store))
+	defer server.Close()
+	get := func(path string) (int, string) {
+		resp, err := server.Client().Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "app.js")
+
+	status, body = get("/api/runs")
+	require.Equal(t, http.StatusOK, status)
+	var summaries []*RunSummary
+	require.NoError(t, json.Unmarshal([]byte(body), &summaries))
+	assert.Len(t, summaries, 2)
+
+	status, _ = get("/api/runs/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body = get("/api/diff?a=" + ids[0] + "&b=" + ids[1])
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"change":"changed"`)
+
+	status, body = get("/api/runs/" + ids[0] + "/fixture?format=yaml")
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, strings.Contains(body, "kind: llm.chat") && strings.Contains(body, "kind: tool"))
+}
@@ -0,0 +1,157 @@
+package runtrace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runtrace_runs (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	start_time INTEGER NOT NULL,
+	summary    TEXT NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_runtrace_runs_start_time ON runtrace_runs (start_time DESC);
+`
+
+// SQLiteStore stores runs in a SQLite database. Each run is one row holding
+// its summary and the JSON encoded tree, so listing does not decode trees.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, sqliteError(err, "open", "failed to open run database")
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, sqliteError(err, "open", "failed to create run tables")
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Save inserts or replaces the run
+func (s *SQLiteStore) Save(ctx context.Context, run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode run").
+			WithComponent("runtrace").
+			WithOperation("save").
+			WithContext("run_id", run.ID)
+	}
+	summary, err := json.Marshal(run.Summary())
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode run summary").
+			WithComponent("runtrace").
+			WithOperation("save").
+			WithContext("run_id", run.ID)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO runtrace_runs (id, name, status, start_time, summary, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		run.ID, run.Name, string(run.Status), run.StartTime.UnixNano(), string(summary), string(data))
+	if err != nil {
+		return sqliteError(err, "save", "failed to save run")
+	}
+	return nil
+}
+
+// Get returns a run by ID
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*Run, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM runtrace_runs WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound(id)
+	}
+	if err != nil {
+		return nil, sqliteError(err, "get", "failed to load run")
+	}
+
+	run := &Run{}
+	if err := json.Unmarshal([]byte(data), run); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode run").
+			WithComponent("runtrace").
+			WithOperation("get").
+			WithContext("run_id", id)
+	}
+	return run, nil
+}
+
+// List returns run summaries, newest first
+func (s *SQLiteStore) List(ctx context.Context, limit int) ([]*RunSummary, error) {
+	query := `SELECT summary FROM runtrace_runs ORDER BY start_time DESC`
+	args := []interface{}{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, sqliteError(err, "list", "failed to list runs")
+	}
+	defer rows.Close()
+
+	summaries := []*RunSummary{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, sqliteError(err, "list", "failed to read run summary")
+		}
+		summary := &RunSummary{}
+		if err := json.Unmarshal([]byte(data), summary); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode run summary").
+				WithComponent("runtrace").
+				WithOperation("list")
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, sqliteError(err, "list", "failed to list runs")
+	}
+	return summaries, nil
+}
+
+// Delete removes a run
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM runtrace_runs WHERE id = ?`, id)
+	if err != nil {
+		return sqliteError(err, "delete", "failed to delete run")
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return notFound(id)
+	}
+	return nil
+}
+
+// Prune deletes runs started before the given time and returns their number
+func (s *SQLiteStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM runtrace_runs WHERE start_time < ?`, before.UnixNano())
+	if err != nil {
+		return 0, sqliteError(err, "prune", "failed to prune runs")
+	}
+	return result.RowsAffected()
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func sqliteError(err error, operation, message string) error {
+	return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, message).
+		WithComponent("runtrace").
+		WithOperation(operation)
+}
@@ -0,0 +1,139 @@
+"use strict";
+
+const selected = new Set();
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key === "class") node.className = value;
+    else if (key.startsWith("on")) node.addEventListener(key.slice(2), value);
+    else node.setAttribute(key, value);
+  }
+  for (const child of children) {
+    if (child === null || child === undefined) continue;
+    node.append(child instanceof Node ? child : document.createTextNode(String(child)));
+  }
+  return node;
+}
+
+async function fetchJSON(url) {
+  const resp = await fetch(url);
+  if (!resp.ok) throw new Error(await resp.text());
+  return resp.json();
+}
+
+function duration(node) {
+  const start = Date.parse(node.start_time);
+  const end = Date.parse(node.end_time);
+  if (isNaN(start) || isNaN(end) || end < start) return "";
+  const ms = end - start;
+  return ms < 1000 ? `${ms} ms` : `${(ms / 1000).toFixed(2)} s`;
+}
+
+function pretty(value) {
+  return typeof value === "string" ? value : JSON.stringify(value, null, 2);
+}
+
+function showError(err) {
+  const detail = document.getElementById("detail");
+  detail.replaceChildren(el("p", { class: "error" }, err.message));
+}
+
+async function loadRuns() {
+  const runs = await fetchJSON("api/runs");
+  const rows = document.getElementById("run-rows");
+  rows.replaceChildren();
+  for (const run of runs) {
+    const checkbox = el("input", { type: "checkbox" });
+    checkbox.checked = selected.has(run.id);
+    checkbox.addEventListener("click", (event) => {
+      event.stopPropagation();
+      if (checkbox.checked) selected.add(run.id);
+      else selected.delete(run.id);
+      document.getElementById("compare").disabled = selected.size !== 2;
+    });
+    const row = el("tr", { onclick: () => showRun(run.id, row) },
+      el("td", {}, checkbox),
+      el("td", {}, run.name),
+      el("td", { class: `status-${run.status}` }, run.status),
+      el("td", {}, new Date(run.start_time).toLocaleString()),
+      el("td", {}, duration(run)),
+      el("td", {}, run.llm_calls),
+      el("td", {}, run.tool_calls),
+      el("td", {}, run.usage.total_tokens));
+    rows.append(row);
+  }
+}
+
+function renderNode(node, open) {
+  const summary = el("summary", {},
+    el("span", { class: `kind kind-${node.kind}` }, node.kind),
+    node.name || "",
+    el("span", { class: "meta" }, duration(node)),
+    node.model ? el("span", { class: "meta" }, node.model) : null,
+    node.usage ? el("span", { class: "meta" }, `${node.usage.total_tokens} tokens`) : null,
+    node.error ? el("span", { class: "meta error" }, "error") : null);
+
+  const details = el("details", { class: "node" }, summary);
+  details.open = open;
+  if (node.input !== undefined) details.append(el("div", {}, "Input"), el("pre", {}, pretty(node.input)));
+  if (node.output !== undefined) details.append(el("div", {}, "Output"), el("pre", {}, pretty(node.output)));
+  if (node.error) details.append(el("pre", { class: "error" }, node.error));
+  if (node.metadata) details.append(el("div", {}, "Metadata"), el("pre", {}, pretty(node.metadata)));
+  for (const child of node.children || []) details.append(renderNode(child, false));
+  return details;
+}
+
+async function showRun(id, row) {
+  try {
+    const run = await fetchJSON(`api/runs/${encodeURIComponent(id)}`);
+    document.querySelectorAll("#run-rows tr").forEach((tr) => tr.classList.remove("active"));
+    if (row) row.classList.add("active");
+
+    const detail = document.getElementById("detail");
+    detail.replaceChildren(
+      el("h2", {}, run.name),
+      el("p", {},
+        el("span", { class: `status-${run.status}` }, run.status), " · ",
+        duration(run), " · ", `${run.usage.total_tokens} tokens`, " · ",
+        el("a", { href: `api/runs/${encodeURIComponent(id)}/fixture` }, "export fixture (JSON)"), " · ",
+        el("a", { href: `api/runs/${encodeURIComponent(id)}/fixture?format=yaml` }, "YAML")),
+      run.error ? el("pre", { class: "error" }, run.error) : null,
+      renderNode(run.root, true));
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function showDiff() {
+  const [a, b] = [...selected];
+  try {
+    const diff = await fetchJSON(`api/diff?a=${encodeURIComponent(a)}&b=${encodeURIComponent(b)}`);
+    const detail = document.getElementById("detail");
+    detail.replaceChildren(el("h2", {}, `${diff.a.name} ↔ ${diff.b.name}`));
+    if (diff.entries.length === 0) {
+      detail.append(el("p", { class: "hint" }, "The runs are equivalent."));
+      return;
+    }
+    for (const entry of diff.entries) {
+      const block = el("div", { class: `diff-entry diff-${entry.change}` },
+        el("strong", {}, entry.change), " ", el("code", {}, entry.path));
+      if (entry.change === "changed") {
+        for (const field of entry.fields) {
+          block.append(el("div", {}, field.field),
+            el("div", { class: "diff-fields" }, el("pre", {}, pretty(field.a)), el("pre", {}, pretty(field.b))));
+        }
+      } else {
+        const node = entry.a || entry.b;
+        if (node.output !== undefined) block.append(el("pre", {}, pretty(node.output)));
+      }
+      detail.append(block);
+    }
+  } catch (err) {
+    showError(err);
+  }
+}
+
+document.getElementById("refresh").addEventListener("click", () => loadRuns().catch(showError));
+document.getElementById("compare").addEventListener("click", showDiff);
+loadRuns().catch(showError);
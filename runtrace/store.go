@@ -0,0 +1,212 @@
+package runtrace
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// Store persists finished runs
+type Store interface {
+	// Save stores a run, replacing any run with the same ID
+	Save(ctx context.Context, run *Run) error
+
+	// Get returns a run by ID
+	Get(ctx context.Context, id string) (*Run, error)
+
+	// List returns run summaries, newest first. limit <= 0 returns all runs.
+	List(ctx context.Context, limit int) ([]*RunSummary, error)
+
+	// Delete removes a run
+	Delete(ctx context.Context, id string) error
+
+	// Close releases the store resources
+	Close() error
+}
+
+// JSONLStore stores one run per line in a JSON Lines file.
+//
+// Saving appends to the file, so recording stays cheap; reads scan the file
+// and the last line of a run ID wins. Delete rewrites the file.
+type JSONLStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONLStore creates a store backed by the file at path, creating parent
+// directories as needed
+func NewJSONLStore(path string) (*JSONLStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to create run store directory").
+			WithComponent("runtrace").
+			WithOperation("open").
+			WithContext("path", path)
+	}
+	return &JSONLStore{path: path}, nil
+}
+
+// Save appends the run to the file
+func (s *JSONLStore) Save(ctx context.Context, run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode run").
+			WithComponent("runtrace").
+			WithOperation("save").
+			WithContext("run_id", run.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return s.ioError(err, "save")
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return s.ioError(err, "save")
+	}
+	return nil
+}
+
+// Get returns a run by ID
+func (s *JSONLStore) Get(ctx context.Context, id string) (*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	run, ok := runs[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	return run, nil
+}
+
+// List returns run summaries, newest first
+func (s *JSONLStore) List(ctx context.Context, limit int) ([]*RunSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*RunSummary, 0, len(runs))
+	for _, run := range runs {
+		summaries = append(summaries, run.Summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartTime.After(summaries[j].StartTime)
+	})
+	if limit > 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return summaries, nil
+}
+
+// Delete removes a run by rewriting the file without it
+func (s *JSONLStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs, order, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := runs[id]; !ok {
+		return notFound(id)
+	}
+
+	tmp := s.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return s.ioError(err, "delete")
+	}
+	writer := bufio.NewWriter(file)
+	for _, runID := range order {
+		if runID == id {
+			continue
+		}
+		data, err := json.Marshal(runs[runID])
+		if err != nil {
+			file.Close()
+			return s.ioError(err, "delete")
+		}
+		_, _ = writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return s.ioError(err, "delete")
+	}
+	if err := file.Close(); err != nil {
+		return s.ioError(err, "delete")
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return s.ioError(err, "delete")
+	}
+	return nil
+}
+
+// Close implements Store; the file is only open during operations
+func (s *JSONLStore) Close() error {
+	return nil
+}
+
+// load reads all runs keyed by ID, with IDs in first-seen order
+func (s *JSONLStore) load() (map[string]*Run, []string, error) {
+	runs := make(map[string]*Run)
+	var order []string
+
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return runs, order, nil
+	}
+	if err != nil {
+		return nil, nil, s.ioError(err, "load")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		run := &Run{}
+		if err := json.Unmarshal(line, run); err != nil {
+			return nil, nil, agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode run").
+				WithComponent("runtrace").
+				WithOperation("load").
+				WithContext("path", s.path)
+		}
+		if _, ok := runs[run.ID]; !ok {
+			order = append(order, run.ID)
+		}
+		runs[run.ID] = run
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, s.ioError(err, "load")
+	}
+	return runs, order, nil
+}
+
+func (s *JSONLStore) ioError(err error, operation string) error {
+	return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "run store I/O failed").
+		WithComponent("runtrace").
+		WithOperation(operation).
+		WithContext("path", s.path)
+}
+
+// notFound returns the error for a missing run
+func notFound(id string) error {
+	return agentErrors.New(agentErrors.CodeStoreNotFound, "run not found").
+		WithComponent("runtrace").
+		WithOperation("get").
+		WithContext("run_id", id)
+}
@@ -0,0 +1,156 @@
+package runtrace
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Viewer is a small local web UI over a Store.
+//
+// Routes:
+//
+//	GET    /                         run list, tree view and diff UI
+//	GET    /api/runs?limit=N         run summaries, newest first
+//	GET    /api/runs/{id}            a full run
+//	DELETE /api/runs/{id}            delete a run
+//	GET    /api/runs/{id}/fixture    the run as a cassette (?format=yaml)
+//	GET    /api/diff?a=ID&b=ID       differences between two runs
+//
+// The viewer has no authentication; bind it to localhost or mount it behind
+// the application's own middleware.
+type Viewer struct {
+	store Store
+	mux   *http.ServeMux
+}
+
+// NewViewer creates the viewer handler
+func NewViewer(store Store) *Viewer {
+	v := &Viewer{store: store, mux: http.NewServeMux()}
+
+	static, _ := fs.Sub(staticFiles, "static")
+	v.mux.Handle("GET /", http.FileServer(http.FS(static)))
+	v.mux.HandleFunc("GET /api/runs", v.listRuns)
+	v.mux.HandleFunc("GET /api/runs/{id}", v.getRun)
+	v.mux.HandleFunc("DELETE /api/runs/{id}", v.deleteRun)
+	v.mux.HandleFunc("GET /api/runs/{id}/fixture", v.exportFixture)
+	v.mux.HandleFunc("GET /api/diff", v.diffRuns)
+	return v
+}
+
+// ServeHTTP implements http.Handler
+func (v *Viewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mux.ServeHTTP(w, r)
+}
+
+func (v *Viewer) listRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	runs, err := v.store.List(r.Context(), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, runs)
+}
+
+func (v *Viewer) getRun(w http.ResponseWriter, r *http.Request) {
+	run, err := v.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, run)
+}
+
+func (v *Viewer) deleteRun(w http.ResponseWriter, r *http.Request) {
+	if err := v.store.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (v *Viewer) exportFixture(w http.ResponseWriter, r *http.Request) {
+	run, err := v.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	fixture := Fixture(run)
+
+	if r.URL.Query().Get("format") == "yaml" {
+		data, err := yaml.Marshal(fixture)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", `attachment; filename="run-`+run.ID+`.yaml"`)
+		_, _ = w.Write(data)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="run-`+run.ID+`.json"`)
+	writeJSON(w, fixture)
+}
+
+func (v *Viewer) diffRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("a") == "" || query.Get("b") == "" {
+		http.Error(w, "both a and b run IDs are required", http.StatusBadRequest)
+		return
+	}
+	a, err := v.store.Get(r.Context(), query.Get("a"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := v.store.Get(r.Context(), query.Get("b"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entries := Diff(a, b)
+	if entries == nil {
+		entries = []DiffEntry{}
+	}
+	writeJSON(w, map[string]interface{}{
+		"a":       a.Summary(),
+		"b":       b.Summary(),
+		"entries": entries,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if agentErrors.GetCode(err) == agentErrors.CodeStoreNotFound {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
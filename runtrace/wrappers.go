@@ -0,0 +1,234 @@
+package runtrace
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/retrieval"
+	"github.com/kart-io/goagent/secrets"
+)
+
+// Recorded LLM call types, stored in the "call" metadata of LLM nodes
+const (
+	callComplete = "complete"
+	callChat     = "chat"
+)
+
+// chatRequest is the recorded input of a Chat call
+type chatRequest struct {
+	Messages []llm.Message `json:"messages"`
+}
+
+// toolRequest is the recorded input of a tool invocation
+type toolRequest struct {
+	Args map[string]interface{} `json:"args"`
+}
+
+// enter opens a node for a wrapped component. When the agent already opened
+// a matching node through callbacks (OnLLMStart, OnToolStart), that node is
+// adopted instead so the call is not recorded twice; the wrapper then only
+// enriches it and the callback closes it.
+func (r *Recorder) enter(ctx context.Context, kind NodeKind, name string, input interface{}) (*Node, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	parent := r.parentOf(ctx)
+	if parent != nil && matches(parent, kind, name) && parent.Output == nil {
+		parent.Input = toGeneric(input)
+		return parent, true
+	}
+	return r.open(parent, kind, name, input), false
+}
+
+// leave records the result of a wrapped call. update runs under the lock to
+// fill kind-specific fields.
+func (r *Recorder) leave(ctx context.Context, node *Node, adopted bool, output interface{}, err error, update func(node *Node)) {
+	r.mu.Lock()
+	if update != nil {
+		update(node)
+	}
+	var finished *Run
+	if adopted {
+		node.Output = toGeneric(output)
+		if err != nil {
+			node.Error = errorString(err)
+		}
+	} else {
+		finished = r.close(node, output, err)
+	}
+	r.mu.Unlock()
+	_ = r.persist(ctx, finished)
+}
+
+// setMetadata sets a metadata entry of the node
+func (n *Node) setMetadata(key string, value interface{}) {
+	if n.Metadata == nil {
+		n.Metadata = make(map[string]interface{})
+	}
+	n.Metadata[key] = value
+}
+
+// Client is an llm.Client that records every call as an LLM node
+type Client struct {
+	rec   *Recorder
+	inner llm.Client
+}
+
+// LLM wraps an LLM client so that its calls are recorded with the full
+// messages, response and token usage
+func (r *Recorder) LLM(inner llm.Client) *Client {
+	return &Client{rec: r, inner: inner}
+}
+
+// Complete records a completion call
+func (c *Client) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return c.call(ctx, callComplete, req, func(ctx context.Context) (*llm.CompletionResponse, error) {
+		return c.inner.Complete(ctx, req)
+	})
+}
+
+// Chat records a chat call
+func (c *Client) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	return c.call(ctx, callChat, chatRequest{Messages: messages}, func(ctx context.Context) (*llm.CompletionResponse, error) {
+		return c.inner.Chat(ctx, messages)
+	})
+}
+
+// Provider returns the wrapped client's provider
+func (c *Client) Provider() constants.Provider {
+	return c.inner.Provider()
+}
+
+// IsAvailable reports whether the wrapped client is available
+func (c *Client) IsAvailable() bool {
+	return c.inner.IsAvailable()
+}
+
+func (c *Client) call(ctx context.Context, call string, request interface{}, invoke func(ctx context.Context) (*llm.CompletionResponse, error)) (*llm.CompletionResponse, error) {
+	node, adopted := c.rec.enter(ctx, KindLLM, "", request)
+	resp, err := invoke(context.WithValue(ctx, nodeKey{}, node))
+
+	var output interface{}
+	if resp != nil {
+		output = resp
+	}
+	c.rec.leave(ctx, node, adopted, output, err, func(node *Node) {
+		node.setMetadata("call", call)
+		node.setMetadata("provider", string(c.inner.Provider()))
+		if req, ok := request.(*llm.CompletionRequest); ok && req != nil && req.Model != "" {
+			node.Model = req.Model
+		}
+		if resp == nil {
+			return
+		}
+		if resp.Model != "" {
+			node.Model = resp.Model
+		}
+		if node.Name == "" {
+			node.Name = node.Model
+		}
+		switch {
+		case resp.Usage != nil:
+			usage := *resp.Usage
+			node.Usage = &usage
+		case resp.TokensUsed > 0:
+			node.Usage = &interfaces.TokenUsage{TotalTokens: resp.TokensUsed}
+		}
+	})
+	return resp, err
+}
+
+// Tool is an interfaces.Tool that records every invocation as a tool node
+type Tool struct {
+	rec   *Recorder
+	inner interfaces.Tool
+}
+
+// Tool wraps a tool so that its invocations are recorded
+func (r *Recorder) Tool(inner interfaces.Tool) *Tool {
+	return &Tool{rec: r, inner: inner}
+}
+
+// Tools wraps every tool of the slice
+func (r *Recorder) Tools(tools []interfaces.Tool) []interfaces.Tool {
+	wrapped := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = r.Tool(tool)
+	}
+	return wrapped
+}
+
+// Name returns the wrapped tool name
+func (t *Tool) Name() string { return t.inner.Name() }
+
+// Description returns the wrapped tool description
+func (t *Tool) Description() string { return t.inner.Description() }
+
+// ArgsSchema returns the wrapped tool schema
+func (t *Tool) ArgsSchema() string { return t.inner.ArgsSchema() }
+
+// Invoke records the tool invocation
+func (t *Tool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	request := toolRequest{}
+	if input != nil {
+		request.Args = input.Args
+	}
+	node, adopted := t.rec.enter(ctx, KindTool, t.inner.Name(), request)
+	if input != nil && input.Context != nil {
+		input.Context = context.WithValue(input.Context, nodeKey{}, node)
+	}
+	output, err := t.inner.Invoke(context.WithValue(ctx, nodeKey{}, node), input)
+
+	var result interface{}
+	if output != nil {
+		result = output
+	}
+	t.rec.leave(ctx, node, adopted, result, err, func(node *Node) {
+		if err == nil && output != nil && output.Error != "" {
+			node.Error = secrets.Mask(output.Error)
+		}
+	})
+	return output, err
+}
+
+// Retriever is a retrieval.Retriever that records every search as a
+// retriever node. Streaming and batch calls are passed through unrecorded.
+type Retriever struct {
+	retrieval.Retriever
+	rec  *Recorder
+	name string
+}
+
+// Retriever wraps a retriever; name identifies it in the run tree
+func (r *Recorder) Retriever(name string, inner retrieval.Retriever) *Retriever {
+	return &Retriever{Retriever: inner, rec: r, name: name}
+}
+
+// Invoke records the search
+func (r *Retriever) Invoke(ctx context.Context, query string) ([]*retrieval.Document, error) {
+	return r.search(ctx, query, r.Retriever.Invoke)
+}
+
+// GetRelevantDocuments records the search
+func (r *Retriever) GetRelevantDocuments(ctx context.Context, query string) ([]*retrieval.Document, error) {
+	return r.search(ctx, query, r.Retriever.GetRelevantDocuments)
+}
+
+func (r *Retriever) search(ctx context.Context, query string, invoke func(context.Context, string) ([]*retrieval.Document, error)) ([]*retrieval.Document, error) {
+	node, adopted := r.rec.enter(ctx, KindRetriever, r.name, query)
+	if adopted {
+		// a nested search of the same retriever is part of the outer one
+		return invoke(ctx, query)
+	}
+	docs, err := invoke(context.WithValue(ctx, nodeKey{}, node), query)
+
+	var output interface{}
+	if docs != nil {
+		output = docs
+	}
+	r.rec.leave(ctx, node, false, output, err, func(node *Node) {
+		node.setMetadata("documents", len(docs))
+	})
+	return docs, err
+}
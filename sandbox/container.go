@@ -0,0 +1,180 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// scratchMount is where the scratch directory appears inside containers
+const scratchMount = "/sandbox"
+
+// Container runs programs in Docker or Podman containers. Containers run
+// with all capabilities dropped, no-new-privileges, a read-only root file
+// system, a private /tmp and the scratch directory mounted at /sandbox.
+type Container struct {
+	cfg   Config
+	image string
+}
+
+// NewContainer creates a container sandbox for image. The runtime defaults
+// to docker; use WithContainerRuntime("podman") for Podman.
+func NewContainer(image string, opts ...Option) *Container {
+	return &Container{cfg: newConfig(opts), image: image}
+}
+
+// Name returns the container runtime name
+func (c *Container) Name() string { return c.cfg.ContainerRuntime }
+
+// Close is a no-op
+func (c *Container) Close() error { return nil }
+
+// Available reports whether the container runtime CLI is installed
+func (c *Container) Available() bool {
+	_, err := exec.LookPath(c.cfg.ContainerRuntime)
+	return err == nil
+}
+
+// Run executes the program in a fresh container
+func (c *Container) Run(ctx context.Context, req *Request) (*Result, error) {
+	runtimePath, err := exec.LookPath(c.cfg.ContainerRuntime)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeSandboxUnavailable, "container runtime not found").
+			WithComponent("sandbox").
+			WithOperation("run").
+			WithContext("runtime", c.cfg.ContainerRuntime)
+	}
+	limits := c.cfg.Limits.merge(req.Limits)
+
+	scratch, cleanup, err := c.cfg.scratch(req)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	// the container user may differ from the owner of the scratch directory
+	_ = os.Chmod(scratch, 0o777)
+
+	name := "goagent-sandbox-" + randomSuffix()
+	args := c.args(name, scratch, req, limits)
+
+	runCtx := ctx
+	if limits.WallTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limits.WallTime)
+		defer cancel()
+	}
+
+	stdout, stderr := newLimitedBuffer(limits.MaxOutput), newLimitedBuffer(limits.MaxOutput)
+	cmd := exec.CommandContext(runCtx, runtimePath, args...)
+	cmd.Stdin = strings.NewReader(req.Stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = 5 * time.Second
+	cmd.Cancel = func() error {
+		// killing the CLI leaves the container running
+		_ = exec.Command(runtimePath, "rm", "-f", name).Run()
+		return cmd.Process.Kill()
+	}
+
+	start := time.Now()
+	err = cmd.Run()
+	result := &Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  time.Since(start),
+		Truncated: stdout.truncated || stderr.truncated,
+		Isolation: []string{"container"},
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, timeoutError(c.Name(), limits.WallTime)
+	case ctx.Err() != nil:
+		result.ExitCode = -1
+		return result, ctx.Err()
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		// exit codes 125 and above come from the runtime, not the program
+		if result.ExitCode == 125 {
+			return result, agentErrors.New(agentErrors.CodeSandboxUnavailable, "container runtime failed to start the container").
+				WithComponent("sandbox").
+				WithOperation("run").
+				WithContext("image", c.image).
+				WithContext("stderr", result.Stderr)
+		}
+		return result, nil
+	case err != nil:
+		return result, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "failed to run container").
+			WithComponent("sandbox").
+			WithOperation("run").
+			WithContext("image", c.image)
+	}
+	return result, nil
+}
+
+// args builds the run command line
+func (c *Container) args(name, scratch string, req *Request, limits Limits) []string {
+	args := []string{
+		"run", "--rm", "-i", "--name", name,
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--read-only",
+		"--tmpfs", "/tmp:rw,exec,size=64m",
+		"-v", scratch + ":" + scratchMount + ":rw",
+		"-w", scratchMount,
+		"-e", "HOME=" + scratchMount,
+		"-e", "TMPDIR=/tmp",
+		"-e", "SANDBOX_SCRATCH=" + scratchMount,
+	}
+	if !c.cfg.Network {
+		args = append(args, "--network", "none")
+	}
+	if req.Dir != "" {
+		mount := req.Dir
+		if req.Root != "" {
+			mount = req.Root
+		}
+		args = append(args, "-v", mount+":"+mount+":rw", "-w", req.Dir)
+	}
+	if limits.Memory > 0 {
+		memory := strconv.FormatInt(limits.Memory, 10)
+		args = append(args, "--memory", memory, "--memory-swap", memory)
+	}
+	if limits.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64))
+	}
+	if limits.MaxProcesses > 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(limits.MaxProcesses))
+	}
+	if limits.CPUTime > 0 {
+		seconds := int64((limits.CPUTime + time.Second - 1) / time.Second)
+		args = append(args, "--ulimit", fmt.Sprintf("cpu=%d:%d", seconds, seconds))
+	}
+	if limits.MaxFileSize > 0 {
+		args = append(args, "--ulimit", fmt.Sprintf("fsize=%d:%d", limits.MaxFileSize, limits.MaxFileSize))
+	}
+	for _, kv := range c.cfg.env(req, false, nil) {
+		args = append(args, "-e", kv)
+	}
+	args = append(args, c.image, req.Command)
+	return append(args, req.Args...)
+}
+
+// randomSuffix returns a short random hex string
+func randomSuffix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
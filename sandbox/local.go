@@ -0,0 +1,124 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Local runs programs as host processes. See the package documentation for
+// the isolation it applies; Result.Isolation reports what was available.
+type Local struct {
+	cfg Config
+}
+
+// NewLocal creates a local process sandbox
+func NewLocal(opts ...Option) *Local {
+	return &Local{cfg: newConfig(opts)}
+}
+
+// Name returns "local"
+func (l *Local) Name() string { return "local" }
+
+// Close is a no-op
+func (l *Local) Close() error { return nil }
+
+// Run executes the program
+func (l *Local) Run(ctx context.Context, req *Request) (*Result, error) {
+	limits := l.cfg.Limits.merge(req.Limits)
+
+	path, err := exec.LookPath(req.Command)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "sandboxed command not found").
+			WithComponent("sandbox").
+			WithOperation("run").
+			WithContext("command", req.Command)
+	}
+	if !filepath.IsAbs(path) {
+		if path, err = filepath.Abs(path); err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "failed to resolve sandboxed command").
+				WithComponent("sandbox").
+				WithOperation("run").
+				WithContext("command", req.Command)
+		}
+	}
+
+	scratch, cleanup, err := l.cfg.scratch(req)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	dir := scratch
+	if req.Dir != "" {
+		dir = req.Dir
+	}
+	env := l.cfg.env(req, true, map[string]string{"TMPDIR": scratch, "SANDBOX_SCRATCH": scratch})
+
+	runCtx := ctx
+	if limits.WallTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limits.WallTime)
+		defer cancel()
+	}
+
+	stdout, stderr := newLimitedBuffer(limits.MaxOutput), newLimitedBuffer(limits.MaxOutput)
+	start := time.Now()
+	isolation, err := l.run(runCtx, &process{
+		path:    path,
+		args:    req.Args,
+		dir:     dir,
+		env:     env,
+		stdin:   req.Stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+		limits:  limits,
+		network: l.cfg.Network,
+	})
+	result := &Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  time.Since(start),
+		Truncated: stdout.truncated || stderr.truncated,
+		Isolation: isolation,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, timeoutError(l.Name(), limits.WallTime)
+	case ctx.Err() != nil:
+		result.ExitCode = -1
+		return result, ctx.Err()
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	case agentErrors.IsCode(err, agentErrors.CodeSandboxUnavailable):
+		return nil, err
+	case err != nil:
+		return result, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "failed to run sandboxed command").
+			WithComponent("sandbox").
+			WithOperation("run").
+			WithContext("command", req.Command)
+	}
+	return result, nil
+}
+
+// process is a prepared local run
+type process struct {
+	path    string
+	args    []string
+	dir     string
+	env     []string
+	stdin   string
+	stdout  *limitedBuffer
+	stderr  *limitedBuffer
+	limits  Limits
+	network bool
+}
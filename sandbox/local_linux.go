@@ -0,0 +1,229 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// helperEnv carries the helper spec to the re-executed binary
+const helperEnv = "GOAGENT_SANDBOX_HELPER"
+
+// helperSpec tells the helper what to apply before executing the program
+type helperSpec struct {
+	Path        string   `json:"path"`
+	Args        []string `json:"args"`
+	Limits      Limits   `json:"limits"`
+	Seccomp     bool     `json:"seccomp"`
+	DenyNetwork bool     `json:"deny_network"`
+}
+
+// helperEnabled records that the program calls MaybeRunHelper, so that Local
+// may re-execute it as the helper
+var helperEnabled atomic.Bool
+
+// MaybeRunHelper turns the process into the sandbox helper when it was
+// re-executed by Local: it applies rlimits and the seccomp filter to itself
+// and then replaces itself with the sandboxed program, so that the limits
+// hold from the program's first instruction. Otherwise it returns and
+// enables the helper for Local runs.
+//
+// Call it first thing in main (or TestMain), before any other work:
+//
+//	func main() {
+//		sandbox.MaybeRunHelper()
+//		...
+//	}
+//
+// Programs that do not call it run sandboxed commands without rlimits and
+// seccomp, relying on namespaces alone.
+func MaybeRunHelper() {
+	raw, ok := os.LookupEnv(helperEnv)
+	if !ok {
+		helperEnabled.Store(true)
+		return
+	}
+	os.Exit(runHelper(raw))
+}
+
+// runHelper only returns on failure
+func runHelper(raw string) int {
+	_ = os.Unsetenv(helperEnv)
+	var spec helperSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: invalid helper spec: %v\n", err)
+		return 126
+	}
+
+	// seccomp filters and no_new_privs are per thread; execve keeps the
+	// filters of the calling thread
+	runtime.LockOSThread()
+
+	if err := setRlimits(spec.Limits); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		return 126
+	}
+	if spec.Seccomp {
+		if err := installSeccomp(spec.DenyNetwork); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: seccomp: %v\n", err)
+			return 126
+		}
+	}
+	err := syscall.Exec(spec.Path, spec.Args, os.Environ())
+	fmt.Fprintf(os.Stderr, "sandbox: exec %s: %v\n", spec.Path, err)
+	return 127
+}
+
+// setRlimits applies the limits to the current process
+func setRlimits(limits Limits) error {
+	set := func(resource int, value uint64, name string) error {
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("setrlimit %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := set(unix.RLIMIT_CORE, 0, "core"); err != nil {
+		return err
+	}
+	if limits.CPUTime > 0 {
+		seconds := uint64((limits.CPUTime + time.Second - 1) / time.Second)
+		if err := set(unix.RLIMIT_CPU, seconds, "cpu"); err != nil {
+			return err
+		}
+	}
+	if limits.Memory > 0 {
+		if err := set(unix.RLIMIT_AS, uint64(limits.Memory), "as"); err != nil {
+			return err
+		}
+	}
+	if limits.MaxFileSize > 0 {
+		if err := set(unix.RLIMIT_FSIZE, uint64(limits.MaxFileSize), "fsize"); err != nil {
+			return err
+		}
+	}
+	if limits.MaxProcesses > 0 {
+		if err := set(unix.RLIMIT_NPROC, uint64(limits.MaxProcesses), "nproc"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run starts the helper in new namespaces, falling back to the host
+// namespaces when the kernel refuses to create them. Without the helper the
+// program is started directly. Runs without network access fail when
+// neither a network namespace nor the seccomp filter can deny it.
+func (l *Local) run(ctx context.Context, p *process) ([]string, error) {
+	self, err := os.Executable()
+	helper := err == nil && helperEnabled.Load()
+	seccomp := helper && seccompAvailable()
+	for _, namespaces := range []bool{true, false} {
+		if !namespaces && !p.network && !seccomp {
+			return nil, agentErrors.New(agentErrors.CodeSandboxUnavailable, "network access cannot be denied: no network namespace and no seccomp filter").
+				WithComponent("sandbox").
+				WithOperation("run").
+				WithContext("helper", helper)
+		}
+
+		var cmd *exec.Cmd
+		var isolation []string
+		if helper {
+			spec := helperSpec{
+				Path:        p.path,
+				Args:        append([]string{p.path}, p.args...),
+				Limits:      p.limits,
+				Seccomp:     seccomp,
+				DenyNetwork: !p.network && !namespaces,
+			}
+			raw, err := json.Marshal(spec)
+			if err != nil {
+				return nil, err
+			}
+			cmd = exec.CommandContext(ctx, self)
+			cmd.Args = []string{"goagent-sandbox"}
+			cmd.Env = append(append([]string{}, p.env...), helperEnv+"="+string(raw))
+			isolation = []string{"rlimits"}
+		} else {
+			// without the helper only the wall time and namespaces apply
+			cmd = exec.CommandContext(ctx, p.path, p.args...)
+			isolation = []string{"timeout"}
+		}
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+		if namespaces {
+			flags := uintptr(syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS)
+			if !p.network {
+				flags |= syscall.CLONE_NEWNET
+			}
+			cmd.SysProcAttr.Cloneflags = flags
+			cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+			cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+			cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+			isolation = append(isolation, "namespaces")
+		}
+		if seccomp {
+			isolation = append(isolation, "seccomp")
+		}
+
+		err = l.start(cmd, p)
+		if namespaces && isNamespaceError(err) {
+			continue
+		}
+		return isolation, err
+	}
+	return nil, errors.New("unreachable")
+}
+
+// start runs cmd with the process I/O, killing the whole process group on
+// cancellation
+func (l *Local) start(cmd *exec.Cmd, p *process) error {
+	cmd.Dir = p.dir
+	if cmd.Env == nil {
+		cmd.Env = p.env
+	}
+	cmd.Stdin = strings.NewReader(p.stdin)
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+	cmd.WaitDelay = time.Second
+	cmd.Cancel = func() error {
+		if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		return cmd.Process.Kill()
+	}
+	return cmd.Run()
+}
+
+// isNamespaceError reports whether starting failed because namespaces
+// could not be created (unprivileged user namespaces disabled, nested
+// containers, exhausted namespace quotas)
+func isNamespaceError(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EPERM || errno == syscall.EINVAL || errno == syscall.ENOSPC ||
+		errno == syscall.EUSERS || errno == syscall.EACCES
+}
+
+// seccompAvailable reports whether the kernel supports seccomp filters
+func seccompAvailable() bool {
+	if !seccompSupported {
+		return false
+	}
+	status, err := os.ReadFile("/proc/self/status")
+	return err == nil && strings.Contains(string(status), "\nSeccomp:")
+}
@@ -0,0 +1,99 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+func cloneNewUser() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWUSER}
+}
+
+// networkProbeEnv makes the test binary install the network-denying
+// seccomp filter and report how creating network sockets fails
+const networkProbeEnv = "GOAGENT_SANDBOX_NETWORK_PROBE"
+
+func runNetworkProbe() {
+	if os.Getenv(networkProbeEnv) == "" {
+		return
+	}
+	runtime.LockOSThread()
+	if err := installSeccomp(true); err != nil {
+		fmt.Println("seccomp:", err)
+		os.Exit(1)
+	}
+	_, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM, 0)
+	fmt.Println("socket:", err)
+	var params [120]byte // struct io_uring_params
+	_, _, errno := unix.Syscall(unix.SYS_IO_URING_SETUP, 1, uintptr(unsafe.Pointer(&params)), 0)
+	fmt.Println("io_uring_setup:", errno)
+	os.Exit(0)
+}
+
+func TestLocal_SeccompBlocksUserNamespaces(t *testing.T) {
+	if !seccompAvailable() {
+		t.Skip("seccomp not available")
+	}
+	self, err := os.Executable()
+	require.NoError(t, err)
+
+	result, err := NewLocal().Run(context.Background(), &Request{
+		Command: self,
+		Env:     map[string]string{cloneProbeEnv: "1"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, result.Isolation, "seccomp")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(result.Stdout), "operation not permitted"), result.Stdout+result.Stderr)
+}
+
+func TestSeccomp_DeniesNetwork(t *testing.T) {
+	if !seccompAvailable() {
+		t.Skip("seccomp not available")
+	}
+	self, err := os.Executable()
+	require.NoError(t, err)
+
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), networkProbeEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "socket: permission denied")
+	assert.Contains(t, string(out), "io_uring_setup: operation not permitted")
+}
+
+func TestLocal_WithoutHelper(t *testing.T) {
+	requireShell(t)
+	helperEnabled.Store(false)
+	defer helperEnabled.Store(true)
+
+	result, err := NewLocal().Run(context.Background(), &Request{Command: "sh", Args: []string{"-c", "echo direct"}})
+	if agentErrors.IsCode(err, agentErrors.CodeSandboxUnavailable) {
+		// no network namespace and no seccomp without the helper: the run
+		// must fail rather than reach the network
+		assert.Nil(t, result)
+		return
+	}
+	require.NoError(t, err)
+	assert.Equal(t, "direct\n", result.Stdout)
+	assert.Equal(t, []string{"timeout", "namespaces"}, result.Isolation)
+
+	result, err = NewLocal(WithNetwork(true)).Run(context.Background(), &Request{Command: "sh", Args: []string{"-c", "echo direct"}})
+	require.NoError(t, err)
+	assert.Equal(t, "direct\n", result.Stdout)
+	assert.Contains(t, result.Isolation, "timeout")
+}
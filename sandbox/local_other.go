@@ -0,0 +1,36 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// MaybeRunHelper is a no-op on this platform; see the Linux version
+func MaybeRunHelper() {}
+
+// run starts the program directly; only the wall time and output limits are
+// enforced on this platform. Network access cannot be denied, so runs
+// without it fail.
+func (l *Local) run(ctx context.Context, p *process) ([]string, error) {
+	if !p.network {
+		return nil, agentErrors.New(agentErrors.CodeSandboxUnavailable, "network access cannot be denied on "+runtime.GOOS).
+			WithComponent("sandbox").
+			WithOperation("run")
+	}
+
+	cmd := exec.CommandContext(ctx, p.path, p.args...)
+	cmd.Dir = p.dir
+	cmd.Env = p.env
+	cmd.Stdin = strings.NewReader(p.stdin)
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+	cmd.WaitDelay = time.Second
+	return []string{"timeout"}, cmd.Run()
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+func cloneNewUser() *syscall.SysProcAttr { return nil }
+
+func runNetworkProbe() {}
+
+func TestLocal_NetworkUnavailable(t *testing.T) {
+	_, err := NewLocal().Run(context.Background(), &Request{Command: "sh", Args: []string{"-c", "echo direct"}})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSandboxUnavailable))
+}
@@ -0,0 +1,330 @@
+// Package sandbox runs untrusted programs, such as code generated by an
+// LLM, with resource limits, without network access by default and inside a
+// throwaway scratch directory.
+//
+// Three backends implement the Sandbox interface:
+//
+//   - Local runs a host process under rlimits and, on Linux where the kernel
+//     allows it, in fresh user, PID, IPC, UTS and network namespaces with a
+//     seccomp filter that blocks privileged system calls. Rlimits and
+//     seccomp are applied by re-executing the program as a helper, which
+//     requires calling MaybeRunHelper first thing in main. Runs without
+//     network access fail rather than run with the network reachable.
+//   - Container runs the program in a Docker or Podman container.
+//   - WASM runs WASI modules (for example Python or QuickJS compiled to
+//     WebAssembly) in the wazero runtime, in process and without any host
+//     access beyond the scratch directory.
+//
+// Usage:
+//
+//	sb := sandbox.NewLocal(sandbox.WithLimits(sandbox.Limits{WallTime: 5 * time.Second}))
+//	result, err := sb.Run(ctx, &sandbox.Request{
+//		Command: "python3",
+//		Args:    []string{"main.py"},
+//		Files:   map[string]string{"main.py": code},
+//	})
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Sandbox runs programs in isolation
+type Sandbox interface {
+	// Name identifies the backend
+	Name() string
+
+	// Run executes a program to completion. A non-zero exit status is
+	// reported in Result.ExitCode, not as an error; errors mean the program
+	// could not be run or exceeded its wall time (CodeToolTimeout, with the
+	// partial Result).
+	Run(ctx context.Context, req *Request) (*Result, error)
+
+	// Close releases backend resources
+	Close() error
+}
+
+// Limits bounds the resources of a run. Zero fields are unlimited, except
+// where Config defaults apply.
+type Limits struct {
+	// WallTime bounds the real time of a run
+	WallTime time.Duration `json:"wall_time,omitempty" yaml:"wall_time,omitempty"`
+	// CPUTime bounds the CPU time of the process
+	CPUTime time.Duration `json:"cpu_time,omitempty" yaml:"cpu_time,omitempty"`
+	// CPUs limits the number of CPUs (containers only)
+	CPUs float64 `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	// Memory bounds memory in bytes. Local processes are limited in address
+	// space, which runtimes reserving large virtual ranges (Node.js, Go)
+	// need to be given generously.
+	Memory int64 `json:"memory,omitempty" yaml:"memory,omitempty"`
+	// MaxOutput bounds captured stdout and stderr, each, in bytes; the rest
+	// is discarded and Result.Truncated set
+	MaxOutput int64 `json:"max_output,omitempty" yaml:"max_output,omitempty"`
+	// MaxFileSize bounds the size of files the program writes
+	MaxFileSize int64 `json:"max_file_size,omitempty" yaml:"max_file_size,omitempty"`
+	// MaxProcesses bounds the number of processes (threads count as well)
+	MaxProcesses int `json:"max_processes,omitempty" yaml:"max_processes,omitempty"`
+}
+
+// DefaultLimits are applied to fields left zero in Config.Limits
+var DefaultLimits = Limits{
+	WallTime:  30 * time.Second,
+	MaxOutput: 1 << 20,
+}
+
+// merge overrides l with the non-zero fields of o
+func (l Limits) merge(o *Limits) Limits {
+	if o == nil {
+		return l
+	}
+	if o.WallTime > 0 {
+		l.WallTime = o.WallTime
+	}
+	if o.CPUTime > 0 {
+		l.CPUTime = o.CPUTime
+	}
+	if o.CPUs > 0 {
+		l.CPUs = o.CPUs
+	}
+	if o.Memory > 0 {
+		l.Memory = o.Memory
+	}
+	if o.MaxOutput > 0 {
+		l.MaxOutput = o.MaxOutput
+	}
+	if o.MaxFileSize > 0 {
+		l.MaxFileSize = o.MaxFileSize
+	}
+	if o.MaxProcesses > 0 {
+		l.MaxProcesses = o.MaxProcesses
+	}
+	return l
+}
+
+// Request describes a run
+type Request struct {
+	// Command is the program: an executable for Local and Container, a
+	// module name for WASM
+	Command string
+	Args    []string
+	Stdin   string
+	// Env is added to the sandbox environment
+	Env map[string]string
+	// Files are written into the scratch directory before the run, keyed by
+	// relative path
+	Files map[string]string
+	// Dir is the working directory. Empty means the scratch directory; an
+	// absolute host path is used as is (Local) or mounted (Container).
+	// WASM runs reject it.
+	Dir string
+	// Root is the host directory mounted into containers instead of Dir,
+	// which must lie inside it; this exposes a whole workspace while
+	// running in one of its subdirectories. Local runs ignore it.
+	Root string
+	// Limits overrides the non-zero fields of the configured limits
+	Limits *Limits
+}
+
+// Result is the outcome of a run
+type Result struct {
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	// TimedOut reports that the wall time was exceeded
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Truncated reports that output beyond MaxOutput was discarded
+	Truncated bool `json:"truncated,omitempty"`
+	// Isolation lists the mechanisms that were in effect, e.g. "rlimits",
+	// "namespaces", "seccomp", "container" or "wasi"
+	Isolation []string `json:"isolation,omitempty"`
+}
+
+// Config holds the settings shared by all backends
+type Config struct {
+	Limits Limits
+	// Network allows network access; it is off by default
+	Network bool
+	// ScratchRoot is where scratch directories are created (default:
+	// os.TempDir())
+	ScratchRoot string
+	// KeepScratch keeps scratch directories after the run, for debugging
+	KeepScratch bool
+	// Env is set in every run
+	Env map[string]string
+	// InheritEnv lists host variables passed to Local runs; other host
+	// variables, such as API keys, are not visible to the program
+	InheritEnv []string
+	// ContainerRuntime is the Docker-compatible CLI used by Container
+	ContainerRuntime string
+}
+
+// Option configures a backend
+type Option func(*Config)
+
+// WithLimits sets the resource limits; zero fields keep DefaultLimits
+func WithLimits(limits Limits) Option {
+	return func(c *Config) {
+		c.Limits = c.Limits.merge(&limits)
+	}
+}
+
+// WithNetwork allows or denies network access
+func WithNetwork(enabled bool) Option {
+	return func(c *Config) {
+		c.Network = enabled
+	}
+}
+
+// WithScratchRoot sets the directory scratch directories are created in
+func WithScratchRoot(dir string) Option {
+	return func(c *Config) {
+		c.ScratchRoot = dir
+	}
+}
+
+// WithKeepScratch keeps scratch directories after runs
+func WithKeepScratch(keep bool) Option {
+	return func(c *Config) {
+		c.KeepScratch = keep
+	}
+}
+
+// WithEnv adds environment variables to every run
+func WithEnv(env map[string]string) Option {
+	return func(c *Config) {
+		if c.Env == nil {
+			c.Env = make(map[string]string, len(env))
+		}
+		for k, v := range env {
+			c.Env[k] = v
+		}
+	}
+}
+
+// WithInheritEnv replaces the host variables passed to Local runs
+func WithInheritEnv(keys ...string) Option {
+	return func(c *Config) {
+		c.InheritEnv = keys
+	}
+}
+
+// WithContainerRuntime selects the container CLI, e.g. "podman"
+func WithContainerRuntime(runtime string) Option {
+	return func(c *Config) {
+		c.ContainerRuntime = runtime
+	}
+}
+
+// newConfig applies options over the defaults
+func newConfig(opts []Option) Config {
+	cfg := Config{
+		Limits:           DefaultLimits,
+		InheritEnv:       []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ"},
+		ContainerRuntime: "docker",
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// scratch creates the scratch directory of a run and writes the request
+// files into it
+func (c *Config) scratch(req *Request) (string, func(), error) {
+	dir, err := os.MkdirTemp(c.ScratchRoot, "goagent-sandbox-")
+	if err != nil {
+		return "", nil, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "failed to create scratch directory").
+			WithComponent("sandbox").
+			WithOperation("scratch")
+	}
+	cleanup := func() {
+		if !c.KeepScratch {
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	for name, content := range req.Files {
+		path := filepath.Join(dir, filepath.Clean("/"+name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, []byte(content), 0o644)
+		}
+		if err != nil {
+			cleanup()
+			return "", nil, agentErrors.Wrap(err, agentErrors.CodeToolExecution, "failed to write scratch file").
+				WithComponent("sandbox").
+				WithOperation("scratch").
+				WithContext("file", name)
+		}
+	}
+	return dir, cleanup, nil
+}
+
+// env builds the environment of a run: inherited host variables, then the
+// configured variables, then the request variables
+func (c *Config) env(req *Request, inherit bool, extra map[string]string) []string {
+	values := make(map[string]string)
+	if inherit {
+		for _, key := range c.InheritEnv {
+			if value, ok := os.LookupEnv(key); ok {
+				values[key] = value
+			}
+		}
+	}
+	for _, layer := range []map[string]string{extra, c.Env, req.Env} {
+		for k, v := range layer {
+			values[k] = v
+		}
+	}
+	env := make([]string, 0, len(values))
+	for k, v := range values {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// timeoutError reports an exceeded wall time
+func timeoutError(backend string, limit time.Duration) error {
+	return agentErrors.New(agentErrors.CodeToolTimeout, "sandboxed program exceeded its wall time").
+		WithComponent("sandbox").
+		WithOperation("run").
+		WithContext("backend", backend).
+		WithContext("wall_time", limit.String())
+}
+
+// limitedBuffer keeps the first max bytes written to it
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int64
+	truncated bool
+}
+
+func newLimitedBuffer(max int64) *limitedBuffer {
+	return &limitedBuffer{max: max}
+}
+
+// Write never fails, so that the program is not killed by a broken pipe
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.max <= 0 {
+		return b.buf.Write(p)
+	}
+	remaining := b.max - int64(b.buf.Len())
+	if int64(len(p)) > remaining {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
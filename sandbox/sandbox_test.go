@@ -0,0 +1,307 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// cloneProbeEnv makes the test binary try to create a user namespace with
+// clone instead of running the tests
+const cloneProbeEnv = "GOAGENT_SANDBOX_CLONE_PROBE"
+
+func TestMain(m *testing.M) {
+	MaybeRunHelper()
+	runNetworkProbe()
+	if os.Getenv(cloneProbeEnv) != "" {
+		cmd := exec.Command("true")
+		cmd.SysProcAttr = cloneNewUser()
+		fmt.Println(cmd.Run())
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func requireShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+}
+
+// newTestLocal creates a Local sandbox. Other platforms than Linux cannot
+// deny network access, so runs there enable it.
+func newTestLocal(opts ...Option) *Local {
+	if runtime.GOOS != "linux" {
+		opts = append([]Option{WithNetwork(true)}, opts...)
+	}
+	return NewLocal(opts...)
+}
+
+func TestLocal_Run(t *testing.T) {
+	requireShell(t)
+	sb := newTestLocal()
+	ctx := context.Background()
+
+	result, err := sb.Run(ctx, &Request{Command: "sh", Args: []string{"-c", "echo hello; echo oops >&2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", result.Stdout)
+	assert.Equal(t, "oops\n", result.Stderr)
+	assert.Equal(t, 0, result.ExitCode)
+	if runtime.GOOS == "linux" {
+		assert.Contains(t, result.Isolation, "rlimits")
+	}
+
+	result, err = sb.Run(ctx, &Request{Command: "cat", Stdin: "from stdin"})
+	require.NoError(t, err)
+	assert.Equal(t, "from stdin", result.Stdout)
+
+	result, err = sb.Run(ctx, &Request{Command: "sh", Args: []string{"-c", "exit 3"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+
+	_, err = sb.Run(ctx, &Request{Command: "goagent-no-such-command"})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeToolExecution))
+}
+
+func TestLocal_FilesAndScratch(t *testing.T) {
+	requireShell(t)
+	root := t.TempDir()
+	sb := newTestLocal(WithScratchRoot(root))
+
+	result, err := sb.Run(context.Background(), &Request{
+		Command: "sh",
+		Args:    []string{"-c", `cat lib/data.txt; echo "$TMPDIR" | grep -q "^$SANDBOX_SCRATCH$" && echo tmp-is-scratch; touch out.txt`},
+		Files:   map[string]string{"lib/data.txt": "data\n", "../escape.txt": "x"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "data\ntmp-is-scratch\n", result.Stdout)
+
+	// the scratch directory is removed and files cannot escape it
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = os.Stat(filepath.Join(filepath.Dir(root), "escape.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocal_Env(t *testing.T) {
+	requireShell(t)
+	t.Setenv("GOAGENT_SANDBOX_TEST_SECRET", "s3cr3t")
+	sb := newTestLocal(WithEnv(map[string]string{"CONFIGURED": "yes"}))
+
+	result, err := sb.Run(context.Background(), &Request{
+		Command: "sh",
+		Args:    []string{"-c", `echo "secret=$GOAGENT_SANDBOX_TEST_SECRET configured=$CONFIGURED request=$REQUEST"`},
+		Env:     map[string]string{"REQUEST": "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "secret= configured=yes request=1\n", result.Stdout)
+}
+
+func TestLocal_Timeout(t *testing.T) {
+	requireShell(t)
+	sb := newTestLocal(WithLimits(Limits{WallTime: 200 * time.Millisecond}))
+
+	start := time.Now()
+	result, err := sb.Run(context.Background(), &Request{Command: "sh", Args: []string{"-c", "echo started; sleep 10"}})
+	require.Error(t, err)
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeToolTimeout))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.NotNil(t, result)
+	assert.True(t, result.TimedOut)
+	assert.Equal(t, "started\n", result.Stdout)
+
+	// request limits override the configured ones
+	_, err = sb.Run(context.Background(), &Request{
+		Command: "sh",
+		Args:    []string{"-c", "sleep 0.5"},
+		Limits:  &Limits{WallTime: 5 * time.Second},
+	})
+	assert.NoError(t, err)
+}
+
+func TestLocal_OutputLimit(t *testing.T) {
+	requireShell(t)
+	sb := newTestLocal(WithLimits(Limits{MaxOutput: 10}))
+
+	result, err := sb.Run(context.Background(), &Request{Command: "sh", Args: []string{"-c", "echo 0123456789abcdef"}})
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", result.Stdout)
+	assert.True(t, result.Truncated)
+}
+
+func TestLocal_NetworkDenied(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network isolation requires Linux")
+	}
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	script := `
+import socket
+try:
+    socket.create_connection(("1.1.1.1", 53), timeout=2)
+    print("connected")
+except OSError:
+    print("blocked")
+`
+	result, err := NewLocal().Run(context.Background(), &Request{Command: python, Args: []string{"-c", script}})
+	require.NoError(t, err)
+	if result.ExitCode != 0 {
+		t.Skipf("python3 failed in the sandbox: %s", result.Stderr)
+	}
+	assert.Equal(t, "blocked\n", result.Stdout)
+}
+
+func TestContainer_Args(t *testing.T) {
+	c := NewContainer("python:3.12-slim", WithEnv(map[string]string{"MODE": "test"}))
+	limits := c.cfg.Limits.merge(&Limits{Memory: 256 << 20, CPUs: 0.5, MaxProcesses: 64, CPUTime: 1500 * time.Millisecond})
+
+	args := c.args("name", "/tmp/scratch", &Request{Command: "python3", Args: []string{"main.py"}}, limits)
+	joined := strings.Join(args, " ")
+
+	assert.Equal(t, "docker", c.Name())
+	assert.Contains(t, joined, "--cap-drop ALL")
+	assert.Contains(t, joined, "--read-only")
+	assert.Contains(t, joined, "--network none")
+	assert.Contains(t, joined, "-v /tmp/scratch:/sandbox:rw")
+	assert.Contains(t, joined, "--memory 268435456")
+	assert.Contains(t, joined, "--cpus 0.5")
+	assert.Contains(t, joined, "--pids-limit 64")
+	assert.Contains(t, joined, "--ulimit cpu=2:2")
+	assert.Contains(t, joined, "-e MODE=test")
+	assert.True(t, strings.HasSuffix(joined, "python:3.12-slim python3 main.py"))
+
+	c = NewContainer("alpine", WithNetwork(true), WithContainerRuntime("podman"))
+	args = c.args("name", "/tmp/scratch", &Request{Command: "true"}, c.cfg.Limits)
+	assert.Equal(t, "podman", c.Name())
+	assert.NotContains(t, args, "none")
+
+	args = c.args("name", "/tmp/scratch", &Request{Command: "ls", Dir: "/work/repo/pkg", Root: "/work/repo"}, c.cfg.Limits)
+	joined = strings.Join(args, " ")
+	assert.Contains(t, joined, "-v /work/repo:/work/repo:rw -w /work/repo/pkg")
+	assert.NotContains(t, joined, "/work/repo/pkg:")
+}
+
+func TestContainer_Unavailable(t *testing.T) {
+	c := NewContainer("alpine", WithContainerRuntime("goagent-no-such-runtime"))
+	assert.False(t, c.Available())
+
+	_, err := c.Run(context.Background(), &Request{Command: "true"})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSandboxUnavailable))
+}
+
+const wasmProgram = `package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+)
+
+func main() {
+	switch os.Args[1] {
+	case "echo":
+		in, _ := io.ReadAll(os.Stdin)
+		data, err := os.ReadFile("input.txt")
+		fmt.Printf("%s|%s|%v|%s\n", in, data, err, os.Getenv("GREETING"))
+		_ = os.WriteFile("output.txt", []byte("ok"), 0o644)
+	case "exit":
+		code, _ := strconv.Atoi(os.Args[2])
+		os.Exit(code)
+	case "escape":
+		_, err := os.ReadFile("/../../../../etc/hostname")
+		fmt.Println(err != nil)
+	case "net":
+		_, err := net.Dial("tcp", "1.1.1.1:53")
+		fmt.Println(err != nil)
+	case "loop":
+		for {
+		}
+	}
+}
+`
+
+func buildWASM(t *testing.T) []byte {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping WASM build in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(wasmProgram), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module wasmtest\n\ngo 1.21\n"), 0o644))
+
+	cmd := exec.Command(goBin, "build", "-o", "prog.wasm", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "CGO_ENABLED=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build wasip1 module: %v\n%s", err, out)
+	}
+	binary, err := os.ReadFile(filepath.Join(dir, "prog.wasm"))
+	require.NoError(t, err)
+	return binary
+}
+
+func TestWASM_Run(t *testing.T) {
+	binary := buildWASM(t)
+	ctx := context.Background()
+
+	sb, err := NewWASM(ctx, []Module{{Name: "prog", Binary: binary, Env: map[string]string{"GREETING": "hi"}}},
+		WithLimits(Limits{WallTime: 10 * time.Second, Memory: 256 << 20}))
+	require.NoError(t, err)
+	defer sb.Close()
+	assert.Equal(t, "wasm", sb.Name())
+
+	result, err := sb.Run(ctx, &Request{
+		Command: "prog",
+		Args:    []string{"echo"},
+		Stdin:   "stdin",
+		Files:   map[string]string{"input.txt": "file"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "stdin|file|<nil>|hi\n", result.Stdout)
+	assert.Equal(t, []string{"wasi"}, result.Isolation)
+
+	result, err = sb.Run(ctx, &Request{Command: "prog", Args: []string{"exit", "7"}})
+	require.NoError(t, err)
+	assert.Equal(t, 7, result.ExitCode)
+
+	result, err = sb.Run(ctx, &Request{Command: "prog", Args: []string{"escape"}})
+	require.NoError(t, err)
+	assert.Equal(t, "true\n", result.Stdout)
+
+	result, err = sb.Run(ctx, &Request{Command: "prog", Args: []string{"net"}})
+	require.NoError(t, err)
+	assert.Equal(t, "true\n", result.Stdout)
+
+	result, err = sb.Run(ctx, &Request{Command: "prog", Args: []string{"loop"}, Limits: &Limits{WallTime: 300 * time.Millisecond}})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeToolTimeout))
+	require.NotNil(t, result)
+	assert.True(t, result.TimedOut)
+
+	_, err = sb.Run(ctx, &Request{Command: "prog", Args: []string{"exit", "0"}, Dir: t.TempDir()})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeInvalidInput))
+
+	_, err = sb.Run(ctx, &Request{Command: "python"})
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSandboxUnavailable))
+}
@@ -0,0 +1,136 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const seccompSupported = true
+
+// deniedSyscalls fail with EPERM: debugging other processes, kernel and
+// mount administration, namespace changes and kernel attack surface that
+// untrusted code has no business using
+var deniedSyscalls = []uint32{
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT,
+	unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_REBOOT,
+	unix.SYS_KEXEC_LOAD, unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_OPEN_BY_HANDLE_AT, unix.SYS_NAME_TO_HANDLE_AT,
+	unix.SYS_ACCT, unix.SYS_QUOTACTL, unix.SYS_SYSLOG,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_ADJTIMEX,
+}
+
+// seccomp_data field offsets
+const (
+	offsetNr   = 0
+	offsetArch = 4
+	offsetArg0 = 16 // low word on little-endian architectures
+)
+
+// bpfProgram assembles classic BPF with symbolic jump targets
+type bpfProgram struct {
+	insns  []unix.SockFilter
+	jumps  map[int][2]string
+	labels map[string]int
+}
+
+func (p *bpfProgram) stmt(code uint16, k uint32) {
+	p.insns = append(p.insns, unix.SockFilter{Code: code, K: k})
+}
+
+// jump adds a conditional jump; empty targets fall through
+func (p *bpfProgram) jump(code uint16, k uint32, jt, jf string) {
+	p.jumps[len(p.insns)] = [2]string{jt, jf}
+	p.stmt(code, k)
+}
+
+func (p *bpfProgram) label(name string) {
+	p.labels[name] = len(p.insns)
+}
+
+func (p *bpfProgram) resolve() []unix.SockFilter {
+	for i, targets := range p.jumps {
+		if targets[0] != "" {
+			p.insns[i].Jt = uint8(p.labels[targets[0]] - i - 1)
+		}
+		if targets[1] != "" {
+			p.insns[i].Jf = uint8(p.labels[targets[1]] - i - 1)
+		}
+	}
+	return p.insns
+}
+
+// seccompFilter builds the filter. clone cannot create user namespaces;
+// with denyNetwork only AF_UNIX sockets can be created and io_uring is
+// unavailable
+func seccompFilter(denyNetwork bool) []unix.SockFilter {
+	const (
+		load = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+		jeq  = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		jge  = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+		jset = unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K
+		ret  = unix.BPF_RET | unix.BPF_K
+	)
+	arch := uint32(unix.AUDIT_ARCH_X86_64)
+	if runtime.GOARCH == "arm64" {
+		arch = unix.AUDIT_ARCH_AARCH64
+	}
+
+	p := &bpfProgram{jumps: make(map[int][2]string), labels: make(map[string]int)}
+	p.stmt(load, offsetArch)
+	p.jump(jeq, arch, "", "kill")
+	p.stmt(load, offsetNr)
+	if runtime.GOARCH == "amd64" {
+		// x32 system calls
+		p.jump(jge, 0x40000000, "eperm", "")
+	}
+	for _, nr := range deniedSyscalls {
+		p.jump(jeq, nr, "eperm", "")
+	}
+	// clone3 passes its flags in memory the filter cannot inspect; ENOSYS
+	// makes the C library fall back to clone, whose flags it can
+	p.jump(jeq, unix.SYS_CLONE3, "enosys", "")
+	p.jump(jeq, unix.SYS_CLONE, "", "network")
+	p.stmt(load, offsetArg0)
+	p.jump(jset, unix.CLONE_NEWUSER, "eperm", "allow")
+	p.label("network")
+	if denyNetwork {
+		// io_uring creates sockets without socket(2) (IORING_OP_SOCKET)
+		for _, nr := range []uint32{unix.SYS_IO_URING_SETUP, unix.SYS_IO_URING_ENTER, unix.SYS_IO_URING_REGISTER} {
+			p.jump(jeq, nr, "eperm", "")
+		}
+		p.jump(jeq, unix.SYS_SOCKET, "", "allow")
+		p.stmt(load, offsetArg0)
+		p.jump(jeq, unix.AF_UNIX, "allow", "eacces")
+	}
+	p.label("allow")
+	p.stmt(ret, unix.SECCOMP_RET_ALLOW)
+	p.label("eperm")
+	p.stmt(ret, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM))
+	p.label("eacces")
+	p.stmt(ret, unix.SECCOMP_RET_ERRNO|uint32(unix.EACCES))
+	p.label("enosys")
+	p.stmt(ret, unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS))
+	p.label("kill")
+	p.stmt(ret, unix.SECCOMP_RET_KILL_PROCESS)
+	return p.resolve()
+}
+
+// installSeccomp sets no_new_privs and installs the filter on the calling
+// thread
+func installSeccomp(denyNetwork bool) error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return err
+	}
+	filter := seccompFilter(denyNetwork)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	return unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0)
+}
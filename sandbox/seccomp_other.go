@@ -0,0 +1,11 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+import "errors"
+
+const seccompSupported = false
+
+func installSeccomp(denyNetwork bool) error {
+	return errors.New("seccomp filters are not supported on this architecture")
+}
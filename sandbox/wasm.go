@@ -0,0 +1,215 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// wasmPageSize is the size of a WebAssembly memory page
+const wasmPageSize = 64 << 10
+
+// Module is a WASI program runnable by the WASM backend, such as a Python
+// or QuickJS interpreter compiled to wasm32-wasi
+type Module struct {
+	// Name is matched against Request.Command
+	Name string
+	// Binary is the WebAssembly module
+	Binary []byte
+	// Args are inserted before the request arguments
+	Args []string
+	// Mounts expose host directories read-only, e.g. the Python standard
+	// library of the interpreter
+	Mounts []Mount
+	// Env is set for every run of the module
+	Env map[string]string
+}
+
+// Mount maps a host directory into the guest file system
+type Mount struct {
+	HostPath  string
+	GuestPath string
+}
+
+// WASM runs WASI modules in process with the wazero runtime. Guests see the
+// scratch directory as their root file system plus the module mounts, so
+// requests with a Dir are rejected; WASI
+// offers no sockets, so there is no network access regardless of
+// WithNetwork. Memory is limited per module instance and the wall time is
+// enforced by interrupting the guest; CPU, process and file size limits do
+// not apply.
+type WASM struct {
+	cfg     Config
+	runtime wazero.Runtime
+	modules map[string]*wasmModule
+	mu      sync.RWMutex
+}
+
+type wasmModule struct {
+	Module
+	compiled wazero.CompiledModule
+}
+
+// NewWASM creates a WASM sandbox and compiles the modules
+func NewWASM(ctx context.Context, modules []Module, opts ...Option) (*WASM, error) {
+	cfg := newConfig(opts)
+	rtConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if cfg.Limits.Memory > 0 {
+		pages := cfg.Limits.Memory / wasmPageSize
+		if pages > 65536 {
+			pages = 65536
+		}
+		rtConfig = rtConfig.WithMemoryLimitPages(uint32(pages))
+	}
+
+	w := &WASM{
+		cfg:     cfg,
+		runtime: wazero.NewRuntimeWithConfig(ctx, rtConfig),
+		modules: make(map[string]*wasmModule),
+	}
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, w.runtime); err != nil {
+		_ = w.runtime.Close(ctx)
+		return nil, agentErrors.Wrap(err, agentErrors.CodeSandboxUnavailable, "failed to instantiate WASI").
+			WithComponent("sandbox").
+			WithOperation("new_wasm")
+	}
+	for _, module := range modules {
+		if err := w.Register(ctx, module); err != nil {
+			_ = w.runtime.Close(ctx)
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Register compiles and adds a module
+func (w *WASM) Register(ctx context.Context, module Module) error {
+	compiled, err := w.runtime.CompileModule(ctx, module.Binary)
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeInvalidConfig, "failed to compile WASM module").
+			WithComponent("sandbox").
+			WithOperation("register").
+			WithContext("module", module.Name)
+	}
+	w.mu.Lock()
+	w.modules[module.Name] = &wasmModule{Module: module, compiled: compiled}
+	w.mu.Unlock()
+	return nil
+}
+
+// Name returns "wasm"
+func (w *WASM) Name() string { return "wasm" }
+
+// Close releases the runtime and compiled modules
+func (w *WASM) Close() error {
+	return w.runtime.Close(context.Background())
+}
+
+// Run instantiates a fresh instance of the module named by req.Command
+func (w *WASM) Run(ctx context.Context, req *Request) (*Result, error) {
+	w.mu.RLock()
+	module, ok := w.modules[req.Command]
+	w.mu.RUnlock()
+	if !ok {
+		return nil, agentErrors.New(agentErrors.CodeSandboxUnavailable, "WASM module not registered").
+			WithComponent("sandbox").
+			WithOperation("run").
+			WithContext("module", req.Command)
+	}
+	if req.Dir != "" {
+		// guests only see the scratch directory and the module mounts
+		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "WASM sandbox does not support a working directory").
+			WithComponent("sandbox").
+			WithOperation("run").
+			WithContext("dir", req.Dir)
+	}
+	limits := w.cfg.Limits.merge(req.Limits)
+
+	scratch, cleanup, err := w.cfg.scratch(req)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	runCtx := ctx
+	if limits.WallTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limits.WallTime)
+		defer cancel()
+	}
+
+	fsConfig := wazero.NewFSConfig().WithDirMount(scratch, "/")
+	for _, mount := range module.Mounts {
+		fsConfig = fsConfig.WithReadOnlyDirMount(mount.HostPath, mount.GuestPath)
+	}
+	stdout, stderr := newLimitedBuffer(limits.MaxOutput), newLimitedBuffer(limits.MaxOutput)
+	args := append(append([]string{module.Name}, module.Args...), req.Args...)
+	moduleConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(args...).
+		WithStdin(strings.NewReader(req.Stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithFSConfig(fsConfig).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	for _, kv := range w.cfg.env(req, false, mergeEnv(map[string]string{"HOME": "/", "SANDBOX_SCRATCH": "/"}, module.Env)) {
+		key, value, _ := strings.Cut(kv, "=")
+		moduleConfig = moduleConfig.WithEnv(key, value)
+	}
+
+	start := time.Now()
+	instance, err := w.runtime.InstantiateModule(runCtx, module.compiled, moduleConfig)
+	if instance != nil {
+		_ = instance.Close(ctx)
+	}
+	result := &Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  time.Since(start),
+		Truncated: stdout.truncated || stderr.truncated,
+		Isolation: []string{"wasi"},
+	}
+
+	var exitErr *sys.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, timeoutError(w.Name(), limits.WallTime)
+	case ctx.Err() != nil:
+		result.ExitCode = -1
+		return result, ctx.Err()
+	case errors.As(err, &exitErr):
+		result.ExitCode = int(exitErr.ExitCode())
+		return result, nil
+	case err != nil:
+		// traps such as out-of-bounds memory or exceeding the memory limit
+		result.ExitCode = -1
+		result.Stderr += err.Error()
+		return result, nil
+	}
+	return result, nil
+}
+
+// mergeEnv combines environment maps, later maps winning
+func mergeEnv(maps ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
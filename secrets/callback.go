@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// Callback wraps a core.Callback, masking secret values in every input,
+// output, prompt and error before it reaches the inner callback
+type Callback struct {
+	inner  core.Callback
+	masker *Masker
+}
+
+var (
+	_ core.Callback      = (*Callback)(nil)
+	_ core.UsageCallback = (*Callback)(nil)
+)
+
+// NewCallback wraps a callback. A nil masker uses the package-level masker.
+func NewCallback(inner core.Callback, masker *Masker) *Callback {
+	if masker == nil {
+		masker = defaultMasker
+	}
+	return &Callback{inner: inner, masker: masker}
+}
+
+// OnStart forwards the masked agent input
+func (c *Callback) OnStart(ctx context.Context, input interface{}) error {
+	return c.inner.OnStart(ctx, c.masker.MaskValue(input))
+}
+
+// OnEnd forwards the masked agent output
+func (c *Callback) OnEnd(ctx context.Context, output interface{}) error {
+	return c.inner.OnEnd(ctx, c.masker.MaskValue(output))
+}
+
+// OnError forwards the masked agent error
+func (c *Callback) OnError(ctx context.Context, err error) error {
+	return c.inner.OnError(ctx, c.masker.MaskError(err))
+}
+
+// OnLLMStart forwards the masked prompts
+func (c *Callback) OnLLMStart(ctx context.Context, prompts []string, model string) error {
+	masked := make([]string, len(prompts))
+	for i, p := range prompts {
+		masked[i] = c.masker.Mask(p)
+	}
+	return c.inner.OnLLMStart(ctx, masked, model)
+}
+
+// OnLLMEnd forwards the masked completion
+func (c *Callback) OnLLMEnd(ctx context.Context, output string, tokenUsage int) error {
+	return c.inner.OnLLMEnd(ctx, c.masker.Mask(output), tokenUsage)
+}
+
+// OnLLMError forwards the masked LLM error
+func (c *Callback) OnLLMError(ctx context.Context, err error) error {
+	return c.inner.OnLLMError(ctx, c.masker.MaskError(err))
+}
+
+// OnLLMUsage forwards token usage when the inner callback tracks it
+func (c *Callback) OnLLMUsage(ctx context.Context, model string, usage *interfaces.TokenUsage) error {
+	if uc, ok := c.inner.(core.UsageCallback); ok {
+		return uc.OnLLMUsage(ctx, model, usage)
+	}
+	return nil
+}
+
+// OnChainStart forwards the masked chain input
+func (c *Callback) OnChainStart(ctx context.Context, chainName string, input interface{}) error {
+	return c.inner.OnChainStart(ctx, chainName, c.masker.MaskValue(input))
+}
+
+// OnChainEnd forwards the masked chain output
+func (c *Callback) OnChainEnd(ctx context.Context, chainName string, output interface{}) error {
+	return c.inner.OnChainEnd(ctx, chainName, c.masker.MaskValue(output))
+}
+
+// OnChainError forwards the masked chain error
+func (c *Callback) OnChainError(ctx context.Context, chainName string, err error) error {
+	return c.inner.OnChainError(ctx, chainName, c.masker.MaskError(err))
+}
+
+// OnToolStart forwards the masked tool input
+func (c *Callback) OnToolStart(ctx context.Context, toolName string, input interface{}) error {
+	return c.inner.OnToolStart(ctx, toolName, c.masker.MaskValue(input))
+}
+
+// OnToolEnd forwards the masked tool output
+func (c *Callback) OnToolEnd(ctx context.Context, toolName string, output interface{}) error {
+	return c.inner.OnToolEnd(ctx, toolName, c.masker.MaskValue(output))
+}
+
+// OnToolError forwards the masked tool error
+func (c *Callback) OnToolError(ctx context.Context, toolName string, err error) error {
+	return c.inner.OnToolError(ctx, toolName, c.masker.MaskError(err))
+}
+
+// OnAgentAction forwards a copy of the action with masked tool input and log
+func (c *Callback) OnAgentAction(ctx context.Context, action *core.AgentAction) error {
+	if action != nil {
+		masked := *action
+		masked.ToolInput = c.masker.MaskMap(action.ToolInput)
+		masked.Log = c.masker.Mask(action.Log)
+		action = &masked
+	}
+	return c.inner.OnAgentAction(ctx, action)
+}
+
+// OnAgentFinish forwards the masked final output
+func (c *Callback) OnAgentFinish(ctx context.Context, output interface{}) error {
+	return c.inner.OnAgentFinish(ctx, c.masker.MaskValue(output))
+}
@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/core/checkpoint"
+	agentstate "github.com/kart-io/goagent/core/state"
+)
+
+// Checkpointer wraps a checkpoint.Checkpointer, masking secret values in the
+// state before it is persisted
+type Checkpointer struct {
+	inner  checkpoint.Checkpointer
+	masker *Masker
+}
+
+// NewCheckpointer wraps a checkpointer. A nil masker uses the package-level
+// masker.
+func NewCheckpointer(inner checkpoint.Checkpointer, masker *Masker) *Checkpointer {
+	if masker == nil {
+		masker = defaultMasker
+	}
+	return &Checkpointer{inner: inner, masker: masker}
+}
+
+// Save persists a masked copy of the state; the live state is left untouched
+func (c *Checkpointer) Save(ctx context.Context, threadID string, state agentstate.State) error {
+	if state != nil && c.masker.Len() > 0 {
+		state = agentstate.NewAgentStateWithData(c.masker.MaskMap(state.Snapshot()))
+	}
+	return c.inner.Save(ctx, threadID, state)
+}
+
+// Load retrieves the saved state of a thread
+func (c *Checkpointer) Load(ctx context.Context, threadID string) (agentstate.State, error) {
+	return c.inner.Load(ctx, threadID)
+}
+
+// List returns information about all saved checkpoints
+func (c *Checkpointer) List(ctx context.Context) ([]checkpoint.CheckpointInfo, error) {
+	return c.inner.List(ctx)
+}
+
+// Delete removes the checkpoint of a thread
+func (c *Checkpointer) Delete(ctx context.Context, threadID string) error {
+	return c.inner.Delete(ctx, threadID)
+}
+
+// Exists checks if a checkpoint exists for a thread
+func (c *Checkpointer) Exists(ctx context.Context, threadID string) (bool, error) {
+	return c.inner.Exists(ctx, threadID)
+}
@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// Env reads secrets from environment variables
+type Env struct {
+	prefix string
+}
+
+// NewEnv creates a store reading environment variables. Names are looked up
+// as given first, then upper-cased with '-', '.' and '/' mapped to '_', so
+// the handle {{secret:github-token}} with prefix "APP_" reads
+// APP_GITHUB_TOKEN.
+func NewEnv(prefix string) *Env {
+	return &Env{prefix: prefix}
+}
+
+var envReplacer = strings.NewReplacer("-", "_", ".", "_", "/", "_")
+
+// Get returns the value of the environment variable behind the name
+func (e *Env) Get(_ context.Context, name string) (string, error) {
+	candidates := []string{
+		e.prefix + name,
+		strings.ToUpper(e.prefix + envReplacer.Replace(name)),
+	}
+	for _, key := range candidates {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			return value, nil
+		}
+	}
+	return "", NotFound("secret_env", name)
+}
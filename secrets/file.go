@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// File reads secrets from a directory holding one file per secret, the
+// layout used by Docker and Kubernetes secret mounts
+type File struct {
+	dir string
+}
+
+// NewFile creates a store reading the secret files in dir
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+// Get returns the content of the file named after the secret, without the
+// trailing newline. Names cannot escape the directory.
+func (f *File) Get(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", NotFound("secret_file", name)
+	}
+
+	file, err := os.OpenInRoot(f.dir, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", NotFound("secret_file", name)
+		}
+		return "", agentErrors.Wrap(err, agentErrors.CodeSecretStore, "failed to open secret file").
+			WithComponent("secret_file").
+			WithOperation("get").
+			WithContext("name", name)
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := readAllLimited(file)
+	if err != nil {
+		return "", agentErrors.Wrap(err, agentErrors.CodeSecretStore, "failed to read secret file").
+			WithComponent("secret_file").
+			WithOperation("get").
+			WithContext("name", name)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// maxSecretSize bounds the size of a secret file
+const maxSecretSize = 1 << 20
+
+func readAllLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSecretSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSecretSize {
+		return nil, fmt.Errorf("secret exceeds %d bytes", maxSecretSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,304 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+const keystoreVersion = 1
+
+// Argon2id parameters for new keystores. They are saved with the keystore so
+// they can be raised later without breaking existing files.
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024
+	kdfThreads = 4
+	kdfKeyLen  = 32
+	saltLen    = 16
+)
+
+// Bounds on the Argon2id parameters accepted from a keystore file, so an
+// edited file cannot force an unbounded allocation or a zero-thread panic.
+// Memory is in KiB.
+const (
+	maxKDFTime    = 16
+	minKDFMemory  = 8 * 1024
+	maxKDFMemory  = 1024 * 1024
+	maxKDFThreads = 64
+)
+
+// keystoreFile is the on-disk format of a keystore
+type keystoreFile struct {
+	Version int       `json:"version"`
+	KDF     kdfParams `json:"kdf"`
+	Salt    []byte    `json:"salt"`
+	Nonce   []byte    `json:"nonce"`
+	Data    []byte    `json:"data"`
+}
+
+type kdfParams struct {
+	Name    string `json:"name"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// Keystore is an encrypted local secret store.
+//
+// Secrets are kept in a single file encrypted with AES-256-GCM under a key
+// derived from a passphrase with Argon2id. Every write re-encrypts the file
+// with a fresh nonce and replaces it atomically.
+type Keystore struct {
+	mu      sync.RWMutex
+	path    string
+	key     []byte
+	kdf     kdfParams
+	salt    []byte
+	secrets map[string]string
+}
+
+// OpenKeystore opens the keystore at path, creating an empty one if the file
+// does not exist. A wrong passphrase yields a CodeSecretStore error.
+func OpenKeystore(path, passphrase string) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		salt := make([]byte, saltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, keystoreError(err, "failed to generate salt", path)
+		}
+		k := &Keystore{
+			path:    path,
+			kdf:     kdfParams{Name: "argon2id", Time: kdfTime, Memory: kdfMemory, Threads: kdfThreads},
+			salt:    salt,
+			secrets: make(map[string]string),
+		}
+		k.key = k.deriveKey(passphrase)
+		if err := k.save(); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	if err != nil {
+		return nil, keystoreError(err, "failed to read keystore", path)
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, keystoreError(err, "invalid keystore file", path)
+	}
+	if file.Version != keystoreVersion || file.KDF.Name != "argon2id" {
+		return nil, agentErrors.New(agentErrors.CodeSecretStore, "unsupported keystore format").
+			WithComponent("secret_keystore").
+			WithOperation("open").
+			WithContext("path", path).
+			WithContext("version", file.Version)
+	}
+	if err := file.validate(); err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeSecretStore, "invalid keystore file").
+			WithComponent("secret_keystore").
+			WithOperation("open").
+			WithContext("path", path)
+	}
+
+	k := &Keystore{
+		path: path,
+		kdf:  file.KDF,
+		salt: file.Salt,
+	}
+	k.key = k.deriveKey(passphrase)
+
+	gcm, err := k.cipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, agentErrors.New(agentErrors.CodeSecretStore, "invalid keystore file: wrong nonce length").
+			WithComponent("secret_keystore").
+			WithOperation("open").
+			WithContext("path", path)
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, agentErrors.New(agentErrors.CodeSecretStore, "failed to decrypt keystore: wrong passphrase or corrupted file").
+			WithComponent("secret_keystore").
+			WithOperation("open").
+			WithContext("path", path)
+	}
+	if err := json.Unmarshal(plain, &k.secrets); err != nil {
+		return nil, keystoreError(err, "invalid keystore content", path)
+	}
+	if k.secrets == nil {
+		k.secrets = make(map[string]string)
+	}
+	return k, nil
+}
+
+// validate checks the key derivation parameters read from a keystore file
+func (f *keystoreFile) validate() error {
+	switch {
+	case f.KDF.Time < 1 || f.KDF.Time > maxKDFTime:
+		return fmt.Errorf("kdf time %d outside [1, %d]", f.KDF.Time, maxKDFTime)
+	case f.KDF.Memory < minKDFMemory || f.KDF.Memory > maxKDFMemory:
+		return fmt.Errorf("kdf memory %d KiB outside [%d, %d]", f.KDF.Memory, minKDFMemory, maxKDFMemory)
+	case f.KDF.Threads < 1 || f.KDF.Threads > maxKDFThreads:
+		return fmt.Errorf("kdf threads %d outside [1, %d]", f.KDF.Threads, maxKDFThreads)
+	case len(f.Salt) < saltLen:
+		return fmt.Errorf("salt shorter than %d bytes", saltLen)
+	}
+	return nil
+}
+
+// Get returns the value of the named secret
+func (k *Keystore) Get(_ context.Context, name string) (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	value, ok := k.secrets[name]
+	if !ok {
+		return "", NotFound("secret_keystore", name)
+	}
+	return value, nil
+}
+
+// Set stores the value of the named secret and saves the keystore
+func (k *Keystore) Set(_ context.Context, name, value string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	previous, existed := k.secrets[name]
+	k.secrets[name] = value
+	if err := k.save(); err != nil {
+		if existed {
+			k.secrets[name] = previous
+		} else {
+			delete(k.secrets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes the named secret and saves the keystore
+func (k *Keystore) Delete(_ context.Context, name string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	previous, existed := k.secrets[name]
+	if !existed {
+		return NotFound("secret_keystore", name)
+	}
+	delete(k.secrets, name)
+	if err := k.save(); err != nil {
+		k.secrets[name] = previous
+		return err
+	}
+	return nil
+}
+
+// Names returns the sorted names of the stored secrets
+func (k *Keystore) Names() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	names := make([]string, 0, len(k.secrets))
+	for name := range k.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (k *Keystore) deriveKey(passphrase string) []byte {
+	return argon2.IDKey([]byte(passphrase), k.salt, k.kdf.Time, k.kdf.Memory, k.kdf.Threads, kdfKeyLen)
+}
+
+func (k *Keystore) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, keystoreError(err, "failed to create cipher", k.path)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, keystoreError(err, "failed to create cipher", k.path)
+	}
+	return gcm, nil
+}
+
+// save encrypts the secrets and atomically replaces the keystore file.
+// The caller must hold the write lock.
+func (k *Keystore) save() error {
+	plain, err := json.Marshal(k.secrets)
+	if err != nil {
+		return keystoreError(err, "failed to encode secrets", k.path)
+	}
+
+	gcm, err := k.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return keystoreError(err, "failed to generate nonce", k.path)
+	}
+
+	data, err := json.MarshalIndent(keystoreFile{
+		Version: keystoreVersion,
+		KDF:     k.kdf,
+		Salt:    k.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return keystoreError(err, "failed to encode keystore", k.path)
+	}
+
+	dir := filepath.Dir(k.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return keystoreError(err, "failed to create keystore directory", k.path)
+	}
+	tmp, err := os.CreateTemp(dir, ".keystore-*")
+	if err != nil {
+		return keystoreError(err, "failed to write keystore", k.path)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return keystoreError(err, "failed to write keystore", k.path)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return keystoreError(err, "failed to write keystore", k.path)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return keystoreError(err, "failed to write keystore", k.path)
+	}
+	if err := tmp.Close(); err != nil {
+		return keystoreError(err, "failed to write keystore", k.path)
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return keystoreError(err, "failed to write keystore", k.path)
+	}
+	return nil
+}
+
+func keystoreError(err error, message, path string) error {
+	return agentErrors.Wrap(err, agentErrors.CodeSecretStore, message).
+		WithComponent("secret_keystore").
+		WithContext("path", path)
+}
@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	loggercore "github.com/kart-io/logger/core"
+)
+
+// Logger wraps a logger, masking secret values in messages, arguments and
+// structured fields before they are written
+type Logger struct {
+	inner  loggercore.Logger
+	masker *Masker
+}
+
+var _ loggercore.Logger = (*Logger)(nil)
+
+// NewLogger wraps a logger. A nil masker uses the package-level masker.
+func NewLogger(inner loggercore.Logger, masker *Masker) *Logger {
+	if masker == nil {
+		masker = defaultMasker
+	}
+	// Skip the wrapper frame so call sites are still reported correctly
+	return &Logger{inner: inner.WithCallerSkip(1), masker: masker}
+}
+
+func (l *Logger) maskArgs(args []interface{}) []interface{} {
+	if l.masker.Len() == 0 {
+		return args
+	}
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		out[i] = l.masker.MaskValue(arg)
+	}
+	return out
+}
+
+func (l *Logger) format(template string, args []interface{}) string {
+	return l.masker.Mask(fmt.Sprintf(template, args...))
+}
+
+func (l *Logger) Debug(args ...interface{}) { l.inner.Debug(l.maskArgs(args)...) }
+func (l *Logger) Info(args ...interface{})  { l.inner.Info(l.maskArgs(args)...) }
+func (l *Logger) Warn(args ...interface{})  { l.inner.Warn(l.maskArgs(args)...) }
+func (l *Logger) Error(args ...interface{}) { l.inner.Error(l.maskArgs(args)...) }
+func (l *Logger) Fatal(args ...interface{}) { l.inner.Fatal(l.maskArgs(args)...) }
+
+func (l *Logger) Debugf(template string, args ...interface{}) {
+	l.inner.Debug(l.format(template, args))
+}
+
+func (l *Logger) Infof(template string, args ...interface{}) {
+	l.inner.Info(l.format(template, args))
+}
+
+func (l *Logger) Warnf(template string, args ...interface{}) {
+	l.inner.Warn(l.format(template, args))
+}
+
+func (l *Logger) Errorf(template string, args ...interface{}) {
+	l.inner.Error(l.format(template, args))
+}
+
+func (l *Logger) Fatalf(template string, args ...interface{}) {
+	l.inner.Fatal(l.format(template, args))
+}
+
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.inner.Debugw(l.masker.Mask(msg), l.maskArgs(keysAndValues)...)
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.inner.Infow(l.masker.Mask(msg), l.maskArgs(keysAndValues)...)
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.inner.Warnw(l.masker.Mask(msg), l.maskArgs(keysAndValues)...)
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.inner.Errorw(l.masker.Mask(msg), l.maskArgs(keysAndValues)...)
+}
+
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.inner.Fatalw(l.masker.Mask(msg), l.maskArgs(keysAndValues)...)
+}
+
+// With returns a masking logger with the fields added
+func (l *Logger) With(keyValues ...interface{}) loggercore.Logger {
+	return &Logger{inner: l.inner.With(l.maskArgs(keyValues)...), masker: l.masker}
+}
+
+// WithCtx returns a masking logger with the context and fields added
+func (l *Logger) WithCtx(ctx context.Context, keyValues ...interface{}) loggercore.Logger {
+	return &Logger{inner: l.inner.WithCtx(ctx, l.maskArgs(keyValues)...), masker: l.masker}
+}
+
+// WithCallerSkip returns a masking logger skipping additional caller frames
+func (l *Logger) WithCallerSkip(skip int) loggercore.Logger {
+	return &Logger{inner: l.inner.WithCallerSkip(skip), masker: l.masker}
+}
+
+// SetLevel sets the level of the inner logger
+func (l *Logger) SetLevel(level loggercore.Level) {
+	l.inner.SetLevel(level)
+}
+
+// Flush flushes the inner logger
+func (l *Logger) Flush() error {
+	return l.inner.Flush()
+}
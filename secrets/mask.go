@@ -0,0 +1,238 @@
+package secrets
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+// minMaskLength is the shortest value that is masked. Shorter values would
+// match too much unrelated text to be worth hiding.
+const minMaskLength = 4
+
+// Masker replaces known secret values with their handles
+type Masker struct {
+	mu       sync.RWMutex
+	values   map[string]string
+	replacer *strings.Replacer
+}
+
+// NewMasker creates an empty masker
+func NewMasker() *Masker {
+	return &Masker{values: make(map[string]string)}
+}
+
+var defaultMasker = NewMasker()
+
+// DefaultMasker returns the package-level masker. Resolvers register with it
+// unless configured otherwise, and it is applied to traces and LLM API keys.
+func DefaultMasker() *Masker {
+	return defaultMasker
+}
+
+// Register adds a value to the package-level masker
+func Register(name, value string) {
+	defaultMasker.Add(name, value)
+}
+
+// Mask masks s with the package-level masker
+func Mask(s string) string {
+	return defaultMasker.Mask(s)
+}
+
+// MaskValue masks v with the package-level masker
+func MaskValue(v interface{}) interface{} {
+	return defaultMasker.MaskValue(v)
+}
+
+// MaskError masks err with the package-level masker
+func MaskError(err error) error {
+	return defaultMasker.MaskError(err)
+}
+
+// Add registers a secret value to be replaced by the handle of name
+func (m *Masker) Add(name, value string) {
+	if m == nil || len(value) < minMaskLength {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.values[value]; ok && existing == name {
+		return
+	}
+	m.values[value] = name
+	m.replacer = nil
+}
+
+// Len returns the number of registered values
+func (m *Masker) Len() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.values)
+}
+
+func (m *Masker) getReplacer() *strings.Replacer {
+	m.mu.RLock()
+	r := m.replacer
+	n := len(m.values)
+	m.mu.RUnlock()
+	if r != nil || n == 0 {
+		return r
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.replacer != nil {
+		return m.replacer
+	}
+
+	// Longer values go first so a secret containing another one is masked
+	// as a whole
+	values := make([]string, 0, len(m.values))
+	for value := range m.values {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, Ref(m.values[value]))
+	}
+	m.replacer = strings.NewReplacer(pairs...)
+	return m.replacer
+}
+
+// Mask replaces the registered values in s with their handles
+func (m *Masker) Mask(s string) string {
+	if m == nil || s == "" {
+		return s
+	}
+	r := m.getReplacer()
+	if r == nil {
+		return s
+	}
+	return r.Replace(s)
+}
+
+// MaskValue returns a copy of v with the registered values masked in all
+// nested strings, errors and tool outputs
+func (m *Masker) MaskValue(v interface{}) interface{} {
+	if m == nil || m.Len() == 0 {
+		return v
+	}
+
+	switch val := v.(type) {
+	case string:
+		return m.Mask(val)
+	case []byte:
+		return []byte(m.Mask(string(val)))
+	case error:
+		return m.MaskError(val)
+	case map[string]interface{}:
+		return m.MaskMap(val)
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, s := range val {
+			out[k] = m.Mask(s)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = m.MaskValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			out[i] = m.Mask(s)
+		}
+		return out
+	case *interfaces.ToolInput:
+		return m.MaskToolInput(val)
+	case *interfaces.ToolOutput:
+		return m.MaskToolOutput(val)
+	default:
+		return v
+	}
+}
+
+// MaskMap returns a copy of the map with the registered values masked
+func (m *Masker) MaskMap(in map[string]interface{}) map[string]interface{} {
+	if in == nil || m == nil || m.Len() == 0 {
+		return in
+	}
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = m.MaskValue(v)
+	}
+	return out
+}
+
+// MaskToolInput returns a copy of the tool input with the registered values
+// masked
+func (m *Masker) MaskToolInput(in *interfaces.ToolInput) *interfaces.ToolInput {
+	if in == nil || m == nil || m.Len() == 0 {
+		return in
+	}
+	out := *in
+	out.Args = m.MaskMap(in.Args)
+	return &out
+}
+
+// MaskToolOutput returns a copy of the tool output with the registered
+// values masked
+func (m *Masker) MaskToolOutput(in *interfaces.ToolOutput) *interfaces.ToolOutput {
+	if in == nil || m == nil || m.Len() == 0 {
+		return in
+	}
+	out := *in
+	out.Result = m.MaskValue(in.Result)
+	out.Error = m.Mask(in.Error)
+	out.Metadata = m.MaskMap(in.Metadata)
+	return &out
+}
+
+// MaskError returns an error whose message has the registered values masked.
+// AgentErrors keep their code, component and operation; other errors are
+// wrapped so errors.Is and errors.As still reach the original.
+func (m *Masker) MaskError(err error) error {
+	if err == nil || m == nil || m.Len() == 0 {
+		return err
+	}
+	msg := err.Error()
+	if m.Mask(msg) == msg {
+		return err
+	}
+
+	if agentErr, ok := err.(*agentErrors.AgentError); ok {
+		masked := *agentErr
+		masked.Message = m.Mask(agentErr.Message)
+		masked.Context = m.MaskMap(agentErr.Context)
+		masked.Cause = m.MaskError(agentErr.Cause)
+		return &masked
+	}
+	return &maskedError{msg: m.Mask(msg), cause: err}
+}
+
+type maskedError struct {
+	msg   string
+	cause error
+}
+
+func (e *maskedError) Error() string { return e.msg }
+
+func (e *maskedError) Unwrap() error { return e.cause }
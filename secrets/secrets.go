@@ -0,0 +1,458 @@
+// Package secrets keeps credentials out of prompts, tool arguments, logs and
+// persisted state.
+//
+// Credentials are referenced by handle ({{secret:NAME}}) wherever a raw value
+// would otherwise appear. A Resolver swaps handles for their values only at
+// execution time, and every value it resolves is registered with a Masker,
+// which replaces it with its handle again before anything is logged, traced,
+// passed to callbacks or checkpointed.
+//
+// Tools never resolve handles on their own terms: a secret is only sent in a
+// request header, and only to the hosts or by the tools it is bound to with
+// WithBinding. Unbound secrets cannot be used by tools at all.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Store provides access to secret values by name
+type Store interface {
+	// Get returns the value of the named secret. It returns a
+	// CodeSecretNotFound error when the store has no such secret.
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Writer is implemented by stores that can persist secrets
+type Writer interface {
+	Store
+
+	// Set stores the value of the named secret
+	Set(ctx context.Context, name, value string) error
+
+	// Delete removes the named secret
+	Delete(ctx context.Context, name string) error
+}
+
+var handlePattern = regexp.MustCompile(`\{\{\s*secret:([A-Za-z0-9_.\-/#]+)\s*\}\}`)
+
+// Ref returns the handle referencing the named secret
+func Ref(name string) string {
+	return "{{secret:" + name + "}}"
+}
+
+// ContainsRef reports whether s contains a secret handle
+func ContainsRef(s string) bool {
+	return strings.Contains(s, "{{") && handlePattern.MatchString(s)
+}
+
+// Refs returns the names of the secrets referenced in s
+func Refs(s string) []string {
+	var names []string
+	for _, m := range handlePattern.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// ContainsRefValue reports whether any string nested in v contains a secret
+// handle
+func ContainsRefValue(v interface{}) bool {
+	switch val := v.(type) {
+	case string:
+		return ContainsRef(val)
+	case map[string]interface{}:
+		for k, item := range val {
+			if ContainsRef(k) || ContainsRefValue(item) {
+				return true
+			}
+		}
+	case map[string]string:
+		for k, item := range val {
+			if ContainsRef(k) || ContainsRef(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if ContainsRefValue(item) {
+				return true
+			}
+		}
+	case []string:
+		for _, item := range val {
+			if ContainsRef(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NotFound returns the error reported by stores for a missing secret
+func NotFound(component, name string) error {
+	return agentErrors.New(agentErrors.CodeSecretNotFound, "secret not found").
+		WithComponent(component).
+		WithOperation("get").
+		WithContext("name", name)
+}
+
+// IsNotFound reports whether err is a missing secret error
+func IsNotFound(err error) bool {
+	return agentErrors.IsCode(err, agentErrors.CodeSecretNotFound)
+}
+
+// Binding restricts where tools may send a secret. Hosts are matched
+// against the request host, case-insensitively; an entry of the form
+// "*.example.com" matches any subdomain. When both lists are set, a request
+// must satisfy both.
+type Binding struct {
+	// Hosts the secret may be sent to
+	Hosts []string
+
+	// Tools allowed to send the secret
+	Tools []string
+}
+
+func (b Binding) allows(tool, host string) bool {
+	if len(b.Hosts) == 0 && len(b.Tools) == 0 {
+		return false
+	}
+	if len(b.Tools) > 0 && !slices.Contains(b.Tools, tool) {
+		return false
+	}
+	if len(b.Hosts) > 0 && !slices.ContainsFunc(b.Hosts, func(pattern string) bool {
+		return matchHost(pattern, host)
+	}) {
+		return false
+	}
+	return true
+}
+
+func matchHost(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(host)
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host != "" && host == pattern
+}
+
+// Resolver replaces secret handles with their values
+type Resolver struct {
+	store    Store
+	masker   *Masker
+	bindings map[string]Binding
+}
+
+// ResolverOption configures a Resolver
+type ResolverOption func(*Resolver)
+
+// WithMasker sets the masker resolved values are registered with.
+// Defaults to the package-level masker.
+func WithMasker(m *Masker) ResolverOption {
+	return func(r *Resolver) {
+		if m != nil {
+			r.masker = m
+		}
+	}
+}
+
+// WithBinding binds the named secret to the hosts and tools allowed to
+// receive it. Tools resolve a handle only for bound secrets.
+func WithBinding(name string, b Binding) ResolverOption {
+	return func(r *Resolver) {
+		r.bindings[name] = b
+	}
+}
+
+// WithAllowedHosts binds the named secret to the hosts it may be sent to
+func WithAllowedHosts(name string, hosts ...string) ResolverOption {
+	return func(r *Resolver) {
+		b := r.bindings[name]
+		b.Hosts = append(b.Hosts, hosts...)
+		r.bindings[name] = b
+	}
+}
+
+// NewResolver creates a resolver backed by the store. A resolver without a
+// store fails on every handle, which keeps unresolved handles from leaking
+// into requests.
+func NewResolver(store Store, opts ...ResolverOption) *Resolver {
+	r := &Resolver{
+		store:    store,
+		masker:   defaultMasker,
+		bindings: make(map[string]Binding),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Masker returns the masker resolved values are registered with
+func (r *Resolver) Masker() *Masker {
+	return r.masker
+}
+
+// Lookup returns the value of the named secret and registers it for masking.
+// It reports false when no store is configured or the secret does not exist.
+func (r *Resolver) Lookup(ctx context.Context, name string) (string, bool, error) {
+	if r.store == nil {
+		return "", false, nil
+	}
+	value, err := r.store.Get(ctx, name)
+	if err != nil {
+		if IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	r.masker.Add(name, value)
+	return value, true, nil
+}
+
+// Get returns the value of the named secret and registers it for masking
+func (r *Resolver) Get(ctx context.Context, name string) (string, error) {
+	if r.store == nil {
+		return "", agentErrors.New(agentErrors.CodeSecretNotFound, "no secret store configured").
+			WithComponent("secret_resolver").
+			WithOperation("get").
+			WithContext("name", name)
+	}
+	value, err := r.store.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	r.masker.Add(name, value)
+	return value, nil
+}
+
+// Resolve replaces every secret handle in s with the secret's value
+func (r *Resolver) Resolve(ctx context.Context, s string) (string, error) {
+	if !ContainsRef(s) {
+		return s, nil
+	}
+
+	var firstErr error
+	out := handlePattern.ReplaceAllStringFunc(s, func(handle string) string {
+		if firstErr != nil {
+			return handle
+		}
+		name := handlePattern.FindStringSubmatch(handle)[1]
+		value, err := r.Get(ctx, name)
+		if err != nil {
+			firstErr = err
+			return handle
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// ResolveHeader resolves the handles in a header value that the named tool
+// is about to send to host. Every referenced secret must be bound to that
+// tool and host, otherwise a CodeSecretDenied error is returned and nothing
+// is resolved.
+func (r *Resolver) ResolveHeader(ctx context.Context, tool, host, value string) (string, error) {
+	for _, name := range Refs(value) {
+		b, ok := r.bindings[name]
+		if !ok || !b.allows(tool, host) {
+			return "", agentErrors.New(agentErrors.CodeSecretDenied, "secret is not bound to this destination").
+				WithComponent("secret_resolver").
+				WithOperation("resolve_header").
+				WithContext("name", name).
+				WithContext("tool", tool).
+				WithContext("host", host)
+		}
+	}
+	return r.Resolve(ctx, value)
+}
+
+// sentSecretsKey is the context key carrying the secrets resolved into a request
+type sentSecretsKey struct{}
+
+// sentSecrets records the secrets a tool resolved into a request's headers
+type sentSecrets struct {
+	resolver *Resolver
+	tool     string
+	names    []string
+}
+
+// WithSentSecrets returns a context recording that the named tool resolved
+// the secrets referenced in values into a request made with the context.
+// CheckRedirect uses it to keep those secrets from following a redirect to
+// a host they are not bound to.
+func (r *Resolver) WithSentSecrets(ctx context.Context, tool string, values ...string) context.Context {
+	var names []string
+	for _, v := range values {
+		names = append(names, Refs(v)...)
+	}
+	if len(names) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, sentSecretsKey{}, &sentSecrets{resolver: r, tool: tool, names: names})
+}
+
+// CheckRedirect is an http.Client CheckRedirect function for tools sending
+// secrets. Redirects carry the original headers to the new location, so a
+// redirect is rejected with a CodeSecretDenied error unless every secret
+// recorded by WithSentSecrets is bound to the target host. It stops after
+// 10 redirects like the net/http default.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	sent, ok := req.Context().Value(sentSecretsKey{}).(*sentSecrets)
+	if !ok {
+		return nil
+	}
+	host := req.URL.Hostname()
+	for _, name := range sent.names {
+		b, ok := sent.resolver.bindings[name]
+		if !ok || !b.allows(sent.tool, host) {
+			return agentErrors.New(agentErrors.CodeSecretDenied, "redirect target is not bound to the secret").
+				WithComponent("secret_resolver").
+				WithOperation("check_redirect").
+				WithContext("name", name).
+				WithContext("tool", sent.tool).
+				WithContext("host", host)
+		}
+	}
+	return nil
+}
+
+// Denied returns the error tools report for a secret handle outside a
+// request header
+func Denied(component, field string) error {
+	return agentErrors.New(agentErrors.CodeSecretDenied, "secret handles are only resolved in request headers").
+		WithComponent(component).
+		WithOperation("resolve").
+		WithContext("field", field)
+}
+
+// ResolveValue returns a copy of v with the handles in all nested strings
+// resolved. Maps and slices are copied; other values are returned as is.
+func (r *Resolver) ResolveValue(ctx context.Context, v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return r.Resolve(ctx, val)
+	case map[string]interface{}:
+		return r.ResolveArgs(ctx, val)
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, s := range val {
+			resolved, err := r.Resolve(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			resolved, err := r.ResolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			resolved, err := r.Resolve(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// ResolveArgs returns a copy of tool arguments with all handles resolved
+func (r *Resolver) ResolveArgs(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		return nil, nil
+	}
+	out := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		resolved, err := r.ResolveValue(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = resolved
+	}
+	return out, nil
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultResolver = NewResolver(nil)
+)
+
+// SetDefault sets the resolver used by components that are not given one,
+// such as LLM providers resolving API keys
+func SetDefault(r *Resolver) {
+	if r == nil {
+		r = NewResolver(nil)
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultResolver = r
+}
+
+// Default returns the default resolver. Until SetDefault is called it has no
+// store, so handles fail to resolve.
+func Default() *Resolver {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultResolver
+}
+
+// Chain returns a store that looks secrets up in each store in turn
+func Chain(stores ...Store) Store {
+	return chain(stores)
+}
+
+type chain []Store
+
+func (c chain) Get(ctx context.Context, name string) (string, error) {
+	for _, s := range c {
+		value, err := s.Get(ctx, name)
+		if err == nil {
+			return value, nil
+		}
+		if !IsNotFound(err) {
+			return "", err
+		}
+	}
+	return "", NotFound("secret_chain", name)
+}
+
+// Map is an in-memory store, mainly useful in tests
+type Map map[string]string
+
+// Get returns the value of the named secret
+func (m Map) Get(_ context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", NotFound("secret_map", name)
+	}
+	return value, nil
+}
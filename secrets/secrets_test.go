@@ -0,0 +1,325 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/core"
+	"github.com/kart-io/goagent/core/checkpoint"
+	agentstate "github.com/kart-io/goagent/core/state"
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
+)
+
+func TestRefs(t *testing.T) {
+	assert.Equal(t, "{{secret:github}}", Ref("github"))
+	assert.True(t, ContainsRef("Bearer {{secret:github}}"))
+	assert.True(t, ContainsRef("{{ secret:kv/app#token }}"))
+	assert.False(t, ContainsRef("Bearer abc"))
+	assert.False(t, ContainsRef("{{other:github}}"))
+	assert.Equal(t, []string{"a", "b.c"}, Refs("{{secret:a}}:{{secret:b.c}}"))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	ctx := context.Background()
+	m := NewMasker()
+	r := NewResolver(Map{"token": "s3cr3t-token", "user": "alice"}, WithMasker(m))
+
+	out, err := r.Resolve(ctx, "Bearer {{secret:token}} for {{secret:user}}")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer s3cr3t-token for alice", out)
+
+	args, err := r.ResolveArgs(ctx, map[string]interface{}{
+		"headers": map[string]interface{}{"Authorization": "Bearer {{secret:token}}"},
+		"list":    []interface{}{"{{secret:user}}", 3},
+		"count":   1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer s3cr3t-token", args["headers"].(map[string]interface{})["Authorization"])
+	assert.Equal(t, []interface{}{"alice", 3}, args["list"])
+	assert.Equal(t, 1, args["count"])
+
+	// resolved values are registered for masking
+	assert.Equal(t, "Bearer {{secret:token}} for {{secret:user}}", m.Mask(out))
+
+	_, err = r.Resolve(ctx, "{{secret:missing}}")
+	assert.True(t, IsNotFound(err))
+
+	_, err = NewResolver(nil).Resolve(ctx, "{{secret:token}}")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestCheckRedirect(t *testing.T) {
+	r := NewResolver(Map{"token": "s3cr3t-token"},
+		WithMasker(NewMasker()),
+		WithBinding("token", Binding{Tools: []string{"api"}, Hosts: []string{"*.example.com"}}))
+	redirect := func(ctx context.Context, target string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		require.NoError(t, err)
+		return CheckRedirect(req, []*http.Request{req})
+	}
+
+	ctx := r.WithSentSecrets(context.Background(), "api", "Bearer {{secret:token}}", "plain")
+	assert.NoError(t, redirect(ctx, "https://v2.example.com/data"))
+	assert.True(t, agentErrors.IsCode(redirect(ctx, "https://attacker.test/collect"), agentErrors.CodeSecretDenied))
+
+	// requests without resolved secrets follow redirects anywhere
+	plain := r.WithSentSecrets(context.Background(), "api", "plain")
+	assert.NoError(t, redirect(plain, "https://attacker.test/collect"))
+
+	req, err := http.NewRequest(http.MethodGet, "https://v2.example.com", nil)
+	require.NoError(t, err)
+	assert.Error(t, CheckRedirect(req, make([]*http.Request, 10)))
+}
+
+func TestMasker(t *testing.T) {
+	m := NewMasker()
+	m.Add("short", "abc")
+	m.Add("key", "sk-12345")
+	m.Add("longer", "sk-12345-extended")
+	assert.Equal(t, 2, m.Len())
+
+	assert.Equal(t, "abc {{secret:key}} {{secret:longer}}", m.Mask("abc sk-12345 sk-12345-extended"))
+
+	output := m.MaskToolOutput(&interfaces.ToolOutput{
+		Result:   map[string]interface{}{"echo": []interface{}{"sk-12345"}},
+		Error:    "bad key sk-12345",
+		Metadata: map[string]interface{}{"url": "https://api?key=sk-12345"},
+	})
+	assert.Equal(t, []interface{}{"{{secret:key}}"}, output.Result.(map[string]interface{})["echo"])
+	assert.Equal(t, "bad key {{secret:key}}", output.Error)
+	assert.Equal(t, "https://api?key={{secret:key}}", output.Metadata["url"])
+}
+
+func TestMasker_MaskError(t *testing.T) {
+	m := NewMasker()
+	m.Add("key", "sk-12345")
+
+	agentErr := agentErrors.New(agentErrors.CodeToolExecution, "call failed with sk-12345").
+		WithComponent("api").
+		WithContext("url", "https://api?key=sk-12345")
+	masked := m.MaskError(agentErr)
+	assert.NotContains(t, masked.Error(), "sk-12345")
+	assert.True(t, agentErrors.IsCode(masked, agentErrors.CodeToolExecution))
+	assert.Contains(t, agentErr.Error(), "sk-12345", "the original error is left untouched")
+
+	plain := fmt.Errorf("wrapped: %w", os.ErrPermission)
+	assert.Same(t, plain, m.MaskError(plain))
+
+	leaky := fmt.Errorf("sk-12345: %w", os.ErrPermission)
+	masked = m.MaskError(leaky)
+	assert.Equal(t, "{{secret:key}}: permission denied", masked.Error())
+	assert.True(t, errors.Is(masked, os.ErrPermission))
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("APP_GITHUB_TOKEN", "gh-token")
+	store := NewEnv("APP_")
+
+	value, err := store.Get(context.Background(), "github-token")
+	require.NoError(t, err)
+	assert.Equal(t, "gh-token", value)
+
+	_, err = store.Get(context.Background(), "missing")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("hunter22\n"), 0o600))
+	store := NewFile(dir)
+
+	value, err := store.Get(context.Background(), "db_password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter22", value)
+
+	_, err = store.Get(context.Background(), "missing")
+	assert.True(t, IsNotFound(err))
+
+	_, err = store.Get(context.Background(), "../db_password")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestKeystore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys", "store.json")
+
+	ks, err := OpenKeystore(path, "correct horse")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set(ctx, "openai", "sk-live-abcdef"))
+	require.NoError(t, ks.Set(ctx, "github", "ghp-123456"))
+	require.NoError(t, ks.Delete(ctx, "github"))
+	assert.True(t, IsNotFound(ks.Delete(ctx, "github")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-live-abcdef")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := OpenKeystore(path, "correct horse")
+	require.NoError(t, err)
+	value, err := reopened.Get(ctx, "openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-live-abcdef", value)
+	assert.Equal(t, []string{"openai"}, reopened.Names())
+
+	_, err = OpenKeystore(path, "wrong")
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSecretStore))
+}
+
+func TestKeystore_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	_, err := OpenKeystore(path, "pass")
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	tamper := func(edit func(f *keystoreFile)) error {
+		var file keystoreFile
+		require.NoError(t, json.Unmarshal(data, &file))
+		edit(&file)
+		edited, err := json.Marshal(file)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, edited, 0o600))
+		_, err = OpenKeystore(path, "pass")
+		return err
+	}
+
+	for name, edit := range map[string]func(f *keystoreFile){
+		"short nonce":  func(f *keystoreFile) { f.Nonce = f.Nonce[:4] },
+		"zero threads": func(f *keystoreFile) { f.KDF.Threads = 0 },
+		"huge memory":  func(f *keystoreFile) { f.KDF.Memory = 1 << 31 },
+		"huge time":    func(f *keystoreFile) { f.KDF.Time = 1 << 20 },
+		"no salt":      func(f *keystoreFile) { f.Salt = nil },
+	} {
+		err := tamper(edit)
+		assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSecretStore), name)
+	}
+}
+
+func TestVault(t *testing.T) {
+	ctx := context.Background()
+	reads := 0
+	client := VaultClientFunc(func(ctx context.Context, path string) (map[string]string, error) {
+		reads++
+		if path != "kv/app" {
+			return nil, NotFound("test_vault", path)
+		}
+		return map[string]string{"token": "vault-token", "value": "default-value"}, nil
+	})
+
+	now := time.Now()
+	v := NewVault(client, WithCacheTTL(time.Minute))
+	v.now = func() time.Time { return now }
+
+	value, err := v.Get(ctx, "kv/app#token")
+	require.NoError(t, err)
+	assert.Equal(t, "vault-token", value)
+
+	value, err = v.Get(ctx, "kv/app")
+	require.NoError(t, err)
+	assert.Equal(t, "default-value", value)
+	assert.Equal(t, 1, reads, "the path is cached")
+
+	now = now.Add(2 * time.Minute)
+	_, err = v.Get(ctx, "kv/app#token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, reads)
+
+	_, err = v.Get(ctx, "kv/app#missing")
+	assert.True(t, IsNotFound(err))
+	_, err = v.Get(ctx, "kv/other#token")
+	assert.True(t, IsNotFound(err))
+
+	failing := NewVault(VaultClientFunc(func(ctx context.Context, path string) (map[string]string, error) {
+		return nil, errors.New("connection refused")
+	}))
+	_, err = failing.Get(ctx, "kv/app#token")
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSecretStore))
+}
+
+func TestChain(t *testing.T) {
+	store := Chain(Map{"a": "first"}, Map{"a": "second", "b": "only-second"})
+
+	value, err := store.Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	value, err = store.Get(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Equal(t, "only-second", value)
+
+	_, err = store.Get(context.Background(), "c")
+	assert.True(t, IsNotFound(err))
+}
+
+// recordingCallback records what the callback receives
+type recordingCallback struct {
+	core.BaseCallback
+	seen []string
+}
+
+func (c *recordingCallback) OnLLMStart(ctx context.Context, prompts []string, model string) error {
+	c.seen = append(c.seen, prompts...)
+	return nil
+}
+
+func (c *recordingCallback) OnToolStart(ctx context.Context, toolName string, input interface{}) error {
+	c.seen = append(c.seen, fmt.Sprint(input))
+	return nil
+}
+
+func (c *recordingCallback) OnToolError(ctx context.Context, toolName string, err error) error {
+	c.seen = append(c.seen, err.Error())
+	return nil
+}
+
+func TestCallback(t *testing.T) {
+	ctx := context.Background()
+	m := NewMasker()
+	m.Add("key", "sk-12345")
+
+	inner := &recordingCallback{}
+	cb := NewCallback(inner, m)
+	require.NoError(t, cb.OnLLMStart(ctx, []string{"use sk-12345"}, "model"))
+	require.NoError(t, cb.OnToolStart(ctx, "api", map[string]interface{}{"token": "sk-12345"}))
+	require.NoError(t, cb.OnToolError(ctx, "api", errors.New("rejected sk-12345")))
+
+	for _, seen := range inner.seen {
+		assert.NotContains(t, seen, "sk-12345")
+		assert.Contains(t, seen, "{{secret:key}}")
+	}
+}
+
+func TestCheckpointer(t *testing.T) {
+	ctx := context.Background()
+	m := NewMasker()
+	m.Add("key", "sk-12345")
+
+	cp := NewCheckpointer(checkpoint.NewInMemorySaver(), m)
+	state := agentstate.NewAgentState()
+	state.Set("headers", map[string]interface{}{"Authorization": "Bearer sk-12345"})
+	require.NoError(t, cp.Save(ctx, "thread", state))
+
+	loaded, err := cp.Load(ctx, "thread")
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(fmt.Sprint(loaded.Snapshot()), "sk-12345"))
+	headers, _ := loaded.Get("headers")
+	assert.Equal(t, "Bearer {{secret:key}}", headers.(map[string]interface{})["Authorization"])
+
+	live, _ := state.GetMap("headers")
+	assert.Equal(t, "Bearer sk-12345", live["Authorization"], "the live state is left untouched")
+}
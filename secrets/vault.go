@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// VaultClient is the interface to external secret managers such as
+// HashiCorp Vault, AWS Secrets Manager or GCP Secret Manager. Adapters wrap
+// the vendor SDK and return the key/value pairs stored at a path.
+type VaultClient interface {
+	// ReadSecret returns the key/value pairs stored at path. It returns a
+	// CodeSecretNotFound error when nothing is stored there.
+	ReadSecret(ctx context.Context, path string) (map[string]string, error)
+}
+
+// VaultClientFunc adapts a function to VaultClient
+type VaultClientFunc func(ctx context.Context, path string) (map[string]string, error)
+
+// ReadSecret calls the function
+func (f VaultClientFunc) ReadSecret(ctx context.Context, path string) (map[string]string, error) {
+	return f(ctx, path)
+}
+
+// Vault is a store backed by an external secret manager.
+//
+// Names have the form "path#key", for example
+// {{secret:kv/data/github#token}}. A name without "#" reads the "value" key.
+// Read paths are cached for the configured TTL so every tool call does not
+// hit the secret manager.
+type Vault struct {
+	client VaultClient
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]vaultEntry
+	now   func() time.Time
+}
+
+type vaultEntry struct {
+	values  map[string]string
+	expires time.Time
+}
+
+// VaultOption configures a Vault
+type VaultOption func(*Vault)
+
+// WithCacheTTL sets how long read paths are cached. Zero disables caching.
+// Defaults to five minutes.
+func WithCacheTTL(ttl time.Duration) VaultOption {
+	return func(v *Vault) {
+		v.ttl = ttl
+	}
+}
+
+// NewVault creates a store reading from the vault client
+func NewVault(client VaultClient, opts ...VaultOption) *Vault {
+	v := &Vault{
+		client: client,
+		ttl:    5 * time.Minute,
+		cache:  make(map[string]vaultEntry),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Get returns the value of the key named by "path#key"
+func (v *Vault) Get(ctx context.Context, name string) (string, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok {
+		key = "value"
+	}
+
+	values, err := v.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", NotFound("secret_vault", name)
+	}
+	return value, nil
+}
+
+// Purge drops all cached paths, forcing the next reads to hit the vault
+func (v *Vault) Purge() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cache = make(map[string]vaultEntry)
+}
+
+func (v *Vault) read(ctx context.Context, path string) (map[string]string, error) {
+	if v.ttl > 0 {
+		v.mu.Lock()
+		entry, ok := v.cache[path]
+		v.mu.Unlock()
+		if ok && v.now().Before(entry.expires) {
+			return entry.values, nil
+		}
+	}
+
+	values, err := v.client.ReadSecret(ctx, path)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, err
+		}
+		return nil, agentErrors.Wrap(err, agentErrors.CodeSecretStore, "failed to read secret from vault").
+			WithComponent("secret_vault").
+			WithOperation("get").
+			WithContext("path", path)
+	}
+	if values == nil {
+		return nil, NotFound("secret_vault", path)
+	}
+
+	if v.ttl > 0 {
+		v.mu.Lock()
+		v.cache[path] = vaultEntry{values: values, expires: v.now().Add(v.ttl)}
+		v.mu.Unlock()
+	}
+	return values, nil
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Lease provides lease-based leader election on top of a shared backend.
+//
+// A lease is identified by name and held by at most one holder at a time.
+// Holders renew the lease by calling Acquire again before the TTL expires;
+// if a holder stops renewing, another holder can take over once it expires.
+//
+// Use cases:
+//   - Running background jobs (consolidation, compaction) on a single replica
+//   - Coordinating periodic maintenance across processes sharing a store
+type Lease interface {
+	// Acquire obtains or renews the named lease for holder.
+	// It returns false without error when another holder owns an unexpired lease.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// Release gives up the named lease if it is held by holder.
+	Release(ctx context.Context, name, holder string) error
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// lease is an in-process lease record
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+// Acquire obtains or renews the named lease for holder.
+//
+// The lease is only shared by users of the same Store instance, which makes
+// it suitable for tests and single-process deployments.
+func (s *Store) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if current, ok := s.leases[name]; ok && current.holder != holder && now.Before(current.expires) {
+		return false, nil
+	}
+
+	s.leases[name] = &lease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Release gives up the named lease if it is held by holder.
+func (s *Store) Release(ctx context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.leases[name]; ok && current.holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
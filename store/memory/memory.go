@@ -20,12 +20,16 @@ type Store struct {
 	// data maps namespace path to key-value pairs
 	data map[string]map[string]*store.Value
 	mu   sync.RWMutex
+
+	// leases maps lease name to its current holder
+	leases map[string]*lease
 }
 
 // New creates a new in-memory store.
 func New() *Store {
 	return &Store{
-		data: make(map[string]map[string]*store.Value),
+		data:   make(map[string]map[string]*store.Value),
+		leases: make(map[string]*lease),
 	}
 }
 
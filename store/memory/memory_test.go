@@ -385,3 +385,32 @@ func BenchmarkStore_Search(b *testing.B) {
 
 // storeValue is a type alias to make tests work with the local type
 type storeValue = store.Value
+
+func TestStore_Lease(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	ok, err := s.Acquire(ctx, "consolidation", "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = s.Acquire(ctx, "consolidation", "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "lease is held by another replica")
+
+	ok, err = s.Acquire(ctx, "consolidation", "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "holder can renew")
+
+	require.NoError(t, s.Release(ctx, "consolidation", "replica-b"))
+	ok, _ = s.Acquire(ctx, "consolidation", "replica-b", time.Minute)
+	assert.False(t, ok, "only the holder can release")
+
+	require.NoError(t, s.Release(ctx, "consolidation", "replica-a"))
+	ok, _ = s.Acquire(ctx, "consolidation", "replica-b", time.Millisecond)
+	assert.True(t, ok)
+
+	time.Sleep(5 * time.Millisecond)
+	ok, _ = s.Acquire(ctx, "consolidation", "replica-a", time.Minute)
+	assert.True(t, ok, "expired lease can be taken over")
+}
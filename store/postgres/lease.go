@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// leaseModel represents the database schema for leases
+type leaseModel struct {
+	Name      string    `gorm:"primaryKey"`
+	Holder    string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+// Acquire obtains or renews the named lease for holder.
+//
+// The lease row is upserted in a single statement that only overwrites the
+// row when it belongs to holder or has expired. Expiry is computed with the
+// database clock so replicas do not depend on synchronized local clocks.
+func (s *Store) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	table := s.leaseTable()
+	query := fmt.Sprintf(
+		"INSERT INTO %[1]s (name, holder, expires_at) VALUES (?, ?, NOW() + ? * INTERVAL '1 millisecond') "+
+			"ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at "+
+			"WHERE %[1]s.holder = EXCLUDED.holder OR %[1]s.expires_at < NOW()",
+		table,
+	)
+
+	result := s.db.WithContext(ctx).Exec(query, name, holder, ttl.Milliseconds())
+	if result.Error != nil {
+		return false, agentErrors.Wrap(result.Error, agentErrors.CodeStoreConnection, "failed to acquire lease").
+			WithComponent("postgres_store").
+			WithOperation("acquire_lease").
+			WithContext("lease", name)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Release gives up the named lease if it is held by holder.
+func (s *Store) Release(ctx context.Context, name, holder string) error {
+	result := s.db.WithContext(ctx).Table(s.leaseTable()).
+		Where("name = ? AND holder = ?", name, holder).
+		Delete(&leaseModel{})
+	if result.Error != nil {
+		return agentErrors.Wrap(result.Error, agentErrors.CodeStoreConnection, "failed to release lease").
+			WithComponent("postgres_store").
+			WithOperation("release_lease").
+			WithContext("lease", name)
+	}
+	return nil
+}
+
+// leaseTable returns the name of the lease table
+func (s *Store) leaseTable() string {
+	table := s.config.TableName
+	if table == "" {
+		table = "agent_stores"
+	}
+	return table + "_leases"
+}
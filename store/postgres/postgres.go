@@ -125,6 +125,9 @@ func (s *Store) migrate() error {
 		_ = s.db.AutoMigrate(&storeModel{})
 	}
 
+	// Lease table used for leader election
+	_ = s.db.Table(s.leaseTable()).AutoMigrate(&leaseModel{})
+
 	// Create composite unique index
 	return s.db.Exec(fmt.Sprintf(
 		"CREATE UNIQUE INDEX IF NOT EXISTS idx_%s_namespace_key ON %s (namespace, key)",
@@ -282,3 +282,38 @@ func TestStore_Ping(t *testing.T) {
 	_ = store.Ping(ctx)
 	_ = mock // Suppress unused variable warning
 }
+
+func TestStore_Lease(t *testing.T) {
+	store, mock, db := setupTestStore(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	acquire := regexp.QuoteMeta(
+		`INSERT INTO agent_stores_leases (name, holder, expires_at) VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond') ` +
+			`ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at ` +
+			`WHERE agent_stores_leases.holder = EXCLUDED.holder OR agent_stores_leases.expires_at < NOW()`,
+	)
+
+	mock.ExpectExec(acquire).
+		WithArgs("consolidation", "replica-a", int64(60000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(acquire).
+		WithArgs("consolidation", "replica-b", int64(60000)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(
+		`DELETE FROM "agent_stores_leases" WHERE name = $1 AND holder = $2`,
+	)).
+		WithArgs("consolidation", "replica-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ok, err := store.Acquire(ctx, "consolidation", "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.Acquire(ctx, "consolidation", "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "lease is held by another replica")
+
+	require.NoError(t, store.Release(ctx, "consolidation", "replica-a"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
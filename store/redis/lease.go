@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// acquireLeaseScript renews the lease when it is held by the caller,
+// otherwise sets it only if no other holder owns it.
+var acquireLeaseScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if current then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// releaseLeaseScript deletes the lease only when it is held by the caller.
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Acquire obtains or renews the named lease for holder.
+//
+// The lease is stored as a single key with a PX expiry, so it is released
+// automatically when the holder stops renewing it.
+func (s *Store) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+
+	acquired, err := acquireLeaseScript.Run(ctx, s.client, []string{s.leaseKey(name)}, holder, ms).Int()
+	if err != nil {
+		return false, agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to acquire lease").
+			WithComponent("redis_store").
+			WithOperation("acquire_lease").
+			WithContext("lease", name)
+	}
+
+	return acquired == 1, nil
+}
+
+// Release gives up the named lease if it is held by holder.
+func (s *Store) Release(ctx context.Context, name, holder string) error {
+	if err := releaseLeaseScript.Run(ctx, s.client, []string{s.leaseKey(name)}, holder).Err(); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreConnection, "failed to release lease").
+			WithComponent("redis_store").
+			WithOperation("release_lease").
+			WithContext("lease", name)
+	}
+	return nil
+}
+
+// leaseKey creates the Redis key of a lease
+func (s *Store) leaseKey(name string) string {
+	return s.config.Prefix + "lease:" + name
+}
@@ -415,3 +415,34 @@ func TestStore_NamespaceIsolation(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, value2, stored2.Value)
 }
+
+func TestStore_Lease(t *testing.T) {
+	s, mr := setupTestStore(t)
+	defer mr.Close()
+	defer s.Close()
+
+	ctx := context.Background()
+
+	ok, err := s.Acquire(ctx, "consolidation", "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = s.Acquire(ctx, "consolidation", "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "lease is held by another replica")
+
+	ok, err = s.Acquire(ctx, "consolidation", "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "holder can renew")
+
+	require.NoError(t, s.Release(ctx, "consolidation", "replica-b"))
+	assert.True(t, mr.Exists("test:store:lease:consolidation"), "only the holder can release")
+
+	mr.FastForward(2 * time.Minute)
+	ok, err = s.Acquire(ctx, "consolidation", "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "expired lease can be taken over")
+
+	require.NoError(t, s.Release(ctx, "consolidation", "replica-b"))
+	assert.False(t, mr.Exists("test:store:lease:consolidation"))
+}
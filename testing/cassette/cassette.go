@@ -0,0 +1,198 @@
+// Package cassette records and replays LLM, tool and embedding interactions
+// so that multi-step agents can be tested deterministically.
+//
+// A Recorder wraps real clients. In record mode every request/response pair
+// (including stream chunk timing and tool calls) is appended to a cassette
+// file; in replay mode the recorded responses are served back by matching a
+// normalized request hash, and any request without a recording fails with an
+// error instead of silently reaching the network:
+//
+//	rec := cassette.New(t, "testdata/supervisor.yaml")
+//	client := rec.Client(realClient) // realClient may be nil when replaying
+//	agent := agents.NewSupervisorAgent(client, config)
+package cassette
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// Version is the current cassette file format version
+const Version = 1
+
+// Interaction kinds
+const (
+	KindComplete    = "llm.complete"
+	KindChat        = "llm.chat"
+	KindStream      = "llm.complete_stream"
+	KindChatStream  = "llm.chat_stream"
+	KindToolCalling = "llm.tools"
+	KindTool        = "tool"
+	KindEmbed       = "embed"
+	KindEmbedQuery  = "embed.query"
+)
+
+// Cassette is the on-disk collection of recorded interactions
+type Cassette struct {
+	Version      int            `json:"version" yaml:"version"`
+	RecordedAt   time.Time      `json:"recorded_at" yaml:"recorded_at"`
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair.
+//
+// Request and Response hold the JSON form of the original values so that
+// cassettes stay readable and diffable in both JSON and YAML.
+type Interaction struct {
+	Kind     string        `json:"kind" yaml:"kind"`
+	Target   string        `json:"target,omitempty" yaml:"target,omitempty"`
+	Request  interface{}   `json:"request" yaml:"request"`
+	Response interface{}   `json:"response,omitempty" yaml:"response,omitempty"`
+	Chunks   []*Chunk      `json:"chunks,omitempty" yaml:"chunks,omitempty"`
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// Chunk is a recorded stream chunk. Delay is the time elapsed since the
+// previous chunk (or since the stream was opened for the first chunk).
+type Chunk struct {
+	Delta        string        `json:"delta,omitempty" yaml:"delta,omitempty"`
+	Content      string        `json:"content,omitempty" yaml:"content,omitempty"`
+	Role         string        `json:"role,omitempty" yaml:"role,omitempty"`
+	FinishReason string        `json:"finish_reason,omitempty" yaml:"finish_reason,omitempty"`
+	Usage        interface{}   `json:"usage,omitempty" yaml:"usage,omitempty"`
+	Done         bool          `json:"done,omitempty" yaml:"done,omitempty"`
+	Error        string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Delay        time.Duration `json:"delay" yaml:"delay"`
+}
+
+// Load reads a cassette file. Files ending in .yaml or .yml are decoded as
+// YAML, everything else as JSON.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to read cassette").
+			WithComponent("cassette").
+			WithOperation("load").
+			WithContext("path", path)
+	}
+
+	c := &Cassette{}
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, c)
+	} else {
+		err = json.Unmarshal(data, c)
+	}
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to decode cassette").
+			WithComponent("cassette").
+			WithOperation("load").
+			WithContext("path", path)
+	}
+	return c, nil
+}
+
+// Save writes the cassette to path, creating parent directories as needed
+func (c *Cassette) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if isYAML(path) {
+		data, err = yaml.Marshal(c)
+	} else {
+		data, err = json.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to encode cassette").
+			WithComponent("cassette").
+			WithOperation("save").
+			WithContext("path", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to create cassette directory").
+			WithComponent("cassette").
+			WithOperation("save").
+			WithContext("path", path)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStateSave, "failed to write cassette").
+			WithComponent("cassette").
+			WithOperation("save").
+			WithContext("path", path)
+	}
+	return nil
+}
+
+// isYAML reports whether path should be encoded as YAML
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// toGeneric converts v into its JSON form (maps, slices and scalars)
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// fromGeneric decodes a recorded value into target.
+//
+// YAML decoding may produce map[interface{}]interface{} for nested maps,
+// which are normalized before going through JSON.
+func fromGeneric(generic interface{}, target interface{}) error {
+	data, err := json.Marshal(normalizeYAML(generic))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// normalizeYAML converts YAML-specific map types into JSON-compatible ones
+func normalizeYAML(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			converted[toString(k)] = normalizeYAML(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			converted[k] = normalizeYAML(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			converted[i] = normalizeYAML(item)
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
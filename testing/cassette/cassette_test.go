@@ -0,0 +1,274 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/llm/providers"
+	"github.com/kart-io/goagent/retrieval"
+)
+
+// fakeClient answers with a counter so that replayed responses can be told apart
+type fakeClient struct {
+	calls int
+}
+
+func (c *fakeClient) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	c.calls++
+	last := req.Messages[len(req.Messages)-1].Content
+	if last == "fail" {
+		return nil, errors.New("rate limited")
+	}
+	return &llm.CompletionResponse{
+		Content: fmt.Sprintf("answer %d to %s", c.calls, last),
+		Model:   "fake",
+		Usage:   &interfaces.TokenUsage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+	}, nil
+}
+
+func (c *fakeClient) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	return c.Complete(ctx, &llm.CompletionRequest{Messages: messages})
+}
+
+func (c *fakeClient) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (<-chan *llm.StreamChunk, error) {
+	c.calls++
+	out := make(chan *llm.StreamChunk, 3)
+	go func() {
+		defer close(out)
+		content := ""
+		for i, delta := range []string{"Hel", "lo"} {
+			time.Sleep(20 * time.Millisecond)
+			content += delta
+			out <- &llm.StreamChunk{Delta: delta, Content: content, Index: i}
+		}
+		out <- &llm.StreamChunk{Content: content, Done: true, FinishReason: "stop", Usage: &llm.Usage{TotalTokens: 2}}
+	}()
+	return out, nil
+}
+
+func (c *fakeClient) ChatStream(ctx context.Context, messages []llm.Message) (<-chan *llm.StreamChunk, error) {
+	return c.CompleteStream(ctx, &llm.CompletionRequest{Messages: messages})
+}
+
+func (c *fakeClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*providers.ToolCallResponse, error) {
+	c.calls++
+	return &providers.ToolCallResponse{
+		ToolCalls: []providers.ToolCall{{ID: "call_1", Name: tools[0].Name(), Arguments: map[string]interface{}{"city": "Paris"}}},
+	}, nil
+}
+
+func (c *fakeClient) Provider() constants.Provider { return constants.ProviderOpenAI }
+
+func (c *fakeClient) IsAvailable() bool { return true }
+
+// weatherTool reports a fixed temperature and counts invocations
+type weatherTool struct {
+	calls int
+}
+
+func (t *weatherTool) Name() string        { return "weather" }
+func (t *weatherTool) Description() string { return "Returns the weather for a city" }
+func (t *weatherTool) ArgsSchema() string  { return `{"type":"object"}` }
+
+func (t *weatherTool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	t.calls++
+	return &interfaces.ToolOutput{Result: map[string]interface{}{"city": input.Args["city"], "celsius": 21}, Success: true}, nil
+}
+
+func collect(t *testing.T, ch <-chan *llm.StreamChunk) []*llm.StreamChunk {
+	t.Helper()
+	var chunks []*llm.StreamChunk
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// session exercises every wrapped call and returns the observed results
+func session(t *testing.T, rec *Recorder, client *fakeClient, tool *weatherTool, embedder retrieval.Embedder) []string {
+	t.Helper()
+	ctx := context.Background()
+	var inner llm.Client
+	var innerTool interfaces.Tool = tool
+	if client != nil {
+		inner = client
+	}
+	wrapped := rec.Client(inner)
+	var results []string
+
+	for _, prompt := range []string{"hi", "hi", "fail"} {
+		resp, err := wrapped.Chat(ctx, []llm.Message{llm.UserMessage(prompt)})
+		if err != nil {
+			results = append(results, "error: "+err.Error())
+			continue
+		}
+		results = append(results, fmt.Sprintf("%s (%d tokens)", resp.Content, resp.Usage.TotalTokens))
+	}
+
+	stream, err := wrapped.CompleteStream(ctx, &llm.CompletionRequest{Messages: []llm.Message{llm.UserMessage("stream")}})
+	require.NoError(t, err)
+	chunks := collect(t, stream)
+	require.Len(t, chunks, 3)
+	results = append(results, fmt.Sprintf("stream %s %s %d", chunks[2].Content, chunks[2].FinishReason, chunks[2].Usage.TotalTokens))
+
+	toolResp, err := wrapped.GenerateWithTools(ctx, "weather in Paris?", []interfaces.Tool{innerTool})
+	require.NoError(t, err)
+	results = append(results, fmt.Sprintf("tool call %s %v", toolResp.ToolCalls[0].Name, toolResp.ToolCalls[0].Arguments))
+
+	out, err := rec.Tool(innerTool).Invoke(ctx, &interfaces.ToolInput{Args: map[string]interface{}{"city": "Paris"}})
+	require.NoError(t, err)
+	results = append(results, fmt.Sprintf("tool result %v", out.Result))
+
+	vector, err := rec.Embedder(embedder).EmbedQuery(ctx, "paris weather")
+	require.NoError(t, err)
+	results = append(results, fmt.Sprintf("vector %d %.4f", len(vector), vector[0]))
+
+	return results
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	for _, name := range []string{"session.yaml", "session.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassettes", name)
+
+			client, tool := &fakeClient{}, &weatherTool{}
+			rec, err := NewRecorder(path)
+			require.NoError(t, err)
+			assert.Equal(t, ModeRecord, rec.Mode())
+			recorded := session(t, rec, client, tool, retrieval.NewSimpleEmbedder(8))
+			require.NoError(t, rec.Stop())
+			assert.Equal(t, 5, client.calls)
+			assert.Equal(t, 1, tool.calls)
+			assert.Equal(t, "error: rate limited", recorded[2])
+
+			// Replay without real clients; the tool is only consulted for its metadata
+			replayTool := &weatherTool{}
+			rec, err = NewRecorder(path)
+			require.NoError(t, err)
+			assert.Equal(t, ModeReplay, rec.Mode())
+			replayed := session(t, rec, nil, replayTool, nil)
+			require.NoError(t, rec.Stop())
+
+			assert.Equal(t, recorded, replayed)
+			assert.Equal(t, 0, replayTool.calls)
+			assert.Equal(t, "answer 1 to hi (7 tokens)", replayed[0])
+			assert.Equal(t, "answer 2 to hi (7 tokens)", replayed[1], "identical requests replay in recorded order")
+			assert.Equal(t, 8, rec.Embedder(nil).Dimensions())
+		})
+	}
+}
+
+func TestReplay_UnmatchedRequestFailsLoudly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.json")
+	rec, err := NewRecorder(path, WithMode(ModeRecord))
+	require.NoError(t, err)
+	_, err = rec.Client(&fakeClient{}).Chat(context.Background(), []llm.Message{llm.UserMessage("hi")})
+	require.NoError(t, err)
+	require.NoError(t, rec.Stop())
+
+	rec, err = NewRecorder(path, WithMode(ModeReplay))
+	require.NoError(t, err)
+	client := rec.Client(nil)
+
+	_, err = client.Chat(context.Background(), []llm.Message{llm.UserMessage("something else")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no recorded interaction")
+
+	// A recorded request is served once unless repeats are enabled
+	_, err = client.Chat(context.Background(), []llm.Message{llm.UserMessage("hi")})
+	require.NoError(t, err)
+	_, err = client.Chat(context.Background(), []llm.Message{llm.UserMessage("hi")})
+	require.Error(t, err)
+
+	require.Len(t, rec.Unmatched(), 2)
+	assert.Contains(t, rec.Unmatched()[0], "something else")
+	err = rec.Stop()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 request(s) had no recorded interaction")
+
+	rec, err = NewRecorder(path, WithMode(ModeReplay), WithPlaybackRepeats(true))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = rec.Client(nil).Chat(context.Background(), []llm.Message{llm.UserMessage("hi")})
+		require.NoError(t, err)
+	}
+	assert.NoError(t, rec.Stop())
+}
+
+func TestMatchers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matchers.yaml")
+	opts := []Option{WithMatchers(
+		IgnoreFields("temperature"),
+		NormalizeWhitespace(),
+		ReplaceRegexp(`\d{4}-\d{2}-\d{2}`, "<date>"),
+	)}
+
+	rec, err := NewRecorder(path, opts...)
+	require.NoError(t, err)
+	_, err = rec.Client(&fakeClient{}).Complete(context.Background(), &llm.CompletionRequest{
+		Messages:    []llm.Message{llm.UserMessage("Today is 2026-01-02.\n  Plan my day")},
+		Temperature: 0.2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, rec.Stop())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "temperature: 0.2", "matchers must not alter the recorded request")
+
+	rec, err = NewRecorder(path, opts...)
+	require.NoError(t, err)
+	resp, err := rec.Client(nil).Complete(context.Background(), &llm.CompletionRequest{
+		Messages:    []llm.Message{llm.UserMessage("Today is 2026-10-18. Plan my day")},
+		Temperature: 0.9,
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Content, "answer 1"))
+	assert.NoError(t, rec.Stop())
+}
+
+func TestReplay_StreamTiming(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.json")
+	rec, err := NewRecorder(path)
+	require.NoError(t, err)
+	stream, err := rec.Client(&fakeClient{}).ChatStream(context.Background(), []llm.Message{llm.UserMessage("hi")})
+	require.NoError(t, err)
+	collect(t, stream)
+	require.NoError(t, rec.Stop())
+
+	ix := rec.Interactions()[0]
+	require.Len(t, ix.Chunks, 3)
+	assert.GreaterOrEqual(t, ix.Chunks[0].Delay, 15*time.Millisecond)
+
+	rec, err = NewRecorder(path, WithStreamTiming(true))
+	require.NoError(t, err)
+	start := time.Now()
+	stream, err = rec.Client(nil).ChatStream(context.Background(), []llm.Message{llm.UserMessage("hi")})
+	require.NoError(t, err)
+	chunks := collect(t, stream)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Equal(t, "Hel", chunks[0].Delta)
+	assert.True(t, chunks[2].Done)
+}
+
+func TestRecord_RequiresInnerClient(t *testing.T) {
+	rec, err := NewRecorder(filepath.Join(t.TempDir(), "x.json"), WithMode(ModeRecord))
+	require.NoError(t, err)
+	_, err = rec.Client(nil).Chat(context.Background(), nil)
+	assert.Error(t, err)
+
+	_, err = NewRecorder(filepath.Join(t.TempDir(), "missing.json"), WithMode(ModeReplay))
+	assert.Error(t, err)
+}
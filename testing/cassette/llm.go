@@ -0,0 +1,254 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/llm"
+	"github.com/kart-io/goagent/llm/constants"
+	"github.com/kart-io/goagent/llm/providers"
+)
+
+// ToolCallingClient is implemented by providers that support native tool
+// calling (OpenAI, DeepSeek, Gemini)
+type ToolCallingClient interface {
+	GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*providers.ToolCallResponse, error)
+}
+
+// Client is an llm.StreamClient that records or replays the wrapped client
+type Client struct {
+	rec   *Recorder
+	inner llm.Client
+}
+
+// Client wraps an LLM client. inner may be nil in replay mode.
+func (r *Recorder) Client(inner llm.Client) *Client {
+	return &Client{rec: r, inner: inner}
+}
+
+// chatRequest is the recorded form of a Chat call
+type chatRequest struct {
+	Messages []llm.Message `json:"messages"`
+}
+
+// toolCallingRequest is the recorded form of a GenerateWithTools call
+type toolCallingRequest struct {
+	Prompt string         `json:"prompt"`
+	Tools  []toolSnapshot `json:"tools"`
+}
+
+type toolSnapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ArgsSchema  string `json:"args_schema,omitempty"`
+}
+
+// Complete records or replays a completion
+func (c *Client) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return roundTrip(c.rec, KindComplete, "", req, c.inner != nil, func() (*llm.CompletionResponse, error) {
+		return c.inner.Complete(ctx, req)
+	})
+}
+
+// Chat records or replays a chat call
+func (c *Client) Chat(ctx context.Context, messages []llm.Message) (*llm.CompletionResponse, error) {
+	return roundTrip(c.rec, KindChat, "", chatRequest{Messages: messages}, c.inner != nil, func() (*llm.CompletionResponse, error) {
+		return c.inner.Chat(ctx, messages)
+	})
+}
+
+// CompleteStream records or replays a streaming completion
+func (c *Client) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (<-chan *llm.StreamChunk, error) {
+	streamer, ok := c.inner.(llm.StreamClient)
+	return c.rec.stream(ctx, KindStream, req, ok, func() (<-chan *llm.StreamChunk, error) {
+		return streamer.CompleteStream(ctx, req)
+	})
+}
+
+// ChatStream records or replays a streaming chat call
+func (c *Client) ChatStream(ctx context.Context, messages []llm.Message) (<-chan *llm.StreamChunk, error) {
+	streamer, ok := c.inner.(llm.StreamClient)
+	return c.rec.stream(ctx, KindChatStream, chatRequest{Messages: messages}, ok, func() (<-chan *llm.StreamChunk, error) {
+		return streamer.ChatStream(ctx, messages)
+	})
+}
+
+// GenerateWithTools records or replays a native tool-calling completion,
+// including the tool calls chosen by the model
+func (c *Client) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool) (*providers.ToolCallResponse, error) {
+	request := toolCallingRequest{Prompt: prompt, Tools: make([]toolSnapshot, len(tools))}
+	for i, tool := range tools {
+		request.Tools[i] = toolSnapshot{Name: tool.Name(), Description: tool.Description(), ArgsSchema: tool.ArgsSchema()}
+	}
+	caller, ok := c.inner.(ToolCallingClient)
+	return roundTrip(c.rec, KindToolCalling, "", request, ok, func() (*providers.ToolCallResponse, error) {
+		return caller.GenerateWithTools(ctx, prompt, tools)
+	})
+}
+
+// Provider returns the wrapped provider, or ProviderCustom without one
+func (c *Client) Provider() constants.Provider {
+	if c.inner == nil {
+		return constants.ProviderCustom
+	}
+	return c.inner.Provider()
+}
+
+// IsAvailable is always true when replaying
+func (c *Client) IsAvailable() bool {
+	if !c.rec.Recording() {
+		return true
+	}
+	return c.inner != nil && c.inner.IsAvailable()
+}
+
+// roundTrip replays a recorded response or calls invoke and records the result
+func roundTrip[T any](r *Recorder, kind, target string, request interface{}, present bool, invoke func() (*T, error)) (*T, error) {
+	if !r.Recording() {
+		ix, err := r.replay(kind, target, request)
+		if err != nil {
+			return nil, err
+		}
+		var resp *T
+		if ix.Response != nil {
+			resp = new(T)
+			if err := decode(ix, resp); err != nil {
+				return nil, err
+			}
+		}
+		return resp, recordedError(ix)
+	}
+
+	if err := r.requireInner(kind, present); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, callErr := invoke()
+	ix := &Interaction{Kind: kind, Target: target, Duration: time.Since(start)}
+	var response interface{}
+	if resp != nil {
+		response = resp
+	}
+	if err := r.record(ix, request, response, callErr); err != nil {
+		return nil, err
+	}
+	return resp, callErr
+}
+
+// stream replays recorded chunks or tees the live stream into the cassette
+func (r *Recorder) stream(ctx context.Context, kind string, request interface{}, present bool, open func() (<-chan *llm.StreamChunk, error)) (<-chan *llm.StreamChunk, error) {
+	if !r.Recording() {
+		ix, err := r.replay(kind, "", request)
+		if err != nil {
+			return nil, err
+		}
+		if len(ix.Chunks) == 0 {
+			if err := recordedError(ix); err != nil {
+				return nil, err
+			}
+		}
+		return r.replayChunks(ctx, ix)
+	}
+
+	if err := r.requireInner(kind, present); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	in, err := open()
+	if err != nil {
+		ix := &Interaction{Kind: kind, Duration: time.Since(start)}
+		if recErr := r.record(ix, request, nil, err); recErr != nil {
+			return nil, recErr
+		}
+		return nil, err
+	}
+
+	out := make(chan *llm.StreamChunk, cap(in))
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		defer close(out)
+		ix := &Interaction{Kind: kind}
+		last := start
+		forward := true
+		for chunk := range in {
+			now := time.Now()
+			recorded := &Chunk{
+				Delta:        chunk.Delta,
+				Content:      chunk.Content,
+				Role:         chunk.Role,
+				FinishReason: chunk.FinishReason,
+				Done:         chunk.Done,
+				Delay:        now.Sub(last),
+			}
+			last = now
+			if chunk.Usage != nil {
+				recorded.Usage, _ = toGeneric(chunk.Usage)
+			}
+			if chunk.Error != nil {
+				recorded.Error = chunk.Error.Error()
+			}
+			ix.Chunks = append(ix.Chunks, recorded)
+
+			// Keep draining after the consumer is gone so the whole stream is recorded
+			if forward {
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					forward = false
+				}
+			}
+		}
+		ix.Duration = time.Since(start)
+		_ = r.record(ix, request, nil, nil)
+	}()
+	return out, nil
+}
+
+// replayChunks emits recorded chunks, optionally with their recorded delays
+func (r *Recorder) replayChunks(ctx context.Context, ix *Interaction) (<-chan *llm.StreamChunk, error) {
+	chunks := make([]*llm.StreamChunk, len(ix.Chunks))
+	for i, recorded := range ix.Chunks {
+		chunk := &llm.StreamChunk{
+			Content:      recorded.Content,
+			Delta:        recorded.Delta,
+			Role:         recorded.Role,
+			FinishReason: recorded.FinishReason,
+			Index:        i,
+			Done:         recorded.Done,
+		}
+		if recorded.Usage != nil {
+			chunk.Usage = &llm.Usage{}
+			if err := fromGeneric(recorded.Usage, chunk.Usage); err != nil {
+				return nil, r.encodeError(ix.Kind, err)
+			}
+		}
+		if recorded.Error != "" {
+			chunk.Error = errors.New(recorded.Error)
+		}
+		chunks[i] = chunk
+	}
+
+	out := make(chan *llm.StreamChunk, len(chunks))
+	go func() {
+		defer close(out)
+		for i, chunk := range chunks {
+			if r.streamTiming && ix.Chunks[i].Delay > 0 {
+				select {
+				case <-time.After(ix.Chunks[i].Delay):
+				case <-ctx.Done():
+					return
+				}
+			}
+			chunk.Timestamp = time.Now()
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
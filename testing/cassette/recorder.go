@@ -0,0 +1,381 @@
+package cassette
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/utils/json"
+)
+
+// Mode selects whether a Recorder talks to the real clients or to the cassette
+type Mode int
+
+const (
+	// ModeAuto replays when the cassette file exists and records otherwise
+	ModeAuto Mode = iota
+	// ModeRecord calls the wrapped clients and overwrites the cassette on Stop
+	ModeRecord
+	// ModeReplay serves responses from the cassette and never calls the wrapped clients
+	ModeReplay
+)
+
+// String returns the mode name
+func (m Mode) String() string {
+	switch m {
+	case ModeRecord:
+		return "record"
+	case ModeReplay:
+		return "replay"
+	default:
+		return "auto"
+	}
+}
+
+// Matcher normalizes a request before it is hashed for matching.
+//
+// The request is the JSON form of the original value (maps, slices and
+// scalars) and may be modified in place; the recorded request is not affected.
+type Matcher func(kind string, request interface{}) interface{}
+
+// IgnoreFields removes the named object fields at any depth, e.g.
+// IgnoreFields("temperature", "timestamp")
+func IgnoreFields(fields ...string) Matcher {
+	ignored := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		ignored[f] = true
+	}
+	return func(kind string, request interface{}) interface{} {
+		return transform(request, func(key string) bool { return ignored[key] }, nil)
+	}
+}
+
+// NormalizeWhitespace collapses runs of whitespace in all strings and trims them
+func NormalizeWhitespace() Matcher {
+	return func(kind string, request interface{}) interface{} {
+		return transform(request, nil, func(s string) string {
+			return strings.Join(strings.Fields(s), " ")
+		})
+	}
+}
+
+// ReplaceRegexp replaces matches of pattern in all strings, which is useful
+// for masking timestamps or IDs embedded in prompts
+func ReplaceRegexp(pattern, replacement string) Matcher {
+	re := regexp.MustCompile(pattern)
+	return func(kind string, request interface{}) interface{} {
+		return transform(request, nil, func(s string) string {
+			return re.ReplaceAllString(s, replacement)
+		})
+	}
+}
+
+// transform walks a JSON value, dropping object keys and rewriting strings
+func transform(v interface{}, drop func(key string) bool, rewrite func(string) string) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			if drop != nil && drop(k) {
+				delete(value, k)
+				continue
+			}
+			value[k] = transform(item, drop, rewrite)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = transform(item, drop, rewrite)
+		}
+		return value
+	case string:
+		if rewrite != nil {
+			return rewrite(value)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// Option configures a Recorder
+type Option func(*Recorder)
+
+// WithMode sets the recording mode (default ModeAuto)
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithMatchers adds request normalizers applied before hashing
+func WithMatchers(matchers ...Matcher) Option {
+	return func(r *Recorder) {
+		r.matchers = append(r.matchers, matchers...)
+	}
+}
+
+// WithStreamTiming replays stream chunks with their recorded delays instead
+// of emitting them immediately
+func WithStreamTiming(enabled bool) Option {
+	return func(r *Recorder) {
+		r.streamTiming = enabled
+	}
+}
+
+// WithPlaybackRepeats lets a request be served more times than it was
+// recorded by repeating the last matching interaction
+func WithPlaybackRepeats(enabled bool) Option {
+	return func(r *Recorder) {
+		r.repeats = enabled
+	}
+}
+
+// Recorder records or replays interactions of the clients it wraps
+type Recorder struct {
+	path         string
+	mode         Mode
+	matchers     []Matcher
+	streamTiming bool
+	repeats      bool
+
+	pending   sync.WaitGroup
+	mu        sync.Mutex
+	cassette  *Cassette
+	queues    map[string][]*Interaction
+	last      map[string]*Interaction
+	unmatched []string
+	stopped   bool
+}
+
+// NewRecorder creates a Recorder backed by the cassette at path
+func NewRecorder(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:   path,
+		queues: make(map[string][]*Interaction),
+		last:   make(map[string]*Interaction),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeRecord {
+		r.cassette = &Cassette{Version: Version, RecordedAt: time.Now()}
+		return r, nil
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	r.cassette = c
+	for _, ix := range c.Interactions {
+		key, err := r.key(ix.Kind, ix.Target, normalizeYAML(ix.Request))
+		if err != nil {
+			return nil, agentErrors.Wrap(err, agentErrors.CodeStateLoad, "failed to hash recorded request").
+				WithComponent("cassette").
+				WithOperation("load").
+				WithContext("path", path)
+		}
+		r.queues[key] = append(r.queues[key], ix)
+	}
+	return r, nil
+}
+
+// New creates a Recorder for a test. The cassette is saved (record mode) or
+// checked for unmatched requests (replay mode) when the test finishes, and
+// any failure is reported through t.
+func New(t testing.TB, path string, opts ...Option) *Recorder {
+	t.Helper()
+	r, err := NewRecorder(path, opts...)
+	if err != nil {
+		t.Fatalf("cassette: %v", err)
+		return nil
+	}
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Errorf("cassette: %v", err)
+		}
+	})
+	return r
+}
+
+// Mode returns the effective mode
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Recording reports whether the Recorder calls the wrapped clients
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Unmatched returns descriptions of requests that had no recorded interaction
+func (r *Recorder) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.unmatched...)
+}
+
+// Interactions returns the recorded or loaded interactions
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.cassette.Interactions...)
+}
+
+// Stop saves the cassette in record mode. In replay mode it returns an error
+// if any request could not be matched. Streams still being recorded are
+// waited for. Stop is idempotent.
+func (r *Recorder) Stop() error {
+	r.pending.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return nil
+	}
+	r.stopped = true
+
+	if r.mode == ModeRecord {
+		return r.cassette.Save(r.path)
+	}
+	if len(r.unmatched) > 0 {
+		return agentErrors.New(agentErrors.CodeInvalidInput,
+			fmt.Sprintf("%d request(s) had no recorded interaction:\n%s", len(r.unmatched), strings.Join(r.unmatched, "\n"))).
+			WithComponent("cassette").
+			WithOperation("stop").
+			WithContext("path", r.path)
+	}
+	return nil
+}
+
+// key hashes a normalized request
+func (r *Recorder) key(kind, target string, request interface{}) (string, error) {
+	// normalizeYAML returns a deep copy, so matchers may modify it freely
+	normalized := normalizeYAML(request)
+	for _, m := range r.matchers {
+		normalized = m(kind, normalized)
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(kind + "\x00" + target + "\x00" + string(data)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replay returns the next recorded interaction matching the request
+func (r *Recorder) replay(kind, target string, request interface{}) (*Interaction, error) {
+	generic, err := toGeneric(request)
+	if err != nil {
+		return nil, r.encodeError(kind, err)
+	}
+	key, err := r.key(kind, target, generic)
+	if err != nil {
+		return nil, r.encodeError(kind, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if queue := r.queues[key]; len(queue) > 0 {
+		r.queues[key] = queue[1:]
+		r.last[key] = queue[0]
+		return queue[0], nil
+	}
+	if ix, ok := r.last[key]; ok && r.repeats {
+		return ix, nil
+	}
+
+	summary, _ := json.Marshal(generic)
+	description := fmt.Sprintf("%s %s %s", kind, target, truncate(string(summary), 300))
+	r.unmatched = append(r.unmatched, strings.Join(strings.Fields(description), " "))
+	return nil, agentErrors.New(agentErrors.CodeInvalidInput, "no recorded interaction matches request").
+		WithComponent("cassette").
+		WithOperation("replay").
+		WithContext("kind", kind).
+		WithContext("target", target).
+		WithContext("request", truncate(string(summary), 300)).
+		WithContext("path", r.path)
+}
+
+// record appends an interaction to the cassette
+func (r *Recorder) record(ix *Interaction, request, response interface{}, callErr error) error {
+	var err error
+	if ix.Request, err = toGeneric(request); err != nil {
+		return r.encodeError(ix.Kind, err)
+	}
+	if response != nil {
+		if ix.Response, err = toGeneric(response); err != nil {
+			return r.encodeError(ix.Kind, err)
+		}
+	}
+	if callErr != nil {
+		ix.Error = callErr.Error()
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, ix)
+	r.mu.Unlock()
+	return nil
+}
+
+// requireInner fails when recording without a real client to call
+func (r *Recorder) requireInner(kind string, present bool) error {
+	if present {
+		return nil
+	}
+	return agentErrors.New(agentErrors.CodeInvalidConfig, "recording requires a wrapped client").
+		WithComponent("cassette").
+		WithOperation("record").
+		WithContext("kind", kind)
+}
+
+func (r *Recorder) encodeError(kind string, err error) error {
+	return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to encode interaction").
+		WithComponent("cassette").
+		WithOperation(r.mode.String()).
+		WithContext("kind", kind)
+}
+
+// recordedError recreates the error returned during recording
+func recordedError(ix *Interaction) error {
+	if ix.Error == "" {
+		return nil
+	}
+	return errors.New(ix.Error)
+}
+
+// decode decodes a recorded response into target
+func decode(ix *Interaction, target interface{}) error {
+	if ix.Response == nil {
+		return nil
+	}
+	if err := fromGeneric(ix.Response, target); err != nil {
+		return agentErrors.Wrap(err, agentErrors.CodeStoreSerialization, "failed to decode recorded response").
+			WithComponent("cassette").
+			WithOperation("replay").
+			WithContext("kind", ix.Kind)
+	}
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,162 @@
+package cassette
+
+import (
+	"context"
+
+	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/memory"
+	"github.com/kart-io/goagent/retrieval"
+)
+
+// toolRequest is the recorded form of a tool invocation
+type toolRequest struct {
+	Args map[string]interface{} `json:"args"`
+}
+
+// Tool is an interfaces.Tool that records or replays the wrapped tool
+type Tool struct {
+	rec   *Recorder
+	inner interfaces.Tool
+}
+
+// Tool wraps a tool. The wrapped tool provides the name, description and
+// schema in both modes but is only invoked when recording.
+func (r *Recorder) Tool(inner interfaces.Tool) *Tool {
+	return &Tool{rec: r, inner: inner}
+}
+
+// Tools wraps every tool in the slice
+func (r *Recorder) Tools(tools []interfaces.Tool) []interfaces.Tool {
+	wrapped := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = r.Tool(tool)
+	}
+	return wrapped
+}
+
+// Name returns the wrapped tool name
+func (t *Tool) Name() string { return t.inner.Name() }
+
+// Description returns the wrapped tool description
+func (t *Tool) Description() string { return t.inner.Description() }
+
+// ArgsSchema returns the wrapped tool schema
+func (t *Tool) ArgsSchema() string { return t.inner.ArgsSchema() }
+
+// Invoke records or replays the tool call, matching on tool name and arguments
+func (t *Tool) Invoke(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	request := toolRequest{}
+	if input != nil {
+		request.Args = input.Args
+	}
+	return roundTrip(t.rec, KindTool, t.inner.Name(), request, true, func() (*interfaces.ToolOutput, error) {
+		return t.inner.Invoke(ctx, input)
+	})
+}
+
+// Embedder is a retrieval.Embedder that records or replays the wrapped embedder
+type Embedder struct {
+	rec   *Recorder
+	inner retrieval.Embedder
+}
+
+// Embedder wraps a retrieval embedder. inner may be nil in replay mode.
+func (r *Recorder) Embedder(inner retrieval.Embedder) *Embedder {
+	return &Embedder{rec: r, inner: inner}
+}
+
+// embedRequest is the recorded form of an embedding call
+type embedRequest struct {
+	Texts []string `json:"texts,omitempty"`
+	Text  string   `json:"text,omitempty"`
+}
+
+// Embed records or replays a batch embedding
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := roundTrip(e.rec, KindEmbed, "retrieval", embedRequest{Texts: texts}, e.inner != nil, func() (*[][]float32, error) {
+		vectors, err := e.inner.Embed(ctx, texts)
+		return &vectors, err
+	})
+	if vectors == nil {
+		return nil, err
+	}
+	return *vectors, err
+}
+
+// EmbedQuery records or replays a query embedding
+func (e *Embedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	vector, err := roundTrip(e.rec, KindEmbedQuery, "retrieval", embedRequest{Text: query}, e.inner != nil, func() (*[]float32, error) {
+		vector, err := e.inner.EmbedQuery(ctx, query)
+		return &vector, err
+	})
+	if vector == nil {
+		return nil, err
+	}
+	return *vector, err
+}
+
+// Dimensions returns the wrapped embedder's dimensions, or the length of the
+// first recorded vector when replaying without one
+func (e *Embedder) Dimensions() int {
+	if e.inner != nil {
+		return e.inner.Dimensions()
+	}
+	return e.rec.recordedDimensions("retrieval")
+}
+
+// MemoryEmbedder is a memory.Embedder that records or replays the wrapped embedder
+type MemoryEmbedder struct {
+	rec   *Recorder
+	inner memory.Embedder
+}
+
+// MemoryEmbedder wraps a memory embedder. inner may be nil in replay mode.
+func (r *Recorder) MemoryEmbedder(inner memory.Embedder) *MemoryEmbedder {
+	return &MemoryEmbedder{rec: r, inner: inner}
+}
+
+// Embed records or replays a single embedding
+func (e *MemoryEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	vector, err := roundTrip(e.rec, KindEmbedQuery, "memory", embedRequest{Text: text}, e.inner != nil, func() (*[]float64, error) {
+		vector, err := e.inner.Embed(ctx, text)
+		return &vector, err
+	})
+	if vector == nil {
+		return nil, err
+	}
+	return *vector, err
+}
+
+// EmbedBatch records or replays a batch embedding
+func (e *MemoryEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, err := roundTrip(e.rec, KindEmbed, "memory", embedRequest{Texts: texts}, e.inner != nil, func() (*[][]float64, error) {
+		vectors, err := e.inner.EmbedBatch(ctx, texts)
+		return &vectors, err
+	})
+	if vectors == nil {
+		return nil, err
+	}
+	return *vectors, err
+}
+
+// recordedDimensions returns the length of the first recorded query vector
+func (r *Recorder) recordedDimensions(target string) int {
+	for _, ix := range r.Interactions() {
+		if ix.Target != target || ix.Response == nil {
+			continue
+		}
+		switch ix.Kind {
+		case KindEmbedQuery:
+			var vector []float64
+			if decode(ix, &vector) == nil {
+				return len(vector)
+			}
+		case KindEmbed:
+			var vectors [][]float64
+			if decode(ix, &vectors) == nil && len(vectors) > 0 {
+				return len(vectors[0])
+			}
+		}
+	}
+	return 0
+}
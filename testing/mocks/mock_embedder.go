@@ -0,0 +1,126 @@
+package mocks
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// KeywordEmbedder embeds text as the number of occurrences of fixed
+// keywords, so similarity follows keyword overlap deterministically. It
+// implements retrieval.Embedder; MemoryEmbedder adapts it to memory.Embedder.
+type KeywordEmbedder struct {
+	mu       sync.Mutex
+	keywords []string
+	err      error
+	calls    int
+}
+
+// NewKeywordEmbedder creates an embedder over the keywords, matched case
+// insensitively
+func NewKeywordEmbedder(keywords ...string) *KeywordEmbedder {
+	return &KeywordEmbedder{keywords: keywords}
+}
+
+// SetError makes every call fail with err
+func (e *KeywordEmbedder) SetError(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+}
+
+// Calls returns the number of Embed and EmbedQuery calls
+func (e *KeywordEmbedder) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+// Vector returns the embedding of text. Texts without any keyword embed to
+// the last dimension instead of the zero vector, so they are orthogonal to
+// texts with keywords.
+func (e *KeywordEmbedder) Vector(text string) []float32 {
+	text = strings.ToLower(text)
+	vector := make([]float32, len(e.keywords)+1)
+	matched := false
+	for i, keyword := range e.keywords {
+		vector[i] = float32(strings.Count(text, strings.ToLower(keyword)))
+		matched = matched || vector[i] > 0
+	}
+	if !matched {
+		vector[len(e.keywords)] = 1
+	}
+	return vector
+}
+
+// Embed embeds the texts
+func (e *KeywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.call(); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.Vector(text)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds a query
+func (e *KeywordEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if err := e.call(); err != nil {
+		return nil, err
+	}
+	return e.Vector(text), nil
+}
+
+// Dimensions returns the number of keywords plus one
+func (e *KeywordEmbedder) Dimensions() int {
+	return len(e.keywords) + 1
+}
+
+// MemoryEmbedder returns the embedder in the float64 shape of memory.Embedder
+func (e *KeywordEmbedder) MemoryEmbedder() *KeywordMemoryEmbedder {
+	return &KeywordMemoryEmbedder{embedder: e}
+}
+
+func (e *KeywordEmbedder) call() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	return e.err
+}
+
+// KeywordMemoryEmbedder is a KeywordEmbedder implementing memory.Embedder
+type KeywordMemoryEmbedder struct {
+	embedder *KeywordEmbedder
+}
+
+// Embed embeds a text
+func (e *KeywordMemoryEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	vector, err := e.embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return toFloat64(vector), nil
+}
+
+// EmbedBatch embeds the texts
+func (e *KeywordMemoryEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, err := e.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]float64, len(vectors))
+	for i, vector := range vectors {
+		result[i] = toFloat64(vector)
+	}
+	return result, nil
+}
+
+func toFloat64(vector []float32) []float64 {
+	result := make([]float64, len(vector))
+	for i, v := range vector {
+		result[i] = float64(v)
+	}
+	return result
+}
@@ -20,15 +20,32 @@ type MockLLMClient struct {
 	requestHistory    []llm.CompletionRequest
 	functionCallsMode bool
 	functionResponses map[string]interface{}
+	responder         func(prompt string) string
+}
+
+// MockLLMClientOption configures a MockLLMClient
+type MockLLMClientOption func(*MockLLMClient)
+
+// WithResponder makes every completion answer with respond applied to the
+// content of the last message, taking precedence over predefined responses.
+// respond may be called concurrently.
+func WithResponder(respond func(prompt string) string) MockLLMClientOption {
+	return func(m *MockLLMClient) {
+		m.responder = respond
+	}
 }
 
 // NewMockLLMClient creates a new mock LLM client
-func NewMockLLMClient() *MockLLMClient {
-	return &MockLLMClient{
+func NewMockLLMClient(opts ...MockLLMClientOption) *MockLLMClient {
+	m := &MockLLMClient{
 		responses:         []llm.CompletionResponse{},
 		functionResponses: make(map[string]interface{}),
 		requestHistory:    []llm.CompletionRequest{},
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // SetResponses sets the predefined responses to return
@@ -95,6 +112,17 @@ func (m *MockLLMClient) Complete(ctx context.Context, req *llm.CompletionRequest
 		}
 	}
 
+	if respond := m.responder; respond != nil {
+		// respond runs unlocked so that concurrent calls do not serialize
+		m.mu.Unlock()
+		content := respond(lastContent(req.Messages))
+		m.mu.Lock()
+		return &llm.CompletionResponse{
+			Content: content,
+			Model:   "mock-model",
+		}, nil
+	}
+
 	// Return predefined response if available
 	if m.currentIndex < len(m.responses) {
 		response := m.responses[m.currentIndex]
@@ -134,7 +162,18 @@ func (m *MockLLMClient) GetRequestHistory() []llm.CompletionRequest {
 	return m.requestHistory
 }
 
-// Reset resets the mock client state
+// GetPrompts returns the content of the last message of each request made
+func (m *MockLLMClient) GetPrompts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prompts := make([]string, len(m.requestHistory))
+	for i, req := range m.requestHistory {
+		prompts[i] = lastContent(req.Messages)
+	}
+	return prompts
+}
+
+// Reset resets the mock client state; the responder is kept
 func (m *MockLLMClient) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -147,6 +186,14 @@ func (m *MockLLMClient) Reset() {
 	m.functionResponses = make(map[string]interface{})
 }
 
+// lastContent returns the content of the last message
+func lastContent(messages []llm.Message) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1].Content
+}
+
 // MockStreamingLLMClient provides streaming capabilities
 type MockStreamingLLMClient struct {
 	*MockLLMClient
@@ -8,8 +8,10 @@ import (
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/tools/compute"
 	"github.com/kart-io/goagent/tools/http"
+	"github.com/kart-io/goagent/tools/practical"
 	"github.com/kart-io/goagent/tools/search"
 	"github.com/kart-io/goagent/tools/shell"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // Toolkit 工具集接口
@@ -150,8 +152,32 @@ type DevelopmentToolkit struct {
 	*BaseToolkit
 }
 
+// DevelopmentOption 开发工具集选项
+type DevelopmentOption func(*developmentConfig)
+
+// developmentConfig 开发工具集配置
+type developmentConfig struct {
+	workspace *vfs.FS
+}
+
+// WithWorkspace 设置工作区
+//
+// 添加限制在 fsys 内的文件操作工具，并将 Shell 命令的工作目录限制在 fsys 内。
+// fsys 的配额、dry run 模式和 Journal 对文件操作工具生效，
+// 因此一次运行中的所有文件修改都可以统一审查、提交或回滚
+func WithWorkspace(fsys *vfs.FS) DevelopmentOption {
+	return func(c *developmentConfig) {
+		c.workspace = fsys
+	}
+}
+
 // NewDevelopmentToolkit 创建开发工具集
-func NewDevelopmentToolkit() *DevelopmentToolkit {
+func NewDevelopmentToolkit(opts ...DevelopmentOption) *DevelopmentToolkit {
+	config := &developmentConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	// 安全的命令白名单
 	safeCommands := []string{
 		"ls", "pwd", "echo", "cat", "grep", "find",
@@ -159,12 +185,18 @@ func NewDevelopmentToolkit() *DevelopmentToolkit {
 		"uname", "hostname", "whoami", "date",
 	}
 
+	shellTool := shell.NewShellTool(safeCommands, 0)
 	toolList := []interfaces.Tool{
-		shell.NewShellTool(safeCommands, 0),
+		shellTool,
 		http.NewAPITool("", 0, nil),
 		compute.NewCalculatorTool(),
 	}
 
+	if config.workspace != nil {
+		shellTool.WithFS(config.workspace)
+		toolList = append(toolList, practical.NewFileOperationsTool("").WithFS(config.workspace))
+	}
+
 	return &DevelopmentToolkit{
 		BaseToolkit: NewBaseToolkit(toolList...),
 	}
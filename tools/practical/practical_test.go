@@ -4,15 +4,20 @@ package practical
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/secrets"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // API Caller Tests
@@ -588,6 +593,95 @@ func TestFileOperationsTool_UnsupportedOperation(t *testing.T) {
 	}
 }
 
+// TestFileOperationsTool_Jailed 测试在工作区沙箱中执行文件操作
+func TestFileOperationsTool_Jailed(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsys, err := vfs.New(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error creating jail, got: %v", err)
+	}
+	defer func() { _ = fsys.Close() }()
+	tool := NewFileOperationsTool("").WithFS(fsys)
+	ctx := context.Background()
+
+	// Absolute paths resolve inside the jail
+	_, err = tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"operation": "write",
+			"path":      "/notes/todo.txt",
+			"content":   "ship it",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error writing file, got: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(fsys.Dir(), "notes", "todo.txt"))
+	if err != nil || string(data) != "ship it" {
+		t.Errorf("Expected file inside jail, got: %q, %v", data, err)
+	}
+
+	// Escaping the jail is rejected
+	_, err = tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"operation": "write",
+			"path":      "../escape.txt",
+			"content":   "x",
+		},
+	})
+	if !agentErrors.IsCode(err, agentErrors.CodeFSPathEscape) {
+		t.Errorf("Expected path escape error, got: %v", err)
+	}
+
+	// Archives round-trip within the jail
+	for _, args := range []map[string]interface{}{
+		{"operation": "compress", "path": "notes", "options": map[string]interface{}{"compression": "zip"}},
+		{"operation": "decompress", "path": "notes.zip", "destination": "restored"},
+	} {
+		if _, err := tool.Execute(ctx, &interfaces.ToolInput{Args: args}); err != nil {
+			t.Fatalf("Expected no error for %v, got: %v", args["operation"], err)
+		}
+	}
+	data, err = os.ReadFile(filepath.Join(fsys.Dir(), "restored", "todo.txt"))
+	if err != nil || string(data) != "ship it" {
+		t.Errorf("Expected extracted file, got: %q, %v", data, err)
+	}
+}
+
+// TestFileOperationsTool_DryRun 测试 dry run 模式返回差异而不写入
+func TestFileOperationsTool_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	tool := NewFileOperationsTool(tmpDir)
+	ctx := context.Background()
+
+	testFile := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(testFile, []byte("debug: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"operation": "write",
+			"path":      testFile,
+			"content":   "debug: true\n",
+			"options":   map[string]interface{}{"dry_run": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	info := output.Result.(map[string]interface{})["info"].(map[string]interface{})
+	diff, _ := info["diff"].(string)
+	if !strings.Contains(diff, "-debug: false\n+debug: true\n") {
+		t.Errorf("Expected unified diff, got: %q", diff)
+	}
+
+	data, _ := os.ReadFile(testFile)
+	if string(data) != "debug: false\n" {
+		t.Errorf("Expected file to be unchanged, got: %q", data)
+	}
+}
+
 // TestAPICallerTool_Retry 测试重试机制
 func TestAPICallerTool_Retry(t *testing.T) {
 	attempts := 0
@@ -664,6 +758,134 @@ func TestAPICallerTool_Authentication_Bearer(t *testing.T) {
 	}
 }
 
+// TestAPICallerTool_SecretHandles 测试密钥句柄在请求时解析并在输出中屏蔽
+func TestAPICallerTool_SecretHandles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer live-token-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"token":"live-token-123"}`))
+	}))
+	defer server.Close()
+
+	resolver := secrets.NewResolver(secrets.Map{"api": "live-token-123", "other": "other-token"},
+		secrets.WithMasker(secrets.NewMasker()),
+		secrets.WithAllowedHosts("api", "127.0.0.1"),
+		secrets.WithAllowedHosts("missing", "127.0.0.1"),
+		secrets.WithAllowedHosts("other", "api.example.com"))
+	tool := NewAPICallerTool().WithSecrets(resolver)
+	ctx := context.Background()
+
+	output, err := tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"url": server.URL,
+			"auth": map[string]interface{}{
+				"type": "bearer",
+				"credentials": map[string]interface{}{
+					"token": "{{secret:api}}",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	result := output.Result.(map[string]interface{})
+	if result["status_code"] != 200 {
+		t.Errorf("Expected status 200, got: %v", result["status_code"])
+	}
+	if text := fmt.Sprint(result); strings.Contains(text, "live-token-123") || !strings.Contains(text, "{{secret:api}}") {
+		t.Errorf("Expected the token to be masked, got: %s", text)
+	}
+
+	_, err = tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"url":     server.URL,
+			"headers": map[string]interface{}{"Authorization": "{{secret:missing}}"},
+		},
+	})
+	if err == nil {
+		t.Error("Expected an error for an unknown secret")
+	}
+
+	// 未绑定到目标主机的密钥、以及请求头以外位置的句柄都会被拒绝
+	denied := []map[string]interface{}{
+		{"url": server.URL, "headers": map[string]interface{}{"Authorization": "{{secret:other}}"}},
+		{"url": server.URL, "params": map[string]interface{}{"leak": "{{secret:api}}"}},
+		{"url": server.URL, "body": map[string]interface{}{"leak": "{{secret:api}}"}},
+		{"url": server.URL + "/{{secret:api}}"},
+		{"url": server.URL, "auth": map[string]interface{}{
+			"type":        "api_key",
+			"credentials": map[string]interface{}{"key": "{{secret:api}}", "location": "query"},
+		}},
+	}
+	for _, args := range denied {
+		_, err := tool.Execute(ctx, &interfaces.ToolInput{Args: args})
+		if !agentErrors.IsCode(err, agentErrors.CodeSecretDenied) {
+			t.Errorf("Expected a denied secret for %v, got: %v", args, err)
+		}
+	}
+}
+
+// TestAPICallerTool_SecretRedirect 测试密钥不会随重定向发送到未绑定的主机
+func TestAPICallerTool_SecretRedirect(t *testing.T) {
+	var leaked atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "" {
+			leaked.Store(true)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	// 同一服务器通过 localhost 访问时主机名不同
+	external := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/external":
+			http.Redirect(w, r, external+"/collect", http.StatusFound)
+		case "/internal":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	resolver := secrets.NewResolver(secrets.Map{"key": "key-xyz789"},
+		secrets.WithMasker(secrets.NewMasker()),
+		secrets.WithAllowedHosts("key", "127.0.0.1"))
+	tool := NewAPICallerTool().WithSecrets(resolver)
+	ctx := context.Background()
+	headers := map[string]interface{}{"X-Api-Key": "{{secret:key}}"}
+
+	if _, err := tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{"url": server.URL + "/internal", "headers": headers},
+	}); err != nil {
+		t.Fatalf("Expected a redirect to a bound host to succeed, got: %v", err)
+	}
+
+	_, err := tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{"url": server.URL + "/external", "headers": headers},
+	})
+	if err == nil {
+		t.Error("Expected a redirect to an unbound host to fail")
+	}
+	if leaked.Load() {
+		t.Error("Expected the secret not to follow the redirect")
+	}
+
+	// 未引用密钥的请求照常跟随重定向
+	if _, err := tool.Execute(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{"url": server.URL + "/external"},
+	}); err != nil {
+		t.Errorf("Expected a redirect without secrets to succeed, got: %v", err)
+	}
+}
+
 // TestNewAPICallerRuntimeTool 测试创建 API 调用运行时工具
 func TestNewAPICallerRuntimeTool(t *testing.T) {
 	tool := NewAPICallerRuntimeTool()
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
 )
 
 // ShardedToolCache 分片工具缓存
@@ -223,6 +224,7 @@ func (c *ShardedToolCache) Get(ctx context.Context, key string) (*ToolOutput, bo
 func (c *ShardedToolCache) Set(ctx context.Context, key string, output *ToolOutput, ttl time.Duration) error {
 	shard := c.getShard(key)
 	toolName := extractToolNameFromKey(key)
+	subjectID, _ := interfaces.SubjectFromContext(ctx)
 
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
@@ -232,6 +234,7 @@ func (c *ShardedToolCache) Set(ctx context.Context, key string, output *ToolOutp
 		entry.output = output
 		entry.expireTime = time.Now().Add(ttl)
 		entry.toolName = toolName
+		entry.subjectID = subjectID
 		shard.lruList.MoveToFront(entry.element)
 		return nil
 	}
@@ -245,6 +248,7 @@ func (c *ShardedToolCache) Set(ctx context.Context, key string, output *ToolOutp
 	entry := &cacheEntry{
 		key:        key,
 		toolName:   toolName,
+		subjectID:  subjectID,
 		output:     output,
 		expireTime: time.Now().Add(ttl),
 		version:    0,
@@ -371,6 +375,26 @@ func (c *ShardedToolCache) InvalidateByTool(ctx context.Context, toolName string
 	return totalCount, nil
 }
 
+// ForgetSubject 删除数据主体在所有分片中的缓存
+func (c *ShardedToolCache) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	totalCount := 0
+
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		for key, entry := range shard.cache {
+			if entry.subjectID == subjectID {
+				shard.lruList.Remove(entry.element)
+				delete(shard.cache, key)
+				totalCount++
+			}
+		}
+		shard.mu.Unlock()
+	}
+
+	c.stats.recordInvalidation(int64(totalCount))
+	return totalCount, nil
+}
+
 // invalidateDependents 失效依赖指定工具的所有工具
 func (c *ShardedToolCache) invalidateDependents(toolName string) int {
 	c.depMu.RLock()
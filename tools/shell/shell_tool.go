@@ -2,24 +2,29 @@ package shell
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"os/exec"
+	"os"
 	"strings"
 	"time"
 
 	agentErrors "github.com/kart-io/goagent/errors"
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/sandbox"
 	"github.com/kart-io/goagent/tools"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // ShellTool Shell 命令执行工具
 //
-// 提供安全的 shell 命令执行能力，支持命令白名单
+// 提供安全的 shell 命令执行能力，支持命令白名单。
+// 命令在沙箱中执行，默认使用禁止网络访问的本地沙箱（资源限制、命名空间、seccomp），
+// 且不继承宿主机的敏感环境变量
 type ShellTool struct {
 	*tools.BaseTool
 	allowedCommands map[string]bool // 命令白名单
 	timeout         time.Duration   // 默认超时时间
+	sandbox         sandbox.Sandbox // 执行沙箱
+	fs              *vfs.FS         // 工作区
 }
 
 // NewShellTool 创建 Shell 工具
@@ -40,6 +45,9 @@ func NewShellTool(allowedCommands []string, timeout time.Duration) *ShellTool {
 	tool := &ShellTool{
 		allowedCommands: whitelist,
 		timeout:         timeout,
+		sandbox: sandbox.NewLocal(
+			sandbox.WithLimits(sandbox.Limits{WallTime: timeout}),
+		),
 	}
 
 	tool.BaseTool = tools.NewBaseTool(
@@ -64,6 +72,10 @@ func NewShellTool(allowedCommands []string, timeout time.Duration) *ShellTool {
 				"timeout": {
 					"type": "integer",
 					"description": "Timeout in seconds (optional, default: 30)"
+				},
+				"dry_run": {
+					"type": "boolean",
+					"description": "Return the command that would run without executing it (optional)"
 				}
 			},
 			"required": ["command"]
@@ -119,10 +131,6 @@ func (s *ShellTool) run(ctx context.Context, input *interfaces.ToolInput) (*inte
 		timeout = time.Duration(timeoutSec) * time.Second
 	}
 
-	// 应用超时
-	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
 	// Validate command to prevent shell injection
 	if strings.Contains(command, ";") || strings.Contains(command, "|") ||
 		strings.Contains(command, "&") || strings.Contains(command, "`") ||
@@ -142,26 +150,63 @@ func (s *ShellTool) run(ctx context.Context, input *interfaces.ToolInput) (*inte
 				WithContext("command", command))
 	}
 
-	// 构建命令
-	cmd := exec.CommandContext(cmdCtx, command, args...)
-	if workDir != "" {
-		cmd.Dir = workDir
+	// 设置工作区时工作目录限制在工作区内，否则未指定工作目录时在当前目录执行
+	dir := workDir
+	if s.fs != nil {
+		if dir == "" {
+			dir = "."
+		}
+		hostDir, err := s.fs.HostPath(dir)
+		if err != nil {
+			return &interfaces.ToolOutput{
+				Success: false,
+				Error:   err.Error(),
+				Metadata: map[string]interface{}{
+					"work_dir": workDir,
+				},
+			}, tools.NewToolError(s.Name(), "invalid work_dir", err)
+		}
+		dir = hostDir
+	} else if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	// dry run 模式只返回将要执行的命令
+	if dryRun, _ := input.Args["dry_run"].(bool); dryRun || (s.fs != nil && s.fs.DryRun()) {
+		return &interfaces.ToolOutput{
+			Result: map[string]interface{}{
+				"command": command,
+				"args":    args,
+				"dry_run": true,
+			},
+			Success: true,
+			Metadata: map[string]interface{}{
+				"work_dir": workDir,
+				"timeout":  timeout.String(),
+			},
+		}, nil
 	}
 
-	// 执行命令
+	// 在沙箱中执行命令
 	startTime := time.Now()
-	output, err := cmd.CombinedOutput()
+	res, err := s.sandbox.Run(ctx, &sandbox.Request{
+		Command: command,
+		Args:    args,
+		Dir:     dir,
+		Limits:  &sandbox.Limits{WallTime: timeout},
+	})
 	duration := time.Since(startTime)
-
-	exitCode := 0
-	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			exitCode = exitErr.ExitCode()
-		}
+	if s.fs != nil {
+		// 命令可能修改了工作区，重新计算配额
+		s.fs.Invalidate()
 	}
 
-	outputStr := string(output)
+	exitCode := -1
+	outputStr := ""
+	if res != nil {
+		exitCode = res.ExitCode
+		outputStr = res.Stdout + res.Stderr
+	}
 	success := err == nil && exitCode == 0
 
 	result := map[string]interface{}{
@@ -174,7 +219,7 @@ func (s *ShellTool) run(ctx context.Context, input *interfaces.ToolInput) (*inte
 
 	if !success {
 		errorMsg := fmt.Sprintf("command failed with exit code %d", exitCode)
-		if err != nil && exitCode == 0 {
+		if err != nil {
 			errorMsg = err.Error()
 		}
 
@@ -199,6 +244,25 @@ func (s *ShellTool) run(ctx context.Context, input *interfaces.ToolInput) (*inte
 	}, nil
 }
 
+// WithSandbox 设置执行沙箱，例如使用 sandbox.NewContainer 在容器中执行命令
+//
+// 需要网络访问的命令须显式传入允许网络的沙箱，例如
+// sandbox.NewLocal(sandbox.WithNetwork(true))
+func (s *ShellTool) WithSandbox(sb sandbox.Sandbox) *ShellTool {
+	if sb != nil {
+		s.sandbox = sb
+	}
+	return s
+}
+
+// WithFS 设置工作区，命令的工作目录将限制在 fsys 内（默认为工作区根目录）
+//
+// 命令对文件的修改不经过 vfs，不会记录到 vfs.Journal 中
+func (s *ShellTool) WithFS(fsys *vfs.FS) *ShellTool {
+	s.fs = fsys
+	return s
+}
+
 // GetAllowedCommands 获取允许的命令列表
 func (s *ShellTool) GetAllowedCommands() []string {
 	commands := make([]string, 0, len(s.allowedCommands))
@@ -240,6 +304,8 @@ func (s *ShellTool) ExecutePipeline(ctx context.Context, commands []string) (*in
 type ShellToolBuilder struct {
 	allowedCommands []string
 	timeout         time.Duration
+	sandbox         sandbox.Sandbox
+	fs              *vfs.FS
 }
 
 // NewShellToolBuilder 创建 Shell 工具构建器
@@ -262,9 +328,21 @@ func (b *ShellToolBuilder) WithTimeout(timeout time.Duration) *ShellToolBuilder
 	return b
 }
 
+// WithSandbox 设置执行沙箱
+func (b *ShellToolBuilder) WithSandbox(sb sandbox.Sandbox) *ShellToolBuilder {
+	b.sandbox = sb
+	return b
+}
+
+// WithFS 设置工作区
+func (b *ShellToolBuilder) WithFS(fsys *vfs.FS) *ShellToolBuilder {
+	b.fs = fsys
+	return b
+}
+
 // Build 构建工具
 func (b *ShellToolBuilder) Build() *ShellTool {
-	return NewShellTool(b.allowedCommands, b.timeout)
+	return NewShellTool(b.allowedCommands, b.timeout).WithSandbox(b.sandbox).WithFS(b.fs)
 }
 
 // CommonShellTools 创建常用的 Shell 工具集合
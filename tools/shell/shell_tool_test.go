@@ -4,12 +4,15 @@ package shell
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/kart-io/goagent/interfaces"
+	"github.com/kart-io/goagent/vfs"
 )
 
 // TestNewShellTool 测试创建 Shell 工具
@@ -241,6 +244,66 @@ func TestShellTool_Run_WithWorkDir(t *testing.T) {
 	}
 }
 
+// TestShellTool_Run_WithFS 测试在工作区内执行命令
+func TestShellTool_Run_WithFS(t *testing.T) {
+	// Skip on Windows due to path differences
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows")
+	}
+
+	fsys, err := vfs.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error creating workspace, got: %v", err)
+	}
+	defer func() { _ = fsys.Close() }()
+	if err := os.Mkdir(filepath.Join(fsys.Dir(), "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewShellToolBuilder().WithAllowedCommands("pwd").WithFS(fsys).Build()
+	ctx := context.Background()
+
+	// 工作目录相对于工作区解析
+	output, err := tool.Invoke(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"command":  "pwd",
+			"work_dir": "/src",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	outputStr := output.Result.(map[string]interface{})["output"].(string)
+	if strings.TrimSpace(outputStr) != filepath.Join(fsys.Dir(), "src") {
+		t.Errorf("Expected command to run in workspace, got: %s", outputStr)
+	}
+
+	// 不能逃逸出工作区
+	_, err = tool.Invoke(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"command":  "pwd",
+			"work_dir": "../..",
+		},
+	})
+	if err == nil {
+		t.Error("Expected error for work_dir outside the workspace")
+	}
+
+	// dry run 不执行命令
+	output, err = tool.Invoke(ctx, &interfaces.ToolInput{
+		Args: map[string]interface{}{
+			"command": "pwd",
+			"dry_run": true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if output.Result.(map[string]interface{})["dry_run"] != true {
+		t.Errorf("Expected dry run result, got: %v", output.Result)
+	}
+}
+
 // TestShellTool_Run_WithTimeout 测试超时控制
 func TestShellTool_Run_WithTimeout(t *testing.T) {
 	tool := NewShellTool([]string{"sleep"}, 30*time.Second)
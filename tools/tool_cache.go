@@ -17,6 +17,7 @@ import (
 	"time"
 
 	agentErrors "github.com/kart-io/goagent/errors"
+	"github.com/kart-io/goagent/interfaces"
 )
 
 // ToolCache 工具缓存接口
@@ -82,6 +83,7 @@ type MemoryToolCache struct {
 type cacheEntry struct {
 	key        string
 	toolName   string // 工具名称，用于按工具失效
+	subjectID  string // 数据主体，用于按主体删除
 	output     *ToolOutput
 	expireTime time.Time
 	element    *list.Element
@@ -188,6 +190,7 @@ func (c *MemoryToolCache) Set(ctx context.Context, key string, output *ToolOutpu
 
 	// 从缓存键中提取工具名称（格式为 "toolName:hash"）
 	toolName := extractToolNameFromKey(key)
+	subjectID, _ := interfaces.SubjectFromContext(ctx)
 	currentVersion := c.version.Load()
 
 	// 如果已存在，更新
@@ -196,6 +199,7 @@ func (c *MemoryToolCache) Set(ctx context.Context, key string, output *ToolOutpu
 		entry.expireTime = time.Now().Add(ttl)
 		entry.version = currentVersion
 		entry.toolName = toolName
+		entry.subjectID = subjectID
 		c.lruList.MoveToFront(entry.element)
 		return nil
 	}
@@ -209,6 +213,7 @@ func (c *MemoryToolCache) Set(ctx context.Context, key string, output *ToolOutpu
 	entry := &cacheEntry{
 		key:        key,
 		toolName:   toolName,
+		subjectID:  subjectID,
 		output:     output,
 		expireTime: time.Now().Add(ttl),
 		version:    currentVersion,
@@ -357,6 +362,25 @@ func (c *MemoryToolCache) InvalidateByTool(ctx context.Context, toolName string)
 	return totalInvalidated, nil
 }
 
+// ForgetSubject 删除数据主体的所有缓存
+//
+// 缓存条目在 Set 时根据 interfaces.WithSubject 记录数据主体。
+func (c *MemoryToolCache) ForgetSubject(ctx context.Context, subjectID string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for _, entry := range c.cache {
+		if entry.subjectID == subjectID {
+			c.removeEntry(entry)
+			count++
+		}
+	}
+
+	c.stats.recordInvalidation(int64(count))
+	return count, nil
+}
+
 // invalidateDependents 失效依赖指定工具的所有工具（内部方法，不加锁）
 //
 // 递归失效所有直接和间接依赖的工具。
@@ -622,6 +646,11 @@ func (c *CachedTool) Invoke(ctx context.Context, input *ToolInput) (*ToolOutput,
 		return c.tool.Invoke(ctx, input)
 	}
 
+	// 不同数据主体的结果互相隔离
+	if subjectID, ok := interfaces.SubjectFromContext(ctx); ok {
+		cacheKey += "@" + subjectID
+	}
+
 	// 尝试从缓存获取
 	if output, found := c.cache.Get(ctx, cacheKey); found {
 		return output, nil
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/kart-io/goagent/interfaces"
 )
 
 // TestInvalidateByPattern tests pattern-based cache invalidation
@@ -560,3 +562,65 @@ func BenchmarkInvalidateByTool(b *testing.B) {
 		}
 	}
 }
+
+// TestForgetSubject tests removing the cache entries of a data subject
+func TestForgetSubject(t *testing.T) {
+	memoryCache := NewMemoryToolCache(MemoryCacheConfig{
+		Capacity:        100,
+		DefaultTTL:      5 * time.Minute,
+		CleanupInterval: 10 * time.Minute,
+	})
+	defer memoryCache.Close()
+	shardedCache := NewShardedToolCache(ShardedCacheConfig{
+		ShardCount:      4,
+		Capacity:        100,
+		DefaultTTL:      5 * time.Minute,
+		CleanupInterval: 10 * time.Minute,
+	})
+	defer shardedCache.Close()
+
+	caches := map[string]interface {
+		ToolCache
+		ForgetSubject(ctx context.Context, subjectID string) (int, error)
+	}{
+		"memory":  memoryCache,
+		"sharded": shardedCache,
+	}
+
+	for name, cache := range caches {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			tool := NewBaseTool("profile_tool", "Profile lookup", `{}`, func(ctx context.Context, input *ToolInput) (*ToolOutput, error) {
+				calls++
+				subjectID, _ := interfaces.SubjectFromContext(ctx)
+				return &ToolOutput{Result: "profile of " + subjectID, Success: true}, nil
+			})
+			cachedTool := NewCachedTool(tool, cache, 5*time.Minute)
+			input := &ToolInput{Args: map[string]interface{}{"field": "email"}}
+
+			alice := interfaces.WithSubject(context.Background(), "alice")
+			bob := interfaces.WithSubject(context.Background(), "bob")
+
+			_, _ = cachedTool.Invoke(alice, input)
+			output, _ := cachedTool.Invoke(bob, input)
+			if output.Result != "profile of bob" {
+				t.Fatalf("Expected results isolated per subject, got %v", output.Result)
+			}
+			_, _ = cachedTool.Invoke(alice, input)
+			if calls != 2 {
+				t.Fatalf("Expected 2 tool calls, got %d", calls)
+			}
+
+			count, err := cache.ForgetSubject(context.Background(), "alice")
+			if err != nil {
+				t.Fatalf("ForgetSubject failed: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("Expected 1 entry removed, got %d", count)
+			}
+			if cache.Size() != 1 {
+				t.Errorf("Expected bob's entry to remain, got size %d", cache.Size())
+			}
+		})
+	}
+}
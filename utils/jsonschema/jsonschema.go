@@ -0,0 +1,209 @@
+// Package jsonschema 提供 JSON Schema 子集校验
+//
+// 支持 type、properties、required、additionalProperties、items、enum、const、
+// minimum、maximum、minLength、maxLength、pattern、minItems、maxItems
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+// Validate 按 JSON Schema 子集校验值,返回所有违反项,路径以 $ 表示根
+//
+// value 为 JSON 解码后的值(map[string]interface{}、[]interface{}、float64、string、bool、nil)
+func Validate(schema map[string]interface{}, value interface{}) []string {
+	return validateSchema(schema, value, "$")
+}
+
+// validateSchema 校验 path 处的值
+func validateSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	var violations []string
+	fail := func(format string, args ...interface{}) {
+		violations = append(violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if expected, ok := schema["type"]; ok && !matchesType(expected, value) {
+		fail("expected type %v, got %s", expected, jsonType(value))
+		return violations
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if Equal(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %v is not one of %v", value, enum)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !Equal(constant, value) {
+		fail("expected %v, got %v", constant, value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		violations = append(violations, validateObject(schema, v, path)...)
+	case []interface{}:
+		if min, ok := number(schema["minItems"]); ok && float64(len(v)) < min {
+			fail("expected at least %v items, got %d", min, len(v))
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(v)) > max {
+			fail("expected at most %v items, got %d", max, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				violations = append(violations, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := number(schema["minLength"]); ok && length < min {
+			fail("expected at least %v characters, got %v", min, length)
+		}
+		if max, ok := number(schema["maxLength"]); ok && length > max {
+			fail("expected at most %v characters, got %v", max, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("%q does not match pattern %q", v, pattern)
+			}
+		}
+	case float64:
+		if min, ok := number(schema["minimum"]); ok && v < min {
+			fail("%v is less than minimum %v", v, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && v > max {
+			fail("%v is greater than maximum %v", v, max)
+		}
+	}
+
+	return violations
+}
+
+// validateObject 校验对象的 required、properties 与 additionalProperties
+func validateObject(schema map[string]interface{}, object map[string]interface{}, path string) []string {
+	var violations []string
+
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, fmt.Sprintf("%s: missing required property %q", path, name))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "." + name
+		if propertySchema, ok := properties[name].(map[string]interface{}); ok {
+			violations = append(violations, validateSchema(propertySchema, object[name], propertyPath)...)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, fmt.Sprintf("%s: additional property is not allowed", propertyPath))
+			}
+		case map[string]interface{}:
+			violations = append(violations, validateSchema(additional, object[name], propertyPath)...)
+		}
+	}
+	return violations
+}
+
+// matchesType 判断值是否符合 type(字符串或字符串数组)
+func matchesType(expected interface{}, value interface{}) bool {
+	types := stringList(expected)
+	if s, ok := expected.(string); ok {
+		types = []string{s}
+	}
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType 返回 JSON 值的类型名,整数值的 number 视为 integer
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// Equal 比较两个 JSON 值,数值统一按 float64 比较
+func Equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// number 将 Go 数值转换为 float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// stringList 将 []string 或 []interface{} 转换为字符串切片
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
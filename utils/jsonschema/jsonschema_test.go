@@ -0,0 +1,43 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kart-io/goagent/utils/jsonschema"
+)
+
+func TestValidate(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []interface{}{"name", "tags"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": float64(2)},
+			"level": map[string]interface{}{"enum": []interface{}{1, 2, 3}},
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+
+	valid := map[string]interface{}{"name": "ok", "level": float64(2), "tags": []interface{}{"a"}}
+	assert.Empty(t, jsonschema.Validate(schema, valid))
+
+	invalid := map[string]interface{}{"name": "x", "level": float64(5), "tags": []interface{}{1}, "extra": true}
+	violations := jsonschema.Validate(schema, invalid)
+	assert.Len(t, violations, 4)
+	for _, v := range violations {
+		assert.Contains(t, v, "$")
+	}
+
+	assert.NotEmpty(t, jsonschema.Validate(schema, "not an object"))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, jsonschema.Equal(1, float64(1)))
+	assert.True(t, jsonschema.Equal([]interface{}{"a"}, []interface{}{"a"}))
+	assert.False(t, jsonschema.Equal(1, "1"))
+}
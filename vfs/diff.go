@@ -0,0 +1,70 @@
+package vfs
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// devNull labels the missing side of an added or deleted file
+const devNull = "/dev/null"
+
+// UnifiedDiff returns the unified diff turning before into after. A nil
+// before means the file is created, a nil after that it is deleted; binary
+// content is only reported as differing.
+func UnifiedDiff(path string, before, after []byte) string {
+	if before != nil && after != nil && bytes.Equal(before, after) {
+		return ""
+	}
+	label := strings.TrimPrefix(path, "/")
+	from, to := "a/"+label, "b/"+label
+	if before == nil {
+		from = devNull
+	}
+	if after == nil {
+		to = devNull
+	}
+	if isBinary(before) || isBinary(after) {
+		return "Binary files " + from + " and " + to + " differ\n"
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return diff
+}
+
+// renameDiff describes a rename in the style of git
+func renameDiff(from, to string) string {
+	return "rename from " + strings.TrimPrefix(from, "/") + "\nrename to " + strings.TrimPrefix(to, "/") + "\n"
+}
+
+// splitLines splits content into lines that all end in a newline
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// isBinary reports whether content looks binary (a NUL byte in the first 8KB)
+func isBinary(content []byte) bool {
+	if len(content) > 8192 {
+		content = content[:8192]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
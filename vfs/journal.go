@@ -0,0 +1,382 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Status is the net change of a file in a journal
+type Status string
+
+// File change statuses
+const (
+	StatusAdded    Status = "added"
+	StatusModified Status = "modified"
+	StatusDeleted  Status = "deleted"
+)
+
+// FileChange is the net change of one file since it was first touched
+type FileChange struct {
+	// Root is the jail directory ("" for the host file system)
+	Root   string `json:"root,omitempty"`
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+	Diff   string `json:"diff"`
+}
+
+// Journal records mutations of one or more file systems together with the
+// original state of every path they touched, so that all edits can be
+// reviewed (Pending, Diff) and then kept (Commit) or undone (Rollback).
+// Original contents are kept in memory.
+type Journal struct {
+	mu        sync.Mutex
+	changes   []Change
+	originals []*original
+	index     map[originalKey]*original
+}
+
+type entryKind int
+
+const (
+	kindAbsent entryKind = iota
+	kindFile
+	kindDir
+	kindSymlink
+)
+
+type originalKey struct {
+	state *state
+	path  string
+}
+
+// original is the state of a path before the journal first touched it
+type original struct {
+	fs   *FS
+	path string
+	kind entryKind
+	mode fs.FileMode
+	data []byte
+	// target of a symbolic link, and the content it pointed to when writes
+	// went through it
+	target   string
+	linkData []byte
+}
+
+// NewJournal creates an empty journal
+func NewJournal() *Journal {
+	return &Journal{index: make(map[originalKey]*original)}
+}
+
+// Changes returns the recorded mutations in order
+func (j *Journal) Changes() []Change {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Change(nil), j.changes...)
+}
+
+// Pending returns the net change of every file touched since the journal
+// was created or last committed, sorted by path
+func (j *Journal) Pending() ([]FileChange, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var changes []FileChange
+	seen := make(map[originalKey]bool)
+	add := func(o *original, p string, status Status, diff string) {
+		key := originalKey{o.fs.state, p}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		changes = append(changes, FileChange{Root: o.fs.dir, Path: p, Status: status, Diff: diff})
+	}
+
+	for _, o := range j.originals {
+		info, err := o.fs.lstat(o.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		exists := err == nil
+
+		switch o.kind {
+		case kindFile, kindSymlink:
+			before := o.data
+			if o.kind == kindSymlink {
+				before = []byte(o.target)
+			}
+			if !exists || info.IsDir() {
+				add(o, o.path, StatusDeleted, UnifiedDiff(o.path, before, nil))
+				if exists {
+					if err := j.addTree(o, add); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			after, err := o.fs.fileContent(o.path, info)
+			if err != nil {
+				return nil, err
+			}
+			diff := UnifiedDiff(o.path, before, after)
+			if o.linkData != nil {
+				if current, err := o.fs.readFile(o.path); err == nil {
+					diff += UnifiedDiff(o.path, o.linkData, current)
+				}
+			}
+			if diff != "" {
+				add(o, o.path, StatusModified, diff)
+			}
+		case kindAbsent, kindDir:
+			if !exists || (o.kind == kindDir && info.IsDir()) {
+				continue
+			}
+			if err := j.addTree(o, add); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(changes, func(a, b int) bool {
+		if changes[a].Root != changes[b].Root {
+			return changes[a].Root < changes[b].Root
+		}
+		return changes[a].Path < changes[b].Path
+	})
+	return changes, nil
+}
+
+// addTree reports the files at or below an originally absent path as added,
+// skipping paths with their own original
+func (j *Journal) addTree(o *original, add func(*original, string, Status, string)) error {
+	return o.fs.walk(o.path, func(p string, info fs.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		if other, ok := j.index[originalKey{o.fs.state, p}]; ok && other != o && other.kind != kindAbsent {
+			return nil
+		}
+		data, err := o.fs.fileContent(p, info)
+		if err != nil {
+			return err
+		}
+		add(o, p, StatusAdded, UnifiedDiff(p, nil, data))
+		return nil
+	})
+}
+
+// Diff returns the unified diff of all pending changes
+func (j *Journal) Diff() (string, error) {
+	changes, err := j.Pending()
+	if err != nil {
+		return "", err
+	}
+	var diff strings.Builder
+	for _, change := range changes {
+		diff.WriteString(change.Diff)
+	}
+	return diff.String(), nil
+}
+
+// Commit keeps all changes and clears the journal
+func (j *Journal) Commit() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.reset()
+}
+
+// Rollback restores every touched path to its original state and clears
+// the journal. If some paths cannot be restored the journal is kept, so that
+// the rollback can be retried.
+func (j *Journal) Rollback() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	byDepth := func(originals []*original, deepestFirst bool) []*original {
+		sorted := append([]*original(nil), originals...)
+		sort.SliceStable(sorted, func(a, b int) bool {
+			da, db := strings.Count(sorted[a].path, "/"), strings.Count(sorted[b].path, "/")
+			if deepestFirst {
+				return da > db
+			}
+			return da < db
+		})
+		return sorted
+	}
+
+	var errs []error
+	// remove what did not exist, then recreate directories top-down, then
+	// restore files and links
+	for _, o := range byDepth(j.originals, true) {
+		if o.kind == kindAbsent {
+			if err := o.fs.removeAll(o.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, o := range byDepth(j.originals, false) {
+		if err := j.restore(o); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, o := range j.originals {
+		o.fs.state.stale.Store(true)
+	}
+
+	if len(errs) > 0 {
+		return agentErrors.Wrap(errors.Join(errs...), agentErrors.CodeFSJournal, "rollback incomplete").
+			WithComponent("vfs").
+			WithOperation("rollback").
+			WithContext("failures", len(errs))
+	}
+	j.reset()
+	return nil
+}
+
+// restore puts back a file, link or directory
+func (j *Journal) restore(o *original) error {
+	if o.kind == kindAbsent {
+		return nil
+	}
+	f := o.fs
+	info, err := f.lstat(o.path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if o.kind == kindDir {
+		if exists && !info.IsDir() {
+			if err := f.remove(o.path); err != nil {
+				return err
+			}
+		}
+		if err := f.mkdirAll(o.path, o.mode.Perm()); err != nil {
+			return err
+		}
+		return f.chmod(o.path, o.mode.Perm())
+	}
+
+	if exists && (info.IsDir() || (info.Mode()&fs.ModeSymlink != 0) != (o.kind == kindSymlink)) {
+		if err := f.removeAll(o.path); err != nil {
+			return err
+		}
+		exists = false
+	}
+	if err := f.mkdirAll(f.parent(o.path), 0o755); err != nil {
+		return err
+	}
+
+	if o.kind == kindSymlink {
+		if target, err := f.readlink(o.path); err != nil || target != o.target {
+			if exists {
+				if err := f.remove(o.path); err != nil {
+					return err
+				}
+			}
+			if err := f.symlink(o.target, o.path); err != nil {
+				return err
+			}
+		}
+		if o.linkData != nil {
+			return f.writeFile(o.path, o.linkData, 0o644)
+		}
+		return nil
+	}
+
+	if err := f.writeFile(o.path, o.data, o.mode.Perm()); err != nil {
+		return err
+	}
+	return f.chmod(o.path, o.mode.Perm())
+}
+
+// record appends an applied mutation
+func (j *Journal) record(change Change) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.changes = append(j.changes, change)
+}
+
+// capture saves the original state of p, and with tree of everything below
+// it, unless already saved
+func (j *Journal) capture(f *FS, p string, tree bool) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !tree {
+		return j.captureOne(f, p, true)
+	}
+	info, err := f.lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j.captureOne(f, p, false)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return j.captureOne(f, p, false)
+	}
+	return f.walk(p, func(q string, _ fs.FileInfo) error {
+		return j.captureOne(f, q, false)
+	})
+}
+
+// captureOne saves the original state of a single path. With throughLink
+// the content behind a symbolic link is saved as well, for writes that
+// follow the link.
+func (j *Journal) captureOne(f *FS, p string, throughLink bool) error {
+	key := originalKey{f.state, p}
+	if o, ok := j.index[key]; ok {
+		if throughLink && o.kind == kindSymlink && o.linkData == nil {
+			if data, err := f.readFile(p); err == nil {
+				o.linkData = data
+			}
+		}
+		return nil
+	}
+	// paths below an originally absent directory are undone with it
+	for child, dir := p, f.parent(p); dir != child; child, dir = dir, f.parent(dir) {
+		if o, ok := j.index[originalKey{f.state, dir}]; ok && o.kind == kindAbsent {
+			return nil
+		}
+	}
+
+	o := &original{fs: f, path: p}
+	info, err := f.lstat(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		o.kind = kindAbsent
+	case err != nil:
+		return err
+	case info.IsDir():
+		o.kind, o.mode = kindDir, info.Mode()
+	case info.Mode()&fs.ModeSymlink != 0:
+		o.kind = kindSymlink
+		if o.target, err = f.readlink(p); err != nil {
+			return err
+		}
+		if throughLink {
+			if data, err := f.readFile(p); err == nil {
+				o.linkData = data
+			}
+		}
+	default:
+		o.kind, o.mode = kindFile, info.Mode()
+		if o.data, err = f.readFile(p); err != nil {
+			return err
+		}
+	}
+	j.index[key] = o
+	j.originals = append(j.originals, o)
+	return nil
+}
+
+func (j *Journal) reset() {
+	j.changes = nil
+	j.originals = nil
+	j.index = make(map[originalKey]*original)
+}
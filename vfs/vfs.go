@@ -0,0 +1,462 @@
+// Package vfs is the file system layer shared by the file and shell tools.
+// It confines paths to a jail directory, enforces file size and disk quota
+// limits, can run in a dry-run mode that returns unified diffs instead of
+// writing, and records every edit in a Journal so that the edits of a whole
+// agent run can be reviewed and then committed or rolled back.
+//
+// Jails are chroot-style: relative and absolute paths both resolve against
+// the jail directory (absolute host paths inside the jail are accepted as
+// well), ".." cannot climb out of it, and symbolic links are only followed
+// while they stay inside. Containment is enforced by os.Root, so it holds
+// even if the tree is changed concurrently.
+//
+// Usage:
+//
+//	journal := vfs.NewJournal()
+//	fsys, err := vfs.New("/workspace/repo",
+//		vfs.WithQuota(100<<20),
+//		vfs.WithJournal(journal))
+//	tool := practical.NewFileOperationsTool("").WithFS(fsys)
+//
+//	// after the run
+//	diff, _ := journal.Diff()
+//	if approved {
+//		journal.Commit()
+//	} else {
+//		err = journal.Rollback()
+//	}
+package vfs
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// Op is the kind of a file system change
+type Op string
+
+// Change operations
+const (
+	OpWrite  Op = "write"
+	OpAppend Op = "append"
+	OpMkdir  Op = "mkdir"
+	OpRemove Op = "remove"
+	OpRename Op = "rename"
+	OpCopy   Op = "copy"
+)
+
+// Change describes one mutation. In dry-run mode Diff holds the unified
+// diff the mutation would apply; Journal.Pending computes diffs for applied
+// changes.
+type Change struct {
+	Op     Op        `json:"op"`
+	Path   string    `json:"path"`
+	Dest   string    `json:"dest,omitempty"`
+	Size   int64     `json:"size,omitempty"`
+	DryRun bool      `json:"dry_run,omitempty"`
+	Diff   string    `json:"diff,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Config holds the limits and modes of a file system
+type Config struct {
+	// MaxFileSize bounds the size of files read and written (0: unlimited)
+	MaxFileSize int64
+	// Quota bounds the total size of regular files in a jail (0: unlimited)
+	Quota int64
+	// ReadOnly rejects all mutations outside dry-run mode
+	ReadOnly bool
+	// DryRun computes diffs for mutations without applying them
+	DryRun bool
+	// Journal records applied mutations
+	Journal *Journal
+}
+
+// Option configures a file system
+type Option func(*Config)
+
+// WithMaxFileSize bounds the size of files read and written
+func WithMaxFileSize(size int64) Option {
+	return func(c *Config) {
+		c.MaxFileSize = size
+	}
+}
+
+// WithQuota bounds the total size of regular files in the jail
+func WithQuota(bytes int64) Option {
+	return func(c *Config) {
+		c.Quota = bytes
+	}
+}
+
+// WithReadOnly rejects mutations
+func WithReadOnly(readOnly bool) Option {
+	return func(c *Config) {
+		c.ReadOnly = readOnly
+	}
+}
+
+// WithDryRun makes mutations return diffs instead of writing
+func WithDryRun(dryRun bool) Option {
+	return func(c *Config) {
+		c.DryRun = dryRun
+	}
+}
+
+// WithJournal records applied mutations in journal
+func WithJournal(journal *Journal) Option {
+	return func(c *Config) {
+		c.Journal = journal
+	}
+}
+
+// FS is a file system confined to a jail directory, or the unconfined host
+// file system (see Host). Methods are safe for concurrent use; mutations are
+// serialized.
+type FS struct {
+	root  *os.Root
+	dir   string
+	cfg   Config
+	state *state
+}
+
+// state is shared by an FS and its dry-run views
+type state struct {
+	mu    sync.Mutex
+	usage int64
+	stale atomic.Bool
+}
+
+// New creates a file system jailed to dir, which must exist
+func New(dir string, opts ...Option) (*FS, error) {
+	abs, err := filepath.Abs(dir)
+	if err == nil {
+		abs, err = filepath.EvalSymlinks(abs)
+	}
+	var root *os.Root
+	if err == nil {
+		root, err = os.OpenRoot(abs)
+	}
+	if err != nil {
+		return nil, agentErrors.Wrap(err, agentErrors.CodeInvalidConfig, "failed to open jail directory").
+			WithComponent("vfs").
+			WithOperation("new").
+			WithContext("dir", dir)
+	}
+
+	f := &FS{root: root, dir: abs, cfg: newConfig(opts), state: &state{}}
+	f.state.stale.Store(true)
+	return f, nil
+}
+
+// Host returns the unconfined host file system. Paths are used as given;
+// limits, dry-run and the journal apply, the quota does not.
+func Host(opts ...Option) *FS {
+	return &FS{cfg: newConfig(opts), state: &state{}}
+}
+
+func newConfig(opts []Option) Config {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Close releases the jail directory
+func (f *FS) Close() error {
+	if f.root == nil {
+		return nil
+	}
+	return f.root.Close()
+}
+
+// Dir returns the jail directory, or "" for the host file system
+func (f *FS) Dir() string { return f.dir }
+
+// Jailed reports whether the file system is confined to a directory
+func (f *FS) Jailed() bool { return f.root != nil }
+
+// DryRun reports whether mutations are only previewed
+func (f *FS) DryRun() bool { return f.cfg.DryRun }
+
+// Journal returns the journal recording mutations, if any
+func (f *FS) Journal() *Journal { return f.cfg.Journal }
+
+// Invalidate tells the file system that the jail was changed from outside,
+// for example by a shell command, so that quota usage is recomputed before
+// the next write. Such changes are not journaled.
+func (f *FS) Invalidate() { f.state.stale.Store(true) }
+
+// WithDryRun returns a view of the file system with dry-run mode switched on
+// or off. The view shares the jail, limits and journal.
+func (f *FS) WithDryRun(dryRun bool) *FS {
+	view := *f
+	view.cfg.DryRun = dryRun
+	return &view
+}
+
+// Path resolves name to its path in the file system: a slash-separated
+// path relative to the jail ("." for the jail itself), or the cleaned host
+// path. Names climbing out of the jail fail with CodeFSPathEscape.
+func (f *FS) Path(name string) (string, error) {
+	if name == "" {
+		return "", agentErrors.New(agentErrors.CodeInvalidInput, "path is empty").
+			WithComponent("vfs").
+			WithOperation("path")
+	}
+	if f.root == nil {
+		return filepath.Clean(name), nil
+	}
+
+	p := filepath.Clean(name)
+	if filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(f.dir, p); err == nil && filepath.IsLocal(rel) {
+			p = rel
+		} else {
+			// chroot-style: absolute paths are rooted at the jail
+			p = strings.TrimLeft(p[len(filepath.VolumeName(p)):], `/\`)
+			if p == "" {
+				p = "."
+			}
+		}
+	}
+	if p != "." && !filepath.IsLocal(p) {
+		return "", agentErrors.New(agentErrors.CodeFSPathEscape, "path escapes the jail").
+			WithComponent("vfs").
+			WithOperation("path").
+			WithContext("path", name).
+			WithContext("jail", f.dir)
+	}
+	return filepath.ToSlash(p), nil
+}
+
+// HostPath returns the host path of name, for handing to external
+// processes such as shell commands. Symbolic links on the path must resolve
+// inside the jail.
+func (f *FS) HostPath(name string) (string, error) {
+	p, err := f.Path(name)
+	if err != nil || f.root == nil {
+		return p, err
+	}
+	host := filepath.Join(f.dir, filepath.FromSlash(p))
+
+	// resolve the longest existing prefix
+	existing := host
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if rel, err := filepath.Rel(f.dir, resolved); err != nil || (rel != "." && !filepath.IsLocal(rel)) {
+				return "", agentErrors.New(agentErrors.CodeFSPathEscape, "path resolves outside the jail").
+					WithComponent("vfs").
+					WithOperation("host_path").
+					WithContext("path", name).
+					WithContext("jail", f.dir)
+			}
+			return host, nil
+		}
+		if existing == f.dir {
+			return host, nil
+		}
+		existing = filepath.Dir(existing)
+	}
+}
+
+// Join joins path elements in the path syntax of the file system
+func (f *FS) Join(elem ...string) string {
+	if f.root == nil {
+		return filepath.Join(elem...)
+	}
+	return path.Join(elem...)
+}
+
+// Open opens a file for reading
+func (f *FS) Open(name string) (*os.File, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.root == nil {
+		return os.Open(p)
+	}
+	return f.root.Open(p)
+}
+
+// ReadFile reads a file, enforcing MaxFileSize
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.cfg.MaxFileSize > 0 {
+		info, err := f.stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > f.cfg.MaxFileSize {
+			return nil, f.sizeError("read_file", name, info.Size())
+		}
+	}
+	return f.readFile(p)
+}
+
+// Stat returns file information, following symbolic links
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.stat(p)
+}
+
+// Lstat returns file information without following a final symbolic link
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.lstat(p)
+}
+
+// ReadDir lists a directory sorted by name
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.root == nil {
+		return os.ReadDir(p)
+	}
+	return fs.ReadDir(f.root.FS(), p)
+}
+
+// WalkDir walks the tree rooted at name like fs.WalkDir. Paths passed to fn
+// are in the syntax of Path.
+func (f *FS) WalkDir(name string, fn fs.WalkDirFunc) error {
+	p, err := f.Path(name)
+	if err != nil {
+		return err
+	}
+	if f.root == nil {
+		return filepath.WalkDir(p, fn)
+	}
+	return fs.WalkDir(f.root.FS(), p, fn)
+}
+
+// primitives operating on resolved paths
+
+func (f *FS) stat(p string) (fs.FileInfo, error) {
+	if f.root == nil {
+		return os.Stat(p)
+	}
+	return f.root.Stat(p)
+}
+
+func (f *FS) lstat(p string) (fs.FileInfo, error) {
+	if f.root == nil {
+		return os.Lstat(p)
+	}
+	return f.root.Lstat(p)
+}
+
+func (f *FS) readFile(p string) ([]byte, error) {
+	var data []byte
+	var err error
+	if f.root == nil {
+		data, err = os.ReadFile(p)
+	} else {
+		data, err = f.root.ReadFile(p)
+	}
+	if err == nil && data == nil {
+		data = []byte{}
+	}
+	return data, err
+}
+
+func (f *FS) writeFile(p string, data []byte, perm fs.FileMode) error {
+	if f.root == nil {
+		return os.WriteFile(p, data, perm)
+	}
+	return f.root.WriteFile(p, data, perm)
+}
+
+func (f *FS) openFile(p string, flag int, perm fs.FileMode) (*os.File, error) {
+	if f.root == nil {
+		return os.OpenFile(p, flag, perm)
+	}
+	return f.root.OpenFile(p, flag, perm)
+}
+
+func (f *FS) mkdirAll(p string, perm fs.FileMode) error {
+	if f.root == nil {
+		return os.MkdirAll(p, perm)
+	}
+	return f.root.MkdirAll(p, perm)
+}
+
+func (f *FS) remove(p string) error {
+	if f.root == nil {
+		return os.Remove(p)
+	}
+	return f.root.Remove(p)
+}
+
+func (f *FS) removeAll(p string) error {
+	if f.root == nil {
+		return os.RemoveAll(p)
+	}
+	return f.root.RemoveAll(p)
+}
+
+func (f *FS) rename(from, to string) error {
+	if f.root == nil {
+		return os.Rename(from, to)
+	}
+	return f.root.Rename(from, to)
+}
+
+func (f *FS) chmod(p string, mode fs.FileMode) error {
+	if f.root == nil {
+		return os.Chmod(p, mode)
+	}
+	return f.root.Chmod(p, mode)
+}
+
+func (f *FS) readlink(p string) (string, error) {
+	if f.root == nil {
+		return os.Readlink(p)
+	}
+	return f.root.Readlink(p)
+}
+
+func (f *FS) symlink(target, p string) error {
+	if f.root == nil {
+		return os.Symlink(target, p)
+	}
+	return f.root.Symlink(target, p)
+}
+
+// parent returns the parent of a resolved path
+func (f *FS) parent(p string) string {
+	if f.root == nil {
+		return filepath.Dir(p)
+	}
+	return path.Dir(p)
+}
+
+func (f *FS) sizeError(operation, name string, size int64) error {
+	return agentErrors.New(agentErrors.CodeFSLimitExceeded, "file exceeds the maximum size").
+		WithComponent("vfs").
+		WithOperation(operation).
+		WithContext("path", name).
+		WithContext("size", size).
+		WithContext("max_size", f.cfg.MaxFileSize)
+}
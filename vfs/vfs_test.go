@@ -0,0 +1,277 @@
+package vfs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+func newJail(t *testing.T, opts ...Option) (*FS, string) {
+	t.Helper()
+	dir := t.TempDir()
+	fsys, err := New(dir, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fsys.Close() })
+	return fsys, fsys.Dir()
+}
+
+func TestPath(t *testing.T) {
+	fsys, dir := newJail(t)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a.txt", "a.txt"},
+		{"./src/../a.txt", "a.txt"},
+		{"/src/main.go", "src/main.go"},
+		{filepath.Join(dir, "src", "main.go"), "src/main.go"},
+		{dir, "."},
+		{"/", "."},
+		{"/etc/passwd", "etc/passwd"},
+	}
+	for _, tt := range tests {
+		got, err := fsys.Path(tt.name)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+
+	for _, name := range []string{"../outside", "src/../../outside"} {
+		_, err := fsys.Path(name)
+		assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSPathEscape), name)
+	}
+}
+
+func TestSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	fsys, dir := newJail(t)
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(dir, "abs")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inside.txt"), []byte("inside"), 0o644))
+	require.NoError(t, os.Symlink("inside.txt", filepath.Join(dir, "ok")))
+
+	_, err := fsys.ReadFile("link/secret")
+	assert.Error(t, err)
+	_, err = fsys.ReadFile("abs")
+	assert.Error(t, err)
+	_, err = fsys.WriteFile("link/new", []byte("x"), 0o644)
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(outside, "new"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = fsys.HostPath("link/secret")
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSPathEscape))
+
+	data, err := fsys.ReadFile("ok")
+	require.NoError(t, err)
+	assert.Equal(t, "inside", string(data))
+	host, err := fsys.HostPath("sub/dir")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "sub", "dir"), host)
+}
+
+func TestLimits(t *testing.T) {
+	fsys, dir := newJail(t, WithMaxFileSize(10), WithQuota(16))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big"), []byte("0123456789abc"), 0o644))
+
+	_, err := fsys.ReadFile("big")
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSLimitExceeded))
+	_, err = fsys.WriteFile("a", []byte("0123456789abc"), 0o644)
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSLimitExceeded))
+
+	// 13 bytes used, 3 left
+	_, err = fsys.WriteFile("a", []byte("012"), 0o644)
+	require.NoError(t, err)
+	_, err = fsys.AppendFile("a", []byte("3"))
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSLimitExceeded))
+
+	// freeing space makes room again
+	_, err = fsys.Remove("big", false)
+	require.NoError(t, err)
+	_, err = fsys.AppendFile("a", []byte("3456"))
+	assert.NoError(t, err)
+
+	readOnly, _ := newJail(t, WithReadOnly(true))
+	_, err = readOnly.WriteFile("a", []byte("x"), 0o644)
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSReadOnly))
+	change, err := readOnly.WithDryRun(true).WriteFile("a", []byte("x"), 0o644)
+	require.NoError(t, err)
+	assert.True(t, change.DryRun)
+}
+
+func TestDryRun(t *testing.T) {
+	fsys, dir := newJail(t, WithDryRun(true))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	change, err := fsys.WriteFile("main.go", []byte("package main\n\nfunc main() {\n\tprintln(1)\n}\n"), 0o644)
+	require.NoError(t, err)
+	assert.True(t, change.DryRun)
+	assert.Contains(t, change.Diff, "--- a/main.go\n+++ b/main.go\n")
+	assert.Contains(t, change.Diff, "-func main() {}\n+func main() {\n+\tprintln(1)\n+}\n")
+
+	change, err = fsys.WriteFile("new.txt", []byte("hello"), 0o644)
+	require.NoError(t, err)
+	assert.Contains(t, change.Diff, "--- /dev/null\n+++ b/new.txt\n")
+	assert.Contains(t, change.Diff, "+hello\n")
+
+	change, err = fsys.Remove("main.go", false)
+	require.NoError(t, err)
+	assert.Contains(t, change.Diff, "+++ /dev/null")
+
+	// nothing was written
+	data, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", string(data))
+	_, err = os.Stat(filepath.Join(dir, "new.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJournal_Rollback(t *testing.T) {
+	journal := NewJournal()
+	fsys, dir := newJail(t, WithJournal(journal))
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	write("keep.txt", "keep\n")
+	write("edit.txt", "one\ntwo\n")
+	write("old/a.txt", "a\n")
+	write("old/b/c.txt", "c\n")
+
+	_, err := fsys.WriteFile("edit.txt", []byte("one\nthree\n"), 0o644)
+	require.NoError(t, err)
+	_, err = fsys.MkdirAll("new/pkg", 0o755)
+	require.NoError(t, err)
+	_, err = fsys.WriteFile("new/pkg/x.go", []byte("package pkg\n"), 0o644)
+	require.NoError(t, err)
+	_, err = fsys.Rename("old", "moved")
+	require.NoError(t, err)
+	_, err = fsys.AppendFile("moved/a.txt", []byte("more\n"))
+	require.NoError(t, err)
+	_, err = fsys.Remove("keep.txt", false)
+	require.NoError(t, err)
+	assert.Len(t, journal.Changes(), 6)
+
+	pending, err := journal.Pending()
+	require.NoError(t, err)
+	status := make(map[string]Status)
+	for _, change := range pending {
+		status[change.Path] = change.Status
+		assert.Equal(t, dir, change.Root)
+	}
+	assert.Equal(t, map[string]Status{
+		"edit.txt":      StatusModified,
+		"keep.txt":      StatusDeleted,
+		"new/pkg/x.go":  StatusAdded,
+		"old/a.txt":     StatusDeleted,
+		"old/b/c.txt":   StatusDeleted,
+		"moved/a.txt":   StatusAdded,
+		"moved/b/c.txt": StatusAdded,
+	}, status)
+	diff, err := journal.Diff()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-two\n+three\n")
+
+	require.NoError(t, journal.Rollback())
+	assert.Empty(t, journal.Changes())
+
+	for name, content := range map[string]string{"keep.txt": "keep\n", "edit.txt": "one\ntwo\n", "old/a.txt": "a\n", "old/b/c.txt": "c\n"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.Equal(t, content, string(data), name)
+	}
+	info, err := os.Stat(filepath.Join(dir, "edit.txt"))
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	for _, name := range []string{"new", "moved"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	journal := NewJournal()
+	fsys, dir := newJail(t, WithJournal(journal), WithQuota(64))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src.txt"), []byte("source content\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dst.txt"), []byte("old\n"), 0o644))
+
+	change, err := fsys.WithDryRun(true).CopyFile("src.txt", "dst.txt")
+	require.NoError(t, err)
+	assert.Contains(t, change.Diff, "-old\n+source content\n")
+
+	change, err = fsys.CopyFile("src.txt", "dst.txt")
+	require.NoError(t, err)
+	assert.Equal(t, OpCopy, change.Op)
+	assert.Equal(t, "src.txt", change.Path)
+	assert.Equal(t, "dst.txt", change.Dest)
+	data, err := os.ReadFile(filepath.Join(dir, "dst.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "source content\n", string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, "dst.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	// 30 of 64 bytes used: a second copy fits, a third does not
+	_, err = fsys.CopyFile("src.txt", "b.txt")
+	require.NoError(t, err)
+	_, err = fsys.CopyFile("src.txt", "c.txt")
+	require.NoError(t, err)
+	_, err = fsys.CopyFile("src.txt", "d.txt")
+	assert.True(t, agentErrors.IsCode(err, agentErrors.CodeFSLimitExceeded))
+
+	require.NoError(t, journal.Rollback())
+	data, err = os.ReadFile(filepath.Join(dir, "dst.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", string(data))
+}
+
+func TestJournal_Commit(t *testing.T) {
+	journal := NewJournal()
+	fsys, dir := newJail(t, WithJournal(journal))
+
+	_, err := fsys.WriteFile("a.txt", []byte("a"), 0o644)
+	require.NoError(t, err)
+	journal.Commit()
+	pending, err := journal.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// a rollback after the commit keeps committed changes
+	require.NoError(t, journal.Rollback())
+	data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+}
+
+func TestHost(t *testing.T) {
+	dir := t.TempDir()
+	journal := NewJournal()
+	fsys := Host(WithJournal(journal))
+	name := filepath.Join(dir, "file.txt")
+
+	assert.False(t, fsys.Jailed())
+	_, err := fsys.WriteFile(name, []byte("x\n"), 0o644)
+	require.NoError(t, err)
+	change, err := fsys.WithDryRun(true).WriteFile(name, []byte("y\n"), 0o644)
+	require.NoError(t, err)
+	assert.Contains(t, change.Diff, "-x\n+y\n")
+	assert.True(t, strings.HasPrefix(journal.Changes()[0].Path, dir))
+
+	require.NoError(t, journal.Rollback())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+}
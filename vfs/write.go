@@ -0,0 +1,481 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	agentErrors "github.com/kart-io/goagent/errors"
+)
+
+// WriteFile creates or truncates a file. The parent directory must exist.
+func (f *FS) WriteFile(name string, data []byte, perm fs.FileMode) (*Change, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.writable("write_file", name); err != nil {
+		return nil, err
+	}
+	if f.cfg.MaxFileSize > 0 && int64(len(data)) > f.cfg.MaxFileSize {
+		return nil, f.sizeError("write_file", name, int64(len(data)))
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	change := &Change{Op: OpWrite, Path: p, Size: int64(len(data)), DryRun: f.cfg.DryRun, Time: time.Now()}
+	if f.cfg.DryRun {
+		before, err := f.existing(p)
+		if err != nil {
+			return nil, err
+		}
+		change.Diff = UnifiedDiff(p, before, data)
+		return change, nil
+	}
+
+	// only the size of the old content is needed; the journal saves the
+	// content itself when it captures the file
+	beforeSize, err := f.existingSize(p)
+	if err != nil {
+		return nil, err
+	}
+	delta := int64(len(data)) - beforeSize
+	if err := f.reserve("write_file", name, delta); err != nil {
+		return nil, err
+	}
+	if err := f.capture(p, false); err != nil {
+		return nil, err
+	}
+	if err := f.writeFile(p, data, perm); err != nil {
+		return nil, err
+	}
+	f.applied(change, delta)
+	return change, nil
+}
+
+// AppendFile appends to a file, creating it if needed
+func (f *FS) AppendFile(name string, data []byte) (*Change, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.writable("append_file", name); err != nil {
+		return nil, err
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	beforeSize, err := f.existingSize(p)
+	if err != nil {
+		return nil, err
+	}
+	size := beforeSize + int64(len(data))
+	if f.cfg.MaxFileSize > 0 && size > f.cfg.MaxFileSize {
+		return nil, f.sizeError("append_file", name, size)
+	}
+	change := &Change{Op: OpAppend, Path: p, Size: int64(len(data)), DryRun: f.cfg.DryRun, Time: time.Now()}
+	if f.cfg.DryRun {
+		before, err := f.existing(p)
+		if err != nil {
+			return nil, err
+		}
+		after := append(append([]byte{}, before...), data...)
+		change.Diff = UnifiedDiff(p, before, after)
+		return change, nil
+	}
+
+	if err := f.reserve("append_file", name, int64(len(data))); err != nil {
+		return nil, err
+	}
+	if err := f.capture(p, false); err != nil {
+		return nil, err
+	}
+	file, err := f.openFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.applied(change, int64(len(data)))
+	return change, nil
+}
+
+// MkdirAll creates a directory and any missing parents
+func (f *FS) MkdirAll(name string, perm fs.FileMode) (*Change, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.writable("mkdir", name); err != nil {
+		return nil, err
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	change := &Change{Op: OpMkdir, Path: p, DryRun: f.cfg.DryRun, Time: time.Now()}
+	if f.cfg.DryRun {
+		return change, nil
+	}
+	// record the directories that are about to be created
+	for dir := p; dir != "." && dir != "/" && dir != f.parent(dir); dir = f.parent(dir) {
+		if _, err := f.lstat(dir); err == nil {
+			break
+		}
+		if err := f.capture(dir, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := f.mkdirAll(p, perm); err != nil {
+		return nil, err
+	}
+	f.applied(change, 0)
+	return change, nil
+}
+
+// Remove deletes a file or an empty directory, or with recursive a whole
+// tree
+func (f *FS) Remove(name string, recursive bool) (*Change, error) {
+	p, err := f.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if p == "." {
+		return nil, agentErrors.New(agentErrors.CodeFSPathEscape, "cannot remove the jail directory").
+			WithComponent("vfs").
+			WithOperation("remove").
+			WithContext("path", name)
+	}
+	if err := f.writable("remove", name); err != nil {
+		return nil, err
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	if _, err := f.lstat(p); err != nil {
+		return nil, err
+	}
+	change := &Change{Op: OpRemove, Path: p, DryRun: f.cfg.DryRun, Time: time.Now()}
+	if f.cfg.DryRun {
+		diff, err := f.treeDiff(p, true)
+		if err != nil {
+			return nil, err
+		}
+		change.Diff = diff
+		return change, nil
+	}
+
+	freed, err := f.treeSize(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.capture(p, recursive); err != nil {
+		return nil, err
+	}
+	if recursive {
+		err = f.removeAll(p)
+	} else {
+		err = f.remove(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.applied(change, -freed)
+	return change, nil
+}
+
+// Rename moves a file or directory, replacing an existing file at newname
+func (f *FS) Rename(oldname, newname string) (*Change, error) {
+	from, err := f.Path(oldname)
+	if err != nil {
+		return nil, err
+	}
+	to, err := f.Path(newname)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.writable("rename", oldname); err != nil {
+		return nil, err
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	if _, err := f.lstat(from); err != nil {
+		return nil, err
+	}
+	change := &Change{Op: OpRename, Path: from, Dest: to, DryRun: f.cfg.DryRun, Time: time.Now()}
+	if f.cfg.DryRun {
+		change.Diff = renameDiff(from, to)
+		return change, nil
+	}
+
+	replaced, err := f.treeSize(to)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.capture(from, true); err != nil {
+		return nil, err
+	}
+	if err := f.capture(to, true); err != nil {
+		return nil, err
+	}
+	if err := f.rename(from, to); err != nil {
+		return nil, err
+	}
+	f.applied(change, -replaced)
+	return change, nil
+}
+
+// CopyFile copies a regular file, keeping its permissions. The content is
+// streamed between the files; it is only held in memory for a dry-run diff.
+func (f *FS) CopyFile(src, dst string) (*Change, error) {
+	from, err := f.Path(src)
+	if err != nil {
+		return nil, err
+	}
+	to, err := f.Path(dst)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.stat(from)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, agentErrors.New(agentErrors.CodeInvalidInput, "only regular files can be copied").
+			WithComponent("vfs").
+			WithOperation("copy_file").
+			WithContext("path", src)
+	}
+	if f.cfg.MaxFileSize > 0 && info.Size() > f.cfg.MaxFileSize {
+		return nil, f.sizeError("copy_file", src, info.Size())
+	}
+	if err := f.writable("copy_file", dst); err != nil {
+		return nil, err
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	change := &Change{Op: OpCopy, Path: from, Dest: to, Size: info.Size(), DryRun: f.cfg.DryRun, Time: time.Now()}
+	if f.cfg.DryRun {
+		before, err := f.existing(to)
+		if err != nil {
+			return nil, err
+		}
+		data, err := f.readFile(from)
+		if err != nil {
+			return nil, err
+		}
+		change.Diff = UnifiedDiff(to, before, data)
+		return change, nil
+	}
+
+	beforeSize, err := f.existingSize(to)
+	if err != nil {
+		return nil, err
+	}
+	delta := info.Size() - beforeSize
+	if err := f.reserve("copy_file", dst, delta); err != nil {
+		return nil, err
+	}
+	if err := f.capture(to, false); err != nil {
+		return nil, err
+	}
+	if err := f.copyFile(from, to, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	f.applied(change, delta)
+	return change, nil
+}
+
+// copyFile streams the content of from into to and applies perm, which an
+// existing destination would otherwise keep
+func (f *FS) copyFile(from, to string, perm fs.FileMode) error {
+	in, err := f.openFile(from, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := f.openFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return f.chmod(to, perm)
+}
+
+// writable rejects mutations of read-only file systems
+func (f *FS) writable(operation, name string) error {
+	if f.cfg.ReadOnly && !f.cfg.DryRun {
+		return agentErrors.New(agentErrors.CodeFSReadOnly, "file system is read-only").
+			WithComponent("vfs").
+			WithOperation(operation).
+			WithContext("path", name)
+	}
+	return nil
+}
+
+// existing returns the content of a regular file, or nil if it does not exist
+func (f *FS) existing(p string) ([]byte, error) {
+	info, err := f.stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "write", Path: p, Err: errors.New("is a directory")}
+	}
+	return f.readFile(p)
+}
+
+// existingSize returns the size of a regular file, or 0 if it does not exist
+func (f *FS) existingSize(p string) (int64, error) {
+	info, err := f.stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		return 0, &fs.PathError{Op: "write", Path: p, Err: errors.New("is a directory")}
+	}
+	return info.Size(), nil
+}
+
+// reserve checks that growing the jail by delta bytes stays within the
+// quota. The caller holds the state lock.
+func (f *FS) reserve(operation, name string, delta int64) error {
+	if f.root == nil || f.cfg.Quota <= 0 || delta <= 0 {
+		return nil
+	}
+	if f.state.stale.Load() {
+		usage, err := f.treeSize(".")
+		if err != nil {
+			return err
+		}
+		f.state.usage = usage
+		f.state.stale.Store(false)
+	}
+	if f.state.usage+delta > f.cfg.Quota {
+		return agentErrors.New(agentErrors.CodeFSLimitExceeded, "disk quota exceeded").
+			WithComponent("vfs").
+			WithOperation(operation).
+			WithContext("path", name).
+			WithContext("usage", f.state.usage).
+			WithContext("quota", f.cfg.Quota)
+	}
+	return nil
+}
+
+// applied accounts for and journals a completed mutation. The caller holds
+// the state lock.
+func (f *FS) applied(change *Change, delta int64) {
+	f.state.usage += delta
+	if f.cfg.Journal != nil {
+		f.cfg.Journal.record(*change)
+	}
+}
+
+// capture saves the original state of p (and with tree, everything below
+// it) in the journal
+func (f *FS) capture(p string, tree bool) error {
+	if f.cfg.Journal == nil {
+		return nil
+	}
+	return f.cfg.Journal.capture(f, p, tree)
+}
+
+// treeSize sums the sizes of the regular files at or below p
+func (f *FS) treeSize(p string) (int64, error) {
+	var size int64
+	err := f.walk(p, func(_ string, info fs.FileInfo) error {
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// treeDiff returns the diffs deleting (or with deleted false, creating)
+// every file at or below p
+func (f *FS) treeDiff(p string, deleted bool) (string, error) {
+	var diff strings.Builder
+	err := f.walk(p, func(q string, info fs.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		data, err := f.fileContent(q, info)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			diff.WriteString(UnifiedDiff(q, data, nil))
+		} else {
+			diff.WriteString(UnifiedDiff(q, nil, data))
+		}
+		return nil
+	})
+	return diff.String(), err
+}
+
+// fileContent returns the content of a regular file or the target of a
+// symbolic link
+func (f *FS) fileContent(p string, info fs.FileInfo) ([]byte, error) {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := f.readlink(p)
+		return []byte(target), err
+	}
+	return f.readFile(p)
+}
+
+// walk visits p and everything below it without following symbolic links.
+// A missing p is not an error.
+func (f *FS) walk(p string, fn func(p string, info fs.FileInfo) error) error {
+	info, err := f.lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := fn(p, info); err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	entries, err := f.ReadDir(p)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := f.walk(f.Join(p, entry.Name()), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}